@@ -0,0 +1,452 @@
+package b2bua
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/emiago/sipgo"
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Leg is side of call
+type Leg int
+
+const (
+	// LegInbound is dialog with caller, where B2BUA is UAS
+	LegInbound Leg = iota
+	// LegOutbound is dialog with callee, where B2BUA is UAC
+	LegOutbound
+)
+
+func (l Leg) String() string {
+	if l == LegInbound {
+		return "inbound"
+	}
+	return "outbound"
+}
+
+// RouteFunc returns target of outbound leg for incoming INVITE.
+// Returning error rejects call with 404 Not Found
+type RouteFunc func(req *sip.Request) (*sip.Uri, error)
+
+// HeaderFilter reports should header be copied from message received on one leg to message sent on other leg.
+// Dialog and transaction headers are never copied
+type HeaderFilter func(from Leg, h sip.Header) bool
+
+// MediaFunc returns body relayed to other leg for body received on leg, ex. for rewriting SDP to media relay.
+// Message is request or response carrying body
+type MediaFunc func(call *Call, from Leg, msg sip.Message) []byte
+
+// Call is pair of inbound and outbound dialog
+type Call struct {
+	Inbound  *sipgo.DialogServerSession
+	Outbound *sipgo.DialogClientSession
+
+	// lateOffer is INVITE without offer, where answer from caller ACK is relayed to callee ACK
+	lateOffer bool
+	endOnce   sync.Once
+}
+
+type B2BUAOption func(b *B2BUA)
+
+// WithHeaderFilter sets filter of headers relayed between legs. By default all non dialog headers are relayed
+func WithHeaderFilter(f HeaderFilter) B2BUAOption {
+	return func(b *B2BUA) {
+		b.filter = f
+	}
+}
+
+// WithMediaFunc sets handler of relayed bodies. By default body is relayed unchanged
+func WithMediaFunc(f MediaFunc) B2BUAOption {
+	return func(b *B2BUA) {
+		b.media = f
+	}
+}
+
+// WithB2BUALogger allows customizing logger
+func WithB2BUALogger(logger zerolog.Logger) B2BUAOption {
+	return func(b *B2BUA) {
+		b.log = logger
+	}
+}
+
+// B2BUA is back to back user agent. Every incoming INVITE creates inbound dialog and outbound dialog
+// to target returned by RouteFunc. Responses, re-INVITE, UPDATE, CANCEL and BYE are relayed between legs,
+// so application only decides routing and media.
+// Ex:
+//
+//	b := b2bua.NewB2BUA(client, contact, func(req *sip.Request) (*sip.Uri, error) {
+//		return &sip.Uri{User: req.Recipient.User, Host: "pbx.example.com"}, nil
+//	})
+//	b.Register(srv)
+type B2BUA struct {
+	dc      *sipgo.DialogClient
+	ds      *sipgo.DialogServer
+	contact sip.ContactHeader
+
+	route  RouteFunc
+	filter HeaderFilter
+	media  MediaFunc
+	log    zerolog.Logger
+
+	mu sync.Mutex
+	// calls are established calls by Call-ID of both legs
+	calls map[string]*Call
+}
+
+// NewB2BUA creates B2BUA. Contact is used for dialogs on both legs
+func NewB2BUA(client *sipgo.Client, contact sip.ContactHeader, route RouteFunc, options ...B2BUAOption) *B2BUA {
+	b := &B2BUA{
+		dc:      sipgo.NewDialogClient(client, contact),
+		ds:      sipgo.NewDialogServer(client, contact),
+		contact: contact,
+		route:   route,
+		log:     log.Logger.With().Str("caller", "B2BUA").Logger(),
+		calls:   make(map[string]*Call),
+	}
+	for _, o := range options {
+		o(b)
+	}
+	return b
+}
+
+// Register registers B2BUA handlers for INVITE, ACK, BYE and UPDATE on server
+func (b *B2BUA) Register(srv *sipgo.Server) {
+	srv.OnInvite(b.HandleInvite)
+	srv.OnAck(b.handleAck)
+	srv.OnBye(b.handleBye)
+	srv.OnUpdate(b.handleModify)
+}
+
+// Calls returns number of established calls
+func (b *B2BUA) Calls() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.calls) / 2
+}
+
+// HandleInvite is OnInvite handler. New INVITE is routed to outbound leg and blocks until call is answered or fails.
+// CANCEL received on inbound leg cancels outbound INVITE. re-INVITE is relayed to other leg
+func (b *B2BUA) HandleInvite(req *sip.Request, tx sip.ServerTransaction) {
+	if _, inDialog := req.To().Params.Get("tag"); inDialog {
+		b.handleModify(req, tx)
+		return
+	}
+
+	if mf := req.MaxForwards(); mf != nil && mf.Val() == 0 {
+		b.respond(tx, sip.NewResponseFromRequest(req, sip.StatusTooManyHops, "Too Many Hops", nil))
+		return
+	}
+
+	in, err := b.ds.ReadInvite(req, tx)
+	if err != nil {
+		if errors.Is(err, sipgo.ErrDialogInviteNoContact) {
+			b.respond(tx, sip.NewResponseFromRequest(req, sip.StatusBadRequest, "Bad Request", nil))
+		}
+		b.log.Info().Err(err).Msg("Failed to read INVITE")
+		return
+	}
+	call := &Call{Inbound: in, lateOffer: len(req.Body()) == 0}
+
+	target, err := b.route(req)
+	if err != nil {
+		b.log.Info().Err(err).Str("recipient", req.Recipient.Addr()).Msg("Call not routed")
+		b.inboundRespond(call, sip.StatusNotFound, "Not Found")
+		in.Close()
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case creq := <-tx.Cancels():
+			b.respond(tx, sip.NewResponseFromRequest(creq, sip.StatusOK, "OK", nil))
+			cancel()
+		case <-tx.Done():
+		case <-ctx.Done():
+		}
+	}()
+
+	out, err := b.dc.WriteInvite(ctx, b.newInvite(call, req, target))
+	if err != nil {
+		b.log.Info().Err(err).Str("target", target.String()).Msg("Failed to send INVITE")
+		b.inboundRespond(call, sip.StatusServiceUnavailable, "Service Unavailable")
+		in.Close()
+		return
+	}
+	call.Outbound = out
+
+	err = out.WaitAnswer(ctx, sipgo.AnswerOptions{
+		// Answer on offer from callee 2xx is in caller ACK
+		DeferAck: call.lateOffer,
+		OnResponse: func(res *sip.Response) {
+			if !res.IsProvisional() || res.StatusCode == sip.StatusTrying {
+				return
+			}
+			if err := in.WriteResponse(b.relayResponse(call, LegOutbound, req, res)); errors.Is(err, sipgo.ErrDialogCanceled) {
+				cancel()
+			}
+		},
+	})
+	if err != nil {
+		var derr *sipgo.ErrDialogResponse
+		switch {
+		case errors.As(err, &derr):
+			in.WriteResponse(b.relayResponse(call, LegOutbound, req, derr.Res))
+		case ctx.Err() != nil:
+			b.inboundRespond(call, sip.StatusRequestTerminated, "Request Terminated")
+		default:
+			b.log.Info().Err(err).Str("target", target.String()).Msg("Outbound leg failed")
+			b.inboundRespond(call, sip.StatusServiceUnavailable, "Service Unavailable")
+		}
+		in.Close()
+		out.Close()
+		return
+	}
+
+	if err := in.WriteResponse(b.relayResponse(call, LegOutbound, req, out.InviteResponse)); err != nil {
+		b.log.Info().Err(err).Msg("Failed to answer inbound leg")
+		in.Close()
+		byeCtx, byeCancel := context.WithTimeout(context.Background(), sip.GetTimers().Timer_B)
+		defer byeCancel()
+		out.Bye(byeCtx)
+		return
+	}
+
+	b.mu.Lock()
+	b.calls[req.CallID().Value()] = call
+	b.calls[out.InviteRequest.CallID().Value()] = call
+	b.mu.Unlock()
+
+	go b.watch(call)
+}
+
+// newInvite creates outbound INVITE from inbound one. Caller identity is kept, while dialog is new
+func (b *B2BUA) newInvite(call *Call, req *sip.Request, target *sip.Uri) *sip.Request {
+	invite := sip.NewRequest(sip.INVITE, target.Clone())
+
+	from := req.From()
+	fromHDR := &sip.FromHeader{DisplayName: from.DisplayName, Address: *from.Address.Clone(), Params: sip.NewParams()}
+	fromHDR.Params.Add("tag", sip.GenerateTagN(16))
+	invite.AppendHeader(fromHDR)
+	invite.AppendHeader(&sip.ToHeader{Address: *target.Clone(), Params: sip.NewParams()})
+	if mf := req.MaxForwards(); mf != nil {
+		maxfwd := sip.MaxForwardsHeader(mf.Val() - 1)
+		invite.AppendHeader(&maxfwd)
+	}
+
+	b.copyHeaders(LegInbound, invite, req)
+	b.copyBody(call, LegInbound, invite, req)
+	return invite
+}
+
+// relayResponse creates response on request of one leg from response received on other leg
+func (b *B2BUA) relayResponse(call *Call, from Leg, req *sip.Request, res *sip.Response) *sip.Response {
+	relay := sip.NewResponseFromRequest(req, res.StatusCode, res.Reason, nil)
+	b.copyHeaders(from, relay, res)
+	b.copyBody(call, from, relay, res)
+	return relay
+}
+
+func (b *B2BUA) inboundRespond(call *Call, statusCode sip.StatusCode, reason string) {
+	if err := call.Inbound.Respond(statusCode, reason, nil); err != nil {
+		b.log.Info().Err(err).Msg("Failed to respond on inbound leg")
+	}
+}
+
+// relayedHeader reports can header be relayed. Dialog, transaction and per hop extension headers are not relayed
+func relayedHeader(name string) bool {
+	switch strings.ToLower(name) {
+	case "via", "from", "to", "call-id", "cseq", "contact", "route", "record-route", "max-forwards",
+		"content-length", "content-type", "require", "supported", "rseq", "rack":
+		return false
+	}
+	return true
+}
+
+// message is request or response
+type message interface {
+	sip.Message
+	Headers() []sip.Header
+	ContentType() *sip.ContentTypeHeader
+}
+
+func (b *B2BUA) copyHeaders(from Leg, dst sip.Message, src message) {
+	for _, h := range src.Headers() {
+		if !relayedHeader(h.Name()) {
+			continue
+		}
+		if b.filter != nil && !b.filter(from, h) {
+			continue
+		}
+		dst.AppendHeader(sip.HeaderClone(h))
+	}
+}
+
+func (b *B2BUA) copyBody(call *Call, from Leg, dst sip.Message, src message) {
+	body := src.Body()
+	if b.media != nil {
+		body = b.media(call, from, src)
+	}
+	if len(body) == 0 {
+		return
+	}
+
+	contentType := "application/sdp"
+	if h := src.ContentType(); h != nil {
+		contentType = h.Value()
+	}
+	dst.AppendHeader(sip.NewHeader("Content-Type", contentType))
+	dst.SetBody(body)
+}
+
+// findCall returns established call and leg of in dialog request by its Call-ID
+func (b *B2BUA) findCall(req *sip.Request) (*Call, Leg) {
+	callID := req.CallID()
+	if callID == nil {
+		return nil, LegInbound
+	}
+
+	b.mu.Lock()
+	call := b.calls[callID.Value()]
+	b.mu.Unlock()
+	if call == nil {
+		return nil, LegInbound
+	}
+	if call.Inbound.InviteRequest.CallID().Value() == callID.Value() {
+		return call, LegInbound
+	}
+	return call, LegOutbound
+}
+
+func (b *B2BUA) handleAck(req *sip.Request, tx sip.ServerTransaction) {
+	call, leg := b.findCall(req)
+	if call == nil || leg == LegOutbound {
+		// ACK on relayed re-INVITE from callee is absorbed
+		return
+	}
+	if err := b.ds.ReadAck(req, tx); err != nil && !errors.Is(err, sipgo.ErrDialogDoesNotExists) {
+		b.log.Info().Err(err).Msg("Failed to read ACK")
+	}
+
+	if !call.lateOffer || req.CSeq().SeqNo != call.Inbound.InviteRequest.CSeq().SeqNo {
+		return
+	}
+	out := call.Outbound
+	ack := sip.NewAckRequest(out.InviteRequest, out.InviteResponse, nil)
+	b.copyBody(call, LegInbound, ack, req)
+	if err := out.WriteAck(context.Background(), ack); err != nil {
+		b.log.Info().Err(err).Msg("Failed to relay ACK")
+	}
+}
+
+func (b *B2BUA) handleBye(req *sip.Request, tx sip.ServerTransaction) {
+	call, leg := b.findCall(req)
+	if call == nil {
+		b.respond(tx, sip.NewResponseFromRequest(req, sip.StatusCallTransactionDoesNotExists, "Call/Transaction Does Not Exist", nil))
+		return
+	}
+
+	var err error
+	if leg == LegInbound {
+		err = b.ds.ReadBye(req, tx)
+	} else {
+		err = b.dc.ReadBye(req, tx)
+	}
+	if err != nil {
+		b.log.Info().Err(err).Str("leg", leg.String()).Msg("Failed to read BYE")
+	}
+	b.end(call)
+}
+
+// handleModify relays re-INVITE or UPDATE received on one leg to other leg and responds with its final response
+func (b *B2BUA) handleModify(req *sip.Request, tx sip.ServerTransaction) {
+	call, leg := b.findCall(req)
+	if call == nil {
+		b.respond(tx, sip.NewResponseFromRequest(req, sip.StatusCallTransactionDoesNotExists, "Call/Transaction Does Not Exist", nil))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-tx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var body []byte
+	if b.media != nil {
+		body = b.media(call, leg, req)
+	} else if len(req.Body()) > 0 {
+		body = req.Body()
+	}
+
+	var res *sip.Response
+	var err error
+	switch {
+	case leg == LegInbound && req.IsInvite():
+		res, err = call.Outbound.ReInvite(ctx, body)
+	case leg == LegInbound:
+		res, err = call.Outbound.Update(ctx, body)
+	case req.IsInvite():
+		res, err = call.Inbound.ReInvite(ctx, body)
+	default:
+		res, err = call.Inbound.Update(ctx, body)
+	}
+	if res == nil {
+		b.log.Info().Err(err).Str("leg", leg.String()).Str("method", req.Method.String()).Msg("Failed to relay request")
+		b.respond(tx, sip.NewResponseFromRequest(req, sip.StatusServiceUnavailable, "Service Unavailable", nil))
+		return
+	}
+
+	relay := b.relayResponse(call, 1-leg, req, res)
+	if res.IsSuccess() {
+		relay.AppendHeader(sip.HeaderClone(&b.contact))
+	}
+	b.respond(tx, relay)
+}
+
+// watch ends call when any leg ends without BYE passing through B2BUA
+func (b *B2BUA) watch(call *Call) {
+	select {
+	case <-call.Inbound.Done():
+	case <-call.Outbound.Done():
+	}
+	b.end(call)
+}
+
+// end terminates other leg and removes call
+func (b *B2BUA) end(call *Call) {
+	call.endOnce.Do(func() {
+		b.mu.Lock()
+		delete(b.calls, call.Inbound.InviteRequest.CallID().Value())
+		delete(b.calls, call.Outbound.InviteRequest.CallID().Value())
+		b.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), sip.GetTimers().Timer_B)
+		defer cancel()
+		if err := call.Inbound.Bye(ctx); err != nil {
+			b.log.Info().Err(err).Msg("Failed to end inbound leg")
+		}
+		if err := call.Outbound.Bye(ctx); err != nil {
+			b.log.Info().Err(err).Msg("Failed to end outbound leg")
+		}
+		call.Inbound.Close()
+		call.Outbound.Close()
+	})
+}
+
+func (b *B2BUA) respond(tx sip.ServerTransaction, res *sip.Response) {
+	if err := tx.Respond(res); err != nil {
+		b.log.Error().Err(err).Str("res", res.StartLine()).Msg("Failed to respond")
+	}
+}
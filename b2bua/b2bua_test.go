@@ -0,0 +1,150 @@
+package b2bua
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo"
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testUA(t *testing.T) (*sipgo.UserAgent, *sipgo.Server, *sipgo.Client) {
+	ua, err := sipgo.NewUA(sipgo.WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	t.Cleanup(func() { ua.Close() })
+	srv, err := sipgo.NewServer(ua)
+	require.NoError(t, err)
+	cli, err := sipgo.NewClient(ua, sipgo.WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+	return ua, srv, cli
+}
+
+// testCallee answers INVITE with status code and reports BYE
+func testCallee(t *testing.T, statusCode sip.StatusCode) (int, <-chan *sip.Request, <-chan *sip.Request) {
+	_, srv, cli := testUA(t)
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	ds := sipgo.NewDialogServer(cli, sip.ContactHeader{Address: sip.Uri{User: "bob", Host: "127.0.0.1", Port: port}})
+
+	invites := make(chan *sip.Request, 1)
+	byes := make(chan *sip.Request, 1)
+	srv.OnInvite(func(req *sip.Request, tx sip.ServerTransaction) {
+		invites <- req
+		dlg, err := ds.ReadInvite(req, tx)
+		require.NoError(t, err)
+		require.NoError(t, dlg.Respond(sip.StatusRinging, "Ringing", nil))
+		time.Sleep(20 * time.Millisecond)
+		if statusCode != sip.StatusOK {
+			dlg.Respond(statusCode, sip.StatusText(statusCode), nil)
+			dlg.Close()
+			return
+		}
+		dlg.Respond(sip.StatusOK, "OK", []byte("callee-sdp"), sip.NewHeader("Content-Type", "application/sdp"), sip.NewHeader("X-Callee", "1"))
+	})
+	srv.OnAck(func(req *sip.Request, tx sip.ServerTransaction) {
+		ds.ReadAck(req, tx)
+	})
+	srv.OnBye(func(req *sip.Request, tx sip.ServerTransaction) {
+		byes <- req
+		ds.ReadBye(req, tx)
+	})
+	go srv.ServeUDP(conn)
+	return port, invites, byes
+}
+
+func testB2BUA(t *testing.T, calleePort int) (*B2BUA, int) {
+	_, srv, cli := testUA(t)
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+
+	b := NewB2BUA(cli, sip.ContactHeader{Address: sip.Uri{Host: "127.0.0.1", Port: port}}, func(req *sip.Request) (*sip.Uri, error) {
+		if req.Recipient.User != "bob" {
+			return nil, errors.New("unknown user")
+		}
+		return &sip.Uri{User: "bob", Host: "127.0.0.1", Port: calleePort}, nil
+	}, WithHeaderFilter(func(from Leg, h sip.Header) bool {
+		return h.Name() != "X-Secret"
+	}))
+	b.Register(srv)
+	go srv.ServeUDP(conn)
+	return b, port
+}
+
+func TestB2BUACall(t *testing.T) {
+	calleePort, invites, byes := testCallee(t, sip.StatusOK)
+	b, port := testB2BUA(t, calleePort)
+
+	_, _, cli := testUA(t)
+	dc := sipgo.NewDialogClient(cli, sip.ContactHeader{Address: sip.Uri{User: "alice", Host: "127.0.0.1", Port: 5060}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	sess, err := dc.Invite(ctx, &sip.Uri{User: "bob", Host: "127.0.0.1", Port: port}, []byte("caller-sdp"),
+		sip.NewHeader("Content-Type", "application/sdp"),
+		sip.NewHeader("X-Caller", "1"),
+		sip.NewHeader("X-Secret", "1"),
+	)
+	require.NoError(t, err)
+	defer sess.Close()
+
+	var ringing bool
+	err = sess.WaitAnswer(ctx, sipgo.AnswerOptions{
+		OnResponse: func(res *sip.Response) {
+			ringing = ringing || res.StatusCode == sip.StatusRinging
+		},
+	})
+	require.NoError(t, err)
+	assert.True(t, ringing)
+	assert.Equal(t, "callee-sdp", string(sess.InviteResponse.Body()))
+	assert.NotNil(t, sess.InviteResponse.GetHeader("X-Callee"))
+
+	invite := <-invites
+	assert.Equal(t, "caller-sdp", string(invite.Body()))
+	assert.NotNil(t, invite.GetHeader("X-Caller"))
+	assert.Nil(t, invite.GetHeader("X-Secret"))
+	assert.NotEqual(t, sess.InviteRequest.CallID().Value(), invite.CallID().Value())
+	// Caller identity is kept
+	assert.Equal(t, sess.InviteRequest.From().Address.User, invite.From().Address.User)
+	require.Eventually(t, func() bool { return b.Calls() == 1 }, time.Second, time.Millisecond)
+
+	require.NoError(t, sess.Bye(ctx))
+	select {
+	case bye := <-byes:
+		assert.Equal(t, invite.CallID().Value(), bye.CallID().Value())
+	case <-ctx.Done():
+		t.Fatal("BYE not relayed")
+	}
+	require.Eventually(t, func() bool { return b.Calls() == 0 }, time.Second, time.Millisecond)
+}
+
+func TestB2BUAReject(t *testing.T) {
+	calleePort, _, _ := testCallee(t, sip.StatusBusyHere)
+	_, port := testB2BUA(t, calleePort)
+
+	_, _, cli := testUA(t)
+	dc := sipgo.NewDialogClient(cli, sip.ContactHeader{Address: sip.Uri{User: "alice", Host: "127.0.0.1", Port: 5060}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	call := func(user string) sip.StatusCode {
+		sess, err := dc.Invite(ctx, &sip.Uri{User: user, Host: "127.0.0.1", Port: port}, nil)
+		require.NoError(t, err)
+		defer sess.Close()
+		err = sess.WaitAnswer(ctx, sipgo.AnswerOptions{})
+		var derr *sipgo.ErrDialogResponse
+		require.ErrorAs(t, err, &derr)
+		return derr.Res.StatusCode
+	}
+
+	assert.Equal(t, sip.StatusBusyHere, call("bob"))
+	assert.Equal(t, sip.StatusNotFound, call("carol"))
+}
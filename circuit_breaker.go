@@ -0,0 +1,244 @@
+package sipgo
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrCircuitOpen is returned by client for requests to destination with open circuit.
+	// Handlers created with HandlerErr answer it with 503 Service Unavailable
+	ErrCircuitOpen = errors.New("circuit open")
+)
+
+// CircuitState is state of destination circuit
+type CircuitState int
+
+const (
+	// CircuitClosed passes all requests
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails all requests fast
+	CircuitOpen
+	// CircuitHalfOpen passes single probe request deciding is circuit closed or opened again
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreaker stops sending requests to misbehaving destination, ex. dead carrier.
+// After threshold consecutive failures (timeouts, transport errors or 503) circuit opens and requests
+// fail fast locally. After open duration single probe request is passed, and circuit closes on its success
+// Ex:
+//
+//	cb := sipgo.NewCircuitBreaker(5, 30*time.Second)
+//	client, _ := sipgo.NewClient(ua, sipgo.WithClientCircuitBreaker(cb))
+//	expvar.Publish("circuits", cb.Expvar())
+type CircuitBreaker struct {
+	threshold int
+	open      time.Duration
+	log       zerolog.Logger
+
+	// OnStateChange is called when destination circuit changes state
+	OnStateChange func(dest string, from CircuitState, to CircuitState)
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+	rejected uint64
+}
+
+type circuit struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	// probeAt is start of half-open probe
+	probeAt  time.Time
+	rejected uint64
+}
+
+// CircuitStats is state of destination circuit
+type CircuitStats struct {
+	Destination string
+	State       CircuitState
+	// Failures is number of consecutive failures
+	Failures int
+	// Rejected is number of requests failed fast
+	Rejected uint64
+	OpenedAt time.Time
+}
+
+// NewCircuitBreaker creates circuit breaker opening after threshold consecutive failures
+// and probing destination after open duration
+func NewCircuitBreaker(threshold int, open time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{
+		threshold: threshold,
+		open:      open,
+		log:       log.Logger.With().Str("caller", "CircuitBreaker").Logger(),
+		circuits:  make(map[string]*circuit),
+	}
+}
+
+// WithClientCircuitBreaker fails transaction requests fast with ErrCircuitOpen when destination
+// circuit is open. Circuits are keyed by request destination host:port before resolving
+func WithClientCircuitBreaker(cb *CircuitBreaker) ClientOption {
+	return func(c *Client) error {
+		c.breaker = cb
+		return nil
+	}
+}
+
+// Allow checks can request be sent to destination. It returns ErrCircuitOpen when circuit is open
+// or probe request is already in progress
+func (cb *CircuitBreaker) Allow(dest string) error {
+	now := time.Now()
+	cb.mu.Lock()
+	c, exists := cb.circuits[dest]
+	if !exists || c.state == CircuitClosed {
+		cb.mu.Unlock()
+		return nil
+	}
+
+	// Probe without outcome, ex. terminated by caller, is repeated after open duration
+	if (c.state == CircuitOpen && now.Sub(c.openedAt) >= cb.open) ||
+		(c.state == CircuitHalfOpen && now.Sub(c.probeAt) >= cb.open) {
+		from := c.state
+		c.state = CircuitHalfOpen
+		c.probeAt = now
+		cb.mu.Unlock()
+		cb.changed(dest, from, CircuitHalfOpen)
+		return nil
+	}
+
+	c.rejected++
+	cb.rejected++
+	cb.mu.Unlock()
+	return fmt.Errorf("%w: %s", ErrCircuitOpen, dest)
+}
+
+// Success records successful request to destination and closes its circuit
+func (cb *CircuitBreaker) Success(dest string) {
+	cb.mu.Lock()
+	c, exists := cb.circuits[dest]
+	if !exists {
+		cb.mu.Unlock()
+		return
+	}
+	delete(cb.circuits, dest)
+	cb.mu.Unlock()
+
+	if c.state != CircuitClosed {
+		cb.changed(dest, c.state, CircuitClosed)
+	}
+}
+
+// Failure records failed request to destination. Circuit opens after threshold consecutive failures
+// or on failed probe
+func (cb *CircuitBreaker) Failure(dest string) {
+	now := time.Now()
+	cb.mu.Lock()
+	c, exists := cb.circuits[dest]
+	if !exists {
+		c = &circuit{}
+		cb.circuits[dest] = c
+	}
+	c.failures++
+
+	from := c.state
+	switch {
+	case c.state == CircuitHalfOpen:
+	case c.state == CircuitClosed && c.failures >= cb.threshold:
+	default:
+		cb.mu.Unlock()
+		return
+	}
+	c.state = CircuitOpen
+	c.openedAt = now
+	failures := c.failures
+	cb.mu.Unlock()
+
+	cb.log.Warn().Str("dest", dest).Int("failures", failures).Dur("duration", cb.open).Msg("Circuit opened")
+	cb.changed(dest, from, CircuitOpen)
+}
+
+// State returns circuit state of destination
+func (cb *CircuitBreaker) State(dest string) CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if c, exists := cb.circuits[dest]; exists {
+		return c.state
+	}
+	return CircuitClosed
+}
+
+// Stats returns circuits of destinations with failures sorted by destination
+func (cb *CircuitBreaker) Stats() []CircuitStats {
+	cb.mu.Lock()
+	stats := make([]CircuitStats, 0, len(cb.circuits))
+	for dest, c := range cb.circuits {
+		stats = append(stats, CircuitStats{
+			Destination: dest,
+			State:       c.state,
+			Failures:    c.failures,
+			Rejected:    c.rejected,
+			OpenedAt:    c.openedAt,
+		})
+	}
+	cb.mu.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Destination < stats[j].Destination })
+	return stats
+}
+
+// Expvar returns expvar map with open circuits count, total rejected requests
+// and state of every destination with failures
+func (cb *CircuitBreaker) Expvar() *expvar.Map {
+	m := new(expvar.Map)
+	m.Set("open", expvar.Func(func() any {
+		open := 0
+		for _, s := range cb.Stats() {
+			if s.State != CircuitClosed {
+				open++
+			}
+		}
+		return open
+	}))
+	m.Set("rejected", expvar.Func(func() any {
+		cb.mu.Lock()
+		defer cb.mu.Unlock()
+		return cb.rejected
+	}))
+	m.Set("destinations", expvar.Func(func() any {
+		states := make(map[string]string)
+		for _, s := range cb.Stats() {
+			states[s.Destination] = s.State.String()
+		}
+		return states
+	}))
+	return m
+}
+
+func (cb *CircuitBreaker) changed(dest string, from CircuitState, to CircuitState) {
+	cb.log.Debug().Str("dest", dest).Stringer("from", from).Stringer("to", to).Msg("Circuit state changed")
+	if cb.OnStateChange != nil {
+		cb.OnStateChange(dest, from, to)
+	}
+}
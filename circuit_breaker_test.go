@@ -0,0 +1,103 @@
+package sipgo
+
+import (
+	"context"
+	"expvar"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	cb := NewCircuitBreaker(2, 20*time.Millisecond)
+	var events []CircuitState
+	cb.OnStateChange = func(dest string, from CircuitState, to CircuitState) {
+		events = append(events, to)
+	}
+
+	dest := "carrier.example.com:5060"
+	require.NoError(t, cb.Allow(dest))
+	cb.Failure(dest)
+	assert.Equal(t, CircuitClosed, cb.State(dest))
+	cb.Failure(dest)
+	assert.Equal(t, CircuitOpen, cb.State(dest))
+	assert.ErrorIs(t, cb.Allow(dest), ErrCircuitOpen)
+
+	time.Sleep(30 * time.Millisecond)
+	// Single probe is passed
+	require.NoError(t, cb.Allow(dest))
+	assert.Equal(t, CircuitHalfOpen, cb.State(dest))
+	assert.ErrorIs(t, cb.Allow(dest), ErrCircuitOpen)
+
+	// Failed probe opens circuit again
+	cb.Failure(dest)
+	assert.Equal(t, CircuitOpen, cb.State(dest))
+
+	time.Sleep(30 * time.Millisecond)
+	require.NoError(t, cb.Allow(dest))
+	cb.Success(dest)
+	assert.Equal(t, CircuitClosed, cb.State(dest))
+	require.NoError(t, cb.Allow(dest))
+
+	assert.Equal(t, []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitOpen, CircuitHalfOpen, CircuitClosed}, events)
+	assert.Empty(t, cb.Stats())
+
+	cb.Failure(dest)
+	cb.Failure(dest)
+	cb.Allow(dest)
+	stats := cb.Stats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, CircuitOpen, stats[0].State)
+	assert.Equal(t, uint64(1), stats[0].Rejected)
+
+	m := cb.Expvar()
+	assert.Equal(t, "1", m.Get("open").String())
+	assert.Equal(t, "3", m.Get("rejected").String(), "rejected requests of all circuits")
+	assert.Equal(t, `{"carrier.example.com:5060":"open"}`, m.Get("destinations").(expvar.Func).String())
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	// Nothing is listening here
+	dead, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	deadAddr := dead.Addr().String()
+	dead.Close()
+
+	ua, err := NewUA()
+	require.NoError(t, err)
+	defer ua.Close()
+	cb := NewCircuitBreaker(2, time.Minute)
+	cli, err := NewClient(ua, WithClientHostname("127.0.0.1"), WithClientCircuitBreaker(cb))
+	require.NoError(t, err)
+
+	host, port, _ := sip.ParseAddr(deadAddr)
+	newReq := func() *sip.Request {
+		return sip.NewRequest(sip.INVITE, &sip.Uri{User: "+15551234", Host: host, Port: port, UriParams: sip.HeaderParams{"transport": "tcp"}})
+	}
+	for i := 0; i < 2; i++ {
+		_, err := cli.TransactionRequest(context.Background(), newReq())
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrCircuitOpen)
+	}
+	assert.Equal(t, CircuitOpen, cb.State(deadAddr))
+
+	_, err = cli.TransactionRequest(context.Background(), newReq())
+	require.ErrorIs(t, err, ErrCircuitOpen)
+
+	// Proxy handler failing fast is answered locally with 503
+	srv, err := NewServer(ua)
+	require.NoError(t, err)
+	req, _, _ := createTestInvite(t, "sip:+15551234@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	tx := siptest.NewServerTxRecorder(req)
+	srv.HandlerErr(func(req *sip.Request, tx sip.ServerTransaction) error {
+		_, err := cli.TransactionRequest(context.Background(), newReq())
+		return err
+	})(req, tx)
+	require.Len(t, tx.Result(), 1)
+	assert.Equal(t, sip.StatusServiceUnavailable, tx.Result()[0].StatusCode)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"strconv"
 
 	"github.com/emiago/sipgo/sip"
 	"github.com/google/uuid"
@@ -17,10 +18,17 @@ func Init() {
 
 type Client struct {
 	*UserAgent
-	host  string
+	host string
+	// host6 is used instead of host when request destination is IPv6
+	host6 string
 	port  int
 	rport bool
-	log   zerolog.Logger
+	ids   clientIDs
+	// failover retries transaction on next resolved target
+	failover      bool
+	breaker       *CircuitBreaker
+	outboundProxy *outboundProxy
+	log           zerolog.Logger
 }
 
 type ClientOption func(c *Client) error
@@ -56,7 +64,9 @@ func WithClientPort(port int) ClientOption {
 	}
 }
 
-// WithClientNAT makes client aware that is behind NAT.
+// WithClientNAT makes client aware that is behind NAT. It enables symmetric signaling:
+// rport is added on Via so that responses are sent back to request source address,
+// and dialog ACK is sent to source of 2xx response when there is no Record-Route
 func WithClientNAT() ClientOption {
 	return func(s *Client) error {
 		s.rport = true
@@ -87,6 +97,10 @@ func NewClient(ua *UserAgent, options ...ClientOption) (*Client, error) {
 		log:       log.Logger.With().Str("caller", "Client").Logger(),
 	}
 
+	if ip6 := ua.GetIPv6(); ip6 != nil {
+		c.host6 = ip6.String()
+	}
+
 	for _, o := range options {
 		if err := o(c); err != nil {
 			return nil, err
@@ -105,6 +119,22 @@ func (c *Client) GetHostname() string {
 	return c.host
 }
 
+// hostFor returns advertised host matching address family of request destination
+func (c *Client) hostFor(r *sip.Request) string {
+	if c.host6 == "" {
+		return c.host
+	}
+
+	host, _, err := net.SplitHostPort(r.Destination())
+	if err != nil {
+		return c.host
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+		return c.host6
+	}
+	return c.host
+}
+
 // TransactionRequest uses transaction layer to send request and returns transaction
 // NOTE: By default request will not be cloned and it will populate request with missing headers unless options are used
 //
@@ -130,8 +160,13 @@ func (c *Client) TransactionRequest(ctx context.Context, req *sip.Request, optio
 			cseq.MethodName = req.Method
 		}
 
-		clientRequestBuildReq(c, req)
-		return c.tx.Request(ctx, req)
+		if err := clientRequestBuildReq(c, req); err != nil {
+			return nil, err
+		}
+		if err := sipsCheckTransport(req); err != nil {
+			return nil, err
+		}
+		return c.request(ctx, req)
 	}
 
 	for _, o := range options {
@@ -139,6 +174,21 @@ func (c *Client) TransactionRequest(ctx context.Context, req *sip.Request, optio
 			return nil, err
 		}
 	}
+	if err := sipsCheckTransport(req); err != nil {
+		return nil, err
+	}
+	return c.request(ctx, req)
+}
+
+func (c *Client) request(ctx context.Context, req *sip.Request) (sip.ClientTransaction, error) {
+	if c.breaker != nil {
+		if err := c.breaker.Allow(req.Destination()); err != nil {
+			return nil, err
+		}
+	}
+	if c.failover || c.tp.Blacklist != nil || c.breaker != nil {
+		return c.failoverRequest(ctx, req)
+	}
 	return c.tx.Request(ctx, req)
 }
 
@@ -147,7 +197,12 @@ func (c *Client) TransactionRequest(ctx context.Context, req *sip.Request, optio
 // Non-transaction ACK request should be passed like this
 func (c *Client) WriteRequest(req *sip.Request, options ...ClientRequestOption) error {
 	if len(options) == 0 {
-		clientRequestBuildReq(c, req)
+		if err := clientRequestBuildReq(c, req); err != nil {
+			return err
+		}
+		if err := sipsCheckTransport(req); err != nil {
+			return err
+		}
 		return c.tp.WriteMsg(req)
 	}
 
@@ -156,6 +211,9 @@ func (c *Client) WriteRequest(req *sip.Request, options ...ClientRequestOption)
 			return err
 		}
 	}
+	if err := sipsCheckTransport(req); err != nil {
+		return err
+	}
 	return c.tp.WriteMsg(req)
 }
 
@@ -167,6 +225,10 @@ func clientRequestBuildReq(c *Client, req *sip.Request) error {
 	// the following header fields: To, From, CSeq, Call-ID, Max-Forwards,
 	// and Via;
 
+	if c.outboundProxy != nil {
+		c.outboundProxy.apply(req)
+	}
+
 	if v := req.Via(); v == nil {
 		// Multi VIA value must be manually added
 		ClientRequestAddVia(c, req)
@@ -190,7 +252,11 @@ func clientRequestBuildReq(c *Client, req *sip.Request) error {
 			from.Address.Host = c.host
 		}
 
-		from.Params.Add("tag", sip.GenerateTagN(16))
+		tag, err := c.ids.newTag()
+		if err != nil {
+			return err
+		}
+		from.Params.Add("tag", tag)
 		req.AppendHeader(&from)
 	}
 
@@ -198,6 +264,7 @@ func clientRequestBuildReq(c *Client, req *sip.Request) error {
 		to := sip.ToHeader{
 			Address: sip.Uri{
 				Encrypted: req.Recipient.Encrypted,
+				Tel:       req.Recipient.Tel,
 				User:      req.Recipient.User,
 				Host:      req.Recipient.Host,
 				UriParams: sip.NewParams(),
@@ -209,19 +276,19 @@ func clientRequestBuildReq(c *Client, req *sip.Request) error {
 	}
 
 	if v := req.CallID(); v == nil {
-		uuid, err := uuid.NewRandom()
+		id, err := c.ids.newCallID(c.host)
 		if err != nil {
 			return err
 		}
 
-		callid := sip.CallIDHeader(uuid.String())
+		callid := sip.CallIDHeader(id)
 		req.AppendHeader(&callid)
 
 	}
 
 	if v := req.CSeq(); v == nil {
 		cseq := sip.CSeqHeader{
-			SeqNo:      1,
+			SeqNo:      c.ids.newCSeq(),
 			MethodName: req.Method,
 		}
 		req.AppendHeader(&cseq)
@@ -248,17 +315,12 @@ func ClientRequestBuild(c *Client, r *sip.Request) error {
 // ClientRequestAddVia is option for adding via header
 // Based on proxy setup https://www.rfc-editor.org/rfc/rfc3261.html#section-16.6
 func ClientRequestAddVia(c *Client, r *sip.Request) error {
-	// TODO
-	// A client that sends a request to a multicast address MUST add the
-	// "maddr" parameter to its Via header field value containing the
-	// destination multicast address
-
 	newvia := &sip.ViaHeader{
 		ProtocolName:    "SIP",
 		ProtocolVersion: "2.0",
 		Transport:       r.Transport(),
-		Host:            c.host, // This can be rewritten by transport layer
-		Port:            c.port, // This can be rewritten by transport layer
+		Host:            c.hostFor(r), // This can be rewritten by transport layer
+		Port:            c.port,       // This can be rewritten by transport layer
 		Params:          sip.NewParams(),
 	}
 	// NOTE: Consider lenght of branch configurable
@@ -267,6 +329,20 @@ func ClientRequestAddVia(c *Client, r *sip.Request) error {
 		newvia.Params.Add("rport", "")
 	}
 
+	// A client that sends a request to a multicast address MUST add the
+	// "maddr" parameter to its Via header field value containing the
+	// destination multicast address, and for IPv4, SHOULD add the "ttl"
+	// parameter with a value of 1
+	// https://datatracker.ietf.org/doc/html/rfc3261#section-18.1.1
+	if host, _, err := net.SplitHostPort(r.Destination()); err == nil {
+		if ip := net.ParseIP(host); ip != nil && ip.IsMulticast() {
+			newvia.Params.Add("maddr", host)
+			if ip.To4() != nil {
+				newvia.Params.Add("ttl", "1")
+			}
+		}
+	}
+
 	if via := r.Via(); via != nil {
 		// https://datatracker.ietf.org/doc/html/rfc3581#section-6
 		// As proxy rport and received must be fullfiled
@@ -281,28 +357,108 @@ func ClientRequestAddVia(c *Client, r *sip.Request) error {
 }
 
 // ClientRequestAddRecordRoute is option for adding record route header
+// In case request is received on other transport or interface than it is forwarded,
+// two Record-Route headers are added, one for each side https://datatracker.ietf.org/doc/html/rfc5658
 // Based on proxy setup https://www.rfc-editor.org/rfc/rfc3261#section-16
+// For sips Request-URI, Record-Route is sips on TLS sides https://datatracker.ietf.org/doc/html/rfc5630#section-4.1
 func ClientRequestAddRecordRoute(c *Client, r *sip.Request) error {
+	network := sip.NetworkToLower(r.Transport())
+	rr := c.recordRoute(network, c.hostFor(r))
+	secure := r.Recipient != nil && r.Recipient.IsEncrypted()
+	rr.Address.Encrypted = secure && sip.IsSecure(network)
+
+	if rtp := r.ReceivedTransport(); rtp != "" {
+		inNetwork := sip.NetworkToLower(rtp)
+		// Interface facing previous hop is chosen by source IP family
+		inHost := c.host
+		if host, _, err := net.SplitHostPort(r.Source()); err == nil && c.host6 != "" {
+			if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+				inHost = c.host6
+			}
+		}
+
+		inRR := c.recordRoute(inNetwork, inHost)
+		inRR.Address.Encrypted = secure && sip.IsSecure(inNetwork)
+		if inNetwork != network || inRR.Address.Host != rr.Address.Host || inRR.Address.Port != rr.Address.Port {
+			// Upper one faces next hop and lower one faces previous hop
+			r.PrependHeader(inRR)
+		}
+	}
+
+	r.PrependHeader(rr)
+	return nil
+}
+
+// recordRoute creates Record-Route header for listener of network on host
+func (c *Client) recordRoute(network string, host string) *sip.RecordRouteHeader {
 	// We will try to use our listen port. Host must be set to some none NAT IP
-	port := c.tp.GetListenPort(sip.NetworkToLower(r.Transport()))
+	port := c.tp.GetListenPort(network)
+	// Listener advertised address is used in case of multi-homing or 1:1 NAT
+	if adv, ok := c.tp.GetAdvertisedAddr(network, net.JoinHostPort(host, strconv.Itoa(port))); ok {
+		host, port = adv.Host, adv.Port
+	}
 
-	rr := &sip.RecordRouteHeader{
+	return &sip.RecordRouteHeader{
 		Address: sip.Uri{
-			Host: c.host,
+			Host: host,
 			Port: port, // This must be listen port
 			UriParams: sip.HeaderParams{
 				// Transport must be provided as wesll
 				// https://datatracker.ietf.org/doc/html/rfc5658
-				"transport": sip.NetworkToLower(r.Transport()),
+				"transport": network,
 				"lr":        "",
 			},
 		},
 	}
+}
 
-	r.PrependHeader(rr)
+// ClientRequestRoute is option for forwarding requests which follow recorded route set, like ACK for 2xx.
+// Route header pointing to this proxy is removed and request is sent to next Route or Request-URI.
+// Request received from strict router is handled as well
+// Ex:
+//
+//	client.WriteRequest(ack, sipgo.ClientRequestRoute, sipgo.ClientRequestAddVia, sipgo.ClientRequestDecreaseMaxForward)
+//
+// https://datatracker.ietf.org/doc/html/rfc3261#section-16.4
+func ClientRequestRoute(c *Client, r *sip.Request) error {
+	if err := sip.RouteProcess(r, func(uri *sip.Uri) bool { return c.ownRoute(r, uri) }); err != nil {
+		return err
+	}
+	sip.RouteNextHop(r)
 	return nil
 }
 
+// ownRoute checks does uri point to this client listener
+func (c *Client) ownRoute(r *sip.Request, uri *sip.Uri) bool {
+	if uri == nil {
+		return false
+	}
+
+	network := sip.NetworkToLower(r.Transport())
+	if tran, ok := uri.UriParams.Get("transport"); ok && tran != "" {
+		network = sip.NetworkToLower(tran)
+	}
+
+	uriPort := uri.Port
+	if uriPort == 0 {
+		uriPort = sip.DefaultPort(network)
+	}
+	for _, host := range []string{c.host, c.host6} {
+		if host == "" {
+			continue
+		}
+		rr := c.recordRoute(network, host)
+		port := rr.Address.Port
+		if port == 0 {
+			port = sip.DefaultPort(network)
+		}
+		if uri.Host == rr.Address.Host && uriPort == port {
+			return true
+		}
+	}
+	return false
+}
+
 // Based on proxy setup https://www.rfc-editor.org/rfc/rfc3261#section-16
 // ClientRequestDecreaseMaxForward should be used when forwarding request. It decreases max forward
 // in case of 0 it returnes error
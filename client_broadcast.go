@@ -0,0 +1,126 @@
+package sipgo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+// BroadcastResult is outcome of request sent to single target
+type BroadcastResult struct {
+	Target sip.Uri
+	// Request is request sent to target
+	Request *sip.Request
+	// Response is final response. Nil in case of Err
+	Response *sip.Response
+	Err      error
+}
+
+// BroadcastOptions configures Client.Broadcast
+type BroadcastOptions struct {
+	// Concurrency is max number of requests in flight. Default is 10
+	Concurrency int
+	// Timeout limits waiting of single target for final response.
+	// Default is 0, which means transaction timeout 64*T1 is used
+	Timeout time.Duration
+	// OnResult is called as soon as outcome of target is known. It is called concurrently
+	OnResult func(r BroadcastResult)
+}
+
+// Broadcast sends copy of request to every target with bounded concurrency and waits final responses,
+// for ex. paging INVITE or mass NOTIFY. Results are in same order as targets.
+// Every copy is new transaction with Request-URI and To set to target, and own Via, Call-ID and CSeq.
+// 2xx on INVITE is acknowledged, and call can be ended with sip.NewByeRequestUAC(result.Request, result.Response, nil).
+// Canceling ctx stops sending and remaining targets are reported with ctx error
+func (c *Client) Broadcast(ctx context.Context, req *sip.Request, targets []sip.Uri, opts BroadcastOptions) []BroadcastResult {
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 10
+	}
+
+	results := make([]BroadcastResult, len(targets))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range targets {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			for j := i; j < len(targets); j++ {
+				results[j] = BroadcastResult{Target: targets[j], Err: ctx.Err()}
+			}
+			wg.Wait()
+			return results
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = c.broadcastTarget(ctx, req, targets[i], opts.Timeout)
+			if opts.OnResult != nil {
+				opts.OnResult(results[i])
+			}
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+func (c *Client) broadcastTarget(ctx context.Context, template *sip.Request, target sip.Uri, timeout time.Duration) BroadcastResult {
+	result := BroadcastResult{Target: target}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	req := template.Clone()
+	req.Recipient = target.Clone()
+	// Clone resolves transport and destination from template Request-URI. Only explicitly set are kept
+	req.SetTransport(template.MessageData.Transport())
+	req.SetDestination(template.MessageData.Destination())
+	req.SetBody(template.Body())
+	// Missing headers are generated per target by client
+	for _, name := range []string{"Via", "To", "Call-ID", "CSeq"} {
+		req.RemoveHeaders(name)
+	}
+	result.Request = req
+
+	tx, err := c.TransactionRequest(ctx, req, ClientRequestBuild)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer tx.Terminate()
+
+	for {
+		var res *sip.Response
+		select {
+		case res = <-tx.Responses():
+		case <-tx.Done():
+			result.Err = tx.Err()
+			return result
+		case <-ctx.Done():
+			result.Err = ctx.Err()
+			if req.IsInvite() {
+				tx.Cancel(context.Background())
+			}
+			return result
+		}
+
+		if res.IsProvisional() {
+			continue
+		}
+
+		result.Response = res
+		if req.IsInvite() && res.IsSuccess() {
+			ack := sip.NewAckRequest(req, res, nil)
+			if err := c.WriteRequest(ack); err != nil {
+				result.Err = err
+			}
+		}
+		return result
+	}
+}
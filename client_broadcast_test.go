@@ -0,0 +1,100 @@
+package sipgo
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientBroadcast(t *testing.T) {
+	srvUA, err := NewUA()
+	require.NoError(t, err)
+	defer srvUA.Close()
+	srv, err := NewServer(srvUA)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	callIDs := map[string]bool{}
+	srv.OnMessage(func(req *sip.Request, tx sip.ServerTransaction) {
+		mu.Lock()
+		callIDs[req.CallID().Value()] = true
+		mu.Unlock()
+		tx.Respond(sip.NewResponseFromRequest(req, sip.StatusOK, "OK", nil))
+	})
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go srv.ServeUDP(conn)
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+
+	// Nothing is listening here
+	dead, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	deadPort := dead.LocalAddr().(*net.UDPAddr).Port
+	dead.Close()
+
+	ua, err := NewUA()
+	require.NoError(t, err)
+	defer ua.Close()
+	cli, err := NewClient(ua, WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+
+	targets := []sip.Uri{
+		{User: "alice", Host: "127.0.0.1", Port: port},
+		{User: "bob", Host: "127.0.0.1", Port: port},
+		{User: "carol", Host: "127.0.0.1", Port: deadPort},
+	}
+	req := sip.NewRequest(sip.MESSAGE, &sip.Uri{Host: "127.0.0.1"})
+	req.SetContent("text/plain", []byte("evacuate"))
+
+	var reported atomic.Int32
+	results := cli.Broadcast(context.Background(), req, targets, BroadcastOptions{
+		Concurrency: 2,
+		Timeout:     300 * time.Millisecond,
+		OnResult:    func(r BroadcastResult) { reported.Add(1) },
+	})
+	require.Len(t, results, 3)
+	assert.Equal(t, int32(3), reported.Load())
+
+	for _, r := range results[:2] {
+		require.NoError(t, r.Err)
+		assert.Equal(t, sip.StatusOK, r.Response.StatusCode)
+		assert.Equal(t, r.Target.User, r.Request.To().Address.User)
+		assert.Equal(t, "evacuate", string(r.Request.Body()))
+	}
+	assert.Error(t, results[2].Err)
+	mu.Lock()
+	assert.Len(t, callIDs, 2)
+	mu.Unlock()
+	// Template is not changed
+	assert.Nil(t, req.CallID())
+
+	t.Run("Invite", func(t *testing.T) {
+		uas, err := siptest.NewBrokenUAS("127.0.0.1:0", siptest.FaultNone)
+		require.NoError(t, err)
+		defer uas.Close()
+
+		invite := sip.NewRequest(sip.INVITE, &sip.Uri{Host: "127.0.0.1"})
+		results := cli.Broadcast(context.Background(), invite, []sip.Uri{{User: "pager", Host: "127.0.0.1", Port: uas.Port()}}, BroadcastOptions{})
+		require.NoError(t, results[0].Err)
+		// 2xx is acked
+		require.Eventually(t, func() bool { return len(uas.Requests(sip.ACK)) == 1 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		results := cli.Broadcast(ctx, req, targets, BroadcastOptions{})
+		for _, r := range results {
+			assert.ErrorIs(t, r.Err, context.Canceled)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package sipgo
+
+import (
+	"context"
+	"sync"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+// TransactionRequestCtx is TransactionRequest with transaction bound to ctx, while TransactionRequest uses ctx
+// only for connection setup. When ctx is canceled or its deadline passes before transaction terminates,
+// transaction is terminated and Err returns *sip.TransactionError with ctx error. Passed deadline is timeout
+// and matches sip.ErrTransactionTimeout. INVITE is first canceled with CANCEL and its final response is awaited
+// Ex:
+//
+//	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+//	defer cancel()
+//	tx, err := client.TransactionRequestCtx(ctx, req)
+func (c *Client) TransactionRequestCtx(ctx context.Context, req *sip.Request, options ...ClientRequestOption) (sip.ClientTransaction, error) {
+	tx, err := c.TransactionRequest(ctx, req, options...)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Done() == nil {
+		return tx, nil
+	}
+
+	t := &contextTx{ClientTransaction: tx, req: req}
+	go t.watch(ctx)
+	return t, nil
+}
+
+// contextTx terminates transaction on context done
+type contextTx struct {
+	sip.ClientTransaction
+	req *sip.Request
+
+	mu  sync.Mutex
+	err error
+}
+
+func (t *contextTx) watch(ctx context.Context) {
+	select {
+	case <-t.ClientTransaction.Done():
+		return
+	case <-ctx.Done():
+	}
+
+	if t.req.IsInvite() {
+		// Waiting is limited by transaction timers
+		t.ClientTransaction.Cancel(context.Background())
+	}
+
+	t.mu.Lock()
+	t.err = &sip.TransactionError{
+		Destination: t.req.Destination(),
+		Transport:   t.ClientTransaction.Transport(),
+		Err:         ctx.Err(),
+	}
+	t.mu.Unlock()
+	t.ClientTransaction.Terminate()
+}
+
+func (t *contextTx) Err() error {
+	t.mu.Lock()
+	err := t.err
+	t.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return t.ClientTransaction.Err()
+}
@@ -0,0 +1,89 @@
+package sipgo
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientTransactionRequestCtx(t *testing.T) {
+	ua, err := NewUA()
+	require.NoError(t, err)
+	defer ua.Close()
+	cli, err := NewClient(ua, WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+
+	t.Run("Deadline", func(t *testing.T) {
+		// Blackhole never responds
+		blackhole, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer blackhole.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		req := sip.NewRequest(sip.OPTIONS, &sip.Uri{Host: "127.0.0.1", Port: blackhole.LocalAddr().(*net.UDPAddr).Port})
+		tx, err := cli.TransactionRequestCtx(ctx, req)
+		require.NoError(t, err)
+
+		select {
+		case <-tx.Done():
+		case <-time.After(time.Second):
+			t.Fatal("transaction not terminated on deadline")
+		}
+
+		var txErr *sip.TransactionError
+		require.ErrorAs(t, tx.Err(), &txErr)
+		assert.True(t, txErr.Timeout())
+		assert.Equal(t, blackhole.LocalAddr().String(), txErr.Destination)
+		assert.ErrorIs(t, tx.Err(), sip.ErrTransactionTimeout)
+		assert.ErrorIs(t, tx.Err(), context.DeadlineExceeded)
+		assert.NotErrorIs(t, tx.Err(), sip.ErrTransactionCanceled)
+		assert.NotErrorIs(t, tx.Err(), sip.ErrTransactionTransport)
+	})
+
+	t.Run("CancelInvite", func(t *testing.T) {
+		srvua, err := NewUA()
+		require.NoError(t, err)
+		defer srvua.Close()
+		srv, err := NewServer(srvua)
+		require.NoError(t, err)
+		srv.OnInvite(func(req *sip.Request, tx sip.ServerTransaction) {
+			tx.Respond(sip.NewResponseFromRequest(req, sip.StatusRinging, "Ringing", nil))
+			select {
+			case cancel := <-tx.Cancels():
+				tx.Respond(sip.NewResponseFromRequest(cancel, sip.StatusOK, "OK", nil))
+				tx.Respond(sip.NewResponseFromRequest(req, sip.StatusRequestTerminated, "Request Terminated", nil))
+			case <-tx.Done():
+			}
+		})
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		go srv.ServeUDP(conn)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		req := sip.NewRequest(sip.INVITE, &sip.Uri{User: "bob", Host: "127.0.0.1", Port: conn.LocalAddr().(*net.UDPAddr).Port})
+		tx, err := cli.TransactionRequestCtx(ctx, req)
+		require.NoError(t, err)
+
+		res := <-tx.Responses()
+		require.Equal(t, sip.StatusRinging, res.StatusCode)
+		cancel()
+
+		select {
+		case res := <-tx.Responses():
+			assert.Equal(t, sip.StatusRequestTerminated, res.StatusCode)
+		case <-time.After(time.Second):
+			t.Fatal("INVITE not canceled")
+		}
+		<-tx.Done()
+		assert.ErrorIs(t, tx.Err(), context.Canceled)
+		assert.ErrorIs(t, tx.Err(), sip.ErrTransactionCanceled)
+		assert.NotErrorIs(t, tx.Err(), sip.ErrTransactionTimeout)
+	})
+}
@@ -0,0 +1,298 @@
+package sipgo
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"sync"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+// WithClientFailover enables retrying transaction against next target resolved for request destination,
+// ex. next SRV record, when transaction times out or fails on transport before any response is received.
+// Retry is new transaction with same request and new Via branch
+// https://datatracker.ietf.org/doc/html/rfc3263#section-4.3
+func WithClientFailover() ClientOption {
+	return func(c *Client) error {
+		c.failover = true
+		return nil
+	}
+}
+
+// failoverError reports can request be retried on next target after this error
+func failoverError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, sip.ErrTransactionTimeout) || errors.Is(err, sip.ErrTransactionTransport) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
+
+// failoverTx is client transaction moving to next target on failure.
+// Responses of all attempts are passed on single channel.
+// Outcome of every attempt is recorded on transport layer blacklist when it is set,
+// and overall outcome on client circuit breaker
+type failoverTx struct {
+	c   *Client
+	ctx context.Context
+	// retry enables moving to next target, otherwise only outcome is recorded
+	retry bool
+	// origin is request before sending, used as template for retries
+	origin  *sip.Request
+	targets []string
+	tried   []string
+	// dest is destination of current attempt
+	dest string
+	// peer is destination before resolving, used as circuit breaker key
+	peer string
+
+	responses chan *sip.Response
+	done      chan struct{}
+	// terminated is closed by caller terminating transaction
+	terminated    chan struct{}
+	terminateOnce sync.Once
+
+	mu      sync.Mutex
+	tx      sip.ClientTransaction
+	err     error
+	stopped bool
+}
+
+func (c *Client) failoverRequest(ctx context.Context, req *sip.Request) (sip.ClientTransaction, error) {
+	host, _, err := sip.ParseAddr(req.Destination())
+	if c.breaker == nil && (err != nil || net.ParseIP(host) != nil) {
+		// Destination is already chosen, nothing to fail over to
+		return c.tx.Request(ctx, req)
+	}
+
+	origin := req.Clone()
+	origin.SetBody(req.Body())
+	f := &failoverTx{
+		c:          c,
+		ctx:        ctx,
+		retry:      c.failover,
+		origin:     origin,
+		peer:       req.Destination(),
+		responses:  make(chan *sip.Response),
+		done:       make(chan struct{}),
+		terminated: make(chan struct{}),
+	}
+
+	var tx sip.ClientTransaction
+	tx, err = c.tx.Request(ctx, req)
+	f.dest = req.Destination()
+	f.tried = append(f.tried, f.dest)
+	if err != nil && f.retry && failoverError(err) {
+		tx, err = f.next(err)
+	}
+	if err != nil {
+		f.recordPeer(nil, err)
+		return nil, err
+	}
+	f.tx = tx
+	go f.run()
+	return f, nil
+}
+
+// next sends request to next targets until transaction is created.
+// Passed error is returned when there are no more targets
+func (f *failoverTx) next(err error) (sip.ClientTransaction, error) {
+	for failoverError(err) && f.ctx.Err() == nil {
+		req := f.nextRequest()
+		if req == nil {
+			break
+		}
+
+		f.c.log.Info().Err(err).Str("req", req.StartLine()).Str("target", req.Destination()).Msg("Failing over to next target")
+		var tx sip.ClientTransaction
+		tx, err = f.c.tx.Request(f.ctx, req)
+		if err == nil {
+			f.dest = req.Destination()
+			return tx, nil
+		}
+	}
+	return nil, err
+}
+
+// nextRequest returns request for next not tried target or nil if all are tried
+func (f *failoverTx) nextRequest() *sip.Request {
+	if f.targets == nil {
+		targets, err := f.c.tp.ResolveTargets(f.ctx, f.origin)
+		if err != nil {
+			f.c.log.Debug().Err(err).Msg("Failover targets resolving failed")
+			return nil
+		}
+		f.targets = targets
+	}
+
+	for len(f.targets) > 0 {
+		target := f.targets[0]
+		f.targets = f.targets[1:]
+		if slices.Contains(f.tried, target) {
+			continue
+		}
+		f.tried = append(f.tried, target)
+
+		req := f.origin.Clone()
+		req.SetBody(f.origin.Body())
+		req.SetDestination(target)
+		if via := req.Via(); via != nil {
+			via.Params.Add("branch", sip.GenerateBranch())
+		}
+		return req
+	}
+	return nil
+}
+
+func (f *failoverTx) run() {
+	defer close(f.done)
+
+	f.mu.Lock()
+	tx := f.tx
+	f.mu.Unlock()
+	peerRecorded := false
+	for {
+		received, recorded := false, false
+	loop:
+		for {
+			select {
+			case res := <-tx.Responses():
+				if !recorded && !res.IsProvisional() {
+					f.record(res, nil)
+					recorded = true
+					if !peerRecorded {
+						f.recordPeer(res, nil)
+						peerRecorded = true
+					}
+				}
+				received = true
+				// Response is already passed by transaction, so it is kept until read
+				select {
+				case f.responses <- res:
+				case <-f.terminated:
+				}
+			case <-tx.Done():
+				break loop
+			}
+		}
+
+		err := tx.Err()
+		f.mu.Lock()
+		stopped := f.stopped
+		f.mu.Unlock()
+		if !received && !stopped {
+			f.record(nil, err)
+		}
+		if received || stopped || !f.retry || !failoverError(err) {
+			if !received && !stopped {
+				f.recordPeer(nil, err)
+			}
+			f.setErr(err)
+			return
+		}
+
+		tx, err = f.next(err)
+		if err != nil {
+			f.recordPeer(nil, err)
+			f.setErr(err)
+			return
+		}
+
+		f.mu.Lock()
+		f.tx = tx
+		if f.stopped {
+			tx.Terminate()
+		}
+		f.mu.Unlock()
+	}
+}
+
+// record records outcome of current attempt on blacklist. First final response or transaction error is passed
+func (f *failoverTx) record(res *sip.Response, err error) {
+	bl := f.c.tp.Blacklist
+	if bl == nil {
+		return
+	}
+	switch {
+	case res != nil && res.StatusCode == sip.StatusServiceUnavailable:
+		bl.Failure(f.dest, res.StartLine())
+	case res != nil:
+		bl.Success(f.dest)
+	case failoverError(err):
+		bl.Failure(f.dest, err.Error())
+	}
+}
+
+// recordPeer records outcome of request on circuit breaker
+func (f *failoverTx) recordPeer(res *sip.Response, err error) {
+	cb := f.c.breaker
+	if cb == nil {
+		return
+	}
+	switch {
+	case res != nil && res.StatusCode == sip.StatusServiceUnavailable:
+		cb.Failure(f.peer)
+	case res != nil:
+		cb.Success(f.peer)
+	case failoverError(err):
+		cb.Failure(f.peer)
+	}
+}
+
+func (f *failoverTx) setErr(err error) {
+	f.mu.Lock()
+	f.err = err
+	f.mu.Unlock()
+}
+
+func (f *failoverTx) current() sip.ClientTransaction {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.tx
+}
+
+func (f *failoverTx) Responses() <-chan *sip.Response {
+	return f.responses
+}
+
+// Cancel cancels current attempt. No further targets are tried
+func (f *failoverTx) Cancel(ctx context.Context) (*sip.Response, error) {
+	f.mu.Lock()
+	f.stopped = true
+	tx := f.tx
+	f.mu.Unlock()
+	return tx.Cancel(ctx)
+}
+
+func (f *failoverTx) Terminate() {
+	f.mu.Lock()
+	f.stopped = true
+	tx := f.tx
+	f.mu.Unlock()
+	tx.Terminate()
+	f.terminateOnce.Do(func() { close(f.terminated) })
+}
+
+func (f *failoverTx) Done() <-chan struct{} {
+	return f.done
+}
+
+func (f *failoverTx) Err() error {
+	select {
+	case <-f.done:
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return f.err
+	default:
+	}
+	return f.current().Err()
+}
+
+func (f *failoverTx) Transport() string {
+	return f.current().Transport()
+}
@@ -0,0 +1,231 @@
+package sipgo
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failoverTestResolver resolves every host only over SRV records
+type failoverTestResolver struct {
+	srv []*net.SRV
+}
+
+func (r *failoverTestResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func (r *failoverTestResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	return "", r.srv, nil
+}
+
+func testFailoverServer(t *testing.T) *Server {
+	ua, err := NewUA()
+	require.NoError(t, err)
+	t.Cleanup(func() { ua.Close() })
+	srv, err := NewServer(ua)
+	require.NoError(t, err)
+	srv.OnOptions(func(req *sip.Request, tx sip.ServerTransaction) {
+		tx.Respond(sip.NewResponseFromRequest(req, sip.StatusOK, "OK", nil))
+	})
+	return srv
+}
+
+func testFailoverClient(t *testing.T, srv []*net.SRV, options ...ClientOption) *Client {
+	return testFailoverClientUA(t, srv, nil, options...)
+}
+
+func testFailoverClientUA(t *testing.T, srv []*net.SRV, uaOptions []UserAgentOption, options ...ClientOption) *Client {
+	ua, err := NewUA(uaOptions...)
+	require.NoError(t, err)
+	t.Cleanup(func() { ua.Close() })
+	ua.TransportLayer().SetDNSResolver(&failoverTestResolver{srv: srv})
+
+	cli, err := NewClient(ua, append([]ClientOption{WithClientHostname("127.0.0.1")}, options...)...)
+	require.NoError(t, err)
+	return cli
+}
+
+func testFailoverWait(t *testing.T, tx sip.ClientTransaction) (*sip.Response, error) {
+	select {
+	case res := <-tx.Responses():
+		return res, nil
+	case <-tx.Done():
+		return nil, tx.Err()
+	case <-time.After(5 * time.Second):
+		t.Fatal("transaction did not finish")
+	}
+	return nil, nil
+}
+
+func TestClientFailoverConnectionRefused(t *testing.T) {
+	srv := testFailoverServer(t)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go srv.ServeTCP(l)
+
+	// Nothing is listening here
+	dead, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	deadPort := dead.Addr().(*net.TCPAddr).Port
+	dead.Close()
+
+	records := []*net.SRV{
+		{Target: "127.0.0.1.", Port: uint16(deadPort), Priority: 10},
+		{Target: "127.0.0.1.", Port: uint16(l.Addr().(*net.TCPAddr).Port), Priority: 20},
+	}
+	newReq := func() *sip.Request {
+		return sip.NewRequest(sip.OPTIONS, &sip.Uri{Host: "example.com", UriParams: sip.HeaderParams{"transport": "tcp"}})
+	}
+
+	cli := testFailoverClient(t, records, WithClientFailover())
+	tx, err := cli.TransactionRequest(context.Background(), newReq())
+	require.NoError(t, err)
+	defer tx.Terminate()
+
+	res, err := testFailoverWait(t, tx)
+	require.NoError(t, err)
+	assert.Equal(t, sip.StatusOK, res.StatusCode)
+	assert.Equal(t, l.Addr().String(), res.Source())
+
+	t.Run("Disabled", func(t *testing.T) {
+		cli := testFailoverClient(t, records)
+		_, err := cli.TransactionRequest(context.Background(), newReq())
+		require.Error(t, err)
+	})
+}
+
+func TestClientFailoverTimeout(t *testing.T) {
+	timers := sip.GetTimers()
+	sip.SetTimers(10*time.Millisecond, 40*time.Millisecond, 50*time.Millisecond)
+	defer sip.ApplyTimers(timers)
+
+	srv := testFailoverServer(t)
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go srv.ServeUDP(conn)
+
+	// Blackhole receives request but never responds
+	blackhole, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer blackhole.Close()
+	received := make(chan struct{}, 1)
+	go func() {
+		buf := make([]byte, 2048)
+		for {
+			if _, _, err := blackhole.ReadFrom(buf); err != nil {
+				return
+			}
+			select {
+			case received <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	records := []*net.SRV{
+		{Target: "127.0.0.1.", Port: uint16(blackhole.LocalAddr().(*net.UDPAddr).Port), Priority: 10},
+		{Target: "127.0.0.1.", Port: uint16(conn.LocalAddr().(*net.UDPAddr).Port), Priority: 20},
+	}
+	cli := testFailoverClient(t, records, WithClientFailover())
+
+	req := sip.NewRequest(sip.OPTIONS, &sip.Uri{Host: "example.com"})
+	tx, err := cli.TransactionRequest(context.Background(), req)
+	require.NoError(t, err)
+	defer tx.Terminate()
+
+	res, err := testFailoverWait(t, tx)
+	require.NoError(t, err)
+	assert.Equal(t, sip.StatusOK, res.StatusCode)
+	assert.NotEqual(t, req.Via().Params["branch"], res.Via().Params["branch"], "retry must be new transaction")
+
+	select {
+	case <-received:
+	default:
+		t.Fatal("first target was not tried")
+	}
+
+	t.Run("AllTargetsFail", func(t *testing.T) {
+		cli := testFailoverClient(t, records[:1], WithClientFailover())
+		tx, err := cli.TransactionRequest(context.Background(), sip.NewRequest(sip.OPTIONS, &sip.Uri{Host: "example.com"}))
+		require.NoError(t, err)
+		defer tx.Terminate()
+
+		_, err = testFailoverWait(t, tx)
+		assert.ErrorIs(t, err, sip.ErrTransactionTimeout)
+	})
+}
+
+func TestClientBlacklist(t *testing.T) {
+	srv := testFailoverServer(t)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go srv.ServeTCP(l)
+
+	dead, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	deadAddr := dead.Addr().String()
+	dead.Close()
+
+	records := []*net.SRV{
+		{Target: "127.0.0.1.", Port: uint16(dead.Addr().(*net.TCPAddr).Port), Priority: 10},
+		{Target: "127.0.0.1.", Port: uint16(l.Addr().(*net.TCPAddr).Port), Priority: 20},
+	}
+	bl := sip.NewDestinationBlacklist(time.Minute, time.Minute)
+	cli := testFailoverClientUA(t, records, []UserAgentOption{WithUserAgentDestinationBlacklist(bl)}, WithClientFailover())
+
+	newReq := func() *sip.Request {
+		return sip.NewRequest(sip.OPTIONS, &sip.Uri{Host: "example.com", UriParams: sip.HeaderParams{"transport": "tcp"}})
+	}
+	tx, err := cli.TransactionRequest(context.Background(), newReq())
+	require.NoError(t, err)
+	_, err = testFailoverWait(t, tx)
+	require.NoError(t, err)
+	tx.Terminate()
+	assert.True(t, bl.Blacklisted(deadAddr))
+
+	// Blacklisted target is skipped
+	req := newReq()
+	tx, err = cli.TransactionRequest(context.Background(), req)
+	require.NoError(t, err)
+	defer tx.Terminate()
+	assert.Equal(t, l.Addr().String(), req.Destination())
+	_, err = testFailoverWait(t, tx)
+	require.NoError(t, err)
+
+	t.Run("ServiceUnavailable", func(t *testing.T) {
+		ua, err := NewUA()
+		require.NoError(t, err)
+		defer ua.Close()
+		srv, err := NewServer(ua)
+		require.NoError(t, err)
+		srv.OnOptions(func(req *sip.Request, tx sip.ServerTransaction) {
+			tx.Respond(sip.NewResponseFromRequest(req, sip.StatusServiceUnavailable, "Service Unavailable", nil))
+		})
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		go srv.ServeUDP(conn)
+
+		records := []*net.SRV{{Target: "127.0.0.1.", Port: uint16(conn.LocalAddr().(*net.UDPAddr).Port)}}
+		bl := sip.NewDestinationBlacklist(time.Minute, time.Minute)
+		cli := testFailoverClientUA(t, records, []UserAgentOption{WithUserAgentDestinationBlacklist(bl)})
+
+		tx, err := cli.TransactionRequest(context.Background(), sip.NewRequest(sip.OPTIONS, &sip.Uri{Host: "example.com"}))
+		require.NoError(t, err)
+		defer tx.Terminate()
+		res, err := testFailoverWait(t, tx)
+		require.NoError(t, err)
+		assert.Equal(t, sip.StatusServiceUnavailable, res.StatusCode)
+
+		entries := bl.Entries()
+		require.Len(t, entries, 1)
+		assert.Equal(t, conn.LocalAddr().String(), entries[0].Destination)
+		assert.Equal(t, "SIP/2.0 503 Service Unavailable", entries[0].Reason)
+	})
+}
@@ -0,0 +1,98 @@
+package sipgo
+
+import (
+	"math/rand"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/google/uuid"
+)
+
+// IDGenerator generates value like Call-ID or tag for new requests
+type IDGenerator func() (string, error)
+
+// clientIDs controls look of generated Call-ID, From tag and CSeq.
+// Some far end equipment and fraud systems fingerprint them and legacy switches may reject some formats
+type clientIDs struct {
+	callID     IDGenerator
+	callIDHost bool
+	tag        IDGenerator
+	cseq       func() uint32
+}
+
+// WithClientCallIDGenerator sets generator of Call-ID for new requests. Default is random UUID
+func WithClientCallIDGenerator(gen IDGenerator) ClientOption {
+	return func(c *Client) error {
+		c.ids.callID = gen
+		return nil
+	}
+}
+
+// WithClientCallIDHost appends @host to generated Call-ID, where host is client hostname
+// https://datatracker.ietf.org/doc/html/rfc3261#section-8.1.1.4
+func WithClientCallIDHost() ClientOption {
+	return func(c *Client) error {
+		c.ids.callIDHost = true
+		return nil
+	}
+}
+
+// WithClientTagGenerator sets generator of From tag for new requests. Default is 16 random characters
+func WithClientTagGenerator(gen IDGenerator) ClientOption {
+	return func(c *Client) error {
+		c.ids.tag = gen
+		return nil
+	}
+}
+
+// WithClientCSeqStart sets initial CSeq number of new requests. Default is 1
+func WithClientCSeqStart(seq uint32) ClientOption {
+	return func(c *Client) error {
+		c.ids.cseq = func() uint32 { return seq }
+		return nil
+	}
+}
+
+// WithClientCSeqRandom makes initial CSeq number of new requests random, leaving room for incrementing below 2^31
+// https://datatracker.ietf.org/doc/html/rfc3261#section-8.1.1.5
+func WithClientCSeqRandom() ClientOption {
+	return func(c *Client) error {
+		c.ids.cseq = func() uint32 { return uint32(rand.Int31n(1<<30)) + 1 }
+		return nil
+	}
+}
+
+func (ids *clientIDs) newCallID(host string) (string, error) {
+	var callid string
+	if ids.callID != nil {
+		v, err := ids.callID()
+		if err != nil {
+			return "", err
+		}
+		callid = v
+	} else {
+		v, err := uuid.NewRandom()
+		if err != nil {
+			return "", err
+		}
+		callid = v.String()
+	}
+
+	if ids.callIDHost && host != "" {
+		callid += "@" + host
+	}
+	return callid, nil
+}
+
+func (ids *clientIDs) newTag() (string, error) {
+	if ids.tag != nil {
+		return ids.tag()
+	}
+	return sip.GenerateTagN(16), nil
+}
+
+func (ids *clientIDs) newCSeq() uint32 {
+	if ids.cseq != nil {
+		return ids.cseq()
+	}
+	return 1
+}
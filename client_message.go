@@ -0,0 +1,95 @@
+package sipgo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+// MessageUDPMaxSize is max body size of MESSAGE sent over UDP. Larger MESSAGE is sent over TCP
+// https://datatracker.ietf.org/doc/html/rfc3428#section-7
+var MessageUDPMaxSize = 1300
+
+// MessageStatus is delivery status of MESSAGE taken from final response
+type MessageStatus int
+
+const (
+	// MessageDelivered is reported on 200. Message is delivered to recipient
+	MessageDelivered MessageStatus = iota
+	// MessageAccepted is reported on 202. Message is accepted for later delivery, ex. stored by server
+	// while recipient is offline, and it is not known will it be delivered
+	MessageAccepted
+	// MessageFailed is reported on non 2xx response
+	MessageFailed
+)
+
+func (s MessageStatus) String() string {
+	switch s {
+	case MessageDelivered:
+		return "delivered"
+	case MessageAccepted:
+		return "accepted"
+	case MessageFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// MessageReport is delivery report of MESSAGE
+type MessageReport struct {
+	Status MessageStatus
+	// Request is sent MESSAGE
+	Request *sip.Request
+	// Response is final response
+	Response *sip.Response
+}
+
+// SendMessage sends pager mode instant message and waits final response, which is returned as delivery report.
+// Error is returned only when final response is not received. Check report Status for failed delivery.
+// MESSAGE with body larger than MessageUDPMaxSize is sent over TCP in case UDP would be used
+// https://datatracker.ietf.org/doc/html/rfc3428
+func (c *Client) SendMessage(ctx context.Context, to sip.Uri, contentType string, body []byte, headers ...sip.Header) (*MessageReport, error) {
+	req := sip.NewRequest(sip.MESSAGE, to.Clone())
+	req.SetBody(body)
+	req.AppendHeader(sip.NewHeader("Content-Type", contentType))
+	for _, h := range headers {
+		req.AppendHeader(h)
+	}
+
+	if len(body) > MessageUDPMaxSize && strings.EqualFold(req.Transport(), sip.TransportUDP) {
+		req.SetTransport(sip.TransportTCP)
+	}
+
+	tx, err := c.TransactionRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Terminate()
+
+	for {
+		var res *sip.Response
+		select {
+		case res = <-tx.Responses():
+		case <-tx.Done():
+			return nil, txTerminatedErr(tx)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		if res.IsProvisional() {
+			continue
+		}
+		return &MessageReport{Status: messageStatus(res.StatusCode), Request: req, Response: res}, nil
+	}
+}
+
+func messageStatus(code sip.StatusCode) MessageStatus {
+	switch {
+	case code == sip.StatusAccepted:
+		return MessageAccepted
+	case code >= 200 && code < 300:
+		return MessageDelivered
+	}
+	return MessageFailed
+}
@@ -0,0 +1,91 @@
+package sipgo
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientSendMessage(t *testing.T) {
+	ua, err := NewUA(WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer ua.Close()
+	srv, err := NewServer(ua)
+	require.NoError(t, err)
+
+	received := make(chan *PagerMessage, 10)
+	srv.OnPagerMessage(func(msg *PagerMessage) (MessageStatus, error) {
+		received <- msg
+		switch msg.To.User {
+		case "offline":
+			return MessageAccepted, nil
+		case "unknown":
+			return MessageFailed, nil
+		}
+		if msg.ContentType != "text/plain" {
+			return MessageFailed, sip.StatusError{Code: sip.StatusUnsupportedMediaType, Reason: "Unsupported Media Type"}
+		}
+		return MessageDelivered, nil
+	})
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	ln, err := net.Listen("tcp", conn.LocalAddr().String())
+	require.NoError(t, err)
+	go srv.ServeUDP(conn)
+	go srv.ServeTCP(ln)
+
+	cua, err := NewUA(WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer cua.Close()
+	cli, err := NewClient(cua, WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	send := func(user string, contentType string, body string) *MessageReport {
+		t.Helper()
+		report, err := cli.SendMessage(ctx, sip.Uri{User: user, Host: "127.0.0.1", Port: port}, contentType, []byte(body))
+		require.NoError(t, err)
+		return report
+	}
+
+	report := send("bob", "text/plain", "hello")
+	assert.Equal(t, MessageDelivered, report.Status)
+	assert.Equal(t, sip.StatusOK, report.Response.StatusCode)
+	msg := <-received
+	assert.Equal(t, "hello", string(msg.Body))
+	assert.Equal(t, "bob", msg.To.User)
+	assert.Equal(t, "text/plain", msg.ContentType)
+
+	report = send("offline", "text/plain", "later")
+	assert.Equal(t, MessageAccepted, report.Status)
+	assert.Equal(t, sip.StatusAccepted, report.Response.StatusCode)
+	<-received
+
+	report = send("unknown", "text/plain", "hello")
+	assert.Equal(t, MessageFailed, report.Status)
+	assert.Equal(t, sip.StatusTemporarilyUnavailable, report.Response.StatusCode)
+	<-received
+
+	report = send("bob", "application/im-iscomposing+xml", "<xml/>")
+	assert.Equal(t, MessageFailed, report.Status)
+	assert.Equal(t, sip.StatusUnsupportedMediaType, report.Response.StatusCode)
+	<-received
+
+	// Large message is not sent over UDP
+	large := strings.Repeat("a", MessageUDPMaxSize+1)
+	report = send("bob", "text/plain", large)
+	assert.Equal(t, MessageDelivered, report.Status)
+	assert.Equal(t, sip.TransportTCP, report.Request.Transport())
+	msg = <-received
+	assert.Equal(t, large, string(msg.Body))
+}
@@ -0,0 +1,105 @@
+package sipgo
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+// OutboundProxyMode decides how request is sent to outbound proxy
+type OutboundProxyMode int
+
+const (
+	// OutboundProxyRoute preloads Route header with loose routing proxy URI
+	// https://datatracker.ietf.org/doc/html/rfc3261#section-8.1.2
+	OutboundProxyRoute OutboundProxyMode = iota
+	// OutboundProxyDestination only overrides request destination, request is not changed.
+	// Useful for SBCs that do not expect Route header
+	OutboundProxyDestination
+)
+
+type outboundProxy struct {
+	uri  sip.Uri
+	mode OutboundProxyMode
+}
+
+// WithClientOutboundProxy sends all requests to proxy uri as next hop regardless of Request-URI.
+// Requests with Route set, like in dialog requests with recorded route, or with destination already
+// set are not changed
+// Ex:
+//
+//	client, _ := sipgo.NewClient(ua, sipgo.WithClientOutboundProxy("sip:sbc.example.com;transport=tcp", sipgo.OutboundProxyRoute))
+func WithClientOutboundProxy(uri string, mode OutboundProxyMode) ClientOption {
+	return func(c *Client) error {
+		p, err := newOutboundProxy(uri, mode)
+		if err != nil {
+			return err
+		}
+		c.outboundProxy = p
+		return nil
+	}
+}
+
+// ClientRequestOutboundProxy is option for sending single request over outbound proxy.
+// It must be passed before ClientRequestBuild or ClientRequestAddVia, so Via has proxy transport
+// Ex:
+//
+//	proxy := sip.Uri{Host: "sbc.example.com", Port: 5060}
+//	client.TransactionRequest(ctx, req, sipgo.ClientRequestOutboundProxy(proxy, sipgo.OutboundProxyRoute), sipgo.ClientRequestBuild)
+func ClientRequestOutboundProxy(uri sip.Uri, mode OutboundProxyMode) ClientRequestOption {
+	p := &outboundProxy{uri: *uri.Clone(), mode: mode}
+	p.init()
+	return func(c *Client, req *sip.Request) error {
+		p.apply(req)
+		return nil
+	}
+}
+
+func newOutboundProxy(uri string, mode OutboundProxyMode) (*outboundProxy, error) {
+	p := &outboundProxy{mode: mode}
+	if err := sip.ParseUri(uri, &p.uri); err != nil {
+		return nil, fmt.Errorf("outbound proxy %q: %w", uri, err)
+	}
+	if p.uri.Host == "" || p.uri.Tel {
+		return nil, fmt.Errorf("outbound proxy %q: missing host", uri)
+	}
+	p.init()
+	return p, nil
+}
+
+func (p *outboundProxy) init() {
+	if p.uri.UriParams == nil {
+		p.uri.UriParams = sip.NewParams()
+	}
+	if p.mode == OutboundProxyRoute {
+		// Strict routing proxies are not supported
+		p.uri.UriParams.Add("lr", "")
+	}
+}
+
+// apply routes request over proxy. It must be called before Via is added
+func (p *outboundProxy) apply(req *sip.Request) {
+	switch p.mode {
+	case OutboundProxyRoute:
+		if req.Route() != nil {
+			return
+		}
+		req.PrependHeader(&sip.RouteHeader{Address: *p.uri.Clone()})
+
+	case OutboundProxyDestination:
+		if req.MessageData.Destination() != "" {
+			return
+		}
+		if tp, ok := p.uri.UriParams.Get("transport"); ok && tp != "" {
+			req.SetTransport(strings.ToUpper(tp))
+		}
+		port := p.uri.Port
+		if port == 0 {
+			port = sip.DefaultPort(req.Transport())
+		}
+		req.SetDestination(net.JoinHostPort(strings.Trim(p.uri.Host, "[]"), strconv.Itoa(port)))
+	}
+}
@@ -0,0 +1,217 @@
+package sipgo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/netip"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// sweepRangeMax limits number of targets generated from single prefix
+const sweepRangeMax = 1 << 16
+
+// SweepResult is OPTIONS probe result of single target
+type SweepResult struct {
+	Target sip.Uri
+	// StatusCode and Reason of final response. Zero in case of Err
+	StatusCode sip.StatusCode
+	Reason     string
+	// UserAgent is User-Agent header of response, or Server header when missing
+	UserAgent string
+	// Allow are methods listed in Allow header
+	Allow   []string
+	Latency time.Duration
+	Err     error
+}
+
+// SweepReport is list of results in same order as targets
+type SweepReport []SweepResult
+
+// Reachable returns results of targets which answered with any final response
+func (r SweepReport) Reachable() SweepReport {
+	var res SweepReport
+	for _, s := range r {
+		if s.Err == nil {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// Write writes report as aligned table with one target per line
+func (r SweepReport) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TARGET\tSTATUS\tLATENCY\tUSER-AGENT\tALLOW")
+	for _, s := range r {
+		status := fmt.Sprintf("%d %s", s.StatusCode, s.Reason)
+		if s.Err != nil {
+			status = s.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", s.Target.String(), status, s.Latency.Round(time.Millisecond), s.UserAgent, strings.Join(s.Allow, ","))
+	}
+	return tw.Flush()
+}
+
+// Sweeper probes many targets with OPTIONS concurrently, for auditing reachability and software of SIP endpoints
+// Ex:
+//
+//	targets, _ := sipgo.SweepRange("10.1.0.0/24", 5060)
+//	report := sipgo.NewSweeper(client, sipgo.WithSweepConcurrency(50)).Sweep(ctx, targets)
+//	report.Reachable().Write(os.Stdout)
+type Sweeper struct {
+	client      *Client
+	concurrency int
+	timeout     time.Duration
+	log         zerolog.Logger
+}
+
+type SweeperOption func(s *Sweeper)
+
+// WithSweepConcurrency sets max number of OPTIONS in flight. Default is 10
+func WithSweepConcurrency(n int) SweeperOption {
+	return func(s *Sweeper) {
+		s.concurrency = n
+	}
+}
+
+// WithSweepTimeout sets how long single target is waited for response.
+// Default is 0, which means transaction timeout 64*T1 is used
+func WithSweepTimeout(d time.Duration) SweeperOption {
+	return func(s *Sweeper) {
+		s.timeout = d
+	}
+}
+
+// WithSweepLogger allows customizing logger
+func WithSweepLogger(logger zerolog.Logger) SweeperOption {
+	return func(s *Sweeper) {
+		s.log = logger
+	}
+}
+
+// NewSweeper creates sweeper sending OPTIONS over client
+func NewSweeper(client *Client, options ...SweeperOption) *Sweeper {
+	s := &Sweeper{
+		client:      client,
+		concurrency: 10,
+		log:         log.Logger.With().Str("caller", "Sweeper").Logger(),
+	}
+	for _, o := range options {
+		o(s)
+	}
+	if s.concurrency < 1 {
+		s.concurrency = 1
+	}
+	return s
+}
+
+// Sweep sends OPTIONS to every target and waits all results. Canceling ctx stops probing
+// and remaining targets are reported with ctx error
+func (s *Sweeper) Sweep(ctx context.Context, targets []sip.Uri) SweepReport {
+	report := make(SweepReport, len(targets))
+	sem := make(chan struct{}, s.concurrency)
+	var wg sync.WaitGroup
+	for i := range targets {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			for j := i; j < len(targets); j++ {
+				report[j] = SweepResult{Target: targets[j], Err: ctx.Err()}
+			}
+			wg.Wait()
+			return report
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			report[i] = s.Probe(ctx, targets[i])
+		}(i)
+	}
+	wg.Wait()
+	return report
+}
+
+// Probe sends single OPTIONS to target and measures latency until final response
+func (s *Sweeper) Probe(ctx context.Context, target sip.Uri) SweepResult {
+	result := SweepResult{Target: target}
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	recipient := target
+	req := sip.NewRequest(sip.OPTIONS, &recipient)
+	start := time.Now()
+	tx, err := s.client.TransactionRequest(ctx, req)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer tx.Terminate()
+
+	for {
+		var res *sip.Response
+		select {
+		case res = <-tx.Responses():
+		case <-tx.Done():
+			result.Err = tx.Err()
+			return result
+		case <-ctx.Done():
+			result.Err = ctx.Err()
+			return result
+		}
+
+		if res.IsProvisional() {
+			continue
+		}
+
+		result.Latency = time.Since(start)
+		result.StatusCode = res.StatusCode
+		result.Reason = res.Reason
+		if h := res.GetHeader("User-Agent"); h != nil {
+			result.UserAgent = h.Value()
+		} else if h := res.GetHeader("Server"); h != nil {
+			result.UserAgent = h.Value()
+		}
+		for _, h := range res.GetHeaders("Allow") {
+			for _, m := range strings.Split(h.Value(), ",") {
+				if m = strings.TrimSpace(m); m != "" {
+					result.Allow = append(result.Allow, m)
+				}
+			}
+		}
+		s.log.Debug().Str("target", target.String()).Int("status", int(res.StatusCode)).Dur("latency", result.Latency).Msg("Target answered")
+		return result
+	}
+}
+
+// SweepRange returns targets for every address in prefix, ex. 10.1.0.0/24. Port 0 means default port.
+// Prefixes with more than 65536 addresses are rejected
+func SweepRange(prefix string, port int) ([]sip.Uri, error) {
+	p, err := netip.ParsePrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	p = p.Masked()
+	if bits := p.Addr().BitLen() - p.Bits(); bits > 16 {
+		return nil, fmt.Errorf("sweep range %s too large", prefix)
+	}
+
+	var targets []sip.Uri
+	for addr := p.Addr(); p.Contains(addr) && len(targets) < sweepRangeMax; addr = addr.Next() {
+		targets = append(targets, sip.Uri{Host: addr.String(), Port: port})
+	}
+	return targets, nil
+}
@@ -0,0 +1,92 @@
+package sipgo
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSweeper(t *testing.T) {
+	srvUA, err := NewUA()
+	require.NoError(t, err)
+	defer srvUA.Close()
+	srv, err := NewServer(srvUA)
+	require.NoError(t, err)
+	srv.OnOptions(func(req *sip.Request, tx sip.ServerTransaction) {
+		res := sip.NewResponseFromRequest(req, sip.StatusOK, "OK", nil)
+		res.AppendHeader(sip.NewHeader("User-Agent", "TestPBX/1.0"))
+		res.AppendHeader(sip.NewHeader("Allow", "INVITE, ACK, BYE, OPTIONS"))
+		tx.Respond(res)
+	})
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go srv.ServeUDP(conn)
+
+	// Nothing is listening here
+	dead, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	deadPort := dead.LocalAddr().(*net.UDPAddr).Port
+	dead.Close()
+
+	ua, err := NewUA()
+	require.NoError(t, err)
+	defer ua.Close()
+	cli, err := NewClient(ua, WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+
+	targets := []sip.Uri{
+		{Host: "127.0.0.1", Port: conn.LocalAddr().(*net.UDPAddr).Port},
+		{Host: "127.0.0.1", Port: deadPort},
+	}
+	sweeper := NewSweeper(cli, WithSweepConcurrency(2), WithSweepTimeout(300*time.Millisecond))
+	report := sweeper.Sweep(context.Background(), targets)
+	require.Len(t, report, 2)
+
+	alive := report[0]
+	require.NoError(t, alive.Err)
+	assert.Equal(t, sip.StatusOK, alive.StatusCode)
+	assert.Equal(t, "TestPBX/1.0", alive.UserAgent)
+	assert.Equal(t, []string{"INVITE", "ACK", "BYE", "OPTIONS"}, alive.Allow)
+	assert.Greater(t, alive.Latency, time.Duration(0))
+
+	assert.Error(t, report[1].Err)
+	assert.Equal(t, targets[1], report[1].Target)
+
+	require.Len(t, report.Reachable(), 1)
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, report.Write(buf))
+	assert.Contains(t, buf.String(), "TestPBX/1.0")
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		report := sweeper.Sweep(ctx, targets)
+		for _, r := range report {
+			assert.ErrorIs(t, r.Err, context.Canceled)
+		}
+	})
+}
+
+func TestSweepRange(t *testing.T) {
+	targets, err := SweepRange("10.1.0.5/30", 5060)
+	require.NoError(t, err)
+	require.Len(t, targets, 4)
+	assert.Equal(t, "sip:10.1.0.4:5060", targets[0].String())
+	assert.Equal(t, "sip:10.1.0.7:5060", targets[3].String())
+
+	targets, err = SweepRange("2001:db8::/126", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "sip:[2001:db8::3]", targets[3].String())
+
+	_, err = SweepRange("10.0.0.0/8", 5060)
+	require.Error(t, err)
+	_, err = SweepRange("10.0.0.0", 5060)
+	require.Error(t, err)
+}
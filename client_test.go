@@ -1,9 +1,13 @@
 package sipgo
 
 import (
+	"context"
+	"errors"
+	"net"
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/emiago/sipgo/sip"
 	"github.com/stretchr/testify/assert"
@@ -200,3 +204,220 @@ func TestClientRequestOptions(t *testing.T) {
 	conn, err := tp.ClientRequestConnection(context.TODO(), req)
 }
 */
+
+func TestClientRequestAddViaMulticast(t *testing.T) {
+	ua, err := NewUA()
+	require.Nil(t, err)
+
+	c, err := NewClient(ua, WithClientHostname("10.0.0.0"))
+	require.Nil(t, err)
+
+	req := sip.NewRequest(sip.REGISTER, &sip.Uri{Host: sip.MulticastHost})
+	req.SetTransport("UDP")
+	err = ClientRequestAddVia(c, req)
+	require.Nil(t, err)
+
+	via := req.Via()
+	maddr, _ := via.Params.Get("maddr")
+	ttl, _ := via.Params.Get("ttl")
+	assert.Equal(t, sip.MulticastHost, maddr)
+	assert.Equal(t, "1", ttl)
+}
+
+func TestClientRequestRoute(t *testing.T) {
+	ua, err := NewUA()
+	require.NoError(t, err)
+	defer ua.Close()
+
+	c, err := NewClient(ua, WithClientHostname("10.0.0.0"))
+	require.NoError(t, err)
+
+	sender := sip.Uri{User: "alice", Host: "10.1.1.1", Port: 5060}
+	recipient := sip.Uri{User: "bob", Host: "10.2.2.2", Port: 5060}
+
+	// Loose routing. Our route is removed and request goes to next route
+	ack := createSimpleRequest(sip.ACK, sender, recipient, "UDP")
+	ack.AppendHeader(&sip.RouteHeader{Address: sip.Uri{Host: "10.0.0.0", UriParams: sip.HeaderParams{"lr": "", "transport": "udp"}}})
+	ack.AppendHeader(&sip.RouteHeader{Address: sip.Uri{Host: "10.3.3.3", Port: 5070, UriParams: sip.HeaderParams{"lr": "", "transport": "tcp"}}})
+	require.NoError(t, ClientRequestRoute(c, ack))
+	routes := ack.GetHeaders("Route")
+	require.Len(t, routes, 1)
+	assert.Equal(t, "10.3.3.3", ack.Route().Address.Host)
+	assert.Equal(t, "TCP", ack.Transport())
+	assert.Equal(t, "10.3.3.3:5070", ack.Destination())
+
+	// No route left, request goes to Request-URI
+	ack = createSimpleRequest(sip.ACK, sender, recipient, "UDP")
+	ack.AppendHeader(&sip.RouteHeader{Address: sip.Uri{Host: "10.0.0.0", UriParams: sip.HeaderParams{"lr": ""}}})
+	require.NoError(t, ClientRequestRoute(c, ack))
+	assert.Nil(t, ack.Route())
+	assert.Equal(t, "10.2.2.2:5060", ack.Destination())
+
+	// Strict router before us placed our uri in Request-URI
+	ack = createSimpleRequest(sip.ACK, sender, sip.Uri{Host: "10.0.0.0"}, "UDP")
+	ack.AppendHeader(&sip.RouteHeader{Address: sip.Uri{Host: "10.3.3.3", UriParams: sip.HeaderParams{"lr": ""}}})
+	ack.AppendHeader(&sip.RouteHeader{Address: recipient})
+	require.NoError(t, ClientRequestRoute(c, ack))
+	assert.Equal(t, "sip:bob@10.2.2.2:5060", ack.Recipient.String())
+	require.Len(t, ack.GetHeaders("Route"), 1)
+	assert.Equal(t, "10.3.3.3:5060", ack.Destination())
+}
+
+func TestClientRequestAddDoubleRecordRoute(t *testing.T) {
+	ua, err := NewUA()
+	require.NoError(t, err)
+	defer ua.Close()
+
+	c, err := NewClient(ua, WithClientHostname("10.0.0.0"))
+	require.NoError(t, err)
+
+	sender := sip.Uri{User: "alice", Host: "10.1.1.1", Port: 5060}
+	recipient := sip.Uri{User: "bob", Host: "10.2.2.2", Port: 5060}
+
+	// Same transport, single Record-Route
+	req := createSimpleRequest(sip.INVITE, sender, recipient, "UDP")
+	req.SetReceivedTransport("UDP")
+	require.NoError(t, ClientRequestAddRecordRoute(c, req))
+	require.Len(t, req.GetHeaders("Record-Route"), 1)
+
+	// Received on UDP and forwarded on TCP
+	req = createSimpleRequest(sip.INVITE, sender, recipient, "UDP")
+	req.SetReceivedTransport("UDP")
+	req.SetTransport("TCP")
+	require.NoError(t, ClientRequestAddRecordRoute(c, req))
+	rrs := req.GetHeaders("Record-Route")
+	require.Len(t, rrs, 2)
+	assert.Equal(t, "tcp", rrs[0].(*sip.RecordRouteHeader).Address.UriParams["transport"])
+	assert.Equal(t, "udp", rrs[1].(*sip.RecordRouteHeader).Address.UriParams["transport"])
+
+	// Request following route set strips both our routes
+	ack := createSimpleRequest(sip.ACK, sender, recipient, "UDP")
+	for _, h := range rrs {
+		rr := h.(*sip.RecordRouteHeader)
+		ack.AppendHeader(&sip.RouteHeader{Address: rr.Address})
+	}
+	require.NoError(t, ClientRequestRoute(c, ack))
+	assert.Nil(t, ack.Route())
+	assert.Equal(t, "10.2.2.2:5060", ack.Destination())
+}
+
+func TestClientRequestBuildIDs(t *testing.T) {
+	ua, err := NewUA()
+	require.NoError(t, err)
+	defer ua.Close()
+
+	recipient := sip.Uri{User: "bob", Host: "10.2.2.2", Port: 5060}
+
+	c, err := NewClient(ua, WithClientHostname("10.0.0.0"))
+	require.NoError(t, err)
+	req := sip.NewRequest(sip.OPTIONS, &recipient)
+	require.NoError(t, clientRequestBuildReq(c, req))
+	assert.NotContains(t, req.CallID().Value(), "@")
+	assert.Len(t, req.From().Params["tag"], 16)
+	assert.Equal(t, uint32(1), req.CSeq().SeqNo)
+
+	c, err = NewClient(ua,
+		WithClientHostname("10.0.0.0"),
+		WithClientCallIDGenerator(func() (string, error) { return "a84b4c76e66710", nil }),
+		WithClientCallIDHost(),
+		WithClientTagGenerator(func() (string, error) { return "1928301774", nil }),
+		WithClientCSeqStart(314159),
+	)
+	require.NoError(t, err)
+	req = sip.NewRequest(sip.OPTIONS, &recipient)
+	require.NoError(t, clientRequestBuildReq(c, req))
+	assert.Equal(t, "a84b4c76e66710@10.0.0.0", req.CallID().Value())
+	assert.Equal(t, "1928301774", req.From().Params["tag"])
+	assert.Equal(t, "314159 OPTIONS", req.CSeq().Value())
+
+	c, err = NewClient(ua, WithClientCSeqRandom())
+	require.NoError(t, err)
+	req = sip.NewRequest(sip.OPTIONS, &recipient)
+	require.NoError(t, clientRequestBuildReq(c, req))
+	assert.Less(t, req.CSeq().SeqNo, uint32(1<<31))
+	assert.Greater(t, req.CSeq().SeqNo, uint32(0))
+
+	// Generator error fails request
+	c, err = NewClient(ua, WithClientCallIDGenerator(func() (string, error) { return "", errors.New("no id") }))
+	require.NoError(t, err)
+	_, err = c.TransactionRequest(context.Background(), sip.NewRequest(sip.OPTIONS, &recipient))
+	require.Error(t, err)
+}
+
+func TestClientOutboundProxy(t *testing.T) {
+	ua, err := NewUA()
+	require.NoError(t, err)
+	defer ua.Close()
+
+	recipient := sip.Uri{User: "bob", Host: "example.com"}
+
+	c, err := NewClient(ua, WithClientHostname("10.0.0.1"), WithClientOutboundProxy("sip:sbc.example.com;transport=tcp", OutboundProxyRoute))
+	require.NoError(t, err)
+	req := sip.NewRequest(sip.INVITE, recipient.Clone())
+	require.NoError(t, clientRequestBuildReq(c, req))
+	route := req.Route().Address
+	assert.Equal(t, "sbc.example.com", route.Host)
+	assert.True(t, route.UriParams.Has("lr"))
+	tp, _ := route.UriParams.Get("transport")
+	assert.Equal(t, "tcp", tp)
+	assert.Equal(t, "TCP", req.Via().Transport)
+	assert.Equal(t, "sbc.example.com:5060", req.Destination())
+	assert.Equal(t, "sip:bob@example.com", req.Recipient.String())
+
+	// Recorded route set is kept
+	req = sip.NewRequest(sip.BYE, recipient.Clone())
+	req.AppendHeader(&sip.RouteHeader{Address: sip.Uri{Host: "proxy.example.com", UriParams: sip.HeaderParams{"lr": ""}}})
+	require.NoError(t, clientRequestBuildReq(c, req))
+	require.Len(t, req.GetHeaders("Route"), 1)
+	assert.Equal(t, "proxy.example.com:5060", req.Destination())
+
+	c, err = NewClient(ua, WithClientHostname("10.0.0.1"), WithClientOutboundProxy("sip:10.1.1.1:5080;transport=tcp", OutboundProxyDestination))
+	require.NoError(t, err)
+	req = sip.NewRequest(sip.INVITE, recipient.Clone())
+	require.NoError(t, clientRequestBuildReq(c, req))
+	assert.Nil(t, req.Route())
+	assert.Equal(t, "TCP", req.Via().Transport)
+	assert.Equal(t, "10.1.1.1:5080", req.Destination())
+
+	_, err = NewClient(ua, WithClientOutboundProxy("tel:+15551234", OutboundProxyRoute))
+	require.Error(t, err)
+
+	// Per request proxy
+	c, err = NewClient(ua, WithClientHostname("10.0.0.1"))
+	require.NoError(t, err)
+	req = sip.NewRequest(sip.OPTIONS, recipient.Clone())
+	opt := ClientRequestOutboundProxy(sip.Uri{Host: "sbc.example.com", Port: 5070}, OutboundProxyRoute)
+	require.NoError(t, opt(c, req))
+	require.NoError(t, ClientRequestBuild(c, req))
+	assert.Equal(t, "<sip:sbc.example.com:5070;lr>", req.Route().Value())
+	assert.Equal(t, "sbc.example.com:5070", req.Destination())
+
+	t.Run("Send", func(t *testing.T) {
+		srv, err := NewServer(ua)
+		require.NoError(t, err)
+		received := make(chan *sip.Request, 1)
+		srv.OnOptions(func(req *sip.Request, tx sip.ServerTransaction) {
+			received <- req
+			tx.Respond(sip.NewResponseFromRequest(req, sip.StatusOK, "OK", nil))
+		})
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		go srv.ServeUDP(conn)
+
+		c, err := NewClient(ua, WithClientHostname("127.0.0.1"), WithClientOutboundProxy("sip:"+conn.LocalAddr().String(), OutboundProxyRoute))
+		require.NoError(t, err)
+		tx, err := c.TransactionRequest(context.Background(), sip.NewRequest(sip.OPTIONS, &sip.Uri{User: "bob", Host: "example.invalid"}))
+		require.NoError(t, err)
+		defer tx.Terminate()
+
+		select {
+		case res := <-tx.Responses():
+			assert.Equal(t, sip.StatusOK, res.StatusCode)
+		case <-time.After(2 * time.Second):
+			t.Fatal("no response from proxy")
+		}
+		req := <-received
+		assert.Equal(t, "sip:bob@example.invalid", req.Recipient.String())
+		assert.Equal(t, "<sip:"+conn.LocalAddr().String()+";lr>", req.Route().Value())
+	})
+}
@@ -0,0 +1,24 @@
+package sipgo
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/emiago/sipgo/siptest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoldenCorpus(t *testing.T) {
+	siptest.RunCorpus(t, "testdata/golden")
+}
+
+func TestGoldenCorpusRoundTripFails(t *testing.T) {
+	data, err := os.ReadFile("testdata/golden/asterisk_invite.sip")
+	require.NoError(t, err)
+
+	// Body shorter than Content-Length
+	broken := strings.TrimSuffix(string(data), "a=sendrecv\r\n")
+	_, err = siptest.RoundTrip([]byte(broken))
+	require.Error(t, err)
+}
@@ -2,6 +2,8 @@ package sipgo
 
 import (
 	"errors"
+	"strings"
+	"sync"
 	"sync/atomic"
 
 	"github.com/emiago/sipgo/sip"
@@ -22,6 +24,27 @@ type Dialog struct {
 
 	state   atomic.Int32
 	stateCh chan sip.DialogState
+	// onState is callback of dialog client or server for state changes
+	onState     DialogStateFunc
+	endReported atomic.Bool
+
+	// lastCSeqNo is CSeq number of last request sent within dialog
+	lastCSeqNo atomic.Uint32
+	// notifies are NOTIFY requests received within dialog. Used for tracking REFER progress
+	notifies chan *sip.Request
+	// onDTMF is callback for DTMF received in INFO
+	onDTMF atomic.Pointer[func(dtmf sip.DTMF)]
+
+	// requestHeaders are custom headers per method appended on requests generated within dialog
+	requestHeaders   map[sip.RequestMethod][]sip.Header
+	requestHeadersMu sync.Mutex
+
+	// offerAnswer tracks SDP offer/answer exchange
+	offerAnswer OfferAnswer
+
+	// slot is taken call slot in case user agent has call limit
+	slot     *callSlots
+	slotOnce sync.Once
 
 	done chan struct{}
 }
@@ -41,12 +64,94 @@ func (d *Dialog) setState(s sip.DialogState) {
 	case d.stateCh <- s:
 	default:
 	}
+	d.reportState(s)
 
 	if s == sip.DialogStateEnded {
 		close(d.done) // Broadcasting done
 	}
 }
 
+// releaseSlot frees call slot taken by dialog. Safe to call multiple times
+func (d *Dialog) releaseSlot() {
+	if d.slot == nil {
+		return
+	}
+	d.slotOnce.Do(d.slot.release)
+}
+
+// passNotify delivers NOTIFY to whoever is waiting on it, like ongoing transfer.
+// It does not block as NOTIFY can be received without any subscription waiting
+func (d *Dialog) passNotify(req *sip.Request) {
+	select {
+	case d.notifies <- req:
+	default:
+	}
+}
+
+// drainNotifies drops NOTIFY requests not consumed so far
+func (d *Dialog) drainNotifies() {
+	for {
+		select {
+		case <-d.notifies:
+		default:
+			return
+		}
+	}
+}
+
+// SetRequestHeaders sets custom headers appended on every request of method generated within dialog.
+// Ex. custom headers on BYE. Headers set for INVITE are used on re-INVITE.
+// Calling without headers removes them
+func (d *Dialog) SetRequestHeaders(method sip.RequestMethod, headers ...sip.Header) {
+	d.requestHeadersMu.Lock()
+	defer d.requestHeadersMu.Unlock()
+	if d.requestHeaders == nil {
+		d.requestHeaders = make(map[sip.RequestMethod][]sip.Header)
+	}
+	if len(headers) == 0 {
+		delete(d.requestHeaders, method)
+		return
+	}
+	d.requestHeaders[method] = headers
+}
+
+// PreserveInviteHeaders keeps headers with names from initial INVITE, so they are sent on re-INVITE.
+// Ex. X-CID header
+func (d *Dialog) PreserveInviteHeaders(names ...string) {
+	d.requestHeadersMu.Lock()
+	defer d.requestHeadersMu.Unlock()
+	if d.requestHeaders == nil {
+		d.requestHeaders = make(map[sip.RequestMethod][]sip.Header)
+	}
+	for _, name := range names {
+		for _, h := range d.InviteRequest.GetHeaders(name) {
+			d.requestHeaders[sip.INVITE] = append(d.requestHeaders[sip.INVITE], sip.HeaderClone(h))
+		}
+	}
+}
+
+// appendRequestHeaders appends custom headers set for request method
+func (d *Dialog) appendRequestHeaders(req *sip.Request) {
+	d.requestHeadersMu.Lock()
+	defer d.requestHeadersMu.Unlock()
+	for _, h := range d.requestHeaders[req.Method] {
+		req.AppendHeader(sip.HeaderClone(h))
+	}
+}
+
+// CustomHeaders returns extension headers prefixed with X- from message.
+// It can be used for reading peer custom headers on INVITE, response or BYE
+func CustomHeaders(msg interface{ Headers() []sip.Header }) []sip.Header {
+	var hdrs []sip.Header
+	for _, h := range msg.Headers() {
+		name := h.Name()
+		if len(name) > 2 && strings.EqualFold(name[:2], "x-") {
+			hdrs = append(hdrs, h)
+		}
+	}
+	return hdrs
+}
+
 func (d *Dialog) State() <-chan sip.DialogState {
 	return d.stateCh
 }
@@ -0,0 +1,153 @@
+package sipgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+var (
+	// ErrThirdPartyNoOffer is returned when party answered INVITE without offer with 2xx without offer
+	ErrThirdPartyNoOffer = errors.New("3pcc: offer missing in 2xx")
+)
+
+// ThirdPartyCall is call between two parties set up by controller. Controller stays in signaling path,
+// while media flows directly between parties
+// https://datatracker.ietf.org/doc/html/rfc3725
+type ThirdPartyCall struct {
+	// A is dialog with party called first, ex. user clicking to dial
+	A *DialogClientSession
+	// B is dialog with party A is connected to
+	B *DialogClientSession
+}
+
+// Bye terminates both dialogs
+func (c *ThirdPartyCall) Bye(ctx context.Context) error {
+	return errors.Join(c.A.Bye(ctx), c.B.Bye(ctx))
+}
+
+// ThirdPartyLeg is dialog session that can be bridged by controller.
+// Both DialogClientSession and DialogServerSession implement it
+type ThirdPartyLeg interface {
+	thirdPartyLeg() (*Client, *Dialog, func(method sip.RequestMethod, body []byte) *sip.Request)
+}
+
+func (s *DialogClientSession) thirdPartyLeg() (*Client, *Dialog, func(method sip.RequestMethod, body []byte) *sip.Request) {
+	return s.dc.c, &s.Dialog, s.newRequest
+}
+
+func (s *DialogServerSession) thirdPartyLeg() (*Client, *Dialog, func(method sip.RequestMethod, body []byte) *sip.Request) {
+	return s.s.c, &s.Dialog, s.newRequest
+}
+
+// ThirdPartyCall connects parties a and b, for ex. for click to dial. Flow I of RFC 3725 is used:
+// A is invited without offer, offer from A 2xx is sent in INVITE to B, and answer from B is sent in ACK to A.
+// A is waiting ACK while B is alerted, so B should answer before A 2xx retransmissions time out (32s).
+// In case B fails, A is terminated with BYE and returned error is ErrDialogResponse of B
+// https://datatracker.ietf.org/doc/html/rfc3725#section-4.1
+func (dc *DialogClient) ThirdPartyCall(ctx context.Context, a sip.Uri, b sip.Uri, headers ...sip.Header) (*ThirdPartyCall, error) {
+	sessA, err := dc.Invite(ctx, &a, nil, headers...)
+	if err != nil {
+		return nil, err
+	}
+	if err := sessA.WaitAnswer(ctx, AnswerOptions{DeferAck: true}); err != nil {
+		sessA.Close()
+		return nil, err
+	}
+
+	offer := sessA.InviteResponse.Body()
+	if len(offer) == 0 {
+		thirdPartyAbort(sessA)
+		return nil, ErrThirdPartyNoOffer
+	}
+
+	bheaders := append([]sip.Header{sip.NewHeader("Content-Type", "application/sdp")}, headers...)
+	sessB, err := dc.Invite(ctx, &b, offer, bheaders...)
+	if err != nil {
+		thirdPartyAbort(sessA)
+		return nil, err
+	}
+	if err := sessB.WaitAnswer(ctx, AnswerOptions{}); err != nil {
+		sessB.Close()
+		thirdPartyAbort(sessA)
+		return nil, err
+	}
+
+	ack := sip.NewAckRequest(sessA.InviteRequest, sessA.InviteResponse, sessB.InviteResponse.Body())
+	ack.AppendHeader(sip.NewHeader("Content-Type", "application/sdp"))
+	if err := sessA.WriteAck(ctx, ack); err != nil {
+		byeCtx, cancel := context.WithTimeout(context.Background(), sip.GetTimers().Timer_B)
+		defer cancel()
+		sessB.Bye(byeCtx)
+		sessA.Close()
+		return nil, err
+	}
+	return &ThirdPartyCall{A: sessA, B: sessB}, nil
+}
+
+// thirdPartyAbort acknowledges and terminates dialog which offer can not be answered
+// https://datatracker.ietf.org/doc/html/rfc3725#section-4.1
+func thirdPartyAbort(sess *DialogClientSession) {
+	ctx, cancel := context.WithTimeout(context.Background(), sip.GetTimers().Timer_B)
+	defer cancel()
+	if err := sess.Ack(ctx); err != nil {
+		sess.Close()
+		return
+	}
+	sess.Bye(ctx)
+}
+
+// ThirdPartyBridge connects media of two established dialogs, ex. after call is picked from hold or parked.
+// Re-INVITE without offer is sent to a, its offer is sent in re-INVITE to b and answer from b is sent in ACK to a
+// https://datatracker.ietf.org/doc/html/rfc3725#section-5
+func ThirdPartyBridge(ctx context.Context, a ThirdPartyLeg, b ThirdPartyLeg) error {
+	c, d, newRequest := a.thirdPartyLeg()
+	state := sip.DialogState(d.state.Load())
+	if state != sip.DialogStateConfirmed {
+		return fmt.Errorf("Dialog not confirmed. ACK not send?")
+	}
+
+	// ACK carries answer from b, so it is not sent with 2xx
+	req := newRequest(sip.INVITE, nil)
+	res, err := dialogTransaction(ctx, c, d, req)
+	if err != nil {
+		return err
+	}
+	if !res.IsSuccess() {
+		return &ErrDialogResponse{Res: res}
+	}
+
+	offer := res.Body()
+	var answer []byte
+	var bridgeErr error
+	if len(offer) == 0 {
+		bridgeErr = ErrThirdPartyNoOffer
+	} else {
+		bc, bd, bNewRequest := b.thirdPartyLeg()
+		_, callIDOwner := b.(*DialogClientSession)
+		bres, err := dialogModify(ctx, bc, bd, bNewRequest, sip.INVITE, offer, callIDOwner)
+		if err != nil {
+			bridgeErr = err
+		} else {
+			answer = bres.Body()
+		}
+	}
+
+	// 2xx must be acknowledged in any case. Without answer session of a stays as before
+	// https://datatracker.ietf.org/doc/html/rfc3725#section-5
+	if answer == nil {
+		answer = d.offerAnswer.LocalDescription()
+	}
+	ack := sip.NewAckRequest(req, res, answer)
+	ack.ReplaceHeaders("Route", req.GetHeaders("Route")...)
+	if answer != nil {
+		ack.AppendHeader(sip.NewHeader("Content-Type", "application/sdp"))
+	}
+	d.trackOfferAnswer(ack, true)
+	if err := c.WriteRequest(ack); err != nil {
+		return errors.Join(bridgeErr, err)
+	}
+	return bridgeErr
+}
@@ -0,0 +1,90 @@
+package sipgo
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testThirdParty starts UAS answering INVITE without body with offer and INVITE with offer with answer.
+// Received INVITE and ACK bodies are passed to channel
+func testThirdPartyUAS(t *testing.T, name string) (sip.Uri, <-chan string) {
+	ua, err := NewUA(WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	t.Cleanup(func() { ua.Close() })
+	srv, err := NewServer(ua)
+	require.NoError(t, err)
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	uri := sip.Uri{User: name, Host: "127.0.0.1", Port: conn.LocalAddr().(*net.UDPAddr).Port}
+
+	bodies := make(chan string, 10)
+	offers := 0
+	srv.OnInvite(func(req *sip.Request, tx sip.ServerTransaction) {
+		bodies <- "INVITE " + string(req.Body())
+		body := "answer-" + name
+		if len(req.Body()) == 0 {
+			offers++
+			body = "offer-" + name + "-" + strconv.Itoa(offers)
+		}
+		tx.RespondWith(sip.StatusOK, "OK", []byte(body), sip.NewHeader("Content-Type", "application/sdp"), &sip.ContactHeader{Address: uri})
+	})
+	srv.OnAck(func(req *sip.Request, tx sip.ServerTransaction) {
+		bodies <- "ACK " + string(req.Body())
+	})
+	srv.OnBye(func(req *sip.Request, tx sip.ServerTransaction) {
+		tx.RespondWith(sip.StatusOK, "OK", nil)
+	})
+	go srv.ServeUDP(conn)
+	return uri, bodies
+}
+
+func testReceive(t *testing.T, ch <-chan string) string {
+	t.Helper()
+	select {
+	case s := <-ch:
+		return s
+	case <-time.After(2 * time.Second):
+		t.Fatal("nothing received")
+	}
+	return ""
+}
+
+func TestThirdPartyCall(t *testing.T) {
+	uriA, bodiesA := testThirdPartyUAS(t, "alice")
+	uriB, bodiesB := testThirdPartyUAS(t, "bob")
+
+	ua, err := NewUA(WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer ua.Close()
+	cli, err := NewClient(ua, WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+	dc := NewDialogClient(cli, sip.ContactHeader{Address: sip.Uri{User: "controller", Host: "127.0.0.1", Port: 5060}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	call, err := dc.ThirdPartyCall(ctx, uriA, uriB)
+	require.NoError(t, err)
+
+	assert.Equal(t, "INVITE ", testReceive(t, bodiesA))
+	assert.Equal(t, "INVITE offer-alice-1", testReceive(t, bodiesB))
+	assert.Equal(t, "ACK ", testReceive(t, bodiesB))
+	assert.Equal(t, "ACK answer-bob", testReceive(t, bodiesA))
+
+	// Bridging again with re-INVITE
+	require.NoError(t, ThirdPartyBridge(ctx, call.A, call.B))
+	assert.Equal(t, "INVITE ", testReceive(t, bodiesA))
+	assert.Equal(t, "INVITE offer-alice-2", testReceive(t, bodiesB))
+	assert.Equal(t, "ACK ", testReceive(t, bodiesB))
+	assert.Equal(t, "ACK answer-bob", testReceive(t, bodiesA))
+	assert.Equal(t, []byte("answer-bob"), call.A.OfferAnswer().LocalDescription())
+
+	require.NoError(t, call.Bye(ctx))
+}
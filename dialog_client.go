@@ -2,7 +2,6 @@ package sipgo
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -15,6 +14,7 @@ type DialogClient struct {
 	c          *Client
 	dialogs    sync.Map // TODO replace with typed version
 	contactHDR sip.ContactHeader
+	onState    DialogStateFunc
 }
 
 func (s *DialogClient) dialogsLen() int {
@@ -74,11 +74,20 @@ func (dc *DialogClient) Invite(ctx context.Context, recipient *sip.Uri, body []b
 func (dc *DialogClient) WriteInvite(ctx context.Context, inviteRequest *sip.Request) (*DialogClientSession, error) {
 	cli := dc.c
 
-	inviteRequest.AppendHeader(&dc.contactHDR)
+	if slots := cli.callSlots; slots != nil {
+		if err := slots.acquire(ctx.Done(), inviteRequest); err != nil {
+			return nil, err
+		}
+	}
+
+	inviteRequest.AppendHeader(sipsContact(inviteRequest, &dc.contactHDR))
 
 	// TODO passing client transaction options is now hidden
 	tx, err := cli.TransactionRequest(ctx, inviteRequest)
 	if err != nil {
+		if cli.callSlots != nil {
+			cli.callSlots.release()
+		}
 		return nil, err
 	}
 
@@ -87,11 +96,15 @@ func (dc *DialogClient) WriteInvite(ctx context.Context, inviteRequest *sip.Requ
 			InviteRequest: inviteRequest,
 			state:         atomic.Int32{},
 			stateCh:       make(chan sip.DialogState, 3),
+			onState:       dc.onState,
+			notifies:      make(chan *sip.Request, 5),
+			slot:          cli.callSlots,
 			done:          make(chan struct{}),
 		},
 		dc:       dc,
 		inviteTx: tx,
 	}
+	dtx.trackOfferAnswer(inviteRequest, true)
 
 	return dtx, nil
 }
@@ -124,16 +137,47 @@ func (dc *DialogClient) ReadBye(req *sip.Request, tx sip.ServerTransaction) erro
 	return nil
 }
 
+// ReadNotify should read from your OnNotify handler.
+// NOTIFY within dialog is responded with 200 and passed to dialog session, for ex. to track transfer progress
+func (dc *DialogClient) ReadNotify(req *sip.Request, tx sip.ServerTransaction) error {
+	callid := req.CallID()
+	from := req.From()
+	to := req.To()
+	if callid == nil || from == nil || to == nil {
+		return ErrDialogOutsideDialog
+	}
+
+	id := sip.MakeDialogID(callid.Value(), from.Params["tag"], to.Params["tag"])
+
+	dt := dc.loadDialog(id)
+	if dt == nil {
+		return fmt.Errorf("callid=%q: %w", callid.Value(), ErrDialogDoesNotExists)
+	}
+
+	res := sip.NewResponseFromRequest(req, 200, "OK", nil)
+	if err := tx.Respond(res); err != nil {
+		return err
+	}
+
+	dt.passNotify(req)
+	return nil
+}
+
 type DialogClientSession struct {
 	Dialog
 	dc       *DialogClient
 	inviteTx sip.ClientTransaction
+
+	// lastAck is resent on 2xx retransmissions
+	lastAck atomic.Pointer[sip.Request]
 }
 
 // Close must be always called in order to cleanup some internal resources
 // Consider that this will not send BYE or CANCEL or change dialog state
 func (s *DialogClientSession) Close() error {
-	s.dc.dialogs.Delete(s.ID)
+	s.deleteDialog()
+	s.releaseSlot()
+	s.reportClosed()
 	// s.setState(sip.DialogStateEnded)
 	// ctx, _ := context.WithTimeout(context.Background(), sip.Timer_B)
 	// return s.Bye(ctx)
@@ -143,38 +187,55 @@ func (s *DialogClientSession) Close() error {
 type AnswerOptions struct {
 	OnResponse func(res *sip.Response)
 
+	// DeferAck leaves sending ACK for 2xx to caller with Ack or WriteAck, for applications that need to attach
+	// late SDP answer to ACK for INVITE without offer. By default ACK is sent along route set as soon as 2xx is received
+	// https://datatracker.ietf.org/doc/html/rfc3261#section-13.2.2.4
+	DeferAck bool
+
+	// OnFork is called with separate dialog session for every other 2xx with different To tag, when INVITE is forked.
+	// Session is already acknowledged. When not set, these dialogs are acknowledged and terminated with BYE
+	// https://datatracker.ietf.org/doc/html/rfc3261#section-13.2.2.4
+	OnFork func(sess *DialogClientSession)
+
 	// For digest authentication
 	Username string
 	Password string
 }
 
 // WaitAnswer waits for success response or returns ErrDialogResponse in case non 2xx
-// Canceling context while waiting 2xx will send Cancel request
+// Canceling context while waiting 2xx returns context error right away and sends Cancel request in background
+// Provisional response with To tag creates early dialog, in which UPDATE and PRACK can be sent.
 // Returns ErrDialogResponse in case non 2xx response
-func (s *DialogClientSession) WaitAnswer(ctx context.Context, opts AnswerOptions) error {
+func (s *DialogClientSession) WaitAnswer(ctx context.Context, opts AnswerOptions) (err error) {
 	client, tx, inviteRequest := s.dc.c, s.inviteTx, s.InviteRequest
+	defer func() {
+		// Early dialog is terminated with non 2xx
+		if err != nil && sip.DialogState(s.state.Load()) == sip.DialogStateEarly {
+			s.deleteDialog()
+		}
+	}()
 
 	var r *sip.Response
-	var err error
 	for {
 		select {
 		case r = <-tx.Responses():
 			// just pass
 		case <-ctx.Done():
-			// Send cancel
-			defer tx.Terminate()
-			if err := tx.Cancel(); err != nil {
-				return errors.Join(err, ctx.Err())
-			}
+			// Send cancel in background, as it waits provisional response and INVITE final response
+			go s.cancelInvite(tx)
 			return ctx.Err()
 
 		case <-tx.Done():
 			return tx.Err()
 		}
 
+		if r.IsProvisional() {
+			s.earlyDialog(r)
+		}
 		if opts.OnResponse != nil {
 			opts.OnResponse(r)
 		}
+		s.trackOfferAnswer(r, false)
 
 		if r.IsSuccess() {
 			break
@@ -191,12 +252,14 @@ func (s *DialogClientSession) WaitAnswer(ctx context.Context, opts AnswerOptions
 				tx, err = digestProxyAuthRequest(ctx, client, inviteRequest, r, digest.Options{
 					Method:   sip.INVITE.String(),
 					URI:      inviteRequest.Recipient.Addr(),
+					GetBody:  digestBody(inviteRequest.Body()),
 					Username: opts.Username,
 					Password: opts.Password,
 				})
 				if err != nil {
 					return err
 				}
+				s.trackOfferAnswer(inviteRequest, true)
 				continue
 			}
 		}
@@ -208,12 +271,14 @@ func (s *DialogClientSession) WaitAnswer(ctx context.Context, opts AnswerOptions
 				tx, err = digestTransactionRequest(ctx, client, inviteRequest, r, digest.Options{
 					Method:   sip.INVITE.String(),
 					URI:      inviteRequest.Recipient.Addr(),
+					GetBody:  digestBody(inviteRequest.Body()),
 					Username: opts.Username,
 					Password: opts.Password,
 				})
 				if err != nil {
 					return err
 				}
+				s.trackOfferAnswer(inviteRequest, true)
 				continue
 			}
 		}
@@ -227,34 +292,204 @@ func (s *DialogClientSession) WaitAnswer(ctx context.Context, opts AnswerOptions
 	}
 	s.inviteTx = tx
 	s.InviteResponse = r
-	s.ID = id
+	s.initCSeqNo()
 	s.setState(sip.DialogStateEstablished)
-	s.dc.dialogs.Store(id, s)
+	s.storeDialog(id)
+
+	go s.ackRetransmissions(tx, opts.OnFork)
+	if !opts.DeferAck {
+		return s.Ack(ctx)
+	}
 	return nil
 }
 
-// Ack sends ack. Use WriteAck for more customizing
+// cancelInvite cancels INVITE transaction and terminates it once final response is received or Timer B fires
+func (s *DialogClientSession) cancelInvite(tx sip.ClientTransaction) {
+	defer tx.Terminate()
+	ctx, cancel := context.WithTimeout(context.Background(), sip.GetTimers().Timer_B)
+	defer cancel()
+	if _, err := tx.Cancel(ctx); err != nil {
+		s.dc.c.log.Info().Err(err).Msg("Failed to cancel INVITE")
+	}
+}
+
+// earlyDialog creates or updates early dialog on provisional response with To tag
+// https://datatracker.ietf.org/doc/html/rfc3261#section-12.1.2
+func (s *DialogClientSession) earlyDialog(res *sip.Response) {
+	if res.StatusCode == sip.StatusTrying {
+		return
+	}
+	if _, ok := res.To().Params.Get("tag"); !ok {
+		return
+	}
+	id, err := sip.MakeDialogIDFromResponse(res)
+	if err != nil {
+		return
+	}
+
+	s.InviteResponse = res
+	s.initCSeqNo()
+	s.storeDialog(id)
+	s.setState(sip.DialogStateEarly)
+}
+
+// initCSeqNo sets CSeq of requests within dialog to continue after INVITE, unless early requests are already sent
+func (s *DialogClientSession) initCSeqNo() {
+	if seq := s.InviteRequest.CSeq().SeqNo; s.lastCSeqNo.Load() < seq {
+		s.lastCSeqNo.Store(seq)
+	}
+}
+
+// storeDialog stores dialog under id, replacing early dialog with other To tag
+func (s *DialogClientSession) storeDialog(id string) {
+	if s.ID == id {
+		return
+	}
+	s.deleteDialog()
+	s.ID = id
+	s.dc.dialogs.Store(id, s)
+	s.dc.c.dialogs.Add(1)
+}
+
+func (s *DialogClientSession) deleteDialog() {
+	if _, loaded := s.dc.dialogs.LoadAndDelete(s.ID); loaded {
+		s.dc.c.dialogs.Add(-1)
+	}
+}
+
+// ackRetransmissions resends last ACK on 2xx retransmissions, as ACK for 2xx is not part of transaction
+// https://datatracker.ietf.org/doc/html/rfc3261#section-13.2.2.4
+// Other 2xx with different To tag are forks and create separate dialogs
+func (s *DialogClientSession) ackRetransmissions(tx sip.ClientTransaction, onFork func(sess *DialogClientSession)) {
+	toTag, _ := s.InviteResponse.To().Params.Get("tag")
+	forks := map[string]*DialogClientSession{}
+	for {
+		select {
+		case res := <-tx.Responses():
+			if !res.IsSuccess() {
+				continue
+			}
+
+			sess := s
+			if tag, _ := res.To().Params.Get("tag"); tag != toTag {
+				fork, exists := forks[tag]
+				if !exists {
+					fork = s.newForkSession(tx, res)
+					if fork == nil {
+						continue
+					}
+					forks[tag] = fork
+					go fork.answerFork(onFork)
+					continue
+				}
+				sess = fork
+			}
+
+			ack := sess.lastAck.Load()
+			if ack == nil {
+				continue
+			}
+			if err := s.dc.c.WriteRequest(ack); err != nil {
+				s.dc.c.log.Error().Err(err).Msg("Failed to resend ACK on 2xx retransmission")
+			}
+		case <-tx.Done():
+			return
+		}
+	}
+}
+
+// newForkSession creates dialog for 2xx of forked INVITE. It shares INVITE transaction, which is not terminated by fork
+func (s *DialogClientSession) newForkSession(tx sip.ClientTransaction, res *sip.Response) *DialogClientSession {
+	id, err := sip.MakeDialogIDFromResponse(res)
+	if err != nil {
+		s.dc.c.log.Info().Err(err).Msg("Failed to create dialog for forked 2xx")
+		return nil
+	}
+
+	fork := &DialogClientSession{
+		Dialog: Dialog{
+			ID:             id,
+			InviteRequest:  s.InviteRequest,
+			InviteResponse: res,
+			stateCh:        make(chan sip.DialogState, 3),
+			onState:        s.onState,
+			notifies:       make(chan *sip.Request, 5),
+			done:           make(chan struct{}),
+		},
+		dc:       s.dc,
+		inviteTx: forkInviteTx{tx},
+	}
+	fork.lastCSeqNo.Store(s.InviteRequest.CSeq().SeqNo)
+	fork.setState(sip.DialogStateEstablished)
+	fork.trackOfferAnswer(s.InviteRequest, true)
+	fork.trackOfferAnswer(res, false)
+	s.dc.dialogs.Store(id, fork)
+	s.dc.c.dialogs.Add(1)
+	return fork
+}
+
+// answerFork acknowledges forked 2xx and passes dialog to application or terminates it
+func (s *DialogClientSession) answerFork(onFork func(sess *DialogClientSession)) {
+	ctx, cancel := context.WithTimeout(context.Background(), sip.GetTimers().Timer_B)
+	defer cancel()
+	if err := s.Ack(ctx); err != nil {
+		s.dc.c.log.Error().Err(err).Msg("Failed to send ACK on forked 2xx")
+	}
+
+	if onFork != nil {
+		onFork(s)
+		return
+	}
+
+	if err := s.Bye(ctx); err != nil {
+		s.dc.c.log.Info().Err(err).Msg("Failed to terminate forked dialog")
+	}
+}
+
+// forkInviteTx is INVITE transaction shared with forked dialog. Terminating is left to main dialog
+type forkInviteTx struct {
+	sip.ClientTransaction
+}
+
+func (tx forkInviteTx) Terminate() {}
+
+// Ack sends ACK for 2xx. It does nothing if ACK is already sent, like by WaitAnswer.
+// Use WriteAck for more customizing
 func (s *DialogClientSession) Ack(ctx context.Context) error {
+	if s.lastAck.Load() != nil {
+		return nil
+	}
 	ack := sip.NewAckRequest(s.InviteRequest, s.InviteResponse, nil)
+	// Symmetric signaling. Peer Contact may not be reachable behind NAT
+	if s.dc.c.rport && len(s.InviteResponse.GetHeaders("Record-Route")) == 0 {
+		if src := s.InviteResponse.MessageData.Source(); src != "" {
+			ack.SetDestination(src)
+		}
+	}
+	s.appendRequestHeaders(ack)
 	return s.WriteAck(ctx, ack)
 }
 
 func (s *DialogClientSession) WriteAck(ctx context.Context, ack *sip.Request) error {
+	s.trackOfferAnswer(ack, true)
 	if err := s.dc.c.WriteRequest(ack); err != nil {
 		// Make sure we close our error
 		// s.Close()
 		return err
 	}
+	s.lastAck.Store(ack)
 	s.setState(sip.DialogStateConfirmed)
 	return nil
 }
 
 // Bye sends bye and terminates session. Use WriteBye if you want to customize bye request
+// CSeq continues after last request sent within dialog
 func (s *DialogClientSession) Bye(ctx context.Context) error {
-	bye := sip.NewByeRequestUAC(s.InviteRequest, s.InviteResponse, nil)
+	bye := s.newRequest(sip.BYE, nil)
 	return s.WriteBye(ctx, bye)
 }
 
+// WriteBye sends bye as is and terminates session. Bye CSeq is not changed
 func (s *DialogClientSession) WriteBye(ctx context.Context, bye *sip.Request) error {
 	dc := s.dc
 	defer s.Close()
@@ -270,7 +505,7 @@ func (s *DialogClientSession) WriteBye(ctx context.Context, bye *sip.Request) er
 		return fmt.Errorf("Dialog not confirmed. ACK not send?")
 	}
 
-	tx, err := dc.c.TransactionRequest(ctx, bye)
+	tx, err := dc.c.TransactionRequest(ctx, bye, ClientRequestBuild)
 	if err != nil {
 		return err
 	}
@@ -292,6 +527,43 @@ func (s *DialogClientSession) WriteBye(ctx context.Context, bye *sip.Request) er
 	}
 }
 
+// newRequest creates new request within dialog
+// https://datatracker.ietf.org/doc/html/rfc3261#section-12.2.1.1
+func (s *DialogClientSession) newRequest(method sip.RequestMethod, body []byte) *sip.Request {
+	inviteRequest, inviteResponse := s.InviteRequest, s.InviteResponse
+
+	recipient := inviteRequest.Recipient
+	if cont := inviteResponse.Contact(); cont != nil {
+		recipient = &cont.Address
+	}
+
+	req := sip.NewRequest(method, recipient.Clone())
+	// Route set is Record-Route of response in reverse order
+	sip.RouteSetApply(req, sip.RouteSetFromRecordRoute(inviteResponse, true))
+
+	if h := inviteRequest.From(); h != nil {
+		req.AppendHeader(sip.HeaderClone(h))
+	}
+
+	if h := inviteResponse.To(); h != nil {
+		req.AppendHeader(sip.HeaderClone(h))
+	}
+
+	if h := inviteRequest.CallID(); h != nil {
+		req.AppendHeader(sip.HeaderClone(h))
+	}
+
+	req.AppendHeader(&sip.CSeqHeader{
+		SeqNo:      s.lastCSeqNo.Add(1),
+		MethodName: method,
+	})
+	req.AppendHeader(sipsContact(inviteRequest, &s.dc.contactHDR))
+	s.appendRequestHeaders(req)
+	req.SetBody(body)
+	req.SetTransport(inviteRequest.Transport())
+	return req
+}
+
 func digestProxyAuthRequest(ctx context.Context, client *Client, req *sip.Request, res *sip.Response, opts digest.Options) (sip.ClientTransaction, error) {
 	authHeader := res.GetHeader("Proxy-Authenticate")
 	chal, err := digest.ParseChallenge(authHeader.Value())
@@ -0,0 +1,167 @@
+package sipgo
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialogClientBrokenUAS(t *testing.T) {
+	ua, err := NewUA(WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer ua.Close()
+	cli, err := NewClient(ua, WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+
+	dc := NewDialogClient(cli, sip.ContactHeader{Address: sip.Uri{User: "alice", Host: "127.0.0.1", Port: 5060}})
+
+	invite := func(t *testing.T, uas *siptest.BrokenUAS, timeout time.Duration) (*DialogClientSession, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		sess, err := dc.Invite(ctx, &sip.Uri{User: "bob", Host: "127.0.0.1", Port: uas.Port()}, nil)
+		require.NoError(t, err)
+		if err := sess.WaitAnswer(ctx, AnswerOptions{}); err != nil {
+			sess.Close()
+			return sess, err
+		}
+		return sess, sess.Ack(ctx)
+	}
+
+	t.Run("NoToTag", func(t *testing.T) {
+		uas, err := siptest.NewBrokenUAS("127.0.0.1:0", siptest.FaultNoToTag)
+		require.NoError(t, err)
+		defer uas.Close()
+
+		_, err = invite(t, uas, time.Second)
+		require.Error(t, err)
+	})
+
+	t.Run("Forked2xx", func(t *testing.T) {
+		uas, err := siptest.NewBrokenUAS("127.0.0.1:0", siptest.FaultForked2xx)
+		require.NoError(t, err)
+		defer uas.Close()
+
+		sess, err := invite(t, uas, time.Second)
+		require.NoError(t, err)
+		defer sess.Close()
+
+		// Dialog is established with first 2xx. Fork gets own dialog which is acked and terminated
+		toTag, _ := sess.InviteResponse.To().Params.Get("tag")
+		require.Eventually(t, func() bool { return len(uas.Requests(sip.BYE)) == 1 }, time.Second, 10*time.Millisecond)
+		acks := uas.Requests(sip.ACK)
+		require.Len(t, acks, 2)
+		forkTag, _ := uas.Requests(sip.BYE)[0].To().Params.Get("tag")
+		assert.NotEqual(t, toTag, forkTag)
+		tags := []string{}
+		for _, ack := range acks {
+			tag, _ := ack.To().Params.Get("tag")
+			tags = append(tags, tag)
+		}
+		assert.ElementsMatch(t, []string{toTag, forkTag}, tags)
+	})
+
+	t.Run("Forked2xxOnFork", func(t *testing.T) {
+		uas, err := siptest.NewBrokenUAS("127.0.0.1:0", siptest.FaultForked2xx)
+		require.NoError(t, err)
+		defer uas.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		sess, err := dc.Invite(ctx, &sip.Uri{User: "bob", Host: "127.0.0.1", Port: uas.Port()}, nil)
+		require.NoError(t, err)
+		defer sess.Close()
+
+		forks := make(chan *DialogClientSession, 1)
+		require.NoError(t, sess.WaitAnswer(ctx, AnswerOptions{OnFork: func(fork *DialogClientSession) { forks <- fork }}))
+
+		var fork *DialogClientSession
+		select {
+		case fork = <-forks:
+		case <-ctx.Done():
+			t.Fatal("fork not delivered")
+		}
+		assert.NotEqual(t, sess.ID, fork.ID)
+		require.Eventually(t, func() bool { return len(uas.Requests(sip.ACK)) == 2 }, time.Second, 10*time.Millisecond)
+
+		// Fork is independent dialog and ending it keeps main one
+		require.NoError(t, fork.Bye(ctx))
+		require.NoError(t, sess.Bye(ctx))
+		require.Eventually(t, func() bool { return len(uas.Requests(sip.BYE)) == 2 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("BadContentLength", func(t *testing.T) {
+		uas, err := siptest.NewBrokenUAS("127.0.0.1:0", siptest.FaultBadContentLength, siptest.WithBrokenUASBody([]byte("v=0\r\n")))
+		require.NoError(t, err)
+		defer uas.Close()
+
+		// Body is read by datagram size on UDP, wrong Content-Length is tolerated
+		sess, err := invite(t, uas, time.Second)
+		require.NoError(t, err)
+		defer sess.Close()
+		assert.Equal(t, "v=0\r\n", string(sess.Body()))
+	})
+
+	t.Run("Unackable2xx", func(t *testing.T) {
+		uas, err := siptest.NewBrokenUAS("127.0.0.1:0", siptest.FaultUnackable2xx, siptest.WithBrokenUASRetransmits(3, 20*time.Millisecond))
+		require.NoError(t, err)
+		defer uas.Close()
+
+		sess, err := invite(t, uas, time.Second)
+		require.NoError(t, err)
+		defer sess.Close()
+
+		// Every 2xx retransmission is acked again
+		require.Eventually(t, func() bool { return len(uas.Requests(sip.ACK)) >= 4 }, time.Second, 10*time.Millisecond)
+	})
+	t.Run("AutoAck", func(t *testing.T) {
+		uas, err := siptest.NewBrokenUAS("127.0.0.1:0", siptest.FaultNone)
+		require.NoError(t, err)
+		defer uas.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		sess, err := dc.Invite(ctx, &sip.Uri{User: "bob", Host: "127.0.0.1", Port: uas.Port()}, nil)
+		require.NoError(t, err)
+		defer sess.Close()
+		require.NoError(t, sess.WaitAnswer(ctx, AnswerOptions{}))
+		require.NoError(t, sess.Ack(ctx), "second ACK is skipped")
+
+		require.Eventually(t, func() bool { return len(uas.Requests(sip.ACK)) > 0 }, time.Second, 10*time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		acks := uas.Requests(sip.ACK)
+		require.Len(t, acks, 1)
+		// Request-URI is remote target from Contact
+		assert.Equal(t, "sip:127.0.0.1:"+strconv.Itoa(uas.Port()), acks[0].Recipient.String())
+		assert.Equal(t, sess.InviteRequest.CSeq().SeqNo, acks[0].CSeq().SeqNo)
+		assert.Equal(t, sip.ACK, acks[0].CSeq().MethodName)
+	})
+
+	t.Run("DeferAck", func(t *testing.T) {
+		uas, err := siptest.NewBrokenUAS("127.0.0.1:0", siptest.FaultNone)
+		require.NoError(t, err)
+		defer uas.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		sess, err := dc.Invite(ctx, &sip.Uri{User: "bob", Host: "127.0.0.1", Port: uas.Port()}, nil)
+		require.NoError(t, err)
+		defer sess.Close()
+		require.NoError(t, sess.WaitAnswer(ctx, AnswerOptions{DeferAck: true}))
+
+		time.Sleep(50 * time.Millisecond)
+		assert.Empty(t, uas.Requests(sip.ACK))
+
+		// Late SDP answer in ACK
+		ack := sip.NewAckRequest(sess.InviteRequest, sess.InviteResponse, []byte("v=0\r\n"))
+		require.NoError(t, sess.WriteAck(ctx, ack))
+		require.Eventually(t, func() bool { return len(uas.Requests(sip.ACK)) == 1 }, time.Second, 10*time.Millisecond)
+		assert.Equal(t, "v=0\r\n", string(uas.Requests(sip.ACK)[0].Body()))
+	})
+}
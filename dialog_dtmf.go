@@ -0,0 +1,118 @@
+package sipgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+// OnDTMF sets callback for DTMF received in INFO within dialog. Check ReadInfo
+func (d *Dialog) OnDTMF(fn func(dtmf sip.DTMF)) {
+	d.onDTMF.Store(&fn)
+}
+
+// passDTMF calls DTMF callback if set
+func (d *Dialog) passDTMF(dtmf sip.DTMF) {
+	if fn := d.onDTMF.Load(); fn != nil && *fn != nil {
+		(*fn)(dtmf)
+	}
+}
+
+// SendDTMF sends digit in INFO with application/dtmf-relay body and waits final response.
+// Returns ErrDialogResponse in case non 2xx response
+func (s *DialogClientSession) SendDTMF(ctx context.Context, digit rune, duration time.Duration) error {
+	return dialogSendDTMF(ctx, s.dc.c, &s.Dialog, s.newRequest, digit, duration)
+}
+
+// SendDTMF sends digit in INFO with application/dtmf-relay body and waits final response.
+// Check DialogClientSession.SendDTMF for more
+func (s *DialogServerSession) SendDTMF(ctx context.Context, digit rune, duration time.Duration) error {
+	return dialogSendDTMF(ctx, s.s.c, &s.Dialog, s.newRequest, digit, duration)
+}
+
+func dialogSendDTMF(ctx context.Context, c *Client, d *Dialog, newRequest func(method sip.RequestMethod, body []byte) *sip.Request, digit rune, duration time.Duration) error {
+	if !sip.IsDTMFDigit(digit) {
+		return fmt.Errorf("%w: digit %q", sip.ErrDTMFInvalid, digit)
+	}
+	if sip.DialogState(d.state.Load()) != sip.DialogStateConfirmed {
+		return fmt.Errorf("Dialog not confirmed. ACK not send?")
+	}
+
+	dtmf := sip.DTMF{Digit: digit, Duration: duration}
+	req := newRequest(sip.INFO, dtmf.RelayBody())
+	req.AppendHeader(sip.NewHeader("Content-Type", sip.ContentTypeDTMFRelay))
+
+	res, err := dialogTransaction(ctx, c, d, req)
+	if err != nil {
+		return err
+	}
+	if !res.IsSuccess() {
+		return &ErrDialogResponse{Res: res}
+	}
+	return nil
+}
+
+// ReadInfo should read from your OnInfo handler.
+// INFO carrying DTMF is responded with 200 and digit is passed to dialog OnDTMF callback.
+// Other content types are responded with 415 and malformed body with 400
+func (dc *DialogClient) ReadInfo(req *sip.Request, tx sip.ServerTransaction) error {
+	callid := req.CallID()
+	from := req.From()
+	to := req.To()
+	if callid == nil || from == nil || to == nil {
+		return ErrDialogOutsideDialog
+	}
+
+	id := sip.MakeDialogID(callid.Value(), from.Params["tag"], to.Params["tag"])
+
+	dt := dc.loadDialog(id)
+	if dt == nil {
+		return fmt.Errorf("callid=%q: %w", callid.Value(), ErrDialogDoesNotExists)
+	}
+	return readInfo(&dt.Dialog, req, tx)
+}
+
+// ReadInfo should read from your OnInfo handler. Check DialogClient.ReadInfo for more
+func (s *DialogServer) ReadInfo(req *sip.Request, tx sip.ServerTransaction) error {
+	id, err := sip.MakeDialogIDFromRequest(req)
+	if err != nil {
+		return errors.Join(ErrDialogOutsideDialog, err)
+	}
+
+	dt := s.loadDialog(id)
+	if dt == nil {
+		return ErrDialogDoesNotExists
+	}
+	return readInfo(&dt.Dialog, req, tx)
+}
+
+func readInfo(d *Dialog, req *sip.Request, tx sip.ServerTransaction) error {
+	var contentType string
+	if h := req.ContentType(); h != nil {
+		contentType = h.Value()
+	}
+
+	dtmf, err := sip.ParseDTMFBody(contentType, req.Body())
+	if err != nil {
+		res := sip.NewResponseFromRequest(req, sip.StatusBadRequest, "Bad Request", nil)
+		if errors.Is(err, sip.ErrDTMFContentType) {
+			res = sip.NewResponseFromRequest(req, sip.StatusUnsupportedMediaType, "Unsupported Media Type", nil)
+			res.AppendHeader(sip.NewHeader("Accept", sip.ContentTypeDTMFRelay+", "+sip.ContentTypeDTMF))
+		}
+		if err := tx.Respond(res); err != nil {
+			return err
+		}
+		return err
+	}
+
+	res := sip.NewResponseFromRequest(req, sip.StatusOK, "OK", nil)
+	if err := tx.Respond(res); err != nil {
+		return err
+	}
+
+	d.passDTMF(dtmf)
+	return nil
+}
@@ -0,0 +1,123 @@
+package sipgo
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDialogListen creates server for user agent and UDP listener on random port with contact using that port
+func testDialogListen(t *testing.T, ua *UserAgent, user string) (*Server, net.PacketConn, sip.ContactHeader) {
+	srv, err := NewServer(ua)
+	require.NoError(t, err)
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	return srv, conn, sip.ContactHeader{Address: sip.Uri{User: user, Host: "127.0.0.1", Port: conn.LocalAddr().(*net.UDPAddr).Port}}
+}
+
+func TestDialogDTMF(t *testing.T) {
+	uasUA, err := NewUA(WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer uasUA.Close()
+	uasCli, err := NewClient(uasUA, WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+	uasSrv, uasConn, uasContact := testDialogListen(t, uasUA, "bob")
+	ds := NewDialogServer(uasCli, uasContact)
+
+	uasDigits := make(chan sip.DTMF, 5)
+	uasSessions := make(chan *DialogServerSession, 1)
+	uasSrv.OnInvite(func(req *sip.Request, tx sip.ServerTransaction) {
+		sess, err := ds.ReadInvite(req, tx)
+		require.NoError(t, err)
+		sess.OnDTMF(func(dtmf sip.DTMF) { uasDigits <- dtmf })
+		require.NoError(t, sess.Respond(sip.StatusOK, "OK", nil))
+		uasSessions <- sess
+	})
+	uasSrv.OnAck(func(req *sip.Request, tx sip.ServerTransaction) {
+		ds.ReadAck(req, tx)
+	})
+	uasSrv.OnInfo(func(req *sip.Request, tx sip.ServerTransaction) {
+		ds.ReadInfo(req, tx)
+	})
+	go uasSrv.ServeUDP(uasConn)
+
+	uacUA, err := NewUA(WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer uacUA.Close()
+	uacCli, err := NewClient(uacUA, WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+	uacSrv, uacConn, uacContact := testDialogListen(t, uacUA, "alice")
+	dc := NewDialogClient(uacCli, uacContact)
+	uacSrv.OnInfo(func(req *sip.Request, tx sip.ServerTransaction) {
+		dc.ReadInfo(req, tx)
+	})
+	go uacSrv.ServeUDP(uacConn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	sess, err := dc.Invite(ctx, &uasContact.Address, nil)
+	require.NoError(t, err)
+	defer sess.Close()
+	uacDigits := make(chan sip.DTMF, 5)
+	sess.OnDTMF(func(dtmf sip.DTMF) { uacDigits <- dtmf })
+	require.NoError(t, sess.WaitAnswer(ctx, AnswerOptions{}))
+
+	var uasSess *DialogServerSession
+	select {
+	case uasSess = <-uasSessions:
+	case <-ctx.Done():
+		t.Fatal("no session")
+	}
+	defer uasSess.Close()
+
+	require.NoError(t, sess.SendDTMF(ctx, '5', 160*time.Millisecond))
+	select {
+	case dtmf := <-uasDigits:
+		assert.Equal(t, sip.DTMF{Digit: '5', Duration: 160 * time.Millisecond}, dtmf)
+	case <-ctx.Done():
+		t.Fatal("DTMF not received")
+	}
+
+	require.Eventually(t, func() bool { return sip.DialogState(uasSess.state.Load()) == sip.DialogStateConfirmed }, time.Second, 10*time.Millisecond)
+	require.NoError(t, uasSess.SendDTMF(ctx, '#', 0))
+	select {
+	case dtmf := <-uacDigits:
+		assert.Equal(t, sip.DTMF{Digit: '#'}, dtmf)
+	case <-ctx.Done():
+		t.Fatal("DTMF not received")
+	}
+
+	assert.ErrorIs(t, sess.SendDTMF(ctx, 'x', 0), sip.ErrDTMFInvalid)
+}
+
+func TestDialogReadInfoUnsupported(t *testing.T) {
+	d := &Dialog{}
+	called := false
+	d.OnDTMF(func(dtmf sip.DTMF) { called = true })
+
+	req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	req.Method = sip.INFO
+	req.SetBody([]byte("<xml/>"))
+	req.AppendHeader(sip.NewHeader("Content-Type", "application/xml"))
+	tx := siptest.NewServerTxRecorder(req)
+	err := readInfo(d, req, tx)
+	assert.ErrorIs(t, err, sip.ErrDTMFContentType)
+	require.Len(t, tx.Result(), 1)
+	assert.Equal(t, sip.StatusUnsupportedMediaType, tx.Result()[0].StatusCode)
+
+	req, _, _ = createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	req.Method = sip.INFO
+	req.SetBody([]byte("Signal=\r\n"))
+	req.AppendHeader(sip.NewHeader("Content-Type", "application/dtmf-relay"))
+	tx = siptest.NewServerTxRecorder(req)
+	assert.ErrorIs(t, readInfo(d, req, tx), sip.ErrDTMFInvalid)
+	assert.Equal(t, sip.StatusBadRequest, tx.Result()[0].StatusCode)
+	assert.False(t, called)
+}
@@ -0,0 +1,104 @@
+package sipgo
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialogEarly(t *testing.T) {
+	srvUA, err := NewUA(WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer srvUA.Close()
+	srv, err := NewServer(srvUA)
+	require.NoError(t, err)
+	srvCli, err := NewClient(srvUA, WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	ds := NewDialogServer(srvCli, sip.ContactHeader{Address: sip.Uri{User: "bob", Host: "127.0.0.1", Port: port}})
+
+	pracked := make(chan struct{})
+	responses := make(chan *sip.Response, 2)
+	srv.OnInvite(func(req *sip.Request, tx sip.ServerTransaction) {
+		dlg, err := ds.ReadInvite(req, tx)
+		require.NoError(t, err)
+		defer dlg.Close()
+
+		require.NoError(t, dlg.Respond(sip.StatusSessionInProgress, "Session Progress", nil, sip.NewHeader("RSeq", "1"), sip.NewHeader("Require", "100rel")))
+		responses <- dlg.InviteResponse
+		assert.Equal(t, sip.DialogStateEarly, sip.DialogState(dlg.state.Load()))
+		select {
+		case <-pracked:
+		case <-time.After(time.Second):
+		}
+		require.NoError(t, dlg.Respond(sip.StatusOK, "OK", nil))
+		responses <- dlg.InviteResponse
+	})
+	earlyReqs := make(chan *sip.Request, 2)
+	earlyHandler := func(req *sip.Request, tx sip.ServerTransaction) {
+		earlyReqs <- req
+		tx.Respond(sip.NewResponseFromRequest(req, sip.StatusOK, "OK", nil))
+	}
+	srv.OnPrack(func(req *sip.Request, tx sip.ServerTransaction) {
+		earlyHandler(req, tx)
+		close(pracked)
+	})
+	srv.OnUpdate(earlyHandler)
+	srv.OnAck(func(req *sip.Request, tx sip.ServerTransaction) {})
+	go srv.ServeUDP(conn)
+
+	ua, err := NewUA(WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer ua.Close()
+	cli, err := NewClient(ua, WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+	dc := NewDialogClient(cli, sip.ContactHeader{Address: sip.Uri{User: "alice", Host: "127.0.0.1", Port: 5060}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	sess, err := dc.Invite(ctx, &sip.Uri{User: "bob", Host: "127.0.0.1", Port: port}, nil)
+	require.NoError(t, err)
+	defer sess.Close()
+
+	var earlyID string
+	err = sess.WaitAnswer(ctx, AnswerOptions{
+		OnResponse: func(res *sip.Response) {
+			if res.GetHeader("RSeq") == nil {
+				return
+			}
+			assert.Equal(t, sip.DialogStateEarly, sip.DialogState(sess.state.Load()))
+			earlyID = sess.ID
+			require.NotNil(t, dc.loadDialog(earlyID))
+
+			_, err := sess.Update(ctx, nil)
+			require.NoError(t, err)
+			_, err = sess.Prack(ctx, res, nil)
+			require.NoError(t, err)
+		},
+	})
+	require.NoError(t, err)
+
+	// Early dialog is confirmed with same To tag
+	assert.Equal(t, earlyID, sess.ID)
+	earlyTag, _ := (<-responses).To().Params.Get("tag")
+	finalTag, _ := (<-responses).To().Params.Get("tag")
+	assert.Equal(t, earlyTag, finalTag)
+	assert.Equal(t, 1, dc.dialogsLen())
+
+	inviteSeq := sess.InviteRequest.CSeq().SeqNo
+	update, prack := <-earlyReqs, <-earlyReqs
+	assert.Equal(t, sip.UPDATE, update.Method)
+	assert.Equal(t, inviteSeq+1, update.CSeq().SeqNo)
+	assert.Equal(t, sip.PRACK, prack.Method)
+	assert.Equal(t, inviteSeq+2, prack.CSeq().SeqNo)
+	assert.Equal(t, "1 "+strconv.Itoa(int(inviteSeq))+" INVITE", prack.GetHeader("RAck").Value())
+}
@@ -52,7 +52,8 @@ func TestIntegrationDialog(t *testing.T) {
 
 			if state == sip.DialogStateConfirmed {
 				time.Sleep(1 * time.Second)
-				ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
 				dlg.Bye(ctx)
 				return
 			}
@@ -148,6 +149,22 @@ func TestIntegrationDialog(t *testing.T) {
 			<-sess.inviteTx.Done()
 		})
 
+		t.Run("UAC auto ACK", func(t *testing.T) {
+			sess, err := dialogCli.Invite(context.TODO(), uasContact.Address.Clone(), nil)
+			require.NoError(t, err)
+
+			// ACK is sent by default
+			err = sess.WaitAnswer(ctx, AnswerOptions{})
+			require.NoError(t, err)
+			require.NotNil(t, sess.lastAck.Load())
+			require.Equal(t, sip.DialogStateConfirmed, sip.DialogState(sess.state.Load()))
+
+			err = sess.Bye(context.TODO())
+			require.NoError(t, err)
+
+			<-sess.inviteTx.Done()
+		})
+
 		require.Empty(t, dialogCli.dialogsLen())
 	}
 
@@ -167,8 +184,9 @@ func TestIntegrationDialogBrokenUAC(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Ports differ from TestIntegrationDialog as its listeners are closed asynchronously
 	uasContact := sip.ContactHeader{
-		Address: sip.Uri{User: "test", Host: "127.0.0.200", Port: 5099},
+		Address: sip.Uri{User: "test", Host: "127.0.0.200", Port: 5098},
 	}
 
 	dialogSrv := NewDialogServer(cli, uasContact)
@@ -216,7 +234,7 @@ func TestIntegrationDialogBrokenUAC(t *testing.T) {
 		cli, _ := NewClient(ua)
 
 		contactHDR := sip.ContactHeader{
-			Address: sip.Uri{User: "test", Host: "127.0.0.200", Port: 5088},
+			Address: sip.Uri{User: "test", Host: "127.0.0.200", Port: 5087},
 		}
 		dialogCli := NewDialogClient(cli, contactHDR)
 
@@ -266,14 +284,15 @@ func TestIntegrationDialogBrokenUAC(t *testing.T) {
 			sess, err := dialogCli.Invite(context.TODO(), uasContact.Address.Clone(), nil)
 			require.NoError(t, err)
 
-			err = sess.WaitAnswer(ctx, AnswerOptions{})
+			err = sess.WaitAnswer(ctx, AnswerOptions{DeferAck: true})
 			require.NoError(t, err)
 			require.Equal(t, sip.StatusOK, sess.InviteResponse.StatusCode)
 
 			// ACK
 			t.Log("UAC: ACK")
 			sess.InviteRequest.SetDestination("nodestination.dst")
-			ctx, _ := context.WithTimeout(context.Background(), 1*time.Millisecond)
+			ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+			defer cancel()
 			err = sess.Ack(ctx)
 			require.Error(t, err)
 
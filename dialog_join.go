@@ -0,0 +1,146 @@
+package sipgo
+
+import (
+	"errors"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+var (
+	ErrDialogJoinMissing    = errors.New("No Join header")
+	ErrDialogJoinTerminated = errors.New("Joined dialog is terminated")
+)
+
+// JoinFunc decides should incoming INVITE with Join be joined to dialog, ex. conference or barge-in.
+// Returning error rejects INVITE. In case of sip.StatusError its code is responded, otherwise 603 Decline
+type JoinFunc func(req *sip.Request, joined *Dialog) error
+
+// OnJoin sets hook for INVITE with Join header read with ReadInvite.
+// Join is matched to dialogs of this server and passed clients. Unmatched INVITE is rejected per RFC 3911
+// before hook is called. Joining is then up to application, ex. mixing media into conference.
+// It must be set before reading INVITE
+// https://datatracker.ietf.org/doc/html/rfc3911#section-3
+func (s *DialogServer) OnJoin(fn JoinFunc, clients ...*DialogClient) {
+	s.onJoin = fn
+	s.joinClients = clients
+}
+
+// MatchJoin returns dialog session referenced by Join header of incoming INVITE.
+// Returns ErrDialogDoesNotExists in case there is no such dialog or it is early dialog not initiated by us,
+// and request should be rejected with 481. In case dialog is terminated ErrDialogJoinTerminated is returned.
+// Joining is then up to application, ex. mixing media into conference
+// https://datatracker.ietf.org/doc/html/rfc3911#section-3
+func (s *DialogServer) MatchJoin(req *sip.Request) (*DialogServerSession, error) {
+	join := req.Join()
+	if join == nil {
+		return nil, ErrDialogJoinMissing
+	}
+
+	dt := s.loadDialog(join.DialogIDUAS())
+	if dt == nil {
+		return nil, ErrDialogDoesNotExists
+	}
+	if err := matchJoin(&dt.Dialog, false); err != nil {
+		return nil, err
+	}
+	return dt, nil
+}
+
+// MatchJoin returns dialog session referenced by Join header of incoming INVITE.
+// Check DialogServer.MatchJoin for more
+func (dc *DialogClient) MatchJoin(req *sip.Request) (*DialogClientSession, error) {
+	join := req.Join()
+	if join == nil {
+		return nil, ErrDialogJoinMissing
+	}
+
+	dt := dc.loadDialog(join.DialogIDUAC())
+	if dt == nil {
+		return nil, ErrDialogDoesNotExists
+	}
+	if err := matchJoin(&dt.Dialog, true); err != nil {
+		return nil, err
+	}
+	return dt, nil
+}
+
+// matchJoin checks can dialog be joined. Unlike Replaces, only early dialog initiated by us can be joined
+func matchJoin(d *Dialog, uac bool) error {
+	switch sip.DialogState(d.state.Load()) {
+	case sip.DialogStateEnded:
+		return ErrDialogJoinTerminated
+	case sip.DialogStateEarly:
+		if !uac {
+			return ErrDialogDoesNotExists
+		}
+	}
+	return nil
+}
+
+// readJoin matches Join of INVITE and passes joined dialog to hook. On error INVITE is rejected
+func (s *DialogServer) readJoin(req *sip.Request, tx sip.ServerTransaction) error {
+	hdrs := req.GetHeaders("Join")
+	if len(hdrs) == 0 {
+		return nil
+	}
+
+	var err error
+	switch {
+	case len(hdrs) > 1:
+		err = sip.StatusError{Code: sip.StatusBadRequest, Reason: "Multiple Join Headers"}
+	case req.GetHeader("Replaces") != nil:
+		err = sip.StatusError{Code: sip.StatusBadRequest, Reason: "Join And Replaces Headers"}
+	default:
+		err = s.joinDialog(req, func(d *Dialog) error {
+			return s.onJoin(req, d)
+		})
+	}
+	if err == nil {
+		return nil
+	}
+
+	res := dialogRefErrResponse(req, "Join", err)
+	if rerr := tx.Respond(res); rerr != nil {
+		return errors.Join(err, rerr)
+	}
+	return err
+}
+
+func (s *DialogServer) joinDialog(req *sip.Request, fn func(d *Dialog) error) error {
+	dt, err := s.MatchJoin(req)
+	if err == nil {
+		return fn(&dt.Dialog)
+	}
+	if !errors.Is(err, ErrDialogDoesNotExists) {
+		return err
+	}
+
+	for _, dc := range s.joinClients {
+		dt, cerr := dc.MatchJoin(req)
+		if cerr == nil {
+			return fn(&dt.Dialog)
+		}
+		if !errors.Is(cerr, ErrDialogDoesNotExists) {
+			return cerr
+		}
+	}
+	return err
+}
+
+// JoinHeader returns Join header which remote party of this dialog matches to this dialog
+func (s *DialogServerSession) JoinHeader() *sip.JoinHeader {
+	return &sip.JoinHeader{
+		CallID:  s.InviteRequest.CallID().Value(),
+		ToTag:   s.InviteRequest.From().Params["tag"],
+		FromTag: s.InviteResponse.To().Params["tag"],
+	}
+}
+
+// JoinHeader returns Join header which remote party of this dialog matches to this dialog
+func (s *DialogClientSession) JoinHeader() *sip.JoinHeader {
+	return &sip.JoinHeader{
+		CallID:  s.InviteRequest.CallID().Value(),
+		ToTag:   s.InviteResponse.To().Params["tag"],
+		FromTag: s.InviteRequest.From().Params["tag"],
+	}
+}
@@ -0,0 +1,111 @@
+package sipgo
+
+import (
+	"testing"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialogServerMatchJoin(t *testing.T) {
+	ua, _ := NewUA()
+	defer ua.Close()
+	cli, err := NewClient(ua)
+	require.NoError(t, err)
+
+	dsrv := NewDialogServer(cli, sip.ContactHeader{Address: sip.Uri{User: "bob", Host: "127.0.0.1", Port: 5060}})
+
+	invite, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	invite.AppendHeader(&sip.ContactHeader{Address: sip.Uri{User: "alice", Host: "127.0.0.2", Port: 5060}})
+	tx := siptest.NewServerTxRecorder(invite)
+	dtx, err := dsrv.ReadInvite(invite, tx)
+	require.NoError(t, err)
+	require.NoError(t, dtx.Respond(200, "OK", nil))
+
+	// Third party wants to join. Tags are as seen by us
+	join := &sip.JoinHeader{
+		CallID:  invite.CallID().Value(),
+		ToTag:   dtx.InviteResponse.To().Params["tag"],
+		FromTag: invite.From().Params["tag"],
+	}
+	req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.3:5060")
+	req.AppendHeader(sip.NewHeader("Join", join.Value()))
+
+	matched, err := dsrv.MatchJoin(req)
+	require.NoError(t, err)
+	assert.Equal(t, dtx, matched)
+
+	// Wrong tags
+	req.RemoveHeader("Join")
+	req.AppendHeader(&sip.JoinHeader{CallID: join.CallID, ToTag: join.FromTag, FromTag: join.ToTag})
+	_, err = dsrv.MatchJoin(req)
+	require.ErrorIs(t, err, ErrDialogDoesNotExists)
+
+	req.RemoveHeader("Join")
+	_, err = dsrv.MatchJoin(req)
+	require.ErrorIs(t, err, ErrDialogJoinMissing)
+
+	// Header for remote party references this dialog from its side
+	remote := dtx.JoinHeader()
+	assert.Equal(t, join.ToTag, remote.FromTag)
+	assert.Equal(t, join.FromTag, remote.ToTag)
+}
+
+func TestDialogServerOnJoin(t *testing.T) {
+	ua, _ := NewUA()
+	defer ua.Close()
+	cli, err := NewClient(ua)
+	require.NoError(t, err)
+
+	dsrv := NewDialogServer(cli, sip.ContactHeader{Address: sip.Uri{User: "bob", Host: "127.0.0.1", Port: 5060}})
+	var joined *Dialog
+	dsrv.OnJoin(func(req *sip.Request, d *Dialog) error {
+		joined = d
+		return nil
+	})
+
+	invite, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	invite.AppendHeader(&sip.ContactHeader{Address: sip.Uri{User: "alice", Host: "127.0.0.2", Port: 5060}})
+	dtx, err := dsrv.ReadInvite(invite, siptest.NewServerTxRecorder(invite))
+	require.NoError(t, err)
+	require.NoError(t, dtx.Respond(180, "Ringing", nil))
+	join := dtx.JoinHeader()
+	join.ToTag, join.FromTag = join.FromTag, join.ToTag
+
+	readInvite := func(headers ...sip.Header) (*sip.Response, error) {
+		req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.3:5060")
+		req.AppendHeader(&sip.ContactHeader{Address: sip.Uri{User: "carol", Host: "127.0.0.3", Port: 5060}})
+		for _, h := range headers {
+			req.AppendHeader(h)
+		}
+		tx := siptest.NewServerTxRecorder(req)
+		_, err := dsrv.ReadInvite(req, tx)
+		if res := tx.Result(); len(res) > 0 {
+			return res[len(res)-1], err
+		}
+		return nil, err
+	}
+
+	// Early dialog we did not initiate can not be joined
+	res, err := readInvite(join)
+	require.ErrorIs(t, err, ErrDialogDoesNotExists)
+	assert.Equal(t, sip.StatusCallTransactionDoesNotExists, res.StatusCode)
+	assert.Nil(t, joined)
+
+	require.NoError(t, dtx.Respond(200, "OK", nil))
+	res, err = readInvite(join)
+	require.NoError(t, err)
+	assert.Nil(t, res)
+	assert.Equal(t, &dtx.Dialog, joined)
+
+	res, err = readInvite(join, &sip.ReplacesHeader{CallID: join.CallID, ToTag: join.ToTag, FromTag: join.FromTag})
+	require.Error(t, err)
+	assert.Equal(t, sip.StatusBadRequest, res.StatusCode)
+
+	dtx.setState(sip.DialogStateEnded)
+	res, err = readInvite(join)
+	require.ErrorIs(t, err, ErrDialogJoinTerminated)
+	assert.Equal(t, sip.StatusGlobalDecline, res.StatusCode)
+}
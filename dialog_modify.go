@@ -0,0 +1,169 @@
+package sipgo
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+// ReInvite sends re-INVITE with new session description, ex. for hold/resume or codec renegotiation,
+// and waits final response. 2xx is acknowledged. On 491 Request Pending request is retried after glare timer
+// https://datatracker.ietf.org/doc/html/rfc3261#section-14.1
+// Returns ErrDialogResponse in case non 2xx response. Session stays as before
+func (s *DialogClientSession) ReInvite(ctx context.Context, body []byte) (*sip.Response, error) {
+	return dialogModify(ctx, s.dc.c, &s.Dialog, s.newRequest, sip.INVITE, body, true)
+}
+
+// Update sends UPDATE with new session description and waits final response.
+// It can be sent in early dialog as well. Check ReInvite for more
+func (s *DialogClientSession) Update(ctx context.Context, body []byte) (*sip.Response, error) {
+	return dialogModify(ctx, s.dc.c, &s.Dialog, s.newRequest, sip.UPDATE, body, true)
+}
+
+// Prack acknowledges reliable provisional response in early dialog and waits final response.
+// Body is answer on offer in provisional response or new offer
+// https://datatracker.ietf.org/doc/html/rfc3262#section-7.2
+func (s *DialogClientSession) Prack(ctx context.Context, res *sip.Response, body []byte) (*sip.Response, error) {
+	if sip.DialogState(s.state.Load()) != sip.DialogStateEarly {
+		return nil, fmt.Errorf("Dialog not early")
+	}
+	rseq := res.GetHeader("RSeq")
+	if rseq == nil {
+		return nil, fmt.Errorf("Provisional response is not reliable. RSeq missing")
+	}
+
+	req := s.newRequest(sip.PRACK, body)
+	cseq := res.CSeq()
+	req.AppendHeader(sip.NewHeader("RAck", rseq.Value()+" "+strconv.FormatUint(uint64(cseq.SeqNo), 10)+" "+cseq.MethodName.String()))
+	if body != nil {
+		req.AppendHeader(sip.NewHeader("Content-Type", "application/sdp"))
+	}
+
+	prackRes, err := dialogSend(ctx, s.dc.c, &s.Dialog, req)
+	if err != nil {
+		return nil, err
+	}
+	if !prackRes.IsSuccess() {
+		return prackRes, &ErrDialogResponse{Res: prackRes}
+	}
+	return prackRes, nil
+}
+
+// ReInvite sends re-INVITE with new session description and waits final response.
+// Check DialogClientSession.ReInvite for more
+func (s *DialogServerSession) ReInvite(ctx context.Context, body []byte) (*sip.Response, error) {
+	return dialogModify(ctx, s.s.c, &s.Dialog, s.newRequest, sip.INVITE, body, false)
+}
+
+// Update sends UPDATE with new session description and waits final response.
+// Check DialogClientSession.ReInvite for more
+func (s *DialogServerSession) Update(ctx context.Context, body []byte) (*sip.Response, error) {
+	return dialogModify(ctx, s.s.c, &s.Dialog, s.newRequest, sip.UPDATE, body, false)
+}
+
+// glareTimer returns time to wait before retrying request rejected with 491.
+// Owner of Call-ID waits between 2.1 and 4s, other side between 0 and 2s, in units of 10ms
+// https://datatracker.ietf.org/doc/html/rfc3261#section-14.1
+func glareTimer(callIDOwner bool) time.Duration {
+	if callIDOwner {
+		return 2100*time.Millisecond + time.Duration(rand.Intn(191))*10*time.Millisecond
+	}
+	return time.Duration(rand.Intn(201)) * 10 * time.Millisecond
+}
+
+func dialogModify(ctx context.Context, c *Client, d *Dialog, newRequest func(method sip.RequestMethod, body []byte) *sip.Request, method sip.RequestMethod, body []byte, callIDOwner bool) (*sip.Response, error) {
+	for {
+		state := sip.DialogState(d.state.Load())
+		if state != sip.DialogStateConfirmed && !(method == sip.UPDATE && state == sip.DialogStateEarly) {
+			return nil, fmt.Errorf("Dialog not confirmed. ACK not send?")
+		}
+
+		// Every attempt is new transaction with new CSeq
+		req := newRequest(method, body)
+		if body != nil {
+			req.AppendHeader(sip.NewHeader("Content-Type", "application/sdp"))
+		}
+
+		res, err := dialogSend(ctx, c, d, req)
+		if err != nil {
+			return nil, err
+		}
+
+		if res.StatusCode == sip.StatusRequestPending {
+			select {
+			case <-time.After(glareTimer(callIDOwner)):
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		if !res.IsSuccess() {
+			return res, &ErrDialogResponse{Res: res}
+		}
+		return res, nil
+	}
+}
+
+// dialogSend applies request on offer/answer and sends it
+func dialogSend(ctx context.Context, c *Client, d *Dialog, req *sip.Request) (*sip.Response, error) {
+	if err := d.offerAnswer.Local(req); err != nil {
+		return nil, err
+	}
+
+	res, err := dialogModifyRequest(ctx, c, d, req)
+	if err != nil {
+		d.offerAnswer.cancel(req)
+		return nil, err
+	}
+	return res, nil
+}
+
+// dialogModifyRequest sends request and waits final response. 2xx on INVITE is acknowledged
+func dialogModifyRequest(ctx context.Context, c *Client, d *Dialog, req *sip.Request) (*sip.Response, error) {
+	res, err := dialogTransaction(ctx, c, d, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.IsInvite() && res.IsSuccess() {
+		ack := sip.NewAckRequest(req, res, nil)
+		// Route set is not changed by re-INVITE
+		ack.ReplaceHeaders("Route", req.GetHeaders("Route")...)
+		d.trackOfferAnswer(ack, true)
+		if err := c.WriteRequest(ack); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// dialogTransaction sends request within dialog and waits final response
+func dialogTransaction(ctx context.Context, c *Client, d *Dialog, req *sip.Request) (*sip.Response, error) {
+	tx, err := c.TransactionRequest(ctx, req, ClientRequestBuild)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Terminate()
+
+	for {
+		var res *sip.Response
+		select {
+		case res = <-tx.Responses():
+		case <-tx.Done():
+			return nil, tx.Err()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		d.trackOfferAnswer(res, false)
+		if res.IsProvisional() {
+			continue
+		}
+		return res, nil
+	}
+}
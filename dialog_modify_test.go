@@ -0,0 +1,73 @@
+package sipgo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialogClientReInvite(t *testing.T) {
+	ua, err := NewUA(WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer ua.Close()
+	cli, err := NewClient(ua, WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+
+	dc := NewDialogClient(cli, sip.ContactHeader{Address: sip.Uri{User: "alice", Host: "127.0.0.1", Port: 5060}})
+
+	uas, err := siptest.NewBrokenUAS("127.0.0.1:0", siptest.FaultNone, siptest.WithBrokenUASBody([]byte("v=0\r\n")))
+	require.NoError(t, err)
+	defer uas.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	sess, err := dc.Invite(ctx, &sip.Uri{User: "bob", Host: "127.0.0.1", Port: uas.Port()}, []byte("v=0\r\n"))
+	require.NoError(t, err)
+	defer sess.Close()
+	require.NoError(t, sess.WaitAnswer(ctx, AnswerOptions{}))
+
+	res, err := sess.ReInvite(ctx, []byte("v=0\r\na=sendonly\r\n"))
+	require.NoError(t, err)
+	assert.Equal(t, sip.StatusOK, res.StatusCode)
+
+	invites := uas.Requests(sip.INVITE)
+	require.Len(t, invites, 2)
+	reinvite := invites[1]
+	assert.Equal(t, sess.InviteRequest.CSeq().SeqNo+1, reinvite.CSeq().SeqNo)
+	toTag, _ := reinvite.To().Params.Get("tag")
+	assert.NotEmpty(t, toTag)
+	assert.Equal(t, "application/sdp", reinvite.ContentType().Value())
+	assert.Equal(t, "v=0\r\na=sendonly\r\n", string(sess.OfferAnswer().LocalDescription()))
+	assert.Equal(t, OfferAnswerStable, sess.OfferAnswer().State())
+
+	// Both 2xx are acked
+	require.Eventually(t, func() bool { return len(uas.Requests(sip.ACK)) == 2 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, reinvite.CSeq().SeqNo, uas.Requests(sip.ACK)[1].CSeq().SeqNo)
+
+	res, err = sess.Update(ctx, []byte("v=0\r\na=sendrecv\r\n"))
+	require.NoError(t, err)
+	assert.Equal(t, sip.StatusOK, res.StatusCode)
+	updates := uas.Requests(sip.UPDATE)
+	require.Len(t, updates, 1)
+	assert.Equal(t, reinvite.CSeq().SeqNo+1, updates[0].CSeq().SeqNo)
+
+	require.NoError(t, sess.Bye(ctx))
+	byes := uas.Requests(sip.BYE)
+	require.Len(t, byes, 1)
+	assert.Equal(t, updates[0].CSeq().SeqNo+1, byes[0].CSeq().SeqNo)
+}
+
+func TestGlareTimer(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		d := glareTimer(true)
+		assert.True(t, d >= 2100*time.Millisecond && d <= 4*time.Second, d)
+		d = glareTimer(false)
+		assert.True(t, d >= 0 && d <= 2*time.Second, d)
+	}
+}
@@ -0,0 +1,256 @@
+package sipgo
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrOfferAnswerPending is returned for new offer while previous one is not answered.
+	// Received request with it should be rejected with 491 Request Pending
+	ErrOfferAnswerPending = errors.New("offer/answer: offer pending")
+	// ErrOfferAnswerUnexpected is returned for session description in message which can not carry it
+	ErrOfferAnswerUnexpected = errors.New("offer/answer: unexpected session description")
+	// ErrOfferAnswerMissing is returned for ACK without answer on offer received in 2xx
+	ErrOfferAnswerMissing = errors.New("offer/answer: answer missing")
+)
+
+// OfferAnswerState tells whose turn is in SDP offer/answer exchange
+type OfferAnswerState int
+
+const (
+	// OfferAnswerStable is when there is no pending offer and any side can offer
+	OfferAnswerStable OfferAnswerState = iota
+	// OfferAnswerLocalOffer is when local offer is sent and answer is expected from remote
+	OfferAnswerLocalOffer
+	// OfferAnswerRemoteOffer is when remote offer is received and local answer must be sent
+	OfferAnswerRemoteOffer
+)
+
+func (s OfferAnswerState) String() string {
+	switch s {
+	case OfferAnswerStable:
+		return "Stable"
+	case OfferAnswerLocalOffer:
+		return "LocalOffer"
+	case OfferAnswerRemoteOffer:
+		return "RemoteOffer"
+	}
+	return "Unknown"
+}
+
+// OfferAnswer tracks SDP offer/answer exchange within dialog
+// https://datatracker.ietf.org/doc/html/rfc3264 and https://datatracker.ietf.org/doc/html/rfc6337
+// Offers are supported in INVITE, reliable 1xx, 2xx, ACK, UPDATE and PRACK.
+// Every sent message is passed with Local and every received with Remote.
+// Offer rejected with non 2xx final response is rolled back.
+// It is safe for concurrent use
+type OfferAnswer struct {
+	mu    sync.Mutex
+	state OfferAnswerState
+
+	// offer is pending offer and offerCSeq, offerMethod identify transaction carrying it
+	offer          []byte
+	offerCSeq      uint32
+	offerMethod    sip.RequestMethod
+	offerInRequest bool
+
+	local  []byte
+	remote []byte
+}
+
+// State returns current state of exchange
+func (oa *OfferAnswer) State() OfferAnswerState {
+	oa.mu.Lock()
+	defer oa.mu.Unlock()
+	return oa.state
+}
+
+// LocalDescription returns last negotiated local session description
+func (oa *OfferAnswer) LocalDescription() []byte {
+	oa.mu.Lock()
+	defer oa.mu.Unlock()
+	return oa.local
+}
+
+// RemoteDescription returns last negotiated remote session description
+func (oa *OfferAnswer) RemoteDescription() []byte {
+	oa.mu.Lock()
+	defer oa.mu.Unlock()
+	return oa.remote
+}
+
+// Local applies message before it is sent. In case of error message violates offer/answer and should not be sent
+func (oa *OfferAnswer) Local(msg sip.Message) error {
+	return oa.apply(msg, true)
+}
+
+// Remote applies received message. In case of error message violates offer/answer and should be rejected
+func (oa *OfferAnswer) Remote(msg sip.Message) error {
+	return oa.apply(msg, false)
+}
+
+func (oa *OfferAnswer) apply(msg sip.Message, local bool) error {
+	cseq := msg.CSeq()
+	if cseq == nil || !offerAnswerCarrier(cseq.MethodName) {
+		return nil
+	}
+	method := cseq.MethodName
+
+	oa.mu.Lock()
+	defer oa.mu.Unlock()
+
+	res, isResponse := msg.(*sip.Response)
+	if isResponse {
+		// Unreliable provisional can not carry offer or answer
+		if res.IsProvisional() && res.GetHeader("RSeq") == nil {
+			return nil
+		}
+		if !res.IsSuccess() && !res.IsProvisional() {
+			if oa.state != OfferAnswerStable && oa.pendingTransaction(cseq) {
+				oa.rollback()
+			}
+			return nil
+		}
+	}
+
+	body := msg.Body()
+	if len(body) == 0 || !isSDPContent(msg) {
+		// Offer received in 2xx must be answered in ACK
+		if method == sip.ACK && oa.waitsAnswerFrom(local) && oa.pendingTransaction(cseq) && !oa.offerInRequest {
+			return ErrOfferAnswerMissing
+		}
+		return nil
+	}
+
+	if oa.state == OfferAnswerStable {
+		// Same description repeated, like in 2xx after reliable 1xx, is not new offer
+		last := oa.remote
+		if local {
+			last = oa.local
+		}
+		if isResponse && bytes.Equal(last, body) {
+			return nil
+		}
+		if method == sip.ACK || (isResponse && method != sip.INVITE) {
+			return ErrOfferAnswerUnexpected
+		}
+
+		oa.offer = body
+		oa.offerCSeq = cseq.SeqNo
+		oa.offerMethod = method
+		oa.offerInRequest = !isResponse
+		oa.state = OfferAnswerRemoteOffer
+		if local {
+			oa.state = OfferAnswerLocalOffer
+		}
+		return nil
+	}
+
+	if !oa.waitsAnswerFrom(local) || !oa.answers(msg, isResponse) {
+		if !isResponse && method != sip.ACK {
+			return ErrOfferAnswerPending
+		}
+		return ErrOfferAnswerUnexpected
+	}
+
+	if local {
+		oa.local, oa.remote = body, oa.offer
+	} else {
+		oa.local, oa.remote = oa.offer, body
+	}
+	oa.rollback()
+	return nil
+}
+
+// waitsAnswerFrom reports is answer expected from side
+func (oa *OfferAnswer) waitsAnswerFrom(local bool) bool {
+	if local {
+		return oa.state == OfferAnswerRemoteOffer
+	}
+	return oa.state == OfferAnswerLocalOffer
+}
+
+// answers reports can message carry answer on pending offer.
+// Offer in request is answered in its response, offer in response is answered in ACK or PRACK
+func (oa *OfferAnswer) answers(msg sip.Message, isResponse bool) bool {
+	cseq := msg.CSeq()
+	if oa.offerInRequest {
+		return isResponse && oa.pendingTransaction(cseq)
+	}
+	if isResponse {
+		return false
+	}
+	switch cseq.MethodName {
+	case sip.ACK:
+		return cseq.SeqNo == oa.offerCSeq
+	case sip.PRACK:
+		return true
+	}
+	return false
+}
+
+func (oa *OfferAnswer) pendingTransaction(cseq *sip.CSeqHeader) bool {
+	if cseq.SeqNo != oa.offerCSeq {
+		return false
+	}
+	// ACK has CSeq number of INVITE
+	return cseq.MethodName == oa.offerMethod || (cseq.MethodName == sip.ACK && oa.offerMethod == sip.INVITE)
+}
+
+// cancel rolls back offer pending in request, ex. when its transaction failed
+func (oa *OfferAnswer) cancel(req *sip.Request) {
+	oa.mu.Lock()
+	defer oa.mu.Unlock()
+	if cseq := req.CSeq(); cseq != nil && oa.state != OfferAnswerStable && oa.pendingTransaction(cseq) {
+		oa.rollback()
+	}
+}
+
+func (oa *OfferAnswer) rollback() {
+	oa.state = OfferAnswerStable
+	oa.offer = nil
+	oa.offerCSeq = 0
+	oa.offerMethod = ""
+	oa.offerInRequest = false
+}
+
+func offerAnswerCarrier(method sip.RequestMethod) bool {
+	switch method {
+	case sip.INVITE, sip.ACK, sip.UPDATE, sip.PRACK:
+		return true
+	}
+	return false
+}
+
+// isSDPContent reports is body session description. Missing Content-Type is treated as SDP
+func isSDPContent(msg sip.Message) bool {
+	h, ok := msg.(interface{ ContentType() *sip.ContentTypeHeader })
+	if !ok {
+		return true
+	}
+	ct := h.ContentType()
+	if ct == nil {
+		return true
+	}
+	mediaType, _, _ := strings.Cut(ct.Value(), ";")
+	return strings.EqualFold(strings.TrimSpace(mediaType), "application/sdp")
+}
+
+// OfferAnswer returns SDP offer/answer tracker of dialog.
+// Dialog sessions apply INVITE, responses and ACK they send or receive
+func (d *Dialog) OfferAnswer() *OfferAnswer {
+	return &d.offerAnswer
+}
+
+// trackOfferAnswer applies message on dialog offer/answer without enforcing it
+func (d *Dialog) trackOfferAnswer(msg sip.Message, local bool) {
+	if err := d.offerAnswer.apply(msg, local); err != nil {
+		log.Debug().Err(err).Str("cseq", msg.CSeq().Value()).Msg("Offer/answer violation")
+	}
+}
@@ -0,0 +1,97 @@
+package sipgo
+
+import (
+	"testing"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOfferAnswer(t *testing.T) {
+	newReq := func(method sip.RequestMethod, seq uint32, body string) *sip.Request {
+		req := sip.NewRequest(method, &sip.Uri{User: "bob", Host: "127.0.0.1"})
+		req.AppendHeader(&sip.ToHeader{Address: sip.Uri{User: "bob", Host: "127.0.0.1"}, Params: sip.NewParams()})
+		req.AppendHeader(&sip.CSeqHeader{SeqNo: seq, MethodName: method})
+		if body != "" {
+			req.SetBody([]byte(body))
+		}
+		return req
+	}
+	newRes := func(req *sip.Request, code sip.StatusCode, body string) *sip.Response {
+		var b []byte
+		if body != "" {
+			b = []byte(body)
+		}
+		return sip.NewResponseFromRequest(req, code, "", b)
+	}
+
+	t.Run("OfferInInvite", func(t *testing.T) {
+		oa := OfferAnswer{}
+		invite := newReq(sip.INVITE, 1, "offer")
+		require.NoError(t, oa.Local(invite))
+		assert.Equal(t, OfferAnswerLocalOffer, oa.State())
+
+		// Unreliable 1xx is ignored
+		require.NoError(t, oa.Remote(newRes(invite, 183, "early")))
+		assert.Equal(t, OfferAnswerLocalOffer, oa.State())
+
+		require.NoError(t, oa.Remote(newRes(invite, 200, "answer")))
+		assert.Equal(t, OfferAnswerStable, oa.State())
+		assert.Equal(t, "offer", string(oa.LocalDescription()))
+		assert.Equal(t, "answer", string(oa.RemoteDescription()))
+
+		require.NoError(t, oa.Local(newReq(sip.ACK, 1, "")))
+		assert.ErrorIs(t, oa.Local(newReq(sip.ACK, 1, "offer")), ErrOfferAnswerUnexpected)
+	})
+
+	t.Run("OfferIn2xx", func(t *testing.T) {
+		oa := OfferAnswer{}
+		invite := newReq(sip.INVITE, 1, "")
+		require.NoError(t, oa.Remote(invite))
+		require.NoError(t, oa.Local(newRes(invite, 200, "offer")))
+		assert.Equal(t, OfferAnswerLocalOffer, oa.State())
+
+		assert.ErrorIs(t, oa.Remote(newReq(sip.ACK, 1, "")), ErrOfferAnswerMissing)
+		require.NoError(t, oa.Remote(newReq(sip.ACK, 1, "answer")))
+		assert.Equal(t, "answer", string(oa.RemoteDescription()))
+	})
+
+	t.Run("Glare", func(t *testing.T) {
+		oa := OfferAnswer{}
+		update := newReq(sip.UPDATE, 2, "offer")
+		require.NoError(t, oa.Local(update))
+		assert.ErrorIs(t, oa.Remote(newReq(sip.INVITE, 5, "other")), ErrOfferAnswerPending)
+		assert.ErrorIs(t, oa.Local(newReq(sip.UPDATE, 3, "again")), ErrOfferAnswerPending)
+
+		// Rejected offer is rolled back
+		require.NoError(t, oa.Remote(newRes(update, sip.StatusRequestPending, "")))
+		assert.Equal(t, OfferAnswerStable, oa.State())
+		assert.Nil(t, oa.LocalDescription())
+	})
+
+	t.Run("OfferInReliable1xx", func(t *testing.T) {
+		oa := OfferAnswer{}
+		invite := newReq(sip.INVITE, 1, "")
+		require.NoError(t, oa.Local(invite))
+		res := newRes(invite, 183, "offer")
+		res.AppendHeader(sip.NewHeader("RSeq", "1"))
+		require.NoError(t, oa.Remote(res))
+		require.NoError(t, oa.Local(newReq(sip.PRACK, 2, "answer")))
+		assert.Equal(t, OfferAnswerStable, oa.State())
+
+		// Same description in 2xx is not new offer
+		require.NoError(t, oa.Remote(newRes(invite, 200, "offer")))
+		assert.Equal(t, OfferAnswerStable, oa.State())
+	})
+
+	t.Run("NotSDP", func(t *testing.T) {
+		oa := OfferAnswer{}
+		info := newReq(sip.INFO, 2, "Signal=1")
+		require.NoError(t, oa.Local(info))
+		update := newReq(sip.UPDATE, 3, "text")
+		update.AppendHeader(sip.NewHeader("Content-Type", "text/plain"))
+		require.NoError(t, oa.Local(update))
+		assert.Equal(t, OfferAnswerStable, oa.State())
+	})
+}
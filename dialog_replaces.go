@@ -0,0 +1,175 @@
+package sipgo
+
+import (
+	"errors"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+var (
+	ErrDialogReplacesMissing    = errors.New("No Replaces header")
+	ErrDialogReplacesEarlyOnly  = errors.New("Replaced dialog is not early")
+	ErrDialogReplacesTerminated = errors.New("Replaced dialog is terminated")
+)
+
+// ReplacesFunc decides should incoming INVITE with Replaces take over replaced dialog, ex. attended transfer or call pickup.
+// Returning error rejects INVITE. In case of sip.StatusError its code is responded, otherwise 603 Decline
+type ReplacesFunc func(req *sip.Request, replaced *Dialog) error
+
+// OnReplaces sets hook for INVITE with Replaces header read with ReadInvite.
+// Replaces is matched to dialogs of this server and passed clients. Unmatched INVITE is rejected per RFC 3891
+// before hook is called. Once new session is confirmed, application should terminate replaced dialog with BYE.
+// It must be set before reading INVITE
+// https://datatracker.ietf.org/doc/html/rfc3891#section-3
+func (s *DialogServer) OnReplaces(fn ReplacesFunc, clients ...*DialogClient) {
+	s.onReplaces = fn
+	s.replacesClients = clients
+}
+
+// MatchReplaces returns dialog session referenced by Replaces header of incoming INVITE.
+// Returns ErrDialogDoesNotExists in case there is no such dialog, ErrDialogReplacesTerminated in case it is terminated
+// and ErrDialogReplacesEarlyOnly in case it is confirmed and header has early-only flag
+// https://datatracker.ietf.org/doc/html/rfc3891#section-3
+func (s *DialogServer) MatchReplaces(req *sip.Request) (*DialogServerSession, error) {
+	replaces := req.Replaces()
+	if replaces == nil {
+		return nil, ErrDialogReplacesMissing
+	}
+
+	dt := s.loadDialog(replaces.DialogIDUAS())
+	if dt == nil {
+		return nil, ErrDialogDoesNotExists
+	}
+	if err := matchReplaces(replaces, &dt.Dialog, false); err != nil {
+		return nil, err
+	}
+	return dt, nil
+}
+
+// MatchReplaces returns dialog session referenced by Replaces header of incoming INVITE.
+// Early dialog initiated by this client can not be replaced and ErrDialogDoesNotExists is returned.
+// Check DialogServer.MatchReplaces for more
+func (dc *DialogClient) MatchReplaces(req *sip.Request) (*DialogClientSession, error) {
+	replaces := req.Replaces()
+	if replaces == nil {
+		return nil, ErrDialogReplacesMissing
+	}
+
+	dt := dc.loadDialog(replaces.DialogIDUAC())
+	if dt == nil {
+		return nil, ErrDialogDoesNotExists
+	}
+	if err := matchReplaces(replaces, &dt.Dialog, true); err != nil {
+		return nil, err
+	}
+	return dt, nil
+}
+
+func matchReplaces(replaces *sip.ReplacesHeader, d *Dialog, uac bool) error {
+	switch sip.DialogState(d.state.Load()) {
+	case sip.DialogStateEnded:
+		return ErrDialogReplacesTerminated
+	case sip.DialogStateEarly:
+		if uac {
+			return ErrDialogDoesNotExists
+		}
+	default:
+		if replaces.EarlyOnly {
+			return ErrDialogReplacesEarlyOnly
+		}
+	}
+	return nil
+}
+
+// readReplaces matches Replaces of INVITE and passes replaced dialog to hook. On error INVITE is rejected
+func (s *DialogServer) readReplaces(req *sip.Request, tx sip.ServerTransaction) error {
+	hdrs := req.GetHeaders("Replaces")
+	if len(hdrs) == 0 {
+		return nil
+	}
+
+	var err error
+	if len(hdrs) > 1 {
+		err = sip.StatusError{Code: sip.StatusBadRequest, Reason: "Multiple Replaces Headers"}
+	} else {
+		err = s.replacesDialog(req, func(d *Dialog) error {
+			return s.onReplaces(req, d)
+		})
+	}
+	if err == nil {
+		return nil
+	}
+
+	res := dialogRefErrResponse(req, "Replaces", err)
+	if rerr := tx.Respond(res); rerr != nil {
+		return errors.Join(err, rerr)
+	}
+	return err
+}
+
+func (s *DialogServer) replacesDialog(req *sip.Request, fn func(d *Dialog) error) error {
+	dt, err := s.MatchReplaces(req)
+	if err == nil {
+		return fn(&dt.Dialog)
+	}
+	if !errors.Is(err, ErrDialogDoesNotExists) {
+		return err
+	}
+
+	for _, dc := range s.replacesClients {
+		dt, cerr := dc.MatchReplaces(req)
+		if cerr == nil {
+			return fn(&dt.Dialog)
+		}
+		if !errors.Is(cerr, ErrDialogDoesNotExists) {
+			return cerr
+		}
+	}
+	return err
+}
+
+// dialogRefErrResponse creates response rejecting INVITE with Replaces or Join header.
+// Header is name of header used in reason phrase
+func dialogRefErrResponse(req *sip.Request, header string, err error) *sip.Response {
+	switch {
+	case errors.Is(err, ErrDialogReplacesMissing), errors.Is(err, ErrDialogJoinMissing):
+		// Header exists but it failed to parse
+		return sip.NewResponseFromRequest(req, sip.StatusBadRequest, "Invalid "+header+" Header", nil)
+	case errors.Is(err, ErrDialogDoesNotExists):
+		return sip.NewResponseFromRequest(req, sip.StatusCallTransactionDoesNotExists, "Call/Transaction Does Not Exist", nil)
+	case errors.Is(err, ErrDialogReplacesTerminated), errors.Is(err, ErrDialogJoinTerminated):
+		return sip.NewResponseFromRequest(req, sip.StatusGlobalDecline, "Decline", nil)
+	case errors.Is(err, ErrDialogReplacesEarlyOnly):
+		return sip.NewResponseFromRequest(req, sip.StatusBusyHere, "Busy Here", nil)
+	}
+
+	var se sip.StatusError
+	if pse := (*sip.StatusError)(nil); errors.As(err, &pse) {
+		se = *pse
+	} else if !errors.As(err, &se) {
+		return sip.NewResponseFromRequest(req, sip.StatusGlobalDecline, "Decline", nil)
+	}
+	res := sip.NewResponseFromRequest(req, se.Code, se.Reason, nil)
+	for _, h := range se.Headers {
+		res.AppendHeader(h)
+	}
+	return res
+}
+
+// ReplacesHeader returns Replaces header which remote party of this dialog matches to this dialog
+func (s *DialogServerSession) ReplacesHeader() *sip.ReplacesHeader {
+	return &sip.ReplacesHeader{
+		CallID:  s.InviteRequest.CallID().Value(),
+		ToTag:   s.InviteRequest.From().Params["tag"],
+		FromTag: s.InviteResponse.To().Params["tag"],
+	}
+}
+
+// ReplacesHeader returns Replaces header which remote party of this dialog matches to this dialog
+func (s *DialogClientSession) ReplacesHeader() *sip.ReplacesHeader {
+	return &sip.ReplacesHeader{
+		CallID:  s.InviteRequest.CallID().Value(),
+		ToTag:   s.InviteResponse.To().Params["tag"],
+		FromTag: s.InviteRequest.From().Params["tag"],
+	}
+}
@@ -0,0 +1,148 @@
+package sipgo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialogServerMatchReplaces(t *testing.T) {
+	ua, _ := NewUA()
+	defer ua.Close()
+	cli, err := NewClient(ua)
+	require.NoError(t, err)
+
+	dsrv := NewDialogServer(cli, sip.ContactHeader{Address: sip.Uri{User: "bob", Host: "127.0.0.1", Port: 5060}})
+
+	invite, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	invite.AppendHeader(&sip.ContactHeader{Address: sip.Uri{User: "alice", Host: "127.0.0.2", Port: 5060}})
+	dtx, err := dsrv.ReadInvite(invite, siptest.NewServerTxRecorder(invite))
+	require.NoError(t, err)
+	require.NoError(t, dtx.Respond(180, "Ringing", nil))
+
+	// Call pickup of ringing call. Tags are as seen by us
+	replaces := &sip.ReplacesHeader{
+		CallID:    invite.CallID().Value(),
+		ToTag:     dtx.InviteResponse.To().Params["tag"],
+		FromTag:   invite.From().Params["tag"],
+		EarlyOnly: true,
+	}
+	req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.3:5060")
+	req.AppendHeader(sip.NewHeader("Replaces", replaces.Value()))
+
+	matched, err := dsrv.MatchReplaces(req)
+	require.NoError(t, err)
+	assert.Equal(t, dtx, matched)
+
+	// Early only can not replace answered call
+	require.NoError(t, dtx.Respond(200, "OK", nil))
+	_, err = dsrv.MatchReplaces(req)
+	require.ErrorIs(t, err, ErrDialogReplacesEarlyOnly)
+
+	req.RemoveHeader("Replaces")
+	req.AppendHeader(&sip.ReplacesHeader{CallID: replaces.CallID, ToTag: replaces.FromTag, FromTag: replaces.ToTag})
+	_, err = dsrv.MatchReplaces(req)
+	require.ErrorIs(t, err, ErrDialogDoesNotExists)
+
+	req.RemoveHeader("Replaces")
+	_, err = dsrv.MatchReplaces(req)
+	require.ErrorIs(t, err, ErrDialogReplacesMissing)
+
+	// Header for remote party references this dialog from its side
+	remote := dtx.ReplacesHeader()
+	assert.Equal(t, replaces.ToTag, remote.FromTag)
+	assert.Equal(t, replaces.FromTag, remote.ToTag)
+}
+
+func TestDialogServerOnReplaces(t *testing.T) {
+	ua, _ := NewUA()
+	defer ua.Close()
+	cli, err := NewClient(ua)
+	require.NoError(t, err)
+
+	dsrv := NewDialogServer(cli, sip.ContactHeader{Address: sip.Uri{User: "bob", Host: "127.0.0.1", Port: 5060}})
+	var replaced *Dialog
+	dsrv.OnReplaces(func(req *sip.Request, d *Dialog) error {
+		if req.From().Address.User == "mallory" {
+			return sip.StatusError{Code: sip.StatusForbidden, Reason: "Forbidden"}
+		}
+		replaced = d
+		return nil
+	})
+
+	invite, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	invite.AppendHeader(&sip.ContactHeader{Address: sip.Uri{User: "alice", Host: "127.0.0.2", Port: 5060}})
+	dtx, err := dsrv.ReadInvite(invite, siptest.NewServerTxRecorder(invite))
+	require.NoError(t, err)
+	require.NoError(t, dtx.Respond(200, "OK", nil))
+	replaces := dtx.ReplacesHeader()
+	replaces.ToTag, replaces.FromTag = replaces.FromTag, replaces.ToTag
+
+	readInvite := func(user string, headers ...sip.Header) (*DialogServerSession, *sip.Response, error) {
+		req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.3:5060")
+		req.AppendHeader(&sip.ContactHeader{Address: sip.Uri{User: user, Host: "127.0.0.3", Port: 5060}})
+		req.From().Address.User = user
+		for _, h := range headers {
+			req.AppendHeader(h)
+		}
+		tx := siptest.NewServerTxRecorder(req)
+		s, err := dsrv.ReadInvite(req, tx)
+		if res := tx.Result(); len(res) > 0 {
+			return s, res[len(res)-1], err
+		}
+		return s, nil, err
+	}
+
+	s, res, err := readInvite("carol", replaces)
+	require.NoError(t, err)
+	require.NotNil(t, s)
+	assert.Nil(t, res)
+	assert.Equal(t, &dtx.Dialog, replaced)
+
+	_, res, err = readInvite("mallory", replaces)
+	require.Error(t, err)
+	assert.Equal(t, sip.StatusForbidden, res.StatusCode)
+
+	_, res, err = readInvite("carol", &sip.ReplacesHeader{CallID: "unknown", ToTag: "1", FromTag: "2"})
+	require.ErrorIs(t, err, ErrDialogDoesNotExists)
+	assert.Equal(t, sip.StatusCallTransactionDoesNotExists, res.StatusCode)
+
+	_, res, err = readInvite("carol", replaces, replaces.Clone())
+	require.Error(t, err)
+	assert.Equal(t, sip.StatusBadRequest, res.StatusCode)
+
+	// Terminated dialog is declined
+	dtx.setState(sip.DialogStateEnded)
+	_, res, err = readInvite("carol", replaces)
+	require.ErrorIs(t, err, ErrDialogReplacesTerminated)
+	assert.Equal(t, sip.StatusGlobalDecline, res.StatusCode)
+
+	// Without Replaces hook is not called
+	replaced = nil
+	_, _, err = readInvite("dave")
+	require.NoError(t, err)
+	assert.Nil(t, replaced)
+}
+
+func TestMatchReplacesUACEarly(t *testing.T) {
+	d := &Dialog{}
+	d.state.Store(int32(sip.DialogStateEarly))
+	err := matchReplaces(&sip.ReplacesHeader{}, d, true)
+	assert.True(t, errors.Is(err, ErrDialogDoesNotExists))
+	assert.NoError(t, matchReplaces(&sip.ReplacesHeader{}, d, false))
+}
+
+func TestDialogRefErrResponse(t *testing.T) {
+	req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	res := dialogRefErrResponse(req, "Replaces", ErrDialogReplacesMissing)
+	assert.Equal(t, sip.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "Invalid Replaces Header", res.Reason)
+
+	res = dialogRefErrResponse(req, "Join", ErrDialogJoinMissing)
+	assert.Equal(t, sip.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "Invalid Join Header", res.Reason)
+}
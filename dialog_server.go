@@ -15,6 +15,12 @@ type DialogServer struct {
 	dialogs    sync.Map // TODO replace with typed version
 	contactHDR sip.ContactHeader
 	c          *Client
+	onState    DialogStateFunc
+
+	onReplaces      ReplacesFunc
+	replacesClients []*DialogClient
+	onJoin          JoinFunc
+	joinClients     []*DialogClient
 }
 
 func (s *DialogServer) loadDialog(id string) *DialogServerSession {
@@ -48,20 +54,66 @@ func (s *DialogServer) ReadInvite(req *sip.Request, tx sip.ServerTransaction) (*
 		return nil, ErrDialogInviteNoContact
 	}
 
+	if s.onReplaces != nil {
+		if err := s.readReplaces(req, tx); err != nil {
+			return nil, err
+		}
+	}
+	if s.onJoin != nil {
+		if err := s.readJoin(req, tx); err != nil {
+			return nil, err
+		}
+	}
+
+	if slots := s.c.callSlots; slots != nil {
+		if err := slots.acquire(tx.Done(), req); err != nil {
+			res := sip.NewResponseFromRequest(req, sip.StatusBusyHere, "Busy Here", nil)
+			if rerr := tx.Respond(res); rerr != nil {
+				return nil, errors.Join(err, rerr)
+			}
+			return nil, err
+		}
+	}
+
 	dtx := &DialogServerSession{
 		Dialog: Dialog{
 			InviteRequest: req,
 			state:         atomic.Int32{},
 			stateCh:       make(chan sip.DialogState, 3),
+			onState:       s.onState,
+			notifies:      make(chan *sip.Request, 5),
+			slot:          s.c.callSlots,
 			done:          make(chan struct{}),
 		},
-		inviteTx: tx,
-		s:        s,
+		inviteTx:   tx,
+		s:          s,
+		contactHDR: s.contactFor(req),
 	}
+	dtx.trackOfferAnswer(req, false)
 
 	return dtx, nil
 }
 
+// contactFor returns contact with address advertised for listener on which request is received
+func (s *DialogServer) contactFor(req *sip.Request) *sip.ContactHeader {
+	cont := s.contactHDR.Clone()
+	if laddr := req.LocalAddr(); laddr != "" {
+		if adv, ok := s.c.tp.GetAdvertisedAddr(req.Transport(), laddr); ok {
+			cont.Address.Host = adv.Host
+			cont.Address.Port = adv.Port
+		}
+	}
+
+	// Contact must be sips for sips Request-URI or top Record-Route
+	// https://datatracker.ietf.org/doc/html/rfc3261#section-12.1.1
+	if req.Recipient != nil && req.Recipient.IsEncrypted() {
+		cont.Address.Encrypted = true
+	} else if rr := req.RecordRoute(); rr != nil && rr.Address.IsEncrypted() {
+		cont.Address.Encrypted = true
+	}
+	return cont
+}
+
 // ReadAck should read from your OnAck handler
 func (s *DialogServer) ReadAck(req *sip.Request, tx sip.ServerTransaction) error {
 	id, err := sip.MakeDialogIDFromRequest(req)
@@ -78,6 +130,7 @@ func (s *DialogServer) ReadAck(req *sip.Request, tx sip.ServerTransaction) error
 		return ErrDialogDoesNotExists
 	}
 
+	dt.trackOfferAnswer(req, false)
 	dt.setState(sip.DialogStateConfirmed)
 
 	// Acks are normally just absorbed, but in case of proxy
@@ -116,15 +169,41 @@ func (s *DialogServer) ReadBye(req *sip.Request, tx sip.ServerTransaction) error
 	return nil
 }
 
+// ReadNotify should read from your OnNotify handler.
+// NOTIFY within dialog is responded with 200 and passed to dialog session, for ex. to track transfer progress
+func (s *DialogServer) ReadNotify(req *sip.Request, tx sip.ServerTransaction) error {
+	id, err := sip.MakeDialogIDFromRequest(req)
+	if err != nil {
+		return errors.Join(ErrDialogOutsideDialog, err)
+	}
+
+	dt := s.loadDialog(id)
+	if dt == nil {
+		return ErrDialogDoesNotExists
+	}
+
+	res := sip.NewResponseFromRequest(req, 200, "OK", nil)
+	if err := tx.Respond(res); err != nil {
+		return err
+	}
+
+	dt.passNotify(req)
+	return nil
+}
+
 type DialogServerSession struct {
 	Dialog
 	inviteTx sip.ServerTransaction
 	s        *DialogServer
+	// contactHDR is dialog server contact with address advertised by listener INVITE is received on
+	contactHDR *sip.ContactHeader
 }
 
 // Close is always good to call for cleanup or terminating dialog state
 func (s *DialogServerSession) Close() error {
-	s.s.dialogs.Delete(s.ID)
+	s.deleteDialog()
+	s.releaseSlot()
+	s.reportClosed()
 	// s.setState(sip.DialogStateEnded)
 	// ctx, _ := context.WithTimeout(context.Background(), transaction.Timer_B)
 	// return s.Bye(ctx)
@@ -151,7 +230,14 @@ func (s *DialogServerSession) WriteResponse(res *sip.Response) error {
 	tx := s.inviteTx
 
 	// Must add contact header
-	res.AppendHeader(&s.s.contactHDR)
+	res.AppendHeader(s.contactHDR)
+	// Same To tag must be used for all responses, as early dialog becomes confirmed one
+	// https://datatracker.ietf.org/doc/html/rfc3261#section-8.2.6.2
+	if prev := s.Dialog.InviteResponse; prev != nil && res.StatusCode != sip.StatusTrying {
+		if tag, ok := prev.To().Params.Get("tag"); ok {
+			res.To().Params.Add("tag", tag)
+		}
+	}
 	s.Dialog.InviteResponse = res
 
 	// Do we have cancel in meantime
@@ -165,8 +251,20 @@ func (s *DialogServerSession) WriteResponse(res *sip.Response) error {
 	default:
 	}
 
+	s.trackOfferAnswer(res, true)
+	if res.IsProvisional() {
+		if res.StatusCode != sip.StatusTrying {
+			if id, err := sip.MakeDialogIDFromResponse(res); err == nil {
+				s.storeDialog(id)
+				s.setState(sip.DialogStateEarly)
+			}
+		}
+		return tx.Respond(res)
+	}
+
 	if !res.IsSuccess() {
 		// This will not create dialog so we will just respond
+		s.deleteDialog()
 		return tx.Respond(res)
 	}
 
@@ -175,17 +273,75 @@ func (s *DialogServerSession) WriteResponse(res *sip.Response) error {
 		return err
 	}
 
-	s.Dialog.ID = id
 	s.setState(sip.DialogStateEstablished)
 
 	if err := tx.Respond(res); err != nil {
 		return err
 	}
 
-	s.s.dialogs.Store(id, s)
+	s.storeDialog(id)
 	return nil
 }
 
+// storeDialog stores dialog under id. Early dialog has same id
+func (s *DialogServerSession) storeDialog(id string) {
+	if s.ID == id {
+		return
+	}
+	s.deleteDialog()
+	s.ID = id
+	s.s.dialogs.Store(id, s)
+	s.s.c.dialogs.Add(1)
+}
+
+func (s *DialogServerSession) deleteDialog() {
+	if _, loaded := s.s.dialogs.LoadAndDelete(s.ID); loaded {
+		s.s.c.dialogs.Add(-1)
+	}
+}
+
+// newRequest creates new request within dialog. From and To are reversed as we are UAS
+// https://datatracker.ietf.org/doc/html/rfc3261#section-12.2.1.1
+func (s *DialogServerSession) newRequest(method sip.RequestMethod, body []byte) *sip.Request {
+	inviteRequest, inviteResponse := s.InviteRequest, s.InviteResponse
+
+	recipient := inviteRequest.Recipient
+	if cont := inviteRequest.Contact(); cont != nil {
+		recipient = &cont.Address
+	}
+
+	req := sip.NewRequest(method, recipient.Clone())
+	// Route set is Record-Route of request in same order
+	sip.RouteSetApply(req, sip.RouteSetFromRecordRoute(inviteRequest, false))
+
+	from := inviteResponse.From()
+	to := inviteResponse.To()
+	req.AppendHeader(&sip.FromHeader{
+		DisplayName: to.DisplayName,
+		Address:     to.Address,
+		Params:      to.Params.Clone().(sip.HeaderParams),
+	})
+	req.AppendHeader(&sip.ToHeader{
+		DisplayName: from.DisplayName,
+		Address:     from.Address,
+		Params:      from.Params.Clone().(sip.HeaderParams),
+	})
+
+	if h := inviteRequest.CallID(); h != nil {
+		req.AppendHeader(sip.HeaderClone(h))
+	}
+
+	req.AppendHeader(&sip.CSeqHeader{
+		SeqNo:      s.lastCSeqNo.Add(1),
+		MethodName: method,
+	})
+	req.AppendHeader(s.contactHDR)
+	s.appendRequestHeaders(req)
+	req.SetBody(body)
+	req.SetTransport(inviteRequest.Transport())
+	return req
+}
+
 func (s *DialogServerSession) Bye(ctx context.Context) error {
 	state := s.state.Load()
 	// In case dialog terminated
@@ -194,7 +350,6 @@ func (s *DialogServerSession) Bye(ctx context.Context) error {
 	}
 
 	cli := s.s.c
-	req := s.Dialog.InviteRequest
 	res := s.Dialog.InviteResponse
 
 	if !res.IsSuccess() {
@@ -215,7 +370,7 @@ func (s *DialogServerSession) Bye(ctx context.Context) error {
 			select {
 			case <-s.inviteTx.Done():
 				// Wait until we timeout
-			case <-time.After(sip.T1):
+			case <-time.After(sip.GetTimers().T1):
 				// Recheck state
 				continue
 			case <-ctx.Done():
@@ -226,54 +381,13 @@ func (s *DialogServerSession) Bye(ctx context.Context) error {
 		break
 	}
 
-	cont := req.Contact()
-	bye := sip.NewRequest(sip.BYE, &cont.Address)
-
-	// Reverse from and to
-	from := res.From()
-	to := res.To()
-	callid := res.CallID()
-
-	newFrom := &sip.FromHeader{
-		DisplayName: to.DisplayName,
-		Address:     to.Address,
-		Params:      to.Params,
-	}
-
-	newTo := &sip.ToHeader{
-		DisplayName: from.DisplayName,
-		Address:     from.Address,
-		Params:      from.Params,
-	}
-
-	bye.AppendHeader(newFrom)
-	bye.AppendHeader(newTo)
-	bye.AppendHeader(callid)
-
-	recordRoute := req.RecordRoute()
-	if recordRoute != nil {
-		if recordRoute.Address.UriParams.Has("lr") {
-			bye.AppendHeader(&sip.RouteHeader{Address: recordRoute.Address})
-		} else {
-			/* TODO
-			   If the route set is not empty, and its first URI does not contain the
-			   lr parameter, the UAC MUST place the first URI from the route set
-			   into the Request-URI, stripping any parameters that are not allowed
-			   in a Request-URI.  The UAC MUST add a Route header field containing
-			   the remainder of the route set values in order, including all
-			   parameters.  The UAC MUST then place the remote target URI into the
-			   Route header field as the last value.
-			*/
-		}
-	}
-
-	callidHDR := bye.CallID()
-	byeID := sip.MakeDialogID(callidHDR.Value(), newFrom.Params["tag"], newTo.Params["tag"])
+	bye := s.newRequest(sip.BYE, nil)
+	byeID := sip.MakeDialogID(bye.CallID().Value(), bye.From().Params["tag"], bye.To().Params["tag"])
 	if s.ID != byeID {
 		return fmt.Errorf("Non matching ID %q %q", s.ID, byeID)
 	}
 
-	tx, err := cli.TransactionRequest(ctx, bye)
+	tx, err := cli.TransactionRequest(ctx, bye, ClientRequestBuild)
 	if err != nil {
 		return err
 	}
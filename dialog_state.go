@@ -0,0 +1,36 @@
+package sipgo
+
+import (
+	"github.com/emiago/sipgo/sip"
+)
+
+// DialogStateFunc is called on every state change of dialog session, ex. for tracking call state of extensions.
+// It is called synchronously and should not block.
+// Session closed before reaching DialogStateEnded, ex. on failed or canceled INVITE, is reported with DialogStateEnded
+type DialogStateFunc func(d *Dialog, state sip.DialogState)
+
+// OnDialogState sets callback for state changes of dialog sessions. It must be set before creating sessions
+func (dc *DialogClient) OnDialogState(fn DialogStateFunc) {
+	dc.onState = fn
+}
+
+// OnDialogState sets callback for state changes of dialog sessions. It must be set before reading INVITE
+func (s *DialogServer) OnDialogState(fn DialogStateFunc) {
+	s.onState = fn
+}
+
+// reportState passes state change to callback. Ended is reported once
+func (d *Dialog) reportState(s sip.DialogState) {
+	if d.onState == nil {
+		return
+	}
+	if s == sip.DialogStateEnded && d.endReported.Swap(true) {
+		return
+	}
+	d.onState(d, s)
+}
+
+// reportClosed reports session closed without reaching ended state
+func (d *Dialog) reportClosed() {
+	d.reportState(sip.DialogStateEnded)
+}
@@ -0,0 +1,112 @@
+package sipgo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testStateRecorder struct {
+	mu     sync.Mutex
+	states []sip.DialogState
+}
+
+func (r *testStateRecorder) record(d *Dialog, s sip.DialogState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states = append(r.states, s)
+}
+
+func (r *testStateRecorder) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states = nil
+}
+
+func (r *testStateRecorder) get() []sip.DialogState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]sip.DialogState(nil), r.states...)
+}
+
+func TestDialogOnDialogState(t *testing.T) {
+	uasUA, err := NewUA(WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer uasUA.Close()
+	uasCli, err := NewClient(uasUA, WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+	uasSrv, uasConn, uasContact := testDialogListen(t, uasUA, "bob")
+	ds := NewDialogServer(uasCli, uasContact)
+	uasStates := &testStateRecorder{}
+	ds.OnDialogState(uasStates.record)
+
+	uasSrv.OnInvite(func(req *sip.Request, tx sip.ServerTransaction) {
+		sess, err := ds.ReadInvite(req, tx)
+		require.NoError(t, err)
+		defer sess.Close()
+		require.NoError(t, sess.Respond(sip.StatusRinging, "Ringing", nil))
+		time.Sleep(20 * time.Millisecond)
+		if req.Recipient.User == "busy" {
+			require.NoError(t, sess.Respond(sip.StatusBusyHere, "Busy Here", nil))
+			return
+		}
+		require.NoError(t, sess.Respond(sip.StatusOK, "OK", nil))
+		<-sess.Done()
+	})
+	uasSrv.OnAck(func(req *sip.Request, tx sip.ServerTransaction) {
+		ds.ReadAck(req, tx)
+	})
+	uasSrv.OnBye(func(req *sip.Request, tx sip.ServerTransaction) {
+		ds.ReadBye(req, tx)
+	})
+	go uasSrv.ServeUDP(uasConn)
+
+	uacUA, err := NewUA(WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer uacUA.Close()
+	uacCli, err := NewClient(uacUA, WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+	dc := NewDialogClient(uacCli, sip.ContactHeader{Address: sip.Uri{User: "alice", Host: "127.0.0.1", Port: 5060}})
+	uacStates := &testStateRecorder{}
+	dc.OnDialogState(uacStates.record)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	t.Run("Answered", func(t *testing.T) {
+		sess, err := dc.Invite(ctx, &uasContact.Address, nil)
+		require.NoError(t, err)
+		require.NoError(t, sess.WaitAnswer(ctx, AnswerOptions{}))
+		// ACK and BYE are handled concurrently by UAS
+		require.Eventually(t, func() bool { return len(uasStates.get()) == 3 }, time.Second, 10*time.Millisecond)
+		require.NoError(t, sess.Bye(ctx))
+		sess.Close()
+
+		assert.Equal(t, []sip.DialogState{sip.DialogStateEarly, sip.DialogStateEstablished, sip.DialogStateConfirmed, sip.DialogStateEnded}, uacStates.get())
+		require.Eventually(t, func() bool { return len(uasStates.get()) == 4 }, time.Second, 10*time.Millisecond)
+		assert.Equal(t, []sip.DialogState{sip.DialogStateEarly, sip.DialogStateEstablished, sip.DialogStateConfirmed, sip.DialogStateEnded}, uasStates.get())
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		uacStates.reset()
+		uasStates.reset()
+		busy := uasContact.Address
+		busy.User = "busy"
+		sess, err := dc.Invite(ctx, &busy, nil)
+		require.NoError(t, err)
+		assert.Error(t, sess.WaitAnswer(ctx, AnswerOptions{}))
+		sess.Close()
+		sess.Close()
+
+		// Closed session is reported ended once
+		assert.Equal(t, []sip.DialogState{sip.DialogStateEarly, sip.DialogStateEnded}, uacStates.get())
+		require.Eventually(t, func() bool { return len(uasStates.get()) == 2 }, time.Second, 10*time.Millisecond)
+		assert.Equal(t, []sip.DialogState{sip.DialogStateEarly, sip.DialogStateEnded}, uasStates.get())
+	})
+}
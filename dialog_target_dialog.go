@@ -0,0 +1,76 @@
+package sipgo
+
+import (
+	"errors"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+var (
+	ErrDialogTargetMissing = errors.New("No Target-Dialog header")
+)
+
+// MatchTargetDialog returns dialog session referenced by Target-Dialog header of out of dialog request, like REFER.
+// Matching dialog can be used for authorizing request. In case dialog does not exist or it is terminated
+// ErrDialogDoesNotExists is returned and request should be processed as if header was not present
+// https://datatracker.ietf.org/doc/html/rfc4538#section-6
+func (s *DialogServer) MatchTargetDialog(req *sip.Request) (*DialogServerSession, error) {
+	target, err := readTargetDialog(req)
+	if err != nil {
+		return nil, err
+	}
+
+	dt := s.loadDialog(target.DialogIDUAS())
+	if dt == nil || sip.DialogState(dt.state.Load()) == sip.DialogStateEnded {
+		return nil, ErrDialogDoesNotExists
+	}
+	return dt, nil
+}
+
+// MatchTargetDialog returns dialog session referenced by Target-Dialog header of out of dialog request.
+// Check DialogServer.MatchTargetDialog for more
+func (dc *DialogClient) MatchTargetDialog(req *sip.Request) (*DialogClientSession, error) {
+	target, err := readTargetDialog(req)
+	if err != nil {
+		return nil, err
+	}
+
+	dt := dc.loadDialog(target.DialogIDUAC())
+	if dt == nil || sip.DialogState(dt.state.Load()) == sip.DialogStateEnded {
+		return nil, ErrDialogDoesNotExists
+	}
+	return dt, nil
+}
+
+func readTargetDialog(req *sip.Request) (*sip.TargetDialogHeader, error) {
+	// Header has meaning only for requests outside of dialog
+	if to := req.To(); to != nil && to.Params.Has("tag") {
+		return nil, ErrDialogOutsideDialog
+	}
+
+	target := req.TargetDialog()
+	if target == nil {
+		return nil, ErrDialogTargetMissing
+	}
+	return target, nil
+}
+
+// TargetDialogHeader returns Target-Dialog header for request sent outside of this dialog,
+// which remote party of this dialog matches to this dialog
+func (s *DialogServerSession) TargetDialogHeader() *sip.TargetDialogHeader {
+	return &sip.TargetDialogHeader{
+		CallID:    s.InviteRequest.CallID().Value(),
+		LocalTag:  s.InviteResponse.To().Params["tag"],
+		RemoteTag: s.InviteRequest.From().Params["tag"],
+	}
+}
+
+// TargetDialogHeader returns Target-Dialog header for request sent outside of this dialog,
+// which remote party of this dialog matches to this dialog
+func (s *DialogClientSession) TargetDialogHeader() *sip.TargetDialogHeader {
+	return &sip.TargetDialogHeader{
+		CallID:    s.InviteRequest.CallID().Value(),
+		LocalTag:  s.InviteRequest.From().Params["tag"],
+		RemoteTag: s.InviteResponse.To().Params["tag"],
+	}
+}
@@ -0,0 +1,59 @@
+package sipgo
+
+import (
+	"testing"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialogServerMatchTargetDialog(t *testing.T) {
+	ua, _ := NewUA()
+	defer ua.Close()
+	cli, err := NewClient(ua)
+	require.NoError(t, err)
+
+	dsrv := NewDialogServer(cli, sip.ContactHeader{Address: sip.Uri{User: "bob", Host: "127.0.0.1", Port: 5060}})
+
+	invite, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	invite.AppendHeader(&sip.ContactHeader{Address: sip.Uri{User: "alice", Host: "127.0.0.2", Port: 5060}})
+	dtx, err := dsrv.ReadInvite(invite, siptest.NewServerTxRecorder(invite))
+	require.NoError(t, err)
+	require.NoError(t, dtx.Respond(200, "OK", nil))
+
+	// Remote party sends REFER outside of dialog. Tags are from its perspective
+	target := &sip.TargetDialogHeader{
+		CallID:    invite.CallID().Value(),
+		LocalTag:  invite.From().Params["tag"],
+		RemoteTag: dtx.InviteResponse.To().Params["tag"],
+	}
+	refer, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	refer.Method = sip.REFER
+	refer.AppendHeader(sip.NewHeader("Target-Dialog", target.Value()))
+
+	matched, err := dsrv.MatchTargetDialog(refer)
+	require.NoError(t, err)
+	assert.Equal(t, dtx, matched)
+
+	// Our header for sending is reversed
+	own := dtx.TargetDialogHeader()
+	assert.Equal(t, target.LocalTag, own.RemoteTag)
+	assert.Equal(t, target.RemoteTag, own.LocalTag)
+
+	refer.RemoveHeader("Target-Dialog")
+	refer.AppendHeader(own)
+	_, err = dsrv.MatchTargetDialog(refer)
+	require.ErrorIs(t, err, ErrDialogDoesNotExists)
+
+	refer.RemoveHeader("Target-Dialog")
+	_, err = dsrv.MatchTargetDialog(refer)
+	require.ErrorIs(t, err, ErrDialogTargetMissing)
+
+	// Header is ignored within dialog
+	refer.AppendHeader(target)
+	refer.To().Params["tag"] = "1234"
+	_, err = dsrv.MatchTargetDialog(refer)
+	require.ErrorIs(t, err, ErrDialogOutsideDialog)
+}
@@ -0,0 +1,87 @@
+package sipgo
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialogRequestHeaders(t *testing.T) {
+	invite, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	invite.AppendHeader(sip.NewHeader("X-CID", "1234"))
+	invite.AppendHeader(sip.NewHeader("Subject", "hello"))
+
+	d := Dialog{InviteRequest: invite}
+	d.PreserveInviteHeaders("X-CID")
+	d.SetRequestHeaders(sip.BYE, sip.NewHeader("X-Reason", "hangup"))
+
+	reinvite := sip.NewRequest(sip.INVITE, invite.Recipient)
+	d.appendRequestHeaders(reinvite)
+	require.NotNil(t, reinvite.GetHeader("X-CID"))
+	assert.Equal(t, "1234", reinvite.GetHeader("X-CID").Value())
+	assert.Nil(t, reinvite.GetHeader("X-Reason"))
+
+	bye := sip.NewRequest(sip.BYE, invite.Recipient)
+	d.appendRequestHeaders(bye)
+	require.NotNil(t, bye.GetHeader("X-Reason"))
+	assert.Nil(t, bye.GetHeader("X-CID"))
+
+	d.SetRequestHeaders(sip.BYE)
+	bye = sip.NewRequest(sip.BYE, invite.Recipient)
+	d.appendRequestHeaders(bye)
+	assert.Nil(t, bye.GetHeader("X-Reason"))
+
+	hdrs := CustomHeaders(invite)
+	require.Len(t, hdrs, 1)
+	assert.Equal(t, "X-CID", hdrs[0].Name())
+}
+
+func TestDialogClientWaitAnswerCanceled(t *testing.T) {
+	ua, err := NewUA(WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer ua.Close()
+	cli, err := NewClient(ua, WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+	dc := NewDialogClient(cli, sip.ContactHeader{Address: sip.Uri{User: "alice", Host: "127.0.0.1", Port: 5060}})
+
+	// UAS never answers until CANCEL is expected
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+	read := func() (*sip.Request, net.Addr) {
+		buf := make([]byte, 65535)
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, raddr, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		msg, err := sip.ParseMessage(buf[:n])
+		require.NoError(t, err)
+		return msg.(*sip.Request), raddr
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sess, err := dc.Invite(ctx, &sip.Uri{User: "bob", Host: "127.0.0.1", Port: conn.LocalAddr().(*net.UDPAddr).Port}, nil)
+	require.NoError(t, err)
+	defer sess.Close()
+	invite, raddr := read()
+
+	cancel()
+	start := time.Now()
+	err = sess.WaitAnswer(ctx, AnswerOptions{})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	// CANCEL is sent once provisional response arrives
+	_, err = conn.WriteTo([]byte(sip.NewResponseFromRequest(invite, sip.StatusRinging, "Ringing", nil).String()), raddr)
+	require.NoError(t, err)
+	req, _ := read()
+	for req.Method == sip.INVITE {
+		// Retransmission sent before provisional response
+		req, _ = read()
+	}
+	assert.Equal(t, sip.CANCEL, req.Method)
+}
@@ -0,0 +1,202 @@
+package sipgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+var (
+	ErrDialogTransferTerminated = errors.New("Dialog terminated during transfer")
+	ErrDialogTransferSipFrag    = errors.New("Invalid transfer sipfrag body")
+)
+
+// TransferResult is final transfer status reported by transferee with NOTIFY sipfrag body
+// https://datatracker.ietf.org/doc/html/rfc3515#section-2.4.5
+type TransferResult struct {
+	StatusCode sip.StatusCode
+	Reason     string
+}
+
+// IsSuccess returns true if transferee reported 2xx from transfer target
+func (r TransferResult) IsSuccess() bool {
+	return r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// TransferTarget is dialog session that can be replaced by attended transfer.
+// Both DialogClientSession and DialogServerSession implement it
+type TransferTarget interface {
+	replacesTarget() sip.Uri
+}
+
+// BlindTransfer sends REFER to remote party with Refer-To target and waits until transfer completes.
+// On success dialog is terminated with BYE.
+// Returned TransferResult reports final status of transfer from NOTIFY.
+// NOTE: NOTIFY requests must be passed with DialogClient.ReadNotify
+func (s *DialogClientSession) BlindTransfer(ctx context.Context, target sip.Uri) (TransferResult, error) {
+	req := s.newRequest(sip.REFER, nil)
+	req.AppendHeader(sip.NewHeader("Refer-To", "<"+target.String()+">"))
+	return dialogTransfer(ctx, s.dc.c, &s.Dialog, req, s.Bye)
+}
+
+// AttendedTransfer sends REFER to remote party asking to replace other call.
+// Check BlindTransfer for more
+func (s *DialogClientSession) AttendedTransfer(ctx context.Context, other TransferTarget) (TransferResult, error) {
+	target := other.replacesTarget()
+	req := s.newRequest(sip.REFER, nil)
+	req.AppendHeader(sip.NewHeader("Refer-To", "<"+target.String()+">"))
+	return dialogTransfer(ctx, s.dc.c, &s.Dialog, req, s.Bye)
+}
+
+func (s *DialogClientSession) replacesTarget() sip.Uri {
+	target := *s.InviteRequest.Recipient
+	if cont := s.InviteResponse.Contact(); cont != nil {
+		target = cont.Address
+	}
+	return replacesTarget(target, s.InviteRequest.CallID().Value(), s.InviteResponse.To().Params["tag"], s.InviteRequest.From().Params["tag"])
+}
+
+// BlindTransfer sends REFER to remote party with Refer-To target and waits until transfer completes.
+// On success dialog is terminated with BYE.
+// Returned TransferResult reports final status of transfer from NOTIFY.
+// NOTE: NOTIFY requests must be passed with DialogServer.ReadNotify
+func (s *DialogServerSession) BlindTransfer(ctx context.Context, target sip.Uri) (TransferResult, error) {
+	req := s.newRequest(sip.REFER, nil)
+	req.AppendHeader(sip.NewHeader("Refer-To", "<"+target.String()+">"))
+	return dialogTransfer(ctx, s.s.c, &s.Dialog, req, s.Bye)
+}
+
+// AttendedTransfer sends REFER to remote party asking to replace other call.
+// Check BlindTransfer for more
+func (s *DialogServerSession) AttendedTransfer(ctx context.Context, other TransferTarget) (TransferResult, error) {
+	target := other.replacesTarget()
+	req := s.newRequest(sip.REFER, nil)
+	req.AppendHeader(sip.NewHeader("Refer-To", "<"+target.String()+">"))
+	return dialogTransfer(ctx, s.s.c, &s.Dialog, req, s.Bye)
+}
+
+func (s *DialogServerSession) replacesTarget() sip.Uri {
+	var target sip.Uri
+	if cont := s.InviteRequest.Contact(); cont != nil {
+		target = cont.Address
+	}
+	return replacesTarget(target, s.InviteRequest.CallID().Value(), s.InviteRequest.From().Params["tag"], s.InviteResponse.To().Params["tag"])
+}
+
+// replacesTarget builds Refer-To uri with escaped Replaces header
+// https://datatracker.ietf.org/doc/html/rfc5589#section-7.1
+func replacesTarget(target sip.Uri, callID string, toTag string, fromTag string) sip.Uri {
+	replaces := (&sip.ReplacesHeader{CallID: callID, ToTag: toTag, FromTag: fromTag}).Value()
+	// Do not modify original uri headers
+	target.Headers = sip.HeaderParams{"Replaces": url.QueryEscape(replaces)}
+	return target
+}
+
+func dialogTransfer(ctx context.Context, c *Client, d *Dialog, req *sip.Request, bye func(ctx context.Context) error) (TransferResult, error) {
+	if sip.DialogState(d.state.Load()) != sip.DialogStateConfirmed {
+		return TransferResult{}, fmt.Errorf("Dialog not confirmed. ACK not send?")
+	}
+
+	// NOTIFYs left from previous transfer, ex. abandoned on context, must not be taken as result of this one
+	d.drainNotifies()
+
+	tx, err := c.TransactionRequest(ctx, req, ClientRequestBuild)
+	if err != nil {
+		return TransferResult{}, err
+	}
+	defer tx.Terminate()
+
+	// Wait final response on REFER
+	for {
+		var res *sip.Response
+		select {
+		case res = <-tx.Responses():
+		case <-tx.Done():
+			return TransferResult{}, tx.Err()
+		case <-ctx.Done():
+			return TransferResult{}, ctx.Err()
+		}
+
+		if res.IsProvisional() {
+			continue
+		}
+
+		if !res.IsSuccess() {
+			return TransferResult{}, &ErrDialogResponse{Res: res}
+		}
+		break
+	}
+
+	result, err := waitTransferNotify(ctx, d, req.CSeq().SeqNo)
+	if err != nil || !result.IsSuccess() {
+		return result, err
+	}
+	return result, bye(ctx)
+}
+
+// waitTransferNotify consumes NOTIFY progress of REFER with CSeq referSeqNo until final status.
+// NOTIFY of other subscriptions within dialog are skipped
+func waitTransferNotify(ctx context.Context, d *Dialog, referSeqNo uint32) (TransferResult, error) {
+	for {
+		select {
+		case notify := <-d.notifies:
+			if !isTransferNotify(notify, referSeqNo) {
+				continue
+			}
+
+			result, err := parseTransferSipFrag(notify.Body())
+			if err != nil {
+				return TransferResult{}, err
+			}
+
+			if result.StatusCode < 200 {
+				continue
+			}
+			return result, nil
+		case <-d.done:
+			return TransferResult{}, ErrDialogTransferTerminated
+		case <-ctx.Done():
+			return TransferResult{}, ctx.Err()
+		}
+	}
+}
+
+// isTransferNotify checks is NOTIFY part of refer subscription created by REFER with CSeq referSeqNo.
+// Id param can be missing for first REFER within dialog
+// https://datatracker.ietf.org/doc/html/rfc3515#section-2.4.6
+func isTransferNotify(notify *sip.Request, referSeqNo uint32) bool {
+	ev := notify.Event()
+	if ev == nil || !strings.EqualFold(ev.Event, "refer") {
+		return false
+	}
+	id := ev.ID()
+	return id == "" || id == strconv.FormatUint(uint64(referSeqNo), 10)
+}
+
+// parseTransferSipFrag parses status line of message/sipfrag body
+// Ex. SIP/2.0 200 OK
+func parseTransferSipFrag(body []byte) (TransferResult, error) {
+	line, _, _ := strings.Cut(string(body), "\r\n")
+	parts := strings.SplitN(strings.TrimSpace(line), " ", 3)
+	if len(parts) < 2 || !strings.HasPrefix(parts[0], "SIP/") {
+		return TransferResult{}, fmt.Errorf("body=%q: %w", line, ErrDialogTransferSipFrag)
+	}
+
+	code, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return TransferResult{}, fmt.Errorf("body=%q: %w", line, ErrDialogTransferSipFrag)
+	}
+
+	result := TransferResult{
+		StatusCode: sip.StatusCode(code),
+	}
+	if len(parts) > 2 {
+		result.Reason = parts[2]
+	}
+	return result, nil
+}
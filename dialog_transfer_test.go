@@ -0,0 +1,164 @@
+package sipgo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTransferSipFrag(t *testing.T) {
+	result, err := parseTransferSipFrag([]byte("SIP/2.0 200 OK\r\n"))
+	require.NoError(t, err)
+	assert.Equal(t, sip.StatusCode(200), result.StatusCode)
+	assert.Equal(t, "OK", result.Reason)
+	assert.True(t, result.IsSuccess())
+
+	result, err = parseTransferSipFrag([]byte("SIP/2.0 100 Trying"))
+	require.NoError(t, err)
+	assert.Equal(t, sip.StatusCode(100), result.StatusCode)
+	assert.False(t, result.IsSuccess())
+
+	result, err = parseTransferSipFrag([]byte("SIP/2.0 486 Busy Here\r\nContent-Length: 0\r\n"))
+	require.NoError(t, err)
+	assert.Equal(t, sip.StatusCode(486), result.StatusCode)
+	assert.Equal(t, "Busy Here", result.Reason)
+
+	_, err = parseTransferSipFrag([]byte("INVITE sip:bob@127.0.0.1 SIP/2.0"))
+	require.ErrorIs(t, err, ErrDialogTransferSipFrag)
+
+	_, err = parseTransferSipFrag(nil)
+	require.ErrorIs(t, err, ErrDialogTransferSipFrag)
+}
+
+func TestDialogTransferNotify(t *testing.T) {
+	notify := func(event string, frag string) *sip.Request {
+		req := sip.NewRequest(sip.NOTIFY, &sip.Uri{User: "alice", Host: "127.0.0.1"})
+		req.AppendHeader(sip.NewHeader("Event", event))
+		req.SetBody([]byte(frag))
+		return req
+	}
+
+	d := &Dialog{notifies: make(chan *sip.Request, 5), done: make(chan struct{})}
+	// Leftover of previous transfer abandoned on context
+	d.passNotify(notify("refer", "SIP/2.0 200 OK\r\n"))
+	d.drainNotifies()
+
+	d.passNotify(notify("dialog", "<dialog-info/>"))
+	d.passNotify(notify("refer;id=2", "SIP/2.0 200 OK\r\n"))
+	d.passNotify(notify("refer;id=3", "SIP/2.0 100 Trying\r\n"))
+	d.passNotify(notify("refer;id=3", "SIP/2.0 486 Busy Here\r\n"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	result, err := waitTransferNotify(ctx, d, 3)
+	require.NoError(t, err)
+	assert.Equal(t, sip.StatusCode(486), result.StatusCode)
+
+	assert.True(t, isTransferNotify(notify("refer", ""), 3))
+	assert.False(t, isTransferNotify(notify("presence;id=3", ""), 3))
+	assert.False(t, isTransferNotify(sip.NewRequest(sip.NOTIFY, &sip.Uri{Host: "127.0.0.1"}), 3))
+}
+
+func TestReplacesTarget(t *testing.T) {
+	orig := sip.Uri{User: "carol", Host: "127.0.0.1", Port: 5060, Headers: sip.HeaderParams{"X": "Y"}}
+	target := replacesTarget(orig, "090459243588173445@a.example", "a6c85cf", "7643")
+
+	assert.Equal(t, "sip:carol@127.0.0.1:5060?Replaces=090459243588173445%40a.example%3Bto-tag%3Da6c85cf%3Bfrom-tag%3D7643", target.String())
+	// Original uri must stay untouched
+	assert.Equal(t, "Y", orig.Headers["X"])
+	_, exists := orig.Headers["Replaces"]
+	assert.False(t, exists)
+}
+
+func TestDialogByeCSeqAfterRefer(t *testing.T) {
+	// REFER is accepted, but NOTIFY never comes, so transfer ends on context
+	refers := make(chan *sip.Request, 2)
+	byes := make(chan *sip.Request, 2)
+	onRefer := func(req *sip.Request, tx sip.ServerTransaction) {
+		refers <- req
+		tx.Respond(sip.NewResponseFromRequest(req, sip.StatusAccepted, "Accepted", nil))
+	}
+
+	uasUA, err := NewUA(WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer uasUA.Close()
+	uasCli, err := NewClient(uasUA, WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+	uasSrv, uasConn, uasContact := testDialogListen(t, uasUA, "bob")
+	ds := NewDialogServer(uasCli, uasContact)
+
+	uasSessions := make(chan *DialogServerSession, 1)
+	uasSrv.OnInvite(func(req *sip.Request, tx sip.ServerTransaction) {
+		sess, err := ds.ReadInvite(req, tx)
+		require.NoError(t, err)
+		require.NoError(t, sess.Respond(sip.StatusOK, "OK", nil))
+		uasSessions <- sess
+	})
+	uasSrv.OnAck(func(req *sip.Request, tx sip.ServerTransaction) {
+		ds.ReadAck(req, tx)
+	})
+	uasSrv.OnRefer(onRefer)
+	uasSrv.OnBye(func(req *sip.Request, tx sip.ServerTransaction) {
+		byes <- req
+		ds.ReadBye(req, tx)
+	})
+	go uasSrv.ServeUDP(uasConn)
+
+	uacUA, err := NewUA(WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer uacUA.Close()
+	uacCli, err := NewClient(uacUA, WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+	uacSrv, uacConn, uacContact := testDialogListen(t, uacUA, "alice")
+	dc := NewDialogClient(uacCli, uacContact)
+	uacSrv.OnRefer(onRefer)
+	uacSrv.OnBye(func(req *sip.Request, tx sip.ServerTransaction) {
+		byes <- req
+		dc.ReadBye(req, tx)
+	})
+	go uacSrv.ServeUDP(uacConn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	call := func() (*DialogClientSession, *DialogServerSession) {
+		sess, err := dc.Invite(ctx, &uasContact.Address, nil)
+		require.NoError(t, err)
+		require.NoError(t, sess.WaitAnswer(ctx, AnswerOptions{}))
+		uasSess := <-uasSessions
+		require.Eventually(t, func() bool { return sip.DialogState(uasSess.state.Load()) == sip.DialogStateConfirmed }, time.Second, 10*time.Millisecond)
+		return sess, uasSess
+	}
+
+	transfer := func(blind func(ctx context.Context, target sip.Uri) (TransferResult, error)) {
+		tctx, tcancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer tcancel()
+		_, err := blind(tctx, sip.Uri{User: "carol", Host: "127.0.0.1"})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	}
+
+	t.Run("UAC", func(t *testing.T) {
+		sess, uasSess := call()
+		defer uasSess.Close()
+		transfer(sess.BlindTransfer)
+		require.NoError(t, sess.Bye(ctx))
+
+		refer, bye := <-refers, <-byes
+		assert.Equal(t, sess.InviteRequest.CSeq().SeqNo+1, refer.CSeq().SeqNo)
+		assert.Equal(t, refer.CSeq().SeqNo+1, bye.CSeq().SeqNo)
+	})
+
+	t.Run("UAS", func(t *testing.T) {
+		sess, uasSess := call()
+		defer sess.Close()
+		transfer(uasSess.BlindTransfer)
+		require.NoError(t, uasSess.Bye(ctx))
+
+		refer, bye := <-refers, <-byes
+		assert.Equal(t, refer.CSeq().SeqNo+1, bye.CSeq().SeqNo)
+	})
+}
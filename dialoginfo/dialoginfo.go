@@ -0,0 +1,113 @@
+package dialoginfo
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+const (
+	// ContentType is content type of dialog-info document
+	ContentType = "application/dialog-info+xml"
+	// Namespace is dialog-info XML namespace
+	Namespace = "urn:ietf:params:xml:ns:dialog-info"
+
+	// Document states
+	StateFull    = "full"
+	StatePartial = "partial"
+
+	// Dialog directions
+	DirectionInitiator = "initiator"
+	DirectionRecipient = "recipient"
+
+	// Dialog states
+	Trying     = "trying"
+	Proceeding = "proceeding"
+	Early      = "early"
+	Confirmed  = "confirmed"
+	Terminated = "terminated"
+)
+
+// Document is dialog-info document describing dialogs of entity
+// https://datatracker.ietf.org/doc/html/rfc4235#section-4
+type Document struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:dialog-info dialog-info"`
+	// Version is incremented with every document sent within subscription
+	Version uint32 `xml:"version,attr"`
+	// State is StateFull or StatePartial
+	State string `xml:"state,attr"`
+	// Entity is URI of monitored user, ex. sip:100@pbx.example.com
+	Entity  string   `xml:"entity,attr"`
+	Dialogs []Dialog `xml:"dialog"`
+}
+
+// Dialog is state of one dialog of entity
+type Dialog struct {
+	ID        string `xml:"id,attr"`
+	CallID    string `xml:"call-id,attr,omitempty"`
+	LocalTag  string `xml:"local-tag,attr,omitempty"`
+	RemoteTag string `xml:"remote-tag,attr,omitempty"`
+	// Direction is DirectionInitiator or DirectionRecipient
+	Direction string `xml:"direction,attr,omitempty"`
+	State     State  `xml:"state"`
+	// Duration is time in seconds dialog is in its state
+	Duration int          `xml:"duration,omitempty"`
+	Local    *Participant `xml:"local,omitempty"`
+	Remote   *Participant `xml:"remote,omitempty"`
+}
+
+// State is dialog state with optional event and response code that caused it, ex. rejected with code 486
+type State struct {
+	Event string `xml:"event,attr,omitempty"`
+	Code  int    `xml:"code,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+type Participant struct {
+	Identity *Identity `xml:"identity,omitempty"`
+	Target   *Target   `xml:"target,omitempty"`
+}
+
+type Identity struct {
+	Display string `xml:"display,attr,omitempty"`
+	URI     string `xml:",chardata"`
+}
+
+type Target struct {
+	URI string `xml:"uri,attr"`
+}
+
+// Parse parses dialog-info document
+func Parse(data []byte) (*Document, error) {
+	doc := &Document{}
+	if err := xml.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("dialog-info: %w", err)
+	}
+	if doc.Entity == "" {
+		return nil, fmt.Errorf("dialog-info: entity missing")
+	}
+	for _, d := range doc.Dialogs {
+		if d.ID == "" {
+			return nil, fmt.Errorf("dialog-info: dialog id missing")
+		}
+	}
+	return doc, nil
+}
+
+// Marshal returns document with XML declaration
+func (d *Document) Marshal() ([]byte, error) {
+	data, err := xml.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// Busy reports is any dialog not terminated, which is what BLF lamp shows
+func (d *Document) Busy() bool {
+	for _, dlg := range d.Dialogs {
+		if dlg.State.Value != Terminated {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package dialoginfo
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo"
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCreateMessage(t *testing.T, lines []string) sip.Message {
+	msg, err := sip.ParseMessage([]byte(strings.Join(lines, "\r\n")))
+	require.NoError(t, err)
+	return msg
+}
+
+func TestDocument(t *testing.T) {
+	// https://datatracker.ietf.org/doc/html/rfc4235#section-5
+	data := `<?xml version="1.0"?>
+<dialog-info xmlns="urn:ietf:params:xml:ns:dialog-info" version="1" state="full" entity="sip:alice@example.com">
+  <dialog id="as7d900as8" call-id="a84b4c76e66710" local-tag="1928301774" direction="initiator">
+    <state event="rejected" code="486">terminated</state>
+    <duration>12</duration>
+    <local>
+      <identity display="Alice">sip:alice@example.com</identity>
+      <target uri="sip:alice@pc33.example.com"/>
+    </local>
+  </dialog>
+</dialog-info>`
+	doc, err := Parse([]byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), doc.Version)
+	assert.Equal(t, StateFull, doc.State)
+	require.Len(t, doc.Dialogs, 1)
+	dlg := doc.Dialogs[0]
+	assert.Equal(t, "a84b4c76e66710", dlg.CallID)
+	assert.Equal(t, DirectionInitiator, dlg.Direction)
+	assert.Equal(t, State{Event: "rejected", Code: 486, Value: Terminated}, dlg.State)
+	assert.Equal(t, 12, dlg.Duration)
+	assert.Equal(t, &Identity{Display: "Alice", URI: "sip:alice@example.com"}, dlg.Local.Identity)
+	assert.Equal(t, "sip:alice@pc33.example.com", dlg.Local.Target.URI)
+	assert.Nil(t, dlg.Remote)
+	assert.False(t, doc.Busy())
+
+	out, err := doc.Marshal()
+	require.NoError(t, err)
+	reparsed, err := Parse(out)
+	require.NoError(t, err)
+	assert.Equal(t, doc.Dialogs, reparsed.Dialogs)
+
+	_, err = Parse([]byte(`<dialog-info xmlns="urn:ietf:params:xml:ns:dialog-info" version="0" state="full"/>`))
+	assert.Error(t, err)
+}
+
+func TestTracker(t *testing.T) {
+	// Watcher receiving NOTIFY
+	watcherUA, err := sipgo.NewUA(sipgo.WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer watcherUA.Close()
+	watcher, err := sipgo.NewServer(watcherUA)
+	require.NoError(t, err)
+	notifies := make(chan *sip.Request, 10)
+	watcher.OnNotify(func(req *sip.Request, tx sip.ServerTransaction) {
+		notifies <- req
+		tx.Respond(sip.NewResponseFromRequest(req, sip.StatusOK, "OK", nil))
+	})
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go watcher.ServeUDP(conn)
+	addr := conn.LocalAddr().String()
+
+	ua, err := sipgo.NewUA(sipgo.WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer ua.Close()
+	cli, err := sipgo.NewClient(ua, sipgo.WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+	es := sipgo.NewEventServer(cli, sip.ContactHeader{Address: sip.Uri{User: "pbx", Host: "127.0.0.1", Port: 5060}})
+	tracker := NewTracker(es)
+
+	waitNotify := func() *Document {
+		t.Helper()
+		select {
+		case req := <-notifies:
+			assert.Equal(t, ContentType, req.ContentType().Value())
+			doc, err := Parse(req.Body())
+			require.NoError(t, err)
+			return doc
+		case <-time.After(2 * time.Second):
+			t.Fatal("NOTIFY not received")
+		}
+		return nil
+	}
+
+	sub := testCreateMessage(t, []string{
+		"SUBSCRIBE sip:bob@127.0.0.1 SIP/2.0",
+		"Via: SIP/2.0/UDP " + addr + ";branch=" + sip.GenerateBranch(),
+		"From: <sip:console@127.0.0.1>;tag=" + sip.GenerateTagN(8),
+		"To: <sip:bob@127.0.0.1>",
+		"Contact: <sip:console@" + addr + ">",
+		"Call-ID: " + sip.GenerateTagN(16),
+		"CSeq: 1 SUBSCRIBE",
+		"Event: dialog",
+		"Expires: 600",
+		"Content-Length: 0",
+		"",
+		"",
+	}).(*sip.Request)
+	require.NoError(t, es.ReadSubscribe(sub, siptest.NewServerTxRecorder(sub)))
+	doc := waitNotify()
+	assert.Equal(t, "sip:bob@127.0.0.1", doc.Entity)
+	assert.Empty(t, doc.Dialogs)
+	version := doc.Version
+
+	// Incoming call to bob
+	invite := testCreateMessage(t, []string{
+		"INVITE sip:bob@127.0.0.1:5060 SIP/2.0",
+		"Via: SIP/2.0/UDP 127.0.0.2:5060;branch=" + sip.GenerateBranch(),
+		"From: <sip:alice@127.0.0.2>;tag=alicetag",
+		"To: <sip:bob@127.0.0.1>",
+		"Contact: <sip:alice@127.0.0.2:5060>",
+		"Call-ID: call1",
+		"CSeq: 1 INVITE",
+		"Content-Length: 0",
+		"",
+		"",
+	}).(*sip.Request)
+	d := &sipgo.Dialog{InviteRequest: invite}
+	respond := func(code sip.StatusCode) {
+		d.InviteResponse = sip.NewResponseFromRequest(invite, code, "", nil)
+		d.InviteResponse.To().Params.Add("tag", "bobtag")
+	}
+
+	respond(sip.StatusRinging)
+	tracker.Recipient(d, sip.DialogStateEarly)
+	doc = waitNotify()
+	assert.Equal(t, version+1, doc.Version)
+	require.Len(t, doc.Dialogs, 1)
+	dlg := doc.Dialogs[0]
+	assert.Equal(t, "call1", dlg.CallID)
+	assert.Equal(t, "bobtag", dlg.LocalTag)
+	assert.Equal(t, "alicetag", dlg.RemoteTag)
+	assert.Equal(t, DirectionRecipient, dlg.Direction)
+	assert.Equal(t, Early, dlg.State.Value)
+	assert.Equal(t, "sip:alice@127.0.0.2", dlg.Remote.Identity.URI)
+	assert.True(t, doc.Busy())
+
+	respond(sip.StatusOK)
+	tracker.Recipient(d, sip.DialogStateEstablished)
+	doc = waitNotify()
+	assert.Equal(t, Confirmed, doc.Dialogs[0].State.Value)
+	// ACK does not change dialog-info state
+	tracker.Recipient(d, sip.DialogStateConfirmed)
+
+	tracker.Recipient(d, sip.DialogStateEnded)
+	doc = waitNotify()
+	assert.Equal(t, version+3, doc.Version)
+	assert.Equal(t, Terminated, doc.Dialogs[0].State.Value)
+	assert.False(t, doc.Busy())
+
+	require.Eventually(t, func() bool { return len(tracker.Document("sip:bob@127.0.0.1").Dialogs) == 0 }, time.Second, 10*time.Millisecond)
+	select {
+	case <-notifies:
+		t.Fatal("unexpected NOTIFY")
+	default:
+	}
+}
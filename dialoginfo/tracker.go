@@ -0,0 +1,171 @@
+// Package dialoginfo implements dialog event package used for BLF (busy lamp field) and attendant consoles.
+// Dialog state changes of sipgo dialog sessions are tracked per entity and sent as dialog-info document
+// in NOTIFY to watchers over sipgo EventServer
+// https://datatracker.ietf.org/doc/html/rfc4235
+package dialoginfo
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/emiago/sipgo"
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Event is dialog event package name
+const Event = "dialog"
+
+// Tracker tracks dialogs of entities and notifies watchers on every change
+// Ex:
+//
+//	es := sipgo.NewEventServer(client, contactHDR)
+//	tracker := dialoginfo.NewTracker(es)
+//	dialogCli.OnDialogState(tracker.Initiator)
+//	dialogSrv.OnDialogState(tracker.Recipient)
+type Tracker struct {
+	es  *sipgo.EventServer
+	log zerolog.Logger
+
+	mu sync.Mutex
+	// entities are tracked dialogs by entity and dialog id
+	entities map[string]map[string]*trackedDialog
+}
+
+type trackedDialog struct {
+	Dialog
+	// since is time of last state change
+	since time.Time
+}
+
+// NewTracker creates tracker and registers dialog event package on event server
+func NewTracker(es *sipgo.EventServer) *Tracker {
+	t := &Tracker{
+		es:       es,
+		log:      log.Logger.With().Str("caller", "dialoginfo").Logger(),
+		entities: make(map[string]map[string]*trackedDialog),
+	}
+	es.Handle(Event, t.state)
+	return t
+}
+
+// Initiator tracks dialogs where entity is caller. Pass it to DialogClient.OnDialogState
+func (t *Tracker) Initiator(d *sipgo.Dialog, state sip.DialogState) {
+	t.update(d, state, DirectionInitiator)
+}
+
+// Recipient tracks dialogs where entity is callee. Pass it to DialogServer.OnDialogState
+func (t *Tracker) Recipient(d *sipgo.Dialog, state sip.DialogState) {
+	t.update(d, state, DirectionRecipient)
+}
+
+// Document returns full state of entity dialogs. Entity is in sip.Uri.Addr form
+func (t *Tracker) Document(entity string) *Document {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	doc := &Document{State: StateFull, Entity: entity}
+	for _, d := range t.entities[entity] {
+		dlg := d.Dialog
+		dlg.Duration = int(time.Since(d.since).Seconds())
+		doc.Dialogs = append(doc.Dialogs, dlg)
+	}
+	sort.Slice(doc.Dialogs, func(i, j int) bool { return doc.Dialogs[i].ID < doc.Dialogs[j].ID })
+	return doc
+}
+
+// state returns NOTIFY body for subscription. Version follows NOTIFY CSeq, which grows by one within subscription
+func (t *Tracker) state(sub sipgo.Subscription) (string, []byte) {
+	doc := t.Document(sub.Resource)
+	doc.Version = sub.CSeq
+	body, err := doc.Marshal()
+	if err != nil {
+		return "", nil
+	}
+	return ContentType, body
+}
+
+func (t *Tracker) update(d *sipgo.Dialog, state sip.DialogState, direction string) {
+	req, res := d.InviteRequest, d.InviteResponse
+	if req == nil || res == nil || req.From() == nil || res.To() == nil {
+		// No response means there is no dialog to report
+		return
+	}
+
+	dlg := Dialog{Direction: direction, CallID: req.CallID().Value()}
+	local, remote := &req.From().Address, &res.To().Address
+	fromTag, _ := req.From().Params.Get("tag")
+	toTag, _ := res.To().Params.Get("tag")
+	dlg.LocalTag, dlg.RemoteTag = fromTag, toTag
+	if direction == DirectionRecipient {
+		local, remote = remote, local
+		dlg.LocalTag, dlg.RemoteTag = toTag, fromTag
+	}
+	if dlg.LocalTag == "" || dlg.RemoteTag == "" {
+		return
+	}
+	dlg.ID = sip.MakeDialogID(dlg.CallID, dlg.LocalTag, dlg.RemoteTag)
+	dlg.Local = &Participant{Identity: &Identity{URI: local.Addr()}}
+	dlg.Remote = &Participant{Identity: &Identity{URI: remote.Addr()}}
+
+	switch state {
+	case sip.DialogStateEarly:
+		dlg.State.Value = Early
+	case sip.DialogStateEstablished, sip.DialogStateConfirmed:
+		dlg.State.Value = Confirmed
+	case sip.DialogStateEnded:
+		dlg.State.Value = Terminated
+		if !res.IsSuccess() && !res.IsProvisional() {
+			dlg.State.Event = "rejected"
+			dlg.State.Code = int(res.StatusCode)
+		}
+	default:
+		return
+	}
+
+	entity := local.Addr()
+	t.mu.Lock()
+	dialogs := t.entities[entity]
+	old, exists := dialogs[dlg.ID]
+	switch {
+	case dlg.State.Value == Terminated && !exists:
+		// Never reported, ex. failed without early dialog
+		t.mu.Unlock()
+		return
+	case exists && old.State == dlg.State:
+		t.mu.Unlock()
+		return
+	}
+	if dialogs == nil {
+		dialogs = make(map[string]*trackedDialog)
+		t.entities[entity] = dialogs
+	}
+	dialogs[dlg.ID] = &trackedDialog{Dialog: dlg, since: time.Now()}
+	t.mu.Unlock()
+
+	go t.notify(entity, dlg)
+}
+
+// notify sends NOTIFY to watchers of entity. Terminated dialog is removed once it is reported
+func (t *Tracker) notify(entity string, dlg Dialog) {
+	ctx, cancel := context.WithTimeout(context.Background(), sip.GetTimers().Timer_F)
+	defer cancel()
+	if err := t.es.Notify(ctx, Event, entity); err != nil {
+		t.log.Info().Err(err).Str("entity", entity).Msg("Failed to notify watchers")
+	}
+
+	if dlg.State.Value != Terminated {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if d, exists := t.entities[entity][dlg.ID]; exists && d.State.Value == Terminated {
+		delete(t.entities[entity], dlg.ID)
+		if len(t.entities[entity]) == 0 {
+			delete(t.entities, entity)
+		}
+	}
+}
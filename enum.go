@@ -0,0 +1,332 @@
+package sipgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrENUMNotFound = errors.New("enum: no sip record for number")
+	ErrENUMNumber   = errors.New("enum: not E.164 number")
+)
+
+// enumMaxDepth limits following non terminal NAPTR records
+const enumMaxDepth = 5
+
+// NAPTR is DNS Naming Authority Pointer record https://datatracker.ietf.org/doc/html/rfc3403#section-4.1
+type NAPTR struct {
+	Order       uint16
+	Preference  uint16
+	Flags       string
+	Service     string
+	Regexp      string
+	Replacement string
+}
+
+// NAPTRLookup returns NAPTR records of domain name. Returning no records means domain has none
+type NAPTRLookup func(ctx context.Context, name string) ([]NAPTR, error)
+
+// ENUMResolver maps E.164 numbers to SIP URIs with NAPTR lookups in ENUM zones
+// https://datatracker.ietf.org/doc/html/rfc6116
+type ENUMResolver struct {
+	zones   []string
+	lookup  NAPTRLookup
+	timeout time.Duration
+	log     zerolog.Logger
+}
+
+type ENUMResolverOption func(r *ENUMResolver)
+
+// WithENUMZones sets ENUM zones queried in order until number is found. Default is e164.arpa
+func WithENUMZones(zones ...string) ENUMResolverOption {
+	return func(r *ENUMResolver) {
+		r.zones = zones
+	}
+}
+
+// WithENUMLookup sets NAPTR lookup, ex. for caching or for custom DNS library.
+// Default is DNSNAPTRLookup with nameserver from /etc/resolv.conf
+func WithENUMLookup(lookup NAPTRLookup) ENUMResolverOption {
+	return func(r *ENUMResolver) {
+		r.lookup = lookup
+	}
+}
+
+// WithENUMNameserver sets nameserver address host:port used by default lookup
+func WithENUMNameserver(addr string) ENUMResolverOption {
+	return func(r *ENUMResolver) {
+		r.lookup = DNSNAPTRLookup(addr)
+	}
+}
+
+// WithENUMTimeout sets timeout of single resolve used by StatelessRouter. Default is 2s
+func WithENUMTimeout(d time.Duration) ENUMResolverOption {
+	return func(r *ENUMResolver) {
+		r.timeout = d
+	}
+}
+
+// WithENUMLogger allows customizing logger
+func WithENUMLogger(logger zerolog.Logger) ENUMResolverOption {
+	return func(r *ENUMResolver) {
+		r.log = logger
+	}
+}
+
+// NewENUMResolver creates ENUM resolver
+func NewENUMResolver(options ...ENUMResolverOption) *ENUMResolver {
+	r := &ENUMResolver{
+		zones:   []string{"e164.arpa"},
+		timeout: 2 * time.Second,
+		log:     log.Logger.With().Str("caller", "ENUMResolver").Logger(),
+	}
+	for _, o := range options {
+		o(r)
+	}
+	if r.lookup == nil {
+		r.lookup = DNSNAPTRLookup(systemNameserver())
+	}
+	return r
+}
+
+// Resolve returns most preferred SIP URI of E.164 number like +4689761234
+func (r *ENUMResolver) Resolve(ctx context.Context, number string) (sip.Uri, error) {
+	uris, err := r.ResolveAll(ctx, number)
+	if err != nil {
+		return sip.Uri{}, err
+	}
+	return uris[0], nil
+}
+
+// ResolveAll returns SIP URIs of E.164 number sorted by order and preference.
+// Zones are tried in order and first zone having sip records is used
+func (r *ENUMResolver) ResolveAll(ctx context.Context, number string) ([]sip.Uri, error) {
+	aus := sip.NormalizeDialString(number)
+	for _, zone := range r.zones {
+		domain, err := ENUMDomain(aus, zone)
+		if err != nil {
+			return nil, err
+		}
+
+		uris, err := r.resolveDomain(ctx, aus, domain, 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(uris) > 0 {
+			r.log.Debug().Str("number", aus).Str("zone", zone).Str("uri", uris[0].String()).Msg("ENUM resolved")
+			return uris, nil
+		}
+	}
+	return nil, ErrENUMNotFound
+}
+
+func (r *ENUMResolver) resolveDomain(ctx context.Context, aus string, domain string, depth int) ([]sip.Uri, error) {
+	if depth > enumMaxDepth {
+		return nil, fmt.Errorf("enum: too many non terminal records for %s", domain)
+	}
+
+	records, err := r.lookup(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Order != records[j].Order {
+			return records[i].Order < records[j].Order
+		}
+		return records[i].Preference < records[j].Preference
+	})
+
+	var uris []sip.Uri
+	for _, rec := range records {
+		if !enumSIPService(rec.Service) {
+			continue
+		}
+
+		// Non terminal record points to other domain with regexp empty
+		// https://datatracker.ietf.org/doc/html/rfc6116#section-3.4.3
+		if rec.Flags == "" && rec.Regexp == "" && rec.Replacement != "" && rec.Replacement != "." {
+			more, err := r.resolveDomain(ctx, aus, strings.TrimSuffix(rec.Replacement, "."), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			uris = append(uris, more...)
+			continue
+		}
+
+		if !strings.EqualFold(rec.Flags, "u") {
+			continue
+		}
+
+		target, err := enumApplyRegexp(rec.Regexp, aus)
+		if err != nil {
+			r.log.Info().Err(err).Str("domain", domain).Str("regexp", rec.Regexp).Msg("Invalid ENUM record")
+			continue
+		}
+
+		var uri sip.Uri
+		if err := sip.ParseUri(target, &uri); err != nil || uri.Tel || uri.Host == "" {
+			r.log.Info().Err(err).Str("domain", domain).Str("uri", target).Msg("ENUM record is not sip URI")
+			continue
+		}
+		uris = append(uris, uri)
+	}
+	return uris, nil
+}
+
+// Retarget changes Request-URI to URI resolved for E.164 number in Request-URI.
+// It returns false with no error in case Request-URI is not E.164 number
+func (r *ENUMResolver) Retarget(ctx context.Context, req *sip.Request) (bool, error) {
+	number, ok := enumNumber(req.Recipient)
+	if !ok {
+		return false, nil
+	}
+
+	uri, err := r.Resolve(ctx, number)
+	if err != nil {
+		return false, err
+	}
+	req.Trail().Addf("enum", "retargeted %s to %s", number, uri.String())
+	req.Recipient = &uri
+	return true, nil
+}
+
+// StatelessRouter returns router for StatelessProxy which retargets E.164 Request-URI before calling next.
+// Requests for numbers not found in ENUM are rejected with 404 Not Found
+// Ex:
+//
+//	enum := sipgo.NewENUMResolver(sipgo.WithENUMZones("e164.carrier.net", "e164.arpa"))
+//	proxy, _ := sipgo.NewStatelessProxy(ua, enum.StatelessRouter(func(req *sip.Request) string {
+//		return req.Recipient.HostPort()
+//	}))
+func (r *ENUMResolver) StatelessRouter(next StatelessRouter) StatelessRouter {
+	return func(req *sip.Request) string {
+		ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+		defer cancel()
+
+		if _, err := r.Retarget(ctx, req); err != nil {
+			r.log.Info().Err(err).Str("uri", req.Recipient.String()).Msg("ENUM retarget failed")
+			return ""
+		}
+		return next(req)
+	}
+}
+
+// ENUMDomain converts E.164 number to domain name in zone. Ex: +4689761234 -> 4.3.2.1.6.7.9.8.6.4.e164.arpa
+// https://datatracker.ietf.org/doc/html/rfc6116#section-2.4
+func ENUMDomain(number string, zone string) (string, error) {
+	number = sip.NormalizeDialString(number)
+	if len(number) < 2 || number[0] != '+' {
+		return "", ErrENUMNumber
+	}
+
+	digits := number[1:]
+	var sb strings.Builder
+	sb.Grow(len(digits)*2 + len(zone))
+	for i := len(digits) - 1; i >= 0; i-- {
+		c := digits[i]
+		if c < '0' || c > '9' {
+			return "", ErrENUMNumber
+		}
+		sb.WriteByte(c)
+		sb.WriteByte('.')
+	}
+	sb.WriteString(strings.Trim(zone, "."))
+	return sb.String(), nil
+}
+
+// enumNumber returns E.164 number of tel, user=phone or +digits Request-URI
+func enumNumber(uri *sip.Uri) (string, bool) {
+	if uri == nil {
+		return "", false
+	}
+	number, ok := uri.PhoneNumber()
+	if !ok {
+		number = uri.User
+	}
+	if len(number) < 2 || number[0] != '+' {
+		return "", false
+	}
+	for i := 1; i < len(number); i++ {
+		if number[i] < '0' || number[i] > '9' {
+			return "", false
+		}
+	}
+	return number, true
+}
+
+// enumSIPService checks E2U+sip or E2U+sips service, also old sip+E2U form
+// https://datatracker.ietf.org/doc/html/rfc3764#section-4
+func enumSIPService(service string) bool {
+	s := strings.ToLower(service)
+	switch {
+	case strings.HasPrefix(s, "e2u+"):
+		for _, t := range strings.Split(s[4:], ":") {
+			if t == "sip" || t == "sips" {
+				return true
+			}
+		}
+	case s == "sip+e2u":
+		return true
+	}
+	return false
+}
+
+// enumApplyRegexp applies NAPTR substitution expression like !^.*$!sip:info@example.com! on number
+// https://datatracker.ietf.org/doc/html/rfc3402#section-3.2
+func enumApplyRegexp(expr string, aus string) (string, error) {
+	if len(expr) < 3 {
+		return "", errors.New("regexp too short")
+	}
+	delim := expr[0:1]
+	parts := strings.Split(expr[1:], delim)
+	if len(parts) != 3 {
+		return "", errors.New("regexp must have pattern and replacement")
+	}
+	pattern, repl, flags := parts[0], parts[1], parts[2]
+	if strings.Contains(flags, "i") {
+		pattern = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", err
+	}
+	m := re.FindStringSubmatchIndex(aus)
+	if m == nil {
+		return "", errors.New("regexp does not match number")
+	}
+
+	// Backreferences \1..\9 are converted to Go template
+	var tmpl strings.Builder
+	for i := 0; i < len(repl); i++ {
+		c := repl[i]
+		if c == '\\' && i+1 < len(repl) {
+			n := repl[i+1]
+			i++
+			if n >= '0' && n <= '9' {
+				tmpl.WriteString("${" + strconv.Itoa(int(n-'0')) + "}")
+				continue
+			}
+			c = n
+		}
+		if c == '$' {
+			tmpl.WriteString("$$")
+			continue
+		}
+		tmpl.WriteByte(c)
+	}
+	// Substitution is sed like, unmatched part of number is kept
+	res := re.ExpandString([]byte(aus[:m[0]]), tmpl.String(), aus, m)
+	return string(res) + aus[m[1]:], nil
+}
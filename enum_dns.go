@@ -0,0 +1,251 @@
+package sipgo
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	dnsTypeNAPTR = 35
+	dnsClassIN   = 1
+
+	dnsRcodeNXDomain = 3
+)
+
+var errDNSMessage = errors.New("dns: malformed message")
+
+// DNSNAPTRLookup returns NAPTR lookup querying nameserver addr host:port directly.
+// Go resolver does not support NAPTR, so minimal DNS client is used. Truncated answers are retried over TCP
+func DNSNAPTRLookup(addr string) NAPTRLookup {
+	return func(ctx context.Context, name string) ([]NAPTR, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+		}
+
+		query, id := dnsNAPTRQuery(name)
+		resp, err := dnsExchange(ctx, "udp", addr, query)
+		if err != nil {
+			return nil, err
+		}
+		records, truncated, err := dnsParseNAPTR(resp, id)
+		if truncated {
+			resp, err = dnsExchange(ctx, "tcp", addr, query)
+			if err != nil {
+				return nil, err
+			}
+			records, _, err = dnsParseNAPTR(resp, id)
+		}
+		return records, err
+	}
+}
+
+// systemNameserver returns first nameserver from /etc/resolv.conf
+func systemNameserver() string {
+	f, err := os.Open("/etc/resolv.conf")
+	if err != nil {
+		return "127.0.0.1:53"
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			return net.JoinHostPort(fields[1], "53")
+		}
+	}
+	return "127.0.0.1:53"
+}
+
+func dnsNAPTRQuery(name string) ([]byte, uint16) {
+	var idb [2]byte
+	rand.Read(idb[:])
+	id := binary.BigEndian.Uint16(idb[:])
+
+	msg := make([]byte, 12, 12+len(name)+6)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	binary.BigEndian.PutUint16(msg[2:], 0x0100) // Recursion desired
+	binary.BigEndian.PutUint16(msg[4:], 1)      // Questions
+	for _, label := range strings.Split(strings.Trim(name, "."), ".") {
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0)
+	msg = binary.BigEndian.AppendUint16(msg, dnsTypeNAPTR)
+	msg = binary.BigEndian.AppendUint16(msg, dnsClassIN)
+	return msg, id
+}
+
+func dnsExchange(ctx context.Context, network string, addr string, query []byte) ([]byte, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if network == "udp" {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, 65535)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+
+	// TCP messages are prefixed with length https://datatracker.ietf.org/doc/html/rfc1035#section-4.2.2
+	msg := binary.BigEndian.AppendUint16(nil, uint16(len(query)))
+	if _, err := conn.Write(append(msg, query...)); err != nil {
+		return nil, err
+	}
+	var l [2]byte
+	if _, err := io.ReadFull(conn, l[:]); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint16(l[:]))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// dnsParseNAPTR parses NAPTR answers of response https://datatracker.ietf.org/doc/html/rfc1035#section-4.1
+func dnsParseNAPTR(msg []byte, id uint16) ([]NAPTR, bool, error) {
+	if len(msg) < 12 {
+		return nil, false, errDNSMessage
+	}
+	if binary.BigEndian.Uint16(msg[0:]) != id {
+		return nil, false, fmt.Errorf("dns: response id mismatch")
+	}
+	flags := binary.BigEndian.Uint16(msg[2:])
+	if flags&0x0200 != 0 {
+		return nil, true, nil
+	}
+	switch rcode := flags & 0x000f; rcode {
+	case 0:
+	case dnsRcodeNXDomain:
+		return nil, false, nil
+	default:
+		return nil, false, fmt.Errorf("dns: query failed rcode=%d", rcode)
+	}
+
+	qdcount := binary.BigEndian.Uint16(msg[4:])
+	ancount := binary.BigEndian.Uint16(msg[6:])
+	off := 12
+	for i := 0; i < int(qdcount); i++ {
+		_, n, err := dnsReadName(msg, off)
+		if err != nil {
+			return nil, false, err
+		}
+		off = n + 4
+	}
+
+	var records []NAPTR
+	for i := 0; i < int(ancount); i++ {
+		_, n, err := dnsReadName(msg, off)
+		if err != nil {
+			return nil, false, err
+		}
+		off = n
+		if off+10 > len(msg) {
+			return nil, false, errDNSMessage
+		}
+		typ := binary.BigEndian.Uint16(msg[off:])
+		rdlen := int(binary.BigEndian.Uint16(msg[off+8:]))
+		off += 10
+		if off+rdlen > len(msg) {
+			return nil, false, errDNSMessage
+		}
+		if typ == dnsTypeNAPTR {
+			rec, err := dnsReadNAPTR(msg, off, off+rdlen)
+			if err != nil {
+				return nil, false, err
+			}
+			records = append(records, rec)
+		}
+		off += rdlen
+	}
+	return records, false, nil
+}
+
+// dnsReadNAPTR reads NAPTR rdata https://datatracker.ietf.org/doc/html/rfc3403#section-4.1
+func dnsReadNAPTR(msg []byte, off int, end int) (NAPTR, error) {
+	var rec NAPTR
+	if off+4 > end {
+		return rec, errDNSMessage
+	}
+	rec.Order = binary.BigEndian.Uint16(msg[off:])
+	rec.Preference = binary.BigEndian.Uint16(msg[off+2:])
+	off += 4
+
+	for _, s := range []*string{&rec.Flags, &rec.Service, &rec.Regexp} {
+		if off >= end || off+1+int(msg[off]) > end {
+			return rec, errDNSMessage
+		}
+		l := int(msg[off])
+		*s = string(msg[off+1 : off+1+l])
+		off += 1 + l
+	}
+
+	name, _, err := dnsReadName(msg[:end], off)
+	if err != nil {
+		return rec, err
+	}
+	rec.Replacement = name
+	return rec, nil
+}
+
+// dnsReadName reads domain name with compression pointers and returns offset after name
+func dnsReadName(msg []byte, off int) (string, int, error) {
+	var labels []string
+	next := -1
+	for jumps := 0; ; {
+		if off >= len(msg) {
+			return "", 0, errDNSMessage
+		}
+		l := int(msg[off])
+		switch {
+		case l == 0:
+			off++
+			if next < 0 {
+				next = off
+			}
+			if len(labels) == 0 {
+				return ".", next, nil
+			}
+			return strings.Join(labels, "."), next, nil
+		case l&0xc0 == 0xc0:
+			if off+1 >= len(msg) || jumps > 10 {
+				return "", 0, errDNSMessage
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+			jumps++
+		default:
+			if off+1+l > len(msg) {
+				return "", 0, errDNSMessage
+			}
+			labels = append(labels, string(msg[off+1:off+1+l]))
+			off += 1 + l
+		}
+	}
+}
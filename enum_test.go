@@ -0,0 +1,181 @@
+package sipgo
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestENUMDomain(t *testing.T) {
+	d, err := ENUMDomain("+46-8-976-1234", "e164.arpa")
+	require.NoError(t, err)
+	assert.Equal(t, "4.3.2.1.6.7.9.8.6.4.e164.arpa", d)
+
+	_, err = ENUMDomain("089761234", "e164.arpa")
+	require.ErrorIs(t, err, ErrENUMNumber)
+	_, err = ENUMDomain("+4689*1234", "e164.arpa")
+	require.ErrorIs(t, err, ErrENUMNumber)
+}
+
+func TestENUMApplyRegexp(t *testing.T) {
+	res, err := enumApplyRegexp("!^.*$!sip:info@example.com!", "+4689761234")
+	require.NoError(t, err)
+	assert.Equal(t, "sip:info@example.com", res)
+
+	res, err = enumApplyRegexp(`!^\+46(.*)$!sip:\1@gw.example.se!`, "+4689761234")
+	require.NoError(t, err)
+	assert.Equal(t, "sip:89761234@gw.example.se", res)
+
+	_, err = enumApplyRegexp("!^\\+1.*$!sip:us@example.com!", "+4689761234")
+	require.Error(t, err)
+	_, err = enumApplyRegexp("!^.*$", "+4689761234")
+	require.Error(t, err)
+}
+
+func TestENUMResolver(t *testing.T) {
+	records := map[string][]NAPTR{
+		"4.3.2.1.6.7.9.8.6.4.e164.arpa": {
+			{Order: 100, Preference: 20, Flags: "u", Service: "E2U+sip", Regexp: "!^.*$!sip:backup@example.com!"},
+			{Order: 100, Preference: 10, Flags: "u", Service: "E2U+sip", Regexp: "!^.*$!sip:primary@example.com!"},
+			{Order: 100, Preference: 5, Flags: "u", Service: "E2U+email:mailto", Regexp: "!^.*$!mailto:info@example.com!"},
+		},
+		// Only in carrier zone, pointing to other domain
+		"5.5.5.1.6.7.9.8.6.4.carrier.net": {
+			{Order: 10, Preference: 10, Service: "E2U+sip", Replacement: "numbers.example.net."},
+		},
+		"numbers.example.net": {
+			{Order: 10, Preference: 10, Flags: "u", Service: "E2U+sip", Regexp: `!^\+(.*)$!sip:\1@sbc.example.net;user=phone!`},
+		},
+	}
+	lookup := func(ctx context.Context, name string) ([]NAPTR, error) {
+		return records[name], nil
+	}
+	r := NewENUMResolver(WithENUMZones("carrier.net", "e164.arpa"), WithENUMLookup(lookup))
+
+	uris, err := r.ResolveAll(context.Background(), "+4689761234")
+	require.NoError(t, err)
+	require.Len(t, uris, 2)
+	assert.Equal(t, "primary", uris[0].User)
+	assert.Equal(t, "backup", uris[1].User)
+
+	uri, err := r.Resolve(context.Background(), "+4689761555")
+	require.NoError(t, err)
+	assert.Equal(t, "sip:4689761555@sbc.example.net;user=phone", uri.String())
+
+	_, err = r.Resolve(context.Background(), "+4689760000")
+	require.ErrorIs(t, err, ErrENUMNotFound)
+
+	t.Run("Retarget", func(t *testing.T) {
+		req := sip.NewRequest(sip.INVITE, &sip.Uri{Tel: true, User: "+46-8-976-1234"})
+		ok, err := r.Retarget(context.Background(), req)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "sip:primary@example.com", req.Recipient.String())
+
+		req = sip.NewRequest(sip.INVITE, &sip.Uri{User: "alice", Host: "example.com"})
+		ok, err = r.Retarget(context.Background(), req)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		router := r.StatelessRouter(func(req *sip.Request) string { return req.Recipient.HostPort() })
+		req = sip.NewRequest(sip.INVITE, &sip.Uri{User: "+4689761555", Host: "proxy.example.com"})
+		assert.Equal(t, "sbc.example.net:0", router(req))
+		req = sip.NewRequest(sip.INVITE, &sip.Uri{User: "+4689760000", Host: "proxy.example.com"})
+		assert.Empty(t, router(req))
+	})
+}
+
+// testDNSNAPTRResponse builds response with NAPTR answers for query
+func testDNSNAPTRResponse(query []byte, truncated bool, records ...NAPTR) []byte {
+	resp := append([]byte(nil), query...)
+	flags := uint16(0x8180)
+	if truncated {
+		flags |= 0x0200
+	}
+	binary.BigEndian.PutUint16(resp[2:], flags)
+	binary.BigEndian.PutUint16(resp[6:], uint16(len(records)))
+	for _, rec := range records {
+		resp = append(resp, 0xc0, 12) // Pointer to question name
+		resp = binary.BigEndian.AppendUint16(resp, dnsTypeNAPTR)
+		resp = binary.BigEndian.AppendUint16(resp, dnsClassIN)
+		resp = binary.BigEndian.AppendUint32(resp, 60)
+
+		var rdata []byte
+		rdata = binary.BigEndian.AppendUint16(rdata, rec.Order)
+		rdata = binary.BigEndian.AppendUint16(rdata, rec.Preference)
+		for _, s := range []string{rec.Flags, rec.Service, rec.Regexp} {
+			rdata = append(rdata, byte(len(s)))
+			rdata = append(rdata, s...)
+		}
+		rdata = append(rdata, 0) // Root replacement
+		resp = binary.BigEndian.AppendUint16(resp, uint16(len(rdata)))
+		resp = append(resp, rdata...)
+	}
+	return resp
+}
+
+func TestDNSNAPTRLookup(t *testing.T) {
+	rec := NAPTR{Order: 100, Preference: 10, Flags: "u", Service: "E2U+sip", Regexp: "!^.*$!sip:info@example.com!"}
+
+	// UDP answer is truncated, full answer is over TCP on same port
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	conn, err := net.ListenPacket("udp", l.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	queried := make(chan string, 1)
+	go func() {
+		buf := make([]byte, 512)
+		n, raddr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		name, _, _ := dnsReadName(buf[:n], 12)
+		queried <- name
+		conn.WriteTo(testDNSNAPTRResponse(buf[:n], true), raddr)
+	}()
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		var lb [2]byte
+		if _, err := io.ReadFull(c, lb[:]); err != nil {
+			return
+		}
+		query := make([]byte, binary.BigEndian.Uint16(lb[:]))
+		if _, err := io.ReadFull(c, query); err != nil {
+			return
+		}
+		resp := testDNSNAPTRResponse(query, false, rec)
+		c.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(resp))), resp...))
+	}()
+
+	records, err := DNSNAPTRLookup(l.Addr().String())(context.Background(), "4.3.2.1.6.7.9.8.6.4.e164.arpa")
+	require.NoError(t, err)
+	assert.Equal(t, "4.3.2.1.6.7.9.8.6.4.e164.arpa", <-queried)
+	require.Len(t, records, 1)
+	rec.Replacement = "."
+	assert.Equal(t, rec, records[0])
+
+	// NXDOMAIN is no records
+	query, id := dnsNAPTRQuery("0.e164.arpa")
+	resp := testDNSNAPTRResponse(query, false)
+	resp[3] |= dnsRcodeNXDomain
+	records, _, err = dnsParseNAPTR(resp, id)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	_, _, err = dnsParseNAPTR(resp[:5], id)
+	require.Error(t, err)
+}
@@ -0,0 +1,231 @@
+package sipgo
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+var ErrEventNoPublication = errors.New("event: publication does not exist")
+
+// Publication is event state published with PUBLISH by event publication agent
+// https://datatracker.ietf.org/doc/html/rfc3903
+type Publication struct {
+	// ETag is entity tag of publication, sent in SIP-ETag and matched with SIP-If-Match
+	ETag string
+	// Event is event package, ex. presence
+	Event string
+	// Resource is address of resource state is published for, Request-URI of PUBLISH in sip.Uri.Addr form
+	Resource    string
+	ContentType string
+	Body        []byte
+	Expires     time.Time
+}
+
+// PublishAcceptFunc validates published state before it is stored. Returned sip.StatusError is responded,
+// ex. with 415 for content type not supported by event package, and any other error with 400
+type PublishAcceptFunc func(pub Publication) error
+
+type eventPublication struct {
+	Publication
+	timer *time.Timer
+}
+
+// HandlePublish allows PUBLISH for event package registered with Handle. Accept can be nil
+func (es *EventServer) HandlePublish(event string, accept PublishAcceptFunc) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if es.publishers == nil {
+		es.publishers = make(map[string]PublishAcceptFunc)
+	}
+	es.publishers[event] = accept
+}
+
+// Publications returns active publications of resource for event package, ordered from oldest.
+// Event state functions use it to compose state sent to watchers
+func (es *EventServer) Publications(event string, resource string) []Publication {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	var pubs []Publication
+	for _, p := range es.pubs[publicationKey(event, resource)] {
+		pubs = append(pubs, p.Publication)
+	}
+	return pubs
+}
+
+// ReadPublish should read from your OnPublish handler. It creates, refreshes, modifies or removes publication
+// matched by SIP-If-Match, responds with new SIP-ETag and sends NOTIFY to watchers of resource when state changes
+// https://datatracker.ietf.org/doc/html/rfc3903#section-6
+func (es *EventServer) ReadPublish(req *sip.Request, tx sip.ServerTransaction) error {
+	event := req.Event()
+	if event == nil {
+		return es.respond(req, tx, sip.StatusBadRequest, "Missing Event header")
+	}
+
+	es.mu.Lock()
+	accept, exists := es.publishers[event.Event]
+	es.mu.Unlock()
+	if !exists {
+		res := sip.NewResponseFromRequest(req, sip.StatusBadEvent, "Bad Event", nil)
+		res.AppendHeader(sip.NewHeader("Allow-Events", es.allowEvents()))
+		if err := tx.Respond(res); err != nil {
+			return err
+		}
+		return ErrEventUnknownPackage
+	}
+
+	expires := es.defaultExpires
+	if h := req.Expires(); h != nil {
+		expires = int(*h)
+	}
+	if expires > 0 && expires < es.minExpires {
+		res := sip.NewResponseFromRequest(req, sip.StatusIntervalToBrief, "Interval Too Brief", nil)
+		res.AppendHeader(sip.NewHeader("Min-Expires", strconv.Itoa(es.minExpires)))
+		return tx.Respond(res)
+	}
+
+	pub := Publication{
+		Event:    event.Event,
+		Resource: req.Recipient.Addr(),
+		Body:     req.Body(),
+	}
+	if h := req.ContentType(); h != nil {
+		pub.ContentType = h.Value()
+	}
+
+	var oldETag string
+	if h := req.GetHeader("SIP-If-Match"); h != nil {
+		oldETag = h.Value()
+		old, exists := es.publication(pub.Event, pub.Resource, oldETag)
+		if !exists {
+			if err := es.respond(req, tx, sip.StatusConditionalRequestFailed, "Conditional Request Failed"); err != nil {
+				return err
+			}
+			return ErrEventNoPublication
+		}
+		if len(pub.Body) == 0 {
+			// Refresh keeps published state
+			pub.ContentType, pub.Body = old.ContentType, old.Body
+		}
+	} else if len(pub.Body) == 0 {
+		return es.respond(req, tx, sip.StatusBadRequest, "Missing Body")
+	}
+
+	if expires == 0 {
+		if oldETag == "" {
+			return es.respond(req, tx, sip.StatusBadRequest, "Bad Request")
+		}
+		es.removePublication(pub.Event, pub.Resource, oldETag)
+		if err := es.respond(req, tx, sip.StatusOK, "OK"); err != nil {
+			return err
+		}
+		go es.notifyResource(pub.Event, pub.Resource)
+		return nil
+	}
+
+	if accept != nil && len(req.Body()) > 0 {
+		if err := accept(pub); err != nil {
+			var se sip.StatusError
+			if !errors.As(err, &se) {
+				se = sip.StatusError{Code: sip.StatusBadRequest, Reason: err.Error()}
+			}
+			res := sip.NewResponseFromRequest(req, se.Code, se.Reason, nil)
+			for _, h := range se.Headers {
+				res.AppendHeader(h)
+			}
+			return tx.Respond(res)
+		}
+	}
+
+	// Entity tag changes with every successful PUBLISH
+	pub.ETag = sip.GenerateTagN(16)
+	pub.Expires = time.Now().Add(time.Duration(expires) * time.Second)
+	es.addPublication(pub, oldETag)
+
+	res := sip.NewResponseFromRequest(req, sip.StatusOK, "OK", nil)
+	res.AppendHeader(sip.NewHeader("SIP-ETag", pub.ETag))
+	res.AppendHeader(sip.NewHeader("Expires", strconv.Itoa(expires)))
+	if err := tx.Respond(res); err != nil {
+		return err
+	}
+
+	if len(req.Body()) > 0 {
+		go es.notifyResource(pub.Event, pub.Resource)
+	}
+	return nil
+}
+
+func publicationKey(event string, resource string) string {
+	return event + " " + resource
+}
+
+func (es *EventServer) publication(event string, resource string, etag string) (Publication, bool) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	for _, p := range es.pubs[publicationKey(event, resource)] {
+		if p.ETag == etag {
+			return p.Publication, true
+		}
+	}
+	return Publication{}, false
+}
+
+// addPublication stores publication replacing one with old entity tag
+func (es *EventServer) addPublication(pub Publication, oldETag string) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if es.pubs == nil {
+		es.pubs = make(map[string][]*eventPublication)
+	}
+	key := publicationKey(pub.Event, pub.Resource)
+	p := &eventPublication{Publication: pub}
+	etag := pub.ETag
+	p.timer = time.AfterFunc(time.Until(pub.Expires), func() {
+		if es.removePublication(pub.Event, pub.Resource, etag) {
+			es.notifyResource(pub.Event, pub.Resource)
+		}
+	})
+
+	pubs := es.pubs[key]
+	for i, old := range pubs {
+		if old.ETag == oldETag {
+			old.timer.Stop()
+			pubs[i] = p
+			return
+		}
+	}
+	es.pubs[key] = append(pubs, p)
+}
+
+func (es *EventServer) removePublication(event string, resource string, etag string) bool {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	key := publicationKey(event, resource)
+	pubs := es.pubs[key]
+	for i, p := range pubs {
+		if p.ETag != etag {
+			continue
+		}
+		p.timer.Stop()
+		pubs = append(pubs[:i], pubs[i+1:]...)
+		if len(pubs) == 0 {
+			delete(es.pubs, key)
+		} else {
+			es.pubs[key] = pubs
+		}
+		return true
+	}
+	return false
+}
+
+// notifyResource sends NOTIFY to watchers of resource after published state change
+func (es *EventServer) notifyResource(event string, resource string) {
+	ctx, cancel := context.WithTimeout(context.Background(), sip.GetTimers().Timer_F)
+	defer cancel()
+	if err := es.Notify(ctx, event, resource); err != nil {
+		es.log.Info().Err(err).Str("event", event).Str("resource", resource).Msg("Failed to notify watchers")
+	}
+}
@@ -0,0 +1,107 @@
+package sipgo
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCreatePublish(t testing.TB, etag string, expires int, body string) *sip.Request {
+	lines := []string{
+		"PUBLISH sip:bob@127.0.0.1:5060 SIP/2.0",
+		"Via: SIP/2.0/UDP 127.0.0.2:5060;branch=" + sip.GenerateBranch(),
+		"From: <sip:bob@127.0.0.1>;tag=" + sip.GenerateTagN(8),
+		"To: <sip:bob@127.0.0.1:5060>",
+		"Call-ID: " + sip.GenerateTagN(16),
+		"CSeq: 1 PUBLISH",
+		"Event: presence",
+		"Expires: " + strconv.Itoa(expires),
+	}
+	if etag != "" {
+		lines = append(lines, "SIP-If-Match: "+etag)
+	}
+	if body != "" {
+		lines = append(lines, "Content-Type: application/pidf+xml")
+	}
+	lines = append(lines, "Content-Length: "+strconv.Itoa(len(body)), "", body)
+	return testCreateMessage(t, lines).(*sip.Request)
+}
+
+func TestEventServerPublish(t *testing.T) {
+	ua, err := NewUA(WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer ua.Close()
+	cli, err := NewClient(ua, WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+
+	es := NewEventServer(cli, sip.ContactHeader{Address: sip.Uri{User: "bob", Host: "127.0.0.1", Port: 5060}})
+	es.Handle("presence", func(sub Subscription) (string, []byte) { return "", nil })
+
+	publish := func(etag string, expires int, body string) *sip.Response {
+		t.Helper()
+		req := testCreatePublish(t, etag, expires, body)
+		tx := siptest.NewServerTxRecorder(req)
+		es.ReadPublish(req, tx)
+		require.Len(t, tx.Result(), 1)
+		return tx.Result()[0]
+	}
+
+	// Package not allowed for PUBLISH
+	res := publish("", 600, "open")
+	assert.Equal(t, sip.StatusBadEvent, res.StatusCode)
+
+	es.HandlePublish("presence", func(pub Publication) error {
+		if string(pub.Body) == "bad" {
+			return sip.StatusError{Code: sip.StatusUnsupportedMediaType, Reason: "Unsupported Media Type"}
+		}
+		return nil
+	})
+
+	res = publish("", 600, "bad")
+	assert.Equal(t, sip.StatusUnsupportedMediaType, res.StatusCode)
+	res = publish("", 600, "")
+	assert.Equal(t, sip.StatusBadRequest, res.StatusCode)
+	res = publish("", 10, "open")
+	assert.Equal(t, sip.StatusIntervalToBrief, res.StatusCode)
+
+	// Initial
+	res = publish("", 600, "open")
+	require.Equal(t, sip.StatusOK, res.StatusCode)
+	etag := res.GetHeader("SIP-ETag").Value()
+	require.NotEmpty(t, etag)
+	assert.Equal(t, "600", res.GetHeader("Expires").Value())
+	pubs := es.Publications("presence", "sip:bob@127.0.0.1:5060")
+	require.Len(t, pubs, 1)
+	assert.Equal(t, "open", string(pubs[0].Body))
+
+	// Refresh keeps state with new entity tag
+	res = publish(etag, 600, "")
+	require.Equal(t, sip.StatusOK, res.StatusCode)
+	refreshed := res.GetHeader("SIP-ETag").Value()
+	assert.NotEqual(t, etag, refreshed)
+	pubs = es.Publications("presence", "sip:bob@127.0.0.1:5060")
+	require.Len(t, pubs, 1)
+	assert.Equal(t, "open", string(pubs[0].Body))
+
+	// Old entity tag is not valid anymore
+	res = publish(etag, 600, "closed")
+	assert.Equal(t, sip.StatusConditionalRequestFailed, res.StatusCode)
+
+	// Modify
+	res = publish(refreshed, 600, "closed")
+	require.Equal(t, sip.StatusOK, res.StatusCode)
+	modified := res.GetHeader("SIP-ETag").Value()
+	pubs = es.Publications("presence", "sip:bob@127.0.0.1:5060")
+	require.Len(t, pubs, 1)
+	assert.Equal(t, "closed", string(pubs[0].Body))
+
+	// Remove
+	res = publish(modified, 0, "")
+	require.Equal(t, sip.StatusOK, res.StatusCode)
+	assert.Empty(t, es.Publications("presence", "sip:bob@127.0.0.1:5060"))
+}
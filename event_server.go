@@ -0,0 +1,485 @@
+package sipgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrEventUnknownPackage = errors.New("event: unknown event package")
+	ErrEventNoSubscription = errors.New("event: subscription does not exist")
+)
+
+// Subscription is active subscription of watcher to resource state, with dialog identifiers
+// needed to send NOTIFY. All fields are plain values, so it can be persisted as is
+// https://datatracker.ietf.org/doc/html/rfc6665
+type Subscription struct {
+	// ID is dialog ID of subscription
+	ID string
+	// Event is event package, ex. presence
+	Event string
+	// EventID is id param of Event header
+	EventID string
+	// Resource is subscribed resource address, Request-URI of SUBSCRIBE in sip.Uri.Addr form
+	Resource string
+	// Watcher is subscriber address from From header
+	Watcher string
+
+	CallID    string
+	LocalTag  string
+	RemoteTag string
+	// RemoteTarget is Contact of watcher where NOTIFY is sent
+	RemoteTarget string
+	// RouteSet is route set of subscription dialog
+	RouteSet []string
+	// Transport is transport on which SUBSCRIBE is received
+	Transport string
+	// CSeq is CSeq number of last sent NOTIFY
+	CSeq uint32
+
+	Expires time.Time
+}
+
+// EventStateFunc returns current state of resource for subscription, sent as NOTIFY body
+type EventStateFunc func(sub Subscription) (contentType string, body []byte)
+
+// EventServer is notifier for event packages. It accepts subscriptions from watchers,
+// sends NOTIFY on subscription and state change, and terminates expired subscriptions.
+// Subscriptions are persisted in SubscriptionStore and recovered with Recover after restart
+// Ex:
+//
+//	es := sipgo.NewEventServer(client, contactHDR, sipgo.WithEventServerStore(store))
+//	es.Handle("presence", presenceState)
+//	srv.OnSubscribe(func(req *sip.Request, tx sip.ServerTransaction) { es.ReadSubscribe(req, tx) })
+//	es.Recover(ctx)
+type EventServer struct {
+	client     *Client
+	contactHDR sip.ContactHeader
+	store      SubscriptionStore
+
+	minExpires     int
+	defaultExpires int
+	log            zerolog.Logger
+
+	mu       sync.Mutex
+	packages map[string]EventStateFunc
+	subs     map[string]*eventSubscription
+	// publishers are event packages accepting PUBLISH
+	publishers map[string]PublishAcceptFunc
+	// pubs are publications by event and resource
+	pubs map[string][]*eventPublication
+}
+
+type eventSubscription struct {
+	Subscription
+	timer *time.Timer
+}
+
+type EventServerOption func(es *EventServer)
+
+// WithEventServerStore sets store for persisting subscriptions. Default is in memory store
+func WithEventServerStore(store SubscriptionStore) EventServerOption {
+	return func(es *EventServer) {
+		es.store = store
+	}
+}
+
+// WithEventServerExpires sets default subscription duration used when SUBSCRIBE has no Expires
+// and minimal one accepted, in seconds. Default is 3600 and 60
+func WithEventServerExpires(defaultExpires int, minExpires int) EventServerOption {
+	return func(es *EventServer) {
+		es.defaultExpires = defaultExpires
+		es.minExpires = minExpires
+	}
+}
+
+// WithEventServerLogger allows customizing logger
+func WithEventServerLogger(logger zerolog.Logger) EventServerOption {
+	return func(es *EventServer) {
+		es.log = logger
+	}
+}
+
+// NewEventServer creates notifier sending NOTIFY over client with contact
+func NewEventServer(client *Client, contactHDR sip.ContactHeader, options ...EventServerOption) *EventServer {
+	es := &EventServer{
+		client:         client,
+		contactHDR:     contactHDR,
+		store:          NewMemorySubscriptionStore(),
+		minExpires:     60,
+		defaultExpires: 3600,
+		log:            log.Logger.With().Str("caller", "EventServer").Logger(),
+		packages:       make(map[string]EventStateFunc),
+		subs:           make(map[string]*eventSubscription),
+	}
+	for _, o := range options {
+		o(es)
+	}
+	return es
+}
+
+// Handle registers event package with function returning current resource state
+func (es *EventServer) Handle(event string, state EventStateFunc) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	es.packages[event] = state
+}
+
+// Subscriptions returns active subscriptions
+func (es *EventServer) Subscriptions() []Subscription {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	subs := make([]Subscription, 0, len(es.subs))
+	for _, s := range es.subs {
+		subs = append(subs, s.Subscription)
+	}
+	return subs
+}
+
+// ReadSubscribe should read from your OnSubscribe handler. It responds to SUBSCRIBE, creates, refreshes
+// or removes subscription and sends NOTIFY with current state
+func (es *EventServer) ReadSubscribe(req *sip.Request, tx sip.ServerTransaction) error {
+	event := req.Event()
+	if event == nil {
+		return es.respond(req, tx, sip.StatusBadRequest, "Missing Event header")
+	}
+
+	es.mu.Lock()
+	_, exists := es.packages[event.Event]
+	es.mu.Unlock()
+	if !exists {
+		res := sip.NewResponseFromRequest(req, sip.StatusBadEvent, "Bad Event", nil)
+		res.AppendHeader(sip.NewHeader("Allow-Events", es.allowEvents()))
+		if err := tx.Respond(res); err != nil {
+			return err
+		}
+		return ErrEventUnknownPackage
+	}
+
+	expires := es.defaultExpires
+	if h := req.Expires(); h != nil {
+		expires = int(*h)
+	}
+	if expires > 0 && expires < es.minExpires {
+		res := sip.NewResponseFromRequest(req, sip.StatusIntervalToBrief, "Interval Too Brief", nil)
+		res.AppendHeader(sip.NewHeader("Min-Expires", strconv.Itoa(es.minExpires)))
+		return tx.Respond(res)
+	}
+
+	var sub Subscription
+	if _, inDialog := req.To().Params.Get("tag"); inDialog {
+		id, err := sip.MakeDialogIDFromRequest(req)
+		if err != nil {
+			return es.respond(req, tx, sip.StatusBadRequest, "Bad Request")
+		}
+		es.mu.Lock()
+		s, exists := es.subs[id]
+		if exists {
+			sub = s.Subscription
+		}
+		es.mu.Unlock()
+		if !exists {
+			if err := es.respond(req, tx, sip.StatusCallTransactionDoesNotExists, "Subscription Does Not Exist"); err != nil {
+				return err
+			}
+			return ErrEventNoSubscription
+		}
+	} else {
+		sub = newSubscription(req, event)
+	}
+
+	res := sip.NewResponseFromRequest(req, sip.StatusOK, "OK", nil)
+	res.To().Params.Add("tag", sub.LocalTag)
+	res.AppendHeader(sip.NewHeader("Expires", strconv.Itoa(expires)))
+	res.AppendHeader(&es.contactHDR)
+	if err := tx.Respond(res); err != nil {
+		return err
+	}
+
+	sub.Expires = time.Now().Add(time.Duration(expires) * time.Second)
+	if expires == 0 {
+		// Unsubscribe still gets final NOTIFY
+		es.remove(sub.ID)
+		go es.notify(sub, sip.SubscriptionStateTerminated, "timeout")
+		return nil
+	}
+
+	es.add(sub)
+	go es.notify(sub, sip.SubscriptionStateActive, "")
+	return nil
+}
+
+// Notify sends NOTIFY with current state to all watchers of resource subscribed to event package.
+// Resource is in sip.Uri.Addr form
+func (es *EventServer) Notify(ctx context.Context, event string, resource string) error {
+	es.mu.Lock()
+	var subs []Subscription
+	for _, s := range es.subs {
+		if s.Event == event && s.Resource == resource {
+			subs = append(subs, s.Subscription)
+		}
+	}
+	es.mu.Unlock()
+
+	var errs []error
+	for _, sub := range subs {
+		if err := es.notifyCtx(ctx, sub, sip.SubscriptionStateActive, ""); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Recover loads persisted subscriptions after restart. Expired are deleted and every active watcher
+// gets NOTIFY with current state, so it does not stay orphaned until its refresh
+func (es *EventServer) Recover(ctx context.Context) error {
+	subs, err := es.store.Load()
+	if err != nil {
+		return fmt.Errorf("load subscriptions: %w", err)
+	}
+
+	var errs []error
+	now := time.Now()
+	for _, sub := range subs {
+		if !sub.Expires.After(now) {
+			if err := es.store.Delete(sub.ID); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		es.add(sub)
+		if err := es.notifyCtx(ctx, sub, sip.SubscriptionStateActive, ""); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func newSubscription(req *sip.Request, event *sip.EventHeader) Subscription {
+	sub := Subscription{
+		Event:     event.Event,
+		EventID:   event.ID(),
+		Resource:  req.Recipient.Addr(),
+		Watcher:   req.From().Address.Addr(),
+		CallID:    req.CallID().Value(),
+		LocalTag:  sip.GenerateTagN(16),
+		Transport: req.Transport(),
+	}
+	sub.RemoteTag, _ = req.From().Params.Get("tag")
+	sub.ID = sip.MakeDialogID(sub.CallID, sub.LocalTag, sub.RemoteTag)
+	if cont := req.Contact(); cont != nil {
+		sub.RemoteTarget = cont.Address.String()
+	} else {
+		sub.RemoteTarget = req.From().Address.String()
+	}
+	// Route set is Record-Route of request in same order as we are UAS
+	for _, r := range sip.RouteSetFromRecordRoute(req, false) {
+		sub.RouteSet = append(sub.RouteSet, r.String())
+	}
+	return sub
+}
+
+func (es *EventServer) add(sub Subscription) {
+	es.mu.Lock()
+	s, exists := es.subs[sub.ID]
+	if exists {
+		// Refresh keeps last NOTIFY CSeq
+		sub.CSeq = max(sub.CSeq, s.CSeq)
+		s.timer.Stop()
+	}
+	s = &eventSubscription{Subscription: sub}
+	id := sub.ID
+	s.timer = time.AfterFunc(time.Until(sub.Expires), func() { es.expire(id) })
+	es.subs[id] = s
+	es.mu.Unlock()
+
+	if err := es.store.Save(sub); err != nil {
+		es.log.Error().Err(err).Str("id", sub.ID).Msg("Failed to save subscription")
+	}
+}
+
+func (es *EventServer) remove(id string) (Subscription, bool) {
+	es.mu.Lock()
+	s, exists := es.subs[id]
+	if exists {
+		s.timer.Stop()
+		delete(es.subs, id)
+	}
+	es.mu.Unlock()
+	if !exists {
+		return Subscription{}, false
+	}
+
+	if err := es.store.Delete(id); err != nil {
+		es.log.Error().Err(err).Str("id", id).Msg("Failed to delete subscription")
+	}
+	return s.Subscription, true
+}
+
+func (es *EventServer) expire(id string) {
+	sub, exists := es.remove(id)
+	if !exists {
+		return
+	}
+	es.notify(sub, sip.SubscriptionStateTerminated, "timeout")
+}
+
+func (es *EventServer) notify(sub Subscription, state string, reason string) {
+	ctx, cancel := context.WithTimeout(context.Background(), sip.GetTimers().Timer_F)
+	defer cancel()
+	if err := es.notifyCtx(ctx, sub, state, reason); err != nil {
+		es.log.Info().Err(err).Str("id", sub.ID).Msg("Failed to notify watcher")
+	}
+}
+
+// notifyCtx sends NOTIFY for subscription and waits final response. Subscription is removed on 481 or other failure
+// https://datatracker.ietf.org/doc/html/rfc6665#section-4.2.2
+func (es *EventServer) notifyCtx(ctx context.Context, sub Subscription, state string, reason string) error {
+	es.mu.Lock()
+	stateFn := es.packages[sub.Event]
+	if s, exists := es.subs[sub.ID]; exists {
+		s.CSeq++
+		sub = s.Subscription
+	} else {
+		sub.CSeq++
+	}
+	es.mu.Unlock()
+	if state != sip.SubscriptionStateTerminated {
+		if err := es.store.Save(sub); err != nil {
+			es.log.Error().Err(err).Str("id", sub.ID).Msg("Failed to save subscription")
+		}
+	}
+
+	req, err := es.newNotify(sub, state, reason)
+	if err != nil {
+		return err
+	}
+	if stateFn != nil {
+		if contentType, body := stateFn(sub); body != nil {
+			req.SetContent(contentType, body)
+		}
+	}
+
+	res, err := es.doRequest(ctx, req)
+	if err != nil {
+		return err
+	}
+	if !res.IsSuccess() {
+		es.remove(sub.ID)
+		return &ErrDialogResponse{Res: res}
+	}
+	return nil
+}
+
+func (es *EventServer) newNotify(sub Subscription, state string, reason string) (*sip.Request, error) {
+	target := sip.Uri{}
+	if err := sip.ParseUri(sub.RemoteTarget, &target); err != nil {
+		return nil, fmt.Errorf("subscription remote target: %w", err)
+	}
+	resource, watcher := sip.Uri{}, sip.Uri{}
+	if err := sip.ParseUri(sub.Resource, &resource); err != nil {
+		return nil, fmt.Errorf("subscription resource: %w", err)
+	}
+	if err := sip.ParseUri(sub.Watcher, &watcher); err != nil {
+		return nil, fmt.Errorf("subscription watcher: %w", err)
+	}
+	routeSet := make([]sip.Uri, len(sub.RouteSet))
+	for i, r := range sub.RouteSet {
+		if err := sip.ParseUri(r, &routeSet[i]); err != nil {
+			return nil, fmt.Errorf("subscription route set: %w", err)
+		}
+	}
+
+	req := sip.NewRequest(sip.NOTIFY, &target)
+	sip.RouteSetApply(req, routeSet)
+	from := &sip.FromHeader{Address: resource, Params: sip.NewParams()}
+	from.Params.Add("tag", sub.LocalTag)
+	req.AppendHeader(from)
+	to := &sip.ToHeader{Address: watcher, Params: sip.NewParams()}
+	to.Params.Add("tag", sub.RemoteTag)
+	req.AppendHeader(to)
+	callid := sip.CallIDHeader(sub.CallID)
+	req.AppendHeader(&callid)
+	req.AppendHeader(&sip.CSeqHeader{SeqNo: sub.CSeq, MethodName: sip.NOTIFY})
+
+	event := &sip.EventHeader{Event: sub.Event}
+	if sub.EventID != "" {
+		event.Params = sip.NewParams()
+		event.Params.Add("id", sub.EventID)
+	}
+	req.AppendHeader(event)
+
+	ss := &sip.SubscriptionStateHeader{State: state, Params: sip.NewParams()}
+	if state == sip.SubscriptionStateTerminated {
+		ss.Params.Add("reason", reason)
+	} else {
+		expires := int(time.Until(sub.Expires).Seconds())
+		ss.Params.Add("expires", strconv.Itoa(max(expires, 0)))
+	}
+	req.AppendHeader(ss)
+	req.AppendHeader(&es.contactHDR)
+	if sub.Transport != "" {
+		req.SetTransport(sub.Transport)
+	}
+	return req, nil
+}
+
+// doRequest sends request and waits final response
+func (es *EventServer) doRequest(ctx context.Context, req *sip.Request) (*sip.Response, error) {
+	tx, err := es.client.TransactionRequest(ctx, req, ClientRequestBuild)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Terminate()
+
+	for {
+		select {
+		case res, more := <-tx.Responses():
+			if !more {
+				return nil, txTerminatedErr(tx)
+			}
+			if res.IsProvisional() {
+				continue
+			}
+			return res, nil
+		case <-tx.Done():
+			return nil, txTerminatedErr(tx)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func txTerminatedErr(tx sip.ClientTransaction) error {
+	if err := tx.Err(); err != nil {
+		return err
+	}
+	return sip.ErrTransactionTerminated
+}
+
+func (es *EventServer) allowEvents() string {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	var events string
+	for e := range es.packages {
+		if events != "" {
+			events += ", "
+		}
+		events += e
+	}
+	return events
+}
+
+func (es *EventServer) respond(req *sip.Request, tx sip.ServerTransaction, code sip.StatusCode, reason string) error {
+	return tx.Respond(sip.NewResponseFromRequest(req, code, reason, nil))
+}
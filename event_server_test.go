@@ -0,0 +1,146 @@
+package sipgo
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCreateSubscribe(t testing.TB, watcherPort int, expires int) *sip.Request {
+	addr := "127.0.0.1:" + strconv.Itoa(watcherPort)
+	return testCreateMessage(t, []string{
+		"SUBSCRIBE sip:bob@127.0.0.1:5060 SIP/2.0",
+		"Via: SIP/2.0/UDP " + addr + ";branch=" + sip.GenerateBranch(),
+		"From: <sip:alice@127.0.0.1>;tag=" + sip.GenerateTagN(8),
+		"To: <sip:bob@127.0.0.1:5060>",
+		"Contact: <sip:alice@" + addr + ">",
+		"Call-ID: " + sip.GenerateTagN(16),
+		"CSeq: 1 SUBSCRIBE",
+		"Event: presence;id=1",
+		"Expires: " + strconv.Itoa(expires),
+		"Content-Length: 0",
+		"",
+		"",
+	}).(*sip.Request)
+}
+
+func TestEventServerRecover(t *testing.T) {
+	// Watcher receiving NOTIFY
+	watcherUA, err := NewUA(WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer watcherUA.Close()
+	watcher, err := NewServer(watcherUA)
+	require.NoError(t, err)
+	notifies := make(chan *sip.Request, 5)
+	watcher.OnNotify(func(req *sip.Request, tx sip.ServerTransaction) {
+		notifies <- req
+		tx.Respond(sip.NewResponseFromRequest(req, sip.StatusOK, "OK", nil))
+	})
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go watcher.ServeUDP(conn)
+	watcherPort := conn.LocalAddr().(*net.UDPAddr).Port
+
+	ua, err := NewUA(WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer ua.Close()
+	cli, err := NewClient(ua, WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+
+	store := NewMemorySubscriptionStore()
+	contact := sip.ContactHeader{Address: sip.Uri{User: "bob", Host: "127.0.0.1", Port: 5060}}
+	state := func(sub Subscription) (string, []byte) {
+		return "application/pidf+xml", []byte("open")
+	}
+	waitNotify := func() *sip.Request {
+		select {
+		case req := <-notifies:
+			return req
+		case <-time.After(2 * time.Second):
+			t.Fatal("NOTIFY not received")
+		}
+		return nil
+	}
+
+	es := NewEventServer(cli, contact, WithEventServerStore(store))
+	es.Handle("presence", state)
+
+	req := testCreateSubscribe(t, watcherPort, 600)
+	tx := siptest.NewServerTxRecorder(req)
+	require.NoError(t, es.ReadSubscribe(req, tx))
+	require.Len(t, tx.Result(), 1)
+	res := tx.Result()[0]
+	assert.Equal(t, sip.StatusOK, res.StatusCode)
+	assert.Equal(t, "600", res.GetHeader("Expires").Value())
+
+	notify := waitNotify()
+	assert.Equal(t, uint32(1), notify.CSeq().SeqNo)
+	assert.Equal(t, "presence;id=1", notify.GetHeader("Event").Value())
+	assert.Contains(t, notify.GetHeader("Subscription-State").Value(), "active;expires=")
+	assert.Equal(t, "open", string(notify.Body()))
+	assert.Equal(t, req.CallID().Value(), notify.CallID().Value())
+	ftag, _ := req.From().Params.Get("tag")
+	totag, _ := notify.To().Params.Get("tag")
+	assert.Equal(t, ftag, totag)
+
+	// Restart with same store
+	es = NewEventServer(cli, contact, WithEventServerStore(store))
+	es.Handle("presence", state)
+	require.NoError(t, es.Recover(context.Background()))
+	notify = waitNotify()
+	assert.Equal(t, uint32(2), notify.CSeq().SeqNo)
+	require.Len(t, es.Subscriptions(), 1)
+
+	// Unsubscribe within dialog
+	unsub := testCreateSubscribe(t, watcherPort, 0)
+	unsub.ReplaceHeaders("Call-ID", req.CallID())
+	unsub.ReplaceHeaders("From", req.From())
+	unsub.To().Params.Add("tag", es.Subscriptions()[0].LocalTag)
+	tx = siptest.NewServerTxRecorder(unsub)
+	require.NoError(t, es.ReadSubscribe(unsub, tx))
+	assert.Equal(t, sip.StatusOK, tx.Result()[0].StatusCode)
+
+	notify = waitNotify()
+	assert.Equal(t, uint32(3), notify.CSeq().SeqNo)
+	assert.Equal(t, "terminated;reason=timeout", notify.GetHeader("Subscription-State").Value())
+	assert.Empty(t, es.Subscriptions())
+	subs, _ := store.Load()
+	assert.Empty(t, subs)
+}
+
+func TestEventServerReject(t *testing.T) {
+	ua, _ := NewUA()
+	defer ua.Close()
+	cli, _ := NewClient(ua)
+	es := NewEventServer(cli, sip.ContactHeader{Address: sip.Uri{User: "bob", Host: "127.0.0.1"}})
+	es.Handle("presence", nil)
+
+	req := testCreateSubscribe(t, 5060, 10)
+	tx := siptest.NewServerTxRecorder(req)
+	require.NoError(t, es.ReadSubscribe(req, tx))
+	assert.Equal(t, sip.StatusIntervalToBrief, tx.Result()[0].StatusCode)
+	assert.Equal(t, "60", tx.Result()[0].GetHeader("Min-Expires").Value())
+
+	req = testCreateSubscribe(t, 5060, 600)
+	req.ReplaceHeaders("Event", sip.NewHeader("Event", "dialog"))
+	tx = siptest.NewServerTxRecorder(req)
+	require.ErrorIs(t, es.ReadSubscribe(req, tx), ErrEventUnknownPackage)
+	assert.Equal(t, sip.StatusCode(489), tx.Result()[0].StatusCode)
+	assert.Equal(t, "presence", tx.Result()[0].GetHeader("Allow-Events").Value())
+
+	// Expired subscriptions are dropped on recover
+	store := NewMemorySubscriptionStore()
+	store.Save(Subscription{ID: "expired", Event: "presence", Expires: time.Now().Add(-time.Second)})
+	es = NewEventServer(cli, sip.ContactHeader{}, WithEventServerStore(store))
+	require.NoError(t, es.Recover(context.Background()))
+	subs, _ := store.Load()
+	assert.Empty(t, subs)
+}
@@ -0,0 +1,56 @@
+package sipgo
+
+import (
+	"sync"
+)
+
+// SubscriptionStore persists subscriptions of EventServer, so they can be recovered after restart.
+// Save is called on every change, including CSeq of sent NOTIFY
+type SubscriptionStore interface {
+	// Save creates or updates subscription by ID
+	Save(sub Subscription) error
+	// Delete removes subscription. Missing subscription is not an error
+	Delete(id string) error
+	// Load returns all stored subscriptions
+	Load() ([]Subscription, error)
+}
+
+// MemorySubscriptionStore is in memory SubscriptionStore. It survives only EventServer recreation
+// and is mostly useful for testing
+type MemorySubscriptionStore struct {
+	mu   sync.Mutex
+	subs map[string]Subscription
+}
+
+// NewMemorySubscriptionStore creates empty in memory subscription store
+func NewMemorySubscriptionStore() *MemorySubscriptionStore {
+	return &MemorySubscriptionStore{
+		subs: make(map[string]Subscription),
+	}
+}
+
+func (s *MemorySubscriptionStore) Save(sub Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub.RouteSet = append([]string(nil), sub.RouteSet...)
+	s.subs[sub.ID] = sub
+	return nil
+}
+
+func (s *MemorySubscriptionStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.subs, id)
+	return nil
+}
+
+func (s *MemorySubscriptionStore) Load() ([]Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	subs := make([]Subscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		sub.RouteSet = append([]string(nil), sub.RouteSet...)
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
@@ -184,7 +184,7 @@ func setupSipProxy(proxydst string, ip string) *sipgo.Server {
 				// Send response imediatelly
 				reply(tx, m, 200, "OK")
 				// Cancel client transacaction without waiting. This will send CANCEL request
-				clTx.Cancel()
+				go clTx.Cancel(context.Background())
 
 			case <-tx.Done():
 				if err := tx.Err(); err != nil {
@@ -5,6 +5,7 @@ import (
 	"net"
 	"sync"
 	"testing"
+	"time"
 )
 
 type TCPConn struct {
@@ -51,6 +52,10 @@ func (c *TCPConn) Close() error {
 	return nil
 }
 
+func (c *TCPConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
 func (c *TCPConn) TestReadConn(t testing.TB) []byte {
 	buffer := make([]byte, 65355)
 	// var buffer [65355]byte
@@ -0,0 +1,77 @@
+package sipgo
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrationIPv6Only(t *testing.T) {
+	if os.Getenv("TEST_INTEGRATION") == "" {
+		t.Skip("Use TEST_INTEGRATION env value to run this test")
+		return
+	}
+
+	dns, err := siptest.NewDNSServer("127.0.0.1:0")
+	require.NoError(t, err)
+	defer dns.Close()
+
+	// Server is reachable only over IPv6, A record points nowhere
+	conn, err := net.ListenPacket("udp", "[::1]:0")
+	require.NoError(t, err)
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	dns.AddHost("pbx.v6.test", net.ParseIP("127.0.0.1"), net.ParseIP("::1"))
+	// DNS64 synthesized from well known ipv4only.arpa address
+	dns.AddHost("ipv4only.arpa", net.ParseIP("64:ff9b::c000:aa"), net.ParseIP("192.0.0.170"))
+
+	srvUA, err := NewUA()
+	require.NoError(t, err)
+	defer srvUA.Close()
+	srv, err := NewServer(srvUA)
+	require.NoError(t, err)
+	vias := make(chan string, 1)
+	srv.OnOptions(func(req *sip.Request, tx sip.ServerTransaction) {
+		vias <- req.Via().Value()
+		res := sip.NewResponseFromRequest(req, sip.StatusOK, "OK", nil)
+		res.AppendHeader(&sip.ContactHeader{Address: sip.Uri{Host: "::1", Port: port}})
+		tx.Respond(res)
+	})
+	go srv.ServeUDP(conn)
+
+	ua, err := NewUA(WithUserAgentDNSResolver(dns.Resolver()), WithUserAgentPreferIPv6())
+	require.NoError(t, err)
+	defer ua.Close()
+	cli, err := NewClient(ua, WithClientHostname("::1"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	req := sip.NewRequest(sip.OPTIONS, &sip.Uri{User: "bob", Host: "pbx.v6.test", Port: port})
+	tx, err := cli.TransactionRequest(ctx, req)
+	require.NoError(t, err)
+	defer tx.Terminate()
+
+	select {
+	case res := <-tx.Responses():
+		assert.Equal(t, sip.StatusOK, res.StatusCode)
+		assert.Equal(t, "<sip:[::1]:"+strconv.Itoa(port)+">", res.Contact().Value())
+	case <-tx.Done():
+		t.Fatal(tx.Err())
+	}
+	assert.Contains(t, <-vias, "SIP/2.0/UDP [::1]:")
+	// AAAA result is used
+	assert.Equal(t, "[::1]:"+strconv.Itoa(port), req.Destination())
+
+	prefix, err := sip.DiscoverNAT64Prefix(ctx, dns.Resolver())
+	require.NoError(t, err)
+	assert.Equal(t, sip.NAT64WellKnownPrefix.String(), prefix.String())
+}
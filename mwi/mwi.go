@@ -0,0 +1,64 @@
+// Package mwi implements message-summary event package for message waiting indication.
+// Mailbox counts set per AOR are sent in NOTIFY to subscribed phones over sipgo EventServer
+// https://datatracker.ietf.org/doc/html/rfc3842
+package mwi
+
+import (
+	"context"
+	"sync"
+
+	"github.com/emiago/sipgo"
+)
+
+// Event is message summary event package name
+const Event = "message-summary"
+
+// Notifier keeps message summaries of mailboxes and notifies subscribed phones on change
+// Ex:
+//
+//	es := sipgo.NewEventServer(client, contactHDR)
+//	notifier := mwi.NewNotifier(es)
+//	srv.OnSubscribe(func(req *sip.Request, tx sip.ServerTransaction) { es.ReadSubscribe(req, tx) })
+//	// On new voicemail
+//	notifier.SetVoicemail(ctx, "sip:alice@example.com", 1, 3)
+type Notifier struct {
+	es *sipgo.EventServer
+
+	mu        sync.Mutex
+	summaries map[string]Summary
+}
+
+// NewNotifier creates notifier and registers message summary event package on event server
+func NewNotifier(es *sipgo.EventServer) *Notifier {
+	n := &Notifier{
+		es:        es,
+		summaries: make(map[string]Summary),
+	}
+	es.Handle(Event, n.state)
+	return n
+}
+
+// Set stores summary of mailbox and sends NOTIFY to phones subscribed to AOR.
+// AOR is in sip.Uri.Addr form, same as Request-URI of SUBSCRIBE
+func (n *Notifier) Set(ctx context.Context, aor string, summary Summary) error {
+	n.mu.Lock()
+	n.summaries[aor] = summary
+	n.mu.Unlock()
+	return n.es.Notify(ctx, Event, aor)
+}
+
+// SetVoicemail sets voice message counts of mailbox. Check Set
+func (n *Notifier) SetVoicemail(ctx context.Context, aor string, newMsgs int, oldMsgs int) error {
+	return n.Set(ctx, aor, NewVoiceSummary(aor, newMsgs, oldMsgs))
+}
+
+// Summary returns summary of mailbox. Mailbox without summary has no messages waiting
+func (n *Notifier) Summary(aor string) Summary {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.summaries[aor]
+}
+
+func (n *Notifier) state(sub sipgo.Subscription) (string, []byte) {
+	return ContentType, n.Summary(sub.Resource).Body()
+}
@@ -0,0 +1,123 @@
+package mwi
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo"
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummary(t *testing.T) {
+	// https://datatracker.ietf.org/doc/html/rfc3842#section-5.2
+	data := "Messages-Waiting: yes\r\n" +
+		"Message-Account: sip:alice@vmail.example.com\r\n" +
+		"Voice-Message: 4/8 (1/2)\r\n" +
+		"Fax-Message: 0/1\r\n" +
+		"\r\n" +
+		"Message-ID: 123\r\n"
+	s, err := Parse([]byte(data))
+	require.NoError(t, err)
+	assert.True(t, s.Waiting)
+	assert.Equal(t, "sip:alice@vmail.example.com", s.Account)
+	assert.Equal(t, []Messages{
+		{Class: ClassVoice, New: 4, Old: 8, UrgentNew: 1, UrgentOld: 2},
+		{Class: ClassFax, New: 0, Old: 1},
+	}, s.Messages)
+
+	assert.Equal(t, "Messages-Waiting: yes\r\n"+
+		"Message-Account: sip:alice@vmail.example.com\r\n"+
+		"Voice-Message: 4/8 (1/2)\r\n"+
+		"Fax-Message: 0/1\r\n", string(s.Body()))
+
+	s, err = Parse([]byte("messages-waiting: no\n"))
+	require.NoError(t, err)
+	assert.False(t, s.Waiting)
+	assert.Equal(t, "Messages-Waiting: no\r\n", string(Summary{}.Body()))
+
+	_, err = Parse([]byte("Voice-Message: 1/0\r\n"))
+	assert.Error(t, err)
+	_, err = Parse([]byte("Messages-Waiting: maybe\r\n"))
+	assert.Error(t, err)
+}
+
+func TestNotifier(t *testing.T) {
+	// Phone receiving NOTIFY
+	phoneUA, err := sipgo.NewUA(sipgo.WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer phoneUA.Close()
+	phone, err := sipgo.NewServer(phoneUA)
+	require.NoError(t, err)
+	notifies := make(chan *sip.Request, 5)
+	phone.OnNotify(func(req *sip.Request, tx sip.ServerTransaction) {
+		notifies <- req
+		tx.Respond(sip.NewResponseFromRequest(req, sip.StatusOK, "OK", nil))
+	})
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go phone.ServeUDP(conn)
+	addr := conn.LocalAddr().String()
+
+	ua, err := sipgo.NewUA(sipgo.WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer ua.Close()
+	cli, err := sipgo.NewClient(ua, sipgo.WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+	es := sipgo.NewEventServer(cli, sip.ContactHeader{Address: sip.Uri{User: "vmail", Host: "127.0.0.1", Port: 5060}})
+	notifier := NewNotifier(es)
+
+	waitNotify := func() Summary {
+		t.Helper()
+		select {
+		case req := <-notifies:
+			assert.Equal(t, ContentType, req.ContentType().Value())
+			assert.Equal(t, Event, req.GetHeader("Event").Value())
+			s, err := Parse(req.Body())
+			require.NoError(t, err)
+			return s
+		case <-time.After(2 * time.Second):
+			t.Fatal("NOTIFY not received")
+		}
+		return Summary{}
+	}
+
+	msg, err := sip.ParseMessage([]byte(strings.Join([]string{
+		"SUBSCRIBE sip:alice@127.0.0.1 SIP/2.0",
+		"Via: SIP/2.0/UDP " + addr + ";branch=" + sip.GenerateBranch(),
+		"From: <sip:alice@127.0.0.1>;tag=" + sip.GenerateTagN(8),
+		"To: <sip:alice@127.0.0.1>",
+		"Contact: <sip:alice@" + addr + ">",
+		"Call-ID: " + sip.GenerateTagN(16),
+		"CSeq: 1 SUBSCRIBE",
+		"Event: message-summary",
+		"Expires: 600",
+		"Content-Length: 0",
+		"",
+		"",
+	}, "\r\n")))
+	require.NoError(t, err)
+	sub := msg.(*sip.Request)
+	require.NoError(t, es.ReadSubscribe(sub, siptest.NewServerTxRecorder(sub)))
+	assert.False(t, waitNotify().Waiting)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, notifier.SetVoicemail(ctx, "sip:alice@127.0.0.1", 2, 5))
+	s := waitNotify()
+	assert.True(t, s.Waiting)
+	assert.Equal(t, []Messages{{Class: ClassVoice, New: 2, Old: 5}}, s.Messages)
+
+	// Other mailbox is not notified to alice
+	require.NoError(t, notifier.SetVoicemail(ctx, "sip:bob@127.0.0.1", 1, 0))
+	require.NoError(t, notifier.SetVoicemail(ctx, "sip:alice@127.0.0.1", 0, 7))
+	s = waitNotify()
+	assert.False(t, s.Waiting)
+	assert.Equal(t, 7, s.Messages[0].Old)
+}
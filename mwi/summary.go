@@ -0,0 +1,163 @@
+package mwi
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ContentType is content type of message summary
+	ContentType = "application/simple-message-summary"
+
+	// Message context classes
+	ClassVoice      = "voice-message"
+	ClassFax        = "fax-message"
+	ClassPager      = "pager-message"
+	ClassMultimedia = "multimedia-message"
+	ClassText       = "text-message"
+)
+
+// Summary is message summary of mailbox
+// https://datatracker.ietf.org/doc/html/rfc3842#section-5
+type Summary struct {
+	// Waiting reports are there new messages. It is set by NewSummary and Parse
+	Waiting bool
+	// Account is mailbox URI, ex. sip:alice@vmail.example.com
+	Account  string
+	Messages []Messages
+}
+
+// Messages are message counts of message context class
+type Messages struct {
+	// Class is message context class, ex. ClassVoice
+	Class     string
+	New       int
+	Old       int
+	UrgentNew int
+	UrgentOld int
+}
+
+// NewVoiceSummary creates summary with voice message counts
+func NewVoiceSummary(account string, newMsgs int, oldMsgs int) Summary {
+	return Summary{
+		Waiting:  newMsgs > 0,
+		Account:  account,
+		Messages: []Messages{{Class: ClassVoice, New: newMsgs, Old: oldMsgs}},
+	}
+}
+
+// Parse parses simple message summary body
+func Parse(data []byte) (Summary, error) {
+	var s Summary
+	var waiting bool
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			return s, fmt.Errorf("mwi: invalid line %q", line)
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "messages-waiting":
+			switch strings.ToLower(value) {
+			case "yes":
+				s.Waiting = true
+			case "no":
+			default:
+				return s, fmt.Errorf("mwi: invalid Messages-Waiting %q", value)
+			}
+			waiting = true
+		case "message-account":
+			s.Account = value
+		default:
+			m, err := parseMessages(value)
+			if err != nil {
+				// Optional headers of summary are ignored
+				continue
+			}
+			m.Class = strings.ToLower(strings.TrimSpace(name))
+			s.Messages = append(s.Messages, m)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return s, fmt.Errorf("mwi: %w", err)
+	}
+	if !waiting {
+		return s, fmt.Errorf("mwi: Messages-Waiting missing")
+	}
+	return s, nil
+}
+
+// parseMessages parses new/old (urgent_new/urgent_old) counts
+func parseMessages(value string) (Messages, error) {
+	var m Messages
+	counts, urgent, hasUrgent := strings.Cut(value, "(")
+	var err error
+	if m.New, m.Old, err = parseCounts(counts); err != nil {
+		return m, err
+	}
+	if hasUrgent {
+		urgent, _, _ = strings.Cut(urgent, ")")
+		if m.UrgentNew, m.UrgentOld, err = parseCounts(urgent); err != nil {
+			return m, err
+		}
+	}
+	return m, nil
+}
+
+func parseCounts(s string) (int, int, error) {
+	newCount, oldCount, found := strings.Cut(strings.TrimSpace(s), "/")
+	if !found {
+		return 0, 0, fmt.Errorf("mwi: invalid counts %q", s)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(newCount))
+	if err != nil {
+		return 0, 0, fmt.Errorf("mwi: invalid counts %q", s)
+	}
+	o, err := strconv.Atoi(strings.TrimSpace(oldCount))
+	if err != nil {
+		return 0, 0, fmt.Errorf("mwi: invalid counts %q", s)
+	}
+	return n, o, nil
+}
+
+// Body returns summary in simple message summary format
+func (s Summary) Body() []byte {
+	var b strings.Builder
+	b.WriteString("Messages-Waiting: ")
+	if s.Waiting {
+		b.WriteString("yes\r\n")
+	} else {
+		b.WriteString("no\r\n")
+	}
+	if s.Account != "" {
+		b.WriteString("Message-Account: " + s.Account + "\r\n")
+	}
+	for _, m := range s.Messages {
+		b.WriteString(headerCase(m.Class) + ": " + strconv.Itoa(m.New) + "/" + strconv.Itoa(m.Old))
+		if m.UrgentNew > 0 || m.UrgentOld > 0 {
+			b.WriteString(" (" + strconv.Itoa(m.UrgentNew) + "/" + strconv.Itoa(m.UrgentOld) + ")")
+		}
+		b.WriteString("\r\n")
+	}
+	return []byte(b.String())
+}
+
+// headerCase converts class to header form, ex. voice-message to Voice-Message
+func headerCase(class string) string {
+	parts := strings.Split(class, "-")
+	for i, p := range parts {
+		if p != "" {
+			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return strings.Join(parts, "-")
+}
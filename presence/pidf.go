@@ -0,0 +1,117 @@
+package presence
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+const (
+	// ContentType is content type of PIDF document
+	ContentType = "application/pidf+xml"
+	// Namespace is PIDF XML namespace
+	Namespace = "urn:ietf:params:xml:ns:pidf"
+
+	BasicOpen   = "open"
+	BasicClosed = "closed"
+)
+
+// Document is PIDF presence document. Elements of extensions like RPID are not kept
+// https://datatracker.ietf.org/doc/html/rfc3863
+type Document struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:pidf presence"`
+	// Entity is presentity URI, ex. pres:alice@example.com
+	Entity string  `xml:"entity,attr"`
+	Tuples []Tuple `xml:"tuple"`
+	Notes  []Note  `xml:"note"`
+}
+
+// Tuple is presence information of one communication address of presentity
+type Tuple struct {
+	ID      string   `xml:"id,attr"`
+	Status  Status   `xml:"status"`
+	Contact *Contact `xml:"contact,omitempty"`
+	Notes   []Note   `xml:"note"`
+	// Timestamp is time of change in RFC 3339 format
+	Timestamp string `xml:"timestamp,omitempty"`
+}
+
+// Status is tuple status. Basic is BasicOpen or BasicClosed
+type Status struct {
+	Basic string `xml:"basic,omitempty"`
+}
+
+type Contact struct {
+	// Priority is value between 0 and 1
+	Priority string `xml:"priority,attr,omitempty"`
+	URI      string `xml:",chardata"`
+}
+
+// Note is human readable comment, ex. "In meeting"
+type Note struct {
+	Lang string `xml:"http://www.w3.org/XML/1998/namespace lang,attr,omitempty"`
+	Text string `xml:",chardata"`
+}
+
+// NewDocument creates document of entity with single tuple with basic status
+func NewDocument(entity string, id string, basic string) *Document {
+	return &Document{
+		Entity: entity,
+		Tuples: []Tuple{{ID: id, Status: Status{Basic: basic}}},
+	}
+}
+
+// Parse parses PIDF document
+func Parse(data []byte) (*Document, error) {
+	doc := &Document{}
+	if err := xml.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("pidf: %w", err)
+	}
+	if doc.Entity == "" {
+		return nil, fmt.Errorf("pidf: entity missing")
+	}
+	for _, t := range doc.Tuples {
+		if t.ID == "" {
+			return nil, fmt.Errorf("pidf: tuple id missing")
+		}
+	}
+	return doc, nil
+}
+
+// Marshal returns document with XML declaration
+func (d *Document) Marshal() ([]byte, error) {
+	data, err := xml.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// Open reports is any tuple open
+func (d *Document) Open() bool {
+	for _, t := range d.Tuples {
+		if t.Status.Basic == BasicOpen {
+			return true
+		}
+	}
+	return false
+}
+
+// Compose merges documents, ex. published from multiple devices, into one document of entity.
+// Tuple with same id in later document replaces earlier one
+// https://datatracker.ietf.org/doc/html/rfc3903#section-4
+func Compose(entity string, docs ...*Document) *Document {
+	composed := &Document{Entity: entity}
+	index := make(map[string]int)
+	for _, doc := range docs {
+		for _, t := range doc.Tuples {
+			if i, exists := index[t.ID]; exists {
+				composed.Tuples[i] = t
+				continue
+			}
+			index[t.ID] = len(composed.Tuples)
+			composed.Tuples = append(composed.Tuples, t)
+		}
+		composed.Notes = append(composed.Notes, doc.Notes...)
+	}
+	return composed
+}
@@ -0,0 +1,70 @@
+// Package presence implements presence event package over sipgo EventServer.
+// Presence published by user agents with PUBLISH is composed into PIDF document sent to watchers in NOTIFY
+// https://datatracker.ietf.org/doc/html/rfc3856
+package presence
+
+import (
+	"strings"
+
+	"github.com/emiago/sipgo"
+	"github.com/emiago/sipgo/sip"
+)
+
+// Event is presence event package name
+const Event = "presence"
+
+// Handle registers presence event package on event server. PUBLISH with PIDF body is accepted
+// and watchers get NOTIFY with document composed of all publications of presentity.
+// Presentity without publications is reported closed
+// Ex:
+//
+//	es := sipgo.NewEventServer(client, contactHDR)
+//	presence.Handle(es)
+//	srv.OnSubscribe(func(req *sip.Request, tx sip.ServerTransaction) { es.ReadSubscribe(req, tx) })
+//	srv.OnPublish(func(req *sip.Request, tx sip.ServerTransaction) { es.ReadPublish(req, tx) })
+func Handle(es *sipgo.EventServer) {
+	es.Handle(Event, func(sub sipgo.Subscription) (string, []byte) {
+		doc := State(es, sub.Resource)
+		body, err := doc.Marshal()
+		if err != nil {
+			return "", nil
+		}
+		return ContentType, body
+	})
+	es.HandlePublish(Event, acceptPublication)
+}
+
+// State returns current presence document of presentity composed from publications.
+// Resource is in sip.Uri.Addr form
+func State(es *sipgo.EventServer, resource string) *Document {
+	var docs []*Document
+	for _, p := range es.Publications(Event, resource) {
+		// Publications are validated on PUBLISH
+		if doc, err := Parse(p.Body); err == nil {
+			docs = append(docs, doc)
+		}
+	}
+
+	entity := resource
+	if len(docs) > 0 {
+		entity = docs[len(docs)-1].Entity
+	}
+	doc := Compose(entity, docs...)
+	if len(doc.Tuples) == 0 {
+		doc.Tuples = []Tuple{{ID: "0", Status: Status{Basic: BasicClosed}}}
+	}
+	return doc
+}
+
+func acceptPublication(pub sipgo.Publication) error {
+	mediaType, _, _ := strings.Cut(pub.ContentType, ";")
+	if !strings.EqualFold(strings.TrimSpace(mediaType), ContentType) {
+		return sip.StatusError{
+			Code:    sip.StatusUnsupportedMediaType,
+			Reason:  "Unsupported Media Type",
+			Headers: []sip.Header{sip.NewHeader("Accept", ContentType)},
+		}
+	}
+	_, err := Parse(pub.Body)
+	return err
+}
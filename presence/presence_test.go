@@ -0,0 +1,163 @@
+package presence
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo"
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCreateRequest(t *testing.T, lines []string) *sip.Request {
+	msg, err := sip.ParseMessage([]byte(strings.Join(lines, "\r\n")))
+	require.NoError(t, err)
+	return msg.(*sip.Request)
+}
+
+func TestDocument(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+<presence xmlns="urn:ietf:params:xml:ns:pidf" entity="pres:alice@example.com">
+  <tuple id="sg89ae">
+    <status><basic>open</basic></status>
+    <contact priority="0.8">tel:+09012345678</contact>
+    <note xml:lang="en">In meeting</note>
+  </tuple>
+  <note>Away</note>
+</presence>`
+	doc, err := Parse([]byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, "pres:alice@example.com", doc.Entity)
+	require.Len(t, doc.Tuples, 1)
+	tuple := doc.Tuples[0]
+	assert.Equal(t, "sg89ae", tuple.ID)
+	assert.Equal(t, BasicOpen, tuple.Status.Basic)
+	assert.Equal(t, &Contact{Priority: "0.8", URI: "tel:+09012345678"}, tuple.Contact)
+	assert.Equal(t, []Note{{Lang: "en", Text: "In meeting"}}, tuple.Notes)
+	assert.Equal(t, "Away", doc.Notes[0].Text)
+	assert.True(t, doc.Open())
+
+	out, err := doc.Marshal()
+	require.NoError(t, err)
+	reparsed, err := Parse(out)
+	require.NoError(t, err)
+	assert.Equal(t, doc.Tuples, reparsed.Tuples)
+	assert.Contains(t, string(out), `xmlns="urn:ietf:params:xml:ns:pidf"`)
+
+	_, err = Parse([]byte(`<presence xmlns="urn:ietf:params:xml:ns:pidf"/>`))
+	assert.Error(t, err)
+	_, err = Parse([]byte(`not xml`))
+	assert.Error(t, err)
+}
+
+func TestCompose(t *testing.T) {
+	phone := NewDocument("pres:alice@example.com", "phone", BasicClosed)
+	laptop := NewDocument("pres:alice@example.com", "laptop", BasicOpen)
+	phoneOpen := NewDocument("pres:alice@example.com", "phone", BasicOpen)
+
+	doc := Compose("pres:alice@example.com", phone, laptop, phoneOpen)
+	require.Len(t, doc.Tuples, 2)
+	assert.Equal(t, "phone", doc.Tuples[0].ID)
+	assert.Equal(t, BasicOpen, doc.Tuples[0].Status.Basic)
+	assert.Equal(t, "laptop", doc.Tuples[1].ID)
+}
+
+func TestPresenceServer(t *testing.T) {
+	// Watcher receiving NOTIFY
+	watcherUA, err := sipgo.NewUA(sipgo.WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer watcherUA.Close()
+	watcher, err := sipgo.NewServer(watcherUA)
+	require.NoError(t, err)
+	notifies := make(chan *sip.Request, 5)
+	watcher.OnNotify(func(req *sip.Request, tx sip.ServerTransaction) {
+		notifies <- req
+		tx.Respond(sip.NewResponseFromRequest(req, sip.StatusOK, "OK", nil))
+	})
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go watcher.ServeUDP(conn)
+	addr := conn.LocalAddr().String()
+
+	ua, err := sipgo.NewUA(sipgo.WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer ua.Close()
+	cli, err := sipgo.NewClient(ua, sipgo.WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+	es := sipgo.NewEventServer(cli, sip.ContactHeader{Address: sip.Uri{User: "bob", Host: "127.0.0.1", Port: 5060}})
+	Handle(es)
+
+	waitNotify := func() *Document {
+		t.Helper()
+		select {
+		case req := <-notifies:
+			assert.Equal(t, ContentType, req.ContentType().Value())
+			doc, err := Parse(req.Body())
+			require.NoError(t, err)
+			return doc
+		case <-time.After(2 * time.Second):
+			t.Fatal("NOTIFY not received")
+		}
+		return nil
+	}
+
+	sub := testCreateRequest(t, []string{
+		"SUBSCRIBE sip:bob@127.0.0.1:5060 SIP/2.0",
+		"Via: SIP/2.0/UDP " + addr + ";branch=" + sip.GenerateBranch(),
+		"From: <sip:alice@127.0.0.1>;tag=" + sip.GenerateTagN(8),
+		"To: <sip:bob@127.0.0.1:5060>",
+		"Contact: <sip:alice@" + addr + ">",
+		"Call-ID: " + sip.GenerateTagN(16),
+		"CSeq: 1 SUBSCRIBE",
+		"Event: presence",
+		"Expires: 600",
+		"Content-Length: 0",
+		"",
+		"",
+	})
+	require.NoError(t, es.ReadSubscribe(sub, siptest.NewServerTxRecorder(sub)))
+	doc := waitNotify()
+	assert.False(t, doc.Open())
+
+	publish := func(contentType string, body []byte) *sip.Response {
+		req := testCreateRequest(t, []string{
+			"PUBLISH sip:bob@127.0.0.1:5060 SIP/2.0",
+			"Via: SIP/2.0/UDP 127.0.0.2:5060;branch=" + sip.GenerateBranch(),
+			"From: <sip:bob@127.0.0.1>;tag=" + sip.GenerateTagN(8),
+			"To: <sip:bob@127.0.0.1:5060>",
+			"Call-ID: " + sip.GenerateTagN(16),
+			"CSeq: 1 PUBLISH",
+			"Event: presence",
+			"Expires: 600",
+			"Content-Type: " + contentType,
+			"Content-Length: " + strconv.Itoa(len(body)),
+			"",
+			string(body),
+		})
+		tx := siptest.NewServerTxRecorder(req)
+		es.ReadPublish(req, tx)
+		require.Len(t, tx.Result(), 1)
+		return tx.Result()[0]
+	}
+
+	res := publish("text/plain", []byte("open"))
+	assert.Equal(t, sip.StatusUnsupportedMediaType, res.StatusCode)
+	res = publish(ContentType, []byte("<presence"))
+	assert.Equal(t, sip.StatusBadRequest, res.StatusCode)
+
+	body, err := NewDocument("pres:bob@127.0.0.1", "phone", BasicOpen).Marshal()
+	require.NoError(t, err)
+	res = publish(ContentType, body)
+	require.Equal(t, sip.StatusOK, res.StatusCode)
+
+	doc = waitNotify()
+	assert.Equal(t, "pres:bob@127.0.0.1", doc.Entity)
+	assert.True(t, doc.Open())
+	assert.True(t, State(es, "sip:bob@127.0.0.1:5060").Open())
+}
@@ -0,0 +1,120 @@
+package sipgo
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrProxyTooManyHops  = errors.New("Too many hops")
+	ErrProxyLoopDetected = errors.New("Loop detected")
+)
+
+// proxyLoopBranchMarker separates loop detection hash in branch
+const proxyLoopBranchMarker = ".lp"
+
+// ProxyLoopBranch appends loop detection hash to branch of Via added by forwarding proxy.
+// Hash covers fields of received request which decide routing. Request coming back with same fields
+// is loop, while request with changed Request-URI is spiral
+// https://datatracker.ietf.org/doc/html/rfc3261#section-16.6 step 8
+func ProxyLoopBranch(branch string, req *sip.Request) string {
+	return branch + proxyLoopBranchMarker + proxyLoopHash(req)
+}
+
+func proxyLoopHash(req *sip.Request) string {
+	h := sha1.New()
+	h.Write([]byte(req.Recipient.String()))
+	if to := req.To(); to != nil {
+		tag, _ := to.Params.Get("tag")
+		h.Write([]byte(tag))
+	}
+	if from := req.From(); from != nil {
+		tag, _ := from.Params.Get("tag")
+		h.Write([]byte(tag))
+	}
+	if callid := req.CallID(); callid != nil {
+		h.Write([]byte(callid.Value()))
+	}
+	if cseq := req.CSeq(); cseq != nil {
+		fmt.Fprint(h, cseq.SeqNo)
+	}
+	for _, name := range []string{"Proxy-Require", "Proxy-Authorization"} {
+		for _, hdr := range req.GetHeaders(name) {
+			h.Write([]byte(hdr.Value()))
+		}
+	}
+	sum := h.Sum(nil)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ProxyCheckLoop enforces Max-Forwards and does loop detection on received request before forwarding.
+// ownVia reports is Via added by this proxy. Loop is detected when own Via branch carries same loop hash
+// as computed for request. It returns ErrProxyTooManyHops or ErrProxyLoopDetected
+// https://datatracker.ietf.org/doc/html/rfc3261#section-16.3
+func ProxyCheckLoop(req *sip.Request, ownVia func(via *sip.ViaHeader) bool) error {
+	if maxfwd := req.MaxForwards(); maxfwd != nil && maxfwd.Val() <= 0 {
+		return ErrProxyTooManyHops
+	}
+
+	var hash string
+	for _, h := range req.GetHeaders("Via") {
+		via, ok := h.(*sip.ViaHeader)
+		if !ok || !ownVia(via) {
+			continue
+		}
+
+		branch, _ := via.Params.Get("branch")
+		ind := strings.LastIndex(branch, proxyLoopBranchMarker)
+		if ind < 0 {
+			continue
+		}
+
+		if hash == "" {
+			hash = proxyLoopHash(req)
+		}
+		if branch[ind+len(proxyLoopBranchMarker):] == hash {
+			return ErrProxyLoopDetected
+		}
+		// Otherwise request is spiraling
+	}
+	return nil
+}
+
+// LoopDetectionHandler wraps request handler of custom forwarder. It rejects request with
+// 483 Too Many Hops when Max-Forwards is exhausted and with 482 Loop Detected on loop.
+// Forwarder should use ProxyLoopBranch for Via branch
+// Ex:
+//
+//	srv.OnInvite(sipgo.LoopDetectionHandler(ownVia, forwardHandler))
+func LoopDetectionHandler(ownVia func(via *sip.ViaHeader) bool, next RequestHandler) RequestHandler {
+	return func(req *sip.Request, tx sip.ServerTransaction) {
+		err := ProxyCheckLoop(req, ownVia)
+		if err == nil {
+			next(req, tx)
+			return
+		}
+
+		log.Debug().Err(err).Str("source", req.Source()).Str("method", req.Method.String()).Msg("Request rejected")
+		if req.IsAck() {
+			return
+		}
+
+		res := proxyLoopResponse(req, err)
+		if err := tx.Respond(res); err != nil {
+			log.Error().Err(err).Msg("respond loop detection failed")
+		}
+	}
+}
+
+func proxyLoopResponse(req *sip.Request, err error) *sip.Response {
+	if errors.Is(err, ErrProxyTooManyHops) {
+		return sip.NewResponseFromRequest(req, 483, "Too Many Hops", nil)
+	}
+	return sip.NewResponseFromRequest(req, 482, "Loop Detected", nil)
+}
@@ -0,0 +1,58 @@
+package sipgo
+
+import (
+	"testing"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyCheckLoop(t *testing.T) {
+	ownVia := func(via *sip.ViaHeader) bool {
+		return via.Host == "10.0.0.1" && via.Port == 5060
+	}
+
+	// Forward request like proxy would do
+	req, _, _ := createTestInvite(t, "sip:bob@10.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	require.NoError(t, ProxyCheckLoop(req, ownVia))
+	via := &sip.ViaHeader{ProtocolName: "SIP", ProtocolVersion: "2.0", Transport: "UDP", Host: "10.0.0.1", Port: 5060, Params: sip.NewParams()}
+	via.Params.Add("branch", ProxyLoopBranch(sip.GenerateBranch(), req))
+	req.PrependHeader(via)
+
+	// Request comes back unchanged
+	looped := req.Clone()
+	other := &sip.ViaHeader{ProtocolName: "SIP", ProtocolVersion: "2.0", Transport: "UDP", Host: "10.0.0.2", Port: 5060, Params: sip.NewParams()}
+	other.Params.Add("branch", sip.GenerateBranch())
+	looped.PrependHeader(other)
+	assert.ErrorIs(t, ProxyCheckLoop(looped, ownVia), ErrProxyLoopDetected)
+
+	// Request comes back with different Request-URI
+	spiral := looped.Clone()
+	spiral.Recipient.User = "bob-mobile"
+	assert.NoError(t, ProxyCheckLoop(spiral, ownVia))
+
+	maxfwd := sip.MaxForwardsHeader(0)
+	spiral.AppendHeader(&maxfwd)
+	assert.ErrorIs(t, ProxyCheckLoop(spiral, ownVia), ErrProxyTooManyHops)
+}
+
+func TestLoopDetectionHandler(t *testing.T) {
+	var handled int
+	h := LoopDetectionHandler(func(via *sip.ViaHeader) bool { return false }, func(req *sip.Request, tx sip.ServerTransaction) {
+		handled++
+	})
+
+	req, _, _ := createTestInvite(t, "sip:bob@10.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	h(req, siptest.NewServerTxRecorder(req))
+	assert.Equal(t, 1, handled)
+
+	maxfwd := sip.MaxForwardsHeader(0)
+	req.AppendHeader(&maxfwd)
+	tx := siptest.NewServerTxRecorder(req)
+	h(req, tx)
+	require.Len(t, tx.Result(), 1)
+	assert.Equal(t, sip.StatusCode(483), tx.Result()[0].StatusCode)
+	assert.Equal(t, 1, handled)
+}
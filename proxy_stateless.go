@@ -0,0 +1,231 @@
+package sipgo
+
+import (
+	"errors"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// StatelessRouter returns destination address host:port where request is forwarded.
+// Router can change request like Recipient or transport with SetTransport.
+// Returning empty destination rejects request with 404 Not Found
+type StatelessRouter func(req *sip.Request) string
+
+// StatelessProxy forwards requests and responses without keeping any transaction state.
+// Via branch is computed from received request, so retransmissions are forwarded with same branch,
+// and responses are routed purely by Via. Max-Forwards and loops are checked with ProxyCheckLoop.
+// It can be used for building load balancer tier
+// https://datatracker.ietf.org/doc/html/rfc3261#section-16.11
+type StatelessProxy struct {
+	tp     *sip.TransportLayer
+	router StatelessRouter
+	host   string
+	// routeSet routes in-dialog requests by Route headers
+	routeSet bool
+	// trustDomain applies identity trust boundary on forwarded requests
+	trustDomain *TrustDomain
+
+	log zerolog.Logger
+}
+
+type StatelessProxyOption func(p *StatelessProxy) error
+
+// WithStatelessProxyLogger allows customizing proxy logger
+func WithStatelessProxyLogger(logger zerolog.Logger) StatelessProxyOption {
+	return func(p *StatelessProxy) error {
+		p.log = logger
+		return nil
+	}
+}
+
+// WithStatelessProxyHost sets host used in Via header added by proxy.
+// Default is user agent IP
+func WithStatelessProxyHost(host string) StatelessProxyOption {
+	return func(p *StatelessProxy) error {
+		p.host = host
+		return nil
+	}
+}
+
+// WithStatelessProxyRouteSet routes requests following recorded route set, like in-dialog BYE or ACK,
+// purely by Route headers and Request-URI without any dialog state. Route headers pointing to proxy are removed,
+// also double Record-Route like with Kamailio, and strict routers are handled in both directions.
+// Router is called only for initial requests, after removing preloaded own Route
+// https://datatracker.ietf.org/doc/html/rfc3261#section-16.4
+func WithStatelessProxyRouteSet() StatelessProxyOption {
+	return func(p *StatelessProxy) error {
+		p.routeSet = true
+		return nil
+	}
+}
+
+// WithStatelessProxyTrustDomain applies trust domain on forwarded requests.
+// Ingress is applied on received request and Egress toward destination returned by router
+func WithStatelessProxyTrustDomain(td *TrustDomain) StatelessProxyOption {
+	return func(p *StatelessProxy) error {
+		p.trustDomain = td
+		return nil
+	}
+}
+
+// NewStatelessProxy creates stateless proxy. It takes over request and unhandled response handling
+// of user agent, so user agent should not be used for Server at same time
+func NewStatelessProxy(ua *UserAgent, router StatelessRouter, options ...StatelessProxyOption) (*StatelessProxy, error) {
+	p := &StatelessProxy{
+		tp:     ua.tp,
+		router: router,
+		host:   ua.GetIP().String(),
+		log:    log.Logger.With().Str("caller", "StatelessProxy").Logger(),
+	}
+	for _, o := range options {
+		if err := o(p); err != nil {
+			return nil, err
+		}
+	}
+
+	ua.txl.OnRequestStateless(p.forwardRequest)
+	ua.txl.UnhandledResponseHandler(p.forwardResponse)
+	return p, nil
+}
+
+// forwardRequest forwards request to destination returned by router
+// https://datatracker.ietf.org/doc/html/rfc3261#section-16.6
+func (p *StatelessProxy) forwardRequest(req *sip.Request) {
+	if err := ProxyCheckLoop(req, p.ownVia); err != nil {
+		p.log.Debug().Err(err).Str("source", req.Source()).Msg("Request rejected")
+		if !req.IsAck() {
+			p.writeResponse(proxyLoopResponse(req, err))
+		}
+		return
+	}
+
+	if maxfwd := req.MaxForwards(); maxfwd != nil {
+		maxfwd.Dec()
+	} else {
+		maxfwd := sip.MaxForwardsHeader(70)
+		req.AppendHeader(&maxfwd)
+	}
+
+	// Branch must be computed from request as received
+	branch := ProxyLoopBranch(sip.GenerateBranchStateless(req), req)
+
+	dst, routed, err := p.routeRequest(req)
+	if err != nil {
+		p.log.Debug().Err(err).Str("source", req.Source()).Msg("Route processing failed")
+		p.respond(req, 400, "Bad Request")
+		return
+	}
+	if !routed {
+		dst = p.router(req)
+	}
+	if dst == "" {
+		p.respond(req, 404, "Not Found")
+		return
+	}
+	if p.trustDomain != nil {
+		p.trustDomain.Ingress(req)
+		p.trustDomain.Egress(req, dst)
+	}
+
+	network := sip.NetworkToLower(req.Transport())
+	via := &sip.ViaHeader{
+		ProtocolName:    "SIP",
+		ProtocolVersion: "2.0",
+		Transport:       req.Transport(),
+		Host:            p.host,
+		Port:            p.tp.GetListenPort(network),
+		Params:          sip.NewParams(),
+	}
+	via.Params.Add("branch", branch)
+	req.PrependHeader(via)
+	req.SetDestination(dst)
+
+	if err := p.tp.WriteMsg(req); err != nil {
+		p.log.Error().Err(err).Str("dst", dst).Str("req", req.Method.String()).Msg("Failed to forward request")
+	}
+}
+
+// forwardResponse removes proxy Via and sends response to address in next Via
+// https://datatracker.ietf.org/doc/html/rfc3261#section-16.7
+func (p *StatelessProxy) forwardResponse(res *sip.Response) {
+	res.RemoveHeader("Via")
+	via := res.Via()
+	if via == nil {
+		// Response was meant for proxy itself
+		p.log.Debug().Str("res", res.Short()).Msg("Response without Via to forward. Dropping")
+		return
+	}
+
+	res.SetTransport(via.Transport)
+	res.SetDestination("")
+	if err := p.tp.WriteMsg(res); err != nil {
+		p.log.Error().Err(err).Str("dst", res.Destination()).Msg("Failed to forward response")
+	}
+}
+
+// routeRequest routes request by route set. Requests which are not in-dialog and do not have Route
+// after removing own are not routed and left to router
+func (p *StatelessProxy) routeRequest(req *sip.Request) (string, bool, error) {
+	if !p.routeSet {
+		return "", false, nil
+	}
+
+	to := req.To()
+	inDialog := to != nil && to.Params.Has("tag")
+	if !inDialog && req.Route() == nil {
+		return "", false, nil
+	}
+
+	if err := sip.RouteProcess(req, func(uri *sip.Uri) bool { return p.ownRoute(req, uri) }); err != nil {
+		if errors.Is(err, sip.ErrRouteSetMissing) {
+			// Request-URI is proxy itself
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	if !inDialog && req.Route() == nil {
+		return "", false, nil
+	}
+
+	sip.RouteNextHop(req)
+	return req.Destination(), true, nil
+}
+
+// ownRoute checks does uri point to proxy. Transport of uri decides listen port
+func (p *StatelessProxy) ownRoute(req *sip.Request, uri *sip.Uri) bool {
+	if uri == nil || uri.Host != p.host {
+		return false
+	}
+
+	network := sip.NetworkToLower(req.Transport())
+	if tran, ok := uri.UriParams.Get("transport"); ok && tran != "" {
+		network = sip.NetworkToLower(tran)
+	}
+	port := uri.Port
+	if port == 0 {
+		port = sip.DefaultPort(network)
+	}
+	return port == p.tp.GetListenPort(network)
+}
+
+// ownVia checks is Via added by proxy
+func (p *StatelessProxy) ownVia(via *sip.ViaHeader) bool {
+	return via.Host == p.host && via.Port == p.tp.GetListenPort(sip.NetworkToLower(via.Transport))
+}
+
+// respond sends response without transaction. ACK is never responded
+func (p *StatelessProxy) respond(req *sip.Request, code sip.StatusCode, reason string) {
+	if req.IsAck() {
+		return
+	}
+	p.writeResponse(sip.NewResponseFromRequest(req, code, reason, nil))
+}
+
+func (p *StatelessProxy) writeResponse(res *sip.Response) {
+	if err := p.tp.WriteMsg(res); err != nil {
+		p.log.Error().Err(err).Int("code", int(res.StatusCode)).Msg("Failed to respond")
+	}
+}
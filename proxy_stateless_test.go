@@ -0,0 +1,219 @@
+package sipgo
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatelessProxy(t *testing.T) {
+	ua, _ := NewUA()
+	defer ua.Close()
+
+	uac, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer uac.Close()
+	uas, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer uas.Close()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	_, err = NewStatelessProxy(ua, func(req *sip.Request) string {
+		switch req.Recipient.User {
+		case "bob":
+			return uas.LocalAddr().String()
+		case "loop":
+			return conn.LocalAddr().String()
+		}
+		return ""
+	}, WithStatelessProxyHost("127.0.0.1"))
+	require.NoError(t, err)
+	go ua.tp.ServeUDP(conn)
+
+	read := func(c net.PacketConn) sip.Message {
+		buf := make([]byte, 2000)
+		c.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := c.ReadFrom(buf)
+		require.NoError(t, err)
+		msg, err := sip.ParseMessage(buf[:n])
+		require.NoError(t, err)
+		return msg
+	}
+
+	invite, _, _ := createTestInvite(t, "sip:bob@"+conn.LocalAddr().String(), "UDP", uac.LocalAddr().String())
+	invite.AppendHeader(sip.NewHeader("Max-Forwards", "70"))
+
+	var branch string
+	for i := 0; i < 2; i++ {
+		_, err = uac.WriteTo([]byte(invite.String()), conn.LocalAddr())
+		require.NoError(t, err)
+
+		fwd := read(uas).(*sip.Request)
+		require.Len(t, fwd.GetHeaders("Via"), 2)
+		assert.Equal(t, "69", fwd.MaxForwards().Value())
+		viaBranch, _ := fwd.Via().Params.Get("branch")
+		if branch != "" {
+			// Retransmission is forwarded with same branch
+			assert.Equal(t, branch, viaBranch)
+		}
+		branch = viaBranch
+
+		res := sip.NewResponseFromRequest(fwd, 486, "Busy Here", nil)
+		_, err = uas.WriteTo([]byte(res.String()), conn.LocalAddr())
+		require.NoError(t, err)
+
+		fwdRes := read(uac).(*sip.Response)
+		assert.Equal(t, sip.StatusCode(486), fwdRes.StatusCode)
+		require.Len(t, fwdRes.GetHeaders("Via"), 1)
+	}
+
+	// Unknown user is rejected statelessly
+	invite, _, _ = createTestInvite(t, "sip:alice@"+conn.LocalAddr().String(), "UDP", uac.LocalAddr().String())
+	_, err = uac.WriteTo([]byte(invite.String()), conn.LocalAddr())
+	require.NoError(t, err)
+	res := read(uac).(*sip.Response)
+	assert.Equal(t, sip.StatusCode(404), res.StatusCode)
+
+	// Request routed back to proxy is loop
+	invite, _, _ = createTestInvite(t, "sip:loop@"+conn.LocalAddr().String(), "UDP", uac.LocalAddr().String())
+	_, err = uac.WriteTo([]byte(invite.String()), conn.LocalAddr())
+	require.NoError(t, err)
+	res = read(uac).(*sip.Response)
+	assert.Equal(t, sip.StatusCode(482), res.StatusCode)
+}
+
+func TestStatelessProxyRouteSet(t *testing.T) {
+	ua, _ := NewUA()
+	defer ua.Close()
+
+	uac, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer uac.Close()
+	uas, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer uas.Close()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	var routed atomic.Int32
+	_, err = NewStatelessProxy(ua, func(req *sip.Request) string {
+		routed.Add(1)
+		if req.Recipient.User == "alice" {
+			return uas.LocalAddr().String()
+		}
+		return ""
+	}, WithStatelessProxyHost("127.0.0.1"), WithStatelessProxyRouteSet())
+	require.NoError(t, err)
+	go ua.tp.ServeUDP(conn)
+	go ua.tp.ServeTCP(l)
+	require.Eventually(t, func() bool { return ua.tp.GetListenPort("tcp") != 0 }, time.Second, time.Millisecond)
+
+	proxyAddr := conn.LocalAddr().String()
+	tcpAddr := l.Addr().String()
+	uasAddr := uas.LocalAddr().String()
+
+	read := func(c net.PacketConn) *sip.Request {
+		buf := make([]byte, 2000)
+		c.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := c.ReadFrom(buf)
+		require.NoError(t, err)
+		msg, err := sip.ParseMessage(buf[:n])
+		require.NoError(t, err)
+		req, ok := msg.(*sip.Request)
+		require.True(t, ok)
+		return req
+	}
+
+	// bye builds in-dialog request as sent by UA following route set
+	bye := func(recipient string, routes ...string) *sip.Request {
+		req, _, _ := createTestInvite(t, recipient, "UDP", uac.LocalAddr().String())
+		req.Method = sip.BYE
+		req.CSeq().MethodName = sip.BYE
+		req.To().Params.Add("tag", "totag")
+		for _, r := range routes {
+			req.AppendHeader(sip.NewHeader("Route", r))
+		}
+		return req
+	}
+
+	routes := func(req *sip.Request) []string {
+		var vals []string
+		for _, h := range req.GetHeaders("Route") {
+			vals = append(vals, h.Value())
+		}
+		return vals
+	}
+
+	testCases := []struct {
+		name      string
+		req       *sip.Request
+		recipient string
+		routes    []string
+	}{
+		{
+			// Asterisk and FreeSWITCH record single loose route
+			name:      "LooseRoute",
+			req:       bye("sip:bob@"+uasAddr, "<sip:"+proxyAddr+";lr>"),
+			recipient: "sip:bob@" + uasAddr,
+		},
+		{
+			// Kamailio and OpenSIPS record route twice on transport change
+			name: "DoubleRecordRoute",
+			req: bye("sip:bob@10.10.10.10:5060",
+				"<sip:"+tcpAddr+";transport=tcp;r2=on;lr>",
+				"<sip:"+proxyAddr+";transport=udp;r2=on;lr>",
+				"<sip:"+uasAddr+";lr>",
+			),
+			recipient: "sip:bob@10.10.10.10:5060",
+			routes:    []string{"<sip:" + uasAddr + ";lr>"},
+		},
+		{
+			// Previous hop is strict router, like older Cisco gateways, placing our Record-Route in Request-URI
+			name:      "FromStrictRouter",
+			req:       bye("sip:"+proxyAddr, "<sip:bob@"+uasAddr+">"),
+			recipient: "sip:bob@" + uasAddr,
+		},
+		{
+			name:      "ToStrictRouter",
+			req:       bye("sip:bob@10.10.10.10:5060", "<sip:"+proxyAddr+";lr>", "<sip:"+uasAddr+">"),
+			recipient: "sip:" + uasAddr,
+			routes:    []string{"<sip:bob@10.10.10.10:5060>"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := uac.WriteTo([]byte(tc.req.String()), conn.LocalAddr())
+			require.NoError(t, err)
+
+			fwd := read(uas)
+			assert.Equal(t, sip.BYE, fwd.Method)
+			assert.Equal(t, tc.recipient, fwd.Recipient.String())
+			assert.Equal(t, tc.routes, routes(fwd))
+			require.Len(t, fwd.GetHeaders("Via"), 2)
+		})
+	}
+	assert.EqualValues(t, 0, routed.Load(), "in-dialog requests must not reach router")
+
+	t.Run("InitialPreloadedRoute", func(t *testing.T) {
+		invite, _, _ := createTestInvite(t, "sip:alice@example.com", "UDP", uac.LocalAddr().String())
+		invite.AppendHeader(sip.NewHeader("Route", "<sip:"+proxyAddr+";lr>"))
+		_, err := uac.WriteTo([]byte(invite.String()), conn.LocalAddr())
+		require.NoError(t, err)
+
+		fwd := read(uas)
+		assert.Equal(t, sip.INVITE, fwd.Method)
+		assert.Nil(t, fwd.Route())
+		assert.EqualValues(t, 1, routed.Load())
+	})
+}
@@ -0,0 +1,175 @@
+package sipgo
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// ProxyTransaction correlates server transaction of request received by stateful proxy
+// with client transactions of all branches where request is forwarded.
+// For INVITE it handles CANCEL internally. CANCEL is answered with 200 OK,
+// all outstanding branches are canceled and request is answered upstream with 487 Request Terminated
+// https://datatracker.ietf.org/doc/html/rfc3261#section-16.10
+//
+// NOTE: server transaction Cancels channel is consumed by ProxyTransaction and should not be read by caller
+type ProxyTransaction struct {
+	req *sip.Request
+	tx  sip.ServerTransaction
+
+	onCancel func(cancel *sip.Request)
+	log      zerolog.Logger
+
+	mu       sync.Mutex
+	branches []sip.ClientTransaction
+	canceled bool
+	final    bool
+}
+
+type ProxyTransactionOption func(p *ProxyTransaction)
+
+// WithProxyTransactionOnCancel sets hook called when CANCEL for request is received.
+// It is called after CANCEL is answered and before branches are canceled
+func WithProxyTransactionOnCancel(f func(cancel *sip.Request)) ProxyTransactionOption {
+	return func(p *ProxyTransaction) {
+		p.onCancel = f
+	}
+}
+
+// WithProxyTransactionLogger allows customizing logger
+func WithProxyTransactionLogger(logger zerolog.Logger) ProxyTransactionOption {
+	return func(p *ProxyTransaction) {
+		p.log = logger
+	}
+}
+
+// NewProxyTransaction creates proxy transaction for received request and its server transaction
+func NewProxyTransaction(req *sip.Request, tx sip.ServerTransaction, options ...ProxyTransactionOption) *ProxyTransaction {
+	p := &ProxyTransaction{
+		req: req,
+		tx:  tx,
+		log: log.Logger.With().Str("caller", "ProxyTransaction").Logger(),
+	}
+	for _, o := range options {
+		o(p)
+	}
+
+	if req.IsInvite() {
+		go p.watchCancel(tx.Cancels())
+	}
+	return p
+}
+
+// AddBranch adds client transaction of forwarded request.
+// In case request is already canceled, branch is canceled immediately
+func (p *ProxyTransaction) AddBranch(clTx sip.ClientTransaction) {
+	p.mu.Lock()
+	p.branches = append(p.branches, clTx)
+	canceled := p.canceled
+	p.mu.Unlock()
+
+	if canceled {
+		p.cancelBranch(clTx)
+	}
+}
+
+// Respond forwards branch response upstream. Response should already have proxy Via removed.
+// After final response other final non 2xx responses are dropped. On 2xx or 6xx
+// all other pending branches are canceled
+// https://datatracker.ietf.org/doc/html/rfc3261#section-16.7
+func (p *ProxyTransaction) Respond(res *sip.Response) error {
+	final := res.StatusCode >= 200
+
+	p.mu.Lock()
+	if p.final && !res.IsSuccess() {
+		p.mu.Unlock()
+		p.log.Debug().Str("res", res.Short()).Msg("Final response already sent. Dropping")
+		return nil
+	}
+	if final {
+		p.final = true
+	}
+	p.mu.Unlock()
+
+	if err := p.tx.Respond(res); err != nil {
+		return err
+	}
+
+	if res.IsSuccess() || res.StatusCode >= 600 {
+		p.Cancel()
+	}
+	return nil
+}
+
+// Cancel cancels all outstanding branches. Branches with final response are not affected
+func (p *ProxyTransaction) Cancel() {
+	p.mu.Lock()
+	p.canceled = true
+	branches := make([]sip.ClientTransaction, len(p.branches))
+	copy(branches, p.branches)
+	p.mu.Unlock()
+
+	for _, b := range branches {
+		p.cancelBranch(b)
+	}
+}
+
+// Canceled reports is request canceled
+func (p *ProxyTransaction) Canceled() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.canceled
+}
+
+func (p *ProxyTransaction) cancelBranch(clTx sip.ClientTransaction) {
+	select {
+	case <-clTx.Done():
+		return
+	default:
+	}
+
+	// Final response of branch is forwarded by its reader, so caller is not blocked waiting it
+	go func() {
+		if _, err := clTx.Cancel(context.Background()); err != nil && !errors.Is(err, sip.ErrTransactionTerminated) {
+			p.log.Error().Err(err).Msg("Failed to cancel branch")
+		}
+	}()
+}
+
+func (p *ProxyTransaction) watchCancel(cancels <-chan *sip.Request) {
+	select {
+	case cancel := <-cancels:
+		p.handleCancel(cancel)
+	case <-p.tx.Done():
+	}
+}
+
+func (p *ProxyTransaction) handleCancel(cancel *sip.Request) {
+	if err := p.tx.Respond(sip.NewResponseFromRequest(cancel, 200, "OK", nil)); err != nil {
+		p.log.Error().Err(err).Msg("Failed to respond CANCEL")
+	}
+
+	if p.onCancel != nil {
+		p.onCancel(cancel)
+	}
+
+	p.Cancel()
+
+	p.mu.Lock()
+	final := p.final
+	p.final = true
+	p.mu.Unlock()
+	if final {
+		return
+	}
+
+	res := sip.NewResponseFromRequest(p.req, 487, "Request Terminated", nil)
+	if err := p.tx.Respond(res); err != nil {
+		p.log.Error().Err(err).Msg("Failed to respond 487 upstream")
+	}
+}
@@ -0,0 +1,94 @@
+package sipgo
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBranchTx struct {
+	done     chan struct{}
+	canceled atomic.Int32
+}
+
+func newFakeBranchTx() *fakeBranchTx {
+	return &fakeBranchTx{done: make(chan struct{})}
+}
+
+func (b *fakeBranchTx) Terminate()                      {}
+func (b *fakeBranchTx) Done() <-chan struct{}           { return b.done }
+func (b *fakeBranchTx) Err() error                      { return nil }
+func (b *fakeBranchTx) Responses() <-chan *sip.Response { return nil }
+func (b *fakeBranchTx) Transport() string               { return "UDP" }
+func (b *fakeBranchTx) Cancel(ctx context.Context) (*sip.Response, error) {
+	b.canceled.Add(1)
+	return nil, nil
+}
+
+func TestProxyTransactionCancel(t *testing.T) {
+	req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	tx := siptest.NewServerTxRecorder(req)
+
+	canceled := make(chan *sip.Request, 1)
+	p := NewProxyTransaction(req, tx, WithProxyTransactionOnCancel(func(cancel *sip.Request) {
+		canceled <- cancel
+	}))
+
+	b1, b2, b3 := newFakeBranchTx(), newFakeBranchTx(), newFakeBranchTx()
+	close(b3.done) // Branch already finished
+	p.AddBranch(b1)
+	p.AddBranch(b2)
+	p.AddBranch(b3)
+
+	require.NoError(t, p.Respond(sip.NewResponseFromRequest(req, 180, "Ringing", nil)))
+
+	require.NoError(t, tx.Receive(sip.NewCancelRequest(req)))
+	select {
+	case cancel := <-canceled:
+		assert.True(t, cancel.IsCancel())
+	case <-time.After(time.Second):
+		t.Fatal("CANCEL not handled")
+	}
+
+	require.Eventually(t, func() bool { return len(tx.Result()) == 3 }, time.Second, 10*time.Millisecond)
+	results := tx.Result()
+	assert.Equal(t, sip.StatusCode(180), results[0].StatusCode)
+	assert.Equal(t, sip.StatusCode(200), results[1].StatusCode)
+	assert.Equal(t, sip.CANCEL, results[1].CSeq().MethodName)
+	assert.Equal(t, sip.StatusCode(487), results[2].StatusCode)
+	assert.Equal(t, sip.INVITE, results[2].CSeq().MethodName)
+
+	require.Eventually(t, func() bool { return b1.canceled.Load() == 1 && b2.canceled.Load() == 1 }, time.Second, 10*time.Millisecond)
+	assert.EqualValues(t, 0, b3.canceled.Load())
+	assert.True(t, p.Canceled())
+
+	// Branch 487 is not forwarded again
+	require.NoError(t, p.Respond(sip.NewResponseFromRequest(req, 487, "Request Terminated", nil)))
+	assert.Len(t, tx.Result(), 3)
+
+	// Late branch is canceled immediately
+	b4 := newFakeBranchTx()
+	p.AddBranch(b4)
+	require.Eventually(t, func() bool { return b4.canceled.Load() == 1 }, time.Second, 10*time.Millisecond)
+}
+
+func TestProxyTransactionSuccessCancelsBranches(t *testing.T) {
+	req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	tx := siptest.NewServerTxRecorder(req)
+	defer tx.Terminate()
+
+	p := NewProxyTransaction(req, tx)
+	b1, b2 := newFakeBranchTx(), newFakeBranchTx()
+	p.AddBranch(b1)
+	p.AddBranch(b2)
+
+	require.NoError(t, p.Respond(sip.NewResponseFromRequest(req, 200, "OK", nil)))
+	require.Eventually(t, func() bool { return b1.canceled.Load() == 1 && b2.canceled.Load() == 1 }, time.Second, 10*time.Millisecond)
+	require.Len(t, tx.Result(), 1)
+}
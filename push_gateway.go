@@ -0,0 +1,221 @@
+package sipgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrPushTimeout = errors.New("push: client did not register in time")
+)
+
+// PushBinding is push notification info of registered mobile client contact
+// https://datatracker.ietf.org/doc/html/rfc8599#section-4.1
+type PushBinding struct {
+	// Provider is pn-provider param, ex. fcm or apns
+	Provider string
+	// PRID is pn-prid param, push resource ID or device token
+	PRID string
+	// Param is pn-param param, provider specific value like APNs topic
+	Param string
+}
+
+// ReadPushBinding reads push notification params of contact uri. Ok is false when contact has no push support
+func ReadPushBinding(contact sip.Uri) (PushBinding, bool) {
+	provider, _ := contact.UriParams.Get("pn-provider")
+	prid, _ := contact.UriParams.Get("pn-prid")
+	if provider == "" || prid == "" {
+		return PushBinding{}, false
+	}
+	param, _ := contact.UriParams.Get("pn-param")
+	return PushBinding{Provider: provider, PRID: prid, Param: param}, true
+}
+
+func (b PushBinding) key() string {
+	return b.Provider + "__" + b.PRID + "__" + b.Param
+}
+
+// PushNotifier sends push notification to external service like FCM or APNs, waking client for request
+type PushNotifier func(ctx context.Context, b PushBinding, req *sip.Request) error
+
+// PushGateway holds requests for sleeping mobile clients. Instead of delivering request to contact,
+// push notification is triggered and request is held until client registers again and its flow is re-established.
+// Registrar must pass every REGISTER to Registered
+// Ex:
+//
+//	contact, err := gw.Deliver(ctx, req, registeredContact)
+//	if err != nil {
+//		tx.Respond(sip.NewResponseFromRequest(req, 480, "Temporarily Unavailable", nil))
+//		return
+//	}
+//	req.Recipient = &contact
+//
+// https://datatracker.ietf.org/doc/html/rfc8599#section-5
+type PushGateway struct {
+	notifier PushNotifier
+	timeout  time.Duration
+	methods  []sip.RequestMethod
+	awake    func(b PushBinding, contact sip.Uri) bool
+	log      zerolog.Logger
+
+	mu      sync.Mutex
+	waiting map[string][]chan sip.Uri
+}
+
+type PushGatewayOption func(gw *PushGateway)
+
+// WithPushGatewayTimeout sets how long request is held waiting for client to register. Default is 10s
+func WithPushGatewayTimeout(d time.Duration) PushGatewayOption {
+	return func(gw *PushGateway) {
+		gw.timeout = d
+	}
+}
+
+// WithPushGatewayMethods sets request methods suppressed for sleeping clients. Default is INVITE and NOTIFY
+func WithPushGatewayMethods(methods ...sip.RequestMethod) PushGatewayOption {
+	return func(gw *PushGateway) {
+		gw.methods = methods
+	}
+}
+
+// WithPushGatewayAwake sets hook reporting client has live flow, like open TCP connection.
+// Request for awake client is delivered without push. Without hook client is always considered sleeping
+func WithPushGatewayAwake(f func(b PushBinding, contact sip.Uri) bool) PushGatewayOption {
+	return func(gw *PushGateway) {
+		gw.awake = f
+	}
+}
+
+// WithPushGatewayLogger allows customizing logger
+func WithPushGatewayLogger(logger zerolog.Logger) PushGatewayOption {
+	return func(gw *PushGateway) {
+		gw.log = logger
+	}
+}
+
+// NewPushGateway creates push gateway using notifier for sending push notifications
+func NewPushGateway(notifier PushNotifier, options ...PushGatewayOption) *PushGateway {
+	gw := &PushGateway{
+		notifier: notifier,
+		timeout:  10 * time.Second,
+		methods:  []sip.RequestMethod{sip.INVITE, sip.NOTIFY},
+		log:      log.Logger.With().Str("caller", "PushGateway").Logger(),
+		waiting:  make(map[string][]chan sip.Uri),
+	}
+	for _, o := range options {
+		o(gw)
+	}
+	return gw
+}
+
+// Deliver returns contact where request should be delivered. For sleeping client push notification is sent
+// and call blocks until client registers again, returning its refreshed contact.
+// Contact without push params or request with other method is returned without change.
+// ErrPushTimeout is returned in case client does not register in time, and request should be answered with 480
+func (gw *PushGateway) Deliver(ctx context.Context, req *sip.Request, contact sip.Uri) (sip.Uri, error) {
+	b, ok := ReadPushBinding(contact)
+	if !ok || !gw.suppressed(req.Method) {
+		return contact, nil
+	}
+	if gw.awake != nil && gw.awake(b, contact) {
+		return contact, nil
+	}
+
+	// Wait must be registered before push, as client can register before notifier returns
+	ch := make(chan sip.Uri, 1)
+	gw.hold(b, ch)
+	defer gw.release(b, ch)
+
+	ctx, cancel := context.WithTimeout(ctx, gw.timeout)
+	defer cancel()
+
+	if err := gw.notifier(ctx, b, req); err != nil {
+		return contact, fmt.Errorf("push notify provider=%q: %w", b.Provider, err)
+	}
+	gw.log.Debug().Str("provider", b.Provider).Str("req", req.Method.String()).Msg("Push sent. Holding request")
+
+	select {
+	case c := <-ch:
+		return c, nil
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return contact, ErrPushTimeout
+		}
+		return contact, ctx.Err()
+	}
+}
+
+// Registered must be called by registrar for every REGISTER request.
+// Requests held for contacts with same push params are released to new contact.
+// It returns number of released requests
+func (gw *PushGateway) Registered(req *sip.Request) int {
+	if expires := req.GetHeader("Expires"); expires != nil && expires.Value() == "0" {
+		return 0
+	}
+
+	released := 0
+	for _, h := range req.GetHeaders("Contact") {
+		cont, ok := h.(*sip.ContactHeader)
+		if !ok {
+			continue
+		}
+		if exp, _ := cont.Params.Get("expires"); exp == "0" {
+			continue
+		}
+		b, ok := ReadPushBinding(cont.Address)
+		if !ok {
+			continue
+		}
+
+		gw.mu.Lock()
+		waiting := gw.waiting[b.key()]
+		delete(gw.waiting, b.key())
+		gw.mu.Unlock()
+
+		for _, ch := range waiting {
+			ch <- cont.Address
+		}
+		released += len(waiting)
+	}
+	return released
+}
+
+func (gw *PushGateway) suppressed(method sip.RequestMethod) bool {
+	for _, m := range gw.methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (gw *PushGateway) hold(b PushBinding, ch chan sip.Uri) {
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+	gw.waiting[b.key()] = append(gw.waiting[b.key()], ch)
+}
+
+func (gw *PushGateway) release(b PushBinding, ch chan sip.Uri) {
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+	waiting := gw.waiting[b.key()]
+	for i, c := range waiting {
+		if c == ch {
+			waiting = append(waiting[:i], waiting[i+1:]...)
+			break
+		}
+	}
+	if len(waiting) == 0 {
+		delete(gw.waiting, b.key())
+		return
+	}
+	gw.waiting[b.key()] = waiting
+}
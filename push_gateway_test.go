@@ -0,0 +1,72 @@
+package sipgo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushGatewayDeliver(t *testing.T) {
+	pushed := make(chan PushBinding, 1)
+	gw := NewPushGateway(func(ctx context.Context, b PushBinding, req *sip.Request) error {
+		pushed <- b
+		return nil
+	})
+
+	params := sip.HeaderParams{"pn-provider": "apns", "pn-prid": "token1", "pn-param": "topic"}
+	contact := sip.Uri{User: "bob", Host: "10.1.1.1", Port: 5060, UriParams: params}
+	sender := sip.Uri{User: "alice", Host: "10.2.2.2", Port: 5060}
+
+	go func() {
+		b := <-pushed
+		assert.Equal(t, PushBinding{Provider: "apns", PRID: "token1", Param: "topic"}, b)
+
+		reg := createSimpleRequest(sip.REGISTER, contact, contact, "TCP")
+		newContact := contact
+		newContact.Host = "10.1.1.9"
+		reg.AppendHeader(&sip.ContactHeader{Address: newContact})
+		assert.Equal(t, 1, gw.Registered(reg))
+	}()
+
+	req := createSimpleRequest(sip.INVITE, sender, contact, "UDP")
+	c, err := gw.Deliver(context.Background(), req, contact)
+	require.NoError(t, err)
+	assert.Equal(t, "10.1.1.9", c.Host)
+
+	// Not suppressed methods are delivered directly
+	req = createSimpleRequest(sip.OPTIONS, sender, contact, "UDP")
+	c, err = gw.Deliver(context.Background(), req, contact)
+	require.NoError(t, err)
+	assert.Equal(t, "10.1.1.1", c.Host)
+	assert.Len(t, pushed, 0)
+
+	// Contact without push params
+	req = createSimpleRequest(sip.INVITE, sender, contact, "UDP")
+	c, err = gw.Deliver(context.Background(), req, sip.Uri{User: "bob", Host: "10.1.1.1"})
+	require.NoError(t, err)
+	assert.Equal(t, "10.1.1.1", c.Host)
+}
+
+func TestPushGatewayTimeout(t *testing.T) {
+	gw := NewPushGateway(func(ctx context.Context, b PushBinding, req *sip.Request) error {
+		return nil
+	}, WithPushGatewayTimeout(50*time.Millisecond))
+
+	contact := sip.Uri{User: "bob", Host: "10.1.1.1", UriParams: sip.HeaderParams{"pn-provider": "fcm", "pn-prid": "token1"}}
+	req := createSimpleRequest(sip.NOTIFY, sip.Uri{User: "alice", Host: "10.2.2.2"}, contact, "UDP")
+	_, err := gw.Deliver(context.Background(), req, contact)
+	require.ErrorIs(t, err, ErrPushTimeout)
+	assert.Empty(t, gw.waiting)
+
+	// Awake client is not pushed
+	gw = NewPushGateway(func(ctx context.Context, b PushBinding, req *sip.Request) error {
+		t.Fatal("push not expected")
+		return nil
+	}, WithPushGatewayAwake(func(b PushBinding, contact sip.Uri) bool { return true }))
+	_, err = gw.Deliver(context.Background(), req, contact)
+	require.NoError(t, err)
+}
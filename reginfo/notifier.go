@@ -0,0 +1,212 @@
+// Package reginfo implements reg event package for registration state.
+// Bindings changed by registrar are tracked per AOR and sent as reginfo document
+// in NOTIFY to watchers over sipgo EventServer, so they do not need to poll location store
+// https://datatracker.ietf.org/doc/html/rfc3680
+package reginfo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/emiago/sipgo"
+	"github.com/emiago/sipgo/sip"
+)
+
+// Event is reg event package name
+const Event = "reg"
+
+// DefaultExpires is used for binding when REGISTER response has no expires
+var DefaultExpires = 3600
+
+// Notifier tracks bindings of AORs and notifies watchers on every change
+// Ex:
+//
+//	es := sipgo.NewEventServer(client, contactHDR)
+//	notifier := reginfo.NewNotifier(es)
+//	srv.OnSubscribe(func(req *sip.Request, tx sip.ServerTransaction) { es.ReadSubscribe(req, tx) })
+//	srv.OnRegister(func(req *sip.Request, tx sip.ServerTransaction) {
+//		res := registrar(req) // 200 OK with all current bindings in Contact
+//		tx.Respond(res)
+//		notifier.Registered(ctx, res)
+//	})
+//	// On binding expire in location store
+//	notifier.Terminate(ctx, aor, contactURI, reginfo.EventExpired)
+type Notifier struct {
+	es *sipgo.EventServer
+
+	mu      sync.Mutex
+	records map[string]*record
+}
+
+type record struct {
+	id       string
+	contacts map[string]*binding
+}
+
+type binding struct {
+	Contact
+	since   time.Time
+	expires time.Time
+}
+
+// NewNotifier creates notifier and registers reg event package on event server
+func NewNotifier(es *sipgo.EventServer) *Notifier {
+	n := &Notifier{
+		es:      es,
+		records: make(map[string]*record),
+	}
+	es.Handle(Event, n.state)
+	return n
+}
+
+// Registered updates bindings of AOR from registrar 2xx response to REGISTER and sends NOTIFY to watchers.
+// Response must contain all current bindings in Contact headers as required by RFC 3261.
+// New bindings are reported as registered, existing as refreshed and missing ones as unregistered
+func (n *Notifier) Registered(ctx context.Context, res *sip.Response) error {
+	if !res.IsSuccess() || res.To() == nil {
+		return fmt.Errorf("reginfo: response %d is not successful registration", res.StatusCode)
+	}
+	aor := res.To().Address.Addr()
+	expires := DefaultExpires
+	if h := res.Expires(); h != nil {
+		expires = int(*h)
+	}
+	var callID string
+	if h := res.CallID(); h != nil {
+		callID = h.Value()
+	}
+	var cseq uint32
+	if h := res.CSeq(); h != nil {
+		cseq = h.SeqNo
+	}
+
+	now := time.Now()
+	n.mu.Lock()
+	r := n.record(aor)
+	current := make(map[string]bool)
+	for _, h := range res.GetHeaders("Contact") {
+		c, ok := h.(*sip.ContactHeader)
+		if !ok {
+			continue
+		}
+		exp := expires
+		if v, ok := c.Params.Get("expires"); ok {
+			if e, err := strconv.Atoi(v); err == nil {
+				exp = e
+			}
+		}
+		uri := c.Address.String()
+		if exp <= 0 {
+			continue
+		}
+		current[uri] = true
+
+		q, _ := c.Params.Get("q")
+		b, exists := r.contacts[uri]
+		if !exists || b.State == StateTerminated {
+			b = &binding{
+				Contact: Contact{ID: sip.GenerateTagN(8), URI: uri, Event: EventRegistered},
+				since:   now,
+			}
+			r.contacts[uri] = b
+		} else {
+			b.Event = EventRefreshed
+		}
+		b.State = StateActive
+		b.Q = q
+		b.CallID = callID
+		b.CSeq = cseq
+		b.DisplayName = c.DisplayName
+		b.expires = now.Add(time.Duration(exp) * time.Second)
+	}
+	for uri, b := range r.contacts {
+		if !current[uri] && b.State == StateActive {
+			b.State, b.Event = StateTerminated, EventUnregistered
+		}
+	}
+	n.mu.Unlock()
+
+	return n.notify(ctx, aor)
+}
+
+// Terminate terminates binding of AOR with event, ex. EventExpired when binding expired in location store,
+// and sends NOTIFY to watchers. Contact is URI as in Contact header of REGISTER
+func (n *Notifier) Terminate(ctx context.Context, aor string, contact sip.Uri, event string) error {
+	n.mu.Lock()
+	r, exists := n.records[aor]
+	if !exists {
+		n.mu.Unlock()
+		return nil
+	}
+	b, exists := r.contacts[contact.String()]
+	if !exists || b.State == StateTerminated {
+		n.mu.Unlock()
+		return nil
+	}
+	b.State, b.Event = StateTerminated, event
+	n.mu.Unlock()
+
+	return n.notify(ctx, aor)
+}
+
+// Document returns full registration state of AOR. AOR is in sip.Uri.Addr form
+func (n *Notifier) Document(aor string) *Document {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	r := n.record(aor)
+	reg := Registration{AOR: aor, ID: r.id, State: StateInit}
+	now := time.Now()
+	for _, b := range r.contacts {
+		c := b.Contact
+		c.DurationRegistered = int(now.Sub(b.since).Seconds())
+		if c.State == StateActive {
+			reg.State = StateActive
+			c.Expires = int(time.Until(b.expires).Seconds())
+		}
+		reg.Contacts = append(reg.Contacts, c)
+	}
+	if reg.State == StateInit && len(reg.Contacts) > 0 {
+		reg.State = StateTerminated
+	}
+	sort.Slice(reg.Contacts, func(i, j int) bool { return reg.Contacts[i].ID < reg.Contacts[j].ID })
+	return &Document{State: StateFull, Registrations: []Registration{reg}}
+}
+
+// record returns record of AOR. Record is kept once created, so registration id stays same within subscription
+func (n *Notifier) record(aor string) *record {
+	r, exists := n.records[aor]
+	if !exists {
+		r = &record{id: sip.GenerateTagN(8), contacts: make(map[string]*binding)}
+		n.records[aor] = r
+	}
+	return r
+}
+
+// state returns NOTIFY body for subscription. Version follows NOTIFY CSeq, which grows by one within subscription
+func (n *Notifier) state(sub sipgo.Subscription) (string, []byte) {
+	doc := n.Document(sub.Resource)
+	doc.Version = sub.CSeq
+	body, err := doc.Marshal()
+	if err != nil {
+		return "", nil
+	}
+	return ContentType, body
+}
+
+// notify sends NOTIFY to watchers of AOR. Terminated bindings are removed once they are reported
+func (n *Notifier) notify(ctx context.Context, aor string) error {
+	err := n.es.Notify(ctx, Event, aor)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for uri, b := range n.records[aor].contacts {
+		if b.State == StateTerminated {
+			delete(n.records[aor].contacts, uri)
+		}
+	}
+	return err
+}
@@ -0,0 +1,95 @@
+package reginfo
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+const (
+	// ContentType is content type of reginfo document
+	ContentType = "application/reginfo+xml"
+	// Namespace is reginfo XML namespace
+	Namespace = "urn:ietf:params:xml:ns:reginfo"
+
+	// Document states
+	StateFull    = "full"
+	StatePartial = "partial"
+
+	// Registration and contact states
+	StateInit       = "init"
+	StateActive     = "active"
+	StateTerminated = "terminated"
+
+	// Contact events
+	EventRegistered   = "registered"
+	EventCreated      = "created"
+	EventRefreshed    = "refreshed"
+	EventShortened    = "shortened"
+	EventExpired      = "expired"
+	EventDeactivated  = "deactivated"
+	EventProbation    = "probation"
+	EventUnregistered = "unregistered"
+	EventRejected     = "rejected"
+)
+
+// Document is reginfo document describing registrations of address of records
+// https://datatracker.ietf.org/doc/html/rfc3680#section-5
+type Document struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:reginfo reginfo"`
+	// Version is incremented with every document sent within subscription
+	Version uint32 `xml:"version,attr"`
+	// State is StateFull or StatePartial
+	State         string         `xml:"state,attr"`
+	Registrations []Registration `xml:"registration"`
+}
+
+// Registration is registration state of address of record
+type Registration struct {
+	AOR string `xml:"aor,attr"`
+	ID  string `xml:"id,attr"`
+	// State is StateInit, StateActive or StateTerminated
+	State    string    `xml:"state,attr"`
+	Contacts []Contact `xml:"contact"`
+}
+
+// Contact is binding of address of record
+type Contact struct {
+	ID string `xml:"id,attr"`
+	// State is StateActive or StateTerminated
+	State string `xml:"state,attr"`
+	// Event is event which caused last state change, ex. EventRefreshed
+	Event string `xml:"event,attr"`
+	// DurationRegistered is number of seconds contact is bound
+	DurationRegistered int `xml:"duration-registered,attr,omitempty"`
+	// Expires is number of seconds until binding expires
+	Expires     int    `xml:"expires,attr,omitempty"`
+	RetryAfter  int    `xml:"retry-after,attr,omitempty"`
+	Q           string `xml:"q,attr,omitempty"`
+	CallID      string `xml:"callid,attr,omitempty"`
+	CSeq        uint32 `xml:"cseq,attr,omitempty"`
+	URI         string `xml:"uri"`
+	DisplayName string `xml:"display-name,omitempty"`
+}
+
+// Parse parses reginfo document
+func Parse(data []byte) (*Document, error) {
+	doc := &Document{}
+	if err := xml.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("reginfo: %w", err)
+	}
+	for _, r := range doc.Registrations {
+		if r.AOR == "" || r.ID == "" {
+			return nil, fmt.Errorf("reginfo: registration aor or id missing")
+		}
+	}
+	return doc, nil
+}
+
+// Marshal returns document with XML declaration
+func (d *Document) Marshal() ([]byte, error) {
+	data, err := xml.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,174 @@
+package reginfo
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo"
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument(t *testing.T) {
+	// https://datatracker.ietf.org/doc/html/rfc3680#section-6
+	data := `<?xml version="1.0"?>
+<reginfo xmlns="urn:ietf:params:xml:ns:reginfo" version="1" state="partial">
+  <registration aor="sip:joe@example.com" id="a7" state="active">
+    <contact id="76" state="active" event="registered" duration-registered="0">
+      <uri>sip:joe@pc887.example.com</uri>
+    </contact>
+  </registration>
+</reginfo>`
+	doc, err := Parse([]byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), doc.Version)
+	assert.Equal(t, StatePartial, doc.State)
+	require.Len(t, doc.Registrations, 1)
+	reg := doc.Registrations[0]
+	assert.Equal(t, "sip:joe@example.com", reg.AOR)
+	assert.Equal(t, StateActive, reg.State)
+	assert.Equal(t, []Contact{{ID: "76", State: StateActive, Event: EventRegistered, URI: "sip:joe@pc887.example.com"}}, reg.Contacts)
+
+	body, err := doc.Marshal()
+	require.NoError(t, err)
+	parsed, err := Parse(body)
+	require.NoError(t, err)
+	assert.Equal(t, doc.Registrations, parsed.Registrations)
+
+	_, err = Parse([]byte(`<reginfo xmlns="urn:ietf:params:xml:ns:reginfo"><registration id="a7"/></reginfo>`))
+	assert.Error(t, err)
+}
+
+func TestNotifier(t *testing.T) {
+	// Watcher, ex. SBC, receiving NOTIFY
+	watcherUA, err := sipgo.NewUA(sipgo.WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer watcherUA.Close()
+	watcher, err := sipgo.NewServer(watcherUA)
+	require.NoError(t, err)
+	notifies := make(chan *sip.Request, 5)
+	watcher.OnNotify(func(req *sip.Request, tx sip.ServerTransaction) {
+		notifies <- req
+		tx.Respond(sip.NewResponseFromRequest(req, sip.StatusOK, "OK", nil))
+	})
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go watcher.ServeUDP(conn)
+	addr := conn.LocalAddr().String()
+
+	ua, err := sipgo.NewUA(sipgo.WithUserAgentHostname("127.0.0.1"))
+	require.NoError(t, err)
+	defer ua.Close()
+	cli, err := sipgo.NewClient(ua, sipgo.WithClientHostname("127.0.0.1"))
+	require.NoError(t, err)
+	es := sipgo.NewEventServer(cli, sip.ContactHeader{Address: sip.Uri{User: "registrar", Host: "127.0.0.1", Port: 5060}})
+	notifier := NewNotifier(es)
+
+	waitNotify := func() Registration {
+		t.Helper()
+		select {
+		case req := <-notifies:
+			assert.Equal(t, ContentType, req.ContentType().Value())
+			assert.Equal(t, Event, req.GetHeader("Event").Value())
+			doc, err := Parse(req.Body())
+			require.NoError(t, err)
+			assert.Equal(t, StateFull, doc.State)
+			require.Len(t, doc.Registrations, 1)
+			return doc.Registrations[0]
+		case <-time.After(2 * time.Second):
+			t.Fatal("NOTIFY not received")
+		}
+		return Registration{}
+	}
+
+	msg, err := sip.ParseMessage([]byte(strings.Join([]string{
+		"SUBSCRIBE sip:alice@127.0.0.1 SIP/2.0",
+		"Via: SIP/2.0/UDP " + addr + ";branch=" + sip.GenerateBranch(),
+		"From: <sip:sbc@127.0.0.1>;tag=" + sip.GenerateTagN(8),
+		"To: <sip:alice@127.0.0.1>",
+		"Contact: <sip:sbc@" + addr + ">",
+		"Call-ID: " + sip.GenerateTagN(16),
+		"CSeq: 1 SUBSCRIBE",
+		"Event: reg",
+		"Expires: 600",
+		"Content-Length: 0",
+		"",
+		"",
+	}, "\r\n")))
+	require.NoError(t, err)
+	sub := msg.(*sip.Request)
+	require.NoError(t, es.ReadSubscribe(sub, siptest.NewServerTxRecorder(sub)))
+	reg := waitNotify()
+	assert.Equal(t, StateInit, reg.State)
+	assert.Equal(t, "sip:alice@127.0.0.1", reg.AOR)
+	assert.Empty(t, reg.Contacts)
+
+	registerOK := func(aor string, contacts ...string) *sip.Response {
+		lines := []string{
+			"SIP/2.0 200 OK",
+			"Via: SIP/2.0/UDP 127.0.0.2:5060;branch=" + sip.GenerateBranch(),
+			"From: <" + aor + ">;tag=" + sip.GenerateTagN(8),
+			"To: <" + aor + ">;tag=" + sip.GenerateTagN(8),
+			"Call-ID: reg-call-id",
+			"CSeq: 2 REGISTER",
+		}
+		for _, c := range contacts {
+			lines = append(lines, "Contact: "+c)
+		}
+		lines = append(lines, "Content-Length: 0", "", "")
+		msg, err := sip.ParseMessage([]byte(strings.Join(lines, "\r\n")))
+		require.NoError(t, err)
+		return msg.(*sip.Response)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, notifier.Registered(ctx, registerOK("sip:alice@127.0.0.1",
+		"<sip:alice@127.0.0.2:5060>;expires=300",
+		"<sip:alice@127.0.0.3:5060>;expires=600;q=0.5",
+	)))
+	reg = waitNotify()
+	assert.Equal(t, StateActive, reg.State)
+	require.Len(t, reg.Contacts, 2)
+	for _, c := range reg.Contacts {
+		assert.Equal(t, StateActive, c.State)
+		assert.Equal(t, EventRegistered, c.Event)
+		assert.Equal(t, "reg-call-id", c.CallID)
+		assert.Equal(t, uint32(2), c.CSeq)
+		assert.Greater(t, c.Expires, 0)
+	}
+	regID := reg.ID
+
+	// Refresh of one binding unregisters the other
+	require.NoError(t, notifier.Registered(ctx, registerOK("sip:alice@127.0.0.1", "<sip:alice@127.0.0.3:5060>;expires=600")))
+	reg = waitNotify()
+	assert.Equal(t, regID, reg.ID)
+	assert.Equal(t, StateActive, reg.State)
+	events := map[string]string{}
+	for _, c := range reg.Contacts {
+		events[c.URI] = c.Event
+	}
+	assert.Equal(t, map[string]string{
+		"sip:alice@127.0.0.2:5060": EventUnregistered,
+		"sip:alice@127.0.0.3:5060": EventRefreshed,
+	}, events)
+
+	// Other AOR is not notified to watcher
+	require.NoError(t, notifier.Registered(ctx, registerOK("sip:bob@127.0.0.1", "<sip:bob@127.0.0.4:5060>")))
+	require.NoError(t, notifier.Terminate(ctx, "sip:alice@127.0.0.1", sip.Uri{User: "alice", Host: "127.0.0.3", Port: 5060}, EventExpired))
+	reg = waitNotify()
+	assert.Equal(t, StateTerminated, reg.State)
+	require.Len(t, reg.Contacts, 1)
+	assert.Equal(t, EventExpired, reg.Contacts[0].Event)
+
+	// Terminated bindings are removed once reported
+	doc := notifier.Document("sip:alice@127.0.0.1")
+	assert.Equal(t, StateInit, doc.Registrations[0].State)
+	assert.Empty(t, doc.Registrations[0].Contacts)
+}
@@ -0,0 +1,170 @@
+package sipgo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RouteMiddleware wraps route handler, ex. for authentication per route
+type RouteMiddleware func(next RequestHandler) RequestHandler
+
+// Router dispatches requests by Request-URI patterns, similar to HTTP mux.
+// Routes are checked in order they are added and first matching route handles request.
+// Router is RequestHandler and is registered per method
+// Ex:
+//
+//	router := sipgo.NewRouter()
+//	router.Route("_1XX@pbx.example.com", extensionHandler)
+//	router.Route("+1*", trunkHandler, authMiddleware)
+//	srv.OnInvite(router.Handle)
+type Router struct {
+	routes      []route
+	middlewares []RouteMiddleware
+	notFound    RequestHandler
+}
+
+type route struct {
+	pattern string
+	user    string
+	domain  string
+	handler RequestHandler
+}
+
+// NewRouter creates router. Request not matching any route is answered with 404 Not Found
+func NewRouter() *Router {
+	return &Router{
+		notFound: func(req *sip.Request, tx sip.ServerTransaction) {
+			res := sip.NewResponseFromRequest(req, 404, "Not Found", nil)
+			if err := tx.Respond(res); err != nil {
+				log.Error().Err(err).Msg("Router failed to respond 404 Not Found")
+			}
+		},
+	}
+}
+
+// Route adds handler for Request-URI pattern "user@domain". Any part can be omitted, meaning it matches any value.
+// User part can be:
+//   - exact user, ex. alice
+//   - digit pattern starting with _ where X matches any digit, Z 1-9 and N 2-9, ex. _1XX
+//   - prefix ending with *, ex. +1*
+//   - * matching any user
+//
+// Domain part is exact domain, * or *.example.com matching subdomains.
+// Middlewares are applied only for this route after router middlewares
+func (r *Router) Route(pattern string, handler RequestHandler, middlewares ...RouteMiddleware) error {
+	pattern = strings.TrimPrefix(strings.TrimPrefix(pattern, "sips:"), "sip:")
+	user, domain, _ := strings.Cut(pattern, "@")
+	if user == "" && domain == "" {
+		return fmt.Errorf("empty route pattern")
+	}
+	if ind := strings.IndexByte(user, '*'); ind >= 0 && ind != len(user)-1 {
+		return fmt.Errorf("route user pattern %q: wildcard must be last", user)
+	}
+	if ind := strings.LastIndexByte(domain, '*'); ind > 0 {
+		return fmt.Errorf("route domain pattern %q: wildcard must be first", domain)
+	}
+
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	r.routes = append(r.routes, route{
+		pattern: pattern,
+		user:    user,
+		domain:  strings.ToLower(domain),
+		handler: handler,
+	})
+	return nil
+}
+
+// Use adds middlewares applied for all routes
+func (r *Router) Use(middlewares ...RouteMiddleware) {
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// NotFound sets handler for requests not matching any route
+func (r *Router) NotFound(handler RequestHandler) {
+	r.notFound = handler
+}
+
+// Handle dispatches request to matching route
+func (r *Router) Handle(req *sip.Request, tx sip.ServerTransaction) {
+	handler := r.notFound
+	rt := r.match(req.Recipient)
+	if rt == nil {
+		req.Trail().Add("router", "no route matched")
+	} else {
+		req.Trail().Add("router", "matched "+rt.pattern)
+		handler = rt.handler
+		for i := len(r.middlewares) - 1; i >= 0; i-- {
+			handler = r.middlewares[i](handler)
+		}
+	}
+	handler(req, tx)
+}
+
+func (r *Router) match(uri *sip.Uri) *route {
+	if uri == nil {
+		return nil
+	}
+	for i := range r.routes {
+		rt := &r.routes[i]
+		if routeMatchUser(rt.user, uri.User) && routeMatchDomain(rt.domain, uri.Host) {
+			return rt
+		}
+	}
+	return nil
+}
+
+func routeMatchUser(pattern string, user string) bool {
+	if pattern == "" || pattern == "*" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(user, prefix)
+	}
+	pattern, ok := strings.CutPrefix(pattern, "_")
+	if !ok {
+		return pattern == user
+	}
+	if len(pattern) != len(user) {
+		return false
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		c := user[i]
+		switch pattern[i] {
+		case 'X':
+			if c < '0' || c > '9' {
+				return false
+			}
+		case 'Z':
+			if c < '1' || c > '9' {
+				return false
+			}
+		case 'N':
+			if c < '2' || c > '9' {
+				return false
+			}
+		default:
+			if pattern[i] != c {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func routeMatchDomain(pattern string, host string) bool {
+	if pattern == "" || pattern == "*" {
+		return true
+	}
+	host = strings.ToLower(host)
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+		return strings.HasSuffix(host, suffix)
+	}
+	return pattern == host
+}
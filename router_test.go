@@ -0,0 +1,71 @@
+package sipgo
+
+import (
+	"testing"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter(t *testing.T) {
+	var matched string
+	handler := func(name string) RequestHandler {
+		return func(req *sip.Request, tx sip.ServerTransaction) {
+			matched = name
+		}
+	}
+
+	var mids []string
+	middleware := func(name string) RouteMiddleware {
+		return func(next RequestHandler) RequestHandler {
+			return func(req *sip.Request, tx sip.ServerTransaction) {
+				mids = append(mids, name)
+				next(req, tx)
+			}
+		}
+	}
+
+	router := NewRouter()
+	router.Use(middleware("global"))
+	require.NoError(t, router.Route("alice@pbx.example.com", handler("alice")))
+	require.NoError(t, router.Route("_1XX@pbx.example.com", handler("extension"), middleware("route")))
+	require.NoError(t, router.Route("+1*", handler("trunk")))
+	require.NoError(t, router.Route("*@*.example.com", handler("subdomain")))
+	require.Error(t, router.Route("1*2", handler("invalid")))
+	require.Error(t, router.Route("", handler("invalid")))
+
+	tests := []struct {
+		uri      string
+		expected string
+	}{
+		{"sip:alice@pbx.example.com", "alice"},
+		{"sip:alice@PBX.example.com", "alice"},
+		{"sip:101@pbx.example.com", "extension"},
+		{"sip:1011@pbx.example.com", "subdomain"},
+		{"sip:+15551234@carrier.net", "trunk"},
+		{"sip:bob@eu.example.com", "subdomain"},
+		{"sip:bob@other.net", ""},
+	}
+
+	for _, tc := range tests {
+		matched, mids = "", nil
+		uri := sip.Uri{}
+		require.NoError(t, sip.ParseUri(tc.uri, &uri))
+
+		req := createSimpleRequest(sip.INVITE, sip.Uri{User: "caller", Host: "10.1.1.1"}, uri, "UDP")
+		tx := siptest.NewServerTxRecorder(req)
+		router.Handle(req, tx)
+		assert.Equal(t, tc.expected, matched, tc.uri)
+
+		if tc.expected == "" {
+			require.Len(t, tx.Result(), 1)
+			assert.Equal(t, sip.StatusCode(404), tx.Result()[0].StatusCode)
+			continue
+		}
+		if tc.expected == "extension" {
+			assert.Equal(t, []string{"global", "route"}, mids)
+		}
+	}
+}
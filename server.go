@@ -4,10 +4,12 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"strings"
+	"sync/atomic"
 
 	"github.com/emiago/sipgo/sip"
 
@@ -22,6 +24,8 @@ var (
 
 type ListenReadyCtxValue chan struct{}
 
+var errTLSNoCertificate = errors.New("tls: neither Certificates, GetCertificate, nor GetConfigForClient set in Config")
+
 // RequestHandler is a callback that will be called on the incoming request
 type RequestHandler func(req *sip.Request, tx sip.ServerTransaction)
 
@@ -37,6 +41,29 @@ type Server struct {
 
 	requestMiddlewares  []func(r *sip.Request)
 	responseMiddlewares []func(r *sip.Response)
+
+	// call screening for new incoming calls
+	callScreener      CallScreener
+	screenTrustDomain *TrustDomain
+	dnd               atomic.Bool
+
+	// statelessCache absorbs retransmissions in stateless mode
+	statelessCache *statelessCache
+
+	// mergedRequests detects same request arriving on multiple paths. Nil when disabled
+	mergedRequests *mergedRequests
+
+	// sipsPolicy rejects Request-URI schemes with 416
+	sipsPolicy SIPSPolicy
+
+	// trail attaches decision trail on requests
+	trail bool
+
+	// listenerPolicies enforce listener roles
+	listenerPolicies []ListenerPolicy
+
+	// keepaliveMethods are vendor keepalive methods answered with 200
+	keepaliveMethods map[sip.RequestMethod]struct{}
 }
 
 type ServerOption func(s *Server) error
@@ -87,6 +114,7 @@ func newBaseServer(ua *UserAgent, options ...ServerOption) (*Server, error) {
 
 // Serve will fire all listeners
 // Network supported: udp, tcp, ws
+// UDP multicast address joins multicast group
 func (srv *Server) ListenAndServe(ctx context.Context, network string, addr string) error {
 	network = strings.ToLower(network)
 	var connCloser io.Closer
@@ -106,14 +134,20 @@ func (srv *Server) ListenAndServe(ctx context.Context, network string, addr stri
 	}()
 
 	switch network {
-	case "udp", "udp4":
+	case "udp", "udp4", "udp6":
 		// resolve local UDP endpoint
 		laddr, err := net.ResolveUDPAddr(network, addr)
 		if err != nil {
 			return fmt.Errorf("fail to resolve address. err=%w", err)
 		}
 
-		udpConn, err := net.ListenUDP(network, laddr)
+		var udpConn net.PacketConn
+		if laddr.IP.IsMulticast() {
+			// Joining group on default interface. Ex. sip.MulticastHost for multicast REGISTER
+			udpConn, err = net.ListenMulticastUDP(network, nil, laddr)
+		} else {
+			udpConn, err = srv.tp.ListenConfig().ListenPacket(ctx, network, laddr.String())
+		}
 		if err != nil {
 			return fmt.Errorf("listen udp error. err=%w", err)
 		}
@@ -124,13 +158,13 @@ func (srv *Server) ListenAndServe(ctx context.Context, network string, addr stri
 		}
 		return srv.tp.ServeUDP(udpConn)
 
-	case "tcp", "tcp4":
+	case "tcp", "tcp4", "tcp6":
 		laddr, err := net.ResolveTCPAddr(network, addr)
 		if err != nil {
 			return fmt.Errorf("fail to resolve address. err=%w", err)
 		}
 
-		conn, err := net.ListenTCP(network, laddr)
+		conn, err := srv.tp.ListenConfig().Listen(ctx, network, laddr.String())
 		if err != nil {
 			return fmt.Errorf("listen tcp error. err=%w", err)
 		}
@@ -148,7 +182,7 @@ func (srv *Server) ListenAndServe(ctx context.Context, network string, addr stri
 			return fmt.Errorf("fail to resolve address. err=%w", err)
 		}
 
-		conn, err := net.ListenTCP(network, laddr)
+		conn, err := srv.tp.ListenConfig().Listen(ctx, network, laddr.String())
 		if err != nil {
 			return fmt.Errorf("listen tcp error. err=%w", err)
 		}
@@ -193,10 +227,19 @@ func (srv *Server) ListenAndServeTLS(ctx context.Context, network string, addr s
 			return fmt.Errorf("fail to resolve address. err=%w", err)
 		}
 
-		listener, err := tls.Listen("tcp", laddr.String(), conf)
+		conf = srv.tp.ApplyTLSGetCertificate(conf)
+		if network == "tls" {
+			conf = srv.tp.ApplyTLSALPN(conf)
+			conf = srv.tp.ApplyTLSMutualAuth(conf)
+		}
+		if conf == nil || len(conf.Certificates) == 0 && conf.GetCertificate == nil && conf.GetConfigForClient == nil {
+			return fmt.Errorf("listen tls error. err=%w", errTLSNoCertificate)
+		}
+		tcpListener, err := srv.tp.ListenConfig().Listen(ctx, "tcp", laddr.String())
 		if err != nil {
 			return fmt.Errorf("listen tls error. err=%w", err)
 		}
+		listener := tls.NewListener(tcpListener, conf)
 
 		connCloser = listener
 
@@ -213,6 +256,46 @@ func (srv *Server) ListenAndServeTLS(ctx context.Context, network string, addr s
 	return sip.ErrTransportNotSuported
 }
 
+// ServePacketConn starts serving UDP on already created packet connection.
+// This allows passing connections from systemd socket activation, privileged port setups or tests.
+// Conn is closed when ctx is done
+func (srv *Server) ServePacketConn(ctx context.Context, conn net.PacketConn) error {
+	stop := context.AfterFunc(ctx, func() {
+		if err := conn.Close(); err != nil {
+			srv.log.Error().Err(err).Msg("Failed to close packet conn")
+		}
+	})
+	defer stop()
+	return srv.tp.ServeUDP(conn)
+}
+
+// ServeListener starts serving on already created listener.
+// Network supported: tcp, tls, ws, wss. For tls and wss listener must be already TLS listener
+// Listener is closed when ctx is done
+func (srv *Server) ServeListener(ctx context.Context, network string, l net.Listener) error {
+	var serve func(l net.Listener) error
+	switch strings.ToLower(network) {
+	case "tcp", "tcp4", "tcp6":
+		serve = srv.tp.ServeTCP
+	case "tls":
+		serve = srv.tp.ServeTLS
+	case "ws":
+		serve = srv.tp.ServeWS
+	case "wss":
+		serve = srv.tp.ServeWSS
+	default:
+		return sip.ErrTransportNotSuported
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		if err := l.Close(); err != nil {
+			srv.log.Error().Err(err).Msg("Failed to close listener")
+		}
+	})
+	defer stop()
+	return serve(l)
+}
+
 // ServeUDP starts serving request on UDP type listener.
 func (srv *Server) ServeUDP(l net.PacketConn) error {
 	return srv.tp.ServeUDP(l)
@@ -238,6 +321,11 @@ func (srv *Server) ServeWSS(l net.Listener) error {
 	return srv.tp.ServeWSS(l)
 }
 
+// ServeQUIC starts serving request on QUIC listener. UA must be created with WithUserAgentQUIC
+func (srv *Server) ServeQUIC(l sip.QUICListener) error {
+	return srv.tp.ServeQUIC(l)
+}
+
 // onRequest gets request from Transaction layer
 func (srv *Server) onRequest(req *sip.Request, tx sip.ServerTransaction) {
 	// Transaction layer is the one who controls concurency execution of every request
@@ -247,10 +335,43 @@ func (srv *Server) onRequest(req *sip.Request, tx sip.ServerTransaction) {
 
 // handleRequest must be run in seperate goroutine
 func (srv *Server) handleRequest(req *sip.Request, tx sip.ServerTransaction) {
+	if srv.absorbRetransmission(req) {
+		if tx != nil {
+			tx.Terminate()
+		}
+		return
+	}
+
+	if srv.mergedRequest(req, tx) {
+		tx.Terminate()
+		return
+	}
+
+	if srv.trail {
+		req.SetTrail(sip.NewTrail())
+		defer func() {
+			if e := srv.log.Debug(); e.Enabled() {
+				logTrail(e, req).Str("req", req.StartLine()).Msg("Request handled")
+			}
+		}()
+	}
+
 	for _, mid := range srv.requestMiddlewares {
 		mid(req)
 	}
 
+	if srv.listenerRejected(req, tx) {
+		if tx != nil {
+			tx.Terminate()
+		}
+		return
+	}
+
+	if srv.sipsRejected(req, tx) || srv.screenCall(req, tx) || srv.autoAnswerRequired(req, tx) {
+		tx.Terminate()
+		return
+	}
+
 	handler := srv.getHandler(req.Method)
 	handler(req, tx)
 	if tx != nil {
@@ -261,6 +382,9 @@ func (srv *Server) handleRequest(req *sip.Request, tx sip.ServerTransaction) {
 
 // WriteResponse will proxy message to transport layer. Use it in stateless mode
 func (srv *Server) WriteResponse(r *sip.Response) error {
+	if srv.statelessCache != nil {
+		srv.statelessCache.store(r)
+	}
 	return srv.tp.WriteMsg(r)
 }
 
@@ -364,12 +488,16 @@ func (srv *Server) RegisteredMethods() []string {
 func (srv *Server) getHandler(method sip.RequestMethod) (handler RequestHandler) {
 	handler, ok := srv.requestHandlers[method]
 	if !ok {
+		if srv.isKeepalive(method) {
+			return srv.keepaliveHandler
+		}
 		return srv.noRouteHandler
 	}
 	return handler
 }
 
 func (srv *Server) defaultUnhandledHandler(req *sip.Request, tx sip.ServerTransaction) {
+	req.Trail().Add("server", "no handler for "+req.Method.String())
 	srv.log.Warn().Msg("SIP request handler not found")
 	res := sip.NewResponseFromRequest(req, 405, "Method Not Allowed", nil)
 	// Send response directly and let transaction terminate
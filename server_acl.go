@@ -0,0 +1,30 @@
+package sipgo
+
+import (
+	"github.com/emiago/sipgo/sip"
+	"github.com/rs/zerolog/log"
+)
+
+// ACLHandler wraps request handler and rejects with 403 Forbidden any request whose source is not allowed by acl.
+// It allows applying different rules per method
+// Ex:
+//
+//	srv.OnRegister(sipgo.ACLHandler(acl, registerHandler))
+func ACLHandler(acl *sip.ACL, next RequestHandler) RequestHandler {
+	return func(req *sip.Request, tx sip.ServerTransaction) {
+		if acl.AllowedAddr(req.Source()) {
+			next(req, tx)
+			return
+		}
+
+		log.Debug().Str("source", req.Source()).Str("method", req.Method.String()).Msg("Request denied by ACL")
+		if req.IsAck() {
+			return
+		}
+
+		res := sip.NewResponseFromRequest(req, 403, "Forbidden", nil)
+		if err := tx.Respond(res); err != nil {
+			log.Error().Err(err).Msg("respond '403 Forbidden' failed")
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package sipgo
+
+import (
+	"testing"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestACLHandler(t *testing.T) {
+	acl, err := sip.NewACL([]string{"10.0.0.0/8"}, nil)
+	require.NoError(t, err)
+
+	var handled int
+	h := ACLHandler(acl, func(req *sip.Request, tx sip.ServerTransaction) {
+		handled++
+		tx.Respond(sip.NewResponseFromRequest(req, 200, "OK", nil))
+	})
+
+	req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "10.1.1.1:5060")
+	req.SetSource("10.1.1.1:5060")
+	tx := siptest.NewServerTxRecorder(req)
+	h(req, tx)
+	assert.Equal(t, 1, handled)
+	require.Len(t, tx.Result(), 1)
+	assert.Equal(t, sip.StatusCode(200), tx.Result()[0].StatusCode)
+
+	req, _, _ = createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.1:5060")
+	req.SetSource("127.0.0.1:5060")
+	tx = siptest.NewServerTxRecorder(req)
+	h(req, tx)
+	assert.Equal(t, 1, handled)
+	require.Len(t, tx.Result(), 1)
+	assert.Equal(t, sip.StatusCode(403), tx.Result()[0].StatusCode)
+}
@@ -0,0 +1,237 @@
+package sipgo
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/icholy/digest"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrDigestAuthMissing  = errors.New("digest: no credentials")
+	ErrDigestAuthInvalid  = errors.New("digest: invalid credentials")
+	ErrDigestAuthNonce    = errors.New("digest: unknown or expired nonce")
+	ErrDigestAuthStale    = errors.New("digest: stale nonce")
+	ErrDigestAuthReplay   = errors.New("digest: nonce count reused")
+	ErrDigestAuthQOP      = errors.New("digest: qop not offered")
+	ErrDigestAuthIntMatch = errors.New("digest: auth-int response mismatch, wrong credentials or body modified in transit")
+)
+
+// DigestCredentials returns password of user in realm. Ok is false in case user does not exist
+type DigestCredentials func(username string, realm string) (password string, ok bool)
+
+// DigestAuthServer challenges and verifies digest authentication of incoming requests
+// https://datatracker.ietf.org/doc/html/rfc3261#section-22.4
+// https://datatracker.ietf.org/doc/html/rfc8760
+type DigestAuthServer struct {
+	realm       string
+	credentials DigestCredentials
+	qop         []string
+	algorithm   string
+	proxy       bool
+	nonces      DigestNonceStore
+	log         zerolog.Logger
+}
+
+type DigestAuthServerOption func(a *DigestAuthServer)
+
+// WithDigestAuthQOP sets qop values offered in challenge. Default is auth.
+// With auth-int message body is protected as well. Empty means RFC 2069 digest without qop
+func WithDigestAuthQOP(qop ...string) DigestAuthServerOption {
+	return func(a *DigestAuthServer) {
+		a.qop = qop
+	}
+}
+
+// WithDigestAuthAlgorithm sets digest algorithm, ex. MD5 or SHA-256. Default is MD5
+func WithDigestAuthAlgorithm(algorithm string) DigestAuthServerOption {
+	return func(a *DigestAuthServer) {
+		a.algorithm = algorithm
+	}
+}
+
+// WithDigestAuthProxy makes server challenge as proxy with 407 Proxy Authentication Required
+// and reading Proxy-Authorization header
+func WithDigestAuthProxy() DigestAuthServerOption {
+	return func(a *DigestAuthServer) {
+		a.proxy = true
+	}
+}
+
+// WithDigestAuthNonceStore sets store for issued nonces. Default is MemoryNonceStore with 5 min expire
+func WithDigestAuthNonceStore(store DigestNonceStore) DigestAuthServerOption {
+	return func(a *DigestAuthServer) {
+		a.nonces = store
+	}
+}
+
+// WithDigestAuthLogger allows customizing logger
+func WithDigestAuthLogger(logger zerolog.Logger) DigestAuthServerOption {
+	return func(a *DigestAuthServer) {
+		a.log = logger
+	}
+}
+
+// NewDigestAuthServer creates digest auth server for realm
+func NewDigestAuthServer(realm string, credentials DigestCredentials, options ...DigestAuthServerOption) *DigestAuthServer {
+	a := &DigestAuthServer{
+		realm:       realm,
+		credentials: credentials,
+		qop:         []string{"auth"},
+		algorithm:   "MD5",
+		nonces:      NewMemoryNonceStore(5 * time.Minute),
+		log:         log.Logger.With().Str("caller", "DigestAuthServer").Logger(),
+	}
+	for _, o := range options {
+		o(a)
+	}
+	return a
+}
+
+// Authorize verifies request credentials. In case credentials are missing or invalid request is answered
+// with challenge and false is returned. Valid credentials with stale nonce are challenged with stale=true,
+// so client retries with new nonce without asking user
+func (a *DigestAuthServer) Authorize(req *sip.Request, tx sip.ServerTransaction) bool {
+	_, err := a.Verify(req)
+	if err == nil {
+		return true
+	}
+
+	if !errors.Is(err, ErrDigestAuthMissing) && !errors.Is(err, ErrDigestAuthStale) {
+		a.log.Info().Err(err).Str("req", req.Method.String()).Msg("Digest auth failed")
+	}
+	if err := tx.Respond(a.challenge(req, errors.Is(err, ErrDigestAuthStale))); err != nil {
+		a.log.Error().Err(err).Msg("Failed to respond digest challenge")
+	}
+	return false
+}
+
+// Challenge creates 401 Unauthorized or 407 Proxy Authentication Required response with new nonce
+func (a *DigestAuthServer) Challenge(req *sip.Request) *sip.Response {
+	return a.challenge(req, false)
+}
+
+func (a *DigestAuthServer) challenge(req *sip.Request, stale bool) *sip.Response {
+	chal := digest.Challenge{
+		Realm:     a.realm,
+		Nonce:     a.newNonce(),
+		Stale:     stale,
+		Algorithm: a.algorithm,
+		QOP:       a.qop,
+	}
+
+	if a.proxy {
+		res := sip.NewResponseFromRequest(req, sip.StatusProxyAuthRequired, "Proxy Authentication Required", nil)
+		res.AppendHeader(sip.NewHeader("Proxy-Authenticate", chal.String()))
+		return res
+	}
+	res := sip.NewResponseFromRequest(req, sip.StatusUnauthorized, "Unauthorized", nil)
+	res.AppendHeader(sip.NewHeader("WWW-Authenticate", chal.String()))
+	return res
+}
+
+// Verify checks digest credentials of request and returns authenticated username.
+// For qop=auth-int request body is verified as well and ErrDigestAuthIntMatch is returned in case it does not match.
+// ErrDigestAuthStale is returned for valid credentials with expired nonce and ErrDigestAuthReplay for reused nonce count
+func (a *DigestAuthServer) Verify(req *sip.Request) (string, error) {
+	name := "Authorization"
+	if a.proxy {
+		name = "Proxy-Authorization"
+	}
+	h := req.GetHeader(name)
+	if h == nil {
+		return "", ErrDigestAuthMissing
+	}
+
+	cred, err := digest.ParseCredentials(h.Value())
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrDigestAuthInvalid, err)
+	}
+	if cred.Realm != a.realm {
+		return "", fmt.Errorf("%w: realm=%q", ErrDigestAuthInvalid, cred.Realm)
+	}
+	if !a.offeredQOP(cred.QOP) {
+		return "", fmt.Errorf("%w: qop=%q", ErrDigestAuthQOP, cred.QOP)
+	}
+	password, ok := a.credentials(cred.Username, cred.Realm)
+	if !ok {
+		return "", fmt.Errorf("%w: username=%q", ErrDigestAuthInvalid, cred.Username)
+	}
+
+	chal := &digest.Challenge{
+		Realm:     cred.Realm,
+		Nonce:     cred.Nonce,
+		Opaque:    cred.Opaque,
+		Algorithm: cred.Algorithm,
+	}
+	if cred.QOP != "" {
+		chal.QOP = []string{cred.QOP}
+	}
+	expected, err := digest.Digest(chal, digest.Options{
+		Method:   req.Method.String(),
+		URI:      cred.URI,
+		GetBody:  digestBody(req.Body()),
+		Count:    cred.Nc,
+		Cnonce:   cred.Cnonce,
+		Username: cred.Username,
+		Password: password,
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrDigestAuthInvalid, err)
+	}
+
+	if expected.Response != cred.Response {
+		if cred.QOP == "auth-int" {
+			return "", ErrDigestAuthIntMatch
+		}
+		return "", ErrDigestAuthInvalid
+	}
+
+	// Nonce is checked after response, as stale is only signaled for valid credentials
+	// https://datatracker.ietf.org/doc/html/rfc2617#section-3.2.1
+	if err := a.nonces.Check(cred.Nonce, cred.Nc); err != nil {
+		if errors.Is(err, ErrDigestAuthNonce) {
+			return "", fmt.Errorf("%w: %w", ErrDigestAuthStale, err)
+		}
+		return "", err
+	}
+	return cred.Username, nil
+}
+
+func (a *DigestAuthServer) offeredQOP(qop string) bool {
+	if len(a.qop) == 0 {
+		return qop == ""
+	}
+	for _, q := range a.qop {
+		if q == qop {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *DigestAuthServer) newNonce() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	nonce := hex.EncodeToString(b)
+	if err := a.nonces.Store(nonce); err != nil {
+		a.log.Error().Err(err).Msg("Failed to store nonce")
+	}
+	return nonce
+}
+
+// digestBody returns body getter used for qop=auth-int
+func digestBody(body []byte) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+}
@@ -0,0 +1,86 @@
+package sipgo
+
+import (
+	"sync"
+	"time"
+)
+
+// DigestNonceStore stores nonces issued by DigestAuthServer. Shared store like Redis allows
+// cluster of servers to verify nonces issued by any node. Redis store can keep nonce as key with TTL
+// and nonce count as value, updated atomically with compare and set script
+type DigestNonceStore interface {
+	// Store saves newly issued nonce
+	Store(nonce string) error
+	// Check validates nonce and nonce count. Nonce count must be greater than previously used.
+	// ErrDigestAuthNonce is returned for unknown or expired nonce, ErrDigestAuthReplay in case nonce count is reused
+	Check(nonce string, nc int) error
+}
+
+// MemoryNonceStore is in memory DigestNonceStore. Nonces are kept in two generations rotated on every expire interval,
+// so nonce is valid at least expire duration and memory is released without scanning
+type MemoryNonceStore struct {
+	expire time.Duration
+
+	mu       sync.Mutex
+	rotated  time.Time
+	current  map[string]int
+	previous map[string]int
+}
+
+// NewMemoryNonceStore creates in memory nonce store with nonce expire duration
+func NewMemoryNonceStore(expire time.Duration) *MemoryNonceStore {
+	return &MemoryNonceStore{
+		expire:   expire,
+		rotated:  time.Now(),
+		current:  make(map[string]int),
+		previous: make(map[string]int),
+	}
+}
+
+func (s *MemoryNonceStore) Store(nonce string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rotate()
+	s.current[nonce] = 0
+	return nil
+}
+
+func (s *MemoryNonceStore) Check(nonce string, nc int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rotate()
+
+	gen := s.current
+	last, ok := gen[nonce]
+	if !ok {
+		gen = s.previous
+		if last, ok = gen[nonce]; !ok {
+			return ErrDigestAuthNonce
+		}
+	}
+
+	// Without qop nonce count is not sent and replay can not be detected
+	if nc == 0 && last == 0 {
+		return nil
+	}
+	if nc <= last {
+		return ErrDigestAuthReplay
+	}
+	gen[nonce] = nc
+	return nil
+}
+
+func (s *MemoryNonceStore) rotate() {
+	since := time.Since(s.rotated)
+	if since < s.expire {
+		return
+	}
+	if since >= 2*s.expire {
+		// Both generations expired
+		s.previous = make(map[string]int)
+	} else {
+		s.previous = s.current
+	}
+	s.current = make(map[string]int)
+	s.rotated = time.Now()
+}
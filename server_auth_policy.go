@@ -0,0 +1,95 @@
+package sipgo
+
+import (
+	"strings"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+// AuthPolicy decides which requests require authentication
+type AuthPolicy struct {
+	// Methods requiring authentication. Empty means all methods except ACK and CANCEL,
+	// which can not be challenged https://datatracker.ietf.org/doc/html/rfc3261#section-22.1
+	Methods []sip.RequestMethod
+	// Domains of Request-URI requiring authentication, as exact domain or *.example.com. Empty means all domains
+	Domains []string
+	// RequireInDialog requires authentication for in-dialog requests. By default they are exempted
+	// as dialog is authenticated with initial request
+	RequireInDialog bool
+	// EmergencyUsers are Request-URI users of emergency calls, which are never challenged.
+	// Default is sos, 112 and 911 https://datatracker.ietf.org/doc/html/rfc5031
+	EmergencyUsers []string
+}
+
+var authEmergencyUsers = []string{"sos", "112", "911"}
+
+// Required reports does request need to be authenticated by policy
+func (p *AuthPolicy) Required(req *sip.Request) bool {
+	if req.IsAck() || req.IsCancel() {
+		return false
+	}
+	if len(p.Methods) > 0 && !authPolicyMethod(p.Methods, req.Method) {
+		return false
+	}
+	if !p.RequireInDialog {
+		if to := req.To(); to != nil && to.Params.Has("tag") {
+			return false
+		}
+	}
+	if req.Recipient == nil {
+		return true
+	}
+	if p.emergency(req.Recipient) {
+		return false
+	}
+	if len(p.Domains) == 0 {
+		return true
+	}
+	for _, d := range p.Domains {
+		if routeMatchDomain(strings.ToLower(d), req.Recipient.Host) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *AuthPolicy) emergency(uri *sip.Uri) bool {
+	users := p.EmergencyUsers
+	if users == nil {
+		users = authEmergencyUsers
+	}
+	user := strings.ToLower(uri.User)
+	for _, u := range users {
+		// sos.fire and other sub services are emergency as well
+		if user == u || strings.HasPrefix(user, u+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func authPolicyMethod(methods []sip.RequestMethod, method sip.RequestMethod) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware returns middleware authorizing requests required by policy. Several auth servers
+// can be chained with different domain policies for per realm authentication
+// Ex:
+//
+//	router.Use(auth.Middleware(sipgo.AuthPolicy{Methods: []sip.RequestMethod{sip.REGISTER, sip.INVITE}}))
+//	srv.OnInvite(auth.Middleware(policy)(inviteHandler))
+func (a *DigestAuthServer) Middleware(policy AuthPolicy) RouteMiddleware {
+	return func(next RequestHandler) RequestHandler {
+		return func(req *sip.Request, tx sip.ServerTransaction) {
+			if policy.Required(req) && !a.Authorize(req, tx) {
+				return
+			}
+			next(req, tx)
+		}
+	}
+}
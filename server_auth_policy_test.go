@@ -0,0 +1,63 @@
+package sipgo
+
+import (
+	"testing"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthPolicy(t *testing.T) {
+	policy := AuthPolicy{
+		Methods: []sip.RequestMethod{sip.REGISTER, sip.INVITE},
+		Domains: []string{"pbx.example.com", "*.tenant.com"},
+	}
+
+	caller := sip.Uri{User: "alice", Host: "10.1.1.1"}
+	newReq := func(method sip.RequestMethod, user, host string) *sip.Request {
+		return createSimpleRequest(method, caller, sip.Uri{User: user, Host: host}, "UDP")
+	}
+
+	assert.True(t, policy.Required(newReq(sip.INVITE, "bob", "pbx.example.com")))
+	assert.True(t, policy.Required(newReq(sip.REGISTER, "bob", "a.tenant.com")))
+	assert.False(t, policy.Required(newReq(sip.OPTIONS, "bob", "pbx.example.com")))
+	assert.False(t, policy.Required(newReq(sip.INVITE, "bob", "other.com")))
+	assert.False(t, policy.Required(newReq(sip.INVITE, "sos", "pbx.example.com")))
+	assert.False(t, policy.Required(newReq(sip.INVITE, "sos.fire", "pbx.example.com")))
+	assert.False(t, policy.Required(newReq(sip.INVITE, "911", "pbx.example.com")))
+
+	inDialog := newReq(sip.INVITE, "bob", "pbx.example.com")
+	inDialog.To().Params.Add("tag", "1234")
+	assert.False(t, policy.Required(inDialog))
+	policy.RequireInDialog = true
+	assert.True(t, policy.Required(inDialog))
+
+	all := AuthPolicy{}
+	assert.True(t, all.Required(newReq(sip.MESSAGE, "bob", "any.com")))
+	assert.False(t, all.Required(newReq(sip.ACK, "bob", "any.com")))
+}
+
+func TestAuthPolicyMiddleware(t *testing.T) {
+	auth := NewDigestAuthServer("sipgo", func(username, realm string) (string, bool) {
+		return "secret", true
+	})
+
+	handled := false
+	handler := auth.Middleware(AuthPolicy{Methods: []sip.RequestMethod{sip.INVITE}})(func(req *sip.Request, tx sip.ServerTransaction) {
+		handled = true
+	})
+
+	caller := sip.Uri{User: "alice", Host: "10.1.1.1"}
+	req := createSimpleRequest(sip.INVITE, caller, sip.Uri{User: "bob", Host: "10.2.2.2"}, "UDP")
+	tx := siptest.NewServerTxRecorder(req)
+	handler(req, tx)
+	assert.False(t, handled)
+	require.Len(t, tx.Result(), 1)
+	assert.Equal(t, sip.StatusUnauthorized, tx.Result()[0].StatusCode)
+
+	req = createSimpleRequest(sip.OPTIONS, caller, sip.Uri{User: "bob", Host: "10.2.2.2"}, "UDP")
+	handler(req, siptest.NewServerTxRecorder(req))
+	assert.True(t, handled)
+}
@@ -0,0 +1,130 @@
+package sipgo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+	"github.com/icholy/digest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDigestAuthServerAuthInt(t *testing.T) {
+	auth := NewDigestAuthServer("sipgo", func(username, realm string) (string, bool) {
+		return "secret", username == "alice"
+	}, WithDigestAuthQOP("auth-int"))
+
+	req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	req.SetBody([]byte("v=0\r\n"))
+
+	tx := siptest.NewServerTxRecorder(req)
+	assert.False(t, auth.Authorize(req, tx))
+	require.Len(t, tx.Result(), 1)
+	res := tx.Result()[0]
+	require.Equal(t, sip.StatusUnauthorized, res.StatusCode)
+
+	chal, err := digest.ParseChallenge(res.GetHeader("WWW-Authenticate").Value())
+	require.NoError(t, err)
+	require.Equal(t, []string{"auth-int"}, chal.QOP)
+
+	sign := func(username string, body []byte) {
+		cred, err := digest.Digest(chal, digest.Options{
+			Method:   sip.INVITE.String(),
+			URI:      req.Recipient.Addr(),
+			GetBody:  digestBody(body),
+			Username: username,
+			Password: "secret",
+		})
+		require.NoError(t, err)
+		req.RemoveHeader("Authorization")
+		req.AppendHeader(sip.NewHeader("Authorization", cred.String()))
+	}
+
+	sign("alice", req.Body())
+	username, err := auth.Verify(req)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", username)
+
+	// Body modified after signing
+	req.SetBody([]byte("v=1\r\n"))
+	_, err = auth.Verify(req)
+	require.ErrorIs(t, err, ErrDigestAuthIntMatch)
+
+	sign("bob", req.Body())
+	_, err = auth.Verify(req)
+	require.ErrorIs(t, err, ErrDigestAuthInvalid)
+
+	// Only offered qop is accepted
+	chal.QOP = []string{"auth"}
+	sign("alice", req.Body())
+	_, err = auth.Verify(req)
+	require.ErrorIs(t, err, ErrDigestAuthQOP)
+
+	// Nonce not issued by server
+	chal.QOP = []string{"auth-int"}
+	chal.Nonce = "1234"
+	sign("alice", req.Body())
+	_, err = auth.Verify(req)
+	require.ErrorIs(t, err, ErrDigestAuthNonce)
+}
+
+func TestDigestAuthServerNonce(t *testing.T) {
+	auth := NewDigestAuthServer("sipgo", func(username, realm string) (string, bool) {
+		return "secret", true
+	})
+
+	req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	chal, err := digest.ParseChallenge(auth.Challenge(req).GetHeader("WWW-Authenticate").Value())
+	require.NoError(t, err)
+
+	sign := func(nc int) {
+		cred, err := digest.Digest(chal, digest.Options{
+			Method:   sip.INVITE.String(),
+			URI:      req.Recipient.Addr(),
+			Count:    nc,
+			Username: "alice",
+			Password: "secret",
+		})
+		require.NoError(t, err)
+		req.RemoveHeader("Authorization")
+		req.AppendHeader(sip.NewHeader("Authorization", cred.String()))
+	}
+
+	sign(1)
+	_, err = auth.Verify(req)
+	require.NoError(t, err)
+
+	// Replay with same nonce count
+	_, err = auth.Verify(req)
+	require.ErrorIs(t, err, ErrDigestAuthReplay)
+
+	sign(2)
+	_, err = auth.Verify(req)
+	require.NoError(t, err)
+
+	// Valid credentials with unknown nonce are challenged with stale
+	chal.Nonce = "expired"
+	sign(1)
+	tx := siptest.NewServerTxRecorder(req)
+	assert.False(t, auth.Authorize(req, tx))
+	require.Len(t, tx.Result(), 1)
+	staleChal, err := digest.ParseChallenge(tx.Result()[0].GetHeader("WWW-Authenticate").Value())
+	require.NoError(t, err)
+	assert.True(t, staleChal.Stale)
+}
+
+func TestMemoryNonceStoreRotation(t *testing.T) {
+	s := NewMemoryNonceStore(time.Minute)
+	require.NoError(t, s.Store("n1"))
+	require.NoError(t, s.Check("n1", 1))
+
+	// Nonce survives one rotation
+	s.rotated = s.rotated.Add(-time.Minute)
+	require.NoError(t, s.Check("n1", 2))
+	require.ErrorIs(t, s.Check("n1", 2), ErrDigestAuthReplay)
+
+	s.rotated = s.rotated.Add(-time.Minute)
+	require.ErrorIs(t, s.Check("n1", 3), ErrDigestAuthNonce)
+}
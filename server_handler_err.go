@@ -0,0 +1,106 @@
+package sipgo
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+// RequestHandlerErr is request handler returning error. In case final response is not sent by handler,
+// returned error is converted to response. sip.StatusError is answered with its status code and
+// any other error with 500 Server Internal Error
+type RequestHandlerErr func(req *sip.Request, tx sip.ServerTransaction) error
+
+// OnRequestErr registers new request callback returning error
+// Ex:
+//
+//	srv.OnRequestErr(sip.INVITE, func(req *sip.Request, tx sip.ServerTransaction) error {
+//		if busy {
+//			return sip.StatusError{Code: sip.StatusBusyHere, Reason: "Busy Here"}
+//		}
+//		...
+//	})
+func (srv *Server) OnRequestErr(method sip.RequestMethod, handler RequestHandlerErr) {
+	srv.requestHandlers[method] = srv.HandlerErr(handler)
+}
+
+// HandlerErr converts handler returning error to RequestHandler, ex. for using it with Router
+func (srv *Server) HandlerErr(handler RequestHandlerErr) RequestHandler {
+	return func(req *sip.Request, tx sip.ServerTransaction) {
+		if tx == nil {
+			if err := handler(req, nil); err != nil {
+				logTrail(srv.log.Error(), req).Err(err).Str("req", req.Method.String()).Msg("Request handler failed")
+			}
+			return
+		}
+
+		ftx := &finalTx{ServerTransaction: tx}
+		err := handler(req, ftx)
+		if err == nil {
+			return
+		}
+
+		req.Trail().Addf("handler", "failed: %s", err)
+		if req.IsAck() {
+			logTrail(srv.log.Error(), req).Err(err).Str("req", req.Method.String()).Msg("Request handler failed")
+			return
+		}
+		if ftx.final.Load() {
+			logTrail(srv.log.Error(), req).Err(err).Str("req", req.Method.String()).Msg("Request handler failed after final response")
+			return
+		}
+
+		res := handlerErrResponse(req, err)
+		if res.StatusCode >= 500 {
+			logTrail(srv.log.Error(), req).Err(err).Str("req", req.Method.String()).Msg("Request handler failed")
+		}
+		if err := tx.Respond(res); err != nil {
+			srv.log.Error().Err(err).Str("res", res.StartLine()).Msg("Failed to respond handler error")
+		}
+	}
+}
+
+func handlerErrResponse(req *sip.Request, err error) *sip.Response {
+	if errors.Is(err, ErrCircuitOpen) {
+		return sip.NewResponseFromRequest(req, sip.StatusServiceUnavailable, "Service Unavailable", nil)
+	}
+
+	var se sip.StatusError
+	if pse := (*sip.StatusError)(nil); errors.As(err, &pse) {
+		se = *pse
+	} else if !errors.As(err, &se) {
+		return sip.NewResponseFromRequest(req, sip.StatusInternalServerError, "Server Internal Error", nil)
+	}
+
+	res := sip.NewResponseFromRequest(req, se.Code, se.Reason, nil)
+	for _, h := range se.Headers {
+		res.AppendHeader(h)
+	}
+	return res
+}
+
+// finalTx tracks is final response sent by handler
+type finalTx struct {
+	sip.ServerTransaction
+	final atomic.Bool
+}
+
+func (tx *finalTx) Respond(res *sip.Response) error {
+	if !res.IsProvisional() {
+		tx.final.Store(true)
+	}
+	return tx.ServerTransaction.Respond(res)
+}
+
+func (tx *finalTx) RespondWith(code sip.StatusCode, reason string, body []byte, headers ...sip.Header) error {
+	if code >= 200 {
+		tx.final.Store(true)
+	}
+	return tx.ServerTransaction.RespondWith(code, reason, body, headers...)
+}
+
+func (tx *finalTx) RespondSDP(sdp []byte) error {
+	tx.final.Store(true)
+	return tx.ServerTransaction.RespondSDP(sdp)
+}
@@ -0,0 +1,52 @@
+package sipgo
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerHandlerErr(t *testing.T) {
+	ua, err := NewUA()
+	require.NoError(t, err)
+	defer ua.Close()
+	srv, err := NewServer(ua)
+	require.NoError(t, err)
+
+	tests := []struct {
+		err    error
+		code   sip.StatusCode
+		reason string
+	}{
+		{sip.StatusError{Code: sip.StatusBusyHere, Reason: "Busy Here"}, 486, "Busy Here"},
+		{fmt.Errorf("wrapped: %w", &sip.StatusError{Code: sip.StatusNotFound, Reason: "Not Found"}), 404, "Not Found"},
+		{errors.New("unexpected"), 500, "Server Internal Error"},
+	}
+
+	for _, tc := range tests {
+		req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+		tx := siptest.NewServerTxRecorder(req)
+		srv.HandlerErr(func(req *sip.Request, tx sip.ServerTransaction) error {
+			return tc.err
+		})(req, tx)
+
+		require.Len(t, tx.Result(), 1)
+		assert.Equal(t, tc.code, tx.Result()[0].StatusCode)
+		assert.Equal(t, tc.reason, tx.Result()[0].Reason)
+	}
+
+	// Error after final response is only logged
+	req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	tx := siptest.NewServerTxRecorder(req)
+	srv.HandlerErr(func(req *sip.Request, tx sip.ServerTransaction) error {
+		tx.Respond(sip.NewResponseFromRequest(req, 200, "OK", nil))
+		return errors.New("late")
+	})(req, tx)
+	require.Len(t, tx.Result(), 1)
+	assert.Equal(t, sip.StatusOK, tx.Result()[0].StatusCode)
+}
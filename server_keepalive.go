@@ -0,0 +1,40 @@
+package sipgo
+
+import (
+	"github.com/emiago/sipgo/sip"
+)
+
+// WithServerKeepaliveMethods sets vendor methods, like PING or KDMQ, which some PBXes send as keepalive.
+// Without registered handler they are answered with 200 OK instead of 405 Method Not Allowed
+// Ex:
+//
+//	srv, _ := sipgo.NewServer(ua, sipgo.WithServerKeepaliveMethods("PING", "KDMQ"))
+func WithServerKeepaliveMethods(methods ...sip.RequestMethod) ServerOption {
+	return func(s *Server) error {
+		if s.keepaliveMethods == nil {
+			s.keepaliveMethods = make(map[sip.RequestMethod]struct{}, len(methods))
+		}
+		for _, m := range methods {
+			s.keepaliveMethods[m] = struct{}{}
+		}
+		return nil
+	}
+}
+
+// isKeepalive reports is method configured as keepalive
+func (srv *Server) isKeepalive(method sip.RequestMethod) bool {
+	_, exists := srv.keepaliveMethods[method]
+	return exists
+}
+
+func (srv *Server) keepaliveHandler(req *sip.Request, tx sip.ServerTransaction) {
+	req.Trail().Add("server", "keepalive "+req.Method.String())
+	res := sip.NewResponseFromRequest(req, sip.StatusOK, "OK", nil)
+	respond := srv.WriteResponse
+	if tx != nil {
+		respond = tx.Respond
+	}
+	if err := respond(res); err != nil {
+		srv.log.Debug().Err(err).Str("method", req.Method.String()).Msg("respond '200 OK' on keepalive failed")
+	}
+}
@@ -0,0 +1,39 @@
+package sipgo
+
+import (
+	"testing"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerKeepaliveMethods(t *testing.T) {
+	ua, _ := NewUA()
+	defer ua.Close()
+
+	srv, err := NewServer(ua, WithServerKeepaliveMethods("PING", "KDMQ"))
+	require.NoError(t, err)
+
+	var handled int
+	srv.OnRequest("KDMQ", func(req *sip.Request, tx sip.ServerTransaction) {
+		handled++
+		tx.Respond(sip.NewResponseFromRequest(req, 202, "Accepted", nil))
+	})
+
+	handle := func(method sip.RequestMethod) sip.StatusCode {
+		req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+		req.Method = method
+		tx := siptest.NewServerTxRecorder(req)
+		srv.handleRequest(req, tx)
+		require.Len(t, tx.Result(), 1)
+		return tx.Result()[0].StatusCode
+	}
+
+	assert.Equal(t, sip.StatusOK, handle("PING"))
+	// Registered handler has precedence
+	assert.Equal(t, sip.StatusCode(202), handle("KDMQ"))
+	assert.Equal(t, 1, handled)
+	assert.NotContains(t, srv.RegisteredMethods(), "PING")
+}
@@ -0,0 +1,134 @@
+package sipgo
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+// ListenerAction is action taken for request that must not arrive on listener
+type ListenerAction int
+
+const (
+	// ListenerReject rejects request with policy status code
+	ListenerReject ListenerAction = iota
+	// ListenerLog logs request and passes it to handler as usual
+	ListenerLog
+	// ListenerForward passes request to policy Forward handler instead of method handler
+	ListenerForward
+)
+
+// ListenerPolicy enforces listener role by methods that can arrive on it.
+// Ex. REGISTER is not expected on public trunk listener or INVITE on management listener
+type ListenerPolicy struct {
+	// Addr is listener local address host:port. Empty or unspecified host matches port on any interface
+	Addr string
+	// Allow lists methods accepted on listener. Empty allows all methods not denied
+	Allow []sip.RequestMethod
+	// Deny lists methods not accepted on listener
+	Deny []sip.RequestMethod
+
+	Action ListenerAction
+	// StatusCode is used with ListenerReject. Default is 405 Method Not Allowed
+	StatusCode sip.StatusCode
+	// Forward handles not accepted requests with ListenerForward
+	Forward RequestHandler
+}
+
+// WithServerListenerPolicy adds policy for requests received on listener.
+// It can be used multiple times for different listeners
+func WithServerListenerPolicy(policy ListenerPolicy) ServerOption {
+	return func(s *Server) error {
+		if _, _, err := net.SplitHostPort(policy.Addr); err != nil {
+			return fmt.Errorf("listener policy address: %w", err)
+		}
+		if policy.Action == ListenerForward && policy.Forward == nil {
+			return fmt.Errorf("listener policy %s: forward handler missing", policy.Addr)
+		}
+		if policy.StatusCode == 0 {
+			policy.StatusCode = sip.StatusMethodNotAllowed
+		}
+		s.listenerPolicies = append(s.listenerPolicies, policy)
+		return nil
+	}
+}
+
+// matchAddr reports is laddr address of policy listener
+func (p *ListenerPolicy) matchAddr(laddr string) bool {
+	host, port, _ := net.SplitHostPort(p.Addr)
+	lhost, lport, err := net.SplitHostPort(laddr)
+	if err != nil || port != lport {
+		return false
+	}
+	if host == "" {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.IsUnspecified() {
+			return true
+		}
+		return ip.Equal(net.ParseIP(lhost))
+	}
+	return host == lhost
+}
+
+// accepts reports is method accepted on listener
+func (p *ListenerPolicy) accepts(method sip.RequestMethod) bool {
+	for _, m := range p.Deny {
+		if m == method {
+			return false
+		}
+	}
+	if len(p.Allow) == 0 {
+		return true
+	}
+	for _, m := range p.Allow {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// listenerRejected applies listener policies on request.
+// Returns true if request is handled and should not be passed further
+func (srv *Server) listenerRejected(req *sip.Request, tx sip.ServerTransaction) bool {
+	laddr := req.LocalAddr()
+	if len(srv.listenerPolicies) == 0 || laddr == "" {
+		return false
+	}
+
+	for i := range srv.listenerPolicies {
+		p := &srv.listenerPolicies[i]
+		if !p.matchAddr(laddr) {
+			continue
+		}
+		if p.accepts(req.Method) {
+			return false
+		}
+
+		switch p.Action {
+		case ListenerLog:
+			req.Trail().Addf("listener", "%s not expected on %s", req.Method, laddr)
+			srv.log.Warn().Str("laddr", laddr).Str("method", req.Method.String()).Str("source", req.Source()).Msg("Request not expected on listener")
+			return false
+		case ListenerForward:
+			req.Trail().Addf("listener", "%s forwarded from %s", req.Method, laddr)
+			p.Forward(req, tx)
+			return true
+		}
+
+		req.Trail().Addf("listener", "%s rejected on %s", req.Method, laddr)
+		srv.log.Info().Str("laddr", laddr).Str("method", req.Method.String()).Str("source", req.Source()).Msg("Request rejected by listener policy")
+		if req.IsAck() || tx == nil {
+			return true
+		}
+		res := sip.NewResponseFromRequest(req, p.StatusCode, sip.StatusText(p.StatusCode), nil)
+		if err := tx.Respond(res); err != nil {
+			srv.log.Error().Err(err).Msg("Failed to respond on listener policy rejection")
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package sipgo
+
+import (
+	"testing"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerListenerPolicy(t *testing.T) {
+	ua, _ := NewUA()
+	defer ua.Close()
+
+	var forwarded int
+	srv, err := NewServer(ua,
+		WithServerListenerPolicy(ListenerPolicy{
+			Addr:       ":5060",
+			Deny:       []sip.RequestMethod{sip.REGISTER},
+			StatusCode: sip.StatusForbidden,
+		}),
+		WithServerListenerPolicy(ListenerPolicy{
+			Addr:   "127.0.0.1:5070",
+			Allow:  []sip.RequestMethod{sip.OPTIONS},
+			Action: ListenerForward,
+			Forward: func(req *sip.Request, tx sip.ServerTransaction) {
+				forwarded++
+				tx.Respond(sip.NewResponseFromRequest(req, 404, "Not Found", nil))
+			},
+		}),
+	)
+	require.NoError(t, err)
+
+	var handled int
+	srv.OnInvite(func(req *sip.Request, tx sip.ServerTransaction) {
+		handled++
+		tx.Respond(sip.NewResponseFromRequest(req, 200, "OK", nil))
+	})
+
+	handle := func(method sip.RequestMethod, laddr string) sip.StatusCode {
+		req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+		req.Method = method
+		req.SetLocalAddr(laddr)
+		tx := siptest.NewServerTxRecorder(req)
+		srv.handleRequest(req, tx)
+		require.Len(t, tx.Result(), 1)
+		return tx.Result()[0].StatusCode
+	}
+
+	assert.Equal(t, sip.StatusForbidden, handle(sip.REGISTER, "10.0.0.1:5060"))
+	assert.Equal(t, sip.StatusOK, handle(sip.INVITE, "10.0.0.1:5060"))
+	assert.Equal(t, 1, handled)
+
+	assert.Equal(t, sip.StatusNotFound, handle(sip.INVITE, "127.0.0.1:5070"))
+	assert.Equal(t, 1, forwarded)
+	assert.Equal(t, 1, handled)
+
+	// Other interface is not matched
+	assert.Equal(t, sip.StatusOK, handle(sip.INVITE, "127.0.0.2:5070"))
+
+	_, err = NewServer(ua, WithServerListenerPolicy(ListenerPolicy{Addr: ":5060", Action: ListenerForward}))
+	require.Error(t, err)
+}
@@ -0,0 +1,99 @@
+package sipgo
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+// WithServerMergedRequestDetection enables merged request detection for UAS.
+// Request outside of dialog matching ongoing transaction by From tag, Call-ID and CSeq,
+// but arriving as different transaction, is answered with 482 Loop Detected.
+// It should not be used for proxy, as proxy must forward spiraled requests
+func WithServerMergedRequestDetection() ServerOption {
+	return func(s *Server) error {
+		s.mergedRequests = newMergedRequests()
+		return nil
+	}
+}
+
+// mergedRequests tracks ongoing server transactions of requests outside of dialog
+// https://datatracker.ietf.org/doc/html/rfc3261#section-8.2.2.2
+type mergedRequests struct {
+	mu      sync.Mutex
+	ongoing map[string]struct{}
+}
+
+func newMergedRequests() *mergedRequests {
+	return &mergedRequests{
+		ongoing: make(map[string]struct{}),
+	}
+}
+
+func mergedRequestKey(req *sip.Request) (string, bool) {
+	if req.IsAck() || req.IsCancel() {
+		return "", false
+	}
+
+	to, from, callid, cseq := req.To(), req.From(), req.CallID(), req.CSeq()
+	if to == nil || from == nil || callid == nil || cseq == nil {
+		return "", false
+	}
+	if _, exists := to.Params["tag"]; exists {
+		return "", false
+	}
+
+	ftag := from.Params["tag"]
+	return ftag + "__" + callid.Value() + "__" + strconv.FormatUint(uint64(cseq.SeqNo), 10) + "__" + string(cseq.MethodName), true
+}
+
+// start registers request transaction. It returns false if request is merged
+func (m *mergedRequests) start(key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.ongoing[key]; exists {
+		return false
+	}
+	m.ongoing[key] = struct{}{}
+	return true
+}
+
+func (m *mergedRequests) done(key string) {
+	m.mu.Lock()
+	delete(m.ongoing, key)
+	m.mu.Unlock()
+}
+
+// mergedRequest responds 482 on merged request. Otherwise request is tracked until its transaction is done
+func (srv *Server) mergedRequest(req *sip.Request, tx sip.ServerTransaction) bool {
+	if srv.mergedRequests == nil || tx == nil {
+		return false
+	}
+
+	key, ok := mergedRequestKey(req)
+	if !ok {
+		return false
+	}
+
+	if srv.mergedRequests.start(key) {
+		go func() {
+			<-tx.Done()
+			srv.mergedRequests.done(key)
+		}()
+		return false
+	}
+
+	srv.log.Debug().Str("req", req.Method.String()).Str("source", req.Source()).Msg("Merged request detected")
+	res := sip.NewResponseFromRequest(req, 482, "Loop Detected", nil)
+	if err := tx.Respond(res); err != nil {
+		srv.log.Error().Err(err).Msg("Failed to respond merged request")
+	}
+	return true
+}
+
+func (m *mergedRequests) len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.ongoing)
+}
@@ -0,0 +1,66 @@
+package sipgo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerMergedRequest(t *testing.T) {
+	ua, _ := NewUA()
+	defer ua.Close()
+
+	srv, err := NewServer(ua, WithServerMergedRequestDetection())
+	require.NoError(t, err)
+
+	handling := make(chan struct{})
+	release := make(chan struct{})
+	srv.OnInvite(func(req *sip.Request, tx sip.ServerTransaction) {
+		handling <- struct{}{}
+		<-release
+		tx.Respond(sip.NewResponseFromRequest(req, 200, "OK", nil))
+	})
+
+	req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	tx := siptest.NewServerTxRecorder(req)
+	go srv.handleRequest(req, tx)
+	<-handling
+
+	// Same request forked over other path has different branch
+	forked := req.Clone()
+	forked.Via().Params["branch"] = sip.GenerateBranch()
+	forkedTx := siptest.NewServerTxRecorder(forked)
+	srv.handleRequest(forked, forkedTx)
+	require.Len(t, forkedTx.Result(), 1)
+	assert.Equal(t, sip.StatusCode(482), forkedTx.Result()[0].StatusCode)
+
+	close(release)
+	<-tx.Done()
+	require.Len(t, tx.Result(), 1)
+	assert.Equal(t, sip.StatusCode(200), tx.Result()[0].StatusCode)
+
+	// Transaction is over, same request is handled again
+	require.Eventually(t, func() bool { return srv.mergedRequests.len() == 0 }, time.Second, 10*time.Millisecond)
+	go func() { <-handling }()
+	retryTx := siptest.NewServerTxRecorder(forked)
+	srv.handleRequest(forked, retryTx)
+	require.Len(t, retryTx.Result(), 1)
+	assert.Equal(t, sip.StatusCode(200), retryTx.Result()[0].StatusCode)
+}
+
+func TestServerMergedRequestDisabled(t *testing.T) {
+	ua, _ := NewUA()
+	defer ua.Close()
+
+	// Disabled by default, as proxy must forward spirals
+	srv, err := NewServer(ua)
+	require.NoError(t, err)
+
+	req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	assert.False(t, srv.mergedRequest(req, siptest.NewServerTxRecorder(req)))
+	assert.False(t, srv.mergedRequest(req, siptest.NewServerTxRecorder(req)))
+}
@@ -0,0 +1,51 @@
+package sipgo
+
+import (
+	"github.com/emiago/sipgo/sip"
+)
+
+// PagerMessage is received pager mode instant message
+type PagerMessage struct {
+	From        sip.Uri
+	To          sip.Uri
+	ContentType string
+	Body        []byte
+	Request     *sip.Request
+}
+
+// PagerMessageHandler handles received MESSAGE and returns delivery status reported to sender in final response:
+// MessageDelivered with 200, MessageAccepted with 202 and MessageFailed with 480.
+// Returned error is responded same as with RequestHandlerErr, ex. sip.StatusError with 415 for unsupported content type
+type PagerMessageHandler func(msg *PagerMessage) (MessageStatus, error)
+
+// OnPagerMessage registers MESSAGE handler for pager mode instant messages.
+// Check Client.SendMessage for sending
+// https://datatracker.ietf.org/doc/html/rfc3428
+func (srv *Server) OnPagerMessage(handler PagerMessageHandler) {
+	srv.OnRequestErr(sip.MESSAGE, func(req *sip.Request, tx sip.ServerTransaction) error {
+		msg := &PagerMessage{Body: req.Body(), Request: req}
+		if h := req.From(); h != nil {
+			msg.From = h.Address
+		}
+		if h := req.To(); h != nil {
+			msg.To = h.Address
+		}
+		if h := req.ContentType(); h != nil {
+			msg.ContentType = h.Value()
+		}
+
+		status, err := handler(msg)
+		if err != nil {
+			return err
+		}
+
+		code := sip.StatusOK
+		switch status {
+		case MessageAccepted:
+			code = sip.StatusAccepted
+		case MessageFailed:
+			code = sip.StatusTemporarilyUnavailable
+		}
+		return tx.Respond(sip.NewResponseFromRequest(req, code, sip.StatusText(code), nil))
+	})
+}
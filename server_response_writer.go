@@ -0,0 +1,90 @@
+package sipgo
+
+import (
+	"github.com/emiago/sipgo/sip"
+)
+
+// RequestHandlerWriter is request handler with net/http like signature. Check sip.ResponseWriter
+type RequestHandlerWriter func(w sip.ResponseWriter, req *sip.Request)
+
+// OnRequestWriter registers new request callback using response writer
+// Ex:
+//
+//	srv.OnRequestWriter(sip.OPTIONS, func(w sip.ResponseWriter, req *sip.Request) {
+//		w.AppendHeader(sip.NewHeader("Allow", "INVITE, ACK, BYE, CANCEL, OPTIONS"))
+//		w.WriteHeader(sip.StatusOK)
+//	})
+func (srv *Server) OnRequestWriter(method sip.RequestMethod, handler RequestHandlerWriter) {
+	srv.requestHandlers[method] = srv.HandlerWriter(handler)
+}
+
+// HandlerWriter converts handler using response writer to RequestHandler, ex. for using it with Router
+func (srv *Server) HandlerWriter(handler RequestHandlerWriter) RequestHandler {
+	return func(req *sip.Request, tx sip.ServerTransaction) {
+		w := &responseWriter{req: req, code: sip.StatusOK}
+		if tx != nil {
+			w.tx = &finalTx{ServerTransaction: tx}
+		}
+		handler(w, req)
+
+		if err := w.Flush(); err != nil && err != sip.ErrResponseWritten {
+			srv.log.Error().Err(err).Str("req", req.Method.String()).Msg("Failed to write response")
+		}
+	}
+}
+
+// responseWriter is sip.ResponseWriter over server transaction. It is not safe for concurrent use
+type responseWriter struct {
+	req *sip.Request
+	tx  *finalTx
+
+	code    sip.StatusCode
+	headers []sip.Header
+	body    []byte
+}
+
+func (w *responseWriter) AppendHeader(h sip.Header) {
+	w.headers = append(w.headers, h)
+}
+
+func (w *responseWriter) Write(body []byte) (int, error) {
+	if w.written() {
+		return 0, sip.ErrResponseWritten
+	}
+	w.body = append(w.body, body...)
+	return len(body), nil
+}
+
+func (w *responseWriter) WriteHeader(code sip.StatusCode) {
+	if code >= 200 {
+		w.code = code
+		return
+	}
+	if w.written() {
+		return
+	}
+
+	// Provisional is sent now with headers appended so far
+	headers := w.headers
+	w.headers = nil
+	w.tx.RespondWith(code, sip.StatusText(code), nil, headers...)
+}
+
+func (w *responseWriter) Flush() error {
+	if w.written() {
+		return sip.ErrResponseWritten
+	}
+	return w.tx.RespondWith(w.code, sip.StatusText(w.code), w.body, w.headers...)
+}
+
+func (w *responseWriter) Transaction() sip.ServerTransaction {
+	if w.tx == nil {
+		return nil
+	}
+	return w.tx
+}
+
+// written reports is final response sent. ACK has no transaction and is never responded
+func (w *responseWriter) written() bool {
+	return w.tx == nil || w.tx.final.Load()
+}
@@ -0,0 +1,71 @@
+package sipgo
+
+import (
+	"testing"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerRequestWriter(t *testing.T) {
+	ua, _ := NewUA()
+	defer ua.Close()
+	srv, err := NewServer(ua)
+	require.NoError(t, err)
+
+	handle := func(handler RequestHandlerWriter) []*sip.Response {
+		srv.OnRequestWriter(sip.INVITE, handler)
+		req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+		tx := siptest.NewServerTxRecorder(req)
+		srv.handleRequest(req, tx)
+		return tx.Result()
+	}
+
+	t.Run("ProvisionalAndFinal", func(t *testing.T) {
+		res := handle(func(w sip.ResponseWriter, req *sip.Request) {
+			w.WriteHeader(sip.StatusRinging)
+			w.AppendHeader(sip.NewHeader("Content-Type", "application/sdp"))
+			w.WriteHeader(sip.StatusOK)
+			n, err := w.Write([]byte("sdp"))
+			require.NoError(t, err)
+			assert.Equal(t, 3, n)
+		})
+		require.Len(t, res, 2)
+		assert.Equal(t, sip.StatusRinging, res[0].StatusCode)
+		assert.Equal(t, sip.StatusOK, res[1].StatusCode)
+		assert.Equal(t, "sdp", string(res[1].Body()))
+		assert.Equal(t, "application/sdp", res[1].GetHeader("Content-Type").Value())
+
+		tag, ok := res[0].To().Params.Get("tag")
+		require.True(t, ok)
+		finalTag, _ := res[1].To().Params.Get("tag")
+		assert.Equal(t, tag, finalTag)
+	})
+
+	t.Run("Implicit200", func(t *testing.T) {
+		res := handle(func(w sip.ResponseWriter, req *sip.Request) {})
+		require.Len(t, res, 1)
+		assert.Equal(t, sip.StatusOK, res[0].StatusCode)
+	})
+
+	t.Run("Flush", func(t *testing.T) {
+		res := handle(func(w sip.ResponseWriter, req *sip.Request) {
+			w.WriteHeader(sip.StatusBusyHere)
+			require.NoError(t, w.Flush())
+			_, err := w.Write([]byte("late"))
+			assert.ErrorIs(t, err, sip.ErrResponseWritten)
+		})
+		require.Len(t, res, 1)
+		assert.Equal(t, sip.StatusBusyHere, res[0].StatusCode)
+	})
+
+	t.Run("Transaction", func(t *testing.T) {
+		res := handle(func(w sip.ResponseWriter, req *sip.Request) {
+			w.Transaction().Respond(sip.NewResponseFromRequest(req, sip.StatusForbidden, "Forbidden", nil))
+		})
+		require.Len(t, res, 1)
+		assert.Equal(t, sip.StatusForbidden, res[0].StatusCode)
+	})
+}
@@ -0,0 +1,123 @@
+package sipgo
+
+import (
+	"github.com/emiago/sipgo/sip"
+)
+
+// ScreenAction is decision made by call screener for incoming call
+type ScreenAction int
+
+const (
+	// ScreenAccept passes call to INVITE handler
+	ScreenAccept ScreenAction = iota
+	// ScreenBusy rejects call with 486 Busy Here
+	ScreenBusy
+	// ScreenDecline rejects call with 603 Decline
+	ScreenDecline
+	// ScreenRedirect redirects call with 302 Moved Temporarily to Contact
+	ScreenRedirect
+)
+
+// ScreenResult is returned by CallScreener.
+type ScreenResult struct {
+	Action ScreenAction
+	// Contact is redirect target used with ScreenRedirect
+	Contact sip.Uri
+}
+
+// CallScreener is consulted for every new incoming call before INVITE handler is called.
+// Caller is identity of caller. Check CallerIdentity and WithServerCallScreeningTrustDomain
+type CallScreener func(caller sip.Uri, req *sip.Request) ScreenResult
+
+// WithServerCallScreening sets call screener for incoming calls.
+// Rejected or redirected calls are never passed to INVITE handler
+func WithServerCallScreening(screener CallScreener) ServerOption {
+	return func(s *Server) error {
+		s.callScreener = screener
+		return nil
+	}
+}
+
+// WithServerCallScreeningTrustDomain sets trust domain used for caller identity passed to call screener.
+// P-Asserted-Identity is used only for requests received from trusted peers, otherwise From is used
+func WithServerCallScreeningTrustDomain(td *TrustDomain) ServerOption {
+	return func(s *Server) error {
+		s.screenTrustDomain = td
+		return nil
+	}
+}
+
+// SetDoNotDisturb when enabled rejects all new incoming calls with 486 Busy Here.
+// It is checked before call screener. Privileged auto answer accepted by user agent policy is not rejected
+func (srv *Server) SetDoNotDisturb(enabled bool) {
+	srv.dnd.Store(enabled)
+}
+
+// DoNotDisturb returns is do-not-disturb enabled
+func (srv *Server) DoNotDisturb() bool {
+	return srv.dnd.Load()
+}
+
+// CallerIdentity returns caller identity of request.
+// P-Asserted-Identity is preferred over From header only when request source is within trust domain,
+// as any untrusted peer can send it. With nil trust domain From header is always used
+// https://datatracker.ietf.org/doc/html/rfc3325#section-9.1
+func CallerIdentity(req *sip.Request, td *TrustDomain) sip.Uri {
+	if td != nil && td.Trusted(req.Source()) {
+		if pai := req.PAssertedIdentity(); len(pai) > 0 {
+			return pai[0].Address
+		}
+	}
+
+	if from := req.From(); from != nil {
+		return from.Address
+	}
+	return sip.Uri{}
+}
+
+// screenCall applies do-not-disturb and screening on new incoming calls.
+// Returns true if call is handled and should not be passed further
+func (srv *Server) screenCall(req *sip.Request, tx sip.ServerTransaction) bool {
+	if !req.IsInvite() || tx == nil {
+		return false
+	}
+
+	// Only new calls are screened, not re-INVITEs
+	if to := req.To(); to != nil {
+		if _, exists := to.Params["tag"]; exists {
+			return false
+		}
+	}
+
+	result := ScreenResult{Action: ScreenAccept}
+	if srv.dnd.Load() {
+		// Accepted privileged auto answer, like paging, bypasses do not disturb
+		if aa, accepted := srv.AutoAnswer(req); accepted && aa.Priv {
+			return false
+		}
+		result.Action = ScreenBusy
+	} else if srv.callScreener != nil {
+		result = srv.callScreener(CallerIdentity(req, srv.screenTrustDomain), req)
+	}
+
+	var res *sip.Response
+	switch result.Action {
+	case ScreenAccept:
+		return false
+	case ScreenBusy:
+		res = sip.NewResponseFromRequest(req, 486, "Busy Here", nil)
+	case ScreenDecline:
+		res = sip.NewResponseFromRequest(req, 603, "Decline", nil)
+	case ScreenRedirect:
+		res = sip.NewResponseFromRequest(req, 302, "Moved Temporarily", nil)
+		res.AppendHeader(&sip.ContactHeader{Address: result.Contact})
+	default:
+		return false
+	}
+
+	req.Trail().Addf("screening", "answered %d %s", res.StatusCode, res.Reason)
+	if err := tx.Respond(res); err != nil {
+		srv.log.Error().Err(err).Int("code", int(res.StatusCode)).Msg("Failed to respond screened call")
+	}
+	return true
+}
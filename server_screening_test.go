@@ -0,0 +1,105 @@
+package sipgo
+
+import (
+	"testing"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerCallScreening(t *testing.T) {
+	ua, _ := NewUA()
+	defer ua.Close()
+
+	srv, err := NewServer(ua, WithServerCallScreening(func(caller sip.Uri, req *sip.Request) ScreenResult {
+		switch caller.User {
+		case "spam":
+			return ScreenResult{Action: ScreenDecline}
+		case "redirect":
+			return ScreenResult{Action: ScreenRedirect, Contact: sip.Uri{User: "voicemail", Host: "127.0.0.1"}}
+		}
+		return ScreenResult{Action: ScreenAccept}
+	}))
+	require.NoError(t, err)
+
+	var handled int
+	srv.OnInvite(func(req *sip.Request, tx sip.ServerTransaction) {
+		handled++
+		tx.Respond(sip.NewResponseFromRequest(req, 200, "OK", nil))
+	})
+
+	invite := func(from string) *siptest.ServerTxRecorder {
+		req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+		req.From().Address.User = from
+		tx := siptest.NewServerTxRecorder(req)
+		srv.handleRequest(req, tx)
+		require.Len(t, tx.Result(), 1)
+		return tx
+	}
+
+	tx := invite("alice")
+	assert.Equal(t, sip.StatusCode(200), tx.Result()[0].StatusCode)
+	assert.Equal(t, 1, handled)
+
+	tx = invite("spam")
+	assert.Equal(t, sip.StatusCode(603), tx.Result()[0].StatusCode)
+
+	tx = invite("redirect")
+	res := tx.Result()[0]
+	assert.Equal(t, sip.StatusCode(302), res.StatusCode)
+	require.NotNil(t, res.Contact())
+	assert.Equal(t, "voicemail", res.Contact().Address.User)
+
+	// Caller identity is taken from asserted identity only from trusted source
+	req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	req.AppendHeader(sip.NewHeader("P-Asserted-Identity", "<sip:spam@127.0.0.2>"))
+	req.SetSource("127.0.0.2:5060")
+	trusted, err := sip.NewACL([]string{"127.0.0.2/32"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "spam", CallerIdentity(req, NewTrustDomain(trusted)).User)
+	req.SetSource("127.0.0.3:5060")
+	assert.Equal(t, "alice", CallerIdentity(req, NewTrustDomain(trusted)).User)
+	assert.Equal(t, "alice", CallerIdentity(req, nil).User)
+
+	srv.SetDoNotDisturb(true)
+	tx = invite("alice")
+	assert.Equal(t, sip.StatusCode(486), tx.Result()[0].StatusCode)
+	assert.Equal(t, 1, handled)
+}
+
+func TestServerCallScreeningTrustDomain(t *testing.T) {
+	ua, _ := NewUA()
+	defer ua.Close()
+
+	trusted, err := sip.NewACL([]string{"127.0.0.2/32"}, nil)
+	require.NoError(t, err)
+	srv, err := NewServer(ua,
+		WithServerCallScreening(func(caller sip.Uri, req *sip.Request) ScreenResult {
+			if caller.User == "spam" {
+				return ScreenResult{Action: ScreenDecline}
+			}
+			return ScreenResult{Action: ScreenAccept}
+		}),
+		WithServerCallScreeningTrustDomain(NewTrustDomain(trusted)),
+	)
+	require.NoError(t, err)
+	srv.OnInvite(func(req *sip.Request, tx sip.ServerTransaction) {
+		tx.Respond(sip.NewResponseFromRequest(req, 200, "OK", nil))
+	})
+
+	invite := func(source string) sip.StatusCode {
+		req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+		req.AppendHeader(sip.NewHeader("P-Asserted-Identity", "<sip:spam@127.0.0.2>"))
+		req.SetSource(source)
+		tx := siptest.NewServerTxRecorder(req)
+		srv.handleRequest(req, tx)
+		require.Len(t, tx.Result(), 1)
+		return tx.Result()[0].StatusCode
+	}
+
+	assert.Equal(t, sip.StatusCode(603), invite("127.0.0.2:5060"))
+	// Spoofed identity from untrusted peer is ignored
+	assert.Equal(t, sip.StatusCode(200), invite("127.0.0.3:5060"))
+}
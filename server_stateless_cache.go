@@ -0,0 +1,122 @@
+package sipgo
+
+import (
+	"sync"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+// WithServerStatelessCache absorbs request retransmissions when responding in stateless mode with WriteResponse.
+// Requests are remembered by Via branch, sent-by and method for ttl. Retransmission does not invoke handler again,
+// instead last response written for request is replayed. If there is no response yet retransmission is dropped
+func WithServerStatelessCache(ttl time.Duration) ServerOption {
+	return func(s *Server) error {
+		s.statelessCache = newStatelessCache(ttl)
+		return nil
+	}
+}
+
+type statelessCache struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]*statelessEntry
+	lastSweep time.Time
+}
+
+type statelessEntry struct {
+	res     *sip.Response
+	expires time.Time
+}
+
+func newStatelessCache(ttl time.Duration) *statelessCache {
+	return &statelessCache{
+		ttl:       ttl,
+		entries:   make(map[string]*statelessEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+func statelessCacheKey(via *sip.ViaHeader, method sip.RequestMethod) (string, bool) {
+	if via == nil {
+		return "", false
+	}
+	branch, _ := via.Params.Get("branch")
+	if branch == "" {
+		return "", false
+	}
+	return branch + "__" + via.SentBy() + "__" + string(method), true
+}
+
+// seen remembers request. It returns true and last response if request is retransmission
+func (c *statelessCache) seen(req *sip.Request) (*sip.Response, bool) {
+	key, ok := statelessCacheKey(req.Via(), req.Method)
+	if !ok {
+		return nil, false
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Sub(c.lastSweep) > c.ttl {
+		c.sweep(now)
+	}
+
+	if e, exists := c.entries[key]; exists && now.Before(e.expires) {
+		return e.res, true
+	}
+	c.entries[key] = &statelessEntry{expires: now.Add(c.ttl)}
+	return nil, false
+}
+
+// store saves response for replaying on request retransmission
+func (c *statelessCache) store(res *sip.Response) {
+	cseq := res.CSeq()
+	if cseq == nil {
+		return
+	}
+	key, ok := statelessCacheKey(res.Via(), cseq.MethodName)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, exists := c.entries[key]; exists {
+		e.res = res
+	}
+}
+
+// sweep removes expired entries. Lock must be held
+func (c *statelessCache) sweep(now time.Time) {
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.lastSweep = now
+}
+
+// absorbRetransmission replays cached response in case request is retransmission
+func (srv *Server) absorbRetransmission(req *sip.Request) bool {
+	if srv.statelessCache == nil || req.IsAck() {
+		return false
+	}
+
+	res, retransmission := srv.statelessCache.seen(req)
+	if !retransmission {
+		return false
+	}
+
+	if res == nil {
+		srv.log.Debug().Str("req", req.Method.String()).Msg("Request retransmission absorbed")
+		return true
+	}
+
+	srv.log.Debug().Str("req", req.Method.String()).Int("status", int(res.StatusCode)).Msg("Request retransmission. Replaying response")
+	if err := srv.tp.WriteMsg(res); err != nil {
+		srv.log.Error().Err(err).Msg("Failed to replay response")
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package sipgo
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerStatelessCache(t *testing.T) {
+	ua, _ := NewUA()
+	defer ua.Close()
+
+	srv, err := NewServer(ua, WithServerStatelessCache(time.Minute))
+	require.NoError(t, err)
+
+	var handled atomic.Int32
+	srv.OnOptions(func(req *sip.Request, tx sip.ServerTransaction) {
+		handled.Add(1)
+		srv.WriteResponse(sip.NewResponseFromRequest(req, 200, "OK", nil))
+	})
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go srv.ServeUDP(conn)
+
+	client, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer client.Close()
+
+	req := testCreateMessage(t, []string{
+		"OPTIONS sip:bob@" + conn.LocalAddr().String() + " SIP/2.0",
+		"Via: SIP/2.0/UDP " + client.LocalAddr().String() + ";branch=" + sip.GenerateBranch(),
+		"From: <sip:alice@127.0.0.1>;tag=1928301774",
+		"To: <sip:bob@127.0.0.1>",
+		"Call-ID: stateless-cache-test",
+		"CSeq: 1 OPTIONS",
+		"Content-Length: 0",
+		"",
+		"",
+	}).(*sip.Request)
+
+	buf := make([]byte, 2000)
+	for i := 0; i < 3; i++ {
+		_, err = client.WriteTo([]byte(req.String()), conn.LocalAddr())
+		require.NoError(t, err)
+
+		client.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := client.ReadFrom(buf)
+		require.NoError(t, err)
+		res, err := sip.ParseMessage(buf[:n])
+		require.NoError(t, err)
+		assert.Equal(t, sip.StatusCode(200), res.(*sip.Response).StatusCode)
+	}
+	assert.Equal(t, int32(1), handled.Load())
+}
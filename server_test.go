@@ -1,6 +1,7 @@
 package sipgo
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/emiago/sipgo/fakes"
 	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
@@ -401,3 +403,59 @@ func ExampleServer_OnNoRoute() {
 		}
 	})
 }
+
+func TestServerServeListener(t *testing.T) {
+	ua, err := NewUA()
+	require.NoError(t, err)
+	defer ua.Close()
+
+	srv, err := NewServer(ua)
+	require.NoError(t, err)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	go func() { errs <- srv.ServeListener(ctx, "tcp", l) }()
+	go func() { errs <- srv.ServePacketConn(ctx, conn) }()
+
+	// Listener must be served
+	c, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	c.Close()
+
+	cancel()
+	for i := 0; i < 2; i++ {
+		err := <-errs
+		if err != nil {
+			require.ErrorIs(t, err, net.ErrClosed)
+		}
+	}
+
+	require.ErrorIs(t, srv.ServeListener(context.Background(), "sctp", l), sip.ErrTransportNotSuported)
+}
+
+func TestServerTxResponders(t *testing.T) {
+	req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	tx := siptest.NewServerTxRecorder(req)
+	defer tx.Terminate()
+
+	require.NoError(t, tx.Provisional(sip.StatusRinging))
+	require.Error(t, tx.Provisional(sip.StatusOK))
+	require.NoError(t, tx.RespondSDP([]byte("v=0\r\n")))
+
+	results := tx.Result()
+	require.Len(t, results, 2)
+	assert.Equal(t, "180 Ringing", fmt.Sprintf("%d %s", results[0].StatusCode, results[0].Reason))
+	assert.Equal(t, sip.StatusOK, results[1].StatusCode)
+	assert.Equal(t, "application/sdp", results[1].ContentType().Value())
+	assert.Equal(t, "v=0\r\n", string(results[1].Body()))
+
+	// Provisional and final response are in same dialog
+	tag := results[0].To().Params["tag"]
+	assert.NotEmpty(t, tag)
+	assert.Equal(t, tag, results[1].To().Params["tag"])
+}
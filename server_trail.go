@@ -0,0 +1,29 @@
+package sipgo
+
+import (
+	"github.com/emiago/sipgo/sip"
+	"github.com/rs/zerolog"
+)
+
+// WithServerRequestTrail attaches sip.Trail on every incoming request. Server components like Router,
+// screening or SIPS policy record their decisions in it, and middlewares and handlers can add own with
+// req.Trail().Add. Trail is logged with handler errors and on debug level when request is handled
+// Ex:
+//
+//	srv.ServeRequest(func(req *sip.Request) {
+//		req.Trail().Add("normalize", "stripped 00 prefix")
+//	})
+func WithServerRequestTrail() ServerOption {
+	return func(s *Server) error {
+		s.trail = true
+		return nil
+	}
+}
+
+// logTrail adds request trail on log event
+func logTrail(e *zerolog.Event, req *sip.Request) *zerolog.Event {
+	if t := req.Trail(); t != nil {
+		return e.Stringer("trail", t)
+	}
+	return e
+}
@@ -0,0 +1,65 @@
+package sipgo
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerRequestTrail(t *testing.T) {
+	ua, err := NewUA()
+	require.NoError(t, err)
+	defer ua.Close()
+
+	logs := bytes.NewBuffer(nil)
+	srv, err := NewServer(ua, WithServerRequestTrail(), WithServerLogger(zerolog.New(logs)))
+	require.NoError(t, err)
+
+	srv.ServeRequest(func(req *sip.Request) {
+		req.Trail().Add("normalize", "stripped 00 prefix")
+	})
+
+	var trail []sip.TrailEntry
+	router := NewRouter()
+	require.NoError(t, router.Route("_1XX", srv.HandlerErr(func(req *sip.Request, tx sip.ServerTransaction) error {
+		return errors.New("no trunk available")
+	})))
+	require.NoError(t, router.Route("bob", func(req *sip.Request, tx sip.ServerTransaction) {
+		trail = req.Trail().Entries()
+		tx.Respond(sip.NewResponseFromRequest(req, sip.StatusOK, "OK", nil))
+	}))
+	srv.OnInvite(router.Handle)
+
+	req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	srv.handleRequest(req, siptest.NewServerTxRecorder(req))
+	require.Len(t, trail, 2)
+	assert.Equal(t, "normalize", trail[0].Source)
+	assert.Equal(t, "router", trail[1].Source)
+	assert.Equal(t, "matched bob", trail[1].Decision)
+
+	// Trail is dumped on handler error
+	req, _, _ = createTestInvite(t, "sip:101@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	tx := siptest.NewServerTxRecorder(req)
+	srv.handleRequest(req, tx)
+	require.Len(t, tx.Result(), 1)
+	assert.Equal(t, sip.StatusInternalServerError, tx.Result()[0].StatusCode)
+	assert.Contains(t, logs.String(), "normalize: stripped 00 prefix -> router: matched _1XX -> handler: failed: no trunk available")
+
+	t.Run("Disabled", func(t *testing.T) {
+		srv, err := NewServer(ua)
+		require.NoError(t, err)
+		srv.OnInvite(func(req *sip.Request, tx sip.ServerTransaction) {
+			// Recording without trail is ignored
+			req.Trail().Add("handler", "ignored")
+			assert.Nil(t, req.Trail())
+		})
+		req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+		srv.handleRequest(req, siptest.NewServerTxRecorder(req))
+	})
+}
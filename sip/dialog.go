@@ -9,6 +9,8 @@ const (
 	DialogStateConfirmed DialogState = 2
 	// Dialog received BYE
 	DialogStateEnded DialogState = 3
+	// Dialog received provisional response with To tag
+	DialogStateEarly DialogState = 4
 )
 
 func (s DialogState) String() string {
@@ -19,6 +21,8 @@ func (s DialogState) String() string {
 		return "Confirmed"
 	case DialogStateEnded:
 		return "Ended"
+	case DialogStateEarly:
+		return "Early"
 	default:
 		return "Unknown Dialog State"
 	}
@@ -0,0 +1,123 @@
+package sip
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ContentTypeDTMFRelay is INFO body with Signal and Duration lines, widely used by vendors
+	ContentTypeDTMFRelay = "application/dtmf-relay"
+	// ContentTypeDTMF is INFO body with digit only
+	ContentTypeDTMF = "application/dtmf"
+)
+
+var (
+	ErrDTMFInvalid = errors.New("invalid DTMF")
+	// ErrDTMFContentType is returned when body content type does not carry DTMF
+	ErrDTMFContentType = errors.New("unsupported DTMF content type")
+)
+
+// DTMF is digit sent out of band in INFO body
+type DTMF struct {
+	// Digit is one of 0-9, *, #, A-D
+	Digit rune
+	// Duration is tone duration. Zero when not known
+	Duration time.Duration
+}
+
+func (d DTMF) String() string {
+	return string(d.Digit)
+}
+
+// RelayBody returns application/dtmf-relay body
+func (d DTMF) RelayBody() []byte {
+	body := "Signal=" + string(d.Digit) + "\r\n"
+	if d.Duration > 0 {
+		body += "Duration=" + strconv.FormatInt(d.Duration.Milliseconds(), 10) + "\r\n"
+	}
+	return []byte(body)
+}
+
+// ParseDTMFBody parses DTMF from INFO body by its content type.
+// Supported are application/dtmf-relay and application/dtmf
+func ParseDTMFBody(contentType string, body []byte) (DTMF, error) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.ToLower(strings.TrimSpace(mediaType)) {
+	case ContentTypeDTMFRelay:
+		return ParseDTMFRelay(body)
+	case ContentTypeDTMF:
+		return ParseDTMF(body)
+	}
+	return DTMF{}, fmt.Errorf("%w: %q", ErrDTMFContentType, contentType)
+}
+
+// ParseDTMFRelay parses application/dtmf-relay body
+//
+//	Signal=5
+//	Duration=160
+func ParseDTMFRelay(body []byte) (DTMF, error) {
+	var d DTMF
+	var signal bool
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		key, val, found := strings.Cut(strings.TrimSpace(string(line)), "=")
+		if !found {
+			continue
+		}
+		val = strings.TrimSpace(val)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "signal":
+			digit, err := parseDTMFDigit(val)
+			if err != nil {
+				return d, err
+			}
+			d.Digit = digit
+			signal = true
+		case "duration":
+			ms, err := strconv.Atoi(val)
+			if err != nil || ms < 0 {
+				return d, fmt.Errorf("%w: duration %q", ErrDTMFInvalid, val)
+			}
+			d.Duration = time.Duration(ms) * time.Millisecond
+		}
+	}
+	if !signal {
+		return d, fmt.Errorf("%w: signal missing", ErrDTMFInvalid)
+	}
+	return d, nil
+}
+
+// ParseDTMF parses application/dtmf body, which contains only digit
+func ParseDTMF(body []byte) (DTMF, error) {
+	digit, err := parseDTMFDigit(strings.TrimSpace(string(body)))
+	return DTMF{Digit: digit}, err
+}
+
+// parseDTMFDigit parses digit. Some vendors send * and # as event codes 10 and 11
+func parseDTMFDigit(s string) (rune, error) {
+	switch s {
+	case "10":
+		return '*', nil
+	case "11":
+		return '#', nil
+	}
+	if len(s) != 1 || !IsDTMFDigit(rune(s[0])) {
+		return 0, fmt.Errorf("%w: digit %q", ErrDTMFInvalid, s)
+	}
+	return rune(strings.ToUpper(s)[0]), nil
+}
+
+// IsDTMFDigit reports is digit valid DTMF digit
+func IsDTMFDigit(digit rune) bool {
+	switch {
+	case digit >= '0' && digit <= '9', digit == '*', digit == '#':
+		return true
+	case digit >= 'A' && digit <= 'D', digit >= 'a' && digit <= 'd':
+		return true
+	}
+	return false
+}
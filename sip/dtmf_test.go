@@ -0,0 +1,46 @@
+package sip
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDTMFRelay(t *testing.T) {
+	for body, exp := range map[string]DTMF{
+		"Signal=5\r\nDuration=160\r\n":  {Digit: '5', Duration: 160 * time.Millisecond},
+		"signal= *\nduration= 250":      {Digit: '*', Duration: 250 * time.Millisecond},
+		"Signal=11\r\nDuration=100\r\n": {Digit: '#', Duration: 100 * time.Millisecond},
+		"Signal=10\r\n":                 {Digit: '*'},
+		"Signal=a\r\nDuration=0\r\n":    {Digit: 'A'},
+	} {
+		dtmf, err := ParseDTMFRelay([]byte(body))
+		require.NoError(t, err, body)
+		assert.Equal(t, exp, dtmf, body)
+	}
+
+	for _, body := range []string{"", "Duration=160\r\n", "Signal=55\r\n", "Signal=x\r\n", "Signal=1\r\nDuration=-1\r\n"} {
+		_, err := ParseDTMFRelay([]byte(body))
+		assert.ErrorIs(t, err, ErrDTMFInvalid, body)
+	}
+}
+
+func TestParseDTMFBody(t *testing.T) {
+	dtmf, err := ParseDTMFBody("application/dtmf", []byte("9\r\n"))
+	require.NoError(t, err)
+	assert.Equal(t, DTMF{Digit: '9'}, dtmf)
+
+	dtmf, err = ParseDTMFBody("Application/DTMF-Relay; charset=utf-8", DTMF{Digit: '#', Duration: time.Second}.RelayBody())
+	require.NoError(t, err)
+	assert.Equal(t, DTMF{Digit: '#', Duration: time.Second}, dtmf)
+
+	_, err = ParseDTMFBody("application/sdp", []byte("v=0"))
+	assert.ErrorIs(t, err, ErrDTMFContentType)
+}
+
+func TestDTMFRelayBody(t *testing.T) {
+	assert.Equal(t, "Signal=5\r\nDuration=160\r\n", string(DTMF{Digit: '5', Duration: 160 * time.Millisecond}.RelayBody()))
+	assert.Equal(t, "Signal=*\r\n", string(DTMF{Digit: '*'}.RelayBody()))
+}
@@ -50,6 +50,9 @@ type headers struct {
 	route         *RouteHeader
 	recordRoute   *RecordRouteHeader
 	maxForwards   *MaxForwardsHeader
+
+	// raw are header lines as received, recorded on parser request
+	raw []RawHeader
 }
 
 func (hs *headers) String() string {
@@ -68,6 +71,51 @@ func (hs *headers) StringWrite(buffer io.StringWriter) {
 	buffer.WriteString("\r\n")
 }
 
+// syncContentLength makes Content-Length header match body length before message is written.
+// Stale Content-Length is replaced and duplicates are dropped, as mismatch mis-frames message on stream transports.
+// Missing Content-Length is added, even for empty body, as it is required on stream transports
+// https://datatracker.ietf.org/doc/html/rfc3261#section-20.14
+func (hs *headers) syncContentLength(bodyLen int) {
+	count := 0
+	var found Header
+	for _, header := range hs.headerOrder {
+		if isContentLengthHeader(header) {
+			count++
+			found = header
+		}
+	}
+	if count == 1 && hs.contentLength != nil && found == Header(hs.contentLength) && int(*hs.contentLength) == bodyLen {
+		return
+	}
+
+	length := ContentLengthHeader(bodyLen)
+	headers := make([]Header, 0, len(hs.headerOrder)+1)
+	written := false
+	for _, header := range hs.headerOrder {
+		if !isContentLengthHeader(header) {
+			headers = append(headers, header)
+			continue
+		}
+		if !written {
+			headers = append(headers, &length)
+			written = true
+		}
+	}
+	if !written {
+		headers = append(headers, &length)
+	}
+	hs.headerOrder = headers
+	hs.contentLength = &length
+}
+
+func isContentLengthHeader(h Header) bool {
+	if _, ok := h.(*ContentLengthHeader); ok {
+		return true
+	}
+	name := h.Name()
+	return strings.EqualFold(name, "content-length") || strings.EqualFold(name, "l")
+}
+
 // setHeaderRef should be always called when new header is added
 // it should point to TOPMOST header value
 // it creates fast access to header
@@ -196,6 +244,7 @@ func (hs *headers) ReplaceHeader(header Header) {
 }
 
 // Headers gets some headers.
+// Returned slice is internal header list. For changing headers while iterating use EachHeader
 func (hs *headers) Headers() []Header {
 	// hdrs := make([]Header, 0)
 	// for _, key := range hs.headerOrder {
@@ -267,6 +316,79 @@ func (hs *headers) RemoveHeader(name string) (removed bool) {
 	return removed
 }
 
+// EachHeader calls fn for every header in order until fn returns false.
+// Iteration is done over snapshot, so fn can safely add, remove or replace headers on message
+func (hs *headers) EachHeader(fn func(h Header) bool) {
+	snapshot := make([]Header, len(hs.headerOrder))
+	copy(snapshot, hs.headerOrder)
+	for _, h := range snapshot {
+		if !fn(h) {
+			return
+		}
+	}
+}
+
+// RemoveHeaders removes all headers with name and returns number of removed headers.
+// Name is matched case insensitive
+func (hs *headers) RemoveHeaders(name string) int {
+	nameLower := HeaderToLower(name)
+	newOrder := make([]Header, 0, len(hs.headerOrder))
+	for _, h := range hs.headerOrder {
+		if HeaderToLower(h.Name()) == nameLower {
+			continue
+		}
+		newOrder = append(newOrder, h)
+	}
+	removed := len(hs.headerOrder) - len(newOrder)
+	if removed > 0 {
+		hs.headerOrder = newOrder
+		hs.resetHeaderRefs()
+	}
+	return removed
+}
+
+// ReplaceHeaders replaces all headers with name by given headers, placed at position of first removed one.
+// In case header does not exist they are appended. Name is matched case insensitive
+func (hs *headers) ReplaceHeaders(name string, headers ...Header) {
+	nameLower := HeaderToLower(name)
+	newOrder := make([]Header, 0, len(hs.headerOrder)+len(headers))
+	replaced := false
+	for _, h := range hs.headerOrder {
+		if HeaderToLower(h.Name()) != nameLower {
+			newOrder = append(newOrder, h)
+			continue
+		}
+		if !replaced {
+			newOrder = append(newOrder, headers...)
+			replaced = true
+		}
+	}
+	if !replaced {
+		newOrder = append(newOrder, headers...)
+	}
+	hs.headerOrder = newOrder
+	hs.resetHeaderRefs()
+}
+
+// resetHeaderRefs rebuilds fast access refs to topmost headers after bulk change of header list.
+// Lazy parsed refs are dropped and parsed again on access
+func (hs *headers) resetHeaderRefs() {
+	hs.via = nil
+	hs.from = nil
+	hs.to = nil
+	hs.callid = nil
+	hs.contact = nil
+	hs.cseq = nil
+	hs.contentLength = nil
+	hs.contentType = nil
+	hs.route = nil
+	hs.recordRoute = nil
+	hs.maxForwards = nil
+	for i := len(hs.headerOrder) - 1; i >= 0; i-- {
+		hs.setHeaderRef(hs.headerOrder[i])
+	}
+}
+
 // CloneHeaders returns all cloned headers in slice.
 func (hs *headers) CloneHeaders() []Header {
 	hdrs := make([]Header, 0)
@@ -344,6 +466,15 @@ func (hs *headers) MaxForwards() *MaxForwardsHeader {
 	return hs.maxForwards
 }
 
+// Expires returns Expires parsed header or nil if not exists
+func (hs *headers) Expires() *ExpiresHeader {
+	var h ExpiresHeader
+	if parseHeaderLazy(hs, parseExpiresHeader, []string{"expires"}, &h) {
+		return &h
+	}
+	return nil
+}
+
 // ContentLength returns Content-Length parsed header or nil if not exists
 func (hs *headers) ContentLength() *ContentLengthHeader {
 	if hs.contentLength == nil {
@@ -825,7 +956,7 @@ type ViaHeader struct {
 
 func (hop *ViaHeader) SentBy() string {
 	var buf bytes.Buffer
-	buf.WriteString(hop.Host)
+	buf.WriteString(hostBracket(hop.Host))
 	if hop.Port > 0 {
 		buf.WriteString(fmt.Sprintf(":%d", hop.Port))
 	}
@@ -860,7 +991,7 @@ func (h *ViaHeader) ValueStringWrite(buffer io.StringWriter) {
 	buffer.WriteString("/")
 	buffer.WriteString(h.Transport)
 	buffer.WriteString(" ")
-	buffer.WriteString(h.Host)
+	buffer.WriteString(hostBracket(h.Host))
 
 	if h.Port > 0 {
 		buffer.WriteString(":")
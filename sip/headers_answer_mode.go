@@ -0,0 +1,128 @@
+package sip
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+const (
+	AnswerModeManual = "Manual"
+	AnswerModeAuto   = "Auto"
+)
+
+// AnswerModeHeader is Answer-Mode or Priv-Answer-Mode header representation
+// https://datatracker.ietf.org/doc/html/rfc5373
+// Answer-Mode: Auto;require
+type AnswerModeHeader struct {
+	// Priv marks Priv-Answer-Mode header. It requests privileged answer mode which can override
+	// local policies like do not disturb
+	Priv bool
+	// Mode is Manual or Auto
+	Mode string
+	// Require is set when request must be rejected if mode can not be honored
+	Require bool
+	// Params are other generic params
+	Params HeaderParams
+}
+
+func (h *AnswerModeHeader) Name() string {
+	if h.Priv {
+		return "Priv-Answer-Mode"
+	}
+	return "Answer-Mode"
+}
+
+func (h *AnswerModeHeader) Value() string {
+	var buffer strings.Builder
+	h.ValueStringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *AnswerModeHeader) ValueStringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.Mode)
+	if h.Require {
+		buffer.WriteString(";require")
+	}
+	if len(h.Params) > 0 {
+		buffer.WriteString(";")
+		buffer.WriteString(h.Params.ToString(';'))
+	}
+}
+
+func (h *AnswerModeHeader) String() string {
+	var buffer strings.Builder
+	h.StringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *AnswerModeHeader) StringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.Name())
+	buffer.WriteString(": ")
+	h.ValueStringWrite(buffer)
+}
+
+func (h *AnswerModeHeader) headerClone() Header {
+	return h.Clone()
+}
+
+func (h *AnswerModeHeader) Clone() *AnswerModeHeader {
+	c := *h
+	if h.Params != nil {
+		c.Params = h.Params.clone()
+	}
+	return &c
+}
+
+// IsAuto reports is automatic answer requested
+func (h *AnswerModeHeader) IsAuto() bool {
+	return strings.EqualFold(h.Mode, AnswerModeAuto)
+}
+
+// AnswerMode returns Answer-Mode parsed header or nil if not exists
+func (hs *headers) AnswerMode() *AnswerModeHeader {
+	return hs.answerMode("answer-mode", false)
+}
+
+// PrivAnswerMode returns Priv-Answer-Mode parsed header or nil if not exists
+func (hs *headers) PrivAnswerMode() *AnswerModeHeader {
+	return hs.answerMode("priv-answer-mode", true)
+}
+
+func (hs *headers) answerMode(name string, priv bool) *AnswerModeHeader {
+	hdr := hs.getHeader(name)
+	if hdr == nil {
+		return nil
+	}
+	if h, ok := hdr.(*AnswerModeHeader); ok {
+		return h
+	}
+
+	h := &AnswerModeHeader{Priv: priv}
+	if err := parseAnswerModeHeader(hdr.Value(), h); err != nil {
+		return nil
+	}
+	return h
+}
+
+func parseAnswerModeHeader(headerText string, h *AnswerModeHeader) error {
+	mode, rest, _ := strings.Cut(strings.TrimSpace(headerText), ";")
+	mode = strings.TrimSpace(mode)
+	if mode == "" {
+		return errors.New("empty answer mode")
+	}
+
+	params := NewParams()
+	if _, err := UnmarshalParams(rest, ';', 0, params); err != nil {
+		return err
+	}
+	h.Require = params.Has("require")
+	params.Remove("require")
+	if len(params) == 0 {
+		params = nil
+	}
+
+	h.Mode = mode
+	h.Params = params
+	return nil
+}
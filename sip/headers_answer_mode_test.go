@@ -0,0 +1,35 @@
+package sip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnswerModeHeader(t *testing.T) {
+	req := NewRequest(INVITE, &Uri{User: "bob", Host: "example.com"})
+	req.AppendHeader(NewHeader("Answer-Mode", "Auto;require"))
+	req.AppendHeader(NewHeader("Priv-Answer-Mode", "Manual"))
+
+	h := req.AnswerMode()
+	require.NotNil(t, h)
+	assert.True(t, h.IsAuto())
+	assert.True(t, h.Require)
+	assert.False(t, h.Priv)
+	assert.Equal(t, "Answer-Mode: Auto;require", h.String())
+
+	priv := req.PrivAnswerMode()
+	require.NotNil(t, priv)
+	assert.False(t, priv.IsAuto())
+	assert.False(t, priv.Require)
+	assert.Equal(t, "Priv-Answer-Mode: Manual", priv.String())
+
+	res := NewResponse(200, "OK")
+	res.AppendHeader(&AnswerModeHeader{Mode: AnswerModeAuto})
+	require.NotNil(t, res.AnswerMode())
+	assert.Equal(t, AnswerModeAuto, res.AnswerMode().Mode)
+
+	var empty AnswerModeHeader
+	assert.Error(t, parseAnswerModeHeader(" ", &empty))
+}
@@ -0,0 +1,235 @@
+package sip
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// AuthorizationHeader is Authorization or Proxy-Authorization header representation
+// https://datatracker.ietf.org/doc/html/rfc3261#section-20.7
+// Authorization: Digest username="alice", realm="atlanta.com", nonce="84a4cc6f", uri="sip:bob@biloxi.com", response="7587245234b3434cc3412213e5f113a5"
+type AuthorizationHeader struct {
+	// Proxy marks Proxy-Authorization header
+	Proxy  bool
+	Scheme string
+	// Params are auth params in order. Values are kept as received, with quotes. Use Get for unquoted value
+	Params []HeaderKV
+}
+
+func (h *AuthorizationHeader) Name() string {
+	if h.Proxy {
+		return "Proxy-Authorization"
+	}
+	return "Authorization"
+}
+
+func (h *AuthorizationHeader) Value() string {
+	var buffer strings.Builder
+	h.ValueStringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *AuthorizationHeader) ValueStringWrite(buffer io.StringWriter) {
+	authStringWrite(buffer, h.Scheme, h.Params)
+}
+
+func (h *AuthorizationHeader) String() string {
+	var buffer strings.Builder
+	h.StringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *AuthorizationHeader) StringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.Name())
+	buffer.WriteString(": ")
+	h.ValueStringWrite(buffer)
+}
+
+func (h *AuthorizationHeader) headerClone() Header {
+	return h.Clone()
+}
+
+func (h *AuthorizationHeader) Clone() *AuthorizationHeader {
+	c := *h
+	c.Params = append([]HeaderKV(nil), h.Params...)
+	return &c
+}
+
+// Get returns unquoted auth param value
+func (h *AuthorizationHeader) Get(key string) (string, bool) {
+	return authParamGet(h.Params, key)
+}
+
+// WWWAuthenticateHeader is WWW-Authenticate or Proxy-Authenticate header representation
+// https://datatracker.ietf.org/doc/html/rfc3261#section-20.44
+// WWW-Authenticate: Digest realm="atlanta.com", nonce="84a4cc6f", qop="auth", algorithm=MD5
+type WWWAuthenticateHeader struct {
+	// Proxy marks Proxy-Authenticate header
+	Proxy  bool
+	Scheme string
+	// Params are auth params in order. Values are kept as received, with quotes. Use Get for unquoted value
+	Params []HeaderKV
+}
+
+func (h *WWWAuthenticateHeader) Name() string {
+	if h.Proxy {
+		return "Proxy-Authenticate"
+	}
+	return "WWW-Authenticate"
+}
+
+func (h *WWWAuthenticateHeader) Value() string {
+	var buffer strings.Builder
+	h.ValueStringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *WWWAuthenticateHeader) ValueStringWrite(buffer io.StringWriter) {
+	authStringWrite(buffer, h.Scheme, h.Params)
+}
+
+func (h *WWWAuthenticateHeader) String() string {
+	var buffer strings.Builder
+	h.StringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *WWWAuthenticateHeader) StringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.Name())
+	buffer.WriteString(": ")
+	h.ValueStringWrite(buffer)
+}
+
+func (h *WWWAuthenticateHeader) headerClone() Header {
+	return h.Clone()
+}
+
+func (h *WWWAuthenticateHeader) Clone() *WWWAuthenticateHeader {
+	c := *h
+	c.Params = append([]HeaderKV(nil), h.Params...)
+	return &c
+}
+
+// Get returns unquoted auth param value
+func (h *WWWAuthenticateHeader) Get(key string) (string, bool) {
+	return authParamGet(h.Params, key)
+}
+
+// Authorization returns Authorization parsed header or nil if not exists
+func (hs *headers) Authorization() *AuthorizationHeader {
+	return hs.authorization("authorization", false)
+}
+
+// ProxyAuthorization returns Proxy-Authorization parsed header or nil if not exists
+func (hs *headers) ProxyAuthorization() *AuthorizationHeader {
+	return hs.authorization("proxy-authorization", true)
+}
+
+func (hs *headers) authorization(name string, proxy bool) *AuthorizationHeader {
+	hdr := hs.getHeader(name)
+	if hdr == nil {
+		return nil
+	}
+	if h, ok := hdr.(*AuthorizationHeader); ok {
+		return h
+	}
+
+	scheme, params, err := parseAuthHeader(hdr.Value())
+	if err != nil {
+		return nil
+	}
+	return &AuthorizationHeader{Proxy: proxy, Scheme: scheme, Params: params}
+}
+
+// WWWAuthenticate returns WWW-Authenticate parsed header or nil if not exists
+func (hs *headers) WWWAuthenticate() *WWWAuthenticateHeader {
+	return hs.wwwAuthenticate("www-authenticate", false)
+}
+
+// ProxyAuthenticate returns Proxy-Authenticate parsed header or nil if not exists
+func (hs *headers) ProxyAuthenticate() *WWWAuthenticateHeader {
+	return hs.wwwAuthenticate("proxy-authenticate", true)
+}
+
+func (hs *headers) wwwAuthenticate(name string, proxy bool) *WWWAuthenticateHeader {
+	hdr := hs.getHeader(name)
+	if hdr == nil {
+		return nil
+	}
+	if h, ok := hdr.(*WWWAuthenticateHeader); ok {
+		return h
+	}
+
+	scheme, params, err := parseAuthHeader(hdr.Value())
+	if err != nil {
+		return nil
+	}
+	return &WWWAuthenticateHeader{Proxy: proxy, Scheme: scheme, Params: params}
+}
+
+// parseAuthHeader parses scheme and comma separated auth params. Commas inside quotes are kept
+func parseAuthHeader(headerText string) (string, []HeaderKV, error) {
+	headerText = strings.TrimSpace(headerText)
+	scheme, rest, _ := strings.Cut(headerText, " ")
+	if scheme == "" {
+		return "", nil, errors.New("empty auth scheme")
+	}
+
+	var params []HeaderKV
+	inQuotes := false
+	start := 0
+	for i := 0; i <= len(rest); i++ {
+		if i < len(rest) {
+			switch rest[i] {
+			case '"':
+				inQuotes = !inQuotes
+				continue
+			case ',':
+				if inQuotes {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+
+		kv := strings.TrimSpace(rest[start:i])
+		start = i + 1
+		if kv == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return "", nil, errors.New("invalid auth param " + kv)
+		}
+		params = append(params, HeaderKV{K: strings.TrimSpace(k), V: strings.TrimSpace(v)})
+	}
+	if inQuotes {
+		return "", nil, errors.New("unclosed quote in auth params")
+	}
+	return scheme, params, nil
+}
+
+func authStringWrite(buffer io.StringWriter, scheme string, params []HeaderKV) {
+	buffer.WriteString(scheme)
+	for i, kv := range params {
+		if i == 0 {
+			buffer.WriteString(" ")
+		} else {
+			buffer.WriteString(", ")
+		}
+		buffer.WriteString(kv.K)
+		buffer.WriteString("=")
+		buffer.WriteString(kv.V)
+	}
+}
+
+func authParamGet(params []HeaderKV, key string) (string, bool) {
+	for _, kv := range params {
+		if strings.EqualFold(kv.K, key) {
+			return strings.Trim(kv.V, `"`), true
+		}
+	}
+	return "", false
+}
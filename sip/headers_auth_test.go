@@ -0,0 +1,52 @@
+package sip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthHeaders(t *testing.T) {
+	req := NewRequest(INVITE, &Uri{User: "bob", Host: "example.com"})
+	req.AppendHeader(NewHeader("Authorization", `Digest username="alice", realm="atlanta.com", uri="sip:bob@example.com", response="abc,def"`))
+	req.AppendHeader(NewHeader("Proxy-Authorization", `Digest username="alice", nonce="1234"`))
+
+	h := req.Authorization()
+	require.NotNil(t, h)
+	assert.False(t, h.Proxy)
+	assert.Equal(t, "Digest", h.Scheme)
+	require.Len(t, h.Params, 4)
+	user, ok := h.Get("Username")
+	assert.True(t, ok)
+	assert.Equal(t, "alice", user)
+	response, _ := h.Get("response")
+	assert.Equal(t, "abc,def", response)
+	assert.Equal(t, `Authorization: Digest username="alice", realm="atlanta.com", uri="sip:bob@example.com", response="abc,def"`, h.String())
+
+	proxy := req.ProxyAuthorization()
+	require.NotNil(t, proxy)
+	assert.Equal(t, "Proxy-Authorization", proxy.Name())
+	nonce, _ := proxy.Get("nonce")
+	assert.Equal(t, "1234", nonce)
+
+	res := NewResponse(401, "Unauthorized")
+	res.AppendHeader(NewHeader("WWW-Authenticate", `Digest realm="atlanta.com", qop="auth,auth-int", algorithm=MD5`))
+	www := res.WWWAuthenticate()
+	require.NotNil(t, www)
+	qop, _ := www.Get("qop")
+	assert.Equal(t, "auth,auth-int", qop)
+	alg, _ := www.Get("algorithm")
+	assert.Equal(t, "MD5", alg)
+	assert.Nil(t, res.ProxyAuthenticate())
+
+	c := www.Clone()
+	c.Params[0].V = `"biloxi.com"`
+	realm, _ := www.Get("realm")
+	assert.Equal(t, "atlanta.com", realm)
+
+	_, _, err := parseAuthHeader(`Digest realm="atlanta.com`)
+	assert.Error(t, err)
+	_, _, err = parseAuthHeader(" ")
+	assert.Error(t, err)
+}
@@ -0,0 +1,83 @@
+package sip
+
+import (
+	"io"
+	"strings"
+)
+
+// CustomHeaderValue is typed value of proprietary header, ex. X-Asterisk-* or P-Charging-Vector.
+// It serializes itself when message is written
+type CustomHeaderValue interface {
+	ValueStringWrite(w io.StringWriter)
+}
+
+// CustomHeaderCloner can be implemented by CustomHeaderValue holding references,
+// otherwise value is shared between cloned headers
+type CustomHeaderCloner interface {
+	CloneValue() CustomHeaderValue
+}
+
+// CustomHeader is header holding typed value. It is created by parser registered with CustomHeaderParser
+// or can be appended directly
+type CustomHeader struct {
+	HeaderName string
+	Data       CustomHeaderValue
+}
+
+func (h *CustomHeader) Name() string { return h.HeaderName }
+
+func (h *CustomHeader) Value() string {
+	var buffer strings.Builder
+	h.Data.ValueStringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *CustomHeader) String() string {
+	var buffer strings.Builder
+	h.StringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *CustomHeader) StringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.HeaderName)
+	buffer.WriteString(": ")
+	h.Data.ValueStringWrite(buffer)
+}
+
+func (h *CustomHeader) headerClone() Header {
+	c := *h
+	if cl, ok := h.Data.(CustomHeaderCloner); ok {
+		c.Data = cl.CloneValue()
+	}
+	return &c
+}
+
+// CustomHeaderParser creates HeaderParser producing CustomHeader with value returned by parse.
+// Name is used when header is written, so compact or lowercased forms are normalized
+// Ex:
+//
+//	p := sip.NewParser(sip.WithHeaderParser(sip.CustomHeaderParser("P-Charging-Vector", parsePCV), "p-charging-vector"))
+func CustomHeaderParser(name string, parse func(value string) (CustomHeaderValue, error)) HeaderParser {
+	return func(headerName string, headerText string) (Header, error) {
+		v, err := parse(headerText)
+		if err != nil {
+			return nil, err
+		}
+		return &CustomHeader{HeaderName: name, Data: v}, nil
+	}
+}
+
+// GetCustomHeader returns typed value of first header with name.
+// Ok is false if header does not exist or it was not parsed as T
+func GetCustomHeader[T CustomHeaderValue](msg Message, name string) (v T, ok bool) {
+	hdrs := msg.GetHeaders(name)
+	if len(hdrs) == 0 {
+		return v, false
+	}
+	h, ok := hdrs[0].(*CustomHeader)
+	if !ok {
+		return v, false
+	}
+	v, ok = h.Data.(T)
+	return v, ok
+}
@@ -0,0 +1,89 @@
+package sip
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testChargingVector struct {
+	ICID   string
+	Params HeaderParams
+}
+
+func (v *testChargingVector) ValueStringWrite(w io.StringWriter) {
+	w.WriteString("icid-value=")
+	w.WriteString(v.ICID)
+	if len(v.Params) > 0 {
+		w.WriteString(";")
+		v.Params.ToStringWrite(';', w)
+	}
+}
+
+func (v *testChargingVector) CloneValue() CustomHeaderValue {
+	c := *v
+	c.Params = v.Params.clone()
+	return &c
+}
+
+func parseTestChargingVector(s string) (CustomHeaderValue, error) {
+	icid, rest, _ := strings.Cut(s, ";")
+	id, ok := strings.CutPrefix(icid, "icid-value=")
+	if !ok {
+		return nil, errors.New("missing icid-value")
+	}
+	v := &testChargingVector{ICID: id, Params: NewParams()}
+	if _, err := UnmarshalParams(rest, ';', 0, v.Params); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func TestCustomHeaderParser(t *testing.T) {
+	parser := NewParser(WithHeaderParser(CustomHeaderParser("P-Charging-Vector", parseTestChargingVector), "P-Charging-Vector"))
+
+	rawMsg := []string{
+		"MESSAGE sip:bob@example.com SIP/2.0",
+		"Via: SIP/2.0/UDP 127.0.0.1:5060;branch=z9hG4bK.123",
+		"From: <sip:alice@example.com>;tag=1",
+		"To: <sip:bob@example.com>",
+		"Call-ID: abc",
+		"CSeq: 1 MESSAGE",
+		"p-charging-vector: icid-value=1234bc9876e;orig-ioi=home1.net",
+		"Content-Length: 0",
+		"",
+		"",
+	}
+	msg, err := parser.ParseSIP([]byte(strings.Join(rawMsg, "\r\n")))
+	require.NoError(t, err)
+
+	pcv, ok := GetCustomHeader[*testChargingVector](msg, "P-Charging-Vector")
+	require.True(t, ok)
+	assert.Equal(t, "1234bc9876e", pcv.ICID)
+	ioi, _ := pcv.Params.Get("orig-ioi")
+	assert.Equal(t, "home1.net", ioi)
+	assert.Contains(t, msg.String(), "P-Charging-Vector: icid-value=1234bc9876e;orig-ioi=home1.net\r\n")
+
+	// Clone does not share value
+	req := msg.(*Request).Clone()
+	cpcv, _ := GetCustomHeader[*testChargingVector](req, "P-Charging-Vector")
+	cpcv.ICID = "changed"
+	assert.Equal(t, "1234bc9876e", pcv.ICID)
+
+	// Default parser keeps it generic
+	msg, err = NewParser().ParseSIP([]byte(strings.Join(rawMsg, "\r\n")))
+	require.NoError(t, err)
+	_, ok = GetCustomHeader[*testChargingVector](msg, "P-Charging-Vector")
+	assert.False(t, ok)
+	_, ok = headersParsers["p-charging-vector"]
+	assert.False(t, ok)
+
+	// Invalid header is skipped as any other badly formatted header
+	msg, err = parser.ParseSIP([]byte(strings.Replace(strings.Join(rawMsg, "\r\n"), "icid-value=", "icid=", 1)))
+	require.NoError(t, err)
+	assert.Empty(t, msg.GetHeaders("P-Charging-Vector"))
+}
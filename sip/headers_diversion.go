@@ -0,0 +1,171 @@
+package sip
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Diversion reasons https://datatracker.ietf.org/doc/html/rfc5806#section-4
+	DiversionUnknown       = "unknown"
+	DiversionUserBusy      = "user-busy"
+	DiversionNoAnswer      = "no-answer"
+	DiversionUnavailable   = "unavailable"
+	DiversionUnconditional = "unconditional"
+	DiversionTimeOfDay     = "time-of-day"
+	DiversionDoNotDisturb  = "do-not-disturb"
+	DiversionDeflection    = "deflection"
+	DiversionFollowMe      = "follow-me"
+	DiversionOutOfService  = "out-of-service"
+	DiversionAway          = "away"
+)
+
+// DiversionHeader is one entry of legacy Diversion header https://datatracker.ietf.org/doc/html/rfc5806
+// Diversion: <sip:alice@example.com>;reason=user-busy;counter=1
+// Most recent diversion is first
+type DiversionHeader struct {
+	DisplayName string
+	// Address is diverting target, which request was diverted from
+	Address Uri
+	// Reason is diversion reason, ex. DiversionUserBusy
+	Reason string
+	// Counter is number of diversions this entry stands for. Zero is not written
+	Counter int
+	// Params are other params, ex. privacy or screen
+	Params HeaderParams
+}
+
+func (h *DiversionHeader) Name() string { return "Diversion" }
+
+func (h *DiversionHeader) Value() string {
+	var buffer strings.Builder
+	h.ValueStringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *DiversionHeader) ValueStringWrite(buffer io.StringWriter) {
+	if h.DisplayName != "" {
+		buffer.WriteString("\"")
+		buffer.WriteString(h.DisplayName)
+		buffer.WriteString("\" ")
+	}
+	buffer.WriteString("<")
+	h.Address.StringWrite(buffer)
+	buffer.WriteString(">")
+	if h.Reason != "" {
+		buffer.WriteString(";reason=")
+		buffer.WriteString(h.Reason)
+	}
+	if h.Counter > 0 {
+		buffer.WriteString(";counter=")
+		buffer.WriteString(strconv.Itoa(h.Counter))
+	}
+	if len(h.Params) > 0 {
+		buffer.WriteString(";")
+		buffer.WriteString(h.Params.ToString(';'))
+	}
+}
+
+func (h *DiversionHeader) String() string {
+	var buffer strings.Builder
+	h.StringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *DiversionHeader) StringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.Name())
+	buffer.WriteString(": ")
+	h.ValueStringWrite(buffer)
+}
+
+func (h *DiversionHeader) headerClone() Header {
+	return h.Clone()
+}
+
+func (h *DiversionHeader) Clone() *DiversionHeader {
+	c := *h
+	c.Address = *h.Address.Clone()
+	if h.Params != nil {
+		c.Params = h.Params.clone()
+	}
+	return &c
+}
+
+// Diversion returns all Diversion entries in order, most recent first. Invalid entries are skipped
+func (req *Request) Diversion() []*DiversionHeader {
+	var entries []*DiversionHeader
+	for _, hdr := range req.GetHeaders("Diversion") {
+		if h, ok := hdr.(*DiversionHeader); ok {
+			entries = append(entries, h)
+			continue
+		}
+		for _, v := range splitHeaderList(hdr.Value()) {
+			h := &DiversionHeader{}
+			if err := parseDiversionHeader(v, h); err != nil {
+				continue
+			}
+			entries = append(entries, h)
+		}
+	}
+	return entries
+}
+
+// AddDiversion records diversion of request from current Request-URI with reason.
+// It must be called before Request-URI is changed. Entry is added on top of Diversion headers and returned
+// https://datatracker.ietf.org/doc/html/rfc5806#section-5
+func (req *Request) AddDiversion(reason string) *DiversionHeader {
+	h := &DiversionHeader{Reason: reason, Counter: 1}
+	if req.Recipient != nil {
+		h.Address = *req.Recipient.Clone()
+	}
+
+	entries := req.Diversion()
+	hdrs := make([]Header, 0, len(entries)+1)
+	hdrs = append(hdrs, h)
+	for _, e := range entries {
+		hdrs = append(hdrs, e)
+	}
+	req.ReplaceHeaders("Diversion", hdrs...)
+	return h
+}
+
+// DiversionReason maps response code which caused retarget to Diversion reason, ex. 486 to DiversionUserBusy.
+// Codes are same as History-Info cause https://datatracker.ietf.org/doc/html/rfc4458#section-3.2
+func DiversionReason(cause StatusCode) string {
+	switch cause {
+	case StatusBusyHere:
+		return DiversionUserBusy
+	case StatusRequestTimeout:
+		return DiversionNoAnswer
+	case StatusMovedTemporarily:
+		return DiversionUnconditional
+	case StatusServiceUnavailable:
+		return DiversionUnavailable
+	case StatusRequestTerminated:
+		return DiversionDeflection
+	}
+	return DiversionUnknown
+}
+
+func parseDiversionHeader(headerText string, h *DiversionHeader) error {
+	params := NewParams()
+	displayName, err := ParseAddressValue(headerText, &h.Address, params)
+	if err != nil {
+		return err
+	}
+	if reason, ok := params.Get("reason"); ok {
+		h.Reason = strings.Trim(reason, "\"")
+		params.Remove("reason")
+	}
+	if counter, ok := params.Get("counter"); ok {
+		h.Counter, _ = strconv.Atoi(counter)
+		params.Remove("counter")
+	}
+	if len(params) == 0 {
+		params = nil
+	}
+	h.DisplayName = displayName
+	h.Params = params
+	return nil
+}
@@ -0,0 +1,44 @@
+package sip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiversionHeader(t *testing.T) {
+	req := NewRequest(INVITE, &Uri{User: "voicemail", Host: "example.com"})
+	req.AppendHeader(NewHeader("Diversion", `"Bob" <sip:bob@example.com>;reason="user-busy";counter=2;privacy=off, <tel:+15551234>;reason=no-answer`))
+
+	entries := req.Diversion()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "Bob", entries[0].DisplayName)
+	assert.Equal(t, "bob", entries[0].Address.User)
+	assert.Equal(t, DiversionUserBusy, entries[0].Reason)
+	assert.Equal(t, 2, entries[0].Counter)
+	assert.Equal(t, "off", entries[0].Params["privacy"])
+	assert.Equal(t, DiversionNoAnswer, entries[1].Reason)
+	assert.Equal(t, 0, entries[1].Counter)
+	assert.Equal(t, `Diversion: "Bob" <sip:bob@example.com>;reason=user-busy;counter=2;privacy=off`, entries[0].String())
+}
+
+func TestAddDiversion(t *testing.T) {
+	req := NewRequest(INVITE, &Uri{User: "alice", Host: "example.com"})
+	req.AppendHeader(NewHeader("Diversion", "<sip:bob@example.com>;reason=unconditional;counter=1"))
+	req.AppendHeader(NewHeader("Max-Forwards", "70"))
+
+	h := req.AddDiversion(DiversionReason(StatusBusyHere))
+	assert.Equal(t, "<sip:alice@example.com>;reason=user-busy;counter=1", h.Value())
+
+	entries := req.Diversion()
+	require.Len(t, entries, 2)
+	assert.Equal(t, h, entries[0])
+	assert.Equal(t, "bob", entries[1].Address.User)
+	// Headers stay at place of existing Diversion
+	assert.Equal(t, "Diversion", req.Headers()[0].Name())
+	assert.Equal(t, "Diversion", req.Headers()[1].Name())
+
+	assert.Equal(t, DiversionNoAnswer, DiversionReason(StatusRequestTimeout))
+	assert.Equal(t, DiversionUnknown, DiversionReason(StatusNotFound))
+}
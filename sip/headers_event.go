@@ -0,0 +1,207 @@
+package sip
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// EventHeader is Event header representation https://datatracker.ietf.org/doc/html/rfc6665#section-8.2.1
+// Event: presence;id=1234
+type EventHeader struct {
+	Event string
+	// Params are event params like id
+	Params HeaderParams
+}
+
+func (h *EventHeader) Name() string { return "Event" }
+
+func (h *EventHeader) Value() string {
+	var buffer strings.Builder
+	h.ValueStringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *EventHeader) ValueStringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.Event)
+	if len(h.Params) > 0 {
+		buffer.WriteString(";")
+		h.Params.ToStringWrite(';', buffer)
+	}
+}
+
+func (h *EventHeader) String() string {
+	var buffer strings.Builder
+	h.StringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *EventHeader) StringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.Name())
+	buffer.WriteString(": ")
+	h.ValueStringWrite(buffer)
+}
+
+func (h *EventHeader) headerClone() Header {
+	return h.Clone()
+}
+
+func (h *EventHeader) Clone() *EventHeader {
+	c := *h
+	if h.Params != nil {
+		c.Params = h.Params.clone()
+	}
+	return &c
+}
+
+// ID returns id param identifying subscription
+func (h *EventHeader) ID() string {
+	id, _ := h.Params.Get("id")
+	return id
+}
+
+// Event returns Event parsed header or nil if not exists
+func (hs *headers) Event() *EventHeader {
+	hdr := hs.getHeader("event")
+	if hdr == nil {
+		hdr = hs.getHeader("o")
+	}
+	if hdr == nil {
+		return nil
+	}
+	if h, ok := hdr.(*EventHeader); ok {
+		return h
+	}
+
+	h := &EventHeader{}
+	if err := parseEventHeader(hdr.Value(), h); err != nil {
+		return nil
+	}
+	return h
+}
+
+func parseEventHeader(headerText string, h *EventHeader) error {
+	event, params, err := parseTokenParams(headerText)
+	if err != nil {
+		return err
+	}
+	h.Event = event
+	h.Params = params
+	return nil
+}
+
+const (
+	SubscriptionStateActive     = "active"
+	SubscriptionStatePending    = "pending"
+	SubscriptionStateTerminated = "terminated"
+)
+
+// SubscriptionStateHeader is Subscription-State header representation https://datatracker.ietf.org/doc/html/rfc6665#section-8.2.3
+// Subscription-State: active;expires=600
+type SubscriptionStateHeader struct {
+	State string
+	// Params are state params like expires, reason and retry-after
+	Params HeaderParams
+}
+
+func (h *SubscriptionStateHeader) Name() string { return "Subscription-State" }
+
+func (h *SubscriptionStateHeader) Value() string {
+	var buffer strings.Builder
+	h.ValueStringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *SubscriptionStateHeader) ValueStringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.State)
+	if len(h.Params) > 0 {
+		buffer.WriteString(";")
+		h.Params.ToStringWrite(';', buffer)
+	}
+}
+
+func (h *SubscriptionStateHeader) String() string {
+	var buffer strings.Builder
+	h.StringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *SubscriptionStateHeader) StringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.Name())
+	buffer.WriteString(": ")
+	h.ValueStringWrite(buffer)
+}
+
+func (h *SubscriptionStateHeader) headerClone() Header {
+	return h.Clone()
+}
+
+func (h *SubscriptionStateHeader) Clone() *SubscriptionStateHeader {
+	c := *h
+	if h.Params != nil {
+		c.Params = h.Params.clone()
+	}
+	return &c
+}
+
+// Expires returns expires param in seconds. Ok is false if param is missing or invalid
+func (h *SubscriptionStateHeader) Expires() (int, bool) {
+	v, ok := h.Params.Get("expires")
+	if !ok {
+		return 0, false
+	}
+	sec, err := strconv.Atoi(v)
+	return sec, err == nil
+}
+
+// Reason returns reason param of terminated subscription
+func (h *SubscriptionStateHeader) Reason() string {
+	r, _ := h.Params.Get("reason")
+	return r
+}
+
+// SubscriptionState returns Subscription-State parsed header or nil if not exists
+func (hs *headers) SubscriptionState() *SubscriptionStateHeader {
+	hdr := hs.getHeader("subscription-state")
+	if hdr == nil {
+		return nil
+	}
+	if h, ok := hdr.(*SubscriptionStateHeader); ok {
+		return h
+	}
+
+	h := &SubscriptionStateHeader{}
+	if err := parseSubscriptionStateHeader(hdr.Value(), h); err != nil {
+		return nil
+	}
+	return h
+}
+
+func parseSubscriptionStateHeader(headerText string, h *SubscriptionStateHeader) error {
+	state, params, err := parseTokenParams(headerText)
+	if err != nil {
+		return err
+	}
+	h.State = strings.ToLower(state)
+	h.Params = params
+	return nil
+}
+
+// parseTokenParams parses token;params value
+func parseTokenParams(headerText string) (string, HeaderParams, error) {
+	token, rest, _ := strings.Cut(strings.TrimSpace(headerText), ";")
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", nil, errors.New("empty header value")
+	}
+
+	if rest == "" {
+		return token, nil, nil
+	}
+	params := NewParams()
+	if _, err := UnmarshalParams(rest, ';', 0, params); err != nil {
+		return "", nil, err
+	}
+	return token, params, nil
+}
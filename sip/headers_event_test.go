@@ -0,0 +1,50 @@
+package sip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventHeader(t *testing.T) {
+	req := NewRequest(SUBSCRIBE, &Uri{User: "bob", Host: "example.com"})
+	req.AppendHeader(NewHeader("o", "presence;id=1234"))
+
+	h := req.Event()
+	require.NotNil(t, h)
+	assert.Equal(t, "presence", h.Event)
+	assert.Equal(t, "1234", h.ID())
+	assert.Equal(t, "Event: presence;id=1234", h.String())
+
+	req = NewRequest(NOTIFY, &Uri{User: "bob", Host: "example.com"})
+	req.AppendHeader(&EventHeader{Event: "refer"})
+	require.NotNil(t, req.Event())
+	assert.Equal(t, "refer", req.Event().Event)
+	assert.Equal(t, "", req.Event().ID())
+
+	var empty EventHeader
+	assert.Error(t, parseEventHeader(" ", &empty))
+}
+
+func TestSubscriptionStateHeader(t *testing.T) {
+	req := NewRequest(NOTIFY, &Uri{User: "bob", Host: "example.com"})
+	req.AppendHeader(NewHeader("Subscription-State", "Active;expires=3600"))
+
+	h := req.SubscriptionState()
+	require.NotNil(t, h)
+	assert.Equal(t, SubscriptionStateActive, h.State)
+	expires, ok := h.Expires()
+	assert.True(t, ok)
+	assert.Equal(t, 3600, expires)
+	assert.Equal(t, "Subscription-State: active;expires=3600", h.String())
+
+	req = NewRequest(NOTIFY, &Uri{User: "bob", Host: "example.com"})
+	req.AppendHeader(NewHeader("Subscription-State", "terminated;reason=noresource"))
+	h = req.SubscriptionState()
+	require.NotNil(t, h)
+	assert.Equal(t, SubscriptionStateTerminated, h.State)
+	assert.Equal(t, "noresource", h.Reason())
+	_, ok = h.Expires()
+	assert.False(t, ok)
+}
@@ -0,0 +1,206 @@
+package sip
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// History-Info tags describing how target was determined https://datatracker.ietf.org/doc/html/rfc7044#section-4.2
+	// HistoryInfoRC is retarget to contact bound to target, ex. by location service
+	HistoryInfoRC = "rc"
+	// HistoryInfoMP is retarget to other user, ex. call forwarding
+	HistoryInfoMP = "mp"
+	// HistoryInfoNP is retarget without change of target
+	HistoryInfoNP = "np"
+)
+
+// HistoryInfoHeader is one entry of History-Info header https://datatracker.ietf.org/doc/html/rfc7044
+// History-Info: <sip:bob@example.com>;index=1, <sip:voicemail@example.com;cause=486>;index=1.1;mp=1
+type HistoryInfoHeader struct {
+	DisplayName string
+	// Address is target of request. Cause uri param holds response code which caused retarget to it
+	Address Uri
+	// Index is position of entry in retarget tree, ex. 1.1.2
+	Index string
+	// Params are other params, ex. rc, mp or np tag with index of parent entry
+	Params HeaderParams
+}
+
+func (h *HistoryInfoHeader) Name() string { return "History-Info" }
+
+func (h *HistoryInfoHeader) Value() string {
+	var buffer strings.Builder
+	h.ValueStringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *HistoryInfoHeader) ValueStringWrite(buffer io.StringWriter) {
+	if h.DisplayName != "" {
+		buffer.WriteString("\"")
+		buffer.WriteString(h.DisplayName)
+		buffer.WriteString("\" ")
+	}
+	buffer.WriteString("<")
+	h.Address.StringWrite(buffer)
+	buffer.WriteString(">;index=")
+	buffer.WriteString(h.Index)
+	if len(h.Params) > 0 {
+		buffer.WriteString(";")
+		buffer.WriteString(h.Params.ToString(';'))
+	}
+}
+
+func (h *HistoryInfoHeader) String() string {
+	var buffer strings.Builder
+	h.StringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *HistoryInfoHeader) StringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.Name())
+	buffer.WriteString(": ")
+	h.ValueStringWrite(buffer)
+}
+
+func (h *HistoryInfoHeader) headerClone() Header {
+	return h.Clone()
+}
+
+func (h *HistoryInfoHeader) Clone() *HistoryInfoHeader {
+	c := *h
+	c.Address = *h.Address.Clone()
+	if h.Params != nil {
+		c.Params = h.Params.clone()
+	}
+	return &c
+}
+
+// Cause returns response code which caused retarget to this entry https://datatracker.ietf.org/doc/html/rfc4458
+func (h *HistoryInfoHeader) Cause() (StatusCode, bool) {
+	v, ok := h.Address.UriParams.Get("cause")
+	if !ok {
+		return 0, false
+	}
+	code, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, false
+	}
+	return StatusCode(code), true
+}
+
+// HistoryInfo returns all History-Info entries in order. Invalid entries are skipped
+func (req *Request) HistoryInfo() []*HistoryInfoHeader {
+	var entries []*HistoryInfoHeader
+	for _, hdr := range req.GetHeaders("History-Info") {
+		if h, ok := hdr.(*HistoryInfoHeader); ok {
+			entries = append(entries, h)
+			continue
+		}
+		for _, v := range splitHeaderList(hdr.Value()) {
+			h := &HistoryInfoHeader{}
+			if err := parseHistoryInfoHeader(v, h); err != nil {
+				continue
+			}
+			entries = append(entries, h)
+		}
+	}
+	return entries
+}
+
+// AppendHistoryInfo records retarget of request to target in History-Info and returns added entry.
+// It must be called before Request-URI is changed, as entry for current Request-URI is added first in case History-Info is missing.
+// Tag is HistoryInfoRC, HistoryInfoMP or HistoryInfoNP. Cause is response code which caused retarget, or 0
+// https://datatracker.ietf.org/doc/html/rfc7044#section-10.3
+func (req *Request) AppendHistoryInfo(target Uri, tag string, cause StatusCode) *HistoryInfoHeader {
+	entries := req.HistoryInfo()
+	if len(entries) == 0 && req.Recipient != nil {
+		first := &HistoryInfoHeader{Address: *req.Recipient.Clone(), Index: "1"}
+		req.AppendHeader(first)
+		entries = append(entries, first)
+	}
+
+	// New entry is child of last entry, which is current target
+	parent := "1"
+	if len(entries) > 0 {
+		parent = entries[len(entries)-1].Index
+	}
+	children := 0
+	for _, e := range entries {
+		if strings.HasPrefix(e.Index, parent+".") && !strings.Contains(e.Index[len(parent)+1:], ".") {
+			children++
+		}
+	}
+
+	h := &HistoryInfoHeader{
+		Address: *target.Clone(),
+		Index:   parent + "." + strconv.Itoa(children+1),
+	}
+	if cause > 0 {
+		if h.Address.UriParams == nil {
+			h.Address.UriParams = NewParams()
+		}
+		h.Address.UriParams.Add("cause", strconv.Itoa(int(cause)))
+	}
+	if tag != "" {
+		h.Params = NewParams()
+		h.Params.Add(tag, parent)
+	}
+	req.AppendHeader(h)
+	return h
+}
+
+func parseHistoryInfoHeader(headerText string, h *HistoryInfoHeader) error {
+	params := NewParams()
+	displayName, err := ParseAddressValue(headerText, &h.Address, params)
+	if err != nil {
+		return err
+	}
+	index, ok := params.Get("index")
+	if !ok {
+		return errors.New("missing index in History-Info header")
+	}
+	params.Remove("index")
+	if len(params) == 0 {
+		params = nil
+	}
+	h.DisplayName = displayName
+	h.Index = index
+	h.Params = params
+	return nil
+}
+
+// splitHeaderList splits comma separated header values. Commas within quotes or angle brackets are kept
+func splitHeaderList(s string) []string {
+	var values []string
+	inQuotes, inBrackets := false, false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			inQuotes = !inQuotes
+		case '<':
+			if !inQuotes {
+				inBrackets = true
+			}
+		case '>':
+			if !inQuotes {
+				inBrackets = false
+			}
+		case ',':
+			if inQuotes || inBrackets {
+				continue
+			}
+			if v := strings.TrimSpace(s[start:i]); v != "" {
+				values = append(values, v)
+			}
+			start = i + 1
+		}
+	}
+	if v := strings.TrimSpace(s[start:]); v != "" {
+		values = append(values, v)
+	}
+	return values
+}
@@ -0,0 +1,54 @@
+package sip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryInfoHeader(t *testing.T) {
+	req := NewRequest(INVITE, &Uri{User: "bob", Host: "example.com"})
+	req.AppendHeader(NewHeader("History-Info", `"Bob" <sip:bob@example.com>;index=1, <sip:bob@192.0.2.4;cause=480>;index=1.1;rc=1`))
+	req.AppendHeader(NewHeader("History-Info", `<sip:voicemail@example.com?Reason=SIP%3Bcause%3D486>;index=1.2;mp=1, <sip:invalid@example.com>`))
+
+	entries := req.HistoryInfo()
+	require.Len(t, entries, 3)
+	assert.Equal(t, "Bob", entries[0].DisplayName)
+	assert.Equal(t, "1", entries[0].Index)
+	assert.Equal(t, "1.1", entries[1].Index)
+	assert.Equal(t, "1", entries[1].Params["rc"])
+	cause, ok := entries[1].Cause()
+	assert.True(t, ok)
+	assert.Equal(t, StatusTemporarilyUnavailable, cause)
+	_, ok = entries[0].Cause()
+	assert.False(t, ok)
+	assert.Equal(t, "voicemail", entries[2].Address.User)
+
+	assert.Equal(t, `History-Info: "Bob" <sip:bob@example.com>;index=1`, entries[0].String())
+	assert.Equal(t, []string{"<a,b>", `"x, y" <c>`, "d"}, splitHeaderList(` <a,b> ,"x, y" <c>,, d`))
+}
+
+func TestAppendHistoryInfo(t *testing.T) {
+	req := NewRequest(INVITE, &Uri{User: "bob", Host: "example.com"})
+
+	// Retarget to registered contact and then forward on busy
+	h := req.AppendHistoryInfo(Uri{User: "bob", Host: "192.0.2.4"}, HistoryInfoRC, 0)
+	assert.Equal(t, "<sip:bob@192.0.2.4>;index=1.1;rc=1", h.Value())
+	req.Recipient = &h.Address
+
+	h = req.AppendHistoryInfo(Uri{User: "voicemail", Host: "example.com"}, HistoryInfoMP, StatusBusyHere)
+	assert.Equal(t, "<sip:voicemail@example.com;cause=486>;index=1.1.1;mp=1.1", h.Value())
+
+	entries := req.HistoryInfo()
+	require.Len(t, entries, 3)
+	assert.Equal(t, "sip:bob@example.com", entries[0].Address.String())
+	assert.Equal(t, "1", entries[0].Index)
+
+	// Parsed entries are continued
+	req = NewRequest(INVITE, &Uri{User: "bob", Host: "192.0.2.4"})
+	req.AppendHeader(NewHeader("History-Info", "<sip:bob@example.com>;index=1, <sip:bob@192.0.2.4>;index=1.1;rc=1, <sip:bob@192.0.2.5>;index=1.2;rc=1"))
+	h = req.AppendHistoryInfo(Uri{User: "alice", Host: "example.com"}, HistoryInfoMP, 0)
+	assert.Equal(t, "<sip:alice@example.com>;index=1.2.1;mp=1.2", h.Value())
+	assert.Len(t, req.HistoryInfo(), 4)
+}
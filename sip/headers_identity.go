@@ -0,0 +1,177 @@
+package sip
+
+import (
+	"io"
+	"strings"
+)
+
+const (
+	// Privacy values https://datatracker.ietf.org/doc/html/rfc3323#section-4.2
+	PrivacyValueNone     = "none"
+	PrivacyValueHeader   = "header"
+	PrivacyValueSession  = "session"
+	PrivacyValueUser     = "user"
+	PrivacyValueCritical = "critical"
+	// PrivacyValueID requests hiding of P-Asserted-Identity outside trust domain https://datatracker.ietf.org/doc/html/rfc3325#section-7
+	PrivacyValueID = "id"
+)
+
+// AssertedIdentityHeader is one entry of P-Asserted-Identity or P-Preferred-Identity header
+// https://datatracker.ietf.org/doc/html/rfc3325#section-9
+// P-Asserted-Identity: "Cullen Jennings" <sip:fluffy@cisco.com>, <tel:+14085264000>
+type AssertedIdentityHeader struct {
+	// Preferred marks P-Preferred-Identity header, which is identity UA would like to be asserted
+	Preferred   bool
+	DisplayName string
+	Address     Uri
+}
+
+func (h *AssertedIdentityHeader) Name() string {
+	if h.Preferred {
+		return "P-Preferred-Identity"
+	}
+	return "P-Asserted-Identity"
+}
+
+func (h *AssertedIdentityHeader) Value() string {
+	var buffer strings.Builder
+	h.ValueStringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *AssertedIdentityHeader) ValueStringWrite(buffer io.StringWriter) {
+	if h.DisplayName != "" {
+		buffer.WriteString("\"")
+		buffer.WriteString(h.DisplayName)
+		buffer.WriteString("\" ")
+	}
+	buffer.WriteString("<")
+	h.Address.StringWrite(buffer)
+	buffer.WriteString(">")
+}
+
+func (h *AssertedIdentityHeader) String() string {
+	var buffer strings.Builder
+	h.StringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *AssertedIdentityHeader) StringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.Name())
+	buffer.WriteString(": ")
+	h.ValueStringWrite(buffer)
+}
+
+func (h *AssertedIdentityHeader) headerClone() Header {
+	return h.Clone()
+}
+
+func (h *AssertedIdentityHeader) Clone() *AssertedIdentityHeader {
+	c := *h
+	c.Address = *h.Address.Clone()
+	return &c
+}
+
+// PrivacyHeader is Privacy header representation https://datatracker.ietf.org/doc/html/rfc3323#section-4.2
+// Privacy: id;user
+type PrivacyHeader struct {
+	Values []string
+}
+
+func (h *PrivacyHeader) Name() string { return "Privacy" }
+
+func (h *PrivacyHeader) Value() string {
+	var buffer strings.Builder
+	h.ValueStringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *PrivacyHeader) ValueStringWrite(buffer io.StringWriter) {
+	for i, v := range h.Values {
+		if i > 0 {
+			buffer.WriteString(";")
+		}
+		buffer.WriteString(v)
+	}
+}
+
+func (h *PrivacyHeader) String() string {
+	var buffer strings.Builder
+	h.StringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *PrivacyHeader) StringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.Name())
+	buffer.WriteString(": ")
+	h.ValueStringWrite(buffer)
+}
+
+func (h *PrivacyHeader) headerClone() Header {
+	return h.Clone()
+}
+
+func (h *PrivacyHeader) Clone() *PrivacyHeader {
+	c := *h
+	c.Values = append([]string(nil), h.Values...)
+	return &c
+}
+
+// Has reports is privacy value requested. Value is matched case insensitive
+func (h *PrivacyHeader) Has(value string) bool {
+	for _, v := range h.Values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// PAssertedIdentity returns all P-Asserted-Identity entries. Invalid entries are skipped
+func (hs *headers) PAssertedIdentity() []*AssertedIdentityHeader {
+	return hs.assertedIdentity("P-Asserted-Identity", false)
+}
+
+// PPreferredIdentity returns all P-Preferred-Identity entries. Invalid entries are skipped
+func (hs *headers) PPreferredIdentity() []*AssertedIdentityHeader {
+	return hs.assertedIdentity("P-Preferred-Identity", true)
+}
+
+func (hs *headers) assertedIdentity(name string, preferred bool) []*AssertedIdentityHeader {
+	var entries []*AssertedIdentityHeader
+	for _, hdr := range hs.GetHeaders(name) {
+		if h, ok := hdr.(*AssertedIdentityHeader); ok {
+			entries = append(entries, h)
+			continue
+		}
+		for _, v := range splitHeaderList(hdr.Value()) {
+			h := &AssertedIdentityHeader{Preferred: preferred}
+			displayName, err := ParseAddressValue(v, &h.Address, NewParams())
+			if err != nil {
+				continue
+			}
+			h.DisplayName = displayName
+			entries = append(entries, h)
+		}
+	}
+	return entries
+}
+
+// Privacy returns Privacy parsed header or nil if not exists
+func (hs *headers) Privacy() *PrivacyHeader {
+	hdr := hs.GetHeader("Privacy")
+	if hdr == nil {
+		return nil
+	}
+	if h, ok := hdr.(*PrivacyHeader); ok {
+		return h
+	}
+
+	h := &PrivacyHeader{}
+	for _, v := range strings.Split(hdr.Value(), ";") {
+		if v = strings.TrimSpace(v); v != "" {
+			h.Values = append(h.Values, v)
+		}
+	}
+	return h
+}
@@ -0,0 +1,41 @@
+package sip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertedIdentityHeader(t *testing.T) {
+	req := NewRequest(INVITE, &Uri{User: "bob", Host: "example.com"})
+	req.AppendHeader(NewHeader("P-Asserted-Identity", `"Cullen Jennings" <sip:fluffy@cisco.com>, <tel:+14085264000>`))
+	req.AppendHeader(NewHeader("P-Preferred-Identity", `<sip:alice@example.com>`))
+	req.AppendHeader(NewHeader("Privacy", "id; user"))
+
+	pai := req.PAssertedIdentity()
+	require.Len(t, pai, 2)
+	assert.Equal(t, "Cullen Jennings", pai[0].DisplayName)
+	assert.Equal(t, "fluffy", pai[0].Address.User)
+	assert.Equal(t, `P-Asserted-Identity: "Cullen Jennings" <sip:fluffy@cisco.com>`, pai[0].String())
+	assert.Equal(t, "<tel:+14085264000>", pai[1].Value())
+
+	ppi := req.PPreferredIdentity()
+	require.Len(t, ppi, 1)
+	assert.True(t, ppi[0].Preferred)
+	assert.Equal(t, "P-Preferred-Identity: <sip:alice@example.com>", ppi[0].String())
+
+	privacy := req.Privacy()
+	require.NotNil(t, privacy)
+	assert.Equal(t, []string{"id", "user"}, privacy.Values)
+	assert.True(t, privacy.Has(PrivacyValueID))
+	assert.False(t, privacy.Has(PrivacyValueHeader))
+	assert.Equal(t, "Privacy: id;user", privacy.String())
+
+	// Typed headers are returned as is
+	req.RemoveHeaders("P-Asserted-Identity")
+	req.ReplaceHeader(&PrivacyHeader{Values: []string{PrivacyValueNone}})
+	req.AppendHeader(pai[1].Clone())
+	assert.Equal(t, []*AssertedIdentityHeader{pai[1]}, req.PAssertedIdentity())
+	assert.False(t, req.Privacy().Has(PrivacyValueID))
+}
@@ -0,0 +1,131 @@
+package sip
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JoinHeader is Join header representation https://datatracker.ietf.org/doc/html/rfc3911
+// Join: 98asjd8@test.com;to-tag=1234;from-tag=5678
+// Tags are from perspective of UA receiving Join. To tag is local tag and From tag is remote tag of joined dialog
+type JoinHeader struct {
+	CallID  string
+	ToTag   string
+	FromTag string
+	// Params are other generic params
+	Params HeaderParams
+}
+
+func (h *JoinHeader) Name() string { return "Join" }
+
+func (h *JoinHeader) Value() string {
+	var buffer strings.Builder
+	h.ValueStringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *JoinHeader) ValueStringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.CallID)
+	buffer.WriteString(";to-tag=")
+	buffer.WriteString(h.ToTag)
+	buffer.WriteString(";from-tag=")
+	buffer.WriteString(h.FromTag)
+	if len(h.Params) > 0 {
+		buffer.WriteString(";")
+		buffer.WriteString(h.Params.ToString(';'))
+	}
+}
+
+func (h *JoinHeader) String() string {
+	var buffer strings.Builder
+	h.StringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *JoinHeader) StringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.Name())
+	buffer.WriteString(": ")
+	h.ValueStringWrite(buffer)
+}
+
+func (h *JoinHeader) headerClone() Header {
+	return h.Clone()
+}
+
+func (h *JoinHeader) Clone() *JoinHeader {
+	c := *h
+	if h.Params != nil {
+		c.Params = h.Params.clone()
+	}
+	return &c
+}
+
+// DialogIDUAS returns dialog ID of joined dialog in case receiving UA was UAS of that dialog
+func (h *JoinHeader) DialogIDUAS() string {
+	return MakeDialogID(h.CallID, h.ToTag, h.FromTag)
+}
+
+// DialogIDUAC returns dialog ID of joined dialog in case receiving UA was UAC of that dialog
+func (h *JoinHeader) DialogIDUAC() string {
+	return MakeDialogID(h.CallID, h.FromTag, h.ToTag)
+}
+
+// Join returns Join parsed header or nil if not exists
+func (req *Request) Join() *JoinHeader {
+	hdr := req.GetHeader("Join")
+	if hdr == nil {
+		return nil
+	}
+	if h, ok := hdr.(*JoinHeader); ok {
+		return h
+	}
+
+	h := &JoinHeader{}
+	if err := parseJoinHeader(hdr.Value(), h); err != nil {
+		return nil
+	}
+	return h
+}
+
+func parseJoinHeader(headerText string, h *JoinHeader) error {
+	callid, toTag, fromTag, params, err := parseDialogRefHeader(headerText, "Join", "to-tag", "from-tag")
+	if err != nil {
+		return err
+	}
+
+	h.CallID = callid
+	h.ToTag = toTag
+	h.FromTag = fromTag
+	h.Params = params
+	return nil
+}
+
+// parseDialogRefHeader parses headers referencing dialog in form callid;tag1=a;tag2=b
+func parseDialogRefHeader(headerText string, name string, tag1 string, tag2 string) (callid string, val1 string, val2 string, params HeaderParams, err error) {
+	callid, rest, _ := strings.Cut(strings.TrimSpace(headerText), ";")
+	callid = strings.TrimSpace(callid)
+	if callid == "" {
+		return "", "", "", nil, fmt.Errorf("empty Call-ID in %s header", name)
+	}
+
+	params = NewParams()
+	if _, err := UnmarshalParams(rest, ';', 0, params); err != nil {
+		return "", "", "", nil, err
+	}
+
+	val1, ok := params.Get(tag1)
+	if !ok {
+		return "", "", "", nil, fmt.Errorf("missing %s in %s header", tag1, name)
+	}
+	val2, ok = params.Get(tag2)
+	if !ok {
+		return "", "", "", nil, fmt.Errorf("missing %s in %s header", tag2, name)
+	}
+	params.Remove(tag1)
+	params.Remove(tag2)
+	if len(params) == 0 {
+		params = nil
+	}
+	return callid, val1, val2, params, nil
+}
@@ -0,0 +1,34 @@
+package sip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoinHeader(t *testing.T) {
+	req := NewRequest(INVITE, &Uri{User: "bob", Host: "example.com"})
+	req.AppendHeader(NewHeader("Join", "12adf2f34456gs5;to-tag=12345;from-tag=54321;x=y"))
+
+	join := req.Join()
+	require.NotNil(t, join)
+	assert.Equal(t, "12adf2f34456gs5", join.CallID)
+	assert.Equal(t, "12345", join.ToTag)
+	assert.Equal(t, "54321", join.FromTag)
+	assert.Equal(t, "y", join.Params["x"])
+	assert.Equal(t, "Join: 12adf2f34456gs5;to-tag=12345;from-tag=54321;x=y", join.String())
+
+	assert.Equal(t, MakeDialogID("12adf2f34456gs5", "12345", "54321"), join.DialogIDUAS())
+	assert.Equal(t, MakeDialogID("12adf2f34456gs5", "54321", "12345"), join.DialogIDUAC())
+
+	// Typed header is returned as is
+	req.RemoveHeader("Join")
+	req.AppendHeader(join.Clone())
+	assert.Equal(t, join.Value(), req.Join().Value())
+
+	for _, v := range []string{"", ";to-tag=1;from-tag=2", "abc;to-tag=1", "abc;from-tag=2"} {
+		var h JoinHeader
+		assert.Error(t, parseJoinHeader(v, &h), v)
+	}
+}
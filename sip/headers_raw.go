@@ -0,0 +1,57 @@
+package sip
+
+import (
+	"strings"
+)
+
+// RawHeader is header line as received on wire, before any parsing or normalization.
+// It is recorded only by parser created with WithParserRawHeaders
+type RawHeader string
+
+// Name returns header name as received, ex. compact form
+func (h RawHeader) Name() string {
+	name, _, _ := strings.Cut(string(h), ":")
+	return strings.TrimSpace(name)
+}
+
+// Value returns untouched header value. Only whitespace after colon is removed,
+// as it is not part of value https://datatracker.ietf.org/doc/html/rfc3261#section-25.1
+func (h RawHeader) Value() string {
+	_, value, _ := strings.Cut(string(h), ":")
+	return strings.TrimLeft(value, " \t")
+}
+
+// String returns full header line without CRLF
+func (h RawHeader) String() string {
+	return string(h)
+}
+
+// RawHeaders returns header lines in original wire order. Changes of parsed headers are not reflected,
+// so they can be used for signature verification like Identity or for byte exact forwarding.
+// It is empty for messages not parsed with WithParserRawHeaders
+func (hs *headers) RawHeaders() []RawHeader {
+	return hs.raw
+}
+
+// RawHeaderValues returns untouched values of headers with name in wire order.
+// Name is matched case insensitive. Compact form is not resolved
+func (hs *headers) RawHeaderValues(name string) []string {
+	var values []string
+	for _, h := range hs.raw {
+		if strings.EqualFold(h.Name(), name) {
+			values = append(values, h.Value())
+		}
+	}
+	return values
+}
+
+func (hs *headers) appendRawHeader(line string) {
+	hs.raw = append(hs.raw, RawHeader(line))
+}
+
+// parseRawHeader records header line on message
+func parseRawHeader(msg Message, line string) {
+	if m, ok := msg.(interface{ appendRawHeader(line string) }); ok {
+		m.appendRawHeader(line)
+	}
+}
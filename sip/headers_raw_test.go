@@ -0,0 +1,58 @@
+package sip
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRawHeaders(t *testing.T) {
+	rawMsg := []string{
+		"INVITE sip:bob@example.com SIP/2.0",
+		"v: SIP/2.0/UDP  127.0.0.1:5060 ;branch=z9hG4bK.123",
+		"From:<sip:alice@example.com>;tag=1",
+		"To: <sip:bob@example.com>",
+		"Call-ID: abc",
+		"CSeq: 1 INVITE",
+		"Contact: <sip:alice@127.0.0.1>, <sip:alice@127.0.0.2>",
+		"Identity: eyJhbGciOiJFUzI1NiJ9.e30.c2ln;info=<https://cert.example.org/passport.cer>",
+		"Content-Length: 0",
+		"",
+		"",
+	}
+	data := []byte(strings.Join(rawMsg, "\r\n"))
+
+	msg, err := NewParser(WithParserRawHeaders()).ParseSIP(data)
+	require.NoError(t, err)
+	req := msg.(*Request)
+
+	raw := req.RawHeaders()
+	require.Len(t, raw, 8)
+	for i, h := range raw {
+		assert.Equal(t, rawMsg[i+1], h.String())
+	}
+	assert.Equal(t, "v", raw[0].Name())
+	assert.Equal(t, "SIP/2.0/UDP  127.0.0.1:5060 ;branch=z9hG4bK.123", raw[0].Value())
+	assert.Equal(t, []string{"<sip:alice@example.com>;tag=1"}, req.RawHeaderValues("from"))
+	assert.Equal(t, []string{"<sip:alice@127.0.0.1>, <sip:alice@127.0.0.2>"}, req.RawHeaderValues("Contact"))
+	assert.Len(t, req.GetHeaders("Contact"), 2)
+
+	// Modifications do not change raw headers
+	req.RemoveHeader("Identity")
+	req.To().Params.Add("tag", "2")
+	assert.Equal(t, []string{"eyJhbGciOiJFUzI1NiJ9.e30.c2ln;info=<https://cert.example.org/passport.cer>"}, req.RawHeaderValues("Identity"))
+	assert.Equal(t, raw, req.Clone().RawHeaders())
+
+	// Not recorded by default
+	msg, err = NewParser().ParseSIP(data)
+	require.NoError(t, err)
+	assert.Empty(t, msg.(*Request).RawHeaders())
+
+	stream := NewParser(WithParserRawHeaders()).NewSIPStream()
+	msgs, err := stream.ParseSIPStream(data)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, raw, msgs[0].(*Request).RawHeaders())
+}
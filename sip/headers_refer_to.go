@@ -0,0 +1,90 @@
+package sip
+
+import (
+	"io"
+	"strings"
+)
+
+// ReferToHeader is Refer-To header representation https://datatracker.ietf.org/doc/html/rfc3515#section-2.1
+// Refer-To: <sip:bob@biloxi.example.net?Replaces=12345%40192.168.118.3%3Bto-tag%3D12345%3Bfrom-tag%3D5FFE-3994>
+// Embedded headers like Replaces are kept in Address Headers
+type ReferToHeader struct {
+	DisplayName string
+	Address     Uri
+	Params      HeaderParams
+}
+
+func (h *ReferToHeader) Name() string { return "Refer-To" }
+
+func (h *ReferToHeader) Value() string {
+	var buffer strings.Builder
+	h.ValueStringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *ReferToHeader) ValueStringWrite(buffer io.StringWriter) {
+	if h.DisplayName != "" {
+		buffer.WriteString("\"")
+		buffer.WriteString(h.DisplayName)
+		buffer.WriteString("\" ")
+	}
+
+	buffer.WriteString("<")
+	h.Address.StringWrite(buffer)
+	buffer.WriteString(">")
+
+	if len(h.Params) > 0 {
+		buffer.WriteString(";")
+		h.Params.ToStringWrite(';', buffer)
+	}
+}
+
+func (h *ReferToHeader) String() string {
+	var buffer strings.Builder
+	h.StringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *ReferToHeader) StringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.Name())
+	buffer.WriteString(": ")
+	h.ValueStringWrite(buffer)
+}
+
+func (h *ReferToHeader) headerClone() Header {
+	return h.Clone()
+}
+
+func (h *ReferToHeader) Clone() *ReferToHeader {
+	c := *h
+	c.Address = *h.Address.Clone()
+	if h.Params != nil {
+		c.Params = h.Params.clone()
+	}
+	return &c
+}
+
+// ReferTo returns Refer-To parsed header or nil if not exists
+func (hs *headers) ReferTo() *ReferToHeader {
+	hdr := hs.getHeader("refer-to")
+	if hdr == nil {
+		hdr = hs.getHeader("r")
+	}
+	if hdr == nil {
+		return nil
+	}
+	if h, ok := hdr.(*ReferToHeader); ok {
+		return h
+	}
+
+	h := &ReferToHeader{Params: NewParams()}
+	displayName, err := ParseAddressValue(hdr.Value(), &h.Address, h.Params)
+	if err != nil {
+		return nil
+	}
+	h.DisplayName = displayName
+	if len(h.Params) == 0 {
+		h.Params = nil
+	}
+	return h
+}
@@ -0,0 +1,35 @@
+package sip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReferToHeader(t *testing.T) {
+	req := NewRequest(REFER, &Uri{User: "bob", Host: "example.com"})
+	req.AppendHeader(NewHeader("Refer-To", `"Carol" <sip:carol@example.com;transport=tcp>;method=INVITE`))
+
+	h := req.ReferTo()
+	require.NotNil(t, h)
+	assert.Equal(t, "Carol", h.DisplayName)
+	assert.Equal(t, "carol", h.Address.User)
+	assert.Equal(t, "example.com", h.Address.Host)
+	assert.Equal(t, "tcp", h.Address.UriParams["transport"])
+	method, _ := h.Params.Get("method")
+	assert.Equal(t, "INVITE", method)
+	assert.Equal(t, `Refer-To: "Carol" <sip:carol@example.com;transport=tcp>;method=INVITE`, h.String())
+
+	c := h.Clone()
+	c.Address.User = "dave"
+	assert.Equal(t, "carol", h.Address.User)
+
+	req = NewRequest(REFER, &Uri{User: "bob", Host: "example.com"})
+	req.AppendHeader(NewHeader("r", "<sip:carol@example.com>"))
+	require.NotNil(t, req.ReferTo())
+	assert.Nil(t, req.ReferTo().Params)
+
+	req = NewRequest(REFER, &Uri{User: "bob", Host: "example.com"})
+	assert.Nil(t, req.ReferTo())
+}
@@ -0,0 +1,113 @@
+package sip
+
+import (
+	"io"
+	"strings"
+)
+
+// ReplacesHeader is Replaces header representation https://datatracker.ietf.org/doc/html/rfc3891
+// Replaces: 98732@sip.example.com;to-tag=r33th4x0r;from-tag=ff87ff;early-only
+// Tags are from perspective of UA receiving Replaces. To tag is local tag and From tag is remote tag of replaced dialog
+type ReplacesHeader struct {
+	CallID  string
+	ToTag   string
+	FromTag string
+	// EarlyOnly is set when only early dialog can be replaced, ex. call pickup
+	EarlyOnly bool
+	// Params are other generic params
+	Params HeaderParams
+}
+
+func (h *ReplacesHeader) Name() string { return "Replaces" }
+
+func (h *ReplacesHeader) Value() string {
+	var buffer strings.Builder
+	h.ValueStringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *ReplacesHeader) ValueStringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.CallID)
+	buffer.WriteString(";to-tag=")
+	buffer.WriteString(h.ToTag)
+	buffer.WriteString(";from-tag=")
+	buffer.WriteString(h.FromTag)
+	if h.EarlyOnly {
+		buffer.WriteString(";early-only")
+	}
+	if len(h.Params) > 0 {
+		buffer.WriteString(";")
+		buffer.WriteString(h.Params.ToString(';'))
+	}
+}
+
+func (h *ReplacesHeader) String() string {
+	var buffer strings.Builder
+	h.StringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *ReplacesHeader) StringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.Name())
+	buffer.WriteString(": ")
+	h.ValueStringWrite(buffer)
+}
+
+func (h *ReplacesHeader) headerClone() Header {
+	return h.Clone()
+}
+
+func (h *ReplacesHeader) Clone() *ReplacesHeader {
+	c := *h
+	if h.Params != nil {
+		c.Params = h.Params.clone()
+	}
+	return &c
+}
+
+// DialogIDUAS returns dialog ID of replaced dialog in case receiving UA was UAS of that dialog
+func (h *ReplacesHeader) DialogIDUAS() string {
+	return MakeDialogID(h.CallID, h.ToTag, h.FromTag)
+}
+
+// DialogIDUAC returns dialog ID of replaced dialog in case receiving UA was UAC of that dialog
+func (h *ReplacesHeader) DialogIDUAC() string {
+	return MakeDialogID(h.CallID, h.FromTag, h.ToTag)
+}
+
+// Replaces returns Replaces parsed header or nil if not exists
+func (req *Request) Replaces() *ReplacesHeader {
+	hdr := req.GetHeader("Replaces")
+	if hdr == nil {
+		return nil
+	}
+	if h, ok := hdr.(*ReplacesHeader); ok {
+		return h
+	}
+
+	h := &ReplacesHeader{}
+	if err := parseReplacesHeader(hdr.Value(), h); err != nil {
+		return nil
+	}
+	return h
+}
+
+func parseReplacesHeader(headerText string, h *ReplacesHeader) error {
+	callid, toTag, fromTag, params, err := parseDialogRefHeader(headerText, "Replaces", "to-tag", "from-tag")
+	if err != nil {
+		return err
+	}
+
+	h.CallID = callid
+	h.ToTag = toTag
+	h.FromTag = fromTag
+	if params.Has("early-only") {
+		h.EarlyOnly = true
+		params.Remove("early-only")
+		if len(params) == 0 {
+			params = nil
+		}
+	}
+	h.Params = params
+	return nil
+}
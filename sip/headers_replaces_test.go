@@ -0,0 +1,36 @@
+package sip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplacesHeader(t *testing.T) {
+	req := NewRequest(INVITE, &Uri{User: "bob", Host: "example.com"})
+	req.AppendHeader(NewHeader("Replaces", "98732@sip.example.com;from-tag=r33th4x0r;to-tag=ff87ff;early-only"))
+
+	replaces := req.Replaces()
+	require.NotNil(t, replaces)
+	assert.Equal(t, "98732@sip.example.com", replaces.CallID)
+	assert.Equal(t, "ff87ff", replaces.ToTag)
+	assert.Equal(t, "r33th4x0r", replaces.FromTag)
+	assert.True(t, replaces.EarlyOnly)
+	assert.Nil(t, replaces.Params)
+	assert.Equal(t, "Replaces: 98732@sip.example.com;to-tag=ff87ff;from-tag=r33th4x0r;early-only", replaces.String())
+
+	assert.Equal(t, MakeDialogID("98732@sip.example.com", "ff87ff", "r33th4x0r"), replaces.DialogIDUAS())
+	assert.Equal(t, MakeDialogID("98732@sip.example.com", "r33th4x0r", "ff87ff"), replaces.DialogIDUAC())
+
+	// Typed header is returned as is
+	req.RemoveHeader("Replaces")
+	req.AppendHeader(&ReplacesHeader{CallID: "abc", ToTag: "1", FromTag: "2", Params: HeaderParams{"x": "y"}})
+	assert.Equal(t, "abc;to-tag=1;from-tag=2;x=y", req.Replaces().Value())
+	assert.False(t, req.Replaces().EarlyOnly)
+
+	for _, v := range []string{"", ";to-tag=1;from-tag=2", "abc;to-tag=1", "abc;from-tag=2"} {
+		var h ReplacesHeader
+		assert.Error(t, parseReplacesHeader(v, &h), v)
+	}
+}
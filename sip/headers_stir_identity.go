@@ -0,0 +1,135 @@
+package sip
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// IdentityHeader is Identity header representation carrying signed PASSporT https://datatracker.ietf.org/doc/html/rfc8224#section-4
+// Identity: eyJhbGciOiJFUzI1NiIs...;info=<https://cert.example.org/passport.cer>;alg=ES256;ppt=shaken
+type IdentityHeader struct {
+	// Token is signed PASSporT in JWS compact serialization
+	Token string
+	// Info is URI of certificate used for signing, written in angle brackets
+	Info string
+	// Params are other params, ex. alg and ppt
+	Params HeaderParams
+}
+
+func (h *IdentityHeader) Name() string { return "Identity" }
+
+func (h *IdentityHeader) Value() string {
+	var buffer strings.Builder
+	h.ValueStringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *IdentityHeader) ValueStringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.Token)
+	if h.Info != "" {
+		buffer.WriteString(";info=<")
+		buffer.WriteString(h.Info)
+		buffer.WriteString(">")
+	}
+	if len(h.Params) > 0 {
+		buffer.WriteString(";")
+		buffer.WriteString(h.Params.ToString(';'))
+	}
+}
+
+func (h *IdentityHeader) String() string {
+	var buffer strings.Builder
+	h.StringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *IdentityHeader) StringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.Name())
+	buffer.WriteString(": ")
+	h.ValueStringWrite(buffer)
+}
+
+func (h *IdentityHeader) headerClone() Header {
+	return h.Clone()
+}
+
+func (h *IdentityHeader) Clone() *IdentityHeader {
+	c := *h
+	if h.Params != nil {
+		c.Params = h.Params.clone()
+	}
+	return &c
+}
+
+// Identity returns all Identity headers. Request can carry multiple PASSporTs, ex. shaken and div.
+// Invalid headers are skipped
+func (req *Request) Identity() []*IdentityHeader {
+	var hdrs []*IdentityHeader
+	for _, hdr := range req.GetHeaders("Identity") {
+		if h, ok := hdr.(*IdentityHeader); ok {
+			hdrs = append(hdrs, h)
+			continue
+		}
+		for _, v := range splitHeaderList(hdr.Value()) {
+			h := &IdentityHeader{}
+			if err := ParseIdentityHeader(v, h); err != nil {
+				continue
+			}
+			hdrs = append(hdrs, h)
+		}
+	}
+	return hdrs
+}
+
+// ParseIdentityHeader parses Identity header value
+func ParseIdentityHeader(headerText string, h *IdentityHeader) error {
+	token, rest, _ := strings.Cut(strings.TrimSpace(headerText), ";")
+	h.Token = strings.TrimSpace(token)
+	if h.Token == "" {
+		return errors.New("empty token in Identity header")
+	}
+
+	for {
+		rest = strings.TrimLeft(rest, " ;")
+		if rest == "" {
+			break
+		}
+		end := strings.IndexByte(rest, ';')
+		if lt := strings.IndexByte(rest, '<'); lt >= 0 && (end < 0 || lt < end) {
+			// Value in angle brackets can contain semicolon
+			gt := strings.IndexByte(rest[lt:], '>')
+			if gt < 0 {
+				return errors.New("unclosed angle bracket in Identity header")
+			}
+			if end = strings.IndexByte(rest[lt+gt:], ';'); end >= 0 {
+				end += lt + gt
+			}
+		}
+		param := rest
+		if end >= 0 {
+			param, rest = rest[:end], rest[end:]
+		} else {
+			rest = ""
+		}
+
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		if name == "" {
+			continue
+		}
+		if name == "info" {
+			if len(value) < 2 || value[0] != '<' || value[len(value)-1] != '>' {
+				return errors.New("invalid info in Identity header")
+			}
+			h.Info = value[1 : len(value)-1]
+			continue
+		}
+		if h.Params == nil {
+			h.Params = NewParams()
+		}
+		h.Params.Add(name, value)
+	}
+	return nil
+}
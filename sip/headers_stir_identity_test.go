@@ -0,0 +1,32 @@
+package sip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentityHeader(t *testing.T) {
+	req := NewRequest(INVITE, &Uri{User: "bob", Host: "example.com"})
+	req.AppendHeader(NewHeader("Identity", "eyJhbGciOiJFUzI1NiJ9.eyJpYXQiOjF9.c2ln;info=<https://cert.example.org/a;b.cer>;alg=ES256;ppt=\"shaken\""))
+	req.AppendHeader(NewHeader("Identity", "aaa.bbb.ccc;ppt=div, ddd.eee.fff"))
+
+	hdrs := req.Identity()
+	require.Len(t, hdrs, 3)
+	assert.Equal(t, "eyJhbGciOiJFUzI1NiJ9.eyJpYXQiOjF9.c2ln", hdrs[0].Token)
+	assert.Equal(t, "https://cert.example.org/a;b.cer", hdrs[0].Info)
+	assert.Equal(t, "ES256", hdrs[0].Params["alg"])
+	assert.Equal(t, "\"shaken\"", hdrs[0].Params["ppt"])
+	assert.Equal(t, "div", hdrs[1].Params["ppt"])
+	assert.Equal(t, "ddd.eee.fff", hdrs[2].Token)
+	assert.Empty(t, hdrs[2].Info)
+
+	h := &IdentityHeader{Token: "a.b.c", Info: "https://cert.example.org/passport.cer"}
+	assert.Equal(t, "Identity: a.b.c;info=<https://cert.example.org/passport.cer>", h.String())
+
+	var parsed IdentityHeader
+	assert.Error(t, ParseIdentityHeader(";info=<https://a>", &parsed))
+	assert.Error(t, ParseIdentityHeader("a.b.c;info=https://a", &parsed))
+	assert.Error(t, ParseIdentityHeader("a.b.c;info=<https://a", &parsed))
+}
@@ -0,0 +1,101 @@
+package sip
+
+import (
+	"io"
+	"strings"
+)
+
+// TargetDialogHeader is Target-Dialog header representation https://datatracker.ietf.org/doc/html/rfc4538
+// Target-Dialog: fa77as7dad8-sd98ajzz@host.example.com;local-tag=kkaz-;remote-tag=6544
+// Tags are from perspective of UA sending request. Local tag is sender tag and remote tag is recipient tag
+type TargetDialogHeader struct {
+	CallID    string
+	LocalTag  string
+	RemoteTag string
+	// Params are other generic params
+	Params HeaderParams
+}
+
+func (h *TargetDialogHeader) Name() string { return "Target-Dialog" }
+
+func (h *TargetDialogHeader) Value() string {
+	var buffer strings.Builder
+	h.ValueStringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *TargetDialogHeader) ValueStringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.CallID)
+	buffer.WriteString(";local-tag=")
+	buffer.WriteString(h.LocalTag)
+	buffer.WriteString(";remote-tag=")
+	buffer.WriteString(h.RemoteTag)
+	if len(h.Params) > 0 {
+		buffer.WriteString(";")
+		buffer.WriteString(h.Params.ToString(';'))
+	}
+}
+
+func (h *TargetDialogHeader) String() string {
+	var buffer strings.Builder
+	h.StringWrite(&buffer)
+	return buffer.String()
+}
+
+func (h *TargetDialogHeader) StringWrite(buffer io.StringWriter) {
+	buffer.WriteString(h.Name())
+	buffer.WriteString(": ")
+	h.ValueStringWrite(buffer)
+}
+
+func (h *TargetDialogHeader) headerClone() Header {
+	return h.Clone()
+}
+
+func (h *TargetDialogHeader) Clone() *TargetDialogHeader {
+	c := *h
+	if h.Params != nil {
+		c.Params = h.Params.clone()
+	}
+	return &c
+}
+
+// DialogIDUAS returns dialog ID of target dialog in case receiving UA was UAS of that dialog
+func (h *TargetDialogHeader) DialogIDUAS() string {
+	return MakeDialogID(h.CallID, h.RemoteTag, h.LocalTag)
+}
+
+// DialogIDUAC returns dialog ID of target dialog in case receiving UA was UAC of that dialog
+func (h *TargetDialogHeader) DialogIDUAC() string {
+	return MakeDialogID(h.CallID, h.LocalTag, h.RemoteTag)
+}
+
+// TargetDialog returns Target-Dialog parsed header or nil if not exists
+func (req *Request) TargetDialog() *TargetDialogHeader {
+	hdr := req.GetHeader("Target-Dialog")
+	if hdr == nil {
+		return nil
+	}
+	if h, ok := hdr.(*TargetDialogHeader); ok {
+		return h
+	}
+
+	h := &TargetDialogHeader{}
+	if err := parseTargetDialogHeader(hdr.Value(), h); err != nil {
+		return nil
+	}
+	return h
+}
+
+func parseTargetDialogHeader(headerText string, h *TargetDialogHeader) error {
+	callid, localTag, remoteTag, params, err := parseDialogRefHeader(headerText, "Target-Dialog", "local-tag", "remote-tag")
+	if err != nil {
+		return err
+	}
+
+	h.CallID = callid
+	h.LocalTag = localTag
+	h.RemoteTag = remoteTag
+	h.Params = params
+	return nil
+}
@@ -0,0 +1,29 @@
+package sip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTargetDialogHeader(t *testing.T) {
+	req := NewRequest(REFER, &Uri{User: "bob", Host: "example.com"})
+	req.AppendHeader(NewHeader("Target-Dialog", "fa77as7dad8-sd98ajzz@host.example.com;local-tag=kkaz-;remote-tag=6544"))
+
+	target := req.TargetDialog()
+	require.NotNil(t, target)
+	assert.Equal(t, "fa77as7dad8-sd98ajzz@host.example.com", target.CallID)
+	assert.Equal(t, "kkaz-", target.LocalTag)
+	assert.Equal(t, "6544", target.RemoteTag)
+	assert.Nil(t, target.Params)
+	assert.Equal(t, "Target-Dialog: fa77as7dad8-sd98ajzz@host.example.com;local-tag=kkaz-;remote-tag=6544", target.String())
+
+	assert.Equal(t, MakeDialogID(target.CallID, "6544", "kkaz-"), target.DialogIDUAS())
+	assert.Equal(t, MakeDialogID(target.CallID, "kkaz-", "6544"), target.DialogIDUAC())
+
+	for _, v := range []string{"", "abc;local-tag=1", "abc;remote-tag=2"} {
+		var h TargetDialogHeader
+		assert.Error(t, parseTargetDialogHeader(v, &h), v)
+	}
+}
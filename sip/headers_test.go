@@ -88,3 +88,58 @@ func TestMaxForwardIncDec(t *testing.T) {
 	maxfwd.Dec()
 	assert.Equal(t, uint32(69), maxfwd.Val(), "Value returned %d", maxfwd.Val())
 }
+
+func TestExpiresHeaderAccessor(t *testing.T) {
+	req := NewRequest(REGISTER, &Uri{Host: "example.com"})
+	assert.Nil(t, req.Expires())
+
+	req.AppendHeader(NewHeader("Expires", " 3600"))
+	require.NotNil(t, req.Expires())
+	assert.Equal(t, ExpiresHeader(3600), *req.Expires())
+
+	req.ReplaceHeader(NewHeader("Expires", "abc"))
+	assert.Nil(t, req.Expires())
+}
+
+func TestHeadersEachMutation(t *testing.T) {
+	hs := headers{}
+	hs.AppendHeader(&ViaHeader{Host: "first"})
+	hs.AppendHeader(NewHeader("X-Custom", "1"))
+	hs.AppendHeader(&ViaHeader{Host: "second"})
+	hs.AppendHeader(NewHeader("x-custom", "2"))
+
+	var names []string
+	hs.EachHeader(func(h Header) bool {
+		names = append(names, h.Name())
+		if h.Name() == "X-Custom" {
+			hs.RemoveHeaders("X-Custom")
+		}
+		return true
+	})
+	assert.Equal(t, []string{"Via", "X-Custom", "Via", "x-custom"}, names)
+	assert.Nil(t, hs.GetHeader("x-custom"))
+
+	t.Run("Stop", func(t *testing.T) {
+		n := 0
+		hs.EachHeader(func(h Header) bool {
+			n++
+			return false
+		})
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run("ReplaceHeaders", func(t *testing.T) {
+		hs.ReplaceHeaders("via", &ViaHeader{Host: "new"})
+		require.Len(t, hs.GetHeaders("via"), 1)
+		assert.Equal(t, "new", hs.Via().Host)
+
+		hs.ReplaceHeaders("X-New", NewHeader("X-New", "a"), NewHeader("X-New", "b"))
+		assert.Len(t, hs.GetHeaders("x-new"), 2)
+	})
+
+	t.Run("RemoveHeaders", func(t *testing.T) {
+		assert.Equal(t, 1, hs.RemoveHeaders("Via"))
+		assert.Nil(t, hs.Via())
+		assert.Equal(t, 0, hs.RemoveHeaders("Via"))
+	})
+}
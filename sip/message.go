@@ -21,30 +21,38 @@ const (
 	StatusQueued            StatusCode = 182
 	StatusSessionInProgress StatusCode = 183
 
-	StatusOK StatusCode = 200
+	StatusOK       StatusCode = 200
+	StatusAccepted StatusCode = 202
 
 	StatusMovedPermanently StatusCode = 301
 	StatusMovedTemporarily StatusCode = 302
 	StatusUseProxy         StatusCode = 305
 
-	StatusBadRequest                   StatusCode = 400
-	StatusUnauthorized                 StatusCode = 401
-	StatusPaymentRequired              StatusCode = 402
-	StatusForbidden                    StatusCode = 403
-	StatusNotFound                     StatusCode = 404
-	StatusMethodNotAllowed             StatusCode = 405
-	StatusNotAcceptable                StatusCode = 406
-	StatusProxyAuthRequired            StatusCode = 407
-	StatusRequestTimeout               StatusCode = 408
-	StatusConflict                     StatusCode = 409
-	StatusGone                         StatusCode = 410
-	StatusRequestEntityTooLarge        StatusCode = 413
-	StatusRequestURITooLong            StatusCode = 414
-	StatusUnsupportedMediaType         StatusCode = 415
+	StatusBadRequest               StatusCode = 400
+	StatusUnauthorized             StatusCode = 401
+	StatusPaymentRequired          StatusCode = 402
+	StatusForbidden                StatusCode = 403
+	StatusNotFound                 StatusCode = 404
+	StatusMethodNotAllowed         StatusCode = 405
+	StatusNotAcceptable            StatusCode = 406
+	StatusProxyAuthRequired        StatusCode = 407
+	StatusRequestTimeout           StatusCode = 408
+	StatusConflict                 StatusCode = 409
+	StatusGone                     StatusCode = 410
+	StatusConditionalRequestFailed StatusCode = 412
+	StatusRequestEntityTooLarge    StatusCode = 413
+	StatusRequestURITooLong        StatusCode = 414
+	StatusUnsupportedMediaType     StatusCode = 415
+	StatusUnsupportedURIScheme     StatusCode = 416
+	// Deprecated: 416 is Unsupported URI Scheme in SIP. Use StatusUnsupportedURIScheme
 	StatusRequestedRangeNotSatisfiable StatusCode = 416
 	StatusBadExtension                 StatusCode = 420
 	StatusExtensionRequired            StatusCode = 421
 	StatusIntervalToBrief              StatusCode = 423
+	StatusUseIdentityHeader            StatusCode = 428
+	StatusBadIdentityInfo              StatusCode = 436
+	StatusUnsupportedCredential        StatusCode = 437
+	StatusInvalidIdentityHeader        StatusCode = 438
 	StatusTemporarilyUnavailable       StatusCode = 480
 	StatusCallTransactionDoesNotExists StatusCode = 481
 	StatusLoopDetected                 StatusCode = 482
@@ -54,6 +62,8 @@ const (
 	StatusBusyHere                     StatusCode = 486
 	StatusRequestTerminated            StatusCode = 487
 	StatusNotAcceptableHere            StatusCode = 488
+	StatusBadEvent                     StatusCode = 489
+	StatusRequestPending               StatusCode = 491
 
 	StatusInternalServerError StatusCode = 500
 	StatusNotImplemented      StatusCode = 501
@@ -69,6 +79,75 @@ const (
 	StatusGlobalNotAcceptable        StatusCode = 606
 )
 
+var statusText = map[StatusCode]string{
+	StatusTrying:            "Trying",
+	StatusRinging:           "Ringing",
+	StatusCallIsForwarded:   "Call Is Being Forwarded",
+	StatusQueued:            "Queued",
+	StatusSessionInProgress: "Session Progress",
+
+	StatusOK:       "OK",
+	StatusAccepted: "Accepted",
+
+	StatusMovedPermanently: "Moved Permanently",
+	StatusMovedTemporarily: "Moved Temporarily",
+	StatusUseProxy:         "Use Proxy",
+
+	StatusBadRequest:                   "Bad Request",
+	StatusUnauthorized:                 "Unauthorized",
+	StatusPaymentRequired:              "Payment Required",
+	StatusForbidden:                    "Forbidden",
+	StatusNotFound:                     "Not Found",
+	StatusMethodNotAllowed:             "Method Not Allowed",
+	StatusNotAcceptable:                "Not Acceptable",
+	StatusProxyAuthRequired:            "Proxy Authentication Required",
+	StatusRequestTimeout:               "Request Timeout",
+	StatusConflict:                     "Conflict",
+	StatusGone:                         "Gone",
+	StatusConditionalRequestFailed:     "Conditional Request Failed",
+	StatusRequestEntityTooLarge:        "Request Entity Too Large",
+	StatusRequestURITooLong:            "Request-URI Too Long",
+	StatusUnsupportedMediaType:         "Unsupported Media Type",
+	StatusUnsupportedURIScheme:         "Unsupported URI Scheme",
+	StatusBadExtension:                 "Bad Extension",
+	StatusExtensionRequired:            "Extension Required",
+	StatusIntervalToBrief:              "Interval Too Brief",
+	StatusUseIdentityHeader:            "Use Identity Header",
+	StatusBadIdentityInfo:              "Bad Identity Info",
+	StatusUnsupportedCredential:        "Unsupported Credential",
+	StatusInvalidIdentityHeader:        "Invalid Identity Header",
+	StatusTemporarilyUnavailable:       "Temporarily Unavailable",
+	StatusCallTransactionDoesNotExists: "Call/Transaction Does Not Exist",
+	StatusLoopDetected:                 "Loop Detected",
+	StatusTooManyHops:                  "Too Many Hops",
+	StatusAddressIncomplete:            "Address Incomplete",
+	StatusAmbiguous:                    "Ambiguous",
+	StatusBusyHere:                     "Busy Here",
+	StatusRequestTerminated:            "Request Terminated",
+	StatusNotAcceptableHere:            "Not Acceptable Here",
+	StatusBadEvent:                     "Bad Event",
+	StatusRequestPending:               "Request Pending",
+
+	StatusInternalServerError: "Server Internal Error",
+	StatusNotImplemented:      "Not Implemented",
+	StatusBadGateway:          "Bad Gateway",
+	StatusServiceUnavailable:  "Service Unavailable",
+	StatusGatewayTimeout:      "Server Time-out",
+	StatusVersionNotSupported: "Version Not Supported",
+	StatusMessageTooLarge:     "Message Too Large",
+
+	StatusGlobalBusyEverywhere:       "Busy Everywhere",
+	StatusGlobalDecline:              "Decline",
+	StatusGlobalDoesNotExistAnywhere: "Does Not Exist Anywhere",
+	StatusGlobalNotAcceptable:        "Not Acceptable",
+}
+
+// StatusText returns reason phrase for status code https://datatracker.ietf.org/doc/html/rfc3261#section-21.
+// Empty string is returned for unknown code
+func StatusText(code StatusCode) string {
+	return statusText[code]
+}
+
 // method names are defined here as constants for convenience.
 const (
 	INVITE    RequestMethod = "INVITE"
@@ -119,6 +198,10 @@ type Message interface {
 	SetSource(src string)
 	Destination() string
 	SetDestination(dest string)
+	LocalAddr() string
+	SetLocalAddr(laddr string)
+	ReceivedTransport() string
+	SetReceivedTransport(tp string)
 }
 
 type MessageData struct {
@@ -132,6 +215,10 @@ type MessageData struct {
 	// This is for internal routing
 	src  string
 	dest string
+	// laddr is local address on which message is received
+	laddr string
+	// rtp is transport on which message is received
+	rtp string
 }
 
 func (msg *MessageData) Body() []byte {
@@ -162,6 +249,21 @@ func (msg *MessageData) SetBody(body []byte) {
 	msg.AppendHeader(&length)
 }
 
+// SetContent sets body with its Content-Type and Content-Length together.
+// Empty content type removes Content-Type, ex. for empty body
+// Ex:
+//
+//	req.SetContent("application/sdp", sdp)
+func (msg *MessageData) SetContent(contentType string, body []byte) {
+	for msg.RemoveHeader("Content-Type") {
+	}
+	if contentType != "" {
+		ct := ContentTypeHeader(contentType)
+		msg.AppendHeader(&ct)
+	}
+	msg.SetBody(body)
+}
+
 func (msg *MessageData) Transport() string {
 	return msg.tp
 }
@@ -185,3 +287,23 @@ func (msg *MessageData) Destination() string {
 func (msg *MessageData) SetDestination(dest string) {
 	msg.dest = dest
 }
+
+// LocalAddr is local host:port address on which message is received.
+// It is empty for messages not received from network
+func (msg *MessageData) LocalAddr() string {
+	return msg.laddr
+}
+
+func (msg *MessageData) SetLocalAddr(laddr string) {
+	msg.laddr = laddr
+}
+
+// ReceivedTransport is transport on which message is received. Unlike Transport it is not changed
+// when message is forwarded over other transport. It is empty for messages not received from network
+func (msg *MessageData) ReceivedTransport() string {
+	return msg.rtp
+}
+
+func (msg *MessageData) SetReceivedTransport(tp string) {
+	msg.rtp = tp
+}
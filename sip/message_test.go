@@ -0,0 +1,73 @@
+package sip
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageSetContent(t *testing.T) {
+	req, _, _ := testCreateInvite(t, "sip:bob@127.0.0.1:5060", "udp", "127.0.0.2:5060")
+	req.SetContent("text/plain", []byte("hello"))
+	assert.Equal(t, "text/plain", req.ContentType().Value())
+	assert.Equal(t, "5", req.ContentLength().Value())
+
+	req.SetContent("application/sdp", []byte("v=0\r\n"))
+	require.Len(t, req.GetHeaders("Content-Type"), 1)
+	assert.Equal(t, "application/sdp", req.ContentType().Value())
+	assert.Equal(t, "5", req.ContentLength().Value())
+
+	req.SetContent("", nil)
+	assert.Nil(t, req.ContentType())
+	assert.Equal(t, "0", req.ContentLength().Value())
+}
+
+func TestMessageContentLengthSerialize(t *testing.T) {
+	body := []byte("v=0\r\n")
+	newReq := func() *Request {
+		req, _, _ := testCreateInvite(t, "sip:bob@127.0.0.1:5060", "udp", "127.0.0.2:5060")
+		req.RemoveHeader("Content-Length")
+		return req
+	}
+
+	t.Run("Stale", func(t *testing.T) {
+		req := newReq()
+		req.AppendHeader(NewHeader("Content-Length", "100"))
+		req.AppendHeader(NewHeader("l", "3"))
+		req.body = body
+		s := req.String()
+		assert.Equal(t, 1, strings.Count(s, "Content-Length"))
+		assert.Contains(t, s, "Content-Length: 5\r\n")
+		assert.NotContains(t, s, "\r\nl: ")
+		// Header is fixed on message as well
+		assert.Equal(t, "5", req.ContentLength().Value())
+		assert.Len(t, req.GetHeaders("Content-Length"), 1)
+
+		msg, err := ParseMessage([]byte(s))
+		require.NoError(t, err)
+		assert.Equal(t, body, msg.Body())
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		req := newReq()
+		req.body = body
+		assert.True(t, strings.HasSuffix(req.String(), "Content-Length: 5\r\n\r\nv=0\r\n"))
+
+		assert.Equal(t, "5", req.ContentLength().Value())
+
+		// Empty body gets Content-Length: 0 as stream transports require it
+		req = newReq()
+		assert.True(t, strings.HasSuffix(req.String(), "Content-Length: 0\r\n\r\n"))
+		assert.Equal(t, "0", req.ContentLength().Value())
+	})
+
+	t.Run("Response", func(t *testing.T) {
+		req := newReq()
+		res := NewResponseFromRequest(req, StatusOK, "OK", body)
+		res.ReplaceHeader(NewHeader("Content-Length", "0"))
+		assert.Contains(t, res.String(), "Content-Length: 5\r\n")
+		assert.Equal(t, "5", res.ContentLength().Value())
+	})
+}
@@ -81,7 +81,7 @@ func (headersParser mapHeadersParser) parseMsgHeader(msg Message, headerText str
 	lowerFieldName := HeaderToLower(fieldName)
 	fieldText := strings.TrimSpace(headerText[colonIdx+1:])
 
-	headerParser, ok := headersParsers[lowerFieldName]
+	headerParser, ok := headersParser[lowerFieldName]
 	if !ok {
 		// We have no registered parser for this header type,
 		// so we encapsulate the header data in a GenericHeader struct.
@@ -158,6 +158,12 @@ func parseMaxForwardsHeader(headerText string, maxfwd *MaxForwardsHeader) error
 	return err
 }
 
+func parseExpiresHeader(headerText string, expires *ExpiresHeader) error {
+	val, err := strconv.ParseUint(strings.TrimSpace(headerText), 10, 32)
+	*expires = ExpiresHeader(val)
+	return err
+}
+
 func headerParserCSeq(headerName string, headerText string) (headers Header, err error) {
 	var cseq CSeqHeader
 	return &cseq, parseCSeqHeader(headerText, &cseq)
@@ -33,11 +33,25 @@ func uriStateSIP(uri *Uri, s string) (uriFSM, string, error) {
 	} else if len(s) >= 5 && strings.EqualFold(s[:5], "sips:") {
 		uri.Encrypted = true
 		return uriStateUser, s[5:], nil
+	} else if len(s) >= 4 && strings.EqualFold(s[:4], "tel:") {
+		uri.Tel = true
+		return uriStateTel, s[4:], nil
 	} else {
 		return uriStateHost, s, nil
 	}
 }
 
+// uriStateTel parses tel URI number. Parameters are parsed as uri params
+// https://datatracker.ietf.org/doc/html/rfc3966#section-3
+func uriStateTel(uri *Uri, s string) (uriFSM, string, error) {
+	number, params, _ := strings.Cut(s, ";")
+	if number == "" {
+		return nil, "", errors.New("empty tel number")
+	}
+	uri.User = number
+	return uriStateUriParams, params, nil
+}
+
 func uriStateUser(uri *Uri, s string) (uriFSM, string, error) {
 	var userend int = 0
 	for i, c := range s {
@@ -71,6 +85,10 @@ func uriStatePassword(uri *Uri, s string) (uriFSM, string, error) {
 }
 
 func uriStateHost(uri *Uri, s string) (uriFSM, string, error) {
+	if len(s) > 0 && s[0] == '[' {
+		return uriStateHostIPv6(uri, s)
+	}
+
 	for i, c := range s {
 		if c == ':' {
 			uri.Host = s[:i]
@@ -92,6 +110,30 @@ func uriStateHost(uri *Uri, s string) (uriFSM, string, error) {
 	return uriStateUriParams, "", nil
 }
 
+// uriStateHostIPv6 parses IPv6 reference host like [2001:db8::1]
+// https://datatracker.ietf.org/doc/html/rfc3261#section-25.1
+func uriStateHostIPv6(uri *Uri, s string) (uriFSM, string, error) {
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return nil, "", fmt.Errorf("missing ] in IPv6 reference")
+	}
+	uri.Host = s[1:end]
+	s = s[end+1:]
+	if len(s) == 0 {
+		return uriStateUriParams, "", nil
+	}
+
+	switch s[0] {
+	case ':':
+		return uriStatePort, s[1:], nil
+	case ';':
+		return uriStateUriParams, s[1:], nil
+	case '?':
+		return uriStateHeaders, s[1:], nil
+	}
+	return nil, "", fmt.Errorf("unexpected char after IPv6 reference")
+}
+
 func uriStatePort(uri *Uri, s string) (uriFSM, string, error) {
 	var err error
 	for i, c := range s {
@@ -78,14 +78,22 @@ func viaStateHost(h *ViaHeader, s string) (viaFSM, int, error) {
 	var colonInd int
 	var endIndex int = len(s)
 	var err error
+	var hostStart int
+	if len(s) > 0 && s[0] == '[' {
+		// IPv6 reference. Skip colons in address
+		hostStart = strings.IndexByte(s, ']')
+		if hostStart < 0 {
+			return nil, 0, errors.New("missing ] in IPv6 reference")
+		}
+	}
 loop:
-	for i, c := range s {
+	for i, c := range s[hostStart:] {
 		switch c {
 		case ';':
-			endIndex = i
+			endIndex = hostStart + i
 			break loop
 		case ':':
-			colonInd = i
+			colonInd = hostStart + i
 			// Uri has port
 		}
 	}
@@ -99,6 +107,7 @@ loop:
 	} else {
 		h.Host = s[:endIndex]
 	}
+	h.Host = hostUnbracket(h.Host)
 
 	if endIndex == len(s) {
 		return nil, 0, nil
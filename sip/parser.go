@@ -51,6 +51,8 @@ type Parser struct {
 	log zerolog.Logger
 	// HeadersParsers uses default list of headers to be parsed. Smaller list parser will be faster
 	headersParsers mapHeadersParser
+	// rawHeaders records header lines as received
+	rawHeaders bool
 }
 
 // ParserOption are addition option for NewParser. Check WithParser...
@@ -88,6 +90,30 @@ func WithHeadersParsers(m map[string]HeaderParser) ParserOption {
 	}
 }
 
+// WithParserRawHeaders makes parser record header lines as received, accessible with RawHeaders.
+// It has extra allocation per header, so use it only when original bytes are needed
+func WithParserRawHeaders() ParserOption {
+	return func(p *Parser) {
+		p.rawHeaders = true
+	}
+}
+
+// WithHeaderParser registers parser for header names, keeping default parsers.
+// Names should contain compact form as well if header has one.
+// Use it for proprietary headers which should be typed, check CustomHeaderParser
+func WithHeaderParser(parser HeaderParser, names ...string) ParserOption {
+	return func(p *Parser) {
+		m := make(mapHeadersParser, len(p.headersParsers)+len(names))
+		for k, v := range p.headersParsers {
+			m[k] = v
+		}
+		for _, n := range names {
+			m[HeaderToLower(n)] = parser
+		}
+		p.headersParsers = m
+	}
+}
+
 // ParseSIP converts data to sip message. Buffer must contain full sip message
 func (p *Parser) ParseSIP(data []byte) (msg Message, err error) {
 	reader := bufReader.Get().(*bytes.Buffer)
@@ -120,6 +146,9 @@ func (p *Parser) ParseSIP(data []byte) (msg Message, err error) {
 			break
 		}
 
+		if p.rawHeaders {
+			parseRawHeader(msg, line)
+		}
 		err = p.headersParsers.parseMsgHeader(msg, line)
 		if err != nil {
 			p.log.Info().Err(err).Str("line", line).Msg("skip header due to error")
@@ -160,6 +189,7 @@ func (p *Parser) ParseSIP(data []byte) (msg Message, err error) {
 func (p *Parser) NewSIPStream() *ParserStream {
 	return &ParserStream{
 		headersParsers: p.headersParsers, // safe as it read only
+		rawHeaders:     p.rawHeaders,
 	}
 }
 
@@ -29,6 +29,7 @@ var streamBufReader = sync.Pool{
 type ParserStream struct {
 	// HeadersParsers uses default list of headers to be parsed. Smaller list parser will be faster
 	headersParsers mapHeadersParser
+	rawHeaders     bool
 
 	// runtime values
 	reader            *bytes.Buffer
@@ -97,6 +98,9 @@ func (p *ParserStream) ParseSIPStream(data []byte) (msgs []Message, err error) {
 					break
 				}
 
+				if p.rawHeaders {
+					parseRawHeader(msg, line)
+				}
 				err = p.headersParsers.parseMsgHeader(msg, line)
 				if err != nil {
 					return nil, fmt.Errorf("%s: %w", err.Error(), ErrParseInvalidMessage)
@@ -98,6 +98,30 @@ func TestParseUri(t *testing.T) {
 	branch, _ := uri.UriParams.Get("branch")
 	assert.Equal(t, "", rport)
 	assert.Equal(t, "z9hG4bKPj6c65c5d9-b6d0-4a30-9383-1f9b42f97de9", branch)
+
+	t.Run("IPv6", func(t *testing.T) {
+		for str, expected := range map[string]Uri{
+			"sip:alice@[2001:db8::1]:5060;transport=tcp": {User: "alice", Host: "2001:db8::1", Port: 5060},
+			"sip:alice@[2001:db8::1]":                    {User: "alice", Host: "2001:db8::1"},
+			"sips:[::1]:5061":                            {Host: "::1", Port: 5061, Encrypted: true},
+			"sip:[fe80::1];lr":                           {Host: "fe80::1"},
+		} {
+			uri := Uri{}
+			err := ParseUri(str, &uri)
+			require.NoError(t, err, str)
+			assert.Equal(t, expected.User, uri.User)
+			assert.Equal(t, expected.Host, uri.Host)
+			assert.Equal(t, expected.Port, uri.Port)
+			assert.Equal(t, expected.Encrypted, uri.Encrypted)
+			assert.Equal(t, str, uri.String())
+		}
+
+		uri := Uri{}
+		require.Error(t, ParseUri("sip:alice@[2001:db8::1", &uri))
+
+		uri = Uri{Host: "2001:db8::1", Port: 5060}
+		assert.Equal(t, "[2001:db8::1]:5060", uri.HostPort())
+	})
 }
 
 func TestUnmarshalParams(t *testing.T) {
@@ -137,6 +161,18 @@ func TestParseHeaders(t *testing.T) {
 		assert.True(t, hstr == header || hstr == unordered, hstr)
 	})
 
+	t.Run("ViaHeaderIPv6", func(t *testing.T) {
+		for header, host := range map[string]string{
+			"Via: SIP/2.0/UDP [2001:db8::1]:5060;branch=z9hG4bK776asdhds": "2001:db8::1",
+			"Via: SIP/2.0/TCP [::1];branch=z9hG4bK776asdhds":              "::1",
+		} {
+			h := testParseHeader(t, parser, header)
+			via := h.(*ViaHeader)
+			assert.Equal(t, host, via.Host)
+			assert.Equal(t, header, h.String())
+		}
+	})
+
 	t.Run("ToHeader", func(t *testing.T) {
 		header := "To: \"Bob\" <sip:bob@127.0.0.1:5060>;xxx=xxx;yyyy=yyyy"
 		h := testParseHeader(t, parser, header)
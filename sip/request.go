@@ -3,6 +3,7 @@ package sip
 import (
 	"fmt"
 	"io"
+	"net"
 	"strconv"
 	"strings"
 )
@@ -12,6 +13,11 @@ type Request struct {
 	MessageData
 	Method    RequestMethod
 	Recipient *Uri
+
+	// connInfo is set when request is received over connection oriented transport
+	connInfo *ConnectionInfo
+	// trail records decisions of request processing
+	trail *Trail
 }
 
 // NewRequest creates base for building sip Request
@@ -73,7 +79,8 @@ func (req *Request) StringWrite(buffer io.StringWriter) {
 	//  the empty line MUST be present even if the message-body is not.
 	req.StartLineWrite(buffer)
 	buffer.WriteString("\r\n")
-	// Write the headers.
+	// Write the headers. Content-Length is synced with body
+	req.headers.syncContentLength(len(req.body))
 	req.headers.StringWrite(buffer)
 	// Empty line
 	buffer.WriteString("\r\n")
@@ -89,6 +96,12 @@ func (req *Request) Clone() *Request {
 	return cloneRequest(req)
 }
 
+// ConnectionInfo returns identity of connection request is received on.
+// It is nil for UDP or for requests not received from network
+func (req *Request) ConnectionInfo() *ConnectionInfo {
+	return req.connInfo
+}
+
 func (req *Request) IsInvite() bool {
 	return req.Method == INVITE
 }
@@ -125,8 +138,10 @@ func (req *Request) Transport() string {
 			}
 		}
 
+		// SIPS must be sent over TLS, UDP is never allowed
+		// https://datatracker.ietf.org/doc/html/rfc5630#section-3.1.3
 		if uri.IsEncrypted() {
-			if tp == "TCP" {
+			if tp == "TCP" || tp == "UDP" {
 				tp = "TLS"
 			} else if tp == "WS" {
 				tp = "WSS"
@@ -178,7 +193,7 @@ func (req *Request) Source() string {
 		}
 	}
 
-	return fmt.Sprintf("%v:%v", host, port)
+	return net.JoinHostPort(hostUnbracket(host), strconv.Itoa(port))
 }
 
 func (req *Request) Destination() string {
@@ -199,13 +214,7 @@ func (req *Request) Destination() string {
 		}
 	}
 
-	host := uri.Host
-	if uri.Port > 0 {
-		return fmt.Sprintf("%v:%v", host, uri.Port)
-	}
-
-	port := DefaultPort(req.Transport())
-	return fmt.Sprintf("%v:%v", host, port)
+	return uriDestination(uri, req.Transport())
 }
 
 // NewAckRequest creates ACK request for 2xx INVITE
@@ -223,14 +232,12 @@ func NewAckRequest(inviteRequest *Request, inviteResponse *Response, body []byte
 	)
 	ackRequest.SipVersion = inviteRequest.SipVersion
 
-	if len(inviteRequest.GetHeaders("Route")) > 0 {
-		CopyHeaders("Route", inviteRequest, ackRequest)
+	if inviteResponse.IsSuccess() {
+		// 2xx ACK follows dialog route set, which is Record-Route of response in reverse order
+		// https://datatracker.ietf.org/doc/html/rfc3261#section-12.2.1.1
+		RouteSetApply(ackRequest, RouteSetFromRecordRoute(inviteResponse, true))
 	} else {
-		hdrs := inviteResponse.GetHeaders("Record-Route")
-		for i := len(hdrs) - 1; i >= 0; i-- {
-			h := hdrs[i].headerClone()
-			ackRequest.AppendHeader(h)
-		}
+		CopyHeaders("Route", inviteRequest, ackRequest)
 	}
 
 	maxForwardsHeader := MaxForwardsHeader(70)
@@ -272,7 +279,9 @@ func NewAckRequest(inviteRequest *Request, inviteResponse *Response, body []byte
 	ackRequest.SetBody(body)
 	ackRequest.SetTransport(inviteRequest.Transport())
 	ackRequest.SetSource(inviteRequest.Source())
-	ackRequest.SetDestination(inviteRequest.Destination())
+	if !inviteResponse.IsSuccess() || ackRequest.Route() == nil {
+		ackRequest.SetDestination(inviteRequest.Destination())
+	}
 
 	return ackRequest
 }
@@ -400,6 +409,10 @@ func cloneRequest(req *Request) *Request {
 	newReq.SetTransport(req.Transport())
 	newReq.SetSource(req.Source())
 	newReq.SetDestination(req.Destination())
+	newReq.laddr = req.laddr
+	newReq.rtp = req.rtp
+	newReq.raw = req.raw
+	newReq.trail = req.trail
 
 	return newReq
 }
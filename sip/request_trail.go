@@ -0,0 +1,90 @@
+package sip
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TrailEntry is single decision taken while processing request
+type TrailEntry struct {
+	// Elapsed is time since trail is created
+	Elapsed time.Duration
+	// Source is component taking decision, ex. router
+	Source   string
+	Decision string
+}
+
+// Trail records decisions taken while processing request, like matched middleware,
+// selected route or applied manipulations, for debugging complex routing pipelines.
+// It is safe for concurrent use. Nil Trail ignores all records, so components can always record
+type Trail struct {
+	start time.Time
+
+	mu      sync.Mutex
+	entries []TrailEntry
+}
+
+// NewTrail creates empty trail
+func NewTrail() *Trail {
+	return &Trail{start: time.Now()}
+}
+
+// Add records decision of source
+func (t *Trail) Add(source string, decision string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.entries = append(t.entries, TrailEntry{
+		Elapsed:  time.Since(t.start),
+		Source:   source,
+		Decision: decision,
+	})
+}
+
+// Addf records decision of source formatted like fmt.Sprintf
+func (t *Trail) Addf(source string, format string, args ...any) {
+	if t == nil {
+		return
+	}
+	t.Add(source, fmt.Sprintf(format, args...))
+}
+
+// Entries returns copy of recorded decisions in order
+func (t *Trail) Entries() []TrailEntry {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entries := make([]TrailEntry, len(t.entries))
+	copy(entries, t.entries)
+	return entries
+}
+
+// String returns decisions in single line, ex. "router: matched _1XX -> screening: busy"
+func (t *Trail) String() string {
+	var sb strings.Builder
+	for i, e := range t.Entries() {
+		if i > 0 {
+			sb.WriteString(" -> ")
+		}
+		sb.WriteString(e.Source)
+		sb.WriteString(": ")
+		sb.WriteString(e.Decision)
+	}
+	return sb.String()
+}
+
+// Trail returns decision trail of request. It is nil unless trail is set
+func (req *Request) Trail() *Trail {
+	return req.trail
+}
+
+// SetTrail sets decision trail of request. Cloned request shares trail with original
+func (req *Request) SetTrail(t *Trail) {
+	req.trail = t
+}
@@ -0,0 +1,28 @@
+package sip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestTrail(t *testing.T) {
+	req := NewRequest(INVITE, &Uri{User: "bob", Host: "example.com"})
+	assert.Nil(t, req.Trail())
+	assert.Empty(t, req.Trail().String())
+
+	req.SetTrail(NewTrail())
+	req.Trail().Add("router", "matched bob")
+	req.Trail().Addf("trunk", "selected %s", "carrier1")
+
+	entries := req.Trail().Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, TrailEntry{Elapsed: entries[1].Elapsed, Source: "trunk", Decision: "selected carrier1"}, entries[1])
+	assert.Equal(t, "router: matched bob -> trunk: selected carrier1", req.Trail().String())
+
+	// Forwarded clone continues same trail
+	fwd := req.Clone()
+	fwd.Trail().Add("proxy", "forwarded")
+	assert.Len(t, req.Trail().Entries(), 3)
+}
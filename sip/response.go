@@ -3,6 +3,7 @@ package sip
 import (
 	"fmt"
 	"io"
+	"net"
 	"strconv"
 	"strings"
 
@@ -75,7 +76,8 @@ func (res *Response) String() string {
 func (res *Response) StringWrite(buffer io.StringWriter) {
 	res.StartLineWrite(buffer)
 	buffer.WriteString("\r\n")
-	// Write the headers.
+	// Write the headers. Content-Length is synced with body
+	res.headers.syncContentLength(len(res.body))
 	res.headers.StringWrite(buffer)
 	// Empty line
 	buffer.WriteString("\r\n")
@@ -167,6 +169,10 @@ func (res *Response) Destination() string {
 		port int
 	)
 
+	if dest, ok := viaMaddrDestination(viaHop, res.Transport()); ok {
+		return dest
+	}
+
 	host = viaHop.Host
 	if viaHop.Port > 0 {
 		port = viaHop.Port
@@ -185,7 +191,7 @@ func (res *Response) Destination() string {
 		}
 	}
 
-	return fmt.Sprintf("%v:%v", host, port)
+	return net.JoinHostPort(hostUnbracket(host), strconv.Itoa(port))
 }
 
 // RFC 3261 - 8.2.6
@@ -239,10 +245,34 @@ func NewResponseFromRequest(
 	res.SetTransport(req.Transport())
 	res.SetSource(req.Destination())
 	res.SetDestination(req.Source())
+	// Response is sent from same address as request is received on
+	res.SetLocalAddr(req.LocalAddr())
+
+	// Multicast request is responded to maddr https://datatracker.ietf.org/doc/html/rfc3261#section-18.2.2
+	if dest, ok := viaMaddrDestination(req.Via(), req.Transport()); ok && !IsReliable(req.Transport()) {
+		res.SetDestination(dest)
+	}
 
 	return res
 }
 
+// viaMaddrDestination returns maddr address with sent-by port if Via has maddr param
+func viaMaddrDestination(viaHop *ViaHeader, transport string) (string, bool) {
+	if viaHop == nil {
+		return "", false
+	}
+	maddr, ok := viaHop.Params.Get("maddr")
+	if !ok || maddr == "" {
+		return "", false
+	}
+
+	port := viaHop.Port
+	if port <= 0 {
+		port = DefaultPort(transport)
+	}
+	return net.JoinHostPort(hostUnbracket(maddr), strconv.Itoa(port)), true
+}
+
 // NewSDPResponseFromRequest is wrapper for 200 response with SDP body
 func NewSDPResponseFromRequest(req *Request, body []byte) *Response {
 	res := NewResponseFromRequest(req, StatusOK, "OK", body)
@@ -268,6 +298,7 @@ func cloneResponse(res *Response) *Response {
 	newRes.SetTransport(res.Transport())
 	newRes.SetSource(res.Source())
 	newRes.SetDestination(res.Destination())
+	newRes.raw = res.raw
 
 	return newRes
 }
@@ -0,0 +1,32 @@
+package sip
+
+import "errors"
+
+// ErrResponseWritten is returned when writing to ResponseWriter after final response is sent
+var ErrResponseWritten = errors.New("final response already written")
+
+// ResponseWriter is used by handler to construct response, similar to net/http ResponseWriter.
+// To tag and transaction are managed by writer, and all responses of transaction share same To tag.
+// Final response is sent once handler returns, or earlier with Flush. In case handler did not write
+// status code, 200 OK is sent
+// Ex:
+//
+//	srv.OnRequestWriter(sip.INVITE, func(w sip.ResponseWriter, req *sip.Request) {
+//		w.WriteHeader(sip.StatusRinging)
+//		w.AppendHeader(sip.NewHeader("Content-Type", "application/sdp"))
+//		w.Write(sdp)
+//	})
+type ResponseWriter interface {
+	// AppendHeader adds header to next response sent
+	AppendHeader(h Header)
+	// Write appends data to body of final response
+	Write(body []byte) (int, error)
+	// WriteHeader sends provisional response immediately. Final status code is stored
+	// and response is sent with body once handler returns or Flush is called
+	WriteHeader(code StatusCode)
+	// Flush sends final response with written status code, headers and body
+	Flush() error
+	// Transaction returns server transaction for lower level control, ex. reading CANCEL.
+	// Final response sent on it directly stops writer from sending one
+	Transaction() ServerTransaction
+}
@@ -0,0 +1,138 @@
+package sip
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrRouteSetMissing = errors.New("request targets proxy without route set")
+)
+
+// RouteMatcher reports does uri point to this element. It is used for removing own Route headers
+type RouteMatcher func(uri *Uri) bool
+
+// RouteProcess does Route processing of request received by proxy
+// https://datatracker.ietf.org/doc/html/rfc3261#section-16.4
+//
+// In case Request-URI matches us, previous hop was strict router and
+// remote target is restored from last Route. Top Route headers matching us are removed.
+// Multiple are removed in case of double Record-Route https://datatracker.ietf.org/doc/html/rfc5658#section-4
+func RouteProcess(req *Request, isOwn RouteMatcher) error {
+	if isOwn(req.Recipient) {
+		routes := req.GetHeaders("Route")
+		if len(routes) == 0 {
+			return ErrRouteSetMissing
+		}
+		last, ok := routes[len(routes)-1].(*RouteHeader)
+		if !ok {
+			return errors.New("invalid Route header")
+		}
+		req.Recipient = last.Address.Clone()
+		for req.RemoveHeader("Route") {
+		}
+		for _, h := range routes[:len(routes)-1] {
+			req.AppendHeader(h)
+		}
+	}
+
+	for route := req.Route(); route != nil && isOwn(&route.Address); route = req.Route() {
+		req.RemoveHeader("Route")
+	}
+	return nil
+}
+
+// RouteNextHop returns uri of next hop, which is top Route or Request-URI in case there is no Route.
+// In case top Route is strict router (no lr param), request is converted for strict router:
+// Request-URI is placed as last Route and top Route is moved to Request-URI.
+// Transport and destination of request are updated for next hop
+// https://datatracker.ietf.org/doc/html/rfc3261#section-16.6 step 6 and 7
+func RouteNextHop(req *Request) *Uri {
+	next := req.Recipient
+	if route := req.Route(); route != nil {
+		next = &route.Address
+		if !route.Address.UriParams.Has("lr") {
+			routeStrict(req, route)
+			next = req.Recipient
+		}
+	}
+
+	if tran, ok := next.UriParams.Get("transport"); ok && tran != "" {
+		req.SetTransport(strings.ToUpper(tran))
+	}
+
+	if req.Recipient == next && req.Route() != nil {
+		// Request-URI is next hop and has priority over Route
+		req.SetDestination(uriDestination(next, req.Transport()))
+	} else {
+		req.SetDestination("")
+	}
+	return next
+}
+
+// RouteSetApply builds Route headers of request from route set. Request Recipient must be set to remote target
+// https://datatracker.ietf.org/doc/html/rfc3261#section-12.2.1.1
+func RouteSetApply(req *Request, routeSet []Uri) {
+	for i := range routeSet {
+		req.AppendHeader(&RouteHeader{Address: *routeSet[i].Clone()})
+	}
+	if len(routeSet) > 0 && !routeSet[0].UriParams.Has("lr") {
+		routeStrict(req, req.Route())
+		req.SetDestination(uriDestination(req.Recipient, req.Transport()))
+	}
+}
+
+// RouteSetFromRecordRoute returns route set from Record-Route headers of message.
+// UAC uses reverse order of response Record-Route and UAS same order as request
+// https://datatracker.ietf.org/doc/html/rfc3261#section-12.1
+func RouteSetFromRecordRoute(msg Message, reverse bool) []Uri {
+	hdrs := msg.GetHeaders("Record-Route")
+	routeSet := make([]Uri, 0, len(hdrs))
+	for _, h := range hdrs {
+		rr, ok := h.(*RecordRouteHeader)
+		if !ok {
+			continue
+		}
+		routeSet = append(routeSet, rr.Address)
+	}
+	if reverse {
+		for i, j := 0, len(routeSet)-1; i < j; i, j = i+1, j-1 {
+			routeSet[i], routeSet[j] = routeSet[j], routeSet[i]
+		}
+	}
+	return routeSet
+}
+
+// routeStrict moves top Route to Request-URI and Request-URI as last Route
+func routeStrict(req *Request, route *RouteHeader) {
+	target := req.Recipient
+	recipient := route.Address.Clone()
+	// Parameters not allowed in Request-URI are stripped
+	// https://datatracker.ietf.org/doc/html/rfc3261#section-19.1.1
+	recipient.Headers = nil
+	if recipient.UriParams != nil {
+		recipient.UriParams.Remove("method")
+	}
+
+	req.RemoveHeader("Route")
+	req.Recipient = recipient
+	if target != nil {
+		req.AppendHeader(&RouteHeader{Address: *target})
+	}
+}
+
+// uriDestination returns host:port address of uri
+func uriDestination(uri *Uri, transport string) string {
+	host := uri.Host
+	// maddr overrides host as destination https://datatracker.ietf.org/doc/html/rfc3261#section-19.1.1
+	if maddr, ok := uri.UriParams.Get("maddr"); ok && maddr != "" {
+		host = maddr
+	}
+	port := uri.Port
+	if port == 0 {
+		port = DefaultPort(transport)
+	}
+	return net.JoinHostPort(hostUnbracket(host), strconv.Itoa(port))
+}
@@ -0,0 +1,87 @@
+package sip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteProcess(t *testing.T) {
+	isOwn := func(uri *Uri) bool { return uri.Host == "10.0.0.0" }
+
+	t.Run("LooseRouting", func(t *testing.T) {
+		req := NewRequest(INVITE, &Uri{User: "bob", Host: "10.2.2.2"})
+		req.AppendHeader(&RouteHeader{Address: Uri{Host: "10.0.0.0", UriParams: HeaderParams{"lr": ""}}})
+		req.AppendHeader(&RouteHeader{Address: Uri{Host: "10.0.0.0", UriParams: HeaderParams{"lr": "", "transport": "tcp"}}})
+		req.AppendHeader(&RouteHeader{Address: Uri{Host: "10.3.3.3", UriParams: HeaderParams{"lr": ""}}})
+
+		require.NoError(t, RouteProcess(req, isOwn))
+		require.Len(t, req.GetHeaders("Route"), 1)
+		assert.Equal(t, "10.3.3.3", RouteNextHop(req).Host)
+		assert.Equal(t, "10.3.3.3:5060", req.Destination())
+	})
+
+	t.Run("StrictRouterBefore", func(t *testing.T) {
+		req := NewRequest(INVITE, &Uri{Host: "10.0.0.0"})
+		req.AppendHeader(&RouteHeader{Address: Uri{Host: "10.3.3.3", UriParams: HeaderParams{"lr": ""}}})
+		req.AppendHeader(&RouteHeader{Address: Uri{User: "bob", Host: "10.2.2.2"}})
+
+		require.NoError(t, RouteProcess(req, isOwn))
+		assert.Equal(t, "sip:bob@10.2.2.2", req.Recipient.String())
+		require.Len(t, req.GetHeaders("Route"), 1)
+		assert.Equal(t, "10.3.3.3", req.Route().Address.Host)
+	})
+
+	t.Run("NoRouteSet", func(t *testing.T) {
+		req := NewRequest(INVITE, &Uri{Host: "10.0.0.0"})
+		require.ErrorIs(t, RouteProcess(req, isOwn), ErrRouteSetMissing)
+	})
+}
+
+func TestRouteNextHop(t *testing.T) {
+	req := NewRequest(INVITE, &Uri{User: "bob", Host: "10.2.2.2"})
+	assert.Equal(t, "10.2.2.2", RouteNextHop(req).Host)
+	assert.Equal(t, "10.2.2.2:5060", req.Destination())
+
+	// Next hop is strict router
+	req = NewRequest(INVITE, &Uri{User: "bob", Host: "10.2.2.2"})
+	req.AppendHeader(&RouteHeader{Address: Uri{Host: "10.3.3.3", Port: 5070, UriParams: HeaderParams{"transport": "tcp", "method": "INVITE"}}})
+	req.AppendHeader(&RouteHeader{Address: Uri{Host: "10.4.4.4", UriParams: HeaderParams{"lr": ""}}})
+
+	next := RouteNextHop(req)
+	assert.Equal(t, "10.3.3.3", next.Host)
+	assert.False(t, req.Recipient.UriParams.Has("method"))
+	assert.Equal(t, "TCP", req.Transport())
+	assert.Equal(t, "10.3.3.3:5070", req.Destination())
+
+	routes := req.GetHeaders("Route")
+	require.Len(t, routes, 2)
+	assert.Equal(t, "10.4.4.4", routes[0].(*RouteHeader).Address.Host)
+	assert.Equal(t, "10.2.2.2", routes[1].(*RouteHeader).Address.Host)
+}
+
+func TestRouteSetApply(t *testing.T) {
+	res := NewResponse(200, "OK")
+	res.AppendHeader(&RecordRouteHeader{Address: Uri{Host: "10.3.3.3", UriParams: HeaderParams{"lr": ""}}})
+	res.AppendHeader(&RecordRouteHeader{Address: Uri{Host: "10.4.4.4", UriParams: HeaderParams{"lr": ""}}})
+
+	routeSet := RouteSetFromRecordRoute(res, true)
+	require.Len(t, routeSet, 2)
+	assert.Equal(t, "10.4.4.4", routeSet[0].Host)
+
+	req := NewRequest(BYE, &Uri{User: "bob", Host: "10.2.2.2"})
+	RouteSetApply(req, routeSet)
+	assert.Equal(t, "10.2.2.2", req.Recipient.Host)
+	assert.Equal(t, "10.4.4.4:5060", req.Destination())
+
+	// Strict route set puts first route into Request-URI and remote target as last route
+	req = NewRequest(BYE, &Uri{User: "bob", Host: "10.2.2.2"})
+	RouteSetApply(req, []Uri{{Host: "10.4.4.4"}, {Host: "10.3.3.3", UriParams: HeaderParams{"lr": ""}}})
+	assert.Equal(t, "10.4.4.4", req.Recipient.Host)
+	assert.Equal(t, "10.4.4.4:5060", req.Destination())
+	routes := req.GetHeaders("Route")
+	require.Len(t, routes, 2)
+	assert.Equal(t, "10.3.3.3", routes[0].(*RouteHeader).Address.Host)
+	assert.Equal(t, "sip:bob@10.2.2.2", routes[1].(*RouteHeader).Address.String())
+}
@@ -1,6 +1,8 @@
 package sip
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
 	"strings"
 )
@@ -18,6 +20,10 @@ const (
 	DefaultWssPort int = 443
 
 	RFC3261BranchMagicCookie = "z9hG4bK"
+
+	// MulticastHost is well known sip.mcast.net address used for multicast REGISTER
+	// https://datatracker.ietf.org/doc/html/rfc3261#section-10.2.6
+	MulticastHost = "224.0.1.75"
 )
 
 // GenerateBranch returns random unique branch ID.
@@ -39,6 +45,46 @@ func generateBranchStringWrite(sb *strings.Builder, n int) {
 	RandStringBytesMask(sb, n)
 }
 
+// GenerateBranchStateless returns branch ID computed from received request as stateless proxy.
+// Retransmissions, CANCEL and ACK for non 2xx response get same branch as forwarded INVITE.
+// If received branch has magic cookie, it is hashed. Otherwise hash of top Via, To tag, From tag,
+// Call-ID, CSeq number and Request-URI is used
+// https://datatracker.ietf.org/doc/html/rfc3261#section-16.11
+func GenerateBranchStateless(req *Request) string {
+	h := sha1.New()
+	via := req.Via()
+	var branch string
+	if via != nil {
+		branch, _ = via.Params.Get("branch")
+	}
+
+	if strings.HasPrefix(branch, RFC3261BranchMagicCookie) {
+		h.Write([]byte(branch))
+	} else {
+		if via != nil {
+			h.Write([]byte(via.Value()))
+		}
+		if to := req.To(); to != nil {
+			tag, _ := to.Params.Get("tag")
+			h.Write([]byte(tag))
+		}
+		if from := req.From(); from != nil {
+			tag, _ := from.Params.Get("tag")
+			h.Write([]byte(tag))
+		}
+		if callid := req.CallID(); callid != nil {
+			h.Write([]byte(callid.Value()))
+		}
+		if cseq := req.CSeq(); cseq != nil {
+			fmt.Fprint(h, cseq.SeqNo)
+		}
+		h.Write([]byte(req.Recipient.String()))
+	}
+
+	sum := h.Sum(nil)
+	return RFC3261BranchMagicCookie + "." + hex.EncodeToString(sum[:8])
+}
+
 func GenerateTagN(n int) string {
 	sb := &strings.Builder{}
 	RandStringBytesMask(sb, n)
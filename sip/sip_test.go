@@ -1,7 +1,10 @@
 package sip
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func BenchmarkGenerateBranch(b *testing.B) {
@@ -33,3 +36,50 @@ func BenchmarkGenerateBranchBufPool(b *testing.B) {
 		}
 	}
 }
+
+func TestGenerateBranchStateless(t *testing.T) {
+	invite, _, _ := testCreateInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	branch := GenerateBranchStateless(invite)
+	assert.True(t, strings.HasPrefix(branch, RFC3261BranchMagicCookie+"."))
+	// Retransmission and CANCEL get same branch
+	assert.Equal(t, branch, GenerateBranchStateless(invite.Clone()))
+	assert.Equal(t, branch, GenerateBranchStateless(NewCancelRequest(invite)))
+
+	other, _, _ := testCreateInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	assert.NotEqual(t, branch, GenerateBranchStateless(other))
+
+	// Branch without magic cookie is computed from request fields
+	invite.Via().Params.Add("branch", "1234")
+	branch = GenerateBranchStateless(invite)
+	assert.Equal(t, branch, GenerateBranchStateless(invite.Clone()))
+	invite.CSeq().SeqNo++
+	assert.NotEqual(t, branch, GenerateBranchStateless(invite))
+}
+
+func TestNewAckRequestRouteSet(t *testing.T) {
+	invite, _, _ := testCreateInvite(t, "sip:bob@127.0.0.1:5060", "udp", "127.0.0.2:5060")
+	invite.AppendHeader(&RouteHeader{Address: Uri{Host: "10.0.0.1", UriParams: HeaderParams{"lr": ""}}})
+	invite.SetDestination("10.0.0.1:5060")
+
+	res := NewResponseFromRequest(invite, 200, "OK", nil)
+	res.AppendHeader(&ContactHeader{Address: Uri{User: "bob", Host: "127.0.0.3", Port: 5060}})
+	res.AppendHeader(&RecordRouteHeader{Address: Uri{Host: "10.0.0.2", UriParams: HeaderParams{"lr": ""}}})
+	res.AppendHeader(&RecordRouteHeader{Address: Uri{Host: "10.0.0.1", UriParams: HeaderParams{"lr": ""}}})
+
+	// Route set is reversed Record-Route and initial Route is not used
+	ack := NewAckRequest(invite, res, nil)
+	routes := ack.GetHeaders("Route")
+	if assert.Len(t, routes, 2) {
+		assert.Equal(t, "<sip:10.0.0.1;lr>", routes[0].Value())
+		assert.Equal(t, "<sip:10.0.0.2;lr>", routes[1].Value())
+	}
+	assert.Empty(t, ack.GetHeaders("Record-Route"))
+	assert.Equal(t, "sip:bob@127.0.0.3:5060", ack.Recipient.String())
+	assert.Equal(t, "10.0.0.1:5060", ack.Destination())
+
+	// Without Record-Route ACK goes directly
+	res = NewResponseFromRequest(invite, 200, "OK", nil)
+	res.RemoveHeader("Record-Route")
+	ack = NewAckRequest(invite, res, nil)
+	assert.Nil(t, ack.Route())
+}
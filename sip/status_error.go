@@ -0,0 +1,15 @@
+package sip
+
+import "fmt"
+
+// StatusError is error carrying response status. Server answers request with this status
+// in case handler returns it. Headers are added to response
+type StatusError struct {
+	Code    StatusCode
+	Reason  string
+	Headers []Header
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("sip status %d %s", e.Code, e.Reason)
+}
@@ -0,0 +1,225 @@
+package sip
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Minimal STUN binding client https://datatracker.ietf.org/doc/html/rfc5389
+const (
+	stunHeaderSize  = 20
+	stunMagicCookie = 0x2112A442
+
+	stunBindingRequest  = 0x0001
+	stunBindingResponse = 0x0101
+
+	stunAttrMappedAddress    = 0x0001
+	stunAttrXorMappedAddress = 0x0020
+)
+
+var (
+	ErrSTUNNoMappedAddress = errors.New("STUN response without mapped address")
+)
+
+// STUNConfig enables public address discovery for UDP listeners behind NAT.
+// Discovered address is set as listener advertised address. Check TransportLayer.SetAdvertisedAddr
+type STUNConfig struct {
+	// Server is STUN server address host:port
+	Server string
+	// Interval is period of repeating discovery for keeping NAT mapping fresh.
+	// If zero, discovery is done only when listener starts
+	Interval time.Duration
+	// OnDiscovered is optional callback with listener local address and discovered public address
+	OnDiscovered func(laddr string, public AdvertisedAddr)
+}
+
+func isSTUNMessage(data []byte) bool {
+	// First two bits are zero and magic cookie is present
+	return len(data) >= stunHeaderSize && data[0]&0xC0 == 0 &&
+		binary.BigEndian.Uint32(data[4:8]) == stunMagicCookie
+}
+
+func newSTUNBindingRequest() (req []byte, txID string, err error) {
+	req = make([]byte, stunHeaderSize)
+	binary.BigEndian.PutUint16(req[0:2], stunBindingRequest)
+	binary.BigEndian.PutUint32(req[4:8], stunMagicCookie)
+	if _, err := rand.Read(req[8:20]); err != nil {
+		return nil, "", err
+	}
+	return req, string(req[8:20]), nil
+}
+
+// parseSTUNBindingResponse returns mapped address from binding success response.
+// XOR-MAPPED-ADDRESS is preferred over MAPPED-ADDRESS
+func parseSTUNBindingResponse(data []byte) (*net.UDPAddr, error) {
+	if !isSTUNMessage(data) {
+		return nil, fmt.Errorf("not a STUN message")
+	}
+	if typ := binary.BigEndian.Uint16(data[0:2]); typ != stunBindingResponse {
+		return nil, fmt.Errorf("unexpected STUN message type=%#04x", typ)
+	}
+
+	length := int(binary.BigEndian.Uint16(data[2:4]))
+	if stunHeaderSize+length > len(data) {
+		return nil, fmt.Errorf("STUN message too short")
+	}
+	attrs := data[stunHeaderSize : stunHeaderSize+length]
+
+	var mapped *net.UDPAddr
+	for len(attrs) >= 4 {
+		typ := binary.BigEndian.Uint16(attrs[0:2])
+		alen := int(binary.BigEndian.Uint16(attrs[2:4]))
+		if 4+alen > len(attrs) {
+			return nil, fmt.Errorf("STUN attribute too short")
+		}
+		value := attrs[4 : 4+alen]
+
+		switch typ {
+		case stunAttrXorMappedAddress:
+			return stunDecodeAddr(value, data[4:20])
+		case stunAttrMappedAddress:
+			addr, err := stunDecodeAddr(value, nil)
+			if err != nil {
+				return nil, err
+			}
+			mapped = addr
+		}
+
+		// Attributes are padded to 4 bytes
+		next := 4 + (alen+3)&^3
+		if next > len(attrs) {
+			break
+		}
+		attrs = attrs[next:]
+	}
+
+	if mapped == nil {
+		return nil, ErrSTUNNoMappedAddress
+	}
+	return mapped, nil
+}
+
+// stunDecodeAddr decodes address attribute. xorKey is magic cookie with transaction id for XOR-MAPPED-ADDRESS
+func stunDecodeAddr(value []byte, xorKey []byte) (*net.UDPAddr, error) {
+	if len(value) < 4 {
+		return nil, fmt.Errorf("STUN address attribute too short")
+	}
+
+	var ipLen int
+	switch value[1] {
+	case 0x01:
+		ipLen = net.IPv4len
+	case 0x02:
+		ipLen = net.IPv6len
+	default:
+		return nil, fmt.Errorf("unknown STUN address family=%d", value[1])
+	}
+	if len(value) < 4+ipLen {
+		return nil, fmt.Errorf("STUN address attribute too short")
+	}
+
+	port := binary.BigEndian.Uint16(value[2:4])
+	ip := make(net.IP, ipLen)
+	copy(ip, value[4:4+ipLen])
+	if xorKey != nil {
+		port ^= uint16(stunMagicCookie >> 16)
+		for i := range ip {
+			ip[i] ^= xorKey[i]
+		}
+	}
+	return &net.UDPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// handleSTUN passes STUN response to waiting binding request
+func (t *transportUDP) handleSTUN(data []byte) {
+	v, ok := t.stunTx.Load(string(data[8:20]))
+	if !ok {
+		t.log.Debug().Msg("Unmatched STUN message received")
+		return
+	}
+
+	msg := make([]byte, len(data))
+	copy(msg, data)
+	select {
+	case v.(chan []byte) <- msg:
+	default:
+	}
+}
+
+// stunBinding sends binding request from conn and waits for response.
+// Request is retransmitted with RTO starting from 500ms as in RFC 5389 section 7.2.1
+func (t *transportUDP) stunBinding(ctx context.Context, conn net.PacketConn, server string) (*net.UDPAddr, error) {
+	raddr, err := net.ResolveUDPAddr("udp", server)
+	if err != nil {
+		return nil, fmt.Errorf("fail to resolve STUN server %q: %w", server, err)
+	}
+
+	req, txID, err := newSTUNBindingRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make(chan []byte, 1)
+	t.stunTx.Store(txID, responses)
+	defer t.stunTx.Delete(txID)
+
+	rto := 500 * time.Millisecond
+	for i := 0; i < 7; i++ {
+		if _, err := conn.WriteTo(req, raddr); err != nil {
+			return nil, fmt.Errorf("fail to send STUN binding request: %w", err)
+		}
+
+		timer := time.NewTimer(rto)
+		select {
+		case data := <-responses:
+			timer.Stop()
+			return parseSTUNBindingResponse(data)
+		case <-timer.C:
+			rto *= 2
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+	return nil, fmt.Errorf("STUN binding request to %s timed out", server)
+}
+
+// serveSTUN discovers public address of UDP listener on start and every interval until ctx is done
+func (l *TransportLayer) serveSTUN(ctx context.Context, conn net.PacketConn, cfg *STUNConfig) {
+	laddr := conn.LocalAddr().String()
+	for {
+		public, err := l.udp.stunBinding(ctx, conn, cfg.Server)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			l.log.Error().Err(err).Str("laddr", laddr).Msg("STUN public address discovery failed")
+		} else {
+			adv := AdvertisedAddr{Host: public.IP.String(), Port: public.Port}
+			l.log.Debug().Str("laddr", laddr).Str("public", adv.String()).Msg("STUN public address discovered")
+			if err := l.SetAdvertisedAddr("udp", laddr, adv); err != nil {
+				l.log.Error().Err(err).Msg("Failed to set advertised address")
+			}
+			if cfg.OnDiscovered != nil {
+				cfg.OnDiscovered(laddr, adv)
+			}
+		}
+
+		if cfg.Interval <= 0 {
+			return
+		}
+
+		t := time.NewTimer(cfg.Interval)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return
+		}
+	}
+}
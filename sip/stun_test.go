@@ -0,0 +1,86 @@
+package sip
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSTUNResponse builds binding success response with XOR-MAPPED-ADDRESS
+func testSTUNResponse(req []byte, addr *net.UDPAddr) []byte {
+	ip := addr.IP.To4()
+	res := make([]byte, stunHeaderSize+12)
+	binary.BigEndian.PutUint16(res[0:2], stunBindingResponse)
+	binary.BigEndian.PutUint16(res[2:4], 12)
+	copy(res[4:20], req[4:20])
+
+	attr := res[stunHeaderSize:]
+	binary.BigEndian.PutUint16(attr[0:2], stunAttrXorMappedAddress)
+	binary.BigEndian.PutUint16(attr[2:4], 8)
+	attr[5] = 0x01
+	binary.BigEndian.PutUint16(attr[6:8], uint16(addr.Port)^uint16(stunMagicCookie>>16))
+	for i := range ip {
+		attr[8+i] = ip[i] ^ res[4+i]
+	}
+	return res
+}
+
+func TestSTUNParseBindingResponse(t *testing.T) {
+	req, _, err := newSTUNBindingRequest()
+	require.NoError(t, err)
+	require.True(t, isSTUNMessage(req))
+	require.False(t, isSTUNMessage([]byte("OPTIONS sip:bob@127.0.0.1 SIP/2.0\r\n")))
+
+	addr, err := parseSTUNBindingResponse(testSTUNResponse(req, &net.UDPAddr{IP: net.ParseIP("203.0.113.5"), Port: 40000}))
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.5:40000", addr.String())
+
+	_, err = parseSTUNBindingResponse(req)
+	require.Error(t, err)
+}
+
+func TestTransportLayerSTUNDiscovery(t *testing.T) {
+	// NOTE it creates real network connection
+	stunServer, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer stunServer.Close()
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, raddr, err := stunServer.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			// Pretend we are behind NAT
+			stunServer.WriteTo(testSTUNResponse(buf[:n], &net.UDPAddr{IP: net.ParseIP("198.51.100.7"), Port: 6060}), raddr)
+		}
+	}()
+
+	tp := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+	defer tp.Close()
+	discovered := make(chan AdvertisedAddr, 1)
+	tp.STUN = &STUNConfig{
+		Server:       stunServer.LocalAddr().String(),
+		OnDiscovered: func(laddr string, public AdvertisedAddr) { discovered <- public },
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+	go tp.ServeUDP(conn)
+
+	select {
+	case public := <-discovered:
+		assert.Equal(t, "198.51.100.7:6060", public.String())
+	case <-time.After(3 * time.Second):
+		t.Fatal("STUN discovery timeout")
+	}
+
+	adv, ok := tp.GetAdvertisedAddr("udp", conn.LocalAddr().String())
+	require.True(t, ok)
+	assert.Equal(t, "198.51.100.7:6060", adv.String())
+}
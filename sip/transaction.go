@@ -1,19 +1,22 @@
 package sip
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog"
 )
 
 var (
-	// SIP timers are exposed for manipulation but best approach is using SetTimers
-	// where all timers get populated based on
+	// SIP timers are exposed for reading. Use SetTimers or ApplyTimers for changing them
+	// where all timers get populated based on. Reading them is not safe while timers are changed
+	// at runtime, so GetTimers should be used instead
 	// T1: Round-trip time (RTT) estimate, Default 500ms
 	T1,
 	// T2: Maximum retransmission interval for non-INVITE requests and INVITE responses
@@ -48,22 +51,71 @@ func init() {
 	SetTimers(t1, t2, t4)
 }
 
+// Timers are transaction timer values. Every transaction takes snapshot of timers on creation,
+// so changing timers at runtime affects only new transactions
+type Timers struct {
+	T1, T2, T4 time.Duration
+
+	Timer_A, Timer_B, Timer_D, Timer_E, Timer_F, Timer_G,
+	Timer_H, Timer_I, Timer_J, Timer_K, Timer_L, Timer_M time.Duration
+}
+
+var currentTimers atomic.Pointer[Timers]
+
+// NewTimers calculates all transaction timers based on T1, T2 and T4 as in RFC 3261 Appendix A
+func NewTimers(t1, t2, t4 time.Duration) Timers {
+	return Timers{
+		T1:      t1,
+		T2:      t2,
+		T4:      t4,
+		Timer_A: t1,
+		Timer_B: 64 * t1,
+		Timer_D: 32 * time.Second,
+		Timer_E: t1,
+		Timer_F: 64 * t1,
+		Timer_G: t1,
+		Timer_H: 64 * t1,
+		Timer_I: t4,
+		Timer_J: 64 * t1,
+		Timer_K: t4,
+		Timer_L: 64 * t1,
+		Timer_M: 64 * t1,
+	}
+}
+
+// SetTimers populates all timers based on t1, t2, t4.
+// It can be called at runtime as long as timers are read only with GetTimers.
+// Running transactions keep timers they are created with
 func SetTimers(t1, t2, t4 time.Duration) {
-	T1 = t1
-	T2 = t2
-	T4 = t4
-	Timer_A = T1
-	Timer_B = 64 * T1
-	Timer_D = 32 * time.Second
-	Timer_E = T1
-	Timer_F = 64 * T1
-	Timer_G = T1
-	Timer_H = 64 * T1
-	Timer_I = T4
-	Timer_J = 64 * T1
-	Timer_K = T4
-	Timer_L = 64 * T1
-	Timer_M = 64 * T1
+	ApplyTimers(NewTimers(t1, t2, t4))
+}
+
+// ApplyTimers sets timers for newly created transactions.
+// It can be used for fine tuning single timer. Ex. Timer_D
+func ApplyTimers(t Timers) {
+	currentTimers.Store(&t)
+
+	// Exported variables are kept for compatibility and should be treated as read only
+	T1 = t.T1
+	T2 = t.T2
+	T4 = t.T4
+	Timer_A = t.Timer_A
+	Timer_B = t.Timer_B
+	Timer_D = t.Timer_D
+	Timer_E = t.Timer_E
+	Timer_F = t.Timer_F
+	Timer_G = t.Timer_G
+	Timer_H = t.Timer_H
+	Timer_I = t.Timer_I
+	Timer_J = t.Timer_J
+	Timer_K = t.Timer_K
+	Timer_L = t.Timer_L
+	Timer_M = t.Timer_M
+}
+
+// GetTimers returns effective timers used for new transactions
+func GetTimers() Timers {
+	return *currentTimers.Load()
 }
 
 var (
@@ -71,12 +123,23 @@ var (
 	// https://www.rfc-editor.org/rfc/rfc3261#section-8.1.3.1
 	ErrTransactionTimeout   = errors.New("transaction timeout")
 	ErrTransactionTransport = errors.New("transaction transport error")
+	// ErrTransactionTerminated is returned when transaction terminated before final response
+	ErrTransactionTerminated = errors.New("transaction terminated")
+
+	// Causes of transaction failure matched from *TransactionError with errors.Is.
+	// Timeouts match ErrTransactionTimeout as well
+
+	// ErrTimerBTimeout is INVITE client transaction timeout, no final response received
+	ErrTimerBTimeout = errors.New("timer B timeout")
+	// ErrTimerFTimeout is non-INVITE client transaction timeout
+	ErrTimerFTimeout = errors.New("timer F timeout")
+	// ErrTransportFailed is same as ErrTransactionTransport. Message could not be written, so failover to other
+	// destination is possible without request reaching peer
+	ErrTransportFailed = ErrTransactionTransport
+	// ErrTransactionCanceled is transaction terminated by canceled caller context
+	ErrTransactionCanceled = errors.New("transaction canceled")
 )
 
-func wrapTransportError(err error) error {
-	return fmt.Errorf("%s. %w", err.Error(), ErrTransactionTransport)
-}
-
 func wrapTimeoutError(err error) error {
 	return fmt.Errorf("%s. %w", err.Error(), ErrTransactionTimeout)
 }
@@ -93,6 +156,12 @@ type Transaction interface {
 type ServerTransaction interface {
 	Transaction
 	Respond(res *Response) error
+	// RespondWith builds response from request and responds. Same To tag is used for all responses
+	RespondWith(code StatusCode, reason string, body []byte, headers ...Header) error
+	// RespondSDP responds 200 OK with SDP body
+	RespondSDP(sdp []byte) error
+	// Provisional responds with provisional response, ex. 180 Ringing
+	Provisional(code StatusCode) error
 	Acks() <-chan *Request
 	Cancels() <-chan *Request
 }
@@ -101,8 +170,10 @@ type ClientTransaction interface {
 	Transaction
 	// Responses returns channel with all responses for transaction
 	Responses() <-chan *Response
-	// Cancel sends cancel request
-	Cancel() error
+	// Cancel sends CANCEL request for INVITE and waits its final response
+	Cancel(ctx context.Context) (*Response, error)
+	// Transport returns transport request is actually sent over
+	Transport() string
 }
 
 type commonTx struct {
@@ -120,6 +191,11 @@ type commonTx struct {
 	//State machine control
 	fsmMu    sync.RWMutex
 	fsmState fsmContextState
+	// journal records state transitions when set
+	journal *TxJournal
+
+	// timers are snapshot of timers at transaction creation
+	timers *Timers
 
 	log         zerolog.Logger
 	onTerminate FnTxTerminate
@@ -159,7 +235,12 @@ func (tx *commonTx) currentFsmState() fsmContextState {
 func (tx *commonTx) spinFsm(in fsmInput) {
 	tx.fsmMu.Lock()
 	for i := in; i != FsmInputNone; {
-		i = tx.fsmState(i)
+		from := tx.fsmState
+		next := tx.fsmState(i)
+		if tx.journal != nil {
+			tx.journal.record(tx, i, from, tx.fsmState)
+		}
+		i = next
 	}
 	tx.fsmMu.Unlock()
 }
@@ -298,6 +379,12 @@ func (store *transactionStore) get(key string) (Transaction, bool) {
 	return tx, ok
 }
 
+func (store *transactionStore) len() int {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	return len(store.transactions)
+}
+
 func (store *transactionStore) drop(key string) bool {
 	store.mu.Lock()
 	defer store.mu.Unlock()
@@ -1,6 +1,7 @@
 package sip
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
@@ -18,6 +19,13 @@ type ClientTx struct {
 	timer_d      *time.Timer
 	timer_m      *time.Timer
 
+	// cancelPending is CANCEL requested before provisional response
+	cancelPending bool
+	// final is closed on first final response
+	final     chan struct{}
+	finalOnce sync.Once
+	finalResp *Response
+
 	mu        sync.RWMutex
 	closeOnce sync.Once
 }
@@ -30,9 +38,11 @@ func NewClientTx(key string, origin *Request, conn Connection, logger zerolog.Lo
 	// buffer chan - about ~10 retransmit responses
 	tx.responses = make(chan *Response)
 	tx.done = make(chan struct{})
+	tx.final = make(chan struct{})
 	tx.log = logger
 
 	tx.origin = origin
+	tx.timers = currentTimers.Load()
 	return tx
 }
 
@@ -41,7 +51,7 @@ func (tx *ClientTx) Init() error {
 
 	if err := tx.conn.WriteMsg(tx.origin); err != nil {
 		tx.log.Debug().Err(err).Str("req", tx.origin.StartLine()).Msg("Fail to write request on init")
-		return wrapTransportError(err)
+		return wrapTransportError(err, tx.origin)
 	}
 
 	reliable := IsReliable(tx.origin.Transport())
@@ -57,21 +67,25 @@ func (tx *ClientTx) Init() error {
 		// Timer A - retransmission
 
 		tx.mu.Lock()
-		tx.timer_a_time = Timer_A
+		tx.timer_a_time = tx.timers.Timer_A
 
 		tx.timer_a = time.AfterFunc(tx.timer_a_time, func() {
 			tx.spinFsm(client_input_timer_a)
 		})
 		// Timer D is set to 32 seconds for unreliable transports
-		tx.timer_d_time = Timer_D
+		tx.timer_d_time = tx.timers.Timer_D
 		tx.mu.Unlock()
 	}
 
-	// Timer B - timeout
+	// Timer B - timeout. For non-INVITE it is Timer F
+	timer, timeout := "B", tx.timers.Timer_B
+	if !tx.origin.IsInvite() {
+		timer, timeout = "F", tx.timers.Timer_F
+	}
 	tx.mu.Lock()
-	tx.timer_b = time.AfterFunc(Timer_B, func() {
+	tx.timer_b = time.AfterFunc(timeout, func() {
 		tx.mu.Lock()
-		tx.lastErr = fmt.Errorf("Timer_B timed out. %w", ErrTransactionTimeout)
+		tx.lastErr = transactionTimeoutError(timer, tx.origin)
 		tx.mu.Unlock()
 		tx.spinFsm(client_input_timer_b)
 	})
@@ -84,10 +98,40 @@ func (tx *ClientTx) Responses() <-chan *Response {
 	return tx.responses
 }
 
-// Cancel cancels client transaction by sending CANCEL request
-func (tx *ClientTx) Cancel() error {
+// Cancel cancels INVITE client transaction by sending CANCEL request with same branch and Route headers.
+// If no provisional response is received yet, CANCEL is sent after first one as RFC 3261 requires.
+// It waits and returns final response of INVITE, normally 487 Request Terminated. Final response is still
+// passed on Responses, and 2xx can be returned if CANCEL raced with answer.
+// Returning on ctx done does not stop sending CANCEL
+// https://datatracker.ietf.org/doc/html/rfc3261#section-9.1
+func (tx *ClientTx) Cancel(ctx context.Context) (*Response, error) {
+	if !tx.origin.IsInvite() {
+		return nil, fmt.Errorf("cancel of %s transaction is not allowed", tx.origin.Method)
+	}
+
 	tx.spinFsm(client_input_cancel)
-	return nil
+
+	select {
+	case <-tx.final:
+		tx.mu.RLock()
+		defer tx.mu.RUnlock()
+		return tx.finalResp, nil
+	case <-tx.done:
+		// Final response could be received with termination
+		select {
+		case <-tx.final:
+			tx.mu.RLock()
+			defer tx.mu.RUnlock()
+			return tx.finalResp, nil
+		default:
+		}
+		if err := tx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrTransactionTerminated
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 }
 
 func (tx *ClientTx) Terminate() {
@@ -100,6 +144,12 @@ func (tx *ClientTx) Terminate() {
 	tx.delete()
 }
 
+// Transport returns transport request is sent over.
+// It can differ from requested one, ex. large request over UDP is sent over TCP
+func (tx *ClientTx) Transport() string {
+	return tx.origin.Transport()
+}
+
 func (tx *ClientTx) Err() error {
 	tx.mu.RLock()
 	err := tx.lastErr
@@ -118,6 +168,16 @@ func (tx *ClientTx) receive(res *Response) error {
 		tx.lastResp = res
 		tx.mu.Unlock()
 
+		if !res.IsProvisional() {
+			// Resolve Cancel even if responses are not consumed
+			tx.finalOnce.Do(func() {
+				tx.mu.Lock()
+				tx.finalResp = res
+				tx.mu.Unlock()
+				close(tx.final)
+			})
+		}
+
 		switch {
 		case res.IsProvisional():
 			input = client_input_1xx
@@ -154,7 +214,7 @@ func (tx *ClientTx) cancel() {
 			Msgf("send CANCEL request failed: %s", err)
 
 		tx.mu.Lock()
-		tx.lastErr = wrapTransportError(err)
+		tx.lastErr = wrapTransportError(err, cancelRequest)
 		tx.mu.Unlock()
 
 		go tx.spinFsm(client_input_transport_err)
@@ -176,7 +236,7 @@ func (tx *ClientTx) ack() {
 			Msgf("send ACK request failed: %s", err)
 
 		tx.mu.Lock()
-		tx.lastErr = wrapTransportError(err)
+		tx.lastErr = wrapTransportError(err, ack)
 		tx.mu.Unlock()
 
 		go tx.spinFsm(client_input_transport_err)
@@ -191,6 +251,9 @@ func (tx *ClientTx) initFSM() {
 	} else {
 		tx.fsmState = tx.stateCalling
 	}
+	if tx.journal != nil {
+		tx.journal.record(&tx.commonTx, fsmInputInit, nil, tx.fsmState)
+	}
 	tx.fsmMu.Unlock()
 }
 
@@ -206,7 +269,7 @@ func (tx *ClientTx) resend() {
 	err := tx.conn.WriteMsg(tx.origin)
 	if err != nil {
 		tx.mu.Lock()
-		tx.lastErr = wrapTransportError(err)
+		tx.lastErr = wrapTransportError(err, tx.origin)
 		tx.mu.Unlock()
 
 		tx.log.Debug().Err(err).Str("req", tx.origin.StartLine()).Msg("Fail to resend request")
@@ -245,7 +308,7 @@ func (tx *ClientTx) passUpRetransmission() {
 	case <-tx.done:
 	case tx.responses <- lastResp:
 		// TODO is T1 best here option? This can take Timer_M as 64*T1
-	case <-time.After(T1):
+	case <-time.After(tx.timers.T1):
 		tx.log.Debug().Msg("skipped response. Retransimission")
 	}
 }
@@ -211,8 +211,8 @@ func (tx *ClientTx) actResend() fsmInput {
 
 	tx.timer_a_time *= 2
 	// For non-INVITE, cap timer A at T2 seconds.
-	if tx.timer_a_time > T2 {
-		tx.timer_a_time = T2
+	if tx.timer_a_time > tx.timers.T2 {
+		tx.timer_a_time = tx.timers.T2
 	}
 	tx.timer_a.Reset(tx.timer_a_time)
 
@@ -226,6 +226,15 @@ func (tx *ClientTx) actResend() fsmInput {
 func (tx *ClientTx) actInviteProceeding() fsmInput {
 	// tx.Log().Debug("actInviteProceeding")
 
+	// CANCEL requested before provisional response can be sent now
+	tx.mu.Lock()
+	pending := tx.cancelPending
+	tx.cancelPending = false
+	tx.mu.Unlock()
+	if pending {
+		tx.cancel()
+	}
+
 	tx.passUp()
 
 	tx.mu.Lock()
@@ -238,6 +247,11 @@ func (tx *ClientTx) actInviteProceeding() fsmInput {
 		tx.timer_b.Stop()
 		tx.timer_b = nil
 	}
+	if pending {
+		tx.timer_b = time.AfterFunc(tx.timers.Timer_B, func() {
+			tx.spinFsm(client_input_timer_b)
+		})
+	}
 
 	tx.mu.Unlock()
 
@@ -303,7 +317,11 @@ func (tx *ClientTx) actFinal() fsmInput {
 func (tx *ClientTx) actCancel() fsmInput {
 	// tx.Log().Debug("actCancel")
 
-	tx.cancel()
+	// CANCEL must not be sent before provisional response
+	// https://datatracker.ietf.org/doc/html/rfc3261#section-9.1
+	tx.mu.Lock()
+	tx.cancelPending = true
+	tx.mu.Unlock()
 
 	return FsmInputNone
 }
@@ -319,7 +337,7 @@ func (tx *ClientTx) actCancelTimeout() fsmInput {
 	if tx.timer_b != nil {
 		tx.timer_b.Stop()
 	}
-	tx.timer_b = time.AfterFunc(Timer_B, func() {
+	tx.timer_b = time.AfterFunc(tx.timers.Timer_B, func() {
 		tx.spinFsm(client_input_timer_b)
 	})
 	tx.mu.Unlock()
@@ -380,7 +398,7 @@ func (tx *ClientTx) actPassupAccept() fsmInput {
 		tx.timer_b = nil
 	}
 
-	tx.timer_m = time.AfterFunc(Timer_M, func() {
+	tx.timer_m = time.AfterFunc(tx.timers.Timer_M, func() {
 		select {
 		case <-tx.done:
 			return
@@ -2,12 +2,15 @@ package sip
 
 import (
 	"bytes"
+	"context"
 	"io"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/emiago/sipgo/fakes"
 	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -55,3 +58,141 @@ func TestClientTransactionInviteFSM(t *testing.T) {
 	// incoming.WriteString(res200.String())
 
 }
+
+func TestClientTransactionError(t *testing.T) {
+	SetTimers(1*time.Millisecond, 4*time.Millisecond, 5*time.Millisecond)
+	defer SetTimers(500*time.Millisecond, 4*time.Second, 5*time.Second)
+
+	newConn := func() *UDPConnection {
+		return &UDPConnection{
+			PacketConn: &fakes.UDPConn{
+				Reader:  bytes.NewBuffer(nil),
+				Writers: map[string]io.Writer{"127.0.0.99:5060": bytes.NewBuffer(nil)},
+			},
+		}
+	}
+
+	t.Run("TimerB", func(t *testing.T) {
+		req, _, _ := testCreateInvite(t, "sip:127.0.0.99:5060", "udp", "127.0.0.2:5060")
+		tx := NewClientTx("timer-b", req, newConn(), log.Logger)
+		require.NoError(t, tx.Init())
+		<-tx.Done()
+
+		var txErr *TransactionError
+		require.ErrorAs(t, tx.Err(), &txErr)
+		assert.Equal(t, "B", txErr.Timer)
+		assert.True(t, txErr.Timeout())
+		assert.Equal(t, "127.0.0.99:5060", txErr.Destination)
+		assert.ErrorIs(t, tx.Err(), ErrTransactionTimeout)
+		assert.ErrorIs(t, tx.Err(), ErrTimerBTimeout)
+		assert.NotErrorIs(t, tx.Err(), ErrTimerFTimeout)
+		assert.NotErrorIs(t, tx.Err(), ErrTransactionTransport)
+	})
+
+	t.Run("TimerF", func(t *testing.T) {
+		req, _, _ := testCreateInvite(t, "sip:127.0.0.99:5060", "udp", "127.0.0.2:5060")
+		req.Method = OPTIONS
+		req.CSeq().MethodName = OPTIONS
+		tx := NewClientTx("timer-f", req, newConn(), log.Logger)
+		require.NoError(t, tx.Init())
+		<-tx.Done()
+
+		var txErr *TransactionError
+		require.ErrorAs(t, tx.Err(), &txErr)
+		assert.Equal(t, "F", txErr.Timer)
+		assert.ErrorIs(t, tx.Err(), ErrTransactionTimeout)
+		assert.ErrorIs(t, tx.Err(), ErrTimerFTimeout)
+	})
+
+	t.Run("Transport", func(t *testing.T) {
+		req, _, _ := testCreateInvite(t, "sip:127.0.0.98:5060", "udp", "127.0.0.2:5060")
+		tx := NewClientTx("transport", req, newConn(), log.Logger)
+		err := tx.Init()
+
+		var txErr *TransactionError
+		require.ErrorAs(t, err, &txErr)
+		assert.False(t, txErr.Timeout())
+		assert.Equal(t, "127.0.0.98:5060", txErr.Destination)
+		assert.Equal(t, "udp", txErr.Transport)
+		assert.ErrorIs(t, err, ErrTransactionTransport)
+		assert.ErrorIs(t, err, ErrTransportFailed)
+		assert.NotErrorIs(t, err, ErrTransactionTimeout)
+		assert.NotErrorIs(t, err, ErrTransactionCanceled)
+		assert.Contains(t, err.Error(), "non existing writer")
+	})
+}
+
+func TestClientTransactionCancel(t *testing.T) {
+	timers := GetTimers()
+	SetTimers(500*time.Millisecond, 4*time.Second, 5*time.Second)
+	defer ApplyTimers(timers)
+
+	req, _, _ := testCreateInvite(t, "sip:127.0.0.99:5060", "udp", "127.0.0.2:5060")
+	req.AppendHeader(NewHeader("Route", "<sip:proxy.example.com;lr>"))
+	req.SetDestination("127.0.0.99:5060")
+	outgoing := bytes.NewBuffer(nil)
+	conn := &UDPConnection{
+		PacketConn: &fakes.UDPConn{
+			Reader:  bytes.NewBuffer(nil),
+			Writers: map[string]io.Writer{"127.0.0.99:5060": outgoing},
+		},
+	}
+	tx := NewClientTx("cancel", req, conn, log.Logger)
+	require.NoError(t, tx.Init())
+	defer tx.Terminate()
+	go func() {
+		for range tx.Responses() {
+		}
+	}()
+	outgoing.Reset()
+
+	type result struct {
+		res *Response
+		err error
+	}
+	canceled := make(chan result)
+	go func() {
+		res, err := tx.Cancel(context.Background())
+		canceled <- result{res, err}
+	}()
+
+	// CANCEL waits for provisional response
+	require.Eventually(t, func() bool {
+		tx.mu.RLock()
+		defer tx.mu.RUnlock()
+		return tx.cancelPending
+	}, time.Second, time.Millisecond)
+	assert.Zero(t, outgoing.Len())
+
+	require.NoError(t, tx.receive(NewResponseFromRequest(req, StatusRinging, "Ringing", nil)))
+	cancel := outgoing.String()
+	assert.True(t, strings.HasPrefix(cancel, "CANCEL sip:127.0.0.99:5060 SIP/2.0\r\n"))
+	assert.Contains(t, cancel, "branch="+req.Via().Params["branch"])
+	assert.Contains(t, cancel, "Route: <sip:proxy.example.com;lr>\r\n")
+
+	require.NoError(t, tx.receive(NewResponseFromRequest(req, StatusRequestTerminated, "Request Terminated", nil)))
+	select {
+	case r := <-canceled:
+		require.NoError(t, r.err)
+		assert.Equal(t, StatusRequestTerminated, r.res.StatusCode)
+	case <-time.After(time.Second):
+		t.Fatal("cancel did not resolve")
+	}
+
+	t.Run("NonInvite", func(t *testing.T) {
+		req, _, _ := testCreateInvite(t, "sip:127.0.0.99:5060", "udp", "127.0.0.2:5060")
+		req.Method = OPTIONS
+		tx := NewClientTx("cancel-options", req, conn, log.Logger)
+		_, err := tx.Cancel(context.Background())
+		require.Error(t, err)
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		tx := NewClientTx("cancel-ctx", req, conn, log.Logger)
+		tx.initFSM()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := tx.Cancel(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,90 @@
+package sip
+
+import (
+	"context"
+	"fmt"
+)
+
+// TransactionError is structured reason of failed transaction. It matches ErrTransactionTimeout, ErrTimerBTimeout,
+// ErrTimerFTimeout, ErrTransportFailed or ErrTransactionCanceled with errors.Is, so retry logic can differ
+// unreachable host from unresponsive application.
+// Ex:
+//
+//	switch err := tx.Err(); {
+//	case errors.Is(err, sip.ErrTransportFailed): // failover
+//	case errors.Is(err, sip.ErrTimerBTimeout): // retry later
+//	case errors.Is(err, sip.ErrTransactionCanceled): // give up
+//	}
+//
+// Use errors.As for details:
+//
+//	var txErr *sip.TransactionError
+//	if errors.As(tx.Err(), &txErr) && txErr.Timer == "B" { ... }
+type TransactionError struct {
+	// Timer is RFC 3261 timer that fired: B for INVITE or F for non-INVITE transaction. Empty for transport error
+	Timer string
+	// Destination and Transport message was sent to
+	Destination string
+	Transport   string
+	// Err is transport error, or context error when transaction is terminated by caller context. Nil for timeout
+	Err error
+}
+
+func (e *TransactionError) Error() string {
+	if e.Timer != "" {
+		return fmt.Sprintf("Timer_%s timed out dest=%s/%s. %s", e.Timer, e.Destination, e.Transport, ErrTransactionTimeout)
+	}
+	if e.contextErr() {
+		if e.Timeout() {
+			return fmt.Sprintf("%s dest=%s/%s. %s", e.Err, e.Destination, e.Transport, ErrTransactionTimeout)
+		}
+		return fmt.Sprintf("%s dest=%s/%s. %s", e.Err, e.Destination, e.Transport, ErrTransactionCanceled)
+	}
+	return fmt.Sprintf("%s dest=%s/%s. %s", e.Err, e.Destination, e.Transport, ErrTransactionTransport)
+}
+
+func (e *TransactionError) Unwrap() []error {
+	switch e.Timer {
+	case "B":
+		return []error{ErrTransactionTimeout, ErrTimerBTimeout}
+	case "F":
+		return []error{ErrTransactionTimeout, ErrTimerFTimeout}
+	case "":
+	default:
+		return []error{ErrTransactionTimeout}
+	}
+	if e.contextErr() {
+		if e.Timeout() {
+			return []error{ErrTransactionTimeout, e.Err}
+		}
+		return []error{ErrTransactionCanceled, e.Err}
+	}
+	return []error{ErrTransactionTransport, e.Err}
+}
+
+// Timeout reports is transaction failed due to timer or passed context deadline
+func (e *TransactionError) Timeout() bool {
+	return e.Timer != "" || e.Err == context.DeadlineExceeded
+}
+
+// contextErr reports is transaction terminated by caller context. Transport errors wrapping context error,
+// like on canceled dial, stay transport errors
+func (e *TransactionError) contextErr() bool {
+	return e.Err == context.Canceled || e.Err == context.DeadlineExceeded
+}
+
+func wrapTransportError(err error, msg Message) error {
+	return &TransactionError{
+		Destination: msg.Destination(),
+		Transport:   msg.Transport(),
+		Err:         err,
+	}
+}
+
+func transactionTimeoutError(timer string, msg Message) error {
+	return &TransactionError{
+		Timer:       timer,
+		Destination: msg.Destination(),
+		Transport:   msg.Transport(),
+	}
+}
@@ -0,0 +1,231 @@
+package sip
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TxJournal is bounded ring buffer of recent transaction state transitions. It keeps forensic
+// trail of stuck calls without debug logging, and can be dumped on signal or over HTTP
+// Ex:
+//
+//	journal := sip.NewTxJournal(10000)
+//	ua, _ := sipgo.NewUA(sipgo.WithUserAgentTransactionJournal(journal))
+//	stop := journal.DumpOnSignal(os.Stderr, syscall.SIGQUIT)
+//	defer stop()
+//	http.Handle("/debug/sip/transactions", journal)
+type TxJournal struct {
+	mu      sync.Mutex
+	entries []txJournalEntry
+	next    int
+	full    bool
+}
+
+type txJournalEntry struct {
+	time   time.Time
+	key    string
+	callID string
+	input  fsmInput
+	from   uintptr
+	to     uintptr
+}
+
+// TxJournalEntry is single transaction state transition
+type TxJournalEntry struct {
+	Time   time.Time
+	Key    string
+	CallID string
+	// Input is FSM input like timer_b or user_2xx. It is init for transaction creation
+	Input string
+	// From and To are FSM state names. From is empty for transaction creation
+	From string
+	To   string
+}
+
+// NewTxJournal creates journal keeping last size transitions
+func NewTxJournal(size int) *TxJournal {
+	if size < 1 {
+		size = 1
+	}
+	return &TxJournal{
+		entries: make([]txJournalEntry, size),
+	}
+}
+
+func (j *TxJournal) record(tx *commonTx, input fsmInput, from fsmContextState, to fsmContextState) {
+	e := txJournalEntry{
+		time:  time.Now(),
+		key:   tx.key,
+		input: input,
+		to:    fsmStatePointer(to),
+	}
+	if from != nil {
+		e.from = fsmStatePointer(from)
+	}
+	if callid := tx.origin.CallID(); callid != nil {
+		e.callID = callid.Value()
+	}
+
+	j.mu.Lock()
+	j.entries[j.next] = e
+	j.next++
+	if j.next == len(j.entries) {
+		j.next = 0
+		j.full = true
+	}
+	j.mu.Unlock()
+}
+
+// Entries returns recorded transitions from oldest
+func (j *TxJournal) Entries() []TxJournalEntry {
+	j.mu.Lock()
+	var raw []txJournalEntry
+	if j.full {
+		raw = append(raw, j.entries[j.next:]...)
+	}
+	raw = append(raw, j.entries[:j.next]...)
+	j.mu.Unlock()
+
+	// State names are resolved only on dump, to keep recording cheap
+	names := make(map[uintptr]string)
+	name := func(p uintptr) string {
+		if p == 0 {
+			return ""
+		}
+		n, ok := names[p]
+		if !ok {
+			n = fsmStateName(p)
+			names[p] = n
+		}
+		return n
+	}
+
+	entries := make([]TxJournalEntry, len(raw))
+	for i, e := range raw {
+		entries[i] = TxJournalEntry{
+			Time:   e.time,
+			Key:    e.key,
+			CallID: e.callID,
+			Input:  e.input.String(),
+			From:   name(e.from),
+			To:     name(e.to),
+		}
+	}
+	return entries
+}
+
+// WriteTo writes recorded transitions as text, one per line
+func (j *TxJournal) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	for _, e := range j.Entries() {
+		c, err := fmt.Fprintf(w, "%s %s call-id=%s %s: %s -> %s\n",
+			e.Time.Format("2006-01-02 15:04:05.000000"), e.Key, e.CallID, e.Input, e.From, e.To)
+		n += int64(c)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// ServeHTTP dumps journal as text, for exposing on admin API
+func (j *TxJournal) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	j.WriteTo(w)
+}
+
+// DumpOnSignal writes journal to w every time one of signals is received, ex. syscall.SIGQUIT.
+// Handling signal replaces its default behavior. Returned function stops it
+func (j *TxJournal) DumpOnSignal(w io.Writer, sigs ...os.Signal) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ch:
+				j.WriteTo(w)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// SetJournal enables recording of transaction state transitions for transactions created after.
+// Nil disables it
+func (txl *TransactionLayer) SetJournal(j *TxJournal) {
+	txl.journal.Store(j)
+}
+
+func fsmStatePointer(s fsmContextState) uintptr {
+	return reflect.ValueOf(s).Pointer()
+}
+
+// fsmStateName converts state method pointer to name, ex. inviteStateCalling
+func fsmStateName(p uintptr) string {
+	f := runtime.FuncForPC(p)
+	if f == nil {
+		return "unknown"
+	}
+	name := f.Name()
+	if ind := strings.LastIndexByte(name, '.'); ind >= 0 {
+		name = name[ind+1:]
+	}
+	return strings.TrimSuffix(name, "-fm")
+}
+
+var fsmInputNames = map[fsmInput]string{
+	FsmInputNone:               "none",
+	server_input_request:       "request",
+	server_input_ack:           "ack",
+	server_input_cancel:        "cancel",
+	server_input_user_1xx:      "user_1xx",
+	server_input_user_2xx:      "user_2xx",
+	server_input_user_300_plus: "user_300_plus",
+	server_input_timer_g:       "timer_g",
+	server_input_timer_h:       "timer_h",
+	server_input_timer_i:       "timer_i",
+	server_input_timer_j:       "timer_j",
+	server_input_timer_l:       "timer_l",
+	server_input_transport_err: "transport_err",
+	server_input_delete:        "delete",
+	client_input_1xx:           "1xx",
+	client_input_2xx:           "2xx",
+	client_input_300_plus:      "300_plus",
+	client_input_timer_a:       "timer_a",
+	client_input_timer_b:       "timer_b",
+	client_input_timer_d:       "timer_d",
+	client_input_timer_m:       "timer_m",
+	client_input_transport_err: "transport_err",
+	client_input_delete:        "delete",
+	client_input_cancel:        "cancel",
+	client_input_canceled:      "canceled",
+	fsmInputInit:               "init",
+}
+
+// fsmInputInit is journal input of transaction creation
+const fsmInputInit fsmInput = -1
+
+func (i fsmInput) String() string {
+	if n, ok := fsmInputNames[i]; ok {
+		return n
+	}
+	return fmt.Sprintf("input_%d", int(i))
+}
@@ -0,0 +1,70 @@
+package sip
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emiago/sipgo/fakes"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxJournal(t *testing.T) {
+	req, callid, _ := testCreateInvite(t, "127.0.0.99:5060", "udp", "127.0.0.2:5060")
+	conn := &UDPConnection{
+		PacketConn: &fakes.UDPConn{
+			Reader:  bytes.NewBuffer([]byte{}),
+			Writers: map[string]io.Writer{"127.0.0.99:5060": bytes.NewBuffer([]byte{})},
+		},
+	}
+
+	journal := NewTxJournal(10)
+	tx := NewClientTx("journal", req, conn, log.Logger)
+	tx.journal = journal
+	require.NoError(t, tx.Init())
+	defer tx.Terminate()
+
+	go func() { <-tx.Responses() }()
+	tx.receive(NewResponseFromRequest(req, StatusTrying, "Trying", nil))
+
+	entries := journal.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, TxJournalEntry{Time: entries[0].Time, Key: "journal", CallID: callid, Input: "init", To: "inviteStateCalling"}, entries[0])
+	assert.Equal(t, "1xx", entries[1].Input)
+	assert.Equal(t, "inviteStateCalling", entries[1].From)
+	assert.Equal(t, "inviteStateProcceeding", entries[1].To)
+
+	rec := httptest.NewRecorder()
+	journal.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assert.Contains(t, rec.Body.String(), "journal call-id="+callid+" 1xx: inviteStateCalling -> inviteStateProcceeding\n")
+
+	t.Run("RingWrap", func(t *testing.T) {
+		journal := NewTxJournal(3)
+		tx := &commonTx{origin: req}
+		for _, key := range []string{"1", "2", "3", "4", "5"} {
+			tx.key = key
+			journal.record(tx, fsmInputInit, nil, tx.stateTest)
+		}
+
+		entries := journal.Entries()
+		require.Len(t, entries, 3)
+		keys := []string{}
+		for _, e := range entries {
+			keys = append(keys, e.Key)
+		}
+		assert.Equal(t, []string{"3", "4", "5"}, keys)
+
+		buf := &strings.Builder{}
+		_, err := journal.WriteTo(buf)
+		require.NoError(t, err)
+		assert.Equal(t, 3, strings.Count(buf.String(), "init:  -> stateTest\n"))
+	})
+}
+
+func (tx *commonTx) stateTest(s fsmInput) fsmInput {
+	return FsmInputNone
+}
@@ -3,6 +3,7 @@ package sip
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -24,10 +25,19 @@ type TransactionLayer struct {
 	tpl           *TransportLayer
 	reqHandler    RequestHandler
 	unRespHandler UnhandledResponseHandler
+	// statelessReqHandler receives requests without creating server transaction
+	statelessReqHandler func(req *Request)
 
 	clientTransactions *transactionStore
 	serverTransactions *transactionStore
 
+	// handling is number of messages currently being processed
+	handling atomic.Int64
+	// profilingLabels enables pprof labels on message processing goroutines
+	profilingLabels atomic.Bool
+	// journal records transaction state transitions
+	journal atomic.Pointer[TxJournal]
+
 	log zerolog.Logger
 }
 
@@ -50,6 +60,28 @@ func (txl *TransactionLayer) OnRequest(h RequestHandler) {
 	txl.reqHandler = h
 }
 
+// ClientTransactionsLen returns number of active client transactions
+func (txl *TransactionLayer) ClientTransactionsLen() int {
+	return txl.clientTransactions.len()
+}
+
+// ServerTransactionsLen returns number of active server transactions
+func (txl *TransactionLayer) ServerTransactionsLen() int {
+	return txl.serverTransactions.len()
+}
+
+// HandlingLen returns number of received messages currently being processed by transaction layer and handlers
+func (txl *TransactionLayer) HandlingLen() int {
+	return int(txl.handling.Load())
+}
+
+// OnRequestStateless sets handler receiving requests without creating server transactions.
+// Retransmissions, ACK and CANCEL are passed as any other request. It has priority over OnRequest
+// and it is meant for stateless proxying
+func (txl *TransactionLayer) OnRequestStateless(h func(req *Request)) {
+	txl.statelessReqHandler = h
+}
+
 // UnhandledResponseHandler can be used in case missing client transactions for handling response
 // ServerTransaction handle responses by state machine
 func (txl *TransactionLayer) UnhandledResponseHandler(f UnhandledResponseHandler) {
@@ -64,15 +96,28 @@ func (txl *TransactionLayer) handleMessage(msg Message) {
 
 	switch msg := msg.(type) {
 	case *Request:
-		go txl.handleRequest(msg)
+		txl.handling.Add(1)
+		go func() {
+			defer txl.handling.Add(-1)
+			txl.withProfilingLabels(msg, func() { txl.handleRequest(msg) })
+		}()
 	case *Response:
-		go txl.handleResponse(msg)
+		txl.handling.Add(1)
+		go func() {
+			defer txl.handling.Add(-1)
+			txl.withProfilingLabels(msg, func() { txl.handleResponse(msg) })
+		}()
 	default:
 		txl.log.Error().Msg("unsupported message, skip it")
 	}
 }
 
 func (txl *TransactionLayer) handleRequest(req *Request) {
+	if txl.statelessReqHandler != nil {
+		txl.statelessReqHandler(req)
+		return
+	}
+
 	key, err := MakeServerTxKey(req)
 	if err != nil {
 		txl.log.Error().Err(err).Msg("Server tx make key failed")
@@ -101,6 +146,7 @@ func (txl *TransactionLayer) handleRequest(req *Request) {
 	}
 
 	tx = NewServerTx(key, req, conn, txl.log)
+	tx.journal = txl.journal.Load()
 
 	if err := tx.Init(); err != nil {
 		txl.log.Error().Err(err).Msg("Server tx init failed")
@@ -160,6 +206,7 @@ func (txl *TransactionLayer) Request(ctx context.Context, req *Request) (*Client
 
 	// TODO
 	tx := NewClientTx(key, req, conn, txl.log)
+	tx.journal = txl.journal.Load()
 	if err != nil {
 		return nil, err
 	}
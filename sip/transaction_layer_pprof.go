@@ -0,0 +1,59 @@
+package sip
+
+import (
+	"context"
+	"hash/fnv"
+	"runtime/pprof"
+	"strconv"
+)
+
+// SetProfilingLabels enables pprof labels on goroutines processing received messages.
+// Labels are sip_msg (request or response), sip_method and sip_callid (hash of Call-ID).
+// Goroutines started by handlers inherit labels, so CPU profiles can be filtered
+// by traffic type or single call. Ex. go tool pprof -tagfocus sip_method=INVITE
+func (txl *TransactionLayer) SetProfilingLabels(enabled bool) {
+	txl.profilingLabels.Store(enabled)
+}
+
+// withProfilingLabels runs f with pprof labels of msg if enabled
+func (txl *TransactionLayer) withProfilingLabels(msg Message, f func()) {
+	if !txl.profilingLabels.Load() {
+		f()
+		return
+	}
+
+	pprof.Do(context.Background(), messageProfilingLabels(msg), func(ctx context.Context) {
+		f()
+	})
+}
+
+func messageProfilingLabels(msg Message) pprof.LabelSet {
+	var typ, method string
+	switch m := msg.(type) {
+	case *Request:
+		typ = "request"
+		method = string(m.Method)
+	case *Response:
+		typ = "response"
+		if cseq := m.CSeq(); cseq != nil {
+			method = string(cseq.MethodName)
+		}
+	}
+
+	return pprof.Labels(
+		"sip_msg", typ,
+		"sip_method", method,
+		"sip_callid", callIDHash(msg),
+	)
+}
+
+// callIDHash returns short hash of Call-ID. Raw Call-ID is avoided as it may be long or contain host info
+func callIDHash(msg Message) string {
+	callid := msg.CallID()
+	if callid == nil {
+		return ""
+	}
+	h := fnv.New32a()
+	h.Write([]byte(callid.Value()))
+	return strconv.FormatUint(uint64(h.Sum32()), 16)
+}
@@ -0,0 +1,26 @@
+package sip
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageProfilingLabels(t *testing.T) {
+	req, _, _ := testCreateInvite(t, "127.0.0.99:5060", "udp", "127.0.0.2:5060")
+	res := NewResponseFromRequest(req, StatusOK, "OK", nil)
+
+	for msg, typ := range map[Message]string{req: "request", res: "response"} {
+		ctx := pprof.WithLabels(context.Background(), messageProfilingLabels(msg))
+
+		v, _ := pprof.Label(ctx, "sip_msg")
+		assert.Equal(t, typ, v)
+		v, _ = pprof.Label(ctx, "sip_method")
+		assert.Equal(t, "INVITE", v)
+		v, _ = pprof.Label(ctx, "sip_callid")
+		assert.Equal(t, callIDHash(req), v)
+		assert.NotEmpty(t, v)
+	}
+}
@@ -0,0 +1,84 @@
+package sip
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ResponseSender is goroutine safe handle for responding on server transaction from async workflows,
+// after request handler returned. It stays valid after transaction terminates, and every respond
+// then fails with ErrTransactionTerminated instead of being silently dropped.
+// Transaction is released on first respond after termination
+// Ex:
+//
+//	srv.OnInvite(func(req *sip.Request, tx sip.ServerTransaction) {
+//		sender := sip.NewResponseSender(tx)
+//		go func() {
+//			if err := sender.RespondWith(sip.StatusOK, "OK", sdp); errors.Is(err, sip.ErrTransactionTerminated) { ... }
+//		}()
+//		<-tx.Done()
+//	})
+type ResponseSender struct {
+	mu sync.Mutex
+	tx ServerTransaction
+}
+
+// NewResponseSender creates response sender for server transaction
+func NewResponseSender(tx ServerTransaction) *ResponseSender {
+	return &ResponseSender{tx: tx}
+}
+
+// Respond sends response over transaction
+func (s *ResponseSender) Respond(res *Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx, err := s.transaction()
+	if err != nil {
+		return fmt.Errorf("respond %s: %w", res.StartLine(), err)
+	}
+	return tx.Respond(res)
+}
+
+// RespondWith builds response from transaction request and sends it. Check ServerTransaction.RespondWith
+func (s *ResponseSender) RespondWith(code StatusCode, reason string, body []byte, headers ...Header) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx, err := s.transaction()
+	if err != nil {
+		return fmt.Errorf("respond %d %s: %w", code, reason, err)
+	}
+	return tx.RespondWith(code, reason, body, headers...)
+}
+
+// Provisional sends provisional response like 180 Ringing
+func (s *ResponseSender) Provisional(code StatusCode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx, err := s.transaction()
+	if err != nil {
+		return fmt.Errorf("respond %d: %w", code, err)
+	}
+	return tx.Provisional(code)
+}
+
+// Terminated reports is transaction terminated
+func (s *ResponseSender) Terminated() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.transaction()
+	return err != nil
+}
+
+// transaction returns transaction if it is not terminated. Lock must be held
+func (s *ResponseSender) transaction() (ServerTransaction, error) {
+	if s.tx == nil {
+		return nil, ErrTransactionTerminated
+	}
+	select {
+	case <-s.tx.Done():
+		s.tx = nil
+		return nil, ErrTransactionTerminated
+	default:
+	}
+	return s.tx, nil
+}
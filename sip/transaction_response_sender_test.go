@@ -0,0 +1,48 @@
+package sip
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/emiago/sipgo/fakes"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseSender(t *testing.T) {
+	req, _, _ := testCreateInvite(t, "sip:127.0.0.99:5060", "udp", "127.0.0.2:5060")
+	req.SetSource("127.0.0.2:5060")
+	outgoing := bytes.NewBuffer(nil)
+	conn := &UDPConnection{
+		PacketConn: &fakes.UDPConn{
+			Reader:  bytes.NewBuffer(nil),
+			Writers: map[string]io.Writer{"127.0.0.2:5060": outgoing},
+		},
+	}
+	tx := NewServerTx("sender", req, conn, log.Logger)
+	require.NoError(t, tx.Init())
+
+	sender := NewResponseSender(tx)
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, sender.Provisional(StatusRinging))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 3, strings.Count(outgoing.String(), "SIP/2.0 180 Ringing"))
+	assert.False(t, sender.Terminated())
+
+	tx.Terminate()
+	err := sender.RespondWith(StatusOK, "OK", nil)
+	require.ErrorIs(t, err, ErrTransactionTerminated)
+	assert.True(t, sender.Terminated())
+	assert.ErrorIs(t, sender.Respond(NewResponseFromRequest(req, StatusOK, "OK", nil)), ErrTransactionTerminated)
+	assert.NotContains(t, outgoing.String(), "200 OK")
+}
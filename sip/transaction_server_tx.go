@@ -25,6 +25,8 @@ type ServerTx struct {
 	timer_1xx    *time.Timer
 	timer_l      *time.Timer
 	reliable     bool
+	// toTag is used in responses built by transaction
+	toTag string
 
 	mu sync.RWMutex
 
@@ -42,6 +44,7 @@ func NewServerTx(key string, origin *Request, conn Connection, logger zerolog.Lo
 	tx.done = make(chan struct{})
 	tx.log = logger
 	tx.origin = origin
+	tx.timers = currentTimers.Load()
 	tx.reliable = IsReliable(origin.Transport())
 	return tx
 }
@@ -54,8 +57,8 @@ func (tx *ServerTx) Init() error {
 	if tx.reliable {
 		tx.timer_i_time = 0
 	} else {
-		tx.timer_g_time = Timer_G
-		tx.timer_i_time = Timer_I
+		tx.timer_g_time = tx.timers.Timer_G
+		tx.timer_i_time = tx.timers.Timer_I
 	}
 
 	tx.mu.Unlock()
@@ -127,6 +130,41 @@ func (tx *ServerTx) Respond(res *Response) error {
 	return nil
 }
 
+// RespondWith builds response from transaction request and responds with it.
+// All responses built by transaction share same To tag
+func (tx *ServerTx) RespondWith(code StatusCode, reason string, body []byte, headers ...Header) error {
+	res := NewResponseFromRequest(tx.origin, code, reason, body)
+	if code != StatusTrying && !tx.origin.To().Params.Has("tag") {
+		res.To().Params["tag"] = tx.responseTag()
+	}
+	for _, h := range headers {
+		res.AppendHeader(h)
+	}
+	return tx.Respond(res)
+}
+
+// RespondSDP responds with 200 OK and SDP body
+func (tx *ServerTx) RespondSDP(sdp []byte) error {
+	return tx.RespondWith(StatusOK, "OK", sdp, NewHeader("Content-Type", "application/sdp"))
+}
+
+// Provisional responds with provisional response like 180 Ringing
+func (tx *ServerTx) Provisional(code StatusCode) error {
+	if code < 100 || code >= 200 {
+		return fmt.Errorf("status code %d is not provisional", code)
+	}
+	return tx.RespondWith(code, StatusText(code), nil)
+}
+
+func (tx *ServerTx) responseTag() string {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.toTag == "" {
+		tx.toTag = GenerateTagN(16)
+	}
+	return tx.toTag
+}
+
 func (tx *ServerTx) receiveRespond(res *Response) (fsmInput, error) {
 	tx.mu.Lock()
 	defer tx.mu.Unlock()
@@ -211,7 +249,7 @@ func (tx *ServerTx) passResp() error {
 	if err != nil {
 		tx.log.Debug().Err(err).Str("res", lastResp.StartLine()).Msg("fail to pass response")
 		tx.mu.Lock()
-		tx.lastErr = wrapTransportError(err)
+		tx.lastErr = wrapTransportError(err, lastResp)
 		tx.mu.Unlock()
 		return err
 	}
@@ -242,6 +280,9 @@ func (tx *ServerTx) initFSM() {
 	} else {
 		tx.fsmState = tx.stateTrying
 	}
+	if tx.journal != nil {
+		tx.journal.record(&tx.commonTx, fsmInputInit, nil, tx.fsmState)
+	}
 	tx.fsmMu.Unlock()
 }
 
@@ -183,8 +183,8 @@ func (tx *ServerTx) actRespondComplete() fsmInput {
 			})
 		} else {
 			tx.timer_g_time *= 2
-			if tx.timer_g_time > T2 {
-				tx.timer_g_time = T2
+			if tx.timer_g_time > tx.timers.T2 {
+				tx.timer_g_time = tx.timers.T2
 			}
 
 			// tx.Log().Tracef("timer_g reset to %v", tx.timer_g_time)
@@ -196,7 +196,7 @@ func (tx *ServerTx) actRespondComplete() fsmInput {
 
 	tx.mu.Lock()
 	if tx.timer_h == nil {
-		tx.timer_h = time.AfterFunc(Timer_H, func() {
+		tx.timer_h = time.AfterFunc(tx.timers.Timer_H, func() {
 			// tx.Log().Trace("timer_h fired")
 			tx.spinFsm(server_input_timer_h)
 		})
@@ -213,7 +213,7 @@ func (tx *ServerTx) actRespondAccept() fsmInput {
 
 	tx.mu.Lock()
 	// tx.Log().Tracef("timer_l set to %v", Timer_L)
-	tx.timer_l = time.AfterFunc(Timer_L, func() {
+	tx.timer_l = time.AfterFunc(tx.timers.Timer_L, func() {
 		// tx.Log().Trace("timer_l fired")
 		tx.spinFsm(server_input_timer_l)
 	})
@@ -234,7 +234,7 @@ func (tx *ServerTx) actFinal() fsmInput {
 	}
 
 	tx.mu.Lock()
-	tx.timer_j = time.AfterFunc(Timer_J, func() {
+	tx.timer_j = time.AfterFunc(tx.timers.Timer_J, func() {
 		// tx.Log().Trace("timer_j fired")
 		tx.spinFsm(server_input_timer_j)
 	})
@@ -273,7 +273,7 @@ func (tx *ServerTx) actRespondDelete() fsmInput {
 
 	if err != nil {
 		tx.mu.Lock()
-		tx.lastErr = wrapTransportError(err)
+		tx.lastErr = wrapTransportError(err, tx.lastResp)
 		tx.mu.Unlock()
 		tx.log.Debug().Err(err).Msg("fail to actRespondDelete")
 		return server_input_transport_err
@@ -302,7 +302,7 @@ func (tx *ServerTx) actConfirm() fsmInput {
 
 	// tx.Log().Tracef("timer_i set to %v", Timer_I)
 
-	tx.timer_i = time.AfterFunc(Timer_I, func() {
+	tx.timer_i = time.AfterFunc(tx.timers.Timer_I, func() {
 		// tx.Log().Trace("timer_i fired")
 		tx.spinFsm(server_input_timer_i)
 	})
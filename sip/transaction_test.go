@@ -0,0 +1,35 @@
+package sip
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionTimersRuntimeChange(t *testing.T) {
+	defer ApplyTimers(GetTimers())
+
+	SetTimers(100*time.Millisecond, time.Second, 2*time.Second)
+	req, _, _ := testCreateInvite(t, "127.0.0.99:5060", "udp", "127.0.0.2:5060")
+	tx := NewClientTx("123", req, nil, log.Logger)
+
+	SetTimers(2*time.Second, 8*time.Second, 10*time.Second)
+	newTx := NewServerTx("124", req, nil, log.Logger)
+
+	// Running transaction keeps timers it is created with
+	assert.Equal(t, 100*time.Millisecond, tx.timers.T1)
+	assert.Equal(t, 6400*time.Millisecond, tx.timers.Timer_B)
+	assert.Equal(t, 2*time.Second, newTx.timers.T1)
+
+	timers := GetTimers()
+	assert.Equal(t, 2*time.Second, timers.T1)
+	assert.Equal(t, 128*time.Second, timers.Timer_B)
+	assert.Equal(t, 10*time.Second, timers.Timer_K)
+	assert.Equal(t, timers.Timer_B, Timer_B)
+
+	timers.Timer_D = 5 * time.Second
+	ApplyTimers(timers)
+	assert.Equal(t, 5*time.Second, GetTimers().Timer_D)
+}
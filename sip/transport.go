@@ -24,6 +24,8 @@ const (
 	TransportTLS = "TLS"
 	TransportWS  = "WS"
 	TransportWSS = "WSS"
+	// TransportQUIC is experimental and not standardized. Check TransportLayer.EnableQUIC
+	TransportQUIC = "QUIC"
 
 	transportBufferSize uint16 = 65535
 
@@ -43,6 +45,18 @@ type Transport interface {
 	Close() error
 }
 
+// Dialer is used by transport layer for creating outbound connections.
+// net.Dialer or dialers like SOCKS5 proxy implement it
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// PacketListener is used by transport layer for creating UDP sockets for outbound requests.
+// net.ListenConfig implements it
+type PacketListener interface {
+	ListenPacket(ctx context.Context, network, address string) (net.PacketConn, error)
+}
+
 type Addr struct {
 	IP   net.IP // Must be in IP format
 	Port int
@@ -0,0 +1,135 @@
+package sip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ACL is access control list matching source IP against CIDR allow/deny lists.
+// Deny list has precedence. If allow list is empty, every address not denied is allowed
+type ACL struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+// NewACL creates ACL from list of CIDR or plain IP addresses
+// Ex: NewACL([]string{"10.0.0.0/8"}, []string{"10.1.1.1"})
+func NewACL(allow []string, deny []string) (*ACL, error) {
+	a := &ACL{}
+	var err error
+	if a.allow, err = parseACLNets(allow); err != nil {
+		return nil, err
+	}
+	if a.deny, err = parseACLNets(deny); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func parseACLNets(list []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(list))
+	for _, s := range list {
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid ACL address %q", s)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ACL address %q. err=%w", s, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// Allowed checks is IP allowed by ACL
+func (a *ACL) Allowed(ip net.IP) bool {
+	for _, n := range a.deny {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+
+	if len(a.allow) == 0 {
+		return true
+	}
+
+	for _, n := range a.allow {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedAddr checks is address in form host:port allowed by ACL
+func (a *ACL) AllowedAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	return a.Allowed(ip)
+}
+
+func aclAllowedNetAddr(a *ACL, addr net.Addr) bool {
+	switch v := addr.(type) {
+	case *net.UDPAddr:
+		return a.Allowed(v.IP)
+	case *net.TCPAddr:
+		return a.Allowed(v.IP)
+	}
+	return a.AllowedAddr(addr.String())
+}
+
+// aclListener closes accepted connections that are not allowed before any read happens
+type aclListener struct {
+	net.Listener
+	acl *ACL
+}
+
+func (l *aclListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		if aclAllowedNetAddr(l.acl, conn.RemoteAddr()) {
+			return conn, nil
+		}
+		conn.Close()
+	}
+}
+
+// aclPacketConn drops packets from addresses not allowed before parsing
+type aclPacketConn struct {
+	net.PacketConn
+	acl *ACL
+}
+
+func (c *aclPacketConn) ReadFrom(b []byte) (n int, addr net.Addr, err error) {
+	for {
+		n, addr, err = c.PacketConn.ReadFrom(b)
+		if err != nil {
+			return n, addr, err
+		}
+
+		if aclAllowedNetAddr(c.acl, addr) {
+			return n, addr, err
+		}
+	}
+}
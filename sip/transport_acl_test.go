@@ -0,0 +1,67 @@
+package sip
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestACL(t *testing.T) {
+	acl, err := NewACL([]string{"10.0.0.0/8", "192.168.1.10", "fd00::/8"}, []string{"10.1.1.1"})
+	require.NoError(t, err)
+
+	assert.True(t, acl.Allowed(net.ParseIP("10.2.3.4")))
+	assert.True(t, acl.Allowed(net.ParseIP("192.168.1.10")))
+	assert.True(t, acl.Allowed(net.ParseIP("fd00::1")))
+	assert.False(t, acl.Allowed(net.ParseIP("10.1.1.1")))
+	assert.False(t, acl.Allowed(net.ParseIP("192.168.1.11")))
+
+	assert.True(t, acl.AllowedAddr("10.2.3.4:5060"))
+	assert.True(t, acl.AllowedAddr("[fd00::1]:5060"))
+	assert.False(t, acl.AllowedAddr("10.1.1.1:5060"))
+	assert.False(t, acl.AllowedAddr("example.com:5060"))
+
+	// Only deny list
+	acl, err = NewACL(nil, []string{"127.0.0.0/8"})
+	require.NoError(t, err)
+	assert.False(t, acl.AllowedAddr("127.0.0.1:5060"))
+	assert.True(t, acl.AllowedAddr("10.0.0.1:5060"))
+
+	_, err = NewACL([]string{"10.0.0.300"}, nil)
+	require.Error(t, err)
+	_, err = NewACL(nil, []string{"10.0.0.0/33"})
+	require.Error(t, err)
+}
+
+func TestACLPacketConn(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	denied, err := net.ListenPacket("udp", "127.0.0.2:0")
+	if err != nil {
+		t.Skip("loopback 127.0.0.2 not available")
+	}
+	defer denied.Close()
+
+	allowed, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer allowed.Close()
+
+	acl, err := NewACL(nil, []string{"127.0.0.2"})
+	require.NoError(t, err)
+	c := &aclPacketConn{PacketConn: conn, acl: acl}
+
+	_, err = denied.WriteTo([]byte("denied"), conn.LocalAddr())
+	require.NoError(t, err)
+	_, err = allowed.WriteTo([]byte("allowed"), conn.LocalAddr())
+	require.NoError(t, err)
+
+	buf := make([]byte, 100)
+	n, raddr, err := c.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "allowed", string(buf[:n]))
+	assert.Equal(t, allowed.LocalAddr().String(), raddr.String())
+}
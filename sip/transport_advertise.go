@@ -0,0 +1,126 @@
+package sip
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// AdvertisedAddr is external address presented to peers in Via, Contact and Record-Route.
+// Host can be IP or FQDN
+type AdvertisedAddr struct {
+	Host string
+	Port int
+}
+
+func (a AdvertisedAddr) String() string {
+	return net.JoinHostPort(a.Host, strconv.Itoa(a.Port))
+}
+
+type listenerAdvertise struct {
+	network string
+	ip      net.IP
+	port    int
+	addr    AdvertisedAddr
+}
+
+// SetAdvertisedAddr sets external address of listener bound on listenAddr.
+// It is used instead of local address when server is bound to multiple interfaces or behind 1:1 NAT.
+// Via sent-by is rewritten for requests sent from listener IP. Port 0 on adv keeps local port.
+// Calling it again for same listener updates address
+func (l *TransportLayer) SetAdvertisedAddr(network string, listenAddr string, adv AdvertisedAddr) error {
+	host, port, err := ParseAddr(listenAddr)
+	if err != nil {
+		return fmt.Errorf("fail to parse listen addr=%q: %w", listenAddr, err)
+	}
+
+	ip := net.ParseIP(host)
+	if host != "" && ip == nil {
+		return fmt.Errorf("listen addr must be IP addr=%q", listenAddr)
+	}
+
+	a := listenerAdvertise{
+		network: NetworkToLower(network),
+		ip:      ip,
+		port:    port,
+		addr:    adv,
+	}
+
+	l.advertisedMu.Lock()
+	defer l.advertisedMu.Unlock()
+	// Replace existing in case of updating
+	for i, e := range l.advertised {
+		if e.network == a.network && e.port == a.port && e.ip.Equal(a.ip) {
+			l.advertised[i] = a
+			return nil
+		}
+	}
+	l.advertised = append(l.advertised, a)
+	return nil
+}
+
+// GetAdvertisedAddr returns advertised address for local address laddr on network.
+// Listener with same IP and port is preferred, then listener on unspecified IP with same port,
+// and at last listener with same IP which matches outbound connections with ephemeral port
+func (l *TransportLayer) GetAdvertisedAddr(network string, laddr string) (AdvertisedAddr, bool) {
+	host, port, err := ParseAddr(laddr)
+	if err != nil {
+		return AdvertisedAddr{}, false
+	}
+	ip := net.ParseIP(host)
+	network = NetworkToLower(network)
+
+	l.advertisedMu.RLock()
+	defer l.advertisedMu.RUnlock()
+
+	var match *listenerAdvertise
+	rank := 0
+	for i := range l.advertised {
+		a := &l.advertised[i]
+		if a.network != network {
+			continue
+		}
+
+		sameIP := a.ip != nil && a.ip.Equal(ip)
+		unspecified := a.ip == nil || a.ip.IsUnspecified()
+		switch {
+		case sameIP && a.port == port:
+			// Exact match
+			return a.resolve(port), true
+		case unspecified && a.port == port && rank < 2:
+			match, rank = a, 2
+		case sameIP && rank < 1:
+			match, rank = a, 1
+		}
+	}
+
+	if match == nil {
+		return AdvertisedAddr{}, false
+	}
+	return match.resolve(port), true
+}
+
+func (a *listenerAdvertise) resolve(localPort int) AdvertisedAddr {
+	addr := a.addr
+	if addr.Port == 0 {
+		addr.Port = localPort
+	}
+	return addr
+}
+
+// applyAdvertisedVia rewrites Via sent-by if connection local address has advertised address
+func (l *TransportLayer) applyAdvertisedVia(network string, c Connection, viaHop *ViaHeader) {
+	l.advertisedMu.RLock()
+	empty := len(l.advertised) == 0
+	l.advertisedMu.RUnlock()
+	if empty {
+		return
+	}
+
+	adv, ok := l.GetAdvertisedAddr(network, c.LocalAddr().String())
+	if !ok {
+		return
+	}
+	viaHop.Host = adv.Host
+	viaHop.Port = adv.Port
+}
@@ -0,0 +1,48 @@
+package sip
+
+import (
+	"net"
+	"testing"
+
+	"github.com/emiago/sipgo/fakes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportLayerAdvertisedAddr(t *testing.T) {
+	tp := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+	require.NoError(t, tp.SetAdvertisedAddr("udp", "10.0.0.1:5060", AdvertisedAddr{Host: "203.0.113.1", Port: 5060}))
+	require.NoError(t, tp.SetAdvertisedAddr("udp", "0.0.0.0:5070", AdvertisedAddr{Host: "sip.example.com"}))
+	require.NoError(t, tp.SetAdvertisedAddr("TCP", "192.168.1.1:5060", AdvertisedAddr{Host: "198.51.100.1", Port: 5060}))
+	require.Error(t, tp.SetAdvertisedAddr("udp", "example.com:5060", AdvertisedAddr{Host: "203.0.113.1"}))
+
+	for _, tc := range []struct {
+		network  string
+		laddr    string
+		expected string
+		ok       bool
+	}{
+		{"udp", "10.0.0.1:5060", "203.0.113.1:5060", true},
+		{"udp", "10.0.0.2:5070", "sip.example.com:5070", true},
+		{"udp", "10.0.0.1:40000", "203.0.113.1:5060", true},
+		{"tcp", "192.168.1.1:51234", "198.51.100.1:5060", true},
+		{"tcp", "10.0.0.1:5060", "", false},
+		{"udp", "10.0.0.3:5060", "", false},
+	} {
+		adv, ok := tp.GetAdvertisedAddr(tc.network, tc.laddr)
+		assert.Equal(t, tc.ok, ok, tc.laddr)
+		if ok {
+			assert.Equal(t, tc.expected, adv.String())
+		}
+	}
+
+	t.Run("Via", func(t *testing.T) {
+		conn := &UDPConnection{
+			PacketConn: &fakes.UDPConn{LAddr: net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5060}},
+		}
+		via := &ViaHeader{Host: "10.0.0.1", Port: 5060, Params: NewParams()}
+		tp.applyAdvertisedVia("udp", conn, via)
+		assert.Equal(t, "203.0.113.1", via.Host)
+		assert.Equal(t, 5060, via.Port)
+	})
+}
@@ -0,0 +1,159 @@
+package sip
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// blacklistMaxDestinations limits tracked destinations before quiet entries are swept
+var blacklistMaxDestinations = 10000
+
+// DestinationBlacklist tracks failing destinations ip:port, like timeouts, refused connections or 503 responses.
+// Blacklisted destination is skipped during target selection, unless all targets are blacklisted.
+// Every consecutive failure doubles blacklist duration from base up to max, and success clears it
+type DestinationBlacklist struct {
+	base time.Duration
+	max  time.Duration
+
+	// OnBlacklist is called when destination gets blacklisted with number of consecutive failures
+	OnBlacklist func(dest string, failures int, d time.Duration)
+
+	mu    sync.Mutex
+	dests map[string]*blacklistDest
+}
+
+type blacklistDest struct {
+	failures int
+	reason   string
+	until    time.Time
+}
+
+// BlacklistEntry is state of tracked destination
+type BlacklistEntry struct {
+	Destination string
+	// Failures is number of consecutive failures
+	Failures int
+	// Reason is last failure
+	Reason string
+	// Until is end of blacklisting. After it destination is retried and next failure doubles duration
+	Until time.Time
+}
+
+// NewDestinationBlacklist creates blacklist with backoff starting at base and limited by max
+func NewDestinationBlacklist(base time.Duration, max time.Duration) *DestinationBlacklist {
+	if max < base {
+		max = base
+	}
+	return &DestinationBlacklist{
+		base:  base,
+		max:   max,
+		dests: make(map[string]*blacklistDest),
+	}
+}
+
+// Failure records failure on destination and returns duration it is blacklisted for
+func (b *DestinationBlacklist) Failure(dest string, reason string) time.Duration {
+	now := time.Now()
+
+	b.mu.Lock()
+	d, exists := b.dests[dest]
+	if !exists {
+		if len(b.dests) >= blacklistMaxDestinations {
+			b.sweep(now)
+		}
+		d = &blacklistDest{}
+		b.dests[dest] = d
+	}
+
+	if now.Before(d.until) {
+		// Failures of requests sent before blacklisting do not extend it
+		d.reason = reason
+		dur := d.until.Sub(now)
+		b.mu.Unlock()
+		return dur
+	}
+
+	d.failures++
+	d.reason = reason
+	dur := b.base
+	for i := 1; i < d.failures && dur < b.max; i++ {
+		dur *= 2
+	}
+	if dur > b.max {
+		dur = b.max
+	}
+	d.until = now.Add(dur)
+	failures := d.failures
+	b.mu.Unlock()
+
+	log.Info().Str("dest", dest).Str("reason", reason).Int("failures", failures).Dur("duration", dur).Msg("Destination blacklisted")
+	if b.OnBlacklist != nil {
+		b.OnBlacklist(dest, failures, dur)
+	}
+	return dur
+}
+
+// Success clears destination failures
+func (b *DestinationBlacklist) Success(dest string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.dests, dest)
+}
+
+// Blacklisted checks is destination currently blacklisted
+func (b *DestinationBlacklist) Blacklisted(dest string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	d, exists := b.dests[dest]
+	if !exists {
+		return false
+	}
+	return time.Now().Before(d.until)
+}
+
+// Entries returns state of all tracked destinations sorted by destination
+func (b *DestinationBlacklist) Entries() []BlacklistEntry {
+	b.mu.Lock()
+	entries := make([]BlacklistEntry, 0, len(b.dests))
+	for dest, d := range b.dests {
+		entries = append(entries, BlacklistEntry{
+			Destination: dest,
+			Failures:    d.failures,
+			Reason:      d.reason,
+			Until:       d.until,
+		})
+	}
+	b.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Destination < entries[j].Destination })
+	return entries
+}
+
+// sweep removes destinations not failing for max duration after blacklisting. Lock must be held
+func (b *DestinationBlacklist) sweep(now time.Time) {
+	for k, d := range b.dests {
+		if now.Sub(d.until) > b.max {
+			delete(b.dests, k)
+		}
+	}
+}
+
+// blacklistOrder moves blacklisted targets behind others, so they are used only as last resort
+func (l *TransportLayer) blacklistOrder(targets []Addr) []Addr {
+	if l.Blacklist == nil {
+		return targets
+	}
+	ordered := make([]Addr, 0, len(targets))
+	var blacklisted []Addr
+	for _, t := range targets {
+		if l.Blacklist.Blacklisted(t.String()) {
+			blacklisted = append(blacklisted, t)
+			continue
+		}
+		ordered = append(ordered, t)
+	}
+	return append(ordered, blacklisted...)
+}
@@ -0,0 +1,71 @@
+package sip
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDestinationBlacklist(t *testing.T) {
+	bl := NewDestinationBlacklist(20*time.Millisecond, 50*time.Millisecond)
+	var notified []int
+	bl.OnBlacklist = func(dest string, failures int, d time.Duration) {
+		notified = append(notified, failures)
+	}
+
+	dest := "192.0.2.1:5060"
+	assert.False(t, bl.Blacklisted(dest))
+	assert.Equal(t, 20*time.Millisecond, bl.Failure(dest, "timeout"))
+	assert.True(t, bl.Blacklisted(dest))
+
+	// Failure while blacklisted does not extend it
+	assert.LessOrEqual(t, bl.Failure(dest, "timeout"), 20*time.Millisecond)
+
+	time.Sleep(30 * time.Millisecond)
+	assert.False(t, bl.Blacklisted(dest))
+	assert.Equal(t, 40*time.Millisecond, bl.Failure(dest, "connection refused"))
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, 50*time.Millisecond, bl.Failure(dest, "503 Service Unavailable"), "backoff is limited by max")
+	assert.Equal(t, []int{1, 2, 3}, notified)
+
+	entries := bl.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, dest, entries[0].Destination)
+	assert.Equal(t, 3, entries[0].Failures)
+	assert.Equal(t, "503 Service Unavailable", entries[0].Reason)
+	assert.True(t, entries[0].Until.After(time.Now()))
+
+	bl.Success(dest)
+	assert.False(t, bl.Blacklisted(dest))
+	assert.Empty(t, bl.Entries())
+}
+
+func TestTransportLayerBlacklistTargets(t *testing.T) {
+	resolver := &testDNSResolver{
+		calls: map[string]int{},
+		ips:   map[string][]net.IPAddr{},
+		srv: []*net.SRV{
+			{Target: "192.0.2.1.", Port: 5060, Priority: 10},
+			{Target: "192.0.2.2.", Port: 5060, Priority: 20},
+		},
+	}
+	tp := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+	defer tp.Close()
+	tp.SetDNSResolver(resolver)
+	tp.Blacklist = NewDestinationBlacklist(time.Minute, time.Minute)
+	tp.Blacklist.Failure("192.0.2.1:5060", "timeout")
+
+	req := NewRequest(OPTIONS, &Uri{Host: "example.com"})
+	targets, err := tp.ResolveTargets(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"192.0.2.2:5060", "192.0.2.1:5060"}, targets)
+
+	addr := Addr{Port: 5060}
+	require.NoError(t, tp.resolveAddr(context.Background(), "udp", "example.com", &addr))
+	assert.Equal(t, "192.0.2.2:5060", addr.String())
+}
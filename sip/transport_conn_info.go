@@ -0,0 +1,75 @@
+package sip
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+)
+
+// ConnectionState is lifecycle state of connection oriented transport connection
+type ConnectionState int
+
+const (
+	ConnectionStateOpen ConnectionState = iota
+	ConnectionStateClosed
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case ConnectionStateOpen:
+		return "Open"
+	case ConnectionStateClosed:
+		return "Closed"
+	}
+	return ""
+}
+
+// ConnectionStateHandler is called on connection open and close for TCP, TLS, WS and WSS transports.
+// It is called from transport read loop, so avoid blocking
+type ConnectionStateHandler func(info *ConnectionInfo, state ConnectionState)
+
+// ConnectionInfo is identity of connection on which message is received
+type ConnectionInfo struct {
+	// Network is transport like TCP, TLS, WS, WSS
+	Network    string
+	LocalAddr  net.Addr
+	RemoteAddr net.Addr
+
+	conn net.Conn
+}
+
+func newConnectionInfo(network string, conn net.Conn) *ConnectionInfo {
+	return &ConnectionInfo{
+		Network:    network,
+		LocalAddr:  conn.LocalAddr(),
+		RemoteAddr: conn.RemoteAddr(),
+		conn:       conn,
+	}
+}
+
+// TLSState returns connection TLS state. Nil is returned if connection is not secured.
+// For TLS transport handshake is completed before ConnectionStateOpen
+func (c *ConnectionInfo) TLSState() *tls.ConnectionState {
+	tc, ok := c.conn.(*tls.Conn)
+	if !ok {
+		return nil
+	}
+	state := tc.ConnectionState()
+	return &state
+}
+
+// PeerCertificates returns certificates presented by remote peer
+func (c *ConnectionInfo) PeerCertificates() []*x509.Certificate {
+	if state := c.TLSState(); state != nil {
+		return state.PeerCertificates
+	}
+	return nil
+}
+
+// NegotiatedProtocol returns protocol negotiated by TLS ALPN. Check TransportLayer.SetTLSALPN
+func (c *ConnectionInfo) NegotiatedProtocol() string {
+	if state := c.TLSState(); state != nil {
+		return state.NegotiatedProtocol
+	}
+	return ""
+}
@@ -0,0 +1,239 @@
+package sip
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrConnectionPoolFull = errors.New("connection pool is full")
+)
+
+// ConnectionPoolConfig manages connections of connection oriented transports TCP, TLS, WS, WSS
+type ConnectionPoolConfig struct {
+	// IdleTimeout closes connections not used for sending for duration and without pending transactions.
+	// 0 disables it
+	IdleTimeout time.Duration
+	// MaxConnections limits number of connections per transport. When reached, least recently used idle
+	// connection is closed for new one, or request fails with ErrConnectionPoolFull.
+	// Accepted connections are counted, but never rejected. 0 is unlimited
+	MaxConnections int
+	// MaxPerDestination limits number of connections to same destination, as without ConnectionReuse
+	// new connection is created per request. When reached, existing connection is reused. 0 is unlimited
+	MaxPerDestination int
+}
+
+// ConnectionPoolStats is occupancy of transport connection pool
+type ConnectionPoolStats struct {
+	Network string
+	// Connections is number of open connections
+	Connections int
+	// Destinations is number of remote addresses with open connections
+	Destinations int
+	// Idle is number of connections without pending transactions
+	Idle int
+}
+
+// SetConnectionPool sets connection pool limits and idle timeout.
+// It must be set before creating any connection
+func (l *TransportLayer) SetConnectionPool(conf ConnectionPoolConfig) {
+	l.poolConf = &conf
+	if conf.IdleTimeout <= 0 {
+		return
+	}
+
+	l.poolDone = make(chan struct{})
+	go l.closeIdleConnections(conf.IdleTimeout, l.poolDone)
+}
+
+func (l *TransportLayer) closeIdleConnections(timeout time.Duration, done chan struct{}) {
+	// Check often enough so connection is not kept much longer than timeout
+	interval := timeout / 4
+	if interval < 100*time.Millisecond {
+		interval = 100 * time.Millisecond
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-t.C:
+		}
+
+		for network, pool := range l.connectionPools() {
+			if n := pool.closeIdle(timeout); n > 0 {
+				l.log.Debug().Str("network", network).Int("closed", n).Msg("Closed idle connections")
+			}
+		}
+	}
+}
+
+// connectionPools returns pools of connection oriented transports
+func (l *TransportLayer) connectionPools() map[string]*ConnectionPool {
+	pools := map[string]*ConnectionPool{
+		"tcp": &l.tcp.pool,
+		"tls": &l.tls.pool,
+		"ws":  &l.ws.pool,
+		"wss": &l.wss.pool,
+	}
+	if l.quic != nil {
+		pools["quic"] = &l.quic.pool
+	}
+	return pools
+}
+
+// poolConnection applies pool limits before new connection to addr is created.
+// Existing connection is returned in case it should be reused instead
+func (l *TransportLayer) poolConnection(network string, transport Transport, addr string) (Connection, error) {
+	if l.poolConf == nil {
+		return nil, nil
+	}
+	pool, ok := l.connectionPools()[network]
+	if !ok {
+		return nil, nil
+	}
+
+	if max := l.poolConf.MaxPerDestination; max > 0 && pool.destinationLen(addr) >= max {
+		if c, _ := transport.GetConnection(addr); c != nil {
+			l.log.Debug().Str("addr", addr).Int("max", max).Msg("Connections per destination limit reached. Reusing connection")
+			return c, nil
+		}
+	}
+
+	if max := l.poolConf.MaxConnections; max > 0 && pool.connectionsLen() >= max {
+		if !pool.evictIdle() {
+			return nil, fmt.Errorf("%s connection to %s: %w", network, addr, ErrConnectionPoolFull)
+		}
+		l.log.Debug().Str("network", network).Int("max", max).Msg("Connection limit reached. Closed least recently used connection")
+	}
+	return nil, nil
+}
+
+// ConnectionPoolStats returns occupancy of connection oriented transport pools sorted by network
+func (l *TransportLayer) ConnectionPoolStats() []ConnectionPoolStats {
+	pools := l.connectionPools()
+	stats := make([]ConnectionPoolStats, 0, len(pools))
+	for _, network := range []string{"tcp", "tls", "ws", "wss", "quic"} {
+		pool, ok := pools[network]
+		if !ok {
+			continue
+		}
+		s := pool.stats()
+		s.Network = network
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// ConnectionPoolExpvar returns expvar map with pool occupancy per network
+func (l *TransportLayer) ConnectionPoolExpvar() *expvar.Map {
+	m := new(expvar.Map)
+	m.Set("pools", expvar.Func(func() any {
+		pools := make(map[string]ConnectionPoolStats)
+		for _, s := range l.ConnectionPoolStats() {
+			pools[s.Network] = s
+		}
+		return pools
+	}))
+	return m
+}
+
+// connectionIdle reports is connection only kept in pool, without transactions or pending responses
+func connectionIdle(c Connection) bool {
+	if c.Ref(0) > 1+IdleConnection {
+		return false
+	}
+	if pc, ok := c.(interface{ hasPending() bool }); ok && pc.hasPending() {
+		return false
+	}
+	return true
+}
+
+func (p *ConnectionPool) destinationLen(addr string) int {
+	p.RLock()
+	defer p.RUnlock()
+	return len(p.conns[addr])
+}
+
+func (p *ConnectionPool) connectionsLen() int {
+	p.RLock()
+	defer p.RUnlock()
+	n := 0
+	for _, conns := range p.conns {
+		n += len(conns)
+	}
+	return n
+}
+
+func (p *ConnectionPool) stats() ConnectionPoolStats {
+	p.RLock()
+	defer p.RUnlock()
+	s := ConnectionPoolStats{Destinations: len(p.conns)}
+	for _, conns := range p.conns {
+		for c := range conns {
+			s.Connections++
+			if connectionIdle(c) {
+				s.Idle++
+			}
+		}
+	}
+	return s
+}
+
+// closeIdle closes connections idle and not used for timeout. Closed connections are removed from pool
+// by their read loop. It returns number of closed connections
+func (p *ConnectionPool) closeIdle(timeout time.Duration) int {
+	deadline := time.Now().Add(-timeout).UnixNano()
+	var idle []Connection
+	p.RLock()
+	for _, conns := range p.conns {
+		for c, pc := range conns {
+			if pc.lastUsed.Load() < deadline && connectionIdle(c) {
+				idle = append(idle, c)
+			}
+		}
+	}
+	p.RUnlock()
+
+	for _, c := range idle {
+		if err := c.Close(); err != nil {
+			log.Debug().Err(err).Msg("Closing idle connection returned error")
+		}
+	}
+	return len(idle)
+}
+
+// evictIdle closes and removes least recently used idle connection. It returns false if there is none
+func (p *ConnectionPool) evictIdle() bool {
+	p.Lock()
+	var (
+		lru     Connection
+		lruAddr string
+		lruUsed int64
+	)
+	for addr, conns := range p.conns {
+		for c, pc := range conns {
+			used := pc.lastUsed.Load()
+			if (lru == nil || used < lruUsed) && connectionIdle(c) {
+				lru, lruAddr, lruUsed = c, addr, used
+			}
+		}
+	}
+	if lru != nil {
+		p.remove(lruAddr, lru)
+	}
+	p.Unlock()
+
+	if lru == nil {
+		return false
+	}
+	if err := lru.Close(); err != nil {
+		log.Debug().Err(err).Msg("Closing evicted connection returned error")
+	}
+	return true
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"net"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
@@ -36,11 +38,23 @@ type ConnectionPool struct {
 	// TODO consider sync.Map way with atomic checks to reduce mutex contention
 	sync.RWMutex
 	m map[string]Connection
+	// conns are all open connections per address with last use. Without connection reuse
+	// new connection replaces previous one in m, while previous one stays open
+	conns map[string]map[Connection]*poolConn
+}
+
+type poolConn struct {
+	lastUsed atomic.Int64
+}
+
+func (pc *poolConn) use() {
+	pc.lastUsed.Store(time.Now().UnixNano())
 }
 
 func NewConnectionPool() ConnectionPool {
 	return ConnectionPool{
-		m: make(map[string]Connection),
+		m:     make(map[string]Connection),
+		conns: make(map[string]map[Connection]*poolConn),
 	}
 }
 
@@ -53,9 +67,24 @@ func (p *ConnectionPool) Add(a string, c Connection) {
 	}
 	p.Lock()
 	p.m[a] = c
+	p.track(a, c)
 	p.Unlock()
 }
 
+func (p *ConnectionPool) track(a string, c Connection) {
+	conns, exists := p.conns[a]
+	if !exists {
+		conns = make(map[Connection]*poolConn)
+		p.conns[a] = conns
+	}
+	pc, exists := conns[c]
+	if !exists {
+		pc = &poolConn{}
+		conns[c] = pc
+	}
+	pc.use()
+}
+
 func (p *ConnectionPool) AddIfNotExists(a string, c Connection) {
 	// TODO how about multi connection support for same remote address
 	// We can then check ref count
@@ -67,6 +96,7 @@ func (p *ConnectionPool) AddIfNotExists(a string, c Connection) {
 		return
 	}
 	p.m[a] = c
+	p.track(a, c)
 	p.Unlock()
 
 	if c.Ref(0) < 1 {
@@ -79,6 +109,11 @@ func (p *ConnectionPool) AddIfNotExists(a string, c Connection) {
 func (p *ConnectionPool) Get(a string) (c Connection) {
 	p.RLock()
 	c, exists := p.m[a]
+	if exists {
+		if pc := p.conns[a][c]; pc != nil {
+			pc.use()
+		}
+	}
 	p.RUnlock()
 	if !exists {
 		return nil
@@ -102,13 +137,32 @@ func (p *ConnectionPool) CloseAndDelete(c Connection, addr string) {
 			log.Warn().Err(err).Msg("Closing conection return error")
 		}
 	}
+	p.remove(addr, c)
+}
+
+// remove deletes connection of address. In case it is one used for address, other open connection replaces it
+func (p *ConnectionPool) remove(addr string, c Connection) {
+	conns := p.conns[addr]
+	delete(conns, c)
+	if len(conns) == 0 {
+		delete(p.conns, addr)
+	}
+
+	if cur, exists := p.m[addr]; exists && cur != c {
+		return
+	}
 	delete(p.m, addr)
+	for other := range conns {
+		p.m[addr] = other
+		break
+	}
 }
 
 func (p *ConnectionPool) Delete(addr string) {
 	p.Lock()
 	defer p.Unlock()
 	delete(p.m, addr)
+	delete(p.conns, addr)
 }
 
 func (p *ConnectionPool) DeleteMultiple(addrs []string) {
@@ -116,6 +170,7 @@ func (p *ConnectionPool) DeleteMultiple(addrs []string) {
 	defer p.Unlock()
 	for _, a := range addrs {
 		delete(p.m, a)
+		delete(p.conns, a)
 	}
 }
 
@@ -123,16 +178,30 @@ func (p *ConnectionPool) DeleteMultiple(addrs []string) {
 func (p *ConnectionPool) Clear() {
 	p.Lock()
 	defer p.Unlock()
-	for _, c := range p.m {
+	closed := make(map[Connection]struct{})
+	closeConn := func(c Connection) {
+		if _, exists := closed[c]; exists {
+			return
+		}
+		closed[c] = struct{}{}
 		if c.Ref(0) <= 0 {
-			continue
+			return
 		}
 		if err := c.Close(); err != nil {
 			log.Warn().Err(err).Msg("Closing conection return error")
 		}
 	}
+	for _, c := range p.m {
+		closeConn(c)
+	}
+	for _, conns := range p.conns {
+		for c := range conns {
+			closeConn(c)
+		}
+	}
 	// Remove all
 	p.m = make(map[string]Connection)
+	p.conns = make(map[string]map[Connection]*poolConn)
 }
 
 func (p *ConnectionPool) Size() int {
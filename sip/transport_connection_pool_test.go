@@ -1,12 +1,16 @@
 package sip
 
 import (
+	"context"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/emiago/sipgo/fakes"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestConnectionPool(t *testing.T) {
@@ -50,3 +54,120 @@ func BenchmarkConnectionPool(b *testing.B) {
 		}
 	}
 }
+
+func TestConnectionPoolReplacedConnection(t *testing.T) {
+	pool := NewConnectionPool()
+	addr := "127.0.0.2:5060"
+	newConn := func() *TCPConnection {
+		return &TCPConnection{Conn: &fakes.TCPConn{
+			LAddr: net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 5060},
+			RAddr: net.TCPAddr{IP: net.ParseIP("127.0.0.2"), Port: 5060},
+		}, refcount: 2}
+	}
+	old, cur := newConn(), newConn()
+	pool.Add(addr, old)
+	pool.Add(addr, cur)
+	require.Equal(t, 2, pool.destinationLen(addr))
+
+	// Closing replaced connection keeps current one
+	pool.CloseAndDelete(old, addr)
+	require.Equal(t, 1, pool.destinationLen(addr))
+	require.Equal(t, Connection(cur), pool.Get(addr))
+
+	pool.Add(addr, old)
+	pool.CloseAndDelete(old, addr)
+	pool.Add(addr, old)
+	// Closing current connection promotes other open one
+	pool.CloseAndDelete(cur, addr)
+	require.Equal(t, Connection(old), pool.Get(addr))
+}
+
+func TestTransportLayerConnectionPool(t *testing.T) {
+	listen := func() (net.Listener, int) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() { l.Close() })
+		go func() {
+			var conns []net.Conn
+			for {
+				conn, err := l.Accept()
+				if err != nil {
+					for _, c := range conns {
+						c.Close()
+					}
+					return
+				}
+				conns = append(conns, conn)
+			}
+		}()
+		_, port, err := ParseAddr(l.Addr().String())
+		require.NoError(t, err)
+		return l, port
+	}
+	request := func(port int) *Request {
+		req := NewRequest(OPTIONS, &Uri{Host: "127.0.0.1", Port: port})
+		req.AppendHeader(&ViaHeader{Host: "127.0.0.1", Port: 0, Transport: TransportTCP, Params: NewParams()})
+		req.SetTransport(TransportTCP)
+		return req
+	}
+
+	t.Run("MaxPerDestination", func(t *testing.T) {
+		_, port := listen()
+		tp := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+		defer tp.Close()
+		tp.ConnectionReuse = false
+		tp.SetConnectionPool(ConnectionPoolConfig{MaxPerDestination: 2})
+
+		conns := map[Connection]struct{}{}
+		for i := 0; i < 4; i++ {
+			c, err := tp.ClientRequestConnection(context.TODO(), request(port))
+			require.NoError(t, err)
+			conns[c] = struct{}{}
+			c.TryClose()
+		}
+		assert.Len(t, conns, 2)
+		assert.Equal(t, ConnectionPoolStats{Network: "tcp", Connections: 2, Destinations: 1, Idle: 2}, tp.ConnectionPoolStats()[0])
+	})
+
+	t.Run("MaxConnections", func(t *testing.T) {
+		_, port1 := listen()
+		_, port2 := listen()
+		_, port3 := listen()
+		tp := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+		defer tp.Close()
+		tp.SetConnectionPool(ConnectionPoolConfig{MaxConnections: 1})
+
+		c1, err := tp.ClientRequestConnection(context.TODO(), request(port1))
+		require.NoError(t, err)
+		c1.TryClose()
+
+		// Idle connection is evicted
+		c2, err := tp.ClientRequestConnection(context.TODO(), request(port2))
+		require.NoError(t, err)
+		require.Error(t, c1.(*TCPConnection).Conn.Close(), "evicted connection must be closed")
+		assert.Equal(t, 1, tp.tcp.pool.connectionsLen())
+
+		// Connection in use is not evicted
+		_, err = tp.ClientRequestConnection(context.TODO(), request(port3))
+		require.ErrorIs(t, err, ErrConnectionPoolFull)
+		c2.TryClose()
+	})
+
+	t.Run("IdleTimeout", func(t *testing.T) {
+		_, port := listen()
+		tp := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+		defer tp.Close()
+		tp.SetConnectionPool(ConnectionPoolConfig{IdleTimeout: 100 * time.Millisecond})
+
+		c, err := tp.ClientRequestConnection(context.TODO(), request(port))
+		require.NoError(t, err)
+		time.Sleep(300 * time.Millisecond)
+		// Connection in use is kept
+		assert.Equal(t, 1, tp.tcp.pool.connectionsLen())
+
+		c.TryClose()
+		require.Eventually(t, func() bool {
+			return tp.tcp.pool.connectionsLen() == 0
+		}, 2*time.Second, 50*time.Millisecond)
+	})
+}
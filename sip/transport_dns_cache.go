@@ -0,0 +1,198 @@
+package sip
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// DNSResolver resolves destination hosts for transport layer. net.Resolver implements it
+type DNSResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// DNSCacheConfig configures DNSCache
+type DNSCacheConfig struct {
+	// TTL of cached records. Go resolver does not expose record TTL, so it should not be higher
+	// than TTL of resolved zones. Default is 60s
+	TTL time.Duration
+	// NegativeTTL of cached not found results. Lookup failures like timeouts are never cached. Default is 10s
+	NegativeTTL time.Duration
+	// Refresh resolves record again in background when it is used close to expiry,
+	// so hot records never expire in request path
+	Refresh bool
+	// MaxEntries limits number of cached records. Default is 10000
+	MaxEntries int
+}
+
+type dnsCacheEntry struct {
+	ips        []net.IPAddr
+	cname      string
+	srv        []*net.SRV
+	err        error
+	expires    time.Time
+	refreshing bool
+}
+
+// DNSCache caches results of resolver, so destinations are not resolved for every transaction
+// Ex:
+//
+//	ua.TransportLayer().SetDNSResolver(sip.NewDNSCache(net.DefaultResolver, sip.DNSCacheConfig{Refresh: true}))
+type DNSCache struct {
+	resolver DNSResolver
+	conf     DNSCacheConfig
+	log      zerolog.Logger
+
+	mu      sync.Mutex
+	entries map[string]*dnsCacheEntry
+}
+
+// NewDNSCache creates cache in front of resolver
+func NewDNSCache(resolver DNSResolver, conf DNSCacheConfig) *DNSCache {
+	if conf.TTL <= 0 {
+		conf.TTL = 60 * time.Second
+	}
+	if conf.NegativeTTL <= 0 {
+		conf.NegativeTTL = 10 * time.Second
+	}
+	if conf.MaxEntries <= 0 {
+		conf.MaxEntries = 10000
+	}
+	return &DNSCache{
+		resolver: resolver,
+		conf:     conf,
+		log:      log.Logger.With().Str("caller", "DNSCache").Logger(),
+		entries:  make(map[string]*dnsCacheEntry),
+	}
+}
+
+// LookupIPAddr returns cached addresses of host
+func (c *DNSCache) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	e, err := c.lookup(ctx, "ip:"+host, func(ctx context.Context) (*dnsCacheEntry, error) {
+		ips, err := c.resolver.LookupIPAddr(ctx, host)
+		return &dnsCacheEntry{ips: ips}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return e.ips, nil
+}
+
+// LookupSRV returns cached SRV records
+func (c *DNSCache) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	e, err := c.lookup(ctx, "srv:"+service+"."+proto+"."+name, func(ctx context.Context) (*dnsCacheEntry, error) {
+		cname, srv, err := c.resolver.LookupSRV(ctx, service, proto, name)
+		return &dnsCacheEntry{cname: cname, srv: srv}, err
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return e.cname, e.srv, nil
+}
+
+// Flush removes all cached records
+func (c *DNSCache) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*dnsCacheEntry)
+}
+
+// Len returns number of cached records
+func (c *DNSCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+func (c *DNSCache) lookup(ctx context.Context, key string, resolve func(ctx context.Context) (*dnsCacheEntry, error)) (*dnsCacheEntry, error) {
+	now := time.Now()
+	c.mu.Lock()
+	e, exists := c.entries[key]
+	if exists && now.Before(e.expires) {
+		// Refresh in last 10% of TTL
+		if c.conf.Refresh && e.err == nil && !e.refreshing && e.expires.Sub(now) < c.conf.TTL/10 {
+			e.refreshing = true
+			go c.refresh(key, resolve)
+		}
+		c.mu.Unlock()
+		return e, e.err
+	}
+	c.mu.Unlock()
+
+	e, err := resolve(ctx)
+	c.store(key, e, err)
+	return e, err
+}
+
+func (c *DNSCache) refresh(key string, resolve func(ctx context.Context) (*dnsCacheEntry, error)) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	e, err := resolve(ctx)
+	if err != nil && !dnsNotFound(err) {
+		// Keep current record until it expires
+		c.log.Debug().Err(err).Str("key", key).Msg("DNS refresh failed")
+		c.mu.Lock()
+		if cur, ok := c.entries[key]; ok {
+			cur.refreshing = false
+		}
+		c.mu.Unlock()
+		return
+	}
+	c.store(key, e, err)
+}
+
+func (c *DNSCache) store(key string, e *dnsCacheEntry, err error) {
+	ttl := c.conf.TTL
+	if err != nil {
+		if !dnsNotFound(err) {
+			return
+		}
+		ttl = c.conf.NegativeTTL
+	}
+
+	now := time.Now()
+	e.err = err
+	e.expires = now.Add(ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.conf.MaxEntries {
+		c.evict(now)
+	}
+	c.entries[key] = e
+}
+
+// evict removes expired records, or any record if none is expired. Must be called with lock
+func (c *DNSCache) evict(now time.Time) {
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	if len(c.entries) < c.conf.MaxEntries {
+		return
+	}
+	for k := range c.entries {
+		delete(c.entries, k)
+		return
+	}
+}
+
+// dnsNotFound reports is error authoritative not found answer
+func dnsNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// SetDNSResolver sets resolver used for resolving destination hosts, ex. DNSCache.
+// It must be set before creating any connection
+func (l *TransportLayer) SetDNSResolver(r DNSResolver) {
+	l.dnsResolver = r
+}
@@ -0,0 +1,118 @@
+package sip
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testDNSResolver struct {
+	mu    sync.Mutex
+	calls map[string]int
+	ips   map[string][]net.IPAddr
+	srv   []*net.SRV
+	err   error
+}
+
+func (r *testDNSResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls[host]++
+	if r.err != nil {
+		return nil, r.err
+	}
+	ips, ok := r.ips[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return ips, nil
+}
+
+func (r *testDNSResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls["srv:"+name]++
+	if r.srv != nil {
+		return "", r.srv, nil
+	}
+	return "", []*net.SRV{{Target: "pbx.example.com.", Port: 5060}}, nil
+}
+
+func (r *testDNSResolver) count(host string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls[host]
+}
+
+func TestDNSCache(t *testing.T) {
+	ctx := context.Background()
+	r := &testDNSResolver{
+		calls: map[string]int{},
+		ips:   map[string][]net.IPAddr{"pbx.example.com": {{IP: net.ParseIP("192.0.2.1")}}},
+	}
+	cache := NewDNSCache(r, DNSCacheConfig{TTL: 100 * time.Millisecond, NegativeTTL: 50 * time.Millisecond})
+
+	for i := 0; i < 3; i++ {
+		ips, err := cache.LookupIPAddr(ctx, "pbx.example.com")
+		require.NoError(t, err)
+		assert.Equal(t, "192.0.2.1", ips[0].IP.String())
+	}
+	assert.Equal(t, 1, r.count("pbx.example.com"))
+
+	// Negative caching
+	for i := 0; i < 3; i++ {
+		_, err := cache.LookupIPAddr(ctx, "missing.example.com")
+		require.True(t, dnsNotFound(err))
+	}
+	assert.Equal(t, 1, r.count("missing.example.com"))
+
+	_, srv, err := cache.LookupSRV(ctx, "sip", "udp", "example.com")
+	require.NoError(t, err)
+	assert.Equal(t, uint16(5060), srv[0].Port)
+	cache.LookupSRV(ctx, "sip", "udp", "example.com")
+	assert.Equal(t, 1, r.count("srv:example.com"))
+	assert.Equal(t, 3, cache.Len())
+
+	// Expired
+	time.Sleep(110 * time.Millisecond)
+	cache.LookupIPAddr(ctx, "pbx.example.com")
+	cache.LookupIPAddr(ctx, "missing.example.com")
+	assert.Equal(t, 2, r.count("pbx.example.com"))
+	assert.Equal(t, 2, r.count("missing.example.com"))
+
+	// Failures are not cached
+	cache.Flush()
+	r.err = errors.New("timeout")
+	cache.LookupIPAddr(ctx, "pbx.example.com")
+	cache.LookupIPAddr(ctx, "pbx.example.com")
+	assert.Equal(t, 4, r.count("pbx.example.com"))
+	assert.Equal(t, 0, cache.Len())
+	r.err = nil
+
+	t.Run("Refresh", func(t *testing.T) {
+		cache := NewDNSCache(r, DNSCacheConfig{TTL: 100 * time.Millisecond, Refresh: true})
+		cache.LookupIPAddr(ctx, "pbx.example.com")
+		time.Sleep(95 * time.Millisecond)
+		// Served from cache and refreshed in background
+		_, err := cache.LookupIPAddr(ctx, "pbx.example.com")
+		require.NoError(t, err)
+		require.Eventually(t, func() bool { return r.count("pbx.example.com") == 6 }, time.Second, 5*time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+		cache.LookupIPAddr(ctx, "pbx.example.com")
+		assert.Equal(t, 6, r.count("pbx.example.com"))
+	})
+
+	t.Run("MaxEntries", func(t *testing.T) {
+		cache := NewDNSCache(r, DNSCacheConfig{MaxEntries: 2})
+		cache.LookupIPAddr(ctx, "a.example.com")
+		cache.LookupIPAddr(ctx, "b.example.com")
+		cache.LookupIPAddr(ctx, "c.example.com")
+		assert.Equal(t, 2, cache.Len())
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math/rand"
 	"net"
+	"strings"
 	"sync"
 	"time"
 
@@ -29,19 +30,57 @@ type TransportLayer struct {
 	tls *transportTLS
 	ws  *transportWS
 	wss *transportWSS
+	// quic is experimental transport registered with EnableQUIC
+	quic *transportQUIC
 
 	transports map[string]Transport
 
 	listenPorts   map[string][]int
 	listenPortsMu sync.Mutex
-	dnsResolver   *net.Resolver
+	dnsResolver   DNSResolver
 
-	handlers []MessageHandler
+	advertised   []listenerAdvertise
+	advertisedMu sync.RWMutex
+
+	handlers     []MessageHandler
+	connHandlers []ConnectionStateHandler
 
 	log zerolog.Logger
 
 	// ConnectionReuse will force connection reuse when passing request
 	ConnectionReuse bool
+
+	// ACL filters connections and packets on served listeners by source address.
+	// It must be set before calling any Serve
+	ACL *ACL
+
+	// STUN enables public address discovery for served UDP listeners.
+	// It must be set before calling ServeUDP
+	STUN *STUNConfig
+
+	// ParseGuard blocks sources sending unparsable messages on served listeners.
+	// It must be set before calling any Serve
+	ParseGuard *ParseErrorGuard
+
+	// PreferIPv6 selects AAAA over A results when resolving destination host, as needed on IPv6-only host.
+	// Addresses synthesized by DNS64 resolver are used transparently
+	PreferIPv6 bool
+
+	// NAT64Prefix makes IPv4 destinations reachable from IPv6-only host through NAT64,
+	// by embedding IPv4 address in prefix. Check DiscoverNAT64Prefix
+	NAT64Prefix *net.IPNet
+
+	// Blacklist skips failing destinations when selecting resolved target.
+	// Failed connection attempts are recorded on it
+	Blacklist *DestinationBlacklist
+
+	// socketOpts are set with SetSocketOptions
+	socketOpts *SocketOptions
+
+	// poolConf limits connection pools. Check SetConnectionPool
+	poolConf *ConnectionPoolConfig
+	// poolDone stops closing idle connections
+	poolDone chan struct{}
 }
 
 // NewLayer creates transport layer.
@@ -72,6 +111,17 @@ func NewTransportLayer(
 	// TODO. Using default dial tls, but it needs to configurable via client
 	l.wss = newWSSTransport(sipparser, tlsConfig)
 
+	l.tcp.connStateHandler = l.handleConnectionState
+	l.tls.connStateHandler = l.handleConnectionState
+	l.ws.connStateHandler = l.handleConnectionState
+	l.wss.connStateHandler = l.handleConnectionState
+
+	l.udp.parseErrHandler = l.handleParseError
+	l.tcp.parseErrHandler = l.handleParseError
+	l.tls.parseErrHandler = l.handleParseError
+	l.ws.parseErrHandler = l.handleParseError
+	l.wss.parseErrHandler = l.handleParseError
+
 	// Fill map for fast access
 	l.transports["udp"] = l.udp
 	l.transports["tcp"] = l.tcp
@@ -100,6 +150,36 @@ func (l *TransportLayer) OnMessage(h MessageHandler) {
 	l.handlers = append(l.handlers, h)
 }
 
+// SetDialer sets dialer used for creating outbound TCP, TLS, WS, WSS and connected UDP connections.
+// Local address from Via is not applied with custom dialer, so binding must be handled by dialer.
+// It must be set before creating any connection
+func (l *TransportLayer) SetDialer(d Dialer) {
+	l.udp.dialer = d
+	l.tcp.dialer = d
+	l.tls.dialer = d
+	l.ws.dialer.NetDial = d.DialContext
+	l.wss.dialer.NetDial = d.DialContext
+}
+
+// SetPacketListener sets packet listener used for creating UDP sockets for outbound requests.
+// It must be set before creating any connection
+func (l *TransportLayer) SetPacketListener(lc PacketListener) {
+	l.udp.packetListener = lc
+}
+
+// OnConnectionState registers handler called on connection open and close.
+// Only connection oriented transports TCP, TLS, WS, WSS are reported.
+// It must be registered before serving or creating connections
+func (l *TransportLayer) OnConnectionState(h ConnectionStateHandler) {
+	l.connHandlers = append(l.connHandlers, h)
+}
+
+func (l *TransportLayer) handleConnectionState(info *ConnectionInfo, state ConnectionState) {
+	for _, h := range l.connHandlers {
+		h(info, state)
+	}
+}
+
 // handleMessage is transport layer for handling messages
 func (l *TransportLayer) handleMessage(msg Message) {
 	// We have to consider
@@ -109,11 +189,47 @@ func (l *TransportLayer) handleMessage(msg Message) {
 	// 18.1.2 Receiving Responses
 	// States that transport should find transaction and if not, it should still forward message to core
 	// l.handler(msg)
+	if req, ok := msg.(*Request); ok {
+		setViaReceived(req)
+	}
+
 	for _, h := range l.handlers {
 		h(msg)
 	}
 }
 
+// setViaReceived fills received and rport parameters of top Via with request source address,
+// so that responses are routed back symmetrically to where request came from
+// https://datatracker.ietf.org/doc/html/rfc3261#section-18.2.1
+// https://datatracker.ietf.org/doc/html/rfc3581#section-4
+func setViaReceived(req *Request) {
+	src := req.MessageData.Source()
+	viaHop := req.Via()
+	if src == "" || viaHop == nil {
+		return
+	}
+
+	host, port, err := net.SplitHostPort(src)
+	if err != nil {
+		return
+	}
+
+	if viaHop.Params == nil {
+		viaHop.Params = NewParams()
+	}
+
+	if viaHop.Params.Has("rport") {
+		// Server MUST add received even if it is same as sent-by when rport is present
+		viaHop.Params.Add("rport", port)
+		viaHop.Params.Add("received", host)
+		return
+	}
+
+	if ip := net.ParseIP(viaHop.Host); ip == nil || !ip.Equal(net.ParseIP(host)) {
+		viaHop.Params.Add("received", host)
+	}
+}
+
 // ServeUDP will listen on udp connection
 func (l *TransportLayer) ServeUDP(c net.PacketConn) error {
 	_, port, err := ParseAddr(c.LocalAddr().String())
@@ -123,6 +239,19 @@ func (l *TransportLayer) ServeUDP(c net.PacketConn) error {
 
 	l.addListenPort("udp", port)
 
+	if l.ACL != nil {
+		c = &aclPacketConn{PacketConn: c, acl: l.ACL}
+	}
+
+	if l.ParseGuard != nil {
+		c = &parseGuardPacketConn{PacketConn: c, guard: l.ParseGuard}
+	}
+
+	if l.STUN != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go l.serveSTUN(ctx, c, l.STUN)
+	}
 	return l.udp.Serve(c, l.handleMessage)
 }
 
@@ -135,6 +264,13 @@ func (l *TransportLayer) ServeTCP(c net.Listener) error {
 
 	l.addListenPort("tcp", port)
 
+	if l.ACL != nil {
+		c = &aclListener{Listener: c, acl: l.ACL}
+	}
+
+	if l.ParseGuard != nil {
+		c = &parseGuardListener{Listener: c, guard: l.ParseGuard}
+	}
 	return l.tcp.Serve(c, l.handleMessage)
 }
 
@@ -147,6 +283,13 @@ func (l *TransportLayer) ServeWS(c net.Listener) error {
 
 	l.addListenPort("ws", port)
 
+	if l.ACL != nil {
+		c = &aclListener{Listener: c, acl: l.ACL}
+	}
+
+	if l.ParseGuard != nil {
+		c = &parseGuardListener{Listener: c, guard: l.ParseGuard}
+	}
 	return l.ws.Serve(c, l.handleMessage)
 }
 
@@ -158,6 +301,14 @@ func (l *TransportLayer) ServeTLS(c net.Listener) error {
 	}
 
 	l.addListenPort("tls", port)
+
+	if l.ACL != nil {
+		c = &aclListener{Listener: c, acl: l.ACL}
+	}
+
+	if l.ParseGuard != nil {
+		c = &parseGuardListener{Listener: c, guard: l.ParseGuard}
+	}
 	return l.tls.Serve(c, l.handleMessage)
 }
 
@@ -170,9 +321,45 @@ func (l *TransportLayer) ServeWSS(c net.Listener) error {
 
 	l.addListenPort("wss", port)
 
+	if l.ACL != nil {
+		c = &aclListener{Listener: c, acl: l.ACL}
+	}
+
+	if l.ParseGuard != nil {
+		c = &parseGuardListener{Listener: c, guard: l.ParseGuard}
+	}
 	return l.wss.Serve(c, l.handleMessage)
 }
 
+// EnableQUIC registers experimental QUIC transport with message framing, which both peers must use.
+// Dialer is used for outbound connections and can be nil for serving only.
+// It must be called before serving or creating any connection
+func (l *TransportLayer) EnableQUIC(dialer QUICDialer, framing QUICFraming) {
+	l.quic = newQUICTransport(l.udp.parser, dialer, framing)
+	l.quic.connStateHandler = l.handleConnectionState
+	l.quic.parseErrHandler = l.handleParseError
+	l.transports["quic"] = l.quic
+}
+
+// ServeQUIC will listen on quic listener. EnableQUIC must be called before
+func (l *TransportLayer) ServeQUIC(c QUICListener) error {
+	if l.quic == nil {
+		return fmt.Errorf("quic: %w", ErrTransportNotSuported)
+	}
+
+	_, port, err := ParseAddr(c.Addr().String())
+	if err != nil {
+		return err
+	}
+
+	l.addListenPort("quic", port)
+
+	if l.ACL != nil || l.ParseGuard != nil {
+		c = &filterQUICListener{QUICListener: c, acl: l.ACL, guard: l.ParseGuard}
+	}
+	return l.quic.Serve(c, l.handleMessage)
+}
+
 func (l *TransportLayer) addListenPort(network string, port int) {
 	l.listenPortsMu.Lock()
 	defer l.listenPortsMu.Unlock()
@@ -187,6 +374,8 @@ func (l *TransportLayer) addListenPort(network string, port int) {
 
 func (l *TransportLayer) GetListenPort(network string) int {
 	network = NetworkToLower(network)
+	l.listenPortsMu.Lock()
+	defer l.listenPortsMu.Unlock()
 	ports, _ := l.listenPorts[network]
 	if len(ports) > 0 {
 		return ports[0]
@@ -220,7 +409,6 @@ func (l *TransportLayer) WriteMsgTo(msg Message, addr string, network string) er
 
 	switch m := msg.(type) {
 	// RFC 3261 - 18.1.1.
-	// 	TODO
 	// 	If a request is within 200 bytes of the path MTU, or if it is larger
 	//    than 1300 bytes and the path MTU is unknown, the request MUST be sent
 	//    using an RFC 2914 [43] congestion controlled transport protocol, such
@@ -239,10 +427,26 @@ func (l *TransportLayer) WriteMsgTo(msg Message, addr string, network string) er
 		defer conn.TryClose()
 
 	case *Response:
-
+		// Response destination is request source, which for connection oriented transports
+		// is remote address of connection request arrived on.
+		// This makes response to be sent over same connection
+		// https://datatracker.ietf.org/doc/html/rfc3261#section-18.2.2
 		conn, err = l.GetConnection(network, addr)
+		if err != nil && !IsReliable(network) && m.LocalAddr() != "" {
+			// Ex. multicast response is sent from listener request was received on
+			conn, err = l.GetConnection(network, m.LocalAddr())
+		}
 		if err != nil {
-			return err
+			if !IsReliable(network) {
+				return err
+			}
+
+			// Connection is gone, so we need to open new one based on Via
+			l.log.Debug().Err(err).Str("addr", addr).Msg("Response connection not found. Creating new")
+			conn, err = l.serverResponseConnection(context.Background(), network, m)
+			if err != nil {
+				return err
+			}
 		}
 
 		defer conn.TryClose()
@@ -276,6 +480,58 @@ func (l *TransportLayer) WriteMsgTo(msg Message, addr string, network string) er
 // In case req destination is DNS resolved, destination will be cached or in
 // other words SetDestination will be called
 func (l *TransportLayer) ClientRequestConnection(ctx context.Context, req *Request) (c Connection, err error) {
+	var viaHost string
+	if viaHop := req.Via(); viaHop != nil {
+		viaHost = viaHop.Host
+	}
+
+	c, err = l.clientRequestConnection(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Per listener advertised address has priority over local address
+	network := NetworkToLower(req.Transport())
+	l.applyAdvertisedVia(network, c, req.Via())
+
+	if network == "udp" && UDPTCPFallback && messageSize(req) > UDPMTUSize-200 {
+		return l.clientRequestConnectionTCPFallback(ctx, req, c, viaHost)
+	}
+	return c, nil
+}
+
+// clientRequestConnectionTCPFallback switches request from UDP to TCP as request is too large for UDP.
+// Via sent-by is rebuilt for TCP connection. If TCP connection can not be established
+// request is retried over UDP
+// https://datatracker.ietf.org/doc/html/rfc3261#section-18.1.1
+func (l *TransportLayer) clientRequestConnectionTCPFallback(ctx context.Context, req *Request, udpConn Connection, viaHost string) (Connection, error) {
+	l.log.Debug().Str("req", req.Method.String()).Msg("Request too large for UDP. Sending over TCP")
+	viaHop := req.Via()
+	udpVia := *viaHop
+	udpTransport := req.Transport()
+	viaHop.Transport = TransportTCP
+	viaHop.Host = viaHost
+	viaHop.Port = 0
+	req.SetTransport(TransportTCP)
+
+	c, err := l.clientRequestConnection(ctx, req)
+	if err != nil {
+		l.log.Info().Err(err).Str("req", req.Method.String()).Msg("TCP fallback for large request failed. Retrying over UDP")
+		viaHop.Transport = udpVia.Transport
+		viaHop.Host = udpVia.Host
+		viaHop.Port = udpVia.Port
+		req.SetTransport(udpTransport)
+		if uc, ok := udpConn.(*UDPConnection); ok {
+			return &udpLargeConnection{uc}, nil
+		}
+		return udpConn, nil
+	}
+	udpConn.TryClose()
+	l.applyAdvertisedVia("tcp", c, viaHop)
+	return c, nil
+}
+
+func (l *TransportLayer) clientRequestConnection(ctx context.Context, req *Request) (c Connection, err error) {
 	network := NetworkToLower(req.Transport())
 	transport, ok := l.transports[network]
 	if !ok {
@@ -303,6 +559,8 @@ func (l *TransportLayer) ClientRequestConnection(ctx context.Context, req *Reque
 		// Save destination in request to avoid repeated resolving
 		req.SetDestination(raddr.String())
 	}
+	dest := raddr.String()
+	raddr.IP = l.nat64(raddr.IP)
 
 	// Now use Via header to determine our local address
 	// Here is from RFC statement:
@@ -327,6 +585,11 @@ func (l *TransportLayer) ClientRequestConnection(ctx context.Context, req *Reque
 		addr := raddr.String()
 
 		c, _ := transport.GetConnection(addr)
+		if hc, ok := c.(interface{ isHalfClosed() bool }); ok && hc.isHalfClosed() {
+			// Peer will not respond over half-closed connection
+			c.TryClose()
+			c = nil
+		}
 		if c != nil {
 			// Update Via sent by
 			la := c.LocalAddr()
@@ -373,10 +636,30 @@ func (l *TransportLayer) ClientRequestConnection(ctx context.Context, req *Reque
 		l.log.Debug().Str("addr", addr).Str("raddr", raddr.String()).Msg("Active connection not found")
 	}
 
+	c, err = l.poolConnection(network, transport, raddr.String())
+	if err != nil {
+		return nil, err
+	}
+	if c != nil {
+		host, port, err := ParseAddr(c.LocalAddr().String())
+		if err != nil {
+			c.TryClose()
+			return nil, fmt.Errorf("fail to parse local connection address network=%s: %w", network, err)
+		}
+		if viaHop.Host == "" {
+			viaHop.Host = host
+		}
+		viaHop.Port = port
+		return c, nil
+	}
+
 	l.log.Debug().Str("host", viaHop.Host).Int("port", viaHop.Port).Str("network", network).Msg("Via header used for creating connection")
 
 	c, err = transport.CreateConnection(ctx, laddr, raddr, l.handleMessage)
 	if err != nil {
+		if l.Blacklist != nil && ctx.Err() == nil {
+			l.Blacklist.Failure(dest, err.Error())
+		}
 		return nil, err
 	}
 
@@ -407,6 +690,45 @@ func (l *TransportLayer) ClientRequestConnection(ctx context.Context, req *Reque
 	return c, nil
 }
 
+// serverResponseConnection creates new connection for response when original connection is closed
+// https://datatracker.ietf.org/doc/html/rfc3261#section-18.2.2
+// If connection is no longer open, server SHOULD open a connection to the IP address in the
+// "received" parameter, using the port in the "sent-by" value, or the default port for transport
+func (l *TransportLayer) serverResponseConnection(ctx context.Context, network string, res *Response) (Connection, error) {
+	network = NetworkToLower(network)
+	transport, ok := l.transports[network]
+	if !ok {
+		return nil, fmt.Errorf("transport %s is not supported", network)
+	}
+
+	viaHop := res.Via()
+	if viaHop == nil {
+		return nil, fmt.Errorf("missing Via Header")
+	}
+
+	host := viaHop.Host
+	if received, ok := viaHop.Params.Get("received"); ok && received != "" {
+		host = received
+	}
+
+	raddr := Addr{
+		IP:   net.ParseIP(host),
+		Port: viaHop.Port,
+	}
+	if raddr.Port == 0 {
+		raddr.Port = DefaultPort(network)
+	}
+
+	if raddr.IP == nil {
+		if err := l.resolveAddr(ctx, network, host, &raddr); err != nil {
+			return nil, err
+		}
+	}
+	raddr.IP = l.nat64(raddr.IP)
+
+	return transport.CreateConnection(ctx, Addr{}, raddr, l.handleMessage)
+}
+
 func (l *TransportLayer) resolveAddr(ctx context.Context, network string, host string, addr *Addr) error {
 	defer func(start time.Time) {
 		if dur := time.Since(start); dur > 50*time.Millisecond {
@@ -414,12 +736,47 @@ func (l *TransportLayer) resolveAddr(ctx context.Context, network string, host s
 		}
 	}(time.Now())
 
+	targets, err := l.resolveTargets(ctx, network, host, addr.Port)
+	if err != nil {
+		return err
+	}
+	*addr = targets[0]
+	return nil
+}
+
+// ResolveTargets returns ordered list of ip:port destinations for request, where next one
+// should be tried when previous fails with timeout or transport error
+// https://datatracker.ietf.org/doc/html/rfc3263#section-4.3
+func (l *TransportLayer) ResolveTargets(ctx context.Context, req *Request) ([]string, error) {
+	network := NetworkToLower(req.Transport())
+	dest := req.Destination()
+	host, port, err := ParseAddr(dest)
+	if err != nil {
+		return nil, fmt.Errorf("build address target for %s: %w", dest, err)
+	}
+	if net.ParseIP(host) != nil {
+		return []string{dest}, nil
+	}
+
+	targets, err := l.resolveTargets(ctx, network, host, port)
+	if err != nil {
+		return nil, err
+	}
+	dests := make([]string, len(targets))
+	for i, t := range targets {
+		dests[i] = t.String()
+	}
+	return dests, nil
+}
+
+// resolveTargets resolves host to addresses with preferred family first.
+// In case host has no address, targets of its SRV records are resolved in order of priority and weight
+func (l *TransportLayer) resolveTargets(ctx context.Context, network string, host string, port int) ([]Addr, error) {
 	l.log.Debug().Str("host", host).Msg("DNS Resolving")
 	// We need to try local resolving.
-	ip, err := net.ResolveIPAddr("ip", host)
-	if err == nil {
-		addr.IP = ip.IP
-		return nil
+	ips, err := l.dnsResolver.LookupIPAddr(ctx, host)
+	if err == nil && len(ips) > 0 {
+		return l.blacklistOrder(l.appendAddrs(nil, ips, port)), nil
 	}
 	log.Debug().Err(err).Msg("IP addr resolving failed, doing via dns resolver")
 
@@ -431,14 +788,46 @@ func (l *TransportLayer) resolveAddr(ctx context.Context, network string, host s
 		lookupnet = "tcp"
 	}
 
-	_, addrs, err := l.dnsResolver.LookupSRV(ctx, "sip", lookupnet, host)
+	_, records, err := l.dnsResolver.LookupSRV(ctx, "sip", lookupnet, host)
 	if err != nil {
-		return fmt.Errorf("fail to resolve target for %q: %w", host, err)
+		return nil, fmt.Errorf("fail to resolve target for %q: %w", host, err)
 	}
-	a := addrs[0]
-	addr.IP = net.ParseIP(a.Target[:len(a.Target)-1])
-	addr.Port = int(a.Port)
-	return nil
+
+	var targets []Addr
+	for _, a := range records {
+		target := strings.TrimSuffix(a.Target, ".")
+		if ip := net.ParseIP(target); ip != nil {
+			targets = append(targets, Addr{IP: ip, Port: int(a.Port)})
+			continue
+		}
+
+		ips, err = l.dnsResolver.LookupIPAddr(ctx, target)
+		if err != nil {
+			err = fmt.Errorf("fail to resolve SRV target %q: %w", target, err)
+			l.log.Debug().Err(err).Msg("Skipping SRV target")
+			continue
+		}
+		targets = l.appendAddrs(targets, ips, int(a.Port))
+	}
+	if len(targets) == 0 {
+		if err == nil {
+			err = fmt.Errorf("no address for SRV targets of %q", host)
+		}
+		return nil, err
+	}
+	return l.blacklistOrder(targets), nil
+}
+
+// appendAddrs appends addresses with preferred family first
+func (l *TransportLayer) appendAddrs(addrs []Addr, ips []net.IPAddr, port int) []Addr {
+	for _, preferred := range []bool{true, false} {
+		for _, ip := range ips {
+			if ((ip.IP.To4() == nil) == l.PreferIPv6) == preferred {
+				addrs = append(addrs, Addr{IP: ip.IP, Port: port})
+			}
+		}
+	}
+	return addrs
 }
 
 // GetConnection gets existing or creates new connection based on addr
@@ -462,8 +851,22 @@ func (l *TransportLayer) getConnection(network, addr string) (Connection, error)
 	return c, err
 }
 
+// ConnectionsLen returns number of connections in transport pools.
+// For UDP listener every remote address is counted
+func (l *TransportLayer) ConnectionsLen() int {
+	n := l.udp.pool.Size() + l.tcp.pool.Size() + l.tls.pool.Size() + l.ws.pool.Size() + l.wss.pool.Size()
+	if l.quic != nil {
+		n += l.quic.pool.Size()
+	}
+	return n
+}
+
 func (l *TransportLayer) Close() error {
 	l.log.Debug().Msg("Layer is closing")
+	if l.poolDone != nil {
+		close(l.poolDone)
+		l.poolDone = nil
+	}
 	var werr error
 	for _, t := range l.transports {
 		if err := t.Close(); err != nil {
@@ -483,6 +886,17 @@ func IsReliable(network string) bool {
 	}
 }
 
+// IsSecure reports is network TLS protected, as required for SIPS
+// https://datatracker.ietf.org/doc/html/rfc5630#section-3.1.3
+func IsSecure(network string) bool {
+	switch network {
+	case "tls", "TLS", "wss", "WSS":
+		return true
+	default:
+		return false
+	}
+}
+
 // NetworkToLower is faster function converting UDP, TCP to udp, tcp
 func NetworkToLower(network string) string {
 	// Switch is faster then lower
@@ -0,0 +1,94 @@
+package sip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// NAT64WellKnownPrefix is 64:ff9b::/96 https://datatracker.ietf.org/doc/html/rfc6052#section-2.1
+var NAT64WellKnownPrefix = &net.IPNet{IP: net.ParseIP("64:ff9b::"), Mask: net.CIDRMask(96, 128)}
+
+// nat64DiscoveryIPs are well known IPv4 addresses of ipv4only.arpa https://datatracker.ietf.org/doc/html/rfc7050#section-2.2
+var nat64DiscoveryIPs = []net.IP{net.IPv4(192, 0, 0, 170).To4(), net.IPv4(192, 0, 0, 171).To4()}
+
+// NAT64Synthesize embeds IPv4 address in NAT64 prefix of length 32, 40, 48, 56, 64 or 96.
+// Ex: 64:ff9b::/96 and 192.0.2.33 -> 64:ff9b::c000:221
+// https://datatracker.ietf.org/doc/html/rfc6052#section-2.2
+func NAT64Synthesize(prefix *net.IPNet, ip net.IP) (net.IP, error) {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return nil, fmt.Errorf("not IPv4 address ip=%s", ip)
+	}
+	ones, bits := prefix.Mask.Size()
+	if bits != 128 || !nat64PrefixLen(ones) {
+		return nil, fmt.Errorf("invalid NAT64 prefix %s", prefix)
+	}
+
+	res := make(net.IP, net.IPv6len)
+	copy(res, prefix.IP.To16()[:ones/8])
+	pos := ones / 8
+	for _, b := range ip4 {
+		// Bits 64 to 71 (u octet) must be zero
+		if pos == 8 {
+			pos++
+		}
+		res[pos] = b
+		pos++
+	}
+	return res, nil
+}
+
+// DiscoverNAT64Prefix discovers NAT64 prefix from AAAA records of ipv4only.arpa synthesized by DNS64 resolver.
+// It returns error in case network has no DNS64
+// https://datatracker.ietf.org/doc/html/rfc7050
+func DiscoverNAT64Prefix(ctx context.Context, r *net.Resolver) (*net.IPNet, error) {
+	ips, err := r.LookupIP(ctx, "ip6", "ipv4only.arpa")
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		if prefix := nat64PrefixOf(ip); prefix != nil {
+			return prefix, nil
+		}
+	}
+	return nil, errors.New("no NAT64 prefix found")
+}
+
+// nat64PrefixOf returns prefix of address synthesized from well known ipv4only.arpa address
+func nat64PrefixOf(ip net.IP) *net.IPNet {
+	if ip.To4() != nil {
+		return nil
+	}
+	for _, ones := range []int{96, 64, 56, 48, 40, 32} {
+		prefix := &net.IPNet{IP: ip.Mask(net.CIDRMask(ones, 128)), Mask: net.CIDRMask(ones, 128)}
+		for _, known := range nat64DiscoveryIPs {
+			if synth, _ := NAT64Synthesize(prefix, known); synth.Equal(ip) {
+				return prefix
+			}
+		}
+	}
+	return nil
+}
+
+func nat64PrefixLen(ones int) bool {
+	switch ones {
+	case 32, 40, 48, 56, 64, 96:
+		return true
+	}
+	return false
+}
+
+// nat64 returns IPv4 address synthesized in NAT64 prefix if prefix is set
+func (l *TransportLayer) nat64(ip net.IP) net.IP {
+	if l.NAT64Prefix == nil || ip == nil || ip.To4() == nil {
+		return ip
+	}
+	synth, err := NAT64Synthesize(l.NAT64Prefix, ip)
+	if err != nil {
+		l.log.Error().Err(err).Msg("Failed to synthesize NAT64 address")
+		return ip
+	}
+	return synth
+}
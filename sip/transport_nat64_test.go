@@ -0,0 +1,59 @@
+package sip
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNAT64Synthesize(t *testing.T) {
+	ip4 := net.ParseIP("192.0.2.33")
+	// Examples from https://datatracker.ietf.org/doc/html/rfc6052#section-2.4
+	for prefix, expected := range map[string]string{
+		"2001:db8::/32":         "2001:db8:c000:221::",
+		"2001:db8:100::/40":     "2001:db8:1c0:2:21::",
+		"2001:db8:122::/48":     "2001:db8:122:c000:2:2100::",
+		"2001:db8:122:300::/56": "2001:db8:122:3c0:0:221::",
+		"2001:db8:122:344::/64": "2001:db8:122:344:c0:2:2100:0",
+		"64:ff9b::/96":          "64:ff9b::c000:221",
+	} {
+		_, p, err := net.ParseCIDR(prefix)
+		require.NoError(t, err)
+		ip, err := NAT64Synthesize(p, ip4)
+		require.NoError(t, err)
+		assert.Equal(t, expected, ip.String(), prefix)
+		assert.Equal(t, p.String(), nat64PrefixOf(mustSynthesize(t, p, net.ParseIP("192.0.0.170"))).String())
+	}
+
+	_, err := NAT64Synthesize(NAT64WellKnownPrefix, net.ParseIP("::1"))
+	require.Error(t, err)
+	_, p, _ := net.ParseCIDR("2001:db8::/50")
+	_, err = NAT64Synthesize(p, ip4)
+	require.Error(t, err)
+	assert.Nil(t, nat64PrefixOf(net.ParseIP("2001:db8::1")))
+}
+
+func mustSynthesize(t *testing.T, prefix *net.IPNet, ip net.IP) net.IP {
+	synth, err := NAT64Synthesize(prefix, ip)
+	require.NoError(t, err)
+	return synth
+}
+
+func TestTransportLayerIPFamily(t *testing.T) {
+	tp := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+	defer tp.Close()
+
+	ips := []net.IPAddr{{IP: net.ParseIP("192.0.2.1")}, {IP: net.ParseIP("2001:db8::1")}}
+	assert.Equal(t, "192.0.2.1", tp.appendAddrs(nil, ips, 5060)[0].IP.String())
+	tp.PreferIPv6 = true
+	assert.Equal(t, "2001:db8::1", tp.appendAddrs(nil, ips, 5060)[0].IP.String())
+	// No AAAA, only A is used
+	assert.Equal(t, "192.0.2.1", tp.appendAddrs(nil, ips[:1], 5060)[0].IP.String())
+
+	assert.Equal(t, "192.0.2.1", tp.nat64(net.ParseIP("192.0.2.1")).String())
+	tp.NAT64Prefix = NAT64WellKnownPrefix
+	assert.Equal(t, "64:ff9b::c000:201", tp.nat64(net.ParseIP("192.0.2.1")).String())
+	assert.Equal(t, "2001:db8::1", tp.nat64(net.ParseIP("2001:db8::1")).String())
+}
@@ -0,0 +1,185 @@
+package sip
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// parseGuardMaxSources limits tracked sources before expired entries are swept
+var parseGuardMaxSources = 10000
+
+// ParseErrorGuard counts parse failures per source IP. Source with Threshold failures
+// within Window is blocked for BlockDuration. Packets and connections from blocked source
+// are dropped at transport layer before parsing
+type ParseErrorGuard struct {
+	threshold int
+	window    time.Duration
+	block     time.Duration
+
+	// OnBlock is security event called when source gets blocked with number of parse errors.
+	// It is called from transport read loop, so avoid blocking
+	OnBlock func(ip net.IP, errors int)
+
+	mu      sync.Mutex
+	sources map[string]*parseErrorSource
+}
+
+type parseErrorSource struct {
+	errors       int
+	windowStart  time.Time
+	blockedUntil time.Time
+}
+
+// NewParseErrorGuard creates guard blocking source for block duration
+// after threshold parse errors within window
+func NewParseErrorGuard(threshold int, window time.Duration, block time.Duration) *ParseErrorGuard {
+	return &ParseErrorGuard{
+		threshold: threshold,
+		window:    window,
+		block:     block,
+		sources:   make(map[string]*parseErrorSource),
+	}
+}
+
+// ParseError records parse failure from ip. It returns true if source is blocked
+func (g *ParseErrorGuard) ParseError(ip net.IP) bool {
+	now := time.Now()
+	key := ip.String()
+
+	g.mu.Lock()
+	s, exists := g.sources[key]
+	if !exists {
+		if len(g.sources) >= parseGuardMaxSources {
+			g.sweep(now)
+		}
+		s = &parseErrorSource{windowStart: now}
+		g.sources[key] = s
+	}
+
+	if now.Before(s.blockedUntil) {
+		g.mu.Unlock()
+		return true
+	}
+
+	if now.Sub(s.windowStart) > g.window {
+		s.errors = 0
+		s.windowStart = now
+	}
+	s.errors++
+
+	if s.errors < g.threshold {
+		g.mu.Unlock()
+		return false
+	}
+
+	errs := s.errors
+	s.errors = 0
+	s.blockedUntil = now.Add(g.block)
+	g.mu.Unlock()
+
+	log.Warn().Str("ip", key).Int("errors", errs).Dur("duration", g.block).Msg("Source blocked due to parse errors")
+	if g.OnBlock != nil {
+		g.OnBlock(ip, errs)
+	}
+	return true
+}
+
+// Blocked checks is ip currently blocked
+func (g *ParseErrorGuard) Blocked(ip net.IP) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s, exists := g.sources[ip.String()]
+	if !exists {
+		return false
+	}
+	return time.Now().Before(s.blockedUntil)
+}
+
+// Unblock removes ip from guard and resets its parse error count
+func (g *ParseErrorGuard) Unblock(ip net.IP) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.sources, ip.String())
+}
+
+// sweep removes sources which are not blocked and have expired window. Lock must be held
+func (g *ParseErrorGuard) sweep(now time.Time) {
+	for k, s := range g.sources {
+		if now.Before(s.blockedUntil) || now.Sub(s.windowStart) <= g.window {
+			continue
+		}
+		delete(g.sources, k)
+	}
+}
+
+func parseGuardNetAddrIP(addr net.Addr) net.IP {
+	switch v := addr.(type) {
+	case *net.UDPAddr:
+		return v.IP
+	case *net.TCPAddr:
+		return v.IP
+	}
+	return parseGuardAddrIP(addr.String())
+}
+
+func parseGuardAddrIP(addr string) net.IP {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return net.ParseIP(host)
+}
+
+// parseGuardListener closes accepted connections from blocked sources before any read happens
+type parseGuardListener struct {
+	net.Listener
+	guard *ParseErrorGuard
+}
+
+func (l *parseGuardListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		if ip := parseGuardNetAddrIP(conn.RemoteAddr()); ip == nil || !l.guard.Blocked(ip) {
+			return conn, nil
+		}
+		conn.Close()
+	}
+}
+
+// parseGuardPacketConn drops packets from blocked sources before parsing
+type parseGuardPacketConn struct {
+	net.PacketConn
+	guard *ParseErrorGuard
+}
+
+func (c *parseGuardPacketConn) ReadFrom(b []byte) (n int, addr net.Addr, err error) {
+	for {
+		n, addr, err = c.PacketConn.ReadFrom(b)
+		if err != nil {
+			return n, addr, err
+		}
+
+		if ip := parseGuardNetAddrIP(addr); ip == nil || !c.guard.Blocked(ip) {
+			return n, addr, err
+		}
+	}
+}
+
+// handleParseError records parse failure on guard. It returns true if source is blocked
+func (l *TransportLayer) handleParseError(src string) bool {
+	if l.ParseGuard == nil {
+		return false
+	}
+	ip := parseGuardAddrIP(src)
+	if ip == nil {
+		return false
+	}
+	return l.ParseGuard.ParseError(ip)
+}
@@ -0,0 +1,89 @@
+package sip
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseErrorGuard(t *testing.T) {
+	g := NewParseErrorGuard(3, time.Minute, 50*time.Millisecond)
+	var blockedIP net.IP
+	g.OnBlock = func(ip net.IP, errors int) {
+		blockedIP = ip
+		assert.Equal(t, 3, errors)
+	}
+
+	ip := net.ParseIP("10.0.0.1")
+	assert.False(t, g.ParseError(ip))
+	assert.False(t, g.ParseError(ip))
+	assert.False(t, g.Blocked(ip))
+	assert.True(t, g.ParseError(ip))
+	assert.True(t, g.Blocked(ip))
+	assert.True(t, blockedIP.Equal(ip))
+	assert.False(t, g.Blocked(net.ParseIP("10.0.0.2")))
+
+	// Block expires
+	time.Sleep(60 * time.Millisecond)
+	assert.False(t, g.Blocked(ip))
+	assert.False(t, g.ParseError(ip))
+
+	g.ParseError(ip)
+	g.ParseError(ip)
+	require.True(t, g.Blocked(ip))
+	g.Unblock(ip)
+	assert.False(t, g.Blocked(ip))
+}
+
+func TestParseErrorGuardWindow(t *testing.T) {
+	g := NewParseErrorGuard(2, 20*time.Millisecond, time.Minute)
+	ip := net.ParseIP("10.0.0.1")
+
+	assert.False(t, g.ParseError(ip))
+	time.Sleep(30 * time.Millisecond)
+	// Window expired, count restarts
+	assert.False(t, g.ParseError(ip))
+	assert.True(t, g.ParseError(ip))
+}
+
+func TestTransportLayerParseGuardUDP(t *testing.T) {
+	tp := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+	tp.ParseGuard = NewParseErrorGuard(2, time.Minute, time.Minute)
+
+	msgs := make(chan Message, 10)
+	tp.OnMessage(func(msg Message) {
+		msgs <- msg
+	})
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go tp.ServeUDP(conn)
+	defer tp.Close()
+
+	client, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer client.Close()
+
+	garbage := []byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	for i := 0; i < 2; i++ {
+		_, err = client.WriteTo(garbage, conn.LocalAddr())
+		require.NoError(t, err)
+	}
+
+	ip := client.LocalAddr().(*net.UDPAddr).IP
+	require.Eventually(t, func() bool { return tp.ParseGuard.Blocked(ip) }, time.Second, 10*time.Millisecond)
+
+	// Valid request from blocked source is dropped before parsing
+	req, _, _ := testCreateInvite(t, "sip:bob@127.0.0.1", "udp", client.LocalAddr().String())
+	_, err = client.WriteTo([]byte(req.String()), conn.LocalAddr())
+	require.NoError(t, err)
+
+	select {
+	case msg := <-msgs:
+		t.Fatalf("message from blocked source received: %s", msg.String())
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,451 @@
+package sip
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// SIP over QUIC is experimental and not standardized, so it only interoperates with peers using same framing.
+// QUIC library is not dependency of this package. It is plugged with small adapter implementing
+// QUICListener, QUICDialer, QUICConn and QUICStream, ex. over quic-go Listener, Connection and Stream.
+
+var (
+	// QUICWriteTimeout limits opening stream and writing message on QUIC connection. 0 disables it
+	QUICWriteTimeout = 10 * time.Second
+
+	ErrQUICStreamNotReady = errors.New("quic stream is not yet opened by peer")
+)
+
+// QUICFraming is how SIP messages are carried on QUIC connection
+type QUICFraming int
+
+const (
+	// QUICFramingStreamPerMessage sends every message on new stream, which is closed after message is written.
+	// Lost packet delays only its own message, avoiding TCP head-of-line blocking
+	QUICFramingStreamPerMessage QUICFraming = iota
+	// QUICFramingLengthPrefixed sends all messages on single stream opened by dialing side.
+	// Every message is prefixed with its length as 4 byte big endian
+	QUICFramingLengthPrefixed
+)
+
+func (f QUICFraming) String() string {
+	switch f {
+	case QUICFramingStreamPerMessage:
+		return "stream-per-message"
+	case QUICFramingLengthPrefixed:
+		return "length-prefixed"
+	}
+	return ""
+}
+
+// QUICStream is bidirectional QUIC stream
+type QUICStream interface {
+	io.Reader
+	io.Writer
+	// Close closes write direction of stream
+	Close() error
+}
+
+// QUICConn is established QUIC connection
+type QUICConn interface {
+	OpenStream(ctx context.Context) (QUICStream, error)
+	AcceptStream(ctx context.Context) (QUICStream, error)
+	LocalAddr() net.Addr
+	RemoteAddr() net.Addr
+	// Close closes connection and all its streams
+	Close() error
+}
+
+// QUICListener accepts QUIC connections
+type QUICListener interface {
+	Accept(ctx context.Context) (QUICConn, error)
+	Addr() net.Addr
+	Close() error
+}
+
+// QUICDialer creates outbound QUIC connections to resolved address IP:port
+type QUICDialer interface {
+	DialQUIC(ctx context.Context, addr string) (QUICConn, error)
+}
+
+// QUIC transport implementation
+type transportQUIC struct {
+	parser  *Parser
+	log     zerolog.Logger
+	framing QUICFraming
+
+	pool ConnectionPool
+
+	connStateHandler ConnectionStateHandler
+	parseErrHandler  func(src string) bool
+	// dialer is used for outbound connections. Without it transport only serves
+	dialer QUICDialer
+}
+
+func newQUICTransport(par *Parser, dialer QUICDialer, framing QUICFraming) *transportQUIC {
+	p := &transportQUIC{
+		parser:  par,
+		pool:    NewConnectionPool(),
+		dialer:  dialer,
+		framing: framing,
+	}
+	p.log = log.Logger.With().Str("caller", "transport<QUIC>").Logger()
+	return p
+}
+
+func (t *transportQUIC) String() string {
+	return "transport<QUIC>"
+}
+
+func (t *transportQUIC) Network() string {
+	return TransportQUIC
+}
+
+func (t *transportQUIC) Close() error {
+	t.pool.Clear()
+	return nil
+}
+
+// Serve accepts connections on listener until it is closed
+func (t *transportQUIC) Serve(l QUICListener, handler MessageHandler) error {
+	t.log.Debug().Msgf("begin listening on %s %s framing=%s", t.Network(), l.Addr().String(), t.framing)
+	for {
+		conn, err := l.Accept(context.Background())
+		if err != nil {
+			t.log.Debug().Err(err).Msg("Fail to accept connection")
+			return err
+		}
+
+		t.initConnection(conn, nil, conn.RemoteAddr().String(), handler)
+	}
+}
+
+func (t *transportQUIC) GetConnection(addr string) (Connection, error) {
+	c := t.pool.Get(addr)
+	return c, nil
+}
+
+func (t *transportQUIC) CreateConnection(ctx context.Context, laddr Addr, raddr Addr, handler MessageHandler) (Connection, error) {
+	if t.dialer == nil {
+		return nil, fmt.Errorf("%s dialer is not set", t)
+	}
+
+	addr := raddr.String()
+	t.log.Debug().Str("raddr", addr).Msg("Dialing new connection")
+
+	conn, err := t.dialer.DialQUIC(ctx, addr)
+	if err != nil {
+		return nil, fmt.Errorf("%s dial err=%w", t, err)
+	}
+
+	var stream QUICStream
+	if t.framing == QUICFramingLengthPrefixed {
+		stream, err = conn.OpenStream(ctx)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s open stream err=%w", t, err)
+		}
+	}
+
+	c := t.initConnection(conn, stream, addr, handler)
+
+	// Increase ref by 1 before returning
+	c.Ref(1)
+	return c, nil
+}
+
+func (t *transportQUIC) initConnection(conn QUICConn, stream QUICStream, addr string, handler MessageHandler) *QUICConnection {
+	t.log.Debug().Str("raddr", addr).Msg("New connection")
+	c := &QUICConnection{
+		conn:     conn,
+		framing:  t.framing,
+		stream:   stream,
+		refcount: 1 + IdleConnection,
+	}
+	t.pool.Add(addr, c)
+
+	info := &ConnectionInfo{
+		Network:    TransportQUIC,
+		LocalAddr:  conn.LocalAddr(),
+		RemoteAddr: conn.RemoteAddr(),
+	}
+	if t.connStateHandler != nil {
+		t.connStateHandler(info, ConnectionStateOpen)
+	}
+	go t.readConnection(c, addr, info, handler)
+	return c
+}
+
+func (t *transportQUIC) readConnection(c *QUICConnection, raddr string, info *ConnectionInfo, handler MessageHandler) {
+	if t.connStateHandler != nil {
+		defer t.connStateHandler(info, ConnectionStateClosed)
+	}
+	defer t.pool.CloseAndDelete(c, raddr)
+
+	if t.framing == QUICFramingLengthPrefixed {
+		stream := c.stream
+		if stream == nil {
+			var err error
+			stream, err = c.conn.AcceptStream(context.Background())
+			if err != nil {
+				t.log.Debug().Err(err).Str("raddr", raddr).Msg("Connection closed before stream opened")
+				return
+			}
+			c.setStream(stream)
+		}
+		t.readLengthPrefixed(c, stream, raddr, info, handler)
+		return
+	}
+
+	for {
+		stream, err := c.conn.AcceptStream(context.Background())
+		if err != nil {
+			t.log.Debug().Err(err).Str("raddr", raddr).Msg("Connection closed")
+			return
+		}
+		go t.readStream(c, stream, raddr, info, handler)
+	}
+}
+
+// readStream reads single message sent on stream
+func (t *transportQUIC) readStream(c *QUICConnection, stream QUICStream, raddr string, info *ConnectionInfo, handler MessageHandler) {
+	defer stream.Close()
+
+	data, err := io.ReadAll(io.LimitReader(stream, int64(transportBufferSize)+1))
+	if err != nil {
+		t.log.Debug().Err(err).Str("raddr", raddr).Msg("Stream read error")
+		return
+	}
+	if len(data) > int(transportBufferSize) {
+		t.log.Error().Str("raddr", raddr).Msg("Message on stream too large")
+		t.parseFailed(c, raddr)
+		return
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return
+	}
+	t.parseFull(c, data, raddr, info, handler)
+}
+
+func (t *transportQUIC) readLengthPrefixed(c *QUICConnection, stream QUICStream, raddr string, info *ConnectionInfo, handler MessageHandler) {
+	var prefix [4]byte
+	buf := make([]byte, transportBufferSize)
+	for {
+		if _, err := io.ReadFull(stream, prefix[:]); err != nil {
+			t.log.Debug().Err(err).Str("raddr", raddr).Msg("Stream closed")
+			return
+		}
+
+		size := binary.BigEndian.Uint32(prefix[:])
+		if size > uint32(transportBufferSize) {
+			t.log.Error().Str("raddr", raddr).Uint32("size", size).Msg("Message length too large")
+			return
+		}
+		if size == 0 {
+			// Empty message is keep alive
+			continue
+		}
+
+		data := buf[:size]
+		if _, err := io.ReadFull(stream, data); err != nil {
+			t.log.Debug().Err(err).Str("raddr", raddr).Msg("Stream closed")
+			return
+		}
+		t.parseFull(c, data, raddr, info, handler)
+	}
+}
+
+func (t *transportQUIC) parseFull(c *QUICConnection, data []byte, src string, info *ConnectionInfo, handler MessageHandler) {
+	if SIPDebug {
+		log.Debug().Msgf("QUIC read %s <- %s:\n%s", info.LocalAddr.String(), src, string(data))
+	}
+
+	msg, err := t.parser.ParseSIP(data)
+	if err != nil {
+		t.log.Error().Err(err).Str("data", string(data)).Msg("failed to parse")
+		t.parseFailed(c, src)
+		return
+	}
+
+	msg.SetTransport(t.Network())
+	msg.SetReceivedTransport(t.Network())
+	msg.SetSource(src)
+	msg.SetLocalAddr(info.LocalAddr.String())
+	if req, ok := msg.(*Request); ok {
+		req.connInfo = info
+	}
+	handler(msg)
+}
+
+func (t *transportQUIC) parseFailed(c *QUICConnection, src string) {
+	if t.parseErrHandler != nil && t.parseErrHandler(src) {
+		c.Close()
+	}
+}
+
+// filterQUICListener closes accepted connections not allowed by ACL or blocked by parse guard
+type filterQUICListener struct {
+	QUICListener
+	acl   *ACL
+	guard *ParseErrorGuard
+}
+
+func (l *filterQUICListener) Accept(ctx context.Context) (QUICConn, error) {
+	for {
+		conn, err := l.QUICListener.Accept(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		raddr := conn.RemoteAddr()
+		allowed := l.acl == nil || aclAllowedNetAddr(l.acl, raddr)
+		if allowed && l.guard != nil {
+			ip := parseGuardNetAddrIP(raddr)
+			allowed = ip == nil || !l.guard.Blocked(ip)
+		}
+		if allowed {
+			return conn, nil
+		}
+		conn.Close()
+	}
+}
+
+type QUICConnection struct {
+	conn    QUICConn
+	framing QUICFraming
+
+	mu       sync.Mutex
+	refcount int
+	// stream is single stream for length prefixed framing
+	stream QUICStream
+	// writeMu serializes writes on single stream
+	writeMu sync.Mutex
+}
+
+func (c *QUICConnection) setStream(s QUICStream) {
+	c.mu.Lock()
+	c.stream = s
+	c.mu.Unlock()
+}
+
+func (c *QUICConnection) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+func (c *QUICConnection) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+func (c *QUICConnection) Ref(i int) int {
+	c.mu.Lock()
+	c.refcount += i
+	ref := c.refcount
+	c.mu.Unlock()
+	log.Debug().Str("ip", c.LocalAddr().String()).Str("dst", c.RemoteAddr().String()).Int("ref", ref).Msg("QUIC reference increment")
+	return ref
+}
+
+func (c *QUICConnection) Close() error {
+	c.mu.Lock()
+	c.refcount = 0
+	c.mu.Unlock()
+	log.Debug().Str("ip", c.LocalAddr().String()).Str("dst", c.RemoteAddr().String()).Int("ref", 0).Msg("QUIC doing hard close")
+	return c.conn.Close()
+}
+
+func (c *QUICConnection) TryClose() (int, error) {
+	c.mu.Lock()
+	c.refcount--
+	ref := c.refcount
+	c.mu.Unlock()
+	log.Debug().Str("ip", c.LocalAddr().String()).Str("dst", c.RemoteAddr().String()).Int("ref", ref).Msg("QUIC reference decrement")
+	if ref > 0 {
+		return ref, nil
+	}
+
+	if ref < 0 {
+		log.Warn().Str("ip", c.LocalAddr().String()).Str("dst", c.RemoteAddr().String()).Int("ref", ref).Msg("QUIC ref went negative")
+		return 0, nil
+	}
+
+	log.Debug().Str("ip", c.LocalAddr().String()).Str("dst", c.RemoteAddr().String()).Int("ref", ref).Msg("QUIC closing")
+	return ref, c.conn.Close()
+}
+
+func (c *QUICConnection) WriteMsg(msg Message) error {
+	buf := bufPool.Get().(*bytes.Buffer)
+	defer bufPool.Put(buf)
+	buf.Reset()
+
+	if c.framing == QUICFramingLengthPrefixed {
+		// Reserve length prefix
+		buf.Write([]byte{0, 0, 0, 0})
+		msg.StringWrite(buf)
+		data := buf.Bytes()
+		binary.BigEndian.PutUint32(data, uint32(len(data)-4))
+		return c.writeLengthPrefixed(data)
+	}
+
+	msg.StringWrite(buf)
+	return c.writeStream(buf.Bytes())
+}
+
+func (c *QUICConnection) writeStream(data []byte) error {
+	ctx := context.Background()
+	if QUICWriteTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, QUICWriteTimeout)
+		defer cancel()
+	}
+
+	stream, err := c.conn.OpenStream(ctx)
+	if err != nil {
+		return fmt.Errorf("conn %s open stream err=%w", c.RemoteAddr().String(), err)
+	}
+	if err := c.write(stream, data); err != nil {
+		return err
+	}
+	return stream.Close()
+}
+
+func (c *QUICConnection) writeLengthPrefixed(data []byte) error {
+	c.mu.Lock()
+	stream := c.stream
+	c.mu.Unlock()
+	if stream == nil {
+		return fmt.Errorf("conn %s: %w", c.RemoteAddr().String(), ErrQUICStreamNotReady)
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.write(stream, data); err != nil {
+		// Broken stream can not be resynced
+		c.conn.Close()
+		return err
+	}
+	return nil
+}
+
+func (c *QUICConnection) write(stream QUICStream, data []byte) error {
+	n, err := stream.Write(data)
+	if err != nil {
+		return fmt.Errorf("conn %s write err=%w", c.RemoteAddr().String(), err)
+	}
+	if n != len(data) {
+		return fmt.Errorf("fail to write full message")
+	}
+	if SIPDebug {
+		log.Debug().Msgf("QUIC write %s -> %s:\n%s", c.LocalAddr().String(), c.RemoteAddr().String(), string(data))
+	}
+	return nil
+}
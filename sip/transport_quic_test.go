@@ -0,0 +1,156 @@
+package sip
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memQUICConn is in memory QUIC connection, where every stream is net.Pipe
+type memQUICConn struct {
+	laddr, raddr net.Addr
+	peer         *memQUICConn
+	streams      chan QUICStream
+	closed       chan struct{}
+	closeOnce    atomic.Bool
+}
+
+func newMemQUICPair(laddr, raddr net.Addr) (*memQUICConn, *memQUICConn) {
+	a := &memQUICConn{laddr: laddr, raddr: raddr, streams: make(chan QUICStream, 10), closed: make(chan struct{})}
+	b := &memQUICConn{laddr: raddr, raddr: laddr, streams: make(chan QUICStream, 10), closed: make(chan struct{})}
+	a.peer, b.peer = b, a
+	return a, b
+}
+
+func (c *memQUICConn) OpenStream(ctx context.Context) (QUICStream, error) {
+	local, remote := net.Pipe()
+	select {
+	case c.peer.streams <- remote:
+		return local, nil
+	case <-c.closed:
+		return nil, net.ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *memQUICConn) AcceptStream(ctx context.Context) (QUICStream, error) {
+	select {
+	case s := <-c.streams:
+		return s, nil
+	case <-c.closed:
+		return nil, net.ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *memQUICConn) LocalAddr() net.Addr  { return c.laddr }
+func (c *memQUICConn) RemoteAddr() net.Addr { return c.raddr }
+
+func (c *memQUICConn) Close() error {
+	if c.closeOnce.CompareAndSwap(false, true) {
+		close(c.closed)
+		c.peer.Close()
+	}
+	return nil
+}
+
+type memQUICListener struct {
+	addr  *net.UDPAddr
+	conns chan QUICConn
+	port  atomic.Int32
+}
+
+func (l *memQUICListener) Accept(ctx context.Context) (QUICConn, error) {
+	c, ok := <-l.conns
+	if !ok {
+		return nil, net.ErrClosed
+	}
+	return c, nil
+}
+
+func (l *memQUICListener) Addr() net.Addr { return l.addr }
+
+func (l *memQUICListener) Close() error {
+	close(l.conns)
+	return nil
+}
+
+func (l *memQUICListener) DialQUIC(ctx context.Context, addr string) (QUICConn, error) {
+	if addr != l.addr.String() {
+		return nil, net.ErrClosed
+	}
+	laddr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 40000 + int(l.port.Add(1))}
+	client, server := newMemQUICPair(laddr, l.addr)
+	l.conns <- server
+	return client, nil
+}
+
+func TestTransportLayerQUIC(t *testing.T) {
+	for _, framing := range []QUICFraming{QUICFramingStreamPerMessage, QUICFramingLengthPrefixed} {
+		t.Run(framing.String(), func(t *testing.T) {
+			ln := &memQUICListener{addr: &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5090}, conns: make(chan QUICConn, 1)}
+
+			srv := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+			defer srv.Close()
+			require.ErrorIs(t, srv.ServeQUIC(ln), ErrTransportNotSuported)
+			srv.EnableQUIC(nil, framing)
+			srvMsgs := make(chan Message, 10)
+			srv.OnMessage(func(msg Message) { srvMsgs <- msg })
+			go srv.ServeQUIC(ln)
+			defer ln.Close()
+
+			cli := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+			defer cli.Close()
+			cli.EnableQUIC(ln, framing)
+			cliMsgs := make(chan Message, 10)
+			cli.OnMessage(func(msg Message) { cliMsgs <- msg })
+
+			for i := 0; i < 3; i++ {
+				via := NewParams()
+				via.Add("branch", GenerateBranch())
+				from := NewParams()
+				from.Add("tag", "abc")
+				req := NewRequest(OPTIONS, &Uri{Host: "127.0.0.1", Port: 5090})
+				req.AppendHeader(&ViaHeader{ProtocolName: "SIP", ProtocolVersion: "2.0", Transport: TransportQUIC, Host: "127.0.0.1", Params: via})
+				req.AppendHeader(&FromHeader{Address: Uri{User: "alice", Host: "127.0.0.1"}, Params: from})
+				req.AppendHeader(&ToHeader{Address: Uri{User: "bob", Host: "127.0.0.1"}, Params: NewParams()})
+				callid := CallIDHeader("quic-test")
+				req.AppendHeader(&callid)
+				req.AppendHeader(&CSeqHeader{SeqNo: uint32(i + 1), MethodName: OPTIONS})
+				req.SetTransport(TransportQUIC)
+				require.NoError(t, cli.WriteMsg(req))
+
+				var recv *Request
+				select {
+				case msg := <-srvMsgs:
+					recv = msg.(*Request)
+				case <-time.After(time.Second):
+					t.Fatal("request not received")
+				}
+				assert.Equal(t, TransportQUIC, recv.Transport())
+				assert.Equal(t, uint32(i+1), recv.CSeq().SeqNo)
+				require.NotNil(t, recv.ConnectionInfo())
+				assert.Equal(t, TransportQUIC, recv.ConnectionInfo().Network)
+
+				// Response goes back over same connection
+				res := NewResponseFromRequest(recv, 200, "OK", nil)
+				require.NoError(t, srv.WriteMsg(res))
+				select {
+				case msg := <-cliMsgs:
+					assert.Equal(t, StatusOK, msg.(*Response).StatusCode)
+				case <-time.After(time.Second):
+					t.Fatal("response not received")
+				}
+			}
+			assert.Equal(t, 1, cli.quic.pool.Size())
+			assert.Equal(t, 1, srv.ConnectionsLen())
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package sip
+
+import (
+	"net"
+	"syscall"
+	"time"
+)
+
+// DSCP values commonly used for SIP signaling. TOS is DSCP shifted by 2, ex. SocketOptions{TOS: DSCPCS3 << 2}
+const (
+	DSCPCS3  = 24
+	DSCPAF31 = 26
+)
+
+// SocketOptions are socket options for listeners and outbound connections, for tuning signaling QoS
+// and dead peer detection. Options not supported by platform return error on socket creation
+// Ex. per listener:
+//
+//	opts := sip.SocketOptions{KeepAlive: 15 * time.Second, TOS: sip.DSCPCS3 << 2}
+//	l, _ := opts.ListenConfig().Listen(ctx, "tcp", "0.0.0.0:5060")
+//	srv.ServeListener(ctx, "tcp", l)
+type SocketOptions struct {
+	// KeepAlive is TCP keepalive period. 0 uses system default and negative disables it
+	KeepAlive time.Duration
+	// ReuseAddr sets SO_REUSEADDR, allowing listener to bind while old connections are in TIME_WAIT
+	ReuseAddr bool
+	// TOS sets IP TOS or IPv6 traffic class for marking signaling packets. 0 is not set
+	TOS int
+	// ReadBuffer and WriteBuffer are socket buffer sizes in bytes. 0 is system default
+	ReadBuffer  int
+	WriteBuffer int
+}
+
+// ListenConfig returns listen config applying options on created listeners and accepted connections
+func (o *SocketOptions) ListenConfig() *net.ListenConfig {
+	if o == nil {
+		return &net.ListenConfig{}
+	}
+	return &net.ListenConfig{
+		KeepAlive: o.KeepAlive,
+		Control:   o.Control,
+	}
+}
+
+// Dialer returns dialer applying options on outbound connections bound to local address, which can be nil
+func (o *SocketOptions) Dialer(laddr net.Addr) *net.Dialer {
+	d := &net.Dialer{LocalAddr: laddr}
+	if o == nil {
+		return d
+	}
+	d.KeepAlive = o.KeepAlive
+	d.Control = o.Control
+	return d
+}
+
+// Control sets options on socket before bind or connect. It can be used as net.ListenConfig or net.Dialer Control
+func (o *SocketOptions) Control(network, address string, c syscall.RawConn) error {
+	var serr error
+	err := c.Control(func(fd uintptr) {
+		serr = setSocketOptions(fd, network, o)
+	})
+	if err != nil {
+		return err
+	}
+	return serr
+}
+
+// SetSocketOptions sets options used for listeners created by server and outbound connections.
+// Custom dialer and packet listener have priority. It must be set before creating any connection
+func (l *TransportLayer) SetSocketOptions(o SocketOptions) {
+	l.socketOpts = &o
+	l.udp.socketOpts = &o
+	l.tcp.socketOpts = &o
+	l.tls.socketOpts = &o
+	if l.ws.dialer.NetDial == nil {
+		l.ws.dialer.NetDial = o.Dialer(nil).DialContext
+	}
+	if l.wss.dialer.NetDial == nil {
+		l.wss.dialer.NetDial = o.Dialer(nil).DialContext
+	}
+}
+
+// ListenConfig returns listen config with socket options set by SetSocketOptions
+func (l *TransportLayer) ListenConfig() *net.ListenConfig {
+	return l.socketOpts.ListenConfig()
+}
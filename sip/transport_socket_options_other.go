@@ -0,0 +1,14 @@
+//go:build !unix
+
+package sip
+
+import (
+	"errors"
+)
+
+func setSocketOptions(fd uintptr, network string, o *SocketOptions) error {
+	if o.ReuseAddr || o.TOS > 0 || o.ReadBuffer > 0 || o.WriteBuffer > 0 {
+		return errors.New("socket options are not supported on this platform")
+	}
+	return nil
+}
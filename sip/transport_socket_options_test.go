@@ -0,0 +1,59 @@
+//go:build unix
+
+package sip
+
+import (
+	"context"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getSockopt(t *testing.T, conn syscall.Conn, level, opt int) int {
+	raw, err := conn.SyscallConn()
+	require.NoError(t, err)
+	var val int
+	var serr error
+	require.NoError(t, raw.Control(func(fd uintptr) {
+		val, serr = syscall.GetsockoptInt(int(fd), level, opt)
+	}))
+	require.NoError(t, serr)
+	return val
+}
+
+func TestSocketOptions(t *testing.T) {
+	opts := SocketOptions{ReuseAddr: true, TOS: DSCPCS3 << 2, ReadBuffer: 64 * 1024}
+	ctx := context.Background()
+
+	l, err := opts.ListenConfig().Listen(ctx, "tcp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	tl := l.(*net.TCPListener)
+	assert.Equal(t, 1, getSockopt(t, tl, syscall.SOL_SOCKET, syscall.SO_REUSEADDR))
+	assert.Equal(t, DSCPCS3<<2, getSockopt(t, tl, syscall.IPPROTO_IP, syscall.IP_TOS))
+	assert.GreaterOrEqual(t, getSockopt(t, tl, syscall.SOL_SOCKET, syscall.SO_RCVBUF), 64*1024)
+
+	pc, err := opts.ListenConfig().ListenPacket(ctx, "udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+	assert.Equal(t, DSCPCS3<<2, getSockopt(t, pc.(*net.UDPConn), syscall.IPPROTO_IP, syscall.IP_TOS))
+
+	// Outbound connections of transport layer
+	tp := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+	defer tp.Close()
+	tp.SetSocketOptions(SocketOptions{TOS: DSCPAF31 << 2})
+
+	_, port, err := ParseAddr(l.Addr().String())
+	require.NoError(t, err)
+	req := NewRequest(OPTIONS, &Uri{Host: "127.0.0.1", Port: port})
+	req.AppendHeader(&ViaHeader{Host: "127.0.0.1", Transport: TransportTCP, Params: NewParams()})
+	req.SetTransport(TransportTCP)
+	c, err := tp.ClientRequestConnection(ctx, req)
+	require.NoError(t, err)
+	defer c.TryClose()
+	tc := c.(*TCPConnection).Conn.(*net.TCPConn)
+	assert.Equal(t, DSCPAF31<<2, getSockopt(t, tc, syscall.IPPROTO_IP, syscall.IP_TOS))
+}
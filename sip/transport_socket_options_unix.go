@@ -0,0 +1,42 @@
+//go:build unix
+
+package sip
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+)
+
+func setSocketOptions(fd uintptr, network string, o *SocketOptions) error {
+	s := int(fd)
+	if o.ReuseAddr {
+		if err := syscall.SetsockoptInt(s, syscall.SOL_SOCKET, syscall.SO_REUSEADDR, 1); err != nil {
+			return fmt.Errorf("set SO_REUSEADDR: %w", err)
+		}
+	}
+
+	if o.TOS > 0 {
+		if strings.HasSuffix(network, "6") {
+			if err := syscall.SetsockoptInt(s, syscall.IPPROTO_IPV6, syscall.IPV6_TCLASS, o.TOS); err != nil {
+				return fmt.Errorf("set IPV6_TCLASS: %w", err)
+			}
+			// Dual stack socket carries IPv4 as well. Not all platforms allow it
+			syscall.SetsockoptInt(s, syscall.IPPROTO_IP, syscall.IP_TOS, o.TOS)
+		} else if err := syscall.SetsockoptInt(s, syscall.IPPROTO_IP, syscall.IP_TOS, o.TOS); err != nil {
+			return fmt.Errorf("set IP_TOS: %w", err)
+		}
+	}
+
+	if o.ReadBuffer > 0 {
+		if err := syscall.SetsockoptInt(s, syscall.SOL_SOCKET, syscall.SO_RCVBUF, o.ReadBuffer); err != nil {
+			return fmt.Errorf("set SO_RCVBUF: %w", err)
+		}
+	}
+	if o.WriteBuffer > 0 {
+		if err := syscall.SetsockoptInt(s, syscall.SOL_SOCKET, syscall.SO_SNDBUF, o.WriteBuffer); err != nil {
+			return fmt.Errorf("set SO_SNDBUF: %w", err)
+		}
+	}
+	return nil
+}
@@ -8,11 +8,22 @@ import (
 	"io"
 	"net"
 	"sync"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
+var (
+	// TCPWriteTimeout is write deadline for TCP and TLS connections.
+	// Connection failing to write is closed instead of queuing messages to peer that does not read. 0 disables it
+	TCPWriteTimeout = 10 * time.Second
+
+	// TCPHalfCloseTimeout is maximum time connection half-closed by peer is kept open
+	// for writing responses on requests received before FIN
+	TCPHalfCloseTimeout = 32 * time.Second
+)
+
 // TCP transport implementation
 type transportTCP struct {
 	addr      string
@@ -21,6 +32,15 @@ type transportTCP struct {
 	log       zerolog.Logger
 
 	pool ConnectionPool
+
+	connStateHandler ConnectionStateHandler
+	// parseErrHandler is called with source address on parse failure.
+	// If it returns true source is blocked and connection is closed
+	parseErrHandler func(src string) bool
+	// dialer if set is used for outbound connections instead of net.Dialer
+	dialer Dialer
+	// socketOpts are applied on outbound connections without custom dialer
+	socketOpts *SocketOptions
 }
 
 func newTCPTransport(par *Parser) *transportTCP {
@@ -92,10 +112,7 @@ func (t *transportTCP) createConnection(ctx context.Context, laddr *net.TCPAddr,
 	addr := raddr.String()
 	t.log.Debug().Str("raddr", addr).Msg("Dialing new connection")
 
-	d := net.Dialer{
-		LocalAddr: laddr,
-	}
-	conn, err := d.DialContext(ctx, "tcp", addr)
+	conn, err := t.dial(ctx, laddr, addr)
 	if err != nil {
 		return nil, fmt.Errorf("%s dial err=%w", t, err)
 	}
@@ -115,6 +132,20 @@ func (t *transportTCP) createConnection(ctx context.Context, laddr *net.TCPAddr,
 	return c, nil
 }
 
+// dial creates connection with custom dialer if set.
+// Local address is only applied with default dialer
+func (t *transportTCP) dial(ctx context.Context, laddr *net.TCPAddr, addr string) (net.Conn, error) {
+	if t.dialer != nil {
+		return t.dialer.DialContext(ctx, "tcp", addr)
+	}
+
+	var la net.Addr
+	if laddr != nil {
+		la = laddr
+	}
+	return t.socketOpts.Dialer(la).DialContext(ctx, "tcp", addr)
+}
+
 func (t *transportTCP) initConnection(conn net.Conn, addr string, handler MessageHandler) Connection {
 	// // conn.SetKeepAlive(true)
 	// conn.SetKeepAlivePeriod(3 * time.Second)
@@ -125,14 +156,22 @@ func (t *transportTCP) initConnection(conn net.Conn, addr string, handler Messag
 		refcount: 1 + IdleConnection,
 	}
 	t.pool.Add(addr, c)
-	go t.readConnection(c, addr, handler)
+
+	info := newConnectionInfo(t.transport, conn)
+	if t.connStateHandler != nil {
+		t.connStateHandler(info, ConnectionStateOpen)
+	}
+	go t.readConnection(c, addr, info, handler)
 	return c
 }
 
 // This should performe better to avoid any interface allocation
-func (t *transportTCP) readConnection(conn *TCPConnection, raddr string, handler MessageHandler) {
+func (t *transportTCP) readConnection(conn *TCPConnection, raddr string, info *ConnectionInfo, handler MessageHandler) {
 	buf := make([]byte, transportBufferSize)
 
+	if t.connStateHandler != nil {
+		defer t.connStateHandler(info, ConnectionStateClosed)
+	}
 	defer t.pool.CloseAndDelete(conn, raddr)
 
 	// Create stream parser context
@@ -141,7 +180,15 @@ func (t *transportTCP) readConnection(conn *TCPConnection, raddr string, handler
 	for {
 		num, err := conn.Read(buf)
 		if err != nil {
-			if errors.Is(err, net.ErrClosed) || errors.Is(err, io.EOF) {
+			if errors.Is(err, io.EOF) {
+				// Peer half-closed connection. It is not used for new requests,
+				// but pending responses are finished before closing
+				t.log.Debug().Str("raddr", raddr).Msg("Connection half-closed by peer")
+				conn.drain(TCPHalfCloseTimeout)
+				return
+			}
+
+			if errors.Is(err, net.ErrClosed) {
 				t.log.Debug().Err(err).Msg("connection was closed")
 				return
 			}
@@ -177,11 +224,11 @@ func (t *transportTCP) readConnection(conn *TCPConnection, raddr string, handler
 		// TODO fallback to parseFull if message size limit is set
 
 		// t.log.Debug().Str("raddr", raddr).Str("data", string(data)).Msg("new message")
-		t.parseStream(par, data, raddr, handler)
+		t.parseStream(par, data, raddr, conn, info, handler)
 	}
 }
 
-func (t *transportTCP) parseStream(par *ParserStream, data []byte, src string, handler MessageHandler) {
+func (t *transportTCP) parseStream(par *ParserStream, data []byte, src string, conn *TCPConnection, info *ConnectionInfo, handler MessageHandler) {
 	msgs, err := par.ParseSIPStream(data)
 	if err == ErrParseSipPartial {
 		return
@@ -190,11 +237,22 @@ func (t *transportTCP) parseStream(par *ParserStream, data []byte, src string, h
 	for _, msg := range msgs {
 		if err != nil {
 			t.log.Error().Err(err).Str("data", string(data)).Msg("failed to parse")
+			if t.parseErrHandler != nil && t.parseErrHandler(src) {
+				conn.Close()
+			}
 			return
 		}
 
 		msg.SetTransport(t.Network())
+		msg.SetReceivedTransport(t.Network())
 		msg.SetSource(src)
+		msg.SetLocalAddr(info.LocalAddr.String())
+		if req, ok := msg.(*Request); ok {
+			req.connInfo = info
+			if !req.IsAck() {
+				conn.addPending()
+			}
+		}
 		handler(msg)
 	}
 }
@@ -217,6 +275,67 @@ type TCPConnection struct {
 
 	mu       sync.RWMutex
 	refcount int
+
+	// pending is number of received requests without final response
+	pending int
+	// halfClosed is set when peer half-closed connection
+	halfClosed bool
+	// drained is closed when pending responses are written after peer half-close
+	drained chan struct{}
+}
+
+func (c *TCPConnection) addPending() {
+	c.mu.Lock()
+	c.pending++
+	c.mu.Unlock()
+}
+
+// hasPending returns true if received requests are waiting for final response
+func (c *TCPConnection) hasPending() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.pending > 0
+}
+
+// isHalfClosed returns true if peer half-closed connection and it should not be used for new requests
+func (c *TCPConnection) isHalfClosed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.halfClosed
+}
+
+// responded marks request as responded with final response
+func (c *TCPConnection) responded() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pending > 0 {
+		c.pending--
+	}
+	if c.pending == 0 && c.drained != nil {
+		close(c.drained)
+		c.drained = nil
+	}
+}
+
+// drain waits until all pending responses are written, connection is closed or timeout
+func (c *TCPConnection) drain(timeout time.Duration) {
+	c.mu.Lock()
+	c.halfClosed = true
+	if c.pending == 0 {
+		c.mu.Unlock()
+		return
+	}
+	c.drained = make(chan struct{})
+	drained := c.drained
+	c.mu.Unlock()
+
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-drained:
+	case <-t.C:
+		log.Debug().Str("dst", c.RemoteAddr().String()).Msg("TCP half-closed connection drain timeout")
+	}
 }
 
 func (c *TCPConnection) Ref(i int) int {
@@ -231,6 +350,10 @@ func (c *TCPConnection) Ref(i int) int {
 func (c *TCPConnection) Close() error {
 	c.mu.Lock()
 	c.refcount = 0
+	if c.drained != nil {
+		close(c.drained)
+		c.drained = nil
+	}
 	c.mu.Unlock()
 	log.Debug().Str("ip", c.LocalAddr().String()).Str("dst", c.RemoteAddr().String()).Int("ref", 0).Msg("TCP doing hard close")
 	return c.Conn.Close()
@@ -280,8 +403,14 @@ func (c *TCPConnection) WriteMsg(msg Message) error {
 	msg.StringWrite(buf)
 	data := buf.Bytes()
 
+	if TCPWriteTimeout > 0 {
+		c.SetWriteDeadline(time.Now().Add(TCPWriteTimeout))
+	}
+
 	n, err := c.Write(data)
 	if err != nil {
+		// Write dead connection. Closing it stops reading and removes it from pool
+		c.Conn.Close()
 		return fmt.Errorf("conn %s write err=%w", c.RemoteAddr().String(), err)
 	}
 
@@ -292,5 +421,9 @@ func (c *TCPConnection) WriteMsg(msg Message) error {
 	if n != len(data) {
 		return fmt.Errorf("fail to write full message")
 	}
+
+	if res, ok := msg.(*Response); ok && res.StatusCode >= 200 {
+		c.responded()
+	}
 	return nil
 }
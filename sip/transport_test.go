@@ -2,9 +2,14 @@ package sip
 
 import (
 	"context"
+	"io"
 	"net"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -43,3 +48,339 @@ func TestTransportLayerConnectionReuse(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, conn, conn2)
 }
+
+func TestTransportLayerResponseConnectionFallback(t *testing.T) {
+	// NOTE it creates real network connection
+	tp := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+	defer tp.Close()
+
+	// Remote side listening on sent-by address
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	host, port, err := ParseAddr(l.Addr().String())
+	require.NoError(t, err)
+
+	params := NewParams()
+	params["branch"] = GenerateBranch()
+	req := NewRequest(OPTIONS, &Uri{Host: "127.0.0.1", Port: 5060})
+	req.AppendHeader(&ViaHeader{ProtocolName: "SIP", ProtocolVersion: "2.0", Transport: "TCP", Host: host, Port: port, Params: params})
+	req.AppendHeader(&FromHeader{Address: Uri{User: "alice", Host: host}, Params: NewParams()})
+	req.AppendHeader(&ToHeader{Address: Uri{User: "bob", Host: "127.0.0.1"}, Params: NewParams()})
+	callid := CallIDHeader("transport-test")
+	req.AppendHeader(&callid)
+	req.AppendHeader(&CSeqHeader{SeqNo: 1, MethodName: OPTIONS})
+	req.SetTransport("TCP")
+	// Source connection does not exist any more
+	req.SetSource("127.0.0.1:1")
+
+	res := NewResponseFromRequest(req, 200, "OK", nil)
+	require.NoError(t, tp.WriteMsg(res))
+
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	buf := make([]byte, 1000)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	require.Contains(t, string(buf[:n]), "SIP/2.0 200 OK")
+}
+
+func TestTransportLayerConnectionState(t *testing.T) {
+	// NOTE it creates real network connection
+	tp := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+	defer tp.Close()
+
+	states := make(chan ConnectionState, 2)
+	tp.OnConnectionState(func(info *ConnectionInfo, state ConnectionState) {
+		require.Equal(t, TransportTCP, info.Network)
+		states <- state
+	})
+
+	reqs := make(chan *Request, 1)
+	tp.OnMessage(func(msg Message) {
+		if req, ok := msg.(*Request); ok {
+			reqs <- req
+		}
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go tp.ServeTCP(l)
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	require.Equal(t, ConnectionStateOpen, <-states)
+
+	_, err = conn.Write([]byte(strings.Join([]string{
+		"OPTIONS sip:bob@127.0.0.1 SIP/2.0",
+		"Via: SIP/2.0/TCP " + conn.LocalAddr().String() + ";branch=z9hG4bK.test",
+		"From: <sip:alice@127.0.0.1>;tag=1234",
+		"To: <sip:bob@127.0.0.1>",
+		"Call-ID: conn-state-test",
+		"CSeq: 1 OPTIONS",
+		"Content-Length: 0",
+		"",
+		"",
+	}, "\r\n")))
+	require.NoError(t, err)
+
+	req := <-reqs
+	info := req.ConnectionInfo()
+	require.NotNil(t, info)
+	require.Equal(t, conn.LocalAddr().String(), info.RemoteAddr.String())
+	require.Nil(t, info.TLSState())
+
+	// Respond so connection is not kept for pending response after close
+	require.NoError(t, tp.WriteMsg(NewResponseFromRequest(req, 200, "OK", nil)))
+	conn.Close()
+	require.Equal(t, ConnectionStateClosed, <-states)
+}
+
+type testDialer struct {
+	net.Dialer
+	dials int
+}
+
+func (d *testDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	d.dials++
+	return d.Dialer.DialContext(ctx, network, address)
+}
+
+func TestTransportLayerCustomDialer(t *testing.T) {
+	// NOTE it creates real network connection
+	tp := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+	defer tp.Close()
+
+	d := &testDialer{}
+	tp.SetDialer(d)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	host, port, err := ParseAddr(l.Addr().String())
+	require.NoError(t, err)
+
+	req := NewRequest(OPTIONS, &Uri{Host: host, Port: port})
+	req.AppendHeader(&ViaHeader{Host: "127.0.0.1", Port: 0, Params: NewParams()})
+	req.SetTransport("TCP")
+
+	conn, err := tp.ClientRequestConnection(context.TODO(), req)
+	require.NoError(t, err)
+	defer conn.TryClose()
+	require.Equal(t, 1, d.dials)
+}
+
+func TestTransportLayerViaReceived(t *testing.T) {
+	for via, expected := range map[string]string{
+		"Via: SIP/2.0/UDP 10.1.1.1:5060;branch=z9hG4bK.abc;rport": "203.0.113.1:40123",
+		"Via: SIP/2.0/UDP 10.1.1.1:5060;branch=z9hG4bK.abc":       "203.0.113.1:5060",
+		"Via: SIP/2.0/UDP 203.0.113.1:5060;branch=z9hG4bK.abc":    "203.0.113.1:5060",
+	} {
+		req := testCreateMessage(t, []string{
+			"INVITE sip:bob@127.0.0.1:5060 SIP/2.0",
+			via,
+			"From: <sip:alice@10.1.1.1>;tag=1928301774",
+			"To: <sip:bob@127.0.0.1>",
+			"Call-ID: a84b4c76e66710",
+			"CSeq: 314159 INVITE",
+			"Content-Length: 0",
+			"",
+			"",
+		}).(*Request)
+		req.SetSource("203.0.113.1:40123")
+		setViaReceived(req)
+
+		// Response built without request source must still route back symmetrically
+		res := NewResponseFromRequest(req, StatusOK, "OK", nil)
+		res.SetDestination("")
+		assert.Equal(t, expected, res.Destination(), via)
+	}
+}
+
+func TestTransportLayerTCPHalfClose(t *testing.T) {
+	// NOTE it creates real network connection
+	tp := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+	defer tp.Close()
+
+	requests := make(chan *Request)
+	tp.OnMessage(func(msg Message) {
+		if req, ok := msg.(*Request); ok {
+			requests <- req
+		}
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go tp.ServeTCP(l)
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	host, port, err := ParseAddr(conn.LocalAddr().String())
+	require.NoError(t, err)
+	req, _, _ := testCreateInvite(t, "sip:bob@"+l.Addr().String(), "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	req.SetBody(nil)
+	_, err = conn.Write([]byte(req.String()))
+	require.NoError(t, err)
+
+	// Peer half-closes after sending request
+	require.NoError(t, conn.(*net.TCPConn).CloseWrite())
+	received := <-requests
+
+	// Response is still written on half-closed connection
+	time.Sleep(50 * time.Millisecond)
+	res := NewResponseFromRequest(received, 200, "OK", nil)
+	require.NoError(t, tp.WriteMsg(res))
+
+	buf := make([]byte, 2000)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	require.Contains(t, string(buf[:n]), "SIP/2.0 200 OK")
+
+	// Connection is closed when pending responses are written
+	_, err = conn.Read(buf)
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestTransportLayerUDPTCPFallback(t *testing.T) {
+	// NOTE it creates real network connection
+	tp := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+	defer tp.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	host, port, err := ParseAddr(l.Addr().String())
+	require.NoError(t, err)
+
+	newReq := func(bodySize int) *Request {
+		req := NewRequest(MESSAGE, &Uri{Host: host, Port: port})
+		req.AppendHeader(&ViaHeader{ProtocolName: "SIP", ProtocolVersion: "2.0", Transport: "UDP", Host: "127.0.0.1", Params: NewParams()})
+		req.SetTransport("UDP")
+		req.SetBody(make([]byte, bodySize))
+		return req
+	}
+
+	req := newReq(100)
+	conn, err := tp.ClientRequestConnection(context.TODO(), req)
+	require.NoError(t, err)
+	defer conn.TryClose()
+	assert.Equal(t, "UDP", req.Transport())
+	assert.Equal(t, "udp", conn.LocalAddr().Network())
+
+	req = newReq(1300)
+	conn, err = tp.ClientRequestConnection(context.TODO(), req)
+	require.NoError(t, err)
+	defer conn.TryClose()
+	assert.Equal(t, "TCP", req.Transport())
+	assert.Equal(t, "TCP", req.Via().Transport)
+	assert.Equal(t, "tcp", conn.LocalAddr().Network())
+	assert.Equal(t, conn.LocalAddr().(*net.TCPAddr).Port, req.Via().Port)
+	assert.NoError(t, conn.WriteMsg(req))
+}
+
+func TestTransportLayerUDPTCPFallbackFailed(t *testing.T) {
+	// NOTE it creates real network connection
+	tp := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+	defer tp.Close()
+
+	// Only UDP is listening, so TCP connect is refused
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	host, port, err := ParseAddr(l.LocalAddr().String())
+	require.NoError(t, err)
+
+	req := NewRequest(MESSAGE, &Uri{Host: host, Port: port})
+	req.AppendHeader(&ViaHeader{ProtocolName: "SIP", ProtocolVersion: "2.0", Transport: "UDP", Host: "127.0.0.1", Params: NewParams()})
+	req.SetTransport("UDP")
+	req.SetBody(make([]byte, 1300))
+
+	conn, err := tp.ClientRequestConnection(context.TODO(), req)
+	require.NoError(t, err)
+	defer conn.TryClose()
+	assert.Equal(t, "UDP", req.Transport())
+	assert.Equal(t, "UDP", req.Via().Transport)
+	assert.Equal(t, "127.0.0.1", req.Via().Host)
+	assert.Equal(t, "udp", conn.LocalAddr().Network())
+	assert.Equal(t, conn.LocalAddr().(*net.UDPAddr).Port, req.Via().Port)
+
+	// Retry over UDP is not limited by MTU
+	require.NoError(t, conn.WriteMsg(req))
+	buf := make([]byte, 65535)
+	l.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := l.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, messageSize(req), n)
+}
+
+func TestTransportLayerMaddr(t *testing.T) {
+	req := testCreateMessage(t, []string{
+		"REGISTER sip:224.0.1.75;maddr=224.0.1.75 SIP/2.0",
+		"Via: SIP/2.0/UDP 10.1.1.1:5070;branch=z9hG4bK.abc;maddr=224.0.1.75;ttl=1",
+		"From: <sip:alice@10.1.1.1>;tag=1928301774",
+		"To: <sip:alice@10.1.1.1>",
+		"Call-ID: a84b4c76e66710",
+		"CSeq: 1 REGISTER",
+		"Content-Length: 0",
+		"",
+		"",
+	}).(*Request)
+	req.SetSource("10.1.1.1:5070")
+	req.SetLocalAddr("224.0.1.75:5060")
+
+	req.SetDestination("")
+	assert.Equal(t, "224.0.1.75:5060", req.Destination())
+
+	// Response to multicast request goes to maddr with sent-by port
+	res := NewResponseFromRequest(req, StatusOK, "OK", nil)
+	assert.Equal(t, "224.0.1.75:5070", res.Destination())
+	assert.Equal(t, "224.0.1.75:5060", res.LocalAddr())
+	res.SetDestination("")
+	assert.Equal(t, "224.0.1.75:5070", res.Destination())
+
+	// Reliable transport keeps responding to source
+	req.SetTransport("TCP")
+	res = NewResponseFromRequest(req, StatusOK, "OK", nil)
+	assert.Equal(t, "10.1.1.1:5070", res.Destination())
+}
+
+func TestTransportLayerResolveTargets(t *testing.T) {
+	resolver := &testDNSResolver{
+		calls: map[string]int{},
+		ips: map[string][]net.IPAddr{
+			"pbx.example.com":  {{IP: net.ParseIP("2001:db8::1")}, {IP: net.ParseIP("192.0.2.1")}},
+			"pbx1.example.com": {{IP: net.ParseIP("192.0.2.10")}},
+			"pbx3.example.com": {{IP: net.ParseIP("192.0.2.30")}, {IP: net.ParseIP("2001:db8::30")}},
+		},
+		srv: []*net.SRV{
+			{Target: "pbx1.example.com.", Port: 5070, Priority: 10},
+			{Target: "pbx2.example.com.", Port: 5070, Priority: 20},
+			{Target: "pbx3.example.com.", Port: 5080, Priority: 30},
+		},
+	}
+	tp := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+	defer tp.Close()
+	tp.SetDNSResolver(resolver)
+	ctx := context.Background()
+
+	req := NewRequest(OPTIONS, &Uri{Host: "pbx.example.com"})
+	targets, err := tp.ResolveTargets(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"192.0.2.1:5060", "[2001:db8::1]:5060"}, targets)
+
+	// Host without address uses SRV targets in order. Unresolvable target is skipped
+	req = NewRequest(OPTIONS, &Uri{Host: "example.com"})
+	targets, err = tp.ResolveTargets(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"192.0.2.10:5070", "192.0.2.30:5080", "[2001:db8::30]:5080"}, targets)
+
+	req.SetDestination("192.0.2.99:5060")
+	targets, err = tp.ResolveTargets(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"192.0.2.99:5060"}, targets)
+}
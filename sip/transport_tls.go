@@ -3,13 +3,22 @@ package sip
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
-var ()
+var (
+	// TLSHandshakeTimeout limits duration of TLS handshake on accepted connections. 0 disables it
+	TLSHandshakeTimeout = 10 * time.Second
+
+	// TLSMaxConcurrentHandshakes limits number of accepted connections doing TLS handshake at same time.
+	// Connections above limit are closed immediately. 0 is unlimited
+	TLSMaxConcurrentHandshakes = 1024
+)
 
 // TLS transport implementation
 type transportTLS struct {
@@ -17,6 +26,11 @@ type transportTLS struct {
 
 	// rootPool *x509.CertPool
 	tlsConf *tls.Config
+	alpn    TLSALPN
+	// mutualAuth if set verifies and authorizes client certificates of accepted connections
+	mutualAuth *TLSMutualAuth
+	// getCertificate if set selects certificate for accepted connections
+	getCertificate TLSGetCertificate
 }
 
 // newTLSTransport needs dialTLSConf for creating connections when dialing
@@ -37,6 +51,80 @@ func (t *transportTLS) String() string {
 	return "transport<TLS>"
 }
 
+// Serve accepts TLS connections. Handshake is completed with timeout before connection is used for SIP,
+// so that slow or plaintext clients do not hold resources
+func (t *transportTLS) Serve(l net.Listener, handler MessageHandler) error {
+	t.log.Debug().Msgf("begin listening on %s %s", t.Network(), l.Addr().String())
+
+	var handshakes chan struct{}
+	if TLSMaxConcurrentHandshakes > 0 {
+		handshakes = make(chan struct{}, TLSMaxConcurrentHandshakes)
+	}
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			t.log.Debug().Err(err).Msg("Fail to accept conenction")
+			return err
+		}
+
+		tlsConn, ok := conn.(*tls.Conn)
+		if !ok {
+			// TLS is terminated by listener itself
+			t.initConnection(conn, conn.RemoteAddr().String(), handler)
+			continue
+		}
+
+		if handshakes != nil {
+			select {
+			case handshakes <- struct{}{}:
+			default:
+				t.log.Warn().Str("raddr", conn.RemoteAddr().String()).Msg("Too many concurrent TLS handshakes. Connection rejected")
+				conn.Close()
+				continue
+			}
+		}
+
+		go t.serverHandshake(tlsConn, handshakes, handler)
+	}
+}
+
+func (t *transportTLS) serverHandshake(conn *tls.Conn, handshakes chan struct{}, handler MessageHandler) {
+	ctx := context.Background()
+	if TLSHandshakeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, TLSHandshakeTimeout)
+		defer cancel()
+	}
+
+	err := conn.HandshakeContext(ctx)
+	if handshakes != nil {
+		<-handshakes
+	}
+
+	raddr := conn.RemoteAddr().String()
+	if err != nil {
+		var recErr tls.RecordHeaderError
+		if errors.As(err, &recErr) {
+			t.log.Debug().Str("raddr", raddr).Msg("Plaintext data on TLS connection. Connection rejected")
+		} else {
+			t.log.Debug().Err(err).Str("raddr", raddr).Msg("TLS handshake failed")
+		}
+		conn.Close()
+		return
+	}
+
+	if !t.authorizePeer(conn) {
+		conn.Close()
+		return
+	}
+
+	if t.handleALPN(conn) {
+		return
+	}
+	t.initConnection(conn, raddr, handler)
+}
+
 // CreateConnection creates TLS connection for TCP transport
 func (t *transportTLS) CreateConnection(ctx context.Context, laddr Addr, raddr Addr, handler MessageHandler) (Connection, error) {
 	// raddr, err := net.ResolveTCPAddr("tcp", addr)
@@ -68,14 +156,7 @@ func (t *transportTLS) createConnection(ctx context.Context, laddr *net.TCPAddr,
 	// SHould we make copy of rootPool?
 	// There is Clone of config
 
-	dialer := tls.Dialer{
-		NetDialer: &net.Dialer{
-			LocalAddr: laddr,
-		},
-		Config: t.tlsConf,
-	}
-
-	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	conn, err := t.dialTLS(ctx, laddr, addr)
 	if err != nil {
 		return nil, fmt.Errorf("%s dial err=%w", t, err)
 	}
@@ -84,3 +165,43 @@ func (t *transportTLS) createConnection(ctx context.Context, laddr *net.TCPAddr,
 	c.Ref(1)
 	return c, nil
 }
+
+func (t *transportTLS) dialTLS(ctx context.Context, laddr *net.TCPAddr, addr string) (net.Conn, error) {
+	if t.dialer == nil {
+		var la net.Addr
+		if laddr != nil {
+			la = laddr
+		}
+		dialer := tls.Dialer{
+			NetDialer: t.socketOpts.Dialer(la),
+			Config:    t.tlsConf,
+		}
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+
+	rawConn, err := t.dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Same as tls.Dialer, server name is taken from address if not set
+	conf := t.tlsConf
+	if conf == nil {
+		conf = &tls.Config{}
+	}
+	if conf.ServerName == "" {
+		conf = conf.Clone()
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		conf.ServerName = host
+	}
+
+	conn := tls.Client(rawConn, conf)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
@@ -0,0 +1,59 @@
+package sip
+
+import (
+	"crypto/tls"
+)
+
+// ALPNHandler is called on accepted TLS connection after handshake with negotiated ALPN protocol.
+// Returning true means handler took over connection and it is not used as SIP connection.
+// This allows negotiating experimental framing or multiplexed protocols next to SIP
+type ALPNHandler func(proto string, conn *tls.Conn) bool
+
+// TLSALPN is ALPN configuration for TLS transport
+type TLSALPN struct {
+	// Protocols are offered in preference order on dial and on served listeners
+	Protocols []string
+	// Handler is optional. Check ALPNHandler
+	Handler ALPNHandler
+}
+
+// SetTLSALPN sets ALPN protocols offered on TLS connections.
+// Listener passed to ServeTLS must be created with config returned by ApplyTLSALPN.
+// It must be set before creating any connection
+func (l *TransportLayer) SetTLSALPN(alpn TLSALPN) {
+	l.tls.alpn = alpn
+	l.tls.tlsConf = l.ApplyTLSALPN(l.tls.tlsConf)
+}
+
+// ApplyTLSALPN returns copy of conf with ALPN protocols set.
+// Conf is returned as is if ALPN is not set or conf has own protocols
+func (l *TransportLayer) ApplyTLSALPN(conf *tls.Config) *tls.Config {
+	protos := l.tls.alpn.Protocols
+	if len(protos) == 0 {
+		return conf
+	}
+
+	if conf == nil {
+		conf = &tls.Config{}
+	} else if len(conf.NextProtos) > 0 {
+		return conf
+	} else {
+		conf = conf.Clone()
+	}
+	conf.NextProtos = append([]string(nil), protos...)
+	return conf
+}
+
+// handleALPN passes accepted connection to ALPN handler. It returns true if connection is taken over
+func (t *transportTLS) handleALPN(conn *tls.Conn) bool {
+	if t.alpn.Handler == nil {
+		return false
+	}
+
+	proto := conn.ConnectionState().NegotiatedProtocol
+	if !t.alpn.Handler(proto, conn) {
+		return false
+	}
+	t.log.Debug().Str("raddr", conn.RemoteAddr().String()).Str("proto", proto).Msg("Connection taken over by ALPN handler")
+	return true
+}
@@ -0,0 +1,57 @@
+package sip
+
+import (
+	"crypto/tls"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportTLSALPN(t *testing.T) {
+	cert, err := tls.LoadX509KeyPair("../testdata/certs/server.crt", "../testdata/certs/server.key")
+	require.NoError(t, err)
+
+	tp := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+	defer tp.Close()
+
+	taken := make(chan string, 1)
+	tp.SetTLSALPN(TLSALPN{
+		Protocols: []string{"sip", "x-mux"},
+		Handler: func(proto string, conn *tls.Conn) bool {
+			if proto != "x-mux" {
+				return false
+			}
+			taken <- proto
+			conn.Close()
+			return true
+		},
+	})
+
+	conf := tp.ApplyTLSALPN(&tls.Config{Certificates: []tls.Certificate{cert}})
+	require.Equal(t, []string{"sip", "x-mux"}, conf.NextProtos)
+	// Dial config gets protocols as well
+	require.Equal(t, []string{"sip", "x-mux"}, tp.tls.tlsConf.NextProtos)
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", conf)
+	require.NoError(t, err)
+	defer l.Close()
+	go tp.ServeTLS(l)
+
+	conn, err := tls.Dial("tcp", l.Addr().String(), &tls.Config{InsecureSkipVerify: true, NextProtos: []string{"x-mux"}})
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, "x-mux", conn.ConnectionState().NegotiatedProtocol)
+	assert.Equal(t, "x-mux", <-taken)
+
+	conn, err = tls.Dial("tcp", l.Addr().String(), &tls.Config{InsecureSkipVerify: true, NextProtos: []string{"sip"}})
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, "sip", conn.ConnectionState().NegotiatedProtocol)
+	select {
+	case <-taken:
+		t.Fatal("SIP connection must not be taken over")
+	default:
+	}
+}
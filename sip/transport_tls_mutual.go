@@ -0,0 +1,118 @@
+package sip
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+)
+
+// TLSPeerAuthorizer is called on accepted TLS connection after handshake and certificate verification.
+// Returning error rejects connection before any SIP message is read, ex. when peer identity is not known trunk
+type TLSPeerAuthorizer func(info *ConnectionInfo) error
+
+// TLSMutualAuth is client certificate configuration for served TLS listeners
+type TLSMutualAuth struct {
+	// ClientCAs verify client certificates. Nil uses system roots
+	ClientCAs *x509.CertPool
+	// GetClientCAs if set returns CA pool on every handshake, so pool can be reloaded while serving.
+	// Check sipgo.CertificateReloader. It has priority over ClientCAs
+	GetClientCAs func() *x509.CertPool
+	// Required rejects clients without certificate. Otherwise certificate is verified only if given
+	Required bool
+	// Authorize is optional per connection authorization. Check TLSPeerAuthorizer
+	Authorize TLSPeerAuthorizer
+}
+
+// TLSPeerIdentity is identity of peer from verified certificate
+type TLSPeerIdentity struct {
+	// Certificate is peer leaf certificate
+	Certificate *x509.Certificate
+	CommonName  string
+	DNSNames    []string
+	URIs        []*url.URL
+}
+
+// Matches reports is identity valid for host, checking SAN and wildcard names as in hostname verification
+func (id *TLSPeerIdentity) Matches(host string) bool {
+	return id.Certificate.VerifyHostname(host) == nil
+}
+
+// SetTLSMutualAuth sets client certificate verification on served TLS listeners.
+// Listener passed to ServeTLS must be created with config returned by ApplyTLSMutualAuth.
+// It must be set before serving
+func (l *TransportLayer) SetTLSMutualAuth(m TLSMutualAuth) {
+	l.tls.mutualAuth = &m
+}
+
+// ApplyTLSMutualAuth returns copy of conf with client certificate verification set.
+// Conf is returned as is if mutual auth is not set. With GetClientCAs conf GetConfigForClient is set,
+// unless conf has own
+func (l *TransportLayer) ApplyTLSMutualAuth(conf *tls.Config) *tls.Config {
+	m := l.tls.mutualAuth
+	if m == nil {
+		return conf
+	}
+
+	if conf == nil {
+		conf = &tls.Config{}
+	} else {
+		conf = conf.Clone()
+	}
+	conf.ClientAuth = tls.VerifyClientCertIfGiven
+	if m.Required {
+		conf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	if m.ClientCAs != nil {
+		conf.ClientCAs = m.ClientCAs
+	}
+	if m.GetClientCAs != nil && conf.GetConfigForClient == nil {
+		base := conf.Clone()
+		conf.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			c := base.Clone()
+			c.ClientCAs = m.GetClientCAs()
+			return c, nil
+		}
+	}
+	return conf
+}
+
+// authorizePeer runs authorizer on accepted connection. It returns false if connection is rejected
+func (t *transportTLS) authorizePeer(conn *tls.Conn) bool {
+	if t.mutualAuth == nil {
+		return true
+	}
+
+	raddr := conn.RemoteAddr().String()
+	info := newConnectionInfo(t.transport, conn)
+	if t.mutualAuth.Required && info.PeerIdentity() == nil {
+		// Listener config is not applied with ApplyTLSMutualAuth
+		t.log.Warn().Str("raddr", raddr).Msg("TLS peer without verified certificate. Connection rejected")
+		return false
+	}
+
+	if t.mutualAuth.Authorize == nil {
+		return true
+	}
+	if err := t.mutualAuth.Authorize(info); err != nil {
+		t.log.Info().Err(err).Str("raddr", raddr).Msg("TLS peer not authorized. Connection rejected")
+		return false
+	}
+	return true
+}
+
+// PeerIdentity returns identity of peer certificate verified during TLS handshake.
+// Nil is returned if connection is not TLS or peer certificate is not verified, ex. client without certificate
+func (c *ConnectionInfo) PeerIdentity() *TLSPeerIdentity {
+	state := c.TLSState()
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	cert := state.VerifiedChains[0][0]
+	return &TLSPeerIdentity{
+		Certificate: cert,
+		CommonName:  cert.Subject.CommonName,
+		DNSNames:    cert.DNSNames,
+		URIs:        cert.URIs,
+	}
+}
@@ -0,0 +1,111 @@
+package sip
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportTLSMutualAuth(t *testing.T) {
+	cert, err := tls.LoadX509KeyPair("../testdata/certs/server.crt", "../testdata/certs/server.key")
+	require.NoError(t, err)
+	clientCert, err := tls.LoadX509KeyPair("../testdata/certs/client.crt", "../testdata/certs/client.key")
+	require.NoError(t, err)
+	rootPEM, err := os.ReadFile("../testdata/certs/rootca-cert.pem")
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(rootPEM))
+
+	tp := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+	defer tp.Close()
+
+	authorized := make(chan *TLSPeerIdentity, 3)
+	var reject atomic.Bool
+	tp.SetTLSMutualAuth(TLSMutualAuth{
+		ClientCAs: roots,
+		Required:  true,
+		Authorize: func(info *ConnectionInfo) error {
+			id := info.PeerIdentity()
+			authorized <- id
+			if reject.Load() || !id.Matches("127.1.1.100") {
+				return errors.New("unknown peer")
+			}
+			return nil
+		},
+	})
+	msgs := make(chan Message, 1)
+	tp.OnMessage(func(msg Message) { msgs <- msg })
+
+	conf := tp.ApplyTLSMutualAuth(&tls.Config{Certificates: []tls.Certificate{cert}})
+	require.Equal(t, tls.RequireAndVerifyClientCert, conf.ClientAuth)
+	l, err := tls.Listen("tcp", "127.0.0.1:0", conf)
+	require.NoError(t, err)
+	defer l.Close()
+	go tp.ServeTLS(l)
+
+	readClosed := func(conn *tls.Conn) error {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		_, err := conn.Read(make([]byte, 1))
+		return err
+	}
+
+	t.Run("NoCertificate", func(t *testing.T) {
+		conn, err := tls.Dial("tcp", l.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+		if err == nil {
+			// With TLS 1.3 client learns about rejection on first read
+			defer conn.Close()
+			err = readClosed(conn)
+		}
+		require.Error(t, err)
+	})
+
+	t.Run("Verified", func(t *testing.T) {
+		conn, err := tls.Dial("tcp", l.Addr().String(), &tls.Config{InsecureSkipVerify: true, Certificates: []tls.Certificate{clientCert}})
+		require.NoError(t, err)
+		defer conn.Close()
+
+		id := <-authorized
+		require.NotNil(t, id)
+		assert.Equal(t, "localhost", id.CommonName)
+
+		_, err = conn.Write([]byte("OPTIONS sip:bob@127.0.0.1 SIP/2.0\r\n" +
+			"Via: SIP/2.0/TLS 127.1.1.100:5061;branch=" + GenerateBranch() + "\r\n" +
+			"From: <sip:alice@127.1.1.100>;tag=abc\r\n" +
+			"To: <sip:bob@127.0.0.1>\r\n" +
+			"Call-ID: mtls-test\r\n" +
+			"CSeq: 1 OPTIONS\r\n" +
+			"Content-Length: 0\r\n\r\n"))
+		require.NoError(t, err)
+
+		select {
+		case msg := <-msgs:
+			info := msg.(*Request).ConnectionInfo()
+			require.NotNil(t, info)
+			peer := info.PeerIdentity()
+			require.NotNil(t, peer)
+			assert.True(t, peer.Matches("127.1.1.100"))
+			assert.False(t, peer.Matches("trunk.example.com"))
+		case <-time.After(time.Second):
+			t.Fatal("request not received")
+		}
+	})
+
+	t.Run("NotAuthorized", func(t *testing.T) {
+		reject.Store(true)
+		conn, err := tls.Dial("tcp", l.Addr().String(), &tls.Config{InsecureSkipVerify: true, Certificates: []tls.Certificate{clientCert}})
+		require.NoError(t, err)
+		defer conn.Close()
+		err = readClosed(conn)
+		require.Error(t, err)
+		var netErr net.Error
+		require.False(t, errors.As(err, &netErr) && netErr.Timeout(), "connection must be closed")
+	})
+}
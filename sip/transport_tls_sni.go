@@ -0,0 +1,102 @@
+package sip
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// TLSGetCertificate selects certificate for accepted TLS connection, ex. by SNI server name in hello.
+// It has same semantics as tls.Config GetCertificate
+type TLSGetCertificate func(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
+
+// SetTLSGetCertificate sets certificate selection on served TLS listeners, so that multi-tenant server
+// can serve certificate per domain. Check SNICertificates.
+// Listener passed to ServeTLS must be created with config returned by ApplyTLSGetCertificate.
+// It must be set before serving
+func (l *TransportLayer) SetTLSGetCertificate(fn TLSGetCertificate) {
+	l.tls.getCertificate = fn
+}
+
+// ApplyTLSGetCertificate returns copy of conf with certificate selection set.
+// Conf is returned as is if selection is not set or conf has own GetCertificate
+func (l *TransportLayer) ApplyTLSGetCertificate(conf *tls.Config) *tls.Config {
+	fn := l.tls.getCertificate
+	if fn == nil {
+		return conf
+	}
+
+	if conf == nil {
+		conf = &tls.Config{}
+	} else if conf.GetCertificate != nil {
+		return conf
+	} else {
+		conf = conf.Clone()
+	}
+	conf.GetCertificate = fn
+	return conf
+}
+
+// SNICertificates selects certificate by server name requested with SNI. Names can be wildcard like *.example.com,
+// which matches single label. It is safe to add and remove certificates while serving
+type SNICertificates struct {
+	mu    sync.RWMutex
+	certs map[string]*tls.Certificate
+	// def is used when client does not send SNI or name is not found
+	def *tls.Certificate
+}
+
+// NewSNICertificates creates SNI selection with default certificate, which can be nil to reject unknown names
+func NewSNICertificates(def *tls.Certificate) *SNICertificates {
+	return &SNICertificates{
+		certs: make(map[string]*tls.Certificate),
+		def:   def,
+	}
+}
+
+// Add sets certificate for server name
+func (s *SNICertificates) Add(name string, cert *tls.Certificate) {
+	s.mu.Lock()
+	s.certs[strings.ToLower(name)] = cert
+	s.mu.Unlock()
+}
+
+// Remove removes certificate for server name
+func (s *SNICertificates) Remove(name string) {
+	s.mu.Lock()
+	delete(s.certs, strings.ToLower(name))
+	s.mu.Unlock()
+}
+
+// GetCertificate implements TLSGetCertificate. Exact name has priority over wildcard
+func (s *SNICertificates) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	name := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if name != "" {
+		if cert, ok := s.certs[name]; ok {
+			return cert, nil
+		}
+		if _, parent, found := strings.Cut(name, "."); found {
+			if cert, ok := s.certs["*."+parent]; ok {
+				return cert, nil
+			}
+		}
+	}
+
+	if s.def == nil {
+		return nil, fmt.Errorf("no certificate for server name %q", hello.ServerName)
+	}
+	return s.def, nil
+}
+
+// ServerName returns server name requested by peer with SNI on accepted TLS connection.
+// Empty is returned if connection is not TLS or peer did not send it
+func (c *ConnectionInfo) ServerName() string {
+	if state := c.TLSState(); state != nil {
+		return state.ServerName
+	}
+	return ""
+}
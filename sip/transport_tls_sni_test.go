@@ -0,0 +1,105 @@
+package sip
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSelfSignedCert(t *testing.T, name string) *tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestSNICertificates(t *testing.T) {
+	def := testSelfSignedCert(t, "default.example.org")
+	tenant := testSelfSignedCert(t, "tenant.example.com")
+	wildcard := testSelfSignedCert(t, "*.example.com")
+
+	sni := NewSNICertificates(def)
+	sni.Add("Tenant.example.com", tenant)
+	sni.Add("*.example.com", wildcard)
+
+	for name, expected := range map[string]*tls.Certificate{
+		"tenant.example.com":  tenant,
+		"TENANT.example.com.": tenant,
+		"other.example.com":   wildcard,
+		"a.b.example.com":     def,
+		"example.com":         def,
+		"":                    def,
+	} {
+		cert, err := sni.GetCertificate(&tls.ClientHelloInfo{ServerName: name})
+		require.NoError(t, err)
+		assert.Same(t, expected, cert, name)
+	}
+
+	sni.Remove("*.example.com")
+	cert, err := sni.GetCertificate(&tls.ClientHelloInfo{ServerName: "other.example.com"})
+	require.NoError(t, err)
+	assert.Same(t, def, cert)
+
+	_, err = NewSNICertificates(nil).GetCertificate(&tls.ClientHelloInfo{ServerName: "other.example.com"})
+	require.Error(t, err)
+}
+
+func TestTransportTLSGetCertificate(t *testing.T) {
+	tp := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+	defer tp.Close()
+
+	sni := NewSNICertificates(testSelfSignedCert(t, "default.example.org"))
+	sni.Add("tenant.example.com", testSelfSignedCert(t, "tenant.example.com"))
+	tp.SetTLSGetCertificate(sni.GetCertificate)
+	msgs := make(chan Message, 1)
+	tp.OnMessage(func(msg Message) { msgs <- msg })
+
+	conf := tp.ApplyTLSGetCertificate(nil)
+	require.NotNil(t, conf.GetCertificate)
+	l, err := tls.Listen("tcp", "127.0.0.1:0", conf)
+	require.NoError(t, err)
+	defer l.Close()
+	go tp.ServeTLS(l)
+
+	conn, err := tls.Dial("tcp", l.Addr().String(), &tls.Config{InsecureSkipVerify: true, ServerName: "tenant.example.com"})
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, []string{"tenant.example.com"}, conn.ConnectionState().PeerCertificates[0].DNSNames)
+
+	_, err = conn.Write([]byte("OPTIONS sip:bob@tenant.example.com SIP/2.0\r\n" +
+		"Via: SIP/2.0/TLS 127.0.0.1:5061;branch=" + GenerateBranch() + "\r\n" +
+		"From: <sip:alice@127.0.0.1>;tag=abc\r\n" +
+		"To: <sip:bob@tenant.example.com>\r\n" +
+		"Call-ID: sni-test\r\n" +
+		"CSeq: 1 OPTIONS\r\n" +
+		"Content-Length: 0\r\n\r\n"))
+	require.NoError(t, err)
+
+	select {
+	case msg := <-msgs:
+		info := msg.(*Request).ConnectionInfo()
+		require.NotNil(t, info)
+		assert.Equal(t, "tenant.example.com", info.ServerName())
+	case <-time.After(time.Second):
+		t.Fatal("request not received")
+	}
+}
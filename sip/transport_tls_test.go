@@ -0,0 +1,89 @@
+package sip
+
+import (
+	"crypto/tls"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testTLSServe(t *testing.T) (*TransportLayer, net.Listener) {
+	tp := NewTransportLayer(net.DefaultResolver, NewParser(), nil)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	// Certificates are not needed as handshake never completes
+	tl := tls.NewListener(l, &tls.Config{})
+	go tp.ServeTLS(tl)
+	return tp, tl
+}
+
+func testConnClosed(t *testing.T, conn net.Conn) bool {
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 100)
+	for {
+		_, err := conn.Read(buf)
+		if err != nil {
+			if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+				return false
+			}
+			return true
+		}
+	}
+}
+
+func TestTransportTLSHandshakeProtection(t *testing.T) {
+	t.Run("Timeout", func(t *testing.T) {
+		defer func(d time.Duration) { TLSHandshakeTimeout = d }(TLSHandshakeTimeout)
+		TLSHandshakeTimeout = 100 * time.Millisecond
+
+		tp, l := testTLSServe(t)
+		defer tp.Close()
+		defer l.Close()
+
+		conn, err := net.Dial("tcp", l.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+		require.True(t, testConnClosed(t, conn))
+	})
+
+	t.Run("Plaintext", func(t *testing.T) {
+		tp, l := testTLSServe(t)
+		defer tp.Close()
+		defer l.Close()
+
+		conn, err := net.Dial("tcp", l.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = conn.Write([]byte("OPTIONS sip:bob@127.0.0.1 SIP/2.0\r\n\r\n"))
+		require.NoError(t, err)
+		require.True(t, testConnClosed(t, conn))
+	})
+
+	t.Run("ConcurrentLimit", func(t *testing.T) {
+		defer func(n int) { TLSMaxConcurrentHandshakes = n }(TLSMaxConcurrentHandshakes)
+		TLSMaxConcurrentHandshakes = 1
+
+		tp, l := testTLSServe(t)
+		defer tp.Close()
+		defer l.Close()
+
+		first, err := net.Dial("tcp", l.Addr().String())
+		require.NoError(t, err)
+		defer first.Close()
+		// Make sure first is accepted before second
+		time.Sleep(50 * time.Millisecond)
+
+		second, err := net.Dial("tcp", l.Addr().String())
+		require.NoError(t, err)
+		defer second.Close()
+		require.True(t, testConnClosed(t, second))
+
+		// First is still waiting on handshake
+		first.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		_, err = first.Read(make([]byte, 10))
+		nerr, ok := err.(net.Error)
+		require.True(t, ok && nerr.Timeout(), err)
+	})
+}
@@ -19,6 +19,10 @@ var (
 	// UDPUseConnectedConnection will force creating UDP connected connection
 	UDPUseConnectedConnection = false
 
+	// UDPTCPFallback sends requests over TCP when they are larger than UDPMTUSize-200,
+	// which by default is 1300 bytes. https://datatracker.ietf.org/doc/html/rfc3261#section-18.1.1
+	UDPTCPFallback = true
+
 	ErrUDPMTUCongestion = errors.New("size of packet larger than MTU")
 )
 
@@ -29,6 +33,18 @@ type transportUDP struct {
 
 	pool ConnectionPool
 	log  zerolog.Logger
+
+	// dialer and packetListener if set are used for outbound sockets
+	dialer         Dialer
+	packetListener PacketListener
+	// socketOpts are applied on outbound sockets without custom dialer or packet listener
+	socketOpts *SocketOptions
+
+	// stunTx are pending STUN binding requests by transaction id
+	stunTx sync.Map
+
+	// parseErrHandler is called with source address on parse failure
+	parseErrHandler func(src string) bool
 }
 
 func newUDPTransport(par *Parser) *transportUDP {
@@ -99,7 +115,10 @@ func (t *transportUDP) CreateConnection(ctx context.Context, laddr Addr, raddr A
 
 func (t *transportUDP) createConnection(ctx context.Context, laddr Addr, raddr Addr, handler MessageHandler) (Connection, error) {
 	laddrStr := laddr.String()
-	lc := &net.ListenConfig{}
+	var lc PacketListener = t.socketOpts.ListenConfig()
+	if t.packetListener != nil {
+		lc = t.packetListener
+	}
 	udpconn, err := lc.ListenPacket(ctx, "udp", laddrStr)
 	if err != nil {
 		return nil, err
@@ -154,8 +173,9 @@ func (t *transportUDP) createConnectedConnection(ctx context.Context, laddr Addr
 	// ex
 	// 192.168.... -> 127.0.0.1
 	// 192.168..... <- 192.168..  This will not work as connected connection can not handle this
-	d := net.Dialer{
-		LocalAddr: uladdr,
+	var d Dialer = t.socketOpts.Dialer(uladdr)
+	if t.dialer != nil {
+		d = t.dialer
 	}
 
 	addr := raddr.String()
@@ -181,6 +201,7 @@ func (t *transportUDP) createConnectedConnection(ctx context.Context, laddr Addr
 
 func (t *transportUDP) readListenerConnection(conn *UDPConnection, addr string, handler MessageHandler) {
 	buf := make([]byte, transportBufferSize)
+	laddr := conn.LocalAddr().String()
 	defer t.pool.CloseAndDelete(conn, addr)
 	defer t.log.Debug().Str("addr", addr).Msg("Read listener connection stopped")
 
@@ -207,6 +228,11 @@ func (t *transportUDP) readListenerConnection(conn *UDPConnection, addr string,
 		if len(bytes.Trim(data, "\x00")) == 0 {
 			continue
 		}
+		if isSTUNMessage(data) {
+			t.handleSTUN(data)
+			continue
+		}
+
 		rastr := raddr.String()
 		if lastRaddr != rastr {
 			// In most cases we are in single connection mode so no need to keep adding in pool
@@ -215,7 +241,7 @@ func (t *transportUDP) readListenerConnection(conn *UDPConnection, addr string,
 			acceptedAddr = append(acceptedAddr, rastr)
 		}
 
-		t.parseAndHandle(data, rastr, handler)
+		t.parseAndHandle(data, rastr, laddr, handler)
 		lastRaddr = rastr
 	}
 }
@@ -223,6 +249,7 @@ func (t *transportUDP) readListenerConnection(conn *UDPConnection, addr string,
 func (t *transportUDP) readConnectedConnection(conn *UDPConnection, handler MessageHandler) {
 	buf := make([]byte, transportBufferSize)
 	raddr := conn.Conn.RemoteAddr().String()
+	laddr := conn.Conn.LocalAddr().String()
 	defer t.pool.CloseAndDelete(conn, raddr)
 	defer t.log.Debug().Str("raddr", raddr).Msg("Read connected connection stopped")
 
@@ -242,7 +269,7 @@ func (t *transportUDP) readConnectedConnection(conn *UDPConnection, handler Mess
 			continue
 		}
 
-		t.parseAndHandle(data, raddr, handler)
+		t.parseAndHandle(data, raddr, laddr, handler)
 	}
 }
 
@@ -270,11 +297,11 @@ func (t *transportUDP) readUDPConn(conn *net.UDPConn, handler MessageHandler) {
 			continue
 		}
 
-		t.parseAndHandle(data, raddr.String(), handler)
+		t.parseAndHandle(data, raddr.String(), conn.LocalAddr().String(), handler)
 	}
 }
 
-func (t *transportUDP) parseAndHandle(data []byte, src string, handler MessageHandler) {
+func (t *transportUDP) parseAndHandle(data []byte, src string, laddr string, handler MessageHandler) {
 	// Check is keep alive
 	if len(data) <= 4 {
 		//One or 2 CRLF
@@ -287,13 +314,18 @@ func (t *transportUDP) parseAndHandle(data []byte, src string, handler MessageHa
 	msg, err := t.parser.ParseSIP(data) //Very expensive operation
 	if err != nil {
 		t.log.Error().Err(err).Str("data", string(data)).Msg("failed to parse")
+		if t.parseErrHandler != nil {
+			t.parseErrHandler(src)
+		}
 		return
 	}
 
 	msg.SetTransport(TransportUDP)
+	msg.SetReceivedTransport(TransportUDP)
 	// TODO should we avoid this and let source be inspected.
 	// Current transaction are taking connection but for UDP they can forward on different src address
 	msg.SetSource(src) // By default we expect our source is behind NAT. https://datatracker.ietf.org/doc/html/rfc3581#section-6
+	msg.SetLocalAddr(laddr)
 	handler(msg)
 }
 
@@ -415,14 +447,38 @@ func (c *UDPConnection) WriteTo(b []byte, addr net.Addr) (n int, err error) {
 	return n, err
 }
 
+// udpLargeConnection writes messages larger than MTU. It is used when TCP fallback
+// for large request fails and request is retried over UDP
+type udpLargeConnection struct {
+	*UDPConnection
+}
+
+func (c *udpLargeConnection) WriteMsg(msg Message) error {
+	// Max UDP payload. Message is fragmented by IP layer
+	return c.writeMsg(msg, 65507)
+}
+
+// messageSize returns size of message as written on wire
+func messageSize(msg Message) int {
+	buf := bufPool.Get().(*bytes.Buffer)
+	defer bufPool.Put(buf)
+	buf.Reset()
+	msg.StringWrite(buf)
+	return buf.Len()
+}
+
 func (c *UDPConnection) WriteMsg(msg Message) error {
+	return c.writeMsg(msg, UDPMTUSize-200)
+}
+
+func (c *UDPConnection) writeMsg(msg Message, maxSize int) error {
 	buf := bufPool.Get().(*bytes.Buffer)
 	defer bufPool.Put(buf)
 	buf.Reset()
 	msg.StringWrite(buf)
 	data := buf.Bytes()
 
-	if len(data) > UDPMTUSize-200 {
+	if len(data) > maxSize {
 		return ErrUDPMTUCongestion
 	}
 
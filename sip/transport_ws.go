@@ -32,6 +32,11 @@ type transportWS struct {
 
 	pool   ConnectionPool
 	dialer ws.Dialer
+
+	connStateHandler ConnectionStateHandler
+	// parseErrHandler is called with source address on parse failure.
+	// If it returns true source is blocked and connection is closed
+	parseErrHandler func(src string) bool
 }
 
 func newWSTransport(par *Parser) *transportWS {
@@ -115,13 +120,21 @@ func (t *transportWS) initConnection(conn net.Conn, addr string, clientSide bool
 		clientSide: clientSide,
 	}
 	t.pool.Add(addr, c)
-	go t.readConnection(c, addr, handler)
+
+	info := newConnectionInfo(t.transport, conn)
+	if t.connStateHandler != nil {
+		t.connStateHandler(info, ConnectionStateOpen)
+	}
+	go t.readConnection(c, addr, info, handler)
 	return c
 }
 
 // This should performe better to avoid any interface allocation
-func (t *transportWS) readConnection(conn *WSConnection, raddr string, handler MessageHandler) {
+func (t *transportWS) readConnection(conn *WSConnection, raddr string, info *ConnectionInfo, handler MessageHandler) {
 	buf := make([]byte, transportBufferSize)
+	if t.connStateHandler != nil {
+		defer t.connStateHandler(info, ConnectionStateClosed)
+	}
 	// defer conn.Close()
 	// defer t.pool.Del(raddr)
 	defer t.pool.CloseAndDelete(conn, raddr)
@@ -164,21 +177,29 @@ func (t *transportWS) readConnection(conn *WSConnection, raddr string, handler M
 			}
 		}
 
-		t.parseStream(par, data, raddr, handler)
+		t.parseStream(par, data, raddr, info, handler)
 	}
 
 }
 
 // TODO: Try to reuse this from TCP transport as func are same
-func (t *transportWS) parseStream(par *ParserStream, data []byte, src string, handler MessageHandler) {
+func (t *transportWS) parseStream(par *ParserStream, data []byte, src string, info *ConnectionInfo, handler MessageHandler) {
 	msg, err := t.parser.ParseSIP(data) //Very expensive operation
 	if err != nil {
 		t.log.Error().Err(err).Str("data", string(data)).Msg("failed to parse")
+		if t.parseErrHandler != nil && t.parseErrHandler(src) {
+			info.conn.Close()
+		}
 		return
 	}
 
 	msg.SetTransport(t.transport)
+	msg.SetReceivedTransport(t.transport)
 	msg.SetSource(src)
+	msg.SetLocalAddr(info.LocalAddr.String())
+	if req, ok := msg.(*Request); ok {
+		req.connInfo = info
+	}
 	handler(msg)
 }
 
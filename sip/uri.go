@@ -2,6 +2,7 @@ package sip
 
 import (
 	"io"
+	"net"
 	"strconv"
 	"strings"
 )
@@ -9,10 +10,13 @@ import (
 // Uri is parsed form of
 // sip:user:password@host:port;uri-parameters?headers
 // In case of `sips:“ Encrypted is set to true
+// In case of `tel:` Tel is set to true, number is in User and tel parameters in UriParams
 type Uri struct {
 	// True if and only if the URI is a SIPS URI.
 	Encrypted bool
 	Wildcard  bool
+	// True if URI is tel URI https://datatracker.ietf.org/doc/html/rfc3966
+	Tel bool
 
 	// The user part of the URI: the 'joe' in sip:joe@bloggs.com
 	// This is a pointer, so that URIs without a user part can have 'nil'.
@@ -51,6 +55,13 @@ func (uri *Uri) String() string {
 
 // StringWrite writes uri string to buffer
 func (uri *Uri) StringWrite(buffer io.StringWriter) {
+	if uri.Tel {
+		buffer.WriteString("tel:")
+		buffer.WriteString(uri.User)
+		telParamsWrite(buffer, uri.UriParams)
+		return
+	}
+
 	// Compulsory protocol identifier.
 	if uri.IsEncrypted() {
 		buffer.WriteString("sips")
@@ -71,7 +82,7 @@ func (uri *Uri) StringWrite(buffer io.StringWriter) {
 	}
 
 	// Compulsory hostname.
-	buffer.WriteString(uri.Host)
+	buffer.WriteString(hostBracket(uri.Host))
 
 	// Optional port number.
 	if uri.Port > 0 {
@@ -103,7 +114,10 @@ func (uri *Uri) IsEncrypted() bool {
 
 // Addr is uri address form. sip:user@host:port
 func (uri *Uri) Addr() string {
-	addr := uri.User + "@" + uri.Host
+	if uri.Tel {
+		return "tel:" + uri.User
+	}
+	addr := uri.User + "@" + hostBracket(uri.Host)
 	if uri.Port > 0 {
 		addr += ":" + strconv.Itoa(uri.Port)
 	}
@@ -117,5 +131,22 @@ func (uri *Uri) Addr() string {
 // HostPort represents host:port part
 func (uri *Uri) HostPort() string {
 	p := strconv.Itoa(uri.Port)
-	return uri.Host + ":" + p
+	return net.JoinHostPort(uri.Host, p)
+}
+
+// hostBracket encloses IPv6 literal in brackets as required for URI and Via host.
+// Host is kept unbracketed after parsing, so it can be passed to net package directly
+func hostBracket(host string) string {
+	if strings.IndexByte(host, ':') < 0 || strings.HasPrefix(host, "[") {
+		return host
+	}
+	return "[" + host + "]"
+}
+
+// hostUnbracket removes brackets from IPv6 reference
+func hostUnbracket(host string) string {
+	if len(host) > 1 && host[0] == '[' && host[len(host)-1] == ']' {
+		return host[1 : len(host)-1]
+	}
+	return host
 }
@@ -0,0 +1,110 @@
+package sip
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// uriHeadersIgnored are headers embedded in URI which are not honored when creating request
+// https://datatracker.ietf.org/doc/html/rfc3261#section-19.1.5
+var uriHeadersIgnored = map[string]struct{}{
+	"from":            {},
+	"to":              {},
+	"call-id":         {},
+	"cseq":            {},
+	"via":             {},
+	"record-route":    {},
+	"route":           {},
+	"max-forwards":    {},
+	"contact":         {},
+	"content-length":  {},
+	"accept":          {},
+	"accept-encoding": {},
+	"accept-language": {},
+	"allow":           {},
+	"organization":    {},
+	"supported":       {},
+	"user-agent":      {},
+}
+
+// EmbeddedHeaders returns headers embedded in URI with unescaped values.
+// Ex: sip:bob@example.com?Replaces=abc%40a.com%3Bto-tag%3D1&Require=replaces
+// Headers are sorted by name. Special "body" header is not returned, use EmbeddedBody
+func (uri *Uri) EmbeddedHeaders() []Header {
+	if len(uri.Headers) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(uri.Headers))
+	for k := range uri.Headers {
+		if strings.EqualFold(k, "body") {
+			continue
+		}
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	hdrs := make([]Header, 0, len(names))
+	for _, name := range names {
+		hdrs = append(hdrs, NewHeader(uriHeaderUnescape(name), uriHeaderUnescape(uri.Headers[name])))
+	}
+	return hdrs
+}
+
+// EmbeddedBody returns unescaped value of special "body" header embedded in URI
+func (uri *Uri) EmbeddedBody() ([]byte, bool) {
+	for k, v := range uri.Headers {
+		if strings.EqualFold(k, "body") {
+			return []byte(uriHeaderUnescape(v)), true
+		}
+	}
+	return nil, false
+}
+
+// NewRequestFromUri creates request from URI with embedded headers. Headers are removed from Request-URI
+// and materialized into request. Dangerous headers like From, Call-ID, CSeq, Via, Route or headers describing
+// own capabilities are not honored. Embedded body sets request body
+// https://datatracker.ietf.org/doc/html/rfc3261#section-19.1.5
+func NewRequestFromUri(method RequestMethod, uri Uri) *Request {
+	recipient := uri
+	recipient.Headers = nil
+	req := NewRequest(method, &recipient)
+
+	for _, h := range uri.EmbeddedHeaders() {
+		if _, ignore := uriHeadersIgnored[HeaderToLower(h.Name())]; ignore {
+			continue
+		}
+		req.AppendHeader(h)
+	}
+
+	if body, ok := uri.EmbeddedBody(); ok {
+		req.SetBody(body)
+	}
+	return req
+}
+
+// ParseReferTo parses Refer-To header of request into uri. Embedded headers like Replaces are kept in uri Headers
+// https://datatracker.ietf.org/doc/html/rfc3515#section-2.1
+func ParseReferTo(req *Request, uri *Uri) error {
+	h := req.GetHeader("Refer-To")
+	if h == nil {
+		return fmt.Errorf("missing Refer-To header")
+	}
+
+	params := NewParams()
+	if _, err := ParseAddressValue(h.Value(), uri, params); err != nil {
+		return fmt.Errorf("parsing Refer-To failed: %w", err)
+	}
+	return nil
+}
+
+func uriHeaderUnescape(s string) string {
+	// + is not space in SIP URI
+	v, err := url.PathUnescape(s)
+	if err != nil {
+		return s
+	}
+	return v
+}
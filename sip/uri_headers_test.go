@@ -0,0 +1,52 @@
+package sip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUriEmbeddedHeaders(t *testing.T) {
+	req := NewRequest(REFER, &Uri{User: "alice", Host: "example.com"})
+	req.AppendHeader(NewHeader("Refer-To", "<sip:bob@example.com?Replaces=abc%40a.com%3Bto-tag%3D1%3Bfrom-tag%3D2&Require=replaces&From=evil%40x.com&Route=%3Csip:evil.com%3E&body=hello>"))
+
+	var uri Uri
+	require.NoError(t, ParseReferTo(req, &uri))
+	assert.Equal(t, "bob", uri.User)
+	assert.Equal(t, "example.com", uri.Host)
+
+	hdrs := uri.EmbeddedHeaders()
+	require.Len(t, hdrs, 4)
+	assert.Equal(t, "From: evil@x.com", hdrs[0].String())
+	assert.Equal(t, "Replaces: abc@a.com;to-tag=1;from-tag=2", hdrs[1].String())
+	assert.Equal(t, "Require: replaces", hdrs[2].String())
+	assert.Equal(t, "Route: <sip:evil.com>", hdrs[3].String())
+
+	invite := NewRequestFromUri(INVITE, uri)
+	assert.Equal(t, "sip:bob@example.com", invite.Recipient.String())
+	assert.Equal(t, "abc@a.com;to-tag=1;from-tag=2", invite.GetHeader("Replaces").Value())
+	assert.Equal(t, "replaces", invite.GetHeader("Require").Value())
+	assert.Nil(t, invite.From())
+	assert.Nil(t, invite.GetHeader("Route"))
+	assert.Equal(t, "hello", string(invite.Body()))
+
+	// Original uri is untouched
+	assert.Len(t, uri.Headers, 5)
+}
+
+func TestUriEmbeddedHeadersPlus(t *testing.T) {
+	uri := Uri{User: "bob", Host: "example.com", Headers: HeaderParams{"Subject": "a+b%20c"}}
+	hdrs := uri.EmbeddedHeaders()
+	require.Len(t, hdrs, 1)
+	assert.Equal(t, "a+b c", hdrs[0].Value())
+
+	_, ok := uri.EmbeddedBody()
+	assert.False(t, ok)
+}
+
+func TestParseReferToMissing(t *testing.T) {
+	req := NewRequest(REFER, &Uri{User: "alice", Host: "example.com"})
+	var uri Uri
+	assert.Error(t, ParseReferTo(req, &uri))
+}
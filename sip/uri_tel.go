@@ -0,0 +1,291 @@
+package sip
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// TelUri is parsed form of tel URI https://datatracker.ietf.org/doc/html/rfc3966
+// tel:+1-201-555-0123;ext=1234
+type TelUri struct {
+	// Number is global number with leading + or local number. Visual separators are kept
+	Number string
+	// Params are tel URI parameters like ext, isub, phone-context
+	Params HeaderParams
+}
+
+// ParseTelUri converts a string representation of tel URI into TelUri and validates it
+func ParseTelUri(s string, tel *TelUri) error {
+	if len(s) < 4 || !strings.EqualFold(s[:4], "tel:") {
+		return errors.New("missing tel scheme")
+	}
+
+	number, params := splitPhoneParams(s[4:])
+	if number == "" {
+		return errors.New("empty tel number")
+	}
+	tel.Number = number
+	tel.Params = params
+	return tel.Validate()
+}
+
+// Validate checks number and parameters following RFC 3966 syntax.
+// Local number must have phone-context, which is domain name or global number prefix.
+// https://datatracker.ietf.org/doc/html/rfc3966#section-5.1.5
+func (t *TelUri) Validate() error {
+	context, hasContext := t.Params.Get("phone-context")
+	if t.IsGlobal() {
+		if !telGlobalDigits(t.Number) {
+			return fmt.Errorf("invalid global tel number %q", t.Number)
+		}
+		if hasContext {
+			return errors.New("phone-context not allowed for global tel number")
+		}
+	} else {
+		if !telLocalDigits(t.Number) {
+			return fmt.Errorf("invalid local tel number %q", t.Number)
+		}
+		if !hasContext {
+			return errors.New("missing phone-context for local tel number")
+		}
+		if !telGlobalDigits(context) && !telDomainName(context) {
+			return fmt.Errorf("invalid phone-context %q", context)
+		}
+	}
+
+	ext, hasExt := t.Params.Get("ext")
+	if hasExt && !telExtDigits(ext) {
+		return fmt.Errorf("invalid ext %q", ext)
+	}
+	isub, hasIsub := t.Params.Get("isub")
+	if hasIsub {
+		if hasExt {
+			return errors.New("ext and isub are mutually exclusive")
+		}
+		if isub == "" {
+			return errors.New("empty isub")
+		}
+	}
+	return nil
+}
+
+// String returns tel URI
+func (t *TelUri) String() string {
+	var sb strings.Builder
+	sb.WriteString("tel:")
+	sb.WriteString(t.Number)
+	telParamsWrite(&sb, t.Params)
+	return sb.String()
+}
+
+// IsGlobal reports is number global E.164 number
+func (t *TelUri) IsGlobal() bool {
+	return strings.HasPrefix(t.Number, "+")
+}
+
+// Uri returns tel URI as Uri, which can be used as Request-URI or To/From address
+func (t *TelUri) Uri() Uri {
+	return Uri{
+		Tel:       true,
+		User:      t.Number,
+		UriParams: t.Params.clone(),
+		Headers:   NewParams(),
+	}
+}
+
+// SipUri converts tel URI to SIP URI with user=phone on host.
+// Tel parameters are placed in user part https://datatracker.ietf.org/doc/html/rfc3261#section-19.1.6
+func (t *TelUri) SipUri(host string) Uri {
+	var sb strings.Builder
+	sb.WriteString(t.Number)
+	telParamsWrite(&sb, t.Params)
+	return Uri{
+		User:      sb.String(),
+		Host:      host,
+		UriParams: HeaderParams{"user": "phone"},
+	}
+}
+
+// IsPhone reports is URI tel URI or user part is telephone number with user=phone parameter
+func (uri *Uri) IsPhone() bool {
+	if uri.Tel {
+		return true
+	}
+	v, _ := uri.UriParams.Get("user")
+	return strings.EqualFold(v, "phone")
+}
+
+// PhoneNumber returns telephone number from user part without parameters and visual separators.
+// It returns false if URI is not tel or user=phone
+func (uri *Uri) PhoneNumber() (string, bool) {
+	if !uri.IsPhone() {
+		return "", false
+	}
+	number, _ := splitPhoneParams(uri.User)
+	return NormalizeDialString(number), true
+}
+
+// TelUri converts tel or user=phone URI to tel URI. Parameters in user part become tel parameters
+func (uri *Uri) TelUri() (TelUri, bool) {
+	if uri.Tel {
+		return TelUri{Number: uri.User, Params: uri.UriParams.clone()}, true
+	}
+	if !uri.IsPhone() {
+		return TelUri{}, false
+	}
+	number, params := splitPhoneParams(uri.User)
+	return TelUri{Number: number, Params: params}, true
+}
+
+// NewPhoneUri creates SIP URI with user=phone from dial string. Visual separators are removed
+// Ex: NewPhoneUri("+1 (201) 555-0123", "gw.example.com") -> sip:+12015550123@gw.example.com;user=phone
+func NewPhoneUri(dial string, host string) Uri {
+	return Uri{
+		User:      NormalizeDialString(dial),
+		Host:      host,
+		UriParams: HeaderParams{"user": "phone"},
+	}
+}
+
+// NormalizeDialString removes visual separators and whitespace from dial string.
+// Leading + is kept. Ex: "+1 (201) 555-0123" -> "+12015550123"
+// https://datatracker.ietf.org/doc/html/rfc3966#section-5.1.1
+func NormalizeDialString(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '-', '.', '(', ')', ' ', '\t':
+			continue
+		case '+':
+			if sb.Len() > 0 {
+				continue
+			}
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// splitPhoneParams splits number;param=value;param2 form
+func splitPhoneParams(s string) (string, HeaderParams) {
+	number, rest, found := strings.Cut(s, ";")
+	if !found {
+		return number, nil
+	}
+
+	params := NewParams()
+	for _, p := range strings.Split(rest, ";") {
+		if p == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(p, "=")
+		params.Add(k, v)
+	}
+	return number, params
+}
+
+// telParamsWrite writes params in order isub or ext, phone-context, then others sorted
+// https://datatracker.ietf.org/doc/html/rfc3966#section-3
+func telParamsWrite(sb io.StringWriter, params HeaderParams) {
+	if len(params) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		switch k {
+		case "isub", "ext", "phone-context":
+		default:
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	keys = append([]string{"isub", "ext", "phone-context"}, keys...)
+
+	for _, k := range keys {
+		v, ok := params[k]
+		if !ok {
+			continue
+		}
+		sb.WriteString(";")
+		sb.WriteString(k)
+		if v != "" {
+			sb.WriteString("=")
+			sb.WriteString(v)
+		}
+	}
+}
+
+// telGlobalDigits checks "+" *phonedigit DIGIT *phonedigit
+func telGlobalDigits(s string) bool {
+	if len(s) < 2 || s[0] != '+' {
+		return false
+	}
+	digit := false
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digit = true
+		case telVisualSeparator(c):
+		default:
+			return false
+		}
+	}
+	return digit
+}
+
+// telLocalDigits checks *phonedigit-hex (HEXDIG / "*" / "#") *phonedigit-hex
+func telLocalDigits(s string) bool {
+	digit := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9', c >= 'a' && c <= 'f', c >= 'A' && c <= 'F', c == '*', c == '#':
+			digit = true
+		case telVisualSeparator(c):
+		default:
+			return false
+		}
+	}
+	return digit
+}
+
+// telExtDigits checks 1*phonedigit
+func telExtDigits(s string) bool {
+	digit := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digit = true
+		case telVisualSeparator(c):
+		default:
+			return false
+		}
+	}
+	return digit
+}
+
+func telVisualSeparator(c byte) bool {
+	return c == '-' || c == '.' || c == '(' || c == ')'
+}
+
+// telDomainName checks domainname of phone-context descriptor
+func telDomainName(s string) bool {
+	if s == "" || s[0] == '.' || s[0] == '-' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package sip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelUri(t *testing.T) {
+	var tel TelUri
+	require.NoError(t, ParseTelUri("tel:+1-201-555-0123;ext=1234", &tel))
+	assert.Equal(t, "+1-201-555-0123", tel.Number)
+	assert.True(t, tel.IsGlobal())
+	assert.Equal(t, "tel:+1-201-555-0123;ext=1234", tel.String())
+
+	uri := tel.SipUri("gw.example.com")
+	assert.Equal(t, "sip:+1-201-555-0123;ext=1234@gw.example.com;user=phone", uri.String())
+
+	// Back from parsed SIP URI
+	var parsed Uri
+	require.NoError(t, ParseUri(uri.String(), &parsed))
+	assert.True(t, parsed.IsPhone())
+	number, ok := parsed.PhoneNumber()
+	require.True(t, ok)
+	assert.Equal(t, "+12015550123", number)
+	back, ok := parsed.TelUri()
+	require.True(t, ok)
+	assert.Equal(t, tel.String(), back.String())
+
+	// Local number params order
+	require.NoError(t, ParseTelUri("tel:7042;phone-context=example.com;isub=11", &tel))
+	assert.False(t, tel.IsGlobal())
+	assert.Equal(t, "tel:7042;isub=11;phone-context=example.com", tel.String())
+
+	require.Error(t, ParseTelUri("sip:alice@example.com", &tel))
+	require.Error(t, ParseTelUri("tel:;ext=1", &tel))
+
+	parsed = Uri{User: "alice", Host: "example.com"}
+	_, ok = parsed.PhoneNumber()
+	assert.False(t, ok)
+}
+
+func TestDialString(t *testing.T) {
+	assert.Equal(t, "+12015550123", NormalizeDialString("+1 (201) 555-0123"))
+	assert.Equal(t, "2015550123", NormalizeDialString("201.555.0123"))
+	assert.Equal(t, "*72#", NormalizeDialString("*72#"))
+
+	uri := NewPhoneUri("+1 (201) 555-0123", "gw.example.com")
+	assert.Equal(t, "sip:+12015550123@gw.example.com;user=phone", uri.String())
+}
+
+func TestTelUriValidate(t *testing.T) {
+	valid := []string{
+		"tel:+1-201-555-0123",
+		"tel:+1(201)555.0123;ext=12-34",
+		"tel:863-1234;phone-context=+1-914-555",
+		"tel:*72#;phone-context=example.com",
+		"tel:7042;phone-context=example.com;isub=11",
+	}
+	for _, s := range valid {
+		var tel TelUri
+		assert.NoError(t, ParseTelUri(s, &tel), s)
+	}
+
+	invalid := []string{
+		"tel:+",
+		"tel:+1-201-555-0123x",
+		"tel:+1-201-555-0123;phone-context=example.com",
+		"tel:7042",
+		"tel:7042;phone-context=",
+		"tel:7042;phone-context=exa_mple.com",
+		"tel:+12015550123;ext=abc",
+		"tel:+12015550123;ext=1;isub=2",
+		"tel:+12015550123;isub",
+	}
+	for _, s := range invalid {
+		var tel TelUri
+		assert.Error(t, ParseTelUri(s, &tel), s)
+	}
+}
+
+func TestTelUriScheme(t *testing.T) {
+	var uri Uri
+	require.NoError(t, ParseUri("tel:7042;phone-context=example.com;isub=11", &uri))
+	assert.True(t, uri.Tel)
+	assert.True(t, uri.IsPhone())
+	assert.Equal(t, "7042", uri.User)
+	assert.Equal(t, "", uri.Host)
+	assert.Equal(t, "tel:7042;isub=11;phone-context=example.com", uri.String())
+	assert.Equal(t, "tel:7042", uri.Addr())
+
+	tel, ok := uri.TelUri()
+	require.True(t, ok)
+	require.NoError(t, tel.Validate())
+	sipUri := tel.SipUri("gw.example.com")
+	assert.Equal(t, "sip:7042;isub=11;phone-context=example.com@gw.example.com;user=phone", sipUri.String())
+
+	back := tel.Uri()
+	assert.Equal(t, uri.String(), back.String())
+
+	require.Error(t, ParseUri("tel:;ext=1", &Uri{}))
+
+	t.Run("Message", func(t *testing.T) {
+		msg := "INVITE tel:+1-201-555-0123 SIP/2.0\r\n" +
+			"Via: SIP/2.0/UDP 10.0.0.1:5060;branch=z9hG4bK776asdhds\r\n" +
+			"To: <tel:+1-201-555-0123>\r\n" +
+			"From: \"Alice\" <tel:+1-201-555-0100>;tag=1928301774\r\n" +
+			"Call-ID: a84b4c76e66710\r\n" +
+			"CSeq: 1 INVITE\r\n" +
+			"Content-Length: 0\r\n\r\n"
+		m, err := ParseMessage([]byte(msg))
+		require.NoError(t, err)
+		req := m.(*Request)
+		assert.True(t, req.Recipient.Tel)
+		assert.Equal(t, "+1-201-555-0123", req.Recipient.User)
+		assert.True(t, req.To().Address.Tel)
+		number, ok := req.From().Address.PhoneNumber()
+		require.True(t, ok)
+		assert.Equal(t, "+12015550100", number)
+		assert.Equal(t, msg, req.String())
+	})
+}
@@ -211,11 +211,13 @@ func findAnyUnescaped(text string, targets string, delims ...delimiter) int {
 }
 
 // Forked from github.com/StefanKopieczek/gossip by @StefanKopieczek
+// IPv4 address is preferred. On IPv6-only host global unicast IPv6 address is returned
 func ResolveSelfIP() (net.IP, error) {
 	ifaces, err := net.Interfaces()
 	if err != nil {
 		return nil, err
 	}
+	var ip6 net.IP
 	for _, iface := range ifaces {
 		if iface.Flags&net.FlagUp == 0 {
 			continue // interface down
@@ -238,13 +240,18 @@ func ResolveSelfIP() (net.IP, error) {
 			if ip == nil || ip.IsLoopback() {
 				continue
 			}
-			ip = ip.To4()
-			if ip == nil {
+			if ip.To4() == nil {
+				if ip6 == nil && ip.IsGlobalUnicast() {
+					ip6 = ip
+				}
 				continue // not an ipv4 address
 			}
-			return ip, nil
+			return ip.To4(), nil
 		}
 	}
+	if ip6 != nil {
+		return ip6, nil
+	}
 	return nil, errors.New("server not connected to any network")
 }
 
@@ -0,0 +1,96 @@
+package sipgo
+
+import (
+	"errors"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+var (
+	ErrSIPSInsecureTransport = errors.New("sips request must be sent over TLS")
+)
+
+// SIPSPolicy decides which Request-URI schemes server accepts.
+// Rejected requests are answered with 416 Unsupported URI Scheme
+// https://datatracker.ietf.org/doc/html/rfc5630
+type SIPSPolicy int
+
+const (
+	// SIPSPolicyNone accepts any scheme over any transport
+	SIPSPolicyNone SIPSPolicy = iota
+	// SIPSPolicySecure rejects sips Request-URI received over transport other than TLS or WSS
+	SIPSPolicySecure
+	// SIPSPolicyRequire rejects any Request-URI which is not sips received over TLS or WSS
+	SIPSPolicyRequire
+	// SIPSPolicyReject rejects sips Request-URI, for servers without TLS listener
+	SIPSPolicyReject
+)
+
+// WithServerSIPSPolicy sets policy for sips Request-URI. Default is SIPSPolicyNone
+func WithServerSIPSPolicy(policy SIPSPolicy) ServerOption {
+	return func(s *Server) error {
+		s.sipsPolicy = policy
+		return nil
+	}
+}
+
+// sipsRejected applies SIPS policy on request.
+// Returns true if request is rejected and should not be passed further
+func (srv *Server) sipsRejected(req *sip.Request, tx sip.ServerTransaction) bool {
+	if srv.sipsPolicy == SIPSPolicyNone || req.IsAck() || tx == nil || req.Recipient == nil {
+		return false
+	}
+
+	secure := req.Recipient.IsEncrypted()
+	var reject bool
+	switch srv.sipsPolicy {
+	case SIPSPolicySecure:
+		reject = secure && !sip.IsSecure(req.Transport())
+	case SIPSPolicyRequire:
+		reject = !secure || !sip.IsSecure(req.Transport())
+	case SIPSPolicyReject:
+		reject = secure
+	}
+	if !reject {
+		return false
+	}
+
+	req.Trail().Add("sips", "rejected by policy")
+	srv.log.Info().Str("uri", req.Recipient.String()).Str("transport", req.Transport()).Msg("Request rejected by SIPS policy")
+	res := sip.NewResponseFromRequest(req, sip.StatusUnsupportedURIScheme, "Unsupported URI Scheme", nil)
+	if err := tx.Respond(res); err != nil {
+		srv.log.Error().Err(err).Msg("Failed to respond on SIPS policy rejection")
+	}
+	return true
+}
+
+// sipsRequired reports must request be sent over TLS and carry sips Contact,
+// which is when Request-URI or top Route is sips https://datatracker.ietf.org/doc/html/rfc3261#section-8.1.1.8
+func sipsRequired(req *sip.Request) bool {
+	if req.Recipient != nil && req.Recipient.IsEncrypted() {
+		return true
+	}
+	if h := req.Route(); h != nil && h.Address.IsEncrypted() {
+		return true
+	}
+	return false
+}
+
+// sipsCheckTransport returns error if sips request would be sent over insecure transport
+func sipsCheckTransport(req *sip.Request) error {
+	if sipsRequired(req) && !sip.IsSecure(req.Transport()) {
+		return ErrSIPSInsecureTransport
+	}
+	return nil
+}
+
+// sipsContact returns contact with sips scheme in case request requires it.
+// Passed contact is returned when no change is needed
+func sipsContact(req *sip.Request, contact *sip.ContactHeader) *sip.ContactHeader {
+	if contact.Address.IsEncrypted() || !sipsRequired(req) {
+		return contact
+	}
+	cont := contact.Clone()
+	cont.Address.Encrypted = true
+	return cont
+}
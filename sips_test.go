@@ -0,0 +1,101 @@
+package sipgo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerSIPSPolicy(t *testing.T) {
+	ua, _ := NewUA()
+	defer ua.Close()
+
+	invite := func(t *testing.T, policy SIPSPolicy, uri string, transport string) sip.StatusCode {
+		srv, err := NewServer(ua, WithServerSIPSPolicy(policy))
+		require.NoError(t, err)
+		srv.OnInvite(func(req *sip.Request, tx sip.ServerTransaction) {
+			tx.Respond(sip.NewResponseFromRequest(req, sip.StatusOK, "OK", nil))
+		})
+
+		req, _, _ := createTestInvite(t, uri, transport, "127.0.0.2:5061")
+		req.SetTransport(transport)
+		tx := siptest.NewServerTxRecorder(req)
+		srv.handleRequest(req, tx)
+		require.Len(t, tx.Result(), 1)
+		return tx.Result()[0].StatusCode
+	}
+
+	assert.Equal(t, sip.StatusOK, invite(t, SIPSPolicyNone, "sips:bob@127.0.0.1", "UDP"))
+
+	assert.Equal(t, sip.StatusOK, invite(t, SIPSPolicySecure, "sips:bob@127.0.0.1", "TLS"))
+	assert.Equal(t, sip.StatusOK, invite(t, SIPSPolicySecure, "sip:bob@127.0.0.1", "UDP"))
+	assert.Equal(t, sip.StatusUnsupportedURIScheme, invite(t, SIPSPolicySecure, "sips:bob@127.0.0.1", "TCP"))
+
+	assert.Equal(t, sip.StatusOK, invite(t, SIPSPolicyRequire, "sips:bob@127.0.0.1", "WSS"))
+	assert.Equal(t, sip.StatusUnsupportedURIScheme, invite(t, SIPSPolicyRequire, "sip:bob@127.0.0.1", "TLS"))
+
+	assert.Equal(t, sip.StatusOK, invite(t, SIPSPolicyReject, "sip:bob@127.0.0.1", "TLS"))
+	assert.Equal(t, sip.StatusUnsupportedURIScheme, invite(t, SIPSPolicyReject, "sips:bob@127.0.0.1", "TLS"))
+}
+
+func TestClientSIPS(t *testing.T) {
+	ua, err := NewUA()
+	require.NoError(t, err)
+	defer ua.Close()
+
+	c, err := NewClient(ua, WithClientHostname("10.0.0.0"))
+	require.NoError(t, err)
+
+	t.Run("Transport", func(t *testing.T) {
+		req := sip.NewRequest(sip.OPTIONS, &sip.Uri{User: "bob", Host: "10.2.2.2", Encrypted: true})
+		assert.Equal(t, "TLS", req.Transport())
+
+		req = sip.NewRequest(sip.OPTIONS, &sip.Uri{User: "bob", Host: "10.2.2.2", Encrypted: true})
+		req.SetTransport("UDP")
+		_, err := c.TransactionRequest(context.Background(), req)
+		require.ErrorIs(t, err, ErrSIPSInsecureTransport)
+		require.ErrorIs(t, c.WriteRequest(req), ErrSIPSInsecureTransport)
+	})
+
+	t.Run("RecordRoute", func(t *testing.T) {
+		sender := sip.Uri{User: "alice", Host: "10.1.1.1", Port: 5061}
+		recipient := sip.Uri{User: "bob", Host: "10.2.2.2", Port: 5061, Encrypted: true}
+
+		req := createSimpleRequest(sip.INVITE, sender, recipient, "TLS")
+		req.SetTransport("TLS")
+		require.NoError(t, ClientRequestAddRecordRoute(c, req))
+		assert.True(t, req.RecordRoute().Address.IsEncrypted())
+
+		recipient.Encrypted = false
+		req = createSimpleRequest(sip.INVITE, sender, recipient, "TLS")
+		req.SetTransport("TLS")
+		require.NoError(t, ClientRequestAddRecordRoute(c, req))
+		assert.False(t, req.RecordRoute().Address.IsEncrypted())
+	})
+
+	t.Run("Contact", func(t *testing.T) {
+		contact := &sip.ContactHeader{Address: sip.Uri{User: "alice", Host: "10.0.0.0"}}
+		req := sip.NewRequest(sip.INVITE, &sip.Uri{User: "bob", Host: "10.2.2.2", Encrypted: true})
+		cont := sipsContact(req, contact)
+		assert.Equal(t, "<sips:alice@10.0.0.0>", cont.Value())
+		assert.False(t, contact.Address.IsEncrypted())
+
+		// Top Route is sips
+		req = sip.NewRequest(sip.INVITE, &sip.Uri{User: "bob", Host: "10.2.2.2"})
+		req.AppendHeader(&sip.RouteHeader{Address: sip.Uri{Host: "proxy.example.com", Encrypted: true, UriParams: sip.HeaderParams{"lr": ""}}})
+		assert.True(t, sipsContact(req, contact).Address.IsEncrypted())
+
+		req = sip.NewRequest(sip.INVITE, &sip.Uri{User: "bob", Host: "10.2.2.2"})
+		assert.Same(t, contact, sipsContact(req, contact))
+
+		// Dialog server answers sips INVITE with sips contact
+		ds := NewDialogServer(c, *contact)
+		invite, _, _ := createTestInvite(t, "sips:bob@10.0.0.0", "TLS", "10.1.1.1:5061")
+		assert.True(t, ds.contactFor(invite).Address.IsEncrypted())
+	})
+}
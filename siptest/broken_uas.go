@@ -0,0 +1,231 @@
+package siptest
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Fault is misbehavior of BrokenUAS when answering INVITE
+type Fault int
+
+const (
+	// FaultNone answers INVITE correctly
+	FaultNone Fault = iota
+	// FaultNoToTag answers with 200 without To tag, so dialog can not be created
+	FaultNoToTag
+	// FaultForked2xx answers with two 200 responses with different To tags, like forking proxy
+	FaultForked2xx
+	// FaultBadContentLength answers with 200 having Content-Length larger than body
+	FaultBadContentLength
+	// FaultUnackable2xx answers with 200 which is retransmitted ignoring ACK
+	FaultUnackable2xx
+)
+
+func (f Fault) String() string {
+	switch f {
+	case FaultNone:
+		return "None"
+	case FaultNoToTag:
+		return "NoToTag"
+	case FaultForked2xx:
+		return "Forked2xx"
+	case FaultBadContentLength:
+		return "BadContentLength"
+	case FaultUnackable2xx:
+		return "Unackable2xx"
+	default:
+		return "Unknown Fault"
+	}
+}
+
+// BrokenUAS is UDP UAS answering INVITE with injected fault, for testing client and dialog robustness
+// against broken peers. It works without transaction layer, so every request is answered as received.
+// Other requests like BYE or CANCEL are answered with 200 and ACK is only recorded
+// Ex:
+//
+//	uas, _ := siptest.NewBrokenUAS("127.0.0.1:0", siptest.FaultForked2xx)
+//	defer uas.Close()
+//	dialog, _ := dialogClient.Invite(ctx, &sip.Uri{Host: "127.0.0.1", Port: uas.Port()}, nil)
+type BrokenUAS struct {
+	conn net.PacketConn
+
+	fault       atomic.Int32
+	retransmits int
+	interval    time.Duration
+	body        []byte
+	log         zerolog.Logger
+
+	mu       sync.Mutex
+	requests []*sip.Request
+
+	wg sync.WaitGroup
+}
+
+type BrokenUASOption func(u *BrokenUAS)
+
+// WithBrokenUASRetransmits sets number and interval of 2xx retransmissions for FaultUnackable2xx. Default is 3 every T1
+func WithBrokenUASRetransmits(n int, interval time.Duration) BrokenUASOption {
+	return func(u *BrokenUAS) {
+		u.retransmits = n
+		u.interval = interval
+	}
+}
+
+// WithBrokenUASBody sets body of 2xx response
+func WithBrokenUASBody(body []byte) BrokenUASOption {
+	return func(u *BrokenUAS) {
+		u.body = body
+	}
+}
+
+// NewBrokenUAS listens UDP on addr and starts answering requests with fault
+func NewBrokenUAS(addr string, fault Fault, options ...BrokenUASOption) (*BrokenUAS, error) {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &BrokenUAS{
+		conn:        conn,
+		retransmits: 3,
+		interval:    sip.GetTimers().T1,
+		log:         log.Logger.With().Str("caller", "BrokenUAS").Logger(),
+	}
+	u.fault.Store(int32(fault))
+	for _, o := range options {
+		o(u)
+	}
+
+	u.wg.Add(1)
+	go u.serve()
+	return u, nil
+}
+
+// Addr returns listening address
+func (u *BrokenUAS) Addr() string {
+	return u.conn.LocalAddr().String()
+}
+
+// Port returns listening port
+func (u *BrokenUAS) Port() int {
+	return u.conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+// SetFault changes fault for next INVITE
+func (u *BrokenUAS) SetFault(f Fault) {
+	u.fault.Store(int32(f))
+}
+
+// Requests returns received requests, including retransmissions and ACK
+func (u *BrokenUAS) Requests(method sip.RequestMethod) []*sip.Request {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	var reqs []*sip.Request
+	for _, r := range u.requests {
+		if r.Method == method {
+			reqs = append(reqs, r)
+		}
+	}
+	return reqs
+}
+
+// Close stops UAS
+func (u *BrokenUAS) Close() error {
+	err := u.conn.Close()
+	u.wg.Wait()
+	return err
+}
+
+func (u *BrokenUAS) serve() {
+	defer u.wg.Done()
+	buf := make([]byte, 65535)
+	for {
+		n, raddr, err := u.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		msg, err := sip.ParseMessage(buf[:n])
+		if err != nil {
+			u.log.Info().Err(err).Msg("Failed to parse message")
+			continue
+		}
+		req, ok := msg.(*sip.Request)
+		if !ok {
+			continue
+		}
+
+		u.mu.Lock()
+		u.requests = append(u.requests, req)
+		u.mu.Unlock()
+
+		switch req.Method {
+		case sip.ACK:
+		case sip.INVITE:
+			u.wg.Add(1)
+			go func() {
+				defer u.wg.Done()
+				u.answerInvite(req, raddr)
+			}()
+		default:
+			u.write(sip.NewResponseFromRequest(req, sip.StatusOK, "OK", nil), raddr)
+		}
+	}
+}
+
+func (u *BrokenUAS) answerInvite(req *sip.Request, raddr net.Addr) {
+	u.write(sip.NewResponseFromRequest(req, sip.StatusTrying, "Trying", nil), raddr)
+
+	res := sip.NewResponseFromRequest(req, sip.StatusOK, "OK", u.body)
+	res.AppendHeader(&sip.ContactHeader{Address: sip.Uri{Host: "127.0.0.1", Port: u.Port()}})
+	if u.body != nil {
+		res.AppendHeader(sip.NewHeader("Content-Type", "application/sdp"))
+	}
+
+	switch Fault(u.fault.Load()) {
+	case FaultNoToTag:
+		res.To().Params.Remove("tag")
+		u.write(res, raddr)
+	case FaultForked2xx:
+		u.write(res, raddr)
+		fork := res.Clone()
+		fork.To().Params.Add("tag", sip.GenerateTagN(16))
+		u.write(fork, raddr)
+	case FaultBadContentLength:
+		data := res.String()
+		cl := "Content-Length: " + strconv.Itoa(len(u.body))
+		data = strings.Replace(data, cl, "Content-Length: "+strconv.Itoa(len(u.body)+100), 1)
+		u.writeRaw([]byte(data), raddr)
+	case FaultUnackable2xx:
+		u.write(res, raddr)
+		for i := 0; i < u.retransmits; i++ {
+			time.Sleep(u.interval)
+			if err := u.write(res, raddr); err != nil {
+				return
+			}
+		}
+	default:
+		u.write(res, raddr)
+	}
+}
+
+func (u *BrokenUAS) write(res *sip.Response, raddr net.Addr) error {
+	return u.writeRaw([]byte(res.String()), raddr)
+}
+
+func (u *BrokenUAS) writeRaw(data []byte, raddr net.Addr) error {
+	_, err := u.conn.WriteTo(data, raddr)
+	if err != nil {
+		u.log.Debug().Err(err).Msg("Failed to write response")
+	}
+	return err
+}
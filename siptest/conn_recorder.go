@@ -2,12 +2,14 @@ package siptest
 
 import (
 	"net"
+	"sync"
 	"sync/atomic"
 
 	"github.com/emiago/sipgo/sip"
 )
 
 type connRecorder struct {
+	mu   sync.Mutex
 	msgs []sip.Message
 
 	ref atomic.Int32
@@ -22,7 +24,9 @@ func (c *connRecorder) LocalAddr() net.Addr {
 }
 
 func (c *connRecorder) WriteMsg(msg sip.Message) error {
+	c.mu.Lock()
 	c.msgs = append(c.msgs, msg)
+	c.mu.Unlock()
 	return nil
 }
 func (c *connRecorder) Ref(i int) int {
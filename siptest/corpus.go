@@ -0,0 +1,178 @@
+package siptest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+// CorpusExt is extension of message files in corpus directory
+const CorpusExt = ".sip"
+
+// RoundTrip parses message, serializes it and parses serialized message again.
+// It fails if any of steps fail or message is not same after round trip, for ex. header or body is lost or changed.
+// Order of header params is not compared, as it is not kept by parser.
+// Data must be message as on wire, with CRLF line endings
+func RoundTrip(data []byte) (sip.Message, error) {
+	msg, err := sip.ParseMessage(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+
+	again, err := sip.ParseMessage([]byte(msg.String()))
+	if err != nil {
+		return nil, fmt.Errorf("parse serialized: %w", err)
+	}
+
+	if canonicalValue(startLine(msg)) != canonicalValue(startLine(again)) {
+		return nil, fmt.Errorf("start line changed: %q != %q", startLine(msg), startLine(again))
+	}
+	if !bytes.Equal(msg.Body(), again.Body()) {
+		return nil, fmt.Errorf("body changed after round trip")
+	}
+
+	// Datagram body is read till end, so declared length is checked against raw data
+	lines := headerLines(data)
+	_, body, _ := bytes.Cut(data, []byte("\r\n\r\n"))
+	for _, line := range lines {
+		name, value, _ := strings.Cut(line, ":")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if (name == "content-length" || name == "l") && strings.TrimSpace(value) != strconv.Itoa(len(body)) {
+			return nil, fmt.Errorf("Content-Length %s does not match body length %d", strings.TrimSpace(value), len(body))
+		}
+	}
+
+	headers, againHeaders := messageHeaders(msg), messageHeaders(again)
+	// Every header line must survive parsing. Comma separated values can be split in more headers
+	if len(headers) < len(lines) {
+		return nil, fmt.Errorf("headers lost: %d parsed from %d lines", len(headers), len(lines))
+	}
+	if len(headers) != len(againHeaders) {
+		return nil, fmt.Errorf("headers count changed: %d != %d", len(headers), len(againHeaders))
+	}
+	for i, h := range headers {
+		a := againHeaders[i]
+		if h.Name() != a.Name() || canonicalValue(h.Value()) != canonicalValue(a.Value()) {
+			return nil, fmt.Errorf("header changed: %q != %q", h.String(), a.String())
+		}
+	}
+	return msg, nil
+}
+
+// RunCorpus runs RoundTrip on every message file in dir as subtest named by file.
+// It can be used to check that parser handles messages captured from own deployment
+// Ex:
+//
+//	func TestCorpus(t *testing.T) {
+//		siptest.RunCorpus(t, "testdata/corpus")
+//	}
+func RunCorpus(t *testing.T, dir string) {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*"+CorpusExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("no %s files in %s", CorpusExt, dir)
+	}
+	sort.Strings(files)
+
+	for _, f := range files {
+		f := f
+		t.Run(strings.TrimSuffix(filepath.Base(f), CorpusExt), func(t *testing.T) {
+			data, err := os.ReadFile(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := RoundTrip(data); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// headerLines returns header lines of message without start line and body
+func headerLines(data []byte) []string {
+	head, _, _ := bytes.Cut(data, []byte("\r\n\r\n"))
+	lines := strings.Split(string(head), "\r\n")
+	var headers []string
+	for _, line := range lines[1:] {
+		// Folded lines continue previous header
+		if line == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		headers = append(headers, line)
+	}
+	return headers
+}
+
+func startLine(msg sip.Message) string {
+	if l, ok := msg.(interface{ StartLine() string }); ok {
+		return l.StartLine()
+	}
+	return ""
+}
+
+func messageHeaders(msg sip.Message) []sip.Header {
+	if hs, ok := msg.(interface{ Headers() []sip.Header }); ok {
+		return hs.Headers()
+	}
+	return nil
+}
+
+// canonicalValue returns header value or start line with sorted params of every header and uri,
+// as params are not ordered after parsing
+func canonicalValue(value string) string {
+	var b strings.Builder
+	var params []string
+	inParams, quoted := false, false
+	start := 0
+	end := func(i int) {
+		if inParams {
+			params = append(params, value[start:i])
+		} else {
+			b.WriteString(value[start:i])
+		}
+	}
+	flush := func() {
+		sort.Strings(params)
+		for _, p := range params {
+			b.WriteString(";")
+			b.WriteString(p)
+		}
+		params = params[:0]
+		inParams = false
+	}
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c == '"' {
+			quoted = !quoted
+		}
+		if quoted {
+			continue
+		}
+		switch c {
+		case ';':
+			end(i)
+			inParams = true
+		case '>', '?', ',', ' ':
+			end(i)
+			flush()
+			b.WriteByte(c)
+		default:
+			continue
+		}
+		start = i + 1
+	}
+	end(len(value))
+	flush()
+	return b.String()
+}
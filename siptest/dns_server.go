@@ -0,0 +1,146 @@
+package siptest
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"sync"
+)
+
+// DNSServer is UDP DNS server answering A and AAAA queries from static records, for testing resolving
+// like IPv6 preference or DNS64 synthesized addresses without real DNS
+// Ex:
+//
+//	dns, _ := siptest.NewDNSServer("127.0.0.1:0")
+//	defer dns.Close()
+//	dns.AddHost("pbx.example.com", net.ParseIP("192.0.2.1"), net.ParseIP("2001:db8::1"))
+//	ua, _ := sipgo.NewUA(sipgo.WithUserAgentDNSResolver(dns.Resolver()))
+type DNSServer struct {
+	conn net.PacketConn
+
+	mu    sync.Mutex
+	hosts map[string][]net.IP
+
+	wg sync.WaitGroup
+}
+
+// NewDNSServer listens UDP on addr and starts answering queries
+func NewDNSServer(addr string) (*DNSServer, error) {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &DNSServer{
+		conn:  conn,
+		hosts: make(map[string][]net.IP),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// AddHost adds addresses of host. IPv4 are answered as A and IPv6 as AAAA records
+func (s *DNSServer) AddHost(host string, ips ...net.IP) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	s.hosts[host] = append(s.hosts[host], ips...)
+}
+
+// Addr returns listening address
+func (s *DNSServer) Addr() string {
+	return s.conn.LocalAddr().String()
+}
+
+// Resolver returns resolver sending all queries to this server
+func (s *DNSServer) Resolver() *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "udp", s.Addr())
+		},
+	}
+}
+
+// Close stops server
+func (s *DNSServer) Close() error {
+	err := s.conn.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *DNSServer) serve() {
+	defer s.wg.Done()
+	buf := make([]byte, 512)
+	for {
+		n, raddr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if resp := s.answer(buf[:n]); resp != nil {
+			s.conn.WriteTo(resp, raddr)
+		}
+	}
+}
+
+// answer builds response for single question query. Names are expected uncompressed in query
+func (s *DNSServer) answer(query []byte) []byte {
+	if len(query) < 12 || binary.BigEndian.Uint16(query[4:]) != 1 {
+		return nil
+	}
+
+	var labels []string
+	off := 12
+	for off < len(query) && query[off] != 0 {
+		l := int(query[off])
+		if off+1+l > len(query) {
+			return nil
+		}
+		labels = append(labels, string(query[off+1:off+1+l]))
+		off += 1 + l
+	}
+	off++
+	if off+4 > len(query) {
+		return nil
+	}
+	qtype := binary.BigEndian.Uint16(query[off:])
+	question := query[12 : off+4]
+
+	s.mu.Lock()
+	ips, exists := s.hosts[strings.ToLower(strings.Join(labels, "."))]
+	s.mu.Unlock()
+
+	resp := make([]byte, 12, 512)
+	copy(resp, query[:2])
+	flags := uint16(0x8180) // Response, recursion desired and available
+	if !exists {
+		flags |= 3 // NXDOMAIN
+	}
+	binary.BigEndian.PutUint16(resp[2:], flags)
+	binary.BigEndian.PutUint16(resp[4:], 1)
+	resp = append(resp, question...)
+
+	var count uint16
+	for _, ip := range ips {
+		var rdata []byte
+		switch {
+		case qtype == 1 && ip.To4() != nil:
+			rdata = ip.To4()
+		case qtype == 28 && ip.To4() == nil:
+			rdata = ip.To16()
+		default:
+			continue
+		}
+		resp = append(resp, 0xc0, 12) // Pointer to question name
+		resp = binary.BigEndian.AppendUint16(resp, qtype)
+		resp = binary.BigEndian.AppendUint16(resp, 1)
+		resp = binary.BigEndian.AppendUint32(resp, 60)
+		resp = binary.BigEndian.AppendUint16(resp, uint16(len(rdata)))
+		resp = append(resp, rdata...)
+		count++
+	}
+	binary.BigEndian.PutUint16(resp[6:], count)
+	return resp
+}
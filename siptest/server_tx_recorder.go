@@ -32,6 +32,8 @@ type ServerTxRecorder struct {
 
 // Result returns sip response. Can be nil if none was processed
 func (r *ServerTxRecorder) Result() []*sip.Response {
+	r.c.mu.Lock()
+	defer r.c.mu.Unlock()
 	if len(r.c.msgs) == 0 {
 		return nil
 	}
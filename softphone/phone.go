@@ -0,0 +1,481 @@
+package softphone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/emiago/sipgo"
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// LinesNum is number of phone lines
+const LinesNum = 2
+
+// session is established call, either as UAC or UAS
+type session interface {
+	Bye(ctx context.Context) error
+	BlindTransfer(ctx context.Context, target sip.Uri) (sipgo.TransferResult, error)
+	Close() error
+	Done() <-chan struct{}
+}
+
+type call struct {
+	// inviteReq is initial INVITE, used for matching in dialog requests
+	inviteReq *sip.Request
+	// localBody is SDP we offered or answered with
+	localBody []byte
+
+	// cancel cancels outgoing call before answer
+	cancel context.CancelFunc
+	// server is incoming call before answer
+	server     *sipgo.DialogServerSession
+	serverMu   sync.Mutex
+	inviteTx   sip.ServerTransaction
+	answered   chan struct{}
+	answerOnce sync.Once
+
+	session session
+
+	// ended is closed when line leaves call
+	ended   chan struct{}
+	endOnce sync.Once
+}
+
+func newCall() *call {
+	return &call{ended: make(chan struct{})}
+}
+
+// respond sends response on incoming call. Ringing can be sent while user already answers
+func (c *call) respond(statusCode sip.StatusCode, reason string, body []byte, headers ...sip.Header) error {
+	c.serverMu.Lock()
+	defer c.serverMu.Unlock()
+	return c.server.Respond(statusCode, reason, body, headers...)
+}
+
+// stopRinging releases INVITE handler waiting for answer
+func (c *call) stopRinging() {
+	c.answerOnce.Do(func() { close(c.answered) })
+}
+
+// HoldHandler puts call of line on hold or resumes it, ex. by sending re-INVITE with sendonly SDP.
+// Line state is changed only if handler succeeds
+type HoldHandler func(ctx context.Context, l *Line, hold bool) error
+
+type PhoneOption func(p *Phone)
+
+// WithHoldHandler sets handler doing media signaling on hold and resume.
+// Without handler hold changes only line state
+func WithHoldHandler(h HoldHandler) PhoneOption {
+	return func(p *Phone) {
+		p.hold = h
+	}
+}
+
+// WithStateHandler sets handler called on every line state change
+func WithStateHandler(h StateHandler) PhoneOption {
+	return func(p *Phone) {
+		p.onChange = h
+	}
+}
+
+// WithPhoneLogger allows customizing logger
+func WithPhoneLogger(logger zerolog.Logger) PhoneOption {
+	return func(p *Phone) {
+		p.log = logger
+	}
+}
+
+// Phone is two line softphone. Only one line can be active, other active line is put on hold
+// when call is dialed, answered or resumed. Incoming call while both lines are busy is rejected with 486 Busy Here.
+// Ex:
+//
+//	phone := softphone.NewPhone(client, contact)
+//	phone.Register(srv)
+//	l, err := phone.Dial(ctx, sip.Uri{User: "bob", Host: "example.com"}, sdp)
+//	...
+//	phone.Hangup(ctx, l)
+type Phone struct {
+	lines [LinesNum]*Line
+
+	dc      *sipgo.DialogClient
+	ds      *sipgo.DialogServer
+	contact sip.ContactHeader
+
+	hold     HoldHandler
+	onChange StateHandler
+	log      zerolog.Logger
+}
+
+// NewPhone creates phone. Contact is used for dialogs of both directions
+func NewPhone(client *sipgo.Client, contact sip.ContactHeader, options ...PhoneOption) *Phone {
+	p := &Phone{
+		dc:      sipgo.NewDialogClient(client, contact),
+		ds:      sipgo.NewDialogServer(client, contact),
+		contact: contact,
+		log:     log.Logger.With().Str("caller", "Phone").Logger(),
+	}
+	for _, o := range options {
+		o(p)
+	}
+	for i := range p.lines {
+		p.lines[i] = NewLine(i, p.onChange)
+	}
+	return p
+}
+
+// Line returns line with id. It panics in case id is not valid
+func (p *Phone) Line(id int) *Line {
+	return p.lines[id]
+}
+
+// Register registers phone handlers for INVITE, ACK, BYE and NOTIFY on server
+func (p *Phone) Register(srv *sipgo.Server) {
+	srv.OnInvite(p.HandleInvite)
+	srv.OnAck(p.handleAck)
+	srv.OnBye(p.handleBye)
+	srv.OnNotify(p.handleNotify)
+}
+
+// Dial calls recipient on free line and blocks until call is answered or fails.
+// Canceling context or Hangup of line before answer cancels call
+func (p *Phone) Dial(ctx context.Context, recipient sip.Uri, body []byte, headers ...sip.Header) (*Line, error) {
+	dialCtx, cancel := context.WithCancel(ctx)
+	c := newCall()
+	c.localBody = body
+	c.cancel = cancel
+	l, err := p.acquire(EventDial, c)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if err := p.holdOthers(ctx, l); err != nil {
+		p.end(l, c)
+		return l, err
+	}
+
+	req := sip.NewRequest(sip.INVITE, &recipient)
+	req.SetBody(body)
+	for _, h := range headers {
+		req.AppendHeader(h)
+	}
+	c.inviteReq = req
+	sess, err := p.dc.WriteInvite(dialCtx, req)
+	if err != nil {
+		p.end(l, c)
+		return l, err
+	}
+
+	// ACK is sent by WaitAnswer
+	if err := sess.WaitAnswer(dialCtx, sipgo.AnswerOptions{}); err != nil {
+		sess.Close()
+		p.end(l, c)
+		return l, err
+	}
+
+	// Hangup can race with answer
+	if dialCtx.Err() == nil {
+		c.session = sess
+		err = l.fire(EventAnswered, c, false)
+	}
+	if dialCtx.Err() != nil || err != nil {
+		p.end(l, c)
+		if err := sess.Bye(ctx); err != nil {
+			p.log.Info().Err(err).Msg("Failed to terminate call with BYE")
+		}
+		return l, context.Canceled
+	}
+	go p.watch(l, c)
+	return l, nil
+}
+
+// HandleInvite is OnInvite handler. New call is taken by free line and responded with 180 Ringing.
+// It blocks until call ends, as INVITE transaction is kept for whole call.
+// In dialog INVITE, like hold from remote side, is answered with last local SDP
+func (p *Phone) HandleInvite(req *sip.Request, tx sip.ServerTransaction) {
+	if to := req.To(); to != nil && to.Params.Has("tag") {
+		p.handleReInvite(req, tx)
+		return
+	}
+
+	sess, err := p.ds.ReadInvite(req, tx)
+	if err != nil {
+		p.log.Info().Err(err).Msg("Failed to read INVITE")
+		p.respond(tx, sip.NewResponseFromRequest(req, sip.StatusBadRequest, "Bad Request", nil))
+		return
+	}
+
+	c := newCall()
+	c.inviteReq = req
+	c.inviteTx = tx
+	c.server = sess
+	c.answered = make(chan struct{})
+	l, err := p.acquire(EventIncoming, c)
+	if err != nil {
+		sess.Close()
+		p.respond(tx, sip.NewResponseFromRequest(req, sip.StatusBusyHere, "Busy Here", nil))
+		return
+	}
+
+	if err := c.respond(sip.StatusRinging, "Ringing", nil); err != nil {
+		p.log.Info().Err(err).Msg("Failed to respond ringing")
+		sess.Close()
+		p.end(l, c)
+		return
+	}
+
+	select {
+	case creq := <-tx.Cancels():
+		p.respond(tx, sip.NewResponseFromRequest(creq, sip.StatusOK, "OK", nil))
+		p.respond(tx, sip.NewResponseFromRequest(req, sip.StatusRequestTerminated, "Request Terminated", nil))
+		sess.Close()
+		p.end(l, c)
+		return
+	case <-tx.Done():
+		sess.Close()
+		p.end(l, c)
+		return
+	case <-c.answered:
+	}
+
+	// Declined or failed answer ended call
+	select {
+	case <-sess.Done():
+		p.end(l, c)
+	case <-c.ended:
+	}
+}
+
+// Answer answers ringing line with 200 OK and body, normally SDP answer
+func (p *Phone) Answer(l *Line, body []byte, headers ...sip.Header) error {
+	c := l.getCall()
+	if c == nil || c.server == nil || !l.Can(EventAnswer) {
+		return fmt.Errorf("%w: line=%d state=%s event=%s", ErrInvalidTransition, l.ID, l.State(), EventAnswer)
+	}
+
+	if err := p.holdOthers(context.Background(), l); err != nil {
+		return err
+	}
+
+	defer c.stopRinging()
+	if body != nil {
+		headers = append(headers, sip.NewHeader("Content-Type", "application/sdp"))
+	}
+	if err := c.respond(sip.StatusOK, "OK", body, headers...); err != nil {
+		c.server.Close()
+		p.end(l, c)
+		return err
+	}
+	c.localBody = body
+	c.session = c.server
+	return l.fire(EventAnswer, c, false)
+}
+
+// Hangup terminates call on line. Outgoing call is canceled, ringing call is declined with 603
+// and established call is terminated with BYE
+func (p *Phone) Hangup(ctx context.Context, l *Line) error {
+	c := l.getCall()
+	if c == nil {
+		return fmt.Errorf("%w: line=%d state=%s event=%s", ErrInvalidTransition, l.ID, l.State(), EventHangup)
+	}
+
+	switch l.State() {
+	case StateCalling:
+		// Dial returns after CANCEL and ends call
+		c.cancel()
+		return nil
+	case StateRinging:
+		defer c.stopRinging()
+		err := c.respond(603, "Decline", nil)
+		c.server.Close()
+		p.end(l, c)
+		return err
+	}
+
+	if !p.end(l, c) {
+		return fmt.Errorf("%w: line=%d call ended event=%s", ErrInvalidTransition, l.ID, EventHangup)
+	}
+	return c.session.Bye(ctx)
+}
+
+// Hold puts active line on hold
+func (p *Phone) Hold(ctx context.Context, l *Line) error {
+	if !l.Can(EventHold) {
+		return fmt.Errorf("%w: line=%d state=%s event=%s", ErrInvalidTransition, l.ID, l.State(), EventHold)
+	}
+	if p.hold != nil {
+		if err := p.hold(ctx, l, true); err != nil {
+			return err
+		}
+	}
+	return l.Fire(EventHold)
+}
+
+// Resume resumes held line. Other active line is put on hold
+func (p *Phone) Resume(ctx context.Context, l *Line) error {
+	if !l.Can(EventResume) {
+		return fmt.Errorf("%w: line=%d state=%s event=%s", ErrInvalidTransition, l.ID, l.State(), EventResume)
+	}
+	if err := p.holdOthers(ctx, l); err != nil {
+		return err
+	}
+	if p.hold != nil {
+		if err := p.hold(ctx, l, false); err != nil {
+			return err
+		}
+	}
+	return l.Fire(EventResume)
+}
+
+// Transfer does blind transfer of line call to target. Active call is put on hold first.
+// On success call is terminated, otherwise line stays held
+func (p *Phone) Transfer(ctx context.Context, l *Line, target sip.Uri) (sipgo.TransferResult, error) {
+	if l.State() == StateActive {
+		if err := p.Hold(ctx, l); err != nil {
+			return sipgo.TransferResult{}, err
+		}
+	}
+
+	c := l.getCall()
+	if c == nil || c.session == nil || !l.Can(EventTransfer) {
+		return sipgo.TransferResult{}, fmt.Errorf("%w: line=%d state=%s event=%s", ErrInvalidTransition, l.ID, l.State(), EventTransfer)
+	}
+	if err := l.fire(EventTransfer, c, false); err != nil {
+		return sipgo.TransferResult{}, err
+	}
+
+	res, err := c.session.BlindTransfer(ctx, target)
+	if err != nil || !res.IsSuccess() {
+		if ferr := l.fire(EventTransferFailed, c, false); ferr != nil {
+			p.log.Debug().Err(ferr).Msg("Call ended during transfer")
+		}
+		return res, err
+	}
+	// Call is terminated with BYE after successful transfer
+	p.end(l, c)
+	return res, nil
+}
+
+// acquire takes first idle line for call
+func (p *Phone) acquire(ev Event, c *call) (*Line, error) {
+	for _, l := range p.lines {
+		if err := l.fire(ev, c, true); err == nil {
+			return l, nil
+		}
+	}
+	return nil, ErrNoFreeLine
+}
+
+// holdOthers puts other active lines on hold
+func (p *Phone) holdOthers(ctx context.Context, l *Line) error {
+	for _, o := range p.lines {
+		if o == l || o.State() != StateActive {
+			continue
+		}
+		if err := p.Hold(ctx, o); err != nil {
+			return fmt.Errorf("hold line=%d: %w", o.ID, err)
+		}
+	}
+	return nil
+}
+
+// end moves line to idle in case c is still line call. It reports whether call was ended by this call
+func (p *Phone) end(l *Line, c *call) bool {
+	if err := l.fire(EventHangup, c, false); err != nil {
+		return false
+	}
+	c.endOnce.Do(func() {
+		close(c.ended)
+		if c.cancel != nil {
+			c.cancel()
+		}
+	})
+	return true
+}
+
+// watch ends line when remote side terminates outgoing call
+func (p *Phone) watch(l *Line, c *call) {
+	select {
+	case <-c.session.Done():
+		p.end(l, c)
+	case <-c.ended:
+	}
+}
+
+func (p *Phone) handleReInvite(req *sip.Request, tx sip.ServerTransaction) {
+	c := p.findCall(req)
+	if c == nil {
+		p.respond(tx, sip.NewResponseFromRequest(req, sip.StatusCallTransactionDoesNotExists, "Call/Transaction Does Not Exist", nil))
+		return
+	}
+
+	res := sip.NewResponseFromRequest(req, sip.StatusOK, "OK", c.localBody)
+	res.AppendHeader(sip.HeaderClone(&p.contact))
+	if c.localBody != nil {
+		res.AppendHeader(sip.NewHeader("Content-Type", "application/sdp"))
+	}
+	p.respond(tx, res)
+}
+
+func (p *Phone) handleAck(req *sip.Request, tx sip.ServerTransaction) {
+	if err := p.ds.ReadAck(req, tx); err != nil && !errors.Is(err, sipgo.ErrDialogDoesNotExists) {
+		p.log.Info().Err(err).Msg("Failed to read ACK")
+	}
+}
+
+func (p *Phone) handleBye(req *sip.Request, tx sip.ServerTransaction) {
+	err := p.ds.ReadBye(req, tx)
+	if errors.Is(err, sipgo.ErrDialogDoesNotExists) {
+		err = p.dc.ReadBye(req, tx)
+	}
+	if errors.Is(err, sipgo.ErrDialogDoesNotExists) {
+		p.respond(tx, sip.NewResponseFromRequest(req, sip.StatusCallTransactionDoesNotExists, "Call/Transaction Does Not Exist", nil))
+		return
+	}
+	if err != nil {
+		p.log.Info().Err(err).Msg("Failed to read BYE")
+	}
+}
+
+func (p *Phone) handleNotify(req *sip.Request, tx sip.ServerTransaction) {
+	err := p.dc.ReadNotify(req, tx)
+	if errors.Is(err, sipgo.ErrDialogDoesNotExists) {
+		err = p.ds.ReadNotify(req, tx)
+	}
+	if errors.Is(err, sipgo.ErrDialogDoesNotExists) {
+		p.respond(tx, sip.NewResponseFromRequest(req, sip.StatusCallTransactionDoesNotExists, "Call/Transaction Does Not Exist", nil))
+		return
+	}
+	if err != nil {
+		p.log.Info().Err(err).Msg("Failed to read NOTIFY")
+	}
+}
+
+// findCall returns line call with same Call-ID as in dialog request
+func (p *Phone) findCall(req *sip.Request) *call {
+	callID := req.CallID()
+	if callID == nil {
+		return nil
+	}
+	for _, l := range p.lines {
+		c := l.getCall()
+		if c == nil || c.inviteReq == nil {
+			continue
+		}
+		if id := c.inviteReq.CallID(); id != nil && id.Value() == callID.Value() {
+			return c
+		}
+	}
+	return nil
+}
+
+func (p *Phone) respond(tx sip.ServerTransaction, res *sip.Response) {
+	if err := tx.Respond(res); err != nil {
+		p.log.Error().Err(err).Str("res", res.StartLine()).Msg("Failed to respond")
+	}
+}
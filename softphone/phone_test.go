@@ -0,0 +1,187 @@
+package softphone
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo"
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testInvite(t *testing.T, user string) *sip.Request {
+	req := sip.NewRequest(sip.INVITE, &sip.Uri{User: "phone", Host: "127.0.0.1", Port: 5060})
+	req.AppendHeader(sip.NewHeader("Via", "SIP/2.0/UDP 127.0.0.2:5060;branch="+sip.GenerateBranch()))
+	req.AppendHeader(&sip.FromHeader{Address: sip.Uri{User: user, Host: "127.0.0.2"}, Params: sip.HeaderParams{"tag": sip.GenerateTagN(8)}})
+	req.AppendHeader(&sip.ToHeader{Address: sip.Uri{User: "phone", Host: "127.0.0.1"}, Params: sip.NewParams()})
+	req.AppendHeader(&sip.ContactHeader{Address: sip.Uri{User: user, Host: "127.0.0.2", Port: 5060}})
+	callID := sip.CallIDHeader(fmt.Sprintf("%s-%d", user, time.Now().UnixNano()))
+	req.AppendHeader(&callID)
+	req.AppendHeader(&sip.CSeqHeader{SeqNo: 1, MethodName: sip.INVITE})
+	req.SetTransport("UDP")
+	req.SetSource("127.0.0.2:5060")
+	return req
+}
+
+func testBye(invite *sip.Request, res *sip.Response) *sip.Request {
+	req := sip.NewRequest(sip.BYE, &sip.Uri{User: "phone", Host: "127.0.0.1", Port: 5060})
+	req.AppendHeader(sip.NewHeader("Via", "SIP/2.0/UDP 127.0.0.2:5060;branch="+sip.GenerateBranch()))
+	req.AppendHeader(sip.HeaderClone(invite.From()))
+	req.AppendHeader(sip.HeaderClone(res.To()))
+	req.AppendHeader(sip.HeaderClone(invite.CallID()))
+	req.AppendHeader(&sip.CSeqHeader{SeqNo: 2, MethodName: sip.BYE})
+	req.SetTransport("UDP")
+	req.SetSource("127.0.0.2:5060")
+	return req
+}
+
+func waitState(t *testing.T, l *Line, s State) {
+	t.Helper()
+	require.Eventually(t, func() bool { return l.State() == s }, time.Second, time.Millisecond, "line=%d state=%s", l.ID, l.State())
+}
+
+func lastResponse(t *testing.T, rec *siptest.ServerTxRecorder) *sip.Response {
+	t.Helper()
+	var res []*sip.Response
+	require.Eventually(t, func() bool {
+		res = rec.Result()
+		return len(res) > 0
+	}, time.Second, time.Millisecond)
+	return res[len(res)-1]
+}
+
+func TestPhoneIncoming(t *testing.T) {
+	ua, err := sipgo.NewUA()
+	require.NoError(t, err)
+	defer ua.Close()
+	client, err := sipgo.NewClient(ua)
+	require.NoError(t, err)
+
+	held := make(chan int, 10)
+	phone := NewPhone(client, sip.ContactHeader{Address: sip.Uri{User: "phone", Host: "127.0.0.1", Port: 5060}},
+		WithHoldHandler(func(ctx context.Context, l *Line, hold bool) error {
+			if hold {
+				held <- l.ID
+			}
+			return nil
+		}),
+	)
+	line0, line1 := phone.Line(0), phone.Line(1)
+
+	// First call rings and is answered on line 0
+	inv0 := testInvite(t, "alice")
+	rec0 := siptest.NewServerTxRecorder(inv0)
+	done0 := make(chan struct{})
+	go func() {
+		phone.HandleInvite(inv0, rec0)
+		close(done0)
+	}()
+	waitState(t, line0, StateRinging)
+	assert.Equal(t, sip.StatusRinging, lastResponse(t, rec0).StatusCode)
+
+	require.NoError(t, phone.Answer(line0, []byte("v=0")))
+	assert.Equal(t, StateActive, line0.State())
+	res0 := lastResponse(t, rec0)
+	assert.Equal(t, sip.StatusOK, res0.StatusCode)
+	assert.Equal(t, "v=0", string(res0.Body()))
+
+	// Second call on line 1. Answering it holds line 0
+	inv1 := testInvite(t, "bob")
+	rec1 := siptest.NewServerTxRecorder(inv1)
+	done1 := make(chan struct{})
+	go func() {
+		phone.HandleInvite(inv1, rec1)
+		close(done1)
+	}()
+	waitState(t, line1, StateRinging)
+
+	// Both lines busy
+	inv2 := testInvite(t, "carol")
+	rec2 := siptest.NewServerTxRecorder(inv2)
+	phone.HandleInvite(inv2, rec2)
+	assert.Equal(t, sip.StatusBusyHere, lastResponse(t, rec2).StatusCode)
+
+	require.NoError(t, phone.Answer(line1, nil))
+	assert.Equal(t, 0, <-held)
+	assert.Equal(t, StateHeld, line0.State())
+	assert.Equal(t, StateActive, line1.State())
+	res1 := lastResponse(t, rec1)
+
+	// Resume is not possible for active line
+	assert.ErrorIs(t, phone.Resume(context.Background(), line1), ErrInvalidTransition)
+
+	// Remote hangs up line 1
+	bye := testBye(inv1, res1)
+	byeRec := siptest.NewServerTxRecorder(bye)
+	phone.handleBye(bye, byeRec)
+	assert.Equal(t, sip.StatusOK, lastResponse(t, byeRec).StatusCode)
+	<-done1
+	assert.Equal(t, StateIdle, line1.State())
+
+	require.NoError(t, phone.Resume(context.Background(), line0))
+	assert.Equal(t, StateActive, line0.State())
+
+	// Remote re-INVITE is answered with local SDP
+	reinv := testInvite(t, "alice")
+	reinv.ReplaceHeader(sip.HeaderClone(inv0.From()))
+	reinv.ReplaceHeader(sip.HeaderClone(res0.To()))
+	reinv.ReplaceHeader(sip.HeaderClone(inv0.CallID()))
+	reinvRec := siptest.NewServerTxRecorder(reinv)
+	phone.HandleInvite(reinv, reinvRec)
+	reinvRes := lastResponse(t, reinvRec)
+	assert.Equal(t, sip.StatusOK, reinvRes.StatusCode)
+	assert.Equal(t, "v=0", string(reinvRes.Body()))
+
+	bye = testBye(inv0, res0)
+	byeRec = siptest.NewServerTxRecorder(bye)
+	phone.handleBye(bye, byeRec)
+	<-done0
+	assert.Equal(t, StateIdle, line0.State())
+}
+
+func TestPhoneIncomingDeclineAndCancel(t *testing.T) {
+	ua, err := sipgo.NewUA()
+	require.NoError(t, err)
+	defer ua.Close()
+	client, err := sipgo.NewClient(ua)
+	require.NoError(t, err)
+
+	phone := NewPhone(client, sip.ContactHeader{Address: sip.Uri{User: "phone", Host: "127.0.0.1", Port: 5060}})
+	line0 := phone.Line(0)
+
+	inv := testInvite(t, "alice")
+	rec := siptest.NewServerTxRecorder(inv)
+	done := make(chan struct{})
+	go func() {
+		phone.HandleInvite(inv, rec)
+		close(done)
+	}()
+	waitState(t, line0, StateRinging)
+
+	require.NoError(t, phone.Hangup(context.Background(), line0))
+	<-done
+	assert.Equal(t, StateIdle, line0.State())
+	assert.Equal(t, sip.StatusCode(603), lastResponse(t, rec).StatusCode)
+	assert.ErrorIs(t, phone.Answer(line0, nil), ErrInvalidTransition)
+
+	// Canceled by caller
+	inv = testInvite(t, "alice")
+	rec = siptest.NewServerTxRecorder(inv)
+	done = make(chan struct{})
+	go func() {
+		phone.HandleInvite(inv, rec)
+		close(done)
+	}()
+	waitState(t, line0, StateRinging)
+
+	cancel := sip.NewCancelRequest(inv)
+	require.NoError(t, rec.Receive(cancel))
+	<-done
+	assert.Equal(t, StateIdle, line0.State())
+	assert.Equal(t, sip.StatusRequestTerminated, lastResponse(t, rec).StatusCode)
+}
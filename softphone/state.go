@@ -0,0 +1,203 @@
+// Package softphone models signaling of two line softphone on top of sipgo dialogs.
+// It is reference how dialog APIs are combined into phone and is used as fixture for testing them.
+package softphone
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrInvalidTransition = errors.New("softphone: invalid transition")
+	ErrNoFreeLine        = errors.New("softphone: no free line")
+)
+
+// State is signaling state of line
+type State int
+
+const (
+	// StateIdle is line without call
+	StateIdle State = iota
+	// StateCalling is outgoing call waiting for answer
+	StateCalling
+	// StateRinging is incoming call waiting for user to answer
+	StateRinging
+	// StateActive is established call with media flowing
+	StateActive
+	// StateHeld is established call put on hold
+	StateHeld
+	// StateTransferring is call being transferred with REFER. Call is held during transfer
+	StateTransferring
+)
+
+func (s State) String() string {
+	switch s {
+	case StateIdle:
+		return "Idle"
+	case StateCalling:
+		return "Calling"
+	case StateRinging:
+		return "Ringing"
+	case StateActive:
+		return "Active"
+	case StateHeld:
+		return "Held"
+	case StateTransferring:
+		return "Transferring"
+	default:
+		return "Unknown State"
+	}
+}
+
+// Event triggers line state transition
+type Event int
+
+const (
+	// EventDial is outgoing INVITE sent
+	EventDial Event = iota
+	// EventIncoming is incoming INVITE received
+	EventIncoming
+	// EventAnswer is incoming call answered locally with 200
+	EventAnswer
+	// EventAnswered is outgoing call answered by remote party
+	EventAnswered
+	EventHold
+	EventResume
+	// EventTransfer is REFER sent
+	EventTransfer
+	// EventTransferFailed is REFER rejected or transfer target not reached. Call stays held
+	EventTransferFailed
+	// EventHangup is call terminated by any side, rejected, canceled or transferred
+	EventHangup
+)
+
+func (e Event) String() string {
+	switch e {
+	case EventDial:
+		return "Dial"
+	case EventIncoming:
+		return "Incoming"
+	case EventAnswer:
+		return "Answer"
+	case EventAnswered:
+		return "Answered"
+	case EventHold:
+		return "Hold"
+	case EventResume:
+		return "Resume"
+	case EventTransfer:
+		return "Transfer"
+	case EventTransferFailed:
+		return "TransferFailed"
+	case EventHangup:
+		return "Hangup"
+	default:
+		return "Unknown Event"
+	}
+}
+
+// transitions is line state machine. Events not listed for state are invalid
+var transitions = map[State]map[Event]State{
+	StateIdle: {
+		EventDial:     StateCalling,
+		EventIncoming: StateRinging,
+	},
+	StateCalling: {
+		EventAnswered: StateActive,
+		EventHangup:   StateIdle,
+	},
+	StateRinging: {
+		EventAnswer: StateActive,
+		EventHangup: StateIdle,
+	},
+	StateActive: {
+		EventHold:     StateHeld,
+		EventTransfer: StateTransferring,
+		EventHangup:   StateIdle,
+	},
+	StateHeld: {
+		EventResume:   StateActive,
+		EventTransfer: StateTransferring,
+		EventHangup:   StateIdle,
+	},
+	StateTransferring: {
+		EventTransferFailed: StateHeld,
+		EventHangup:         StateIdle,
+	},
+}
+
+// StateHandler is called on every line state transition
+type StateHandler func(l *Line, from State, to State, ev Event)
+
+// Line is single phone line. It holds at most one call
+type Line struct {
+	ID int
+
+	mu       sync.Mutex
+	state    State
+	onChange StateHandler
+
+	// call is current call of line, nil when idle
+	call *call
+}
+
+// NewLine creates idle line. Handler can be nil
+func NewLine(id int, onChange StateHandler) *Line {
+	return &Line{ID: id, onChange: onChange}
+}
+
+// State returns current line state
+func (l *Line) State() State {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.state
+}
+
+// Fire applies event on line. ErrInvalidTransition is returned in case event is not valid for current state
+func (l *Line) Fire(ev Event) error {
+	return l.fire(ev, nil, false)
+}
+
+// fire applies event. With start c becomes line call, otherwise non nil c must be current line call,
+// so events of ended call are not applied on next one
+func (l *Line) fire(ev Event, c *call, start bool) error {
+	l.mu.Lock()
+	from := l.state
+	if !start && c != nil && l.call != c {
+		l.mu.Unlock()
+		return fmt.Errorf("%w: line=%d call ended event=%s", ErrInvalidTransition, l.ID, ev)
+	}
+	to, ok := transitions[from][ev]
+	if !ok {
+		l.mu.Unlock()
+		return fmt.Errorf("%w: line=%d state=%s event=%s", ErrInvalidTransition, l.ID, from, ev)
+	}
+	l.state = to
+	if start {
+		l.call = c
+	}
+	if to == StateIdle {
+		l.call = nil
+	}
+	l.mu.Unlock()
+
+	if l.onChange != nil {
+		l.onChange(l, from, to, ev)
+	}
+	return nil
+}
+
+// Can reports is event valid in current state
+func (l *Line) Can(ev Event) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := transitions[l.state][ev]
+	return ok
+}
+
+func (l *Line) getCall() *call {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.call
+}
@@ -0,0 +1,46 @@
+package softphone
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineTransitions(t *testing.T) {
+	var changes []State
+	l := NewLine(0, func(l *Line, from State, to State, ev Event) {
+		changes = append(changes, to)
+	})
+	assert.Equal(t, StateIdle, l.State())
+
+	for _, ev := range []Event{EventIncoming, EventAnswer, EventHold, EventResume, EventTransfer, EventTransferFailed, EventHangup} {
+		require.NoError(t, l.Fire(ev), ev.String())
+	}
+	assert.Equal(t, []State{StateRinging, StateActive, StateHeld, StateActive, StateTransferring, StateHeld, StateIdle}, changes)
+
+	t.Run("Invalid", func(t *testing.T) {
+		l := NewLine(1, nil)
+		assert.ErrorIs(t, l.Fire(EventAnswer), ErrInvalidTransition)
+		assert.ErrorIs(t, l.Fire(EventHangup), ErrInvalidTransition)
+
+		require.NoError(t, l.Fire(EventDial))
+		// Outgoing call is answered by remote, not locally
+		assert.ErrorIs(t, l.Fire(EventAnswer), ErrInvalidTransition)
+		assert.ErrorIs(t, l.Fire(EventHold), ErrInvalidTransition)
+		assert.False(t, l.Can(EventIncoming))
+		require.NoError(t, l.Fire(EventAnswered))
+		assert.ErrorIs(t, l.Fire(EventTransferFailed), ErrInvalidTransition)
+	})
+
+	t.Run("EndedCall", func(t *testing.T) {
+		l := NewLine(1, nil)
+		c1, c2 := newCall(), newCall()
+		require.NoError(t, l.fire(EventDial, c1, true))
+		require.NoError(t, l.fire(EventHangup, c1, false))
+		require.NoError(t, l.fire(EventIncoming, c2, true))
+		// Late hangup of first call must not end second one
+		assert.ErrorIs(t, l.fire(EventHangup, c1, false), ErrInvalidTransition)
+		assert.Equal(t, StateRinging, l.State())
+	})
+}
@@ -0,0 +1,118 @@
+package stir
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// CertMaxSize limits size of fetched certificate chain
+var CertMaxSize int64 = 64 * 1024
+
+// HTTPCertFetcher fetches PEM certificate chain over HTTPS and caches it
+type HTTPCertFetcher struct {
+	client *http.Client
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedCerts
+}
+
+type cachedCerts struct {
+	certs   []*x509.Certificate
+	expires time.Time
+}
+
+// NewHTTPCertFetcher creates fetcher with HTTP client. Fetched certificates are cached for ttl
+func NewHTTPCertFetcher(client *http.Client, ttl time.Duration) *HTTPCertFetcher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPCertFetcher{
+		client: client,
+		ttl:    ttl,
+		cache:  make(map[string]cachedCerts),
+	}
+}
+
+// Fetch returns certificate chain published on x5u. Only https URL is allowed
+func (f *HTTPCertFetcher) Fetch(ctx context.Context, x5u string) ([]*x509.Certificate, error) {
+	f.mu.Lock()
+	c, exists := f.cache[x5u]
+	f.mu.Unlock()
+	if exists && time.Now().Before(c.expires) {
+		return c.certs, nil
+	}
+
+	u, err := url.Parse(x5u)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "https" {
+		return nil, fmt.Errorf("stir: x5u %q is not https", x5u)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, x5u, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("stir: x5u %q responded %s", x5u, res.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Body, CertMaxSize))
+	if err != nil {
+		return nil, err
+	}
+
+	certs, err := ParseCertificates(data)
+	if err != nil {
+		return nil, err
+	}
+	if f.ttl > 0 {
+		f.mu.Lock()
+		f.cache[x5u] = cachedCerts{certs: certs, expires: time.Now().Add(f.ttl)}
+		f.mu.Unlock()
+	}
+	return certs, nil
+}
+
+// ParseCertificates parses PEM certificate chain, leaf first. DER encoded single certificate is accepted as well
+func ParseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) > 0 {
+		return certs, nil
+	}
+
+	cert, err := x509.ParseCertificate(data)
+	if err != nil {
+		return nil, errors.New("stir: no certificate found")
+	}
+	return []*x509.Certificate{cert}, nil
+}
@@ -0,0 +1,173 @@
+package stir
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	// AlgES256 is only signing algorithm allowed by SHAKEN
+	AlgES256 = "ES256"
+	// TypPassport is PASSporT token type
+	TypPassport = "passport"
+	// PPTShaken is SHAKEN PASSporT extension
+	PPTShaken = "shaken"
+)
+
+// Attestation is level of attestation signer has for calling number
+// https://datatracker.ietf.org/doc/html/rfc8588#section-4
+type Attestation string
+
+const (
+	// AttestationFull is set when signer authenticated caller and caller is authorized to use number
+	AttestationFull Attestation = "A"
+	// AttestationPartial is set when signer authenticated caller, but can not verify number
+	AttestationPartial Attestation = "B"
+	// AttestationGateway is set when signer only knows where call entered network
+	AttestationGateway Attestation = "C"
+)
+
+var (
+	ErrPASSporTInvalid   = errors.New("stir: invalid PASSporT")
+	ErrPASSporTSignature = errors.New("stir: PASSporT signature verification failed")
+)
+
+// Header is PASSporT JOSE header. Fields are ordered lexicographically as required for canonical form
+type Header struct {
+	Alg string `json:"alg"`
+	Ppt string `json:"ppt,omitempty"`
+	Typ string `json:"typ"`
+	// X5U is URL of signer certificate
+	X5U string `json:"x5u"`
+}
+
+// Orig is originating identity, telephone number or URI
+type Orig struct {
+	TN  string `json:"tn,omitempty"`
+	URI string `json:"uri,omitempty"`
+}
+
+// Dest are destination identities
+type Dest struct {
+	TN  []string `json:"tn,omitempty"`
+	URI []string `json:"uri,omitempty"`
+}
+
+// Claims are PASSporT payload claims with SHAKEN extension
+// https://datatracker.ietf.org/doc/html/rfc8225#section-5
+type Claims struct {
+	Attest Attestation `json:"attest,omitempty"`
+	Dest   Dest        `json:"dest"`
+	// Iat is issue time in unix seconds
+	Iat  int64 `json:"iat"`
+	Orig Orig  `json:"orig"`
+	// OrigID is opaque unique identifier of call origination point
+	OrigID string `json:"origid,omitempty"`
+}
+
+// PASSporT is parsed Personal Assertion Token https://datatracker.ietf.org/doc/html/rfc8225
+type PASSporT struct {
+	Header Header
+	Claims Claims
+
+	signingInput string
+	signature    []byte
+}
+
+// Parse parses PASSporT in JWS compact serialization. Signature is not verified, use Verify
+func Parse(token string) (*PASSporT, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: expected 3 parts", ErrPASSporTInvalid)
+	}
+	if parts[1] == "" {
+		// Compact form needs payload reconstructed from SIP headers
+		return nil, fmt.Errorf("%w: compact form not supported", ErrPASSporTInvalid)
+	}
+
+	p := &PASSporT{signingInput: parts[0] + "." + parts[1]}
+	if err := decodeSegment(parts[0], &p.Header); err != nil {
+		return nil, err
+	}
+	if err := decodeSegment(parts[1], &p.Claims); err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrPASSporTInvalid, err)
+	}
+	p.signature = sig
+
+	if p.Header.Alg != AlgES256 {
+		return nil, fmt.Errorf("%w: unsupported alg %q", ErrPASSporTInvalid, p.Header.Alg)
+	}
+	if p.Header.Typ != TypPassport {
+		return nil, fmt.Errorf("%w: unsupported typ %q", ErrPASSporTInvalid, p.Header.Typ)
+	}
+	return p, nil
+}
+
+// Verify verifies signature with signer public key, which must be ECDSA P-256 key
+func (p *PASSporT) Verify(key crypto.PublicKey) error {
+	pub, ok := key.(*ecdsa.PublicKey)
+	if !ok || pub.Curve != elliptic.P256() {
+		return fmt.Errorf("%w: key is not P-256", ErrPASSporTSignature)
+	}
+	if len(p.signature) != 64 {
+		return ErrPASSporTSignature
+	}
+
+	hash := sha256.Sum256([]byte(p.signingInput))
+	r := new(big.Int).SetBytes(p.signature[:32])
+	s := new(big.Int).SetBytes(p.signature[32:])
+	if !ecdsa.Verify(pub, hash[:], r, s) {
+		return ErrPASSporTSignature
+	}
+	return nil
+}
+
+// Sign creates signed PASSporT with ES256 in JWS compact serialization
+func Sign(header Header, claims Claims, key *ecdsa.PrivateKey) (string, error) {
+	header.Alg = AlgES256
+	header.Typ = TypPassport
+
+	h, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+
+	hash := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+	if err != nil {
+		return "", err
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+func decodeSegment(seg string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrPASSporTInvalid, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %s", ErrPASSporTInvalid, err)
+	}
+	return nil
+}
@@ -0,0 +1,337 @@
+// Package stir implements STIR/SHAKEN caller identity signing and verification of INVITE requests.
+// Identity header carries PASSporT signed by originating provider, which terminating provider verifies
+// with certificate fetched from x5u. Signing and verification are pluggable with Signer and Verifier
+// https://datatracker.ietf.org/doc/html/rfc8224
+// https://datatracker.ietf.org/doc/html/rfc8588
+package stir
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/emiago/sipgo"
+	"github.com/emiago/sipgo/sip"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrIdentityMissing = errors.New("stir: Identity header missing")
+)
+
+// VerifyTimeout limits verification done by Handler, which includes certificate fetch
+var VerifyTimeout = 5 * time.Second
+
+// Signer signs outbound INVITE by adding Identity header
+type Signer interface {
+	Sign(ctx context.Context, req *sip.Request, attest Attestation) error
+}
+
+// Verifier verifies Identity header of inbound INVITE. Returned error should be *Error
+type Verifier interface {
+	Verify(ctx context.Context, req *sip.Request) (Result, error)
+}
+
+// CertFetcher fetches certificate chain from x5u URL. Leaf certificate is first
+type CertFetcher interface {
+	Fetch(ctx context.Context, x5u string) ([]*x509.Certificate, error)
+}
+
+// Result is verified caller identity
+type Result struct {
+	Attestation Attestation
+	// Orig is verified calling number or URI
+	Orig string
+	// OrigID is origination identifier set by signer
+	OrigID string
+	// X5U is URL of signer certificate
+	X5U string
+	// Cert is signer certificate
+	Cert *x509.Certificate
+}
+
+// Error is verification failure with response code for rejecting request.
+// It unwraps to sip.StatusError, so returning it from handler registered with OnRequestErr responds with its code
+// https://datatracker.ietf.org/doc/html/rfc8224#section-6.2.2
+type Error struct {
+	Code   sip.StatusCode
+	Reason string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("stir: %d %s", e.Code, e.Reason)
+	}
+	return fmt.Sprintf("stir: %d %s: %s", e.Code, e.Reason, e.Err)
+}
+
+func (e *Error) Unwrap() []error {
+	errs := []error{sip.StatusError{Code: e.Code, Reason: e.Reason}}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// ECDSASigner signs PASSporT with SHAKEN extension using provider ES256 key
+type ECDSASigner struct {
+	key *ecdsa.PrivateKey
+	x5u string
+}
+
+// NewSigner creates signer with private key and URL where its certificate is published
+func NewSigner(key *ecdsa.PrivateKey, x5u string) *ECDSASigner {
+	return &ECDSASigner{key: key, x5u: x5u}
+}
+
+// Sign adds Identity header to INVITE. Calling number is taken from P-Asserted-Identity or From
+// and called number from To. Non telephone number identities are signed as URI
+func (s *ECDSASigner) Sign(ctx context.Context, req *sip.Request, attest Attestation) error {
+	claims := Claims{
+		Attest: attest,
+		Iat:    time.Now().Unix(),
+		OrigID: uuid.NewString(),
+	}
+	if orig, ok := callingIdentity(req); ok {
+		claims.Orig = identityOf(orig)
+	} else {
+		return errors.New("stir: calling identity missing")
+	}
+	if to := req.To(); to != nil {
+		dest := identityOf(to.Address)
+		if dest.TN != "" {
+			claims.Dest.TN = []string{dest.TN}
+		} else {
+			claims.Dest.URI = []string{dest.URI}
+		}
+	}
+
+	token, err := Sign(Header{Ppt: PPTShaken, X5U: s.x5u}, claims, s.key)
+	if err != nil {
+		return err
+	}
+	h := &sip.IdentityHeader{Token: token, Info: s.x5u, Params: sip.NewParams()}
+	h.Params.Add("alg", AlgES256)
+	h.Params.Add("ppt", PPTShaken)
+	req.AppendHeader(h)
+	return nil
+}
+
+// CertVerifier verifies SHAKEN PASSporT with certificate fetched from x5u
+type CertVerifier struct {
+	fetcher CertFetcher
+	roots   *x509.CertPool
+	maxAge  time.Duration
+}
+
+type VerifierOption func(v *CertVerifier)
+
+// WithVerifierMaxAge sets max age of PASSporT issue time. Default is 60s
+func WithVerifierMaxAge(d time.Duration) VerifierOption {
+	return func(v *CertVerifier) {
+		v.maxAge = d
+	}
+}
+
+// NewVerifier creates verifier. Roots are trusted certificate authorities, ex. STI-PA approved ones.
+// With nil roots certificate chain is not validated and only signature is checked, which should be used only for testing
+func NewVerifier(fetcher CertFetcher, roots *x509.CertPool, options ...VerifierOption) *CertVerifier {
+	v := &CertVerifier{
+		fetcher: fetcher,
+		roots:   roots,
+		maxAge:  60 * time.Second,
+	}
+	for _, o := range options {
+		o(v)
+	}
+	return v
+}
+
+// Verify verifies SHAKEN Identity header of request. PASSporT must be fresh, signed for calling and called number
+// of request and by certificate chaining to roots
+func (v *CertVerifier) Verify(ctx context.Context, req *sip.Request) (Result, error) {
+	var idh *sip.IdentityHeader
+	for _, h := range req.Identity() {
+		if ppt, ok := h.Params.Get("ppt"); !ok || strings.Trim(ppt, "\"") == PPTShaken {
+			idh = h
+			break
+		}
+	}
+	if idh == nil {
+		return Result{}, &Error{Code: sip.StatusUseIdentityHeader, Reason: "Use Identity Header", Err: ErrIdentityMissing}
+	}
+
+	p, err := Parse(idh.Token)
+	if err != nil {
+		return Result{}, &Error{Code: sip.StatusInvalidIdentityHeader, Reason: "Invalid Identity Header", Err: err}
+	}
+	if p.Header.Ppt != PPTShaken {
+		return Result{}, &Error{Code: sip.StatusInvalidIdentityHeader, Reason: "Invalid Identity Header", Err: fmt.Errorf("unsupported ppt %q", p.Header.Ppt)}
+	}
+
+	iat := time.Unix(p.Claims.Iat, 0)
+	if age := time.Since(iat); age > v.maxAge || age < -v.maxAge {
+		return Result{}, &Error{Code: sip.StatusForbidden, Reason: "Stale Date"}
+	}
+
+	if orig, ok := callingIdentity(req); !ok || !identityMatch(p.Claims.Orig.TN, p.Claims.Orig.URI, orig) {
+		return Result{}, &Error{Code: sip.StatusInvalidIdentityHeader, Reason: "Invalid Identity Header", Err: errors.New("orig does not match calling identity")}
+	}
+	if to := req.To(); to == nil || !destMatch(p.Claims.Dest, to.Address) {
+		return Result{}, &Error{Code: sip.StatusInvalidIdentityHeader, Reason: "Invalid Identity Header", Err: errors.New("dest does not match called identity")}
+	}
+
+	x5u := p.Header.X5U
+	if idh.Info != "" && idh.Info != x5u {
+		return Result{}, &Error{Code: sip.StatusBadIdentityInfo, Reason: "Bad Identity Info", Err: errors.New("info does not match x5u")}
+	}
+	certs, err := v.fetcher.Fetch(ctx, x5u)
+	if err != nil || len(certs) == 0 {
+		return Result{}, &Error{Code: sip.StatusBadIdentityInfo, Reason: "Bad Identity Info", Err: err}
+	}
+	if err := v.verifyChain(certs, iat); err != nil {
+		return Result{}, &Error{Code: sip.StatusUnsupportedCredential, Reason: "Unsupported Credential", Err: err}
+	}
+	if err := p.Verify(certs[0].PublicKey); err != nil {
+		return Result{}, &Error{Code: sip.StatusInvalidIdentityHeader, Reason: "Invalid Identity Header", Err: err}
+	}
+
+	res := Result{
+		Attestation: p.Claims.Attest,
+		Orig:        p.Claims.Orig.TN,
+		OrigID:      p.Claims.OrigID,
+		X5U:         x5u,
+		Cert:        certs[0],
+	}
+	if res.Orig == "" {
+		res.Orig = p.Claims.Orig.URI
+	}
+	return res, nil
+}
+
+func (v *CertVerifier) verifyChain(certs []*x509.Certificate, at time.Time) error {
+	if v.roots == nil {
+		return nil
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		CurrentTime:   at,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return err
+}
+
+// VerifiedHandler is request handler receiving result of Identity verification
+type VerifiedHandler func(req *sip.Request, tx sip.ServerTransaction, res Result, err error)
+
+// Handler verifies new incoming INVITE before passing it to next handler with result, so handler can
+// act on attestation level. Failed verification is passed as *Error, so handler decides to reject with its code
+// or continue unverified. Other requests, like re-INVITE, are passed without verification
+// Ex:
+//
+//	srv.OnInvite(stir.Handler(verifier, func(req *sip.Request, tx sip.ServerTransaction, res stir.Result, err error) {}))
+func Handler(v Verifier, next VerifiedHandler) sipgo.RequestHandler {
+	return func(req *sip.Request, tx sip.ServerTransaction) {
+		if !req.IsInvite() {
+			next(req, tx, Result{}, nil)
+			return
+		}
+		if to := req.To(); to != nil {
+			if _, exists := to.Params["tag"]; exists {
+				next(req, tx, Result{}, nil)
+				return
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), VerifyTimeout)
+		defer cancel()
+		res, err := v.Verify(ctx, req)
+		if err != nil {
+			req.Trail().Addf("stir", "verification failed: %s", err)
+		} else {
+			req.Trail().Addf("stir", "verified %s with attestation %s", res.Orig, res.Attestation)
+		}
+		next(req, tx, res, err)
+	}
+}
+
+// callingIdentity returns calling identity. P-Asserted-Identity is preferred over From
+func callingIdentity(req *sip.Request) (sip.Uri, bool) {
+	if pai := req.PAssertedIdentity(); len(pai) > 0 {
+		return pai[0].Address, true
+	}
+	if from := req.From(); from != nil {
+		return from.Address, true
+	}
+	return sip.Uri{}, false
+}
+
+// identityOf returns telephone number of uri in canonical form, or uri in case it is not number
+func identityOf(uri sip.Uri) Orig {
+	if tn, ok := canonicalTN(uri); ok {
+		return Orig{TN: tn}
+	}
+	return Orig{URI: uri.Addr()}
+}
+
+func identityMatch(tn string, uriStr string, uri sip.Uri) bool {
+	id := identityOf(uri)
+	if tn != "" {
+		return id.TN == tn
+	}
+	return uriStr != "" && id.URI == uriStr
+}
+
+func destMatch(dest Dest, uri sip.Uri) bool {
+	id := identityOf(uri)
+	for _, tn := range dest.TN {
+		if id.TN != "" && id.TN == tn {
+			return true
+		}
+	}
+	for _, u := range dest.URI {
+		if id.URI != "" && id.URI == u {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalTN returns number of tel uri or sip uri user with only digits, as in RFC 8224 canonicalization
+// https://datatracker.ietf.org/doc/html/rfc8224#section-8.3
+func canonicalTN(uri sip.Uri) (string, bool) {
+	user := uri.User
+	if !uri.Tel {
+		if up, _ := uri.UriParams.Get("user"); up != "phone" && !strings.HasPrefix(user, "+") {
+			// Plain numeric user is treated as number too
+			if strings.Trim(user, "0123456789") != "" {
+				return "", false
+			}
+		}
+	}
+
+	var b strings.Builder
+	for _, c := range user {
+		switch {
+		case c >= '0' && c <= '9', c == '*', c == '#':
+			b.WriteRune(c)
+		case c == '+', c == '-', c == '.', c == '(', c == ')':
+		default:
+			return "", false
+		}
+	}
+	if b.Len() == 0 {
+		return "", false
+	}
+	return b.String(), true
+}
@@ -0,0 +1,185 @@
+package stir
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCertificate(t *testing.T) (*ecdsa.PrivateKey, *x509.Certificate, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "SHAKEN Test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return key, cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func testInvite(t *testing.T) *sip.Request {
+	msg, err := sip.ParseMessage([]byte("INVITE sip:+12155551213@example.com SIP/2.0\r\n" +
+		"Via: SIP/2.0/UDP 127.0.0.2:5060;branch=" + sip.GenerateBranch() + "\r\n" +
+		"From: <tel:+1-215-555-1212>;tag=" + sip.GenerateTagN(8) + "\r\n" +
+		"To: <sip:+12155551213@example.com;user=phone>\r\n" +
+		"Call-ID: " + sip.GenerateTagN(16) + "\r\n" +
+		"CSeq: 1 INVITE\r\n" +
+		"Content-Length: 0\r\n\r\n"))
+	require.NoError(t, err)
+	return msg.(*sip.Request)
+}
+
+func TestPASSporT(t *testing.T) {
+	key, _, _ := testCertificate(t)
+	claims := Claims{
+		Attest: AttestationFull,
+		Dest:   Dest{TN: []string{"12155551213"}},
+		Iat:    1443208345,
+		Orig:   Orig{TN: "12155551212"},
+		OrigID: "123e4567-e89b-12d3-a456-426655440000",
+	}
+	token, err := Sign(Header{Ppt: PPTShaken, X5U: "https://cert.example.org/passport.cer"}, claims, key)
+	require.NoError(t, err)
+
+	p, err := Parse(token)
+	require.NoError(t, err)
+	assert.Equal(t, Header{Alg: AlgES256, Ppt: PPTShaken, Typ: TypPassport, X5U: "https://cert.example.org/passport.cer"}, p.Header)
+	assert.Equal(t, claims, p.Claims)
+	require.NoError(t, p.Verify(&key.PublicKey))
+
+	other, _, _ := testCertificate(t)
+	require.ErrorIs(t, p.Verify(&other.PublicKey), ErrPASSporTSignature)
+
+	for _, tok := range []string{"a.b", "a..c", "!.b.c", token[:len(token)-3] + "###"} {
+		_, err := Parse(tok)
+		assert.ErrorIs(t, err, ErrPASSporTInvalid, tok)
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	key, cert, certPEM := testCertificate(t)
+	fetched := 0
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched++
+		w.Write(certPEM)
+	}))
+	defer srv.Close()
+	x5u := srv.URL + "/passport.pem"
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	fetcher := NewHTTPCertFetcher(srv.Client(), time.Minute)
+	verifier := NewVerifier(fetcher, roots)
+	signer := NewSigner(key, x5u)
+	ctx := context.Background()
+
+	req := testInvite(t)
+	require.NoError(t, signer.Sign(ctx, req, AttestationFull))
+	idh := req.Identity()
+	require.Len(t, idh, 1)
+	assert.Equal(t, x5u, idh[0].Info)
+
+	// Header is verified as received from network
+	msg, err := sip.ParseMessage([]byte(req.String()))
+	require.NoError(t, err)
+	res, err := verifier.Verify(ctx, msg.(*sip.Request))
+	require.NoError(t, err)
+	assert.Equal(t, AttestationFull, res.Attestation)
+	assert.Equal(t, "12155551212", res.Orig)
+	assert.Equal(t, x5u, res.X5U)
+	assert.Equal(t, cert, res.Cert)
+
+	verifyCode := func(req *sip.Request, v Verifier) sip.StatusCode {
+		t.Helper()
+		_, err := v.Verify(ctx, req)
+		var se sip.StatusError
+		require.True(t, errors.As(err, &se), err)
+		return se.Code
+	}
+
+	// Called number changed after signing
+	signed := testInvite(t)
+	require.NoError(t, signer.Sign(ctx, signed, AttestationPartial))
+	signed.To().Address.User = "+12155559999"
+	assert.Equal(t, sip.StatusInvalidIdentityHeader, verifyCode(signed, verifier))
+
+	assert.Equal(t, sip.StatusUseIdentityHeader, verifyCode(testInvite(t), verifier))
+
+	// Certificate not trusted
+	_, otherCert, _ := testCertificate(t)
+	otherRoots := x509.NewCertPool()
+	otherRoots.AddCert(otherCert)
+	signed = testInvite(t)
+	require.NoError(t, signer.Sign(ctx, signed, AttestationGateway))
+	assert.Equal(t, sip.StatusUnsupportedCredential, verifyCode(signed, NewVerifier(fetcher, otherRoots)))
+
+	// Stale PASSporT
+	assert.Equal(t, sip.StatusForbidden, verifyCode(signed, NewVerifier(fetcher, roots, WithVerifierMaxAge(-time.Second))))
+
+	// Certificate can not be fetched
+	assert.Equal(t, sip.StatusBadIdentityInfo, verifyCode(signed, NewVerifier(NewHTTPCertFetcher(http.DefaultClient, 0), roots)))
+	assert.Equal(t, 1, fetched)
+}
+
+func TestHandler(t *testing.T) {
+	key, _, certPEM := testCertificate(t)
+	certs, err := ParseCertificates(certPEM)
+	require.NoError(t, err)
+	verifier := NewVerifier(fetcherFunc(func(ctx context.Context, x5u string) ([]*x509.Certificate, error) {
+		return certs, nil
+	}), nil)
+
+	req := testInvite(t)
+	require.NoError(t, NewSigner(key, "https://cert.example.org/passport.cer").Sign(context.Background(), req, AttestationPartial))
+
+	var result Result
+	var verr error
+	h := Handler(verifier, func(req *sip.Request, tx sip.ServerTransaction, res Result, err error) {
+		result, verr = res, err
+	})
+	h(req, nil)
+	require.NoError(t, verr)
+	assert.Equal(t, AttestationPartial, result.Attestation)
+
+	// In dialog requests are not verified
+	req = testInvite(t)
+	req.To().Params.Add("tag", "abc")
+	h(req, nil)
+	require.NoError(t, verr)
+	assert.Equal(t, Result{}, result)
+
+	req = testInvite(t)
+	h(req, nil)
+	var serr *Error
+	require.ErrorAs(t, verr, &serr)
+	assert.Equal(t, sip.StatusUseIdentityHeader, serr.Code)
+}
+
+type fetcherFunc func(ctx context.Context, x5u string) ([]*x509.Certificate, error)
+
+func (f fetcherFunc) Fetch(ctx context.Context, x5u string) ([]*x509.Certificate, error) {
+	return f(ctx, x5u)
+}
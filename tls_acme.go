@@ -0,0 +1,454 @@
+package sipgo
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/acme"
+)
+
+const (
+	ACMEChallengeDNS01  = "dns-01"
+	ACMEChallengeHTTP01 = "http-01"
+)
+
+var (
+	// ACMERetryInterval is delay before retrying failed certificate order
+	ACMERetryInterval = time.Minute
+
+	ErrACMENoCertificate = errors.New("acme: certificate not obtained yet")
+)
+
+// ACMEChallengeSolver proves control of domain to ACME CA
+type ACMEChallengeSolver interface {
+	// ChallengeType is ACMEChallengeDNS01 or ACMEChallengeHTTP01
+	ChallengeType() string
+	// Present publishes challenge response. For dns-01 value is TXT record of _acme-challenge.<domain>,
+	// for http-01 value is served on /.well-known/acme-challenge/<token>
+	Present(ctx context.Context, domain string, token string, value string) error
+	// CleanUp removes challenge response once authorization is done
+	CleanUp(ctx context.Context, domain string, token string, value string) error
+}
+
+// ACMEDNS01Solver solves dns-01 challenge with hooks managing TXT records on DNS provider.
+// It is only challenge allowing wildcard domains and CA not reaching SIP server
+type ACMEDNS01Solver struct {
+	// SetTXT creates TXT record with value for fqdn, ex. _acme-challenge.sip.example.com
+	SetTXT func(ctx context.Context, fqdn string, value string) error
+	// DeleteTXT removes TXT record. Optional
+	DeleteTXT func(ctx context.Context, fqdn string, value string) error
+}
+
+func (s *ACMEDNS01Solver) ChallengeType() string {
+	return ACMEChallengeDNS01
+}
+
+func (s *ACMEDNS01Solver) Present(ctx context.Context, domain string, token string, value string) error {
+	return s.SetTXT(ctx, "_acme-challenge."+domain, value)
+}
+
+func (s *ACMEDNS01Solver) CleanUp(ctx context.Context, domain string, token string, value string) error {
+	if s.DeleteTXT == nil {
+		return nil
+	}
+	return s.DeleteTXT(ctx, "_acme-challenge."+domain, value)
+}
+
+// ACMEHTTP01Solver solves http-01 challenge by serving responses as http.Handler.
+// It must be reachable on port 80 of domain. Ex:
+//
+//	solver := sipgo.NewACMEHTTP01Solver()
+//	go http.ListenAndServe(":80", solver)
+type ACMEHTTP01Solver struct {
+	mu        sync.RWMutex
+	responses map[string]string
+}
+
+func NewACMEHTTP01Solver() *ACMEHTTP01Solver {
+	return &ACMEHTTP01Solver{
+		responses: make(map[string]string),
+	}
+}
+
+func (s *ACMEHTTP01Solver) ChallengeType() string {
+	return ACMEChallengeHTTP01
+}
+
+func (s *ACMEHTTP01Solver) Present(ctx context.Context, domain string, token string, value string) error {
+	s.mu.Lock()
+	s.responses[token] = value
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *ACMEHTTP01Solver) CleanUp(ctx context.Context, domain string, token string, value string) error {
+	s.mu.Lock()
+	delete(s.responses, token)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *ACMEHTTP01Solver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, found := strings.CutPrefix(r.URL.Path, "/.well-known/acme-challenge/")
+	s.mu.RLock()
+	value, exists := s.responses[token]
+	s.mu.RUnlock()
+	if !found || !exists {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(value))
+}
+
+// ACMEConfig configures certificate provisioning with ACME
+type ACMEConfig struct {
+	// DirectoryURL of ACME CA. Default is Let's Encrypt
+	DirectoryURL string
+	// Email is account contact. Optional
+	Email string
+	// AccountKey is ACME account key. If nil it is loaded from CacheDir or generated
+	AccountKey crypto.Signer
+	// Domains are certificate names. First one should be advertised SIP domain
+	Domains []string
+	// Solver proves control of domains. Check ACMEDNS01Solver and ACMEHTTP01Solver
+	Solver ACMEChallengeSolver
+	// RenewBefore renews certificate before it expires. Default is 30 days,
+	// or third of lifetime for short lived certificates
+	RenewBefore time.Duration
+	// CacheDir stores account key and certificate, so they survive restart. Optional.
+	// Certificate is stored as <domain>.crt and <domain>.key
+	CacheDir string
+	// HTTPClient is used for ACME requests. Optional
+	HTTPClient *http.Client
+}
+
+// ACMECertificateManager is CertificateSource provisioning and renewing certificate with ACME.
+// New certificate is used for new handshakes, while existing connections stay open.
+// Ex:
+//
+//	m, _ := sipgo.NewACMECertificateManager(sipgo.ACMEConfig{
+//		Domains: []string{"sip.example.com"},
+//		Solver:  &sipgo.ACMEDNS01Solver{SetTXT: provider.SetTXT, DeleteTXT: provider.DeleteTXT},
+//		CacheDir: "certs",
+//	})
+//	go m.Run(ctx)
+//	conf := sipgo.CertificateTLSConfig("sip.example.com", m)
+type ACMECertificateManager struct {
+	conf   ACMEConfig
+	client *acme.Client
+	log    zerolog.Logger
+
+	// orderMu serializes orders
+	orderMu    sync.Mutex
+	registered bool
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// NewACMECertificateManager creates manager and loads cached certificate. Certificate is obtained with Run or Obtain
+func NewACMECertificateManager(conf ACMEConfig) (*ACMECertificateManager, error) {
+	if len(conf.Domains) == 0 {
+		return nil, errors.New("acme: no domains")
+	}
+	if conf.Solver == nil {
+		return nil, errors.New("acme: no challenge solver")
+	}
+	if conf.DirectoryURL == "" {
+		conf.DirectoryURL = acme.LetsEncryptURL
+	}
+	if conf.RenewBefore <= 0 {
+		conf.RenewBefore = 30 * 24 * time.Hour
+	}
+
+	m := &ACMECertificateManager{
+		conf: conf,
+		log:  log.Logger.With().Str("caller", "ACMECertificateManager").Logger(),
+	}
+
+	key, err := m.accountKey()
+	if err != nil {
+		return nil, err
+	}
+	m.client = &acme.Client{
+		Key:          key,
+		DirectoryURL: conf.DirectoryURL,
+		HTTPClient:   conf.HTTPClient,
+	}
+
+	if conf.CacheDir != "" {
+		certFile, keyFile := m.cacheFiles()
+		if cert, err := tls.LoadX509KeyPair(certFile, keyFile); err == nil {
+			m.setCertificate(&cert)
+		}
+	}
+	return m, nil
+}
+
+// GetCertificate returns current certificate. It implements CertificateSource
+func (m *ACMECertificateManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.cert == nil {
+		return nil, ErrACMENoCertificate
+	}
+	return m.cert, nil
+}
+
+// Run obtains certificate if missing and renews it before expiry until ctx is done.
+// Failed order is logged and retried after ACMERetryInterval
+func (m *ACMECertificateManager) Run(ctx context.Context) error {
+	for {
+		wait := time.Until(m.renewAt())
+		if wait <= 0 {
+			wait = 0
+			if err := m.Obtain(ctx); err != nil {
+				m.log.Error().Err(err).Strs("domains", m.conf.Domains).Msg("Failed to obtain certificate. Retrying")
+				wait = ACMERetryInterval
+			}
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Obtain orders new certificate, solving challenges for domains not yet authorized
+func (m *ACMECertificateManager) Obtain(ctx context.Context) error {
+	m.orderMu.Lock()
+	defer m.orderMu.Unlock()
+	if err := m.register(ctx); err != nil {
+		return err
+	}
+
+	order, err := m.client.AuthorizeOrder(ctx, acme.DomainIDs(m.conf.Domains...))
+	if err != nil {
+		return fmt.Errorf("acme: order: %w", err)
+	}
+	for _, u := range order.AuthzURLs {
+		if err := m.authorize(ctx, u); err != nil {
+			return err
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: m.conf.Domains[0]},
+		DNSNames: m.conf.Domains,
+	}, key)
+	if err != nil {
+		return err
+	}
+	der, _, err := m.client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return fmt.Errorf("acme: finalize: %w", err)
+	}
+
+	cert := &tls.Certificate{Certificate: der, PrivateKey: key}
+	if cert.Leaf, err = x509.ParseCertificate(der[0]); err != nil {
+		return fmt.Errorf("acme: parse certificate: %w", err)
+	}
+	m.setCertificate(cert)
+	m.log.Info().Strs("domains", m.conf.Domains).Time("notAfter", cert.Leaf.NotAfter).Msg("Certificate obtained")
+
+	if err := m.storeCertificate(cert, key); err != nil {
+		m.log.Error().Err(err).Msg("Failed to store certificate in cache")
+	}
+	return nil
+}
+
+func (m *ACMECertificateManager) register(ctx context.Context) error {
+	if m.registered {
+		return nil
+	}
+	acct := &acme.Account{}
+	if m.conf.Email != "" {
+		acct.Contact = []string{"mailto:" + m.conf.Email}
+	}
+	if _, err := m.client.Register(ctx, acct, acme.AcceptTOS); err != nil && !errors.Is(err, acme.ErrAccountAlreadyExists) {
+		return fmt.Errorf("acme: register: %w", err)
+	}
+	m.registered = true
+	return nil
+}
+
+func (m *ACMECertificateManager) authorize(ctx context.Context, authzURL string) error {
+	authz, err := m.client.GetAuthorization(ctx, authzURL)
+	if err != nil {
+		return fmt.Errorf("acme: authorization: %w", err)
+	}
+	if authz.Status == acme.StatusValid {
+		return nil
+	}
+
+	domain := authz.Identifier.Value
+	typ := m.conf.Solver.ChallengeType()
+	var chal *acme.Challenge
+	for _, c := range authz.Challenges {
+		if c.Type == typ {
+			chal = c
+			break
+		}
+	}
+	if chal == nil {
+		return fmt.Errorf("acme: %s challenge not offered for %s", typ, domain)
+	}
+
+	var value string
+	switch typ {
+	case ACMEChallengeDNS01:
+		value, err = m.client.DNS01ChallengeRecord(chal.Token)
+	case ACMEChallengeHTTP01:
+		value, err = m.client.HTTP01ChallengeResponse(chal.Token)
+	default:
+		err = fmt.Errorf("acme: challenge %s not supported", typ)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := m.conf.Solver.Present(ctx, domain, chal.Token, value); err != nil {
+		return fmt.Errorf("acme: present %s challenge for %s: %w", typ, domain, err)
+	}
+	defer func() {
+		if err := m.conf.Solver.CleanUp(ctx, domain, chal.Token, value); err != nil {
+			m.log.Error().Err(err).Str("domain", domain).Msg("Failed to clean up challenge")
+		}
+	}()
+
+	if _, err := m.client.Accept(ctx, chal); err != nil {
+		return fmt.Errorf("acme: accept %s challenge for %s: %w", typ, domain, err)
+	}
+	if _, err := m.client.WaitAuthorization(ctx, authz.URI); err != nil {
+		return fmt.Errorf("acme: authorize %s: %w", domain, err)
+	}
+	return nil
+}
+
+// renewAt returns time when certificate should be renewed. Zero time means it is missing
+func (m *ACMECertificateManager) renewAt() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.cert == nil || m.cert.Leaf == nil {
+		return time.Time{}
+	}
+
+	leaf := m.cert.Leaf
+	before := m.conf.RenewBefore
+	if lifetime := leaf.NotAfter.Sub(leaf.NotBefore); before >= lifetime {
+		before = lifetime / 3
+	}
+	return leaf.NotAfter.Add(-before)
+}
+
+func (m *ACMECertificateManager) setCertificate(cert *tls.Certificate) {
+	if cert.Leaf == nil && len(cert.Certificate) > 0 {
+		cert.Leaf, _ = x509.ParseCertificate(cert.Certificate[0])
+	}
+	m.mu.Lock()
+	m.cert = cert
+	m.mu.Unlock()
+}
+
+func (m *ACMECertificateManager) cacheFiles() (string, string) {
+	name := strings.ReplaceAll(m.conf.Domains[0], "*", "_")
+	return filepath.Join(m.conf.CacheDir, name+".crt"), filepath.Join(m.conf.CacheDir, name+".key")
+}
+
+func (m *ACMECertificateManager) storeCertificate(cert *tls.Certificate, key *ecdsa.PrivateKey) error {
+	if m.conf.CacheDir == "" {
+		return nil
+	}
+
+	var certPEM []byte
+	for _, der := range cert.Certificate {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.conf.CacheDir, 0700); err != nil {
+		return err
+	}
+	certFile, keyFile := m.cacheFiles()
+	// Key is written first, so files are never loaded as pair of new certificate and old key
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(certFile, certPEM, 0644)
+}
+
+// accountKey returns configured account key, or loads it from cache. New key is generated and cached if missing
+func (m *ACMECertificateManager) accountKey() (crypto.Signer, error) {
+	if m.conf.AccountKey != nil {
+		return m.conf.AccountKey, nil
+	}
+
+	var keyFile string
+	if m.conf.CacheDir != "" {
+		keyFile = filepath.Join(m.conf.CacheDir, "acme_account.key")
+		if data, err := os.ReadFile(keyFile); err == nil {
+			block, _ := pem.Decode(data)
+			if block == nil {
+				return nil, fmt.Errorf("acme: no PEM data in %s", keyFile)
+			}
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("acme: parse account key: %w", err)
+			}
+			signer, ok := key.(crypto.Signer)
+			if !ok {
+				return nil, fmt.Errorf("acme: account key %T is not signer", key)
+			}
+			return signer, nil
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if keyFile == "" {
+		return key, nil
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(m.conf.CacheDir, 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
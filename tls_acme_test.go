@@ -0,0 +1,341 @@
+package sipgo
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/acme"
+)
+
+// fakeACME is minimal RFC 8555 CA. Request signatures are not verified
+type fakeACME struct {
+	t        *testing.T
+	srv      *httptest.Server
+	caCert   *x509.Certificate
+	caKey    crypto.Signer
+	lifetime atomic.Int64
+	// validate checks challenge response presented by client
+	validate func(typ string, domain string, token string) bool
+
+	mu     sync.Mutex
+	authz  map[string]string
+	orders atomic.Int32
+	certs  map[string][]byte
+}
+
+func newFakeACME(t *testing.T, typ string, validate func(typ string, domain string, token string) bool) *fakeACME {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Fake ACME CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, caKey.Public(), caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	f := &fakeACME{
+		t:        t,
+		caCert:   caCert,
+		caKey:    caKey,
+		validate: validate,
+		authz:    make(map[string]string),
+		certs:    make(map[string][]byte),
+	}
+
+	f.lifetime.Store(int64(time.Hour))
+	var nonce atomic.Int64
+	mux := http.NewServeMux()
+	f.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Replay-Nonce", fmt.Sprintf("nonce-%d", nonce.Add(1)))
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(f.srv.Close)
+	url := f.srv.URL
+
+	mux.HandleFunc("/dir", func(w http.ResponseWriter, r *http.Request) {
+		f.json(w, http.StatusOK, map[string]string{
+			"newNonce":   url + "/nonce",
+			"newAccount": url + "/account",
+			"newOrder":   url + "/order",
+		})
+	})
+	mux.HandleFunc("/nonce", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/account", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", url+"/account/1")
+		f.json(w, http.StatusCreated, map[string]string{"status": "valid"})
+	})
+	mux.HandleFunc("/order", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Identifiers []struct{ Value string }
+		}
+		f.payload(r, &req)
+		n := f.orders.Add(1)
+		var authz []string
+		for _, id := range req.Identifiers {
+			authz = append(authz, url+"/authz/"+id.Value)
+		}
+		w.Header().Set("Location", fmt.Sprintf("%s/order/%d", url, n))
+		f.json(w, http.StatusCreated, map[string]any{
+			"status":         "pending",
+			"authorizations": authz,
+			"finalize":       fmt.Sprintf("%s/finalize/%d", url, n),
+		})
+	})
+	mux.HandleFunc("/authz/", func(w http.ResponseWriter, r *http.Request) {
+		f.json(w, http.StatusOK, f.authorization(strings.TrimPrefix(r.URL.Path, "/authz/"), typ))
+	})
+	mux.HandleFunc("/chal/", func(w http.ResponseWriter, r *http.Request) {
+		domain := strings.TrimPrefix(r.URL.Path, "/chal/")
+		status := "invalid"
+		if f.validate(typ, domain, "token-"+domain) {
+			status = "valid"
+		}
+		f.mu.Lock()
+		f.authz[domain] = status
+		f.mu.Unlock()
+		f.json(w, http.StatusOK, map[string]string{"type": typ, "url": url + r.URL.Path, "token": "token-" + domain, "status": status})
+	})
+	mux.HandleFunc("/finalize/", func(w http.ResponseWriter, r *http.Request) {
+		n := strings.TrimPrefix(r.URL.Path, "/finalize/")
+		var req struct{ CSR string }
+		f.payload(r, &req)
+		f.issue(n, req.CSR)
+		w.Header().Set("Location", url+"/order/"+n)
+		f.json(w, http.StatusOK, map[string]string{"status": "valid", "certificate": url + "/cert/" + n})
+	})
+	mux.HandleFunc("/cert/", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		w.Write(f.certs[strings.TrimPrefix(r.URL.Path, "/cert/")])
+	})
+	return f
+}
+
+func (f *fakeACME) json(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (f *fakeACME) payload(r *http.Request, v any) {
+	var jws struct{ Payload string }
+	require.NoError(f.t, json.NewDecoder(r.Body).Decode(&jws))
+	data, err := base64.RawURLEncoding.DecodeString(jws.Payload)
+	require.NoError(f.t, err)
+	require.NoError(f.t, json.Unmarshal(data, v))
+}
+
+func (f *fakeACME) authorization(domain string, typ string) map[string]any {
+	f.mu.Lock()
+	status, exists := f.authz[domain]
+	f.mu.Unlock()
+	if !exists {
+		status = "pending"
+	}
+	return map[string]any{
+		"status":     status,
+		"identifier": map[string]string{"type": "dns", "value": domain},
+		"challenges": []map[string]string{
+			{"type": "tls-alpn-01", "url": f.srv.URL + "/chal-alpn/" + domain, "token": "alpn-" + domain},
+			{"type": typ, "url": f.srv.URL + "/chal/" + domain, "token": "token-" + domain},
+		},
+	}
+}
+
+func (f *fakeACME) issue(n string, csrB64 string) {
+	der, err := base64.RawURLEncoding.DecodeString(csrB64)
+	require.NoError(f.t, err)
+	csr, err := x509.ParseCertificateRequest(der)
+	require.NoError(f.t, err)
+
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      csr.Subject,
+		DNSNames:     csr.DNSNames,
+		NotBefore:    now.Add(-time.Second),
+		NotAfter:     now.Add(time.Duration(f.lifetime.Load())),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	leaf, err := x509.CreateCertificate(rand.Reader, tmpl, f.caCert, csr.PublicKey, f.caKey)
+	require.NoError(f.t, err)
+
+	chain := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf})
+	chain = append(chain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: f.caCert.Raw})...)
+	f.mu.Lock()
+	f.certs[n] = chain
+	f.mu.Unlock()
+}
+
+func TestACMECertificateManagerDNS01(t *testing.T) {
+	accountKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	keyAuth := &acme.Client{Key: accountKey}
+
+	var mu sync.Mutex
+	records := make(map[string]string)
+	deleted := make(chan string, 10)
+	solver := &ACMEDNS01Solver{
+		SetTXT: func(ctx context.Context, fqdn string, value string) error {
+			mu.Lock()
+			records[fqdn] = value
+			mu.Unlock()
+			return nil
+		},
+		DeleteTXT: func(ctx context.Context, fqdn string, value string) error {
+			mu.Lock()
+			delete(records, fqdn)
+			mu.Unlock()
+			deleted <- fqdn
+			return nil
+		},
+	}
+
+	ca := newFakeACME(t, ACMEChallengeDNS01, func(typ string, domain string, token string) bool {
+		expected, err := keyAuth.DNS01ChallengeRecord(token)
+		require.NoError(t, err)
+		mu.Lock()
+		defer mu.Unlock()
+		return records["_acme-challenge."+domain] == expected
+	})
+	// Certificate times have second precision
+	ca.lifetime.Store(int64(3 * time.Second))
+
+	dir := t.TempDir()
+	conf := ACMEConfig{
+		DirectoryURL: ca.srv.URL + "/dir",
+		Email:        "admin@example.com",
+		AccountKey:   accountKey,
+		Domains:      []string{"sip.example.com"},
+		Solver:       solver,
+		RenewBefore:  2 * time.Second,
+		CacheDir:     dir,
+	}
+	m, err := NewACMECertificateManager(conf)
+	require.NoError(t, err)
+	_, err = m.GetCertificate(nil)
+	require.ErrorIs(t, err, ErrACMENoCertificate)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.Run(ctx)
+
+	require.Eventually(t, func() bool {
+		_, err := m.GetCertificate(nil)
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "_acme-challenge.sip.example.com", <-deleted)
+
+	tlsConf := CertificateTLSConfig("sip.example.com", m)
+	cert, err := tlsConf.GetCertificate(&tls.ClientHelloInfo{ServerName: "sip.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sip.example.com"}, cert.Leaf.DNSNames)
+	assert.Equal(t, "Fake ACME CA", cert.Leaf.Issuer.CommonName)
+	assert.Len(t, cert.Certificate, 2)
+
+	// Renewed before expiry
+	require.Eventually(t, func() bool {
+		renewed, _ := m.GetCertificate(nil)
+		return renewed.Leaf.SerialNumber.Cmp(cert.Leaf.SerialNumber) != 0
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.True(t, time.Now().Before(cert.Leaf.NotAfter))
+	cancel()
+
+	// Cached certificate is loaded on restart
+	_, err = os.Stat(filepath.Join(dir, "sip.example.com.key"))
+	require.NoError(t, err)
+	ca.lifetime.Store(int64(time.Hour))
+	require.NoError(t, m.Obtain(context.Background()))
+	orders := ca.orders.Load()
+
+	m2, err := NewACMECertificateManager(conf)
+	require.NoError(t, err)
+	cached, err := m2.GetCertificate(nil)
+	require.NoError(t, err)
+	last, _ := m.GetCertificate(nil)
+	assert.Equal(t, last.Certificate, cached.Certificate)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	m2.Run(ctx)
+	assert.Equal(t, orders, ca.orders.Load())
+}
+
+func TestACMECertificateManagerHTTP01(t *testing.T) {
+	solver := NewACMEHTTP01Solver()
+	var keyAuth *acme.Client
+	ca := newFakeACME(t, ACMEChallengeHTTP01, func(typ string, domain string, token string) bool {
+		expected, err := keyAuth.HTTP01ChallengeResponse(token)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		solver.ServeHTTP(rec, httptest.NewRequest("GET", "http://"+domain+keyAuth.HTTP01ChallengePath(token), nil))
+		return rec.Code == http.StatusOK && rec.Body.String() == expected
+	})
+
+	m, err := NewACMECertificateManager(ACMEConfig{
+		DirectoryURL: ca.srv.URL + "/dir",
+		Domains:      []string{"sip.example.com", "sip2.example.com"},
+		Solver:       solver,
+	})
+	require.NoError(t, err)
+	keyAuth = &acme.Client{Key: m.client.Key}
+
+	require.NoError(t, m.Obtain(context.Background()))
+	cert, err := m.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sip.example.com", "sip2.example.com"}, cert.Leaf.DNSNames)
+
+	// Challenge responses are removed
+	rec := httptest.NewRecorder()
+	solver.ServeHTTP(rec, httptest.NewRequest("GET", "/.well-known/acme-challenge/token-sip.example.com", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestACMECertificateManagerChallengeFailed(t *testing.T) {
+	ca := newFakeACME(t, ACMEChallengeDNS01, func(typ string, domain string, token string) bool { return false })
+	m, err := NewACMECertificateManager(ACMEConfig{
+		DirectoryURL: ca.srv.URL + "/dir",
+		Domains:      []string{"sip.example.com"},
+		Solver:       &ACMEDNS01Solver{SetTXT: func(ctx context.Context, fqdn, value string) error { return nil }},
+	})
+	require.NoError(t, err)
+	require.Error(t, m.Obtain(context.Background()))
+	_, err = m.GetCertificate(nil)
+	require.ErrorIs(t, err, ErrACMENoCertificate)
+
+	// Other challenge types are not offered
+	m.conf.Solver = NewACMEHTTP01Solver()
+	require.ErrorContains(t, m.Obtain(context.Background()), "http-01 challenge not offered")
+}
@@ -0,0 +1,176 @@
+package sipgo
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// CertificateSource provides TLS certificate on handshake. ACMECertificateManager implements it and
+// provisions and renews certificates for SIP domain with DNS-01 or HTTP-01 challenge.
+// Other ACME managers like autocert.Manager from golang.org/x/crypto/acme/autocert implement it as well
+type CertificateSource interface {
+	GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
+}
+
+// CertificateTLSConfig returns tls.Config which reads certificate from source on every handshake,
+// so renewed certificate is used without restarting listeners. Same certificate is presented
+// as client certificate for domain on outgoing connections, as required by mutual TLS peers.
+// Ex:
+//
+//	m, _ := sipgo.NewACMECertificateManager(sipgo.ACMEConfig{Domains: []string{"sip.example.com"}, Solver: solver, CacheDir: "certs"})
+//	go m.Run(ctx)
+//	conf := sipgo.CertificateTLSConfig("sip.example.com", m)
+//	ua, _ := sipgo.NewUA(sipgo.WithUserAgenTLSConfig(conf))
+//	srv.ListenAndServeTLS(ctx, "tcp", "0.0.0.0:5061", conf)
+func CertificateTLSConfig(domain string, source CertificateSource) *tls.Config {
+	return &tls.Config{
+		ServerName:     domain,
+		GetCertificate: source.GetCertificate,
+		GetClientCertificate: func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return source.GetCertificate(&tls.ClientHelloInfo{ServerName: domain})
+		},
+	}
+}
+
+// CertificateReloader is CertificateSource loading certificate and optional CA pool from files, which are renewed by
+// external ACME client like certbot or lego, or SPIFFE agent. Files are checked for change at most once per interval
+// and reloaded without restarting listeners, while existing connections stay open. On failed reload last valid
+// certificate and CA pool are kept. Ex. with client certificates verified by reloaded CA pool:
+//
+//	r, _ := sipgo.NewCertificateReloader("server.crt", "server.key", time.Minute, sipgo.WithCertificateReloaderCAFile("ca.pem"))
+//	ua, _ := sipgo.NewUA(
+//		sipgo.WithUserAgentTLSGetCertificate(r.GetCertificate),
+//		sipgo.WithUserAgentTLSMutualAuth(sip.TLSMutualAuth{GetClientCAs: r.ClientCAs, Required: true}),
+//	)
+type CertificateReloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+	interval time.Duration
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	cas     *x509.CertPool
+	modTime time.Time
+	checked time.Time
+}
+
+type CertificateReloaderOption func(r *CertificateReloader)
+
+// WithCertificateReloaderCAFile loads PEM CA pool from file along with certificate. Check ClientCAs
+func WithCertificateReloaderCAFile(caFile string) CertificateReloaderOption {
+	return func(r *CertificateReloader) {
+		r.caFile = caFile
+	}
+}
+
+// NewCertificateReloader loads certificate and key files. Interval is how often files are checked for change
+func NewCertificateReloader(certFile string, keyFile string, interval time.Duration, options ...CertificateReloaderOption) (*CertificateReloader, error) {
+	r := &CertificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: interval,
+	}
+	for _, o := range options {
+		o(r)
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate returns current certificate, reloading it in case files changed
+func (r *CertificateReloader) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.check()
+	return r.cert, nil
+}
+
+// ClientCAs returns current CA pool, reloading it in case files changed. It is nil without CA file.
+// It can be used as sip.TLSMutualAuth GetClientCAs
+func (r *CertificateReloader) ClientCAs() *x509.CertPool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.check()
+	return r.cas
+}
+
+// Reload forces loading files, ex. from ACME client deploy hook
+func (r *CertificateReloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.load()
+}
+
+func (r *CertificateReloader) check() {
+	if time.Since(r.checked) < r.interval {
+		return
+	}
+	r.checked = time.Now()
+	// On error last valid certificate is kept. Renewal may be in progress
+	r.reload()
+}
+
+func (r *CertificateReloader) reload() error {
+	modTime, err := r.filesModTime()
+	if err != nil {
+		return err
+	}
+	if !modTime.After(r.modTime) {
+		return nil
+	}
+	return r.load()
+}
+
+func (r *CertificateReloader) load() error {
+	modTime, err := r.filesModTime()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("fail to load cert. err=%w", err)
+	}
+
+	var cas *x509.CertPool
+	if r.caFile != "" {
+		data, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("fail to load CA. err=%w", err)
+		}
+		cas = x509.NewCertPool()
+		if !cas.AppendCertsFromPEM(data) {
+			return fmt.Errorf("fail to load CA. no certificates found in %s", r.caFile)
+		}
+	}
+
+	r.cert = &cert
+	r.cas = cas
+	r.modTime = modTime
+	r.checked = time.Now()
+	return nil
+}
+
+func (r *CertificateReloader) filesModTime() (time.Time, error) {
+	var modTime time.Time
+	for _, f := range []string{r.certFile, r.keyFile, r.caFile} {
+		if f == "" {
+			continue
+		}
+		st, err := os.Stat(f)
+		if err != nil {
+			return modTime, err
+		}
+		if st.ModTime().After(modTime) {
+			modTime = st.ModTime()
+		}
+	}
+	return modTime, nil
+}
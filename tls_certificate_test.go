@@ -0,0 +1,134 @@
+package sipgo
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertificateReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	copyFile := func(src, dst string) {
+		data, err := os.ReadFile(src)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(dst, data, 0600))
+	}
+	copyFile("testdata/certs/server.crt", certFile)
+	copyFile("testdata/certs/server.key", keyFile)
+
+	r, err := NewCertificateReloader(certFile, keyFile, 0)
+	require.NoError(t, err)
+	serverCert, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+
+	// Renewed certificate is picked up after files change
+	copyFile("testdata/certs/client.crt", certFile)
+	copyFile("testdata/certs/client.key", keyFile)
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+
+	conf := CertificateTLSConfig("sip.example.com", r)
+	cert, err := conf.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.NotEqual(t, serverCert.Certificate[0], cert.Certificate[0])
+
+	clientCert, err := conf.GetClientCertificate(&tls.CertificateRequestInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, cert, clientCert)
+
+	// Broken renewal keeps last certificate
+	require.NoError(t, os.WriteFile(keyFile, []byte("broken"), 0600))
+	require.NoError(t, os.Chtimes(keyFile, future.Add(time.Minute), future.Add(time.Minute)))
+	last, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, cert, last)
+	require.Error(t, r.Reload())
+}
+
+func TestCertificateReloaderClientCAs(t *testing.T) {
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	rootPEM, err := os.ReadFile("testdata/certs/rootca-cert.pem")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(caFile, rootPEM, 0600))
+
+	r, err := NewCertificateReloader("testdata/certs/server.crt", "testdata/certs/server.key", 0, WithCertificateReloaderCAFile(caFile))
+	require.NoError(t, err)
+	roots := r.ClientCAs()
+	require.NotNil(t, roots)
+
+	tp := sip.NewTransportLayer(net.DefaultResolver, sip.NewParser(), nil)
+	defer tp.Close()
+	tp.SetTLSGetCertificate(r.GetCertificate)
+	tp.SetTLSMutualAuth(sip.TLSMutualAuth{GetClientCAs: r.ClientCAs, Required: true})
+	l, err := tls.Listen("tcp", "127.0.0.1:0", tp.ApplyTLSMutualAuth(tp.ApplyTLSGetCertificate(nil)))
+	require.NoError(t, err)
+	defer l.Close()
+	go tp.ServeTLS(l)
+
+	clientCert, err := tls.LoadX509KeyPair("testdata/certs/client.crt", "testdata/certs/client.key")
+	require.NoError(t, err)
+	dial := func() *tls.Conn {
+		conn, err := tls.Dial("tcp", l.Addr().String(), &tls.Config{InsecureSkipVerify: true, Certificates: []tls.Certificate{clientCert}})
+		require.NoError(t, err)
+		return conn
+	}
+	// CRLF keepalive is answered only on accepted connection
+	ping := func(conn *tls.Conn) error {
+		if _, err := conn.Write([]byte("\r\n\r\n")); err != nil {
+			return err
+		}
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		pong := make([]byte, 2)
+		_, err := conn.Read(pong)
+		return err
+	}
+
+	oldConn := dial()
+	defer oldConn.Close()
+	require.NoError(t, ping(oldConn))
+
+	// Broken CA file keeps previous pool
+	require.NoError(t, os.WriteFile(caFile, []byte("broken"), 0600))
+	require.Error(t, r.Reload())
+	assert.Same(t, roots, r.ClientCAs())
+
+	// Rotated CA no longer trusts client, but existing connection stays open
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Other CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, caKey.Public(), caKey)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(caFile, future, future))
+	assert.NotSame(t, roots, r.ClientCAs())
+
+	conn := dial()
+	defer conn.Close()
+	require.Error(t, ping(conn))
+	require.NoError(t, ping(oldConn))
+}
@@ -0,0 +1,58 @@
+package sipgo
+
+import (
+	"context"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+// TransactionLayer is transaction layer used by Server for receiving requests and by Client for sending them.
+// Default is sip.TransactionLayer. Custom implementation can wrap default one, ex. for persisting
+// or sharing transactions between instances, or replace it to stub transactions in unit tests.
+// Check WithUserAgentTransactionLayer
+type TransactionLayer interface {
+	// OnRequest sets handler called with server transaction for every new request
+	OnRequest(h sip.RequestHandler)
+	// Request creates client transaction and sends request
+	Request(ctx context.Context, req *sip.Request) (sip.ClientTransaction, error)
+	// Respond sends response on existing server transaction matching response
+	Respond(res *sip.Response) (sip.ServerTransaction, error)
+	// Close terminates all transactions
+	Close()
+}
+
+// WithUserAgentTransactionLayer sets transaction layer created from default one.
+// Default transaction layer stays attached to transport layer, so implementation that replaces it
+// is responsible for passing requests to handler set with OnRequest
+// Ex:
+//
+//	sipgo.WithUserAgentTransactionLayer(func(txl sipgo.TransactionLayer) sipgo.TransactionLayer {
+//		return &persistentTransactionLayer{TransactionLayer: txl}
+//	})
+func WithUserAgentTransactionLayer(f func(txl TransactionLayer) TransactionLayer) UserAgentOption {
+	return func(s *UserAgent) error {
+		s.txLayerFunc = f
+		return nil
+	}
+}
+
+// transactionLayer adapts sip.TransactionLayer to TransactionLayer
+type transactionLayer struct {
+	*sip.TransactionLayer
+}
+
+func (txl transactionLayer) Request(ctx context.Context, req *sip.Request) (sip.ClientTransaction, error) {
+	tx, err := txl.TransactionLayer.Request(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (txl transactionLayer) Respond(res *sip.Response) (sip.ServerTransaction, error) {
+	tx, err := txl.TransactionLayer.Respond(res)
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
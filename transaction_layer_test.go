@@ -0,0 +1,76 @@
+package sipgo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubTransactionLayer records requests and passes incoming requests to handler without transport
+type stubTransactionLayer struct {
+	TransactionLayer
+	handler  sip.RequestHandler
+	requests []*sip.Request
+}
+
+func (txl *stubTransactionLayer) OnRequest(h sip.RequestHandler) {
+	txl.handler = h
+}
+
+func (txl *stubTransactionLayer) Request(ctx context.Context, req *sip.Request) (sip.ClientTransaction, error) {
+	txl.requests = append(txl.requests, req)
+	tx := &stubClientTx{responses: make(chan *sip.Response, 1), done: make(chan struct{})}
+	tx.responses <- sip.NewResponseFromRequest(req, sip.StatusOK, "OK", nil)
+	return tx, nil
+}
+
+type stubClientTx struct {
+	responses chan *sip.Response
+	done      chan struct{}
+}
+
+func (tx *stubClientTx) Terminate()                      {}
+func (tx *stubClientTx) Done() <-chan struct{}           { return tx.done }
+func (tx *stubClientTx) Err() error                      { return nil }
+func (tx *stubClientTx) Responses() <-chan *sip.Response { return tx.responses }
+func (tx *stubClientTx) Transport() string               { return "UDP" }
+func (tx *stubClientTx) Cancel(ctx context.Context) (*sip.Response, error) {
+	return nil, nil
+}
+
+func TestUserAgentTransactionLayer(t *testing.T) {
+	stub := &stubTransactionLayer{}
+	ua, err := NewUA(WithUserAgentTransactionLayer(func(txl TransactionLayer) TransactionLayer {
+		stub.TransactionLayer = txl
+		return stub
+	}))
+	require.NoError(t, err)
+	defer ua.Close()
+
+	srv, err := NewServer(ua)
+	require.NoError(t, err)
+	srv.OnOptions(func(req *sip.Request, tx sip.ServerTransaction) {
+		tx.Respond(sip.NewResponseFromRequest(req, sip.StatusOK, "OK", nil))
+	})
+	require.NotNil(t, stub.handler)
+
+	req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	req.Method = sip.OPTIONS
+	tx := siptest.NewServerTxRecorder(req)
+	stub.handler(req, tx)
+	require.Len(t, tx.Result(), 1)
+	assert.Equal(t, sip.StatusOK, tx.Result()[0].StatusCode)
+
+	cli, err := NewClient(ua)
+	require.NoError(t, err)
+	clientTx, err := cli.TransactionRequest(context.Background(), sip.NewRequest(sip.OPTIONS, &sip.Uri{User: "bob", Host: "127.0.0.1", Port: 5060}))
+	require.NoError(t, err)
+	res := <-clientTx.Responses()
+	assert.Equal(t, sip.StatusOK, res.StatusCode)
+	require.Len(t, stub.requests, 1)
+}
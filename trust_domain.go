@@ -0,0 +1,100 @@
+package sipgo
+
+import (
+	"github.com/emiago/sipgo/sip"
+)
+
+// IdentityAsserter returns identities to assert for request received from untrusted source, ex. by authenticated user.
+// Preferred are P-Preferred-Identity entries sent by UA. Returning none leaves request without P-Asserted-Identity
+type IdentityAsserter func(req *sip.Request, preferred []*sip.AssertedIdentityHeader) []*sip.AssertedIdentityHeader
+
+// TrustDomain applies RFC 3325 trust boundary on requests passing through proxy or B2BUA.
+// P-Asserted-Identity is accepted only from trusted peers and passed only to trusted peers,
+// unless privacy is not requested. P-Preferred-Identity never leaves proxy
+// Ex:
+//
+//	trusted, _ := sip.NewACL([]string{"198.51.100.0/24"}, nil)
+//	td := sipgo.NewTrustDomain(trusted, sipgo.WithTrustDomainAsserter(asserter))
+//	td.Ingress(req)
+//	td.Egress(req, dst)
+//
+// https://datatracker.ietf.org/doc/html/rfc3325#section-5
+type TrustDomain struct {
+	trusted  *sip.ACL
+	asserter IdentityAsserter
+	// stripUntrusted removes P-Asserted-Identity toward untrusted peer even if privacy is not requested
+	stripUntrusted bool
+}
+
+type TrustDomainOption func(d *TrustDomain)
+
+// WithTrustDomainAsserter sets asserter inserting P-Asserted-Identity for requests from untrusted sources
+func WithTrustDomainAsserter(fn IdentityAsserter) TrustDomainOption {
+	return func(d *TrustDomain) {
+		d.asserter = fn
+	}
+}
+
+// WithTrustDomainStripUntrusted removes P-Asserted-Identity toward untrusted peers even without Privacy: id
+func WithTrustDomainStripUntrusted() TrustDomainOption {
+	return func(d *TrustDomain) {
+		d.stripUntrusted = true
+	}
+}
+
+// NewTrustDomain creates trust domain with trusted peers. Trusted ACL should have allow list,
+// as ACL with empty allow list allows every address
+func NewTrustDomain(trusted *sip.ACL, options ...TrustDomainOption) *TrustDomain {
+	d := &TrustDomain{trusted: trusted}
+	for _, o := range options {
+		o(d)
+	}
+	return d
+}
+
+// Trusted reports is peer with address host:port within trust domain
+func (d *TrustDomain) Trusted(addr string) bool {
+	return addr != "" && d.trusted.AllowedAddr(addr)
+}
+
+// Ingress applies trust boundary on received request. P-Asserted-Identity from trusted source is passed as is.
+// From untrusted source it is removed and replaced by identity returned by asserter
+func (d *TrustDomain) Ingress(req *sip.Request) {
+	if d.Trusted(req.Source()) {
+		return
+	}
+
+	removed := req.RemoveHeaders("P-Asserted-Identity")
+	if d.asserter == nil {
+		if removed > 0 {
+			req.Trail().Addf("identity", "removed untrusted P-Asserted-Identity from %s", req.Source())
+		}
+		return
+	}
+
+	preferred := req.PPreferredIdentity()
+	for _, h := range d.asserter(req, preferred) {
+		h = h.Clone()
+		h.Preferred = false
+		req.AppendHeader(h)
+		req.Trail().Addf("identity", "asserted %s", h.Address.String())
+	}
+}
+
+// Egress applies trust boundary on request before it is forwarded to destination host:port.
+// P-Preferred-Identity is always removed. P-Asserted-Identity is removed toward untrusted peer
+// in case Privacy: id is requested or WithTrustDomainStripUntrusted is set
+func (d *TrustDomain) Egress(req *sip.Request, dst string) {
+	req.RemoveHeaders("P-Preferred-Identity")
+	if d.Trusted(dst) {
+		return
+	}
+
+	privacy := req.Privacy()
+	if !d.stripUntrusted && (privacy == nil || !privacy.Has(sip.PrivacyValueID)) {
+		return
+	}
+	if req.RemoveHeaders("P-Asserted-Identity") > 0 {
+		req.Trail().Addf("identity", "removed P-Asserted-Identity toward untrusted %s", dst)
+	}
+}
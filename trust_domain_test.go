@@ -0,0 +1,67 @@
+package sipgo
+
+import (
+	"testing"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrustDomain(t *testing.T) {
+	trusted, err := sip.NewACL([]string{"10.0.0.0/8"}, nil)
+	require.NoError(t, err)
+
+	td := NewTrustDomain(trusted, WithTrustDomainAsserter(func(req *sip.Request, preferred []*sip.AssertedIdentityHeader) []*sip.AssertedIdentityHeader {
+		// Only preferred identity of caller is allowed
+		for _, p := range preferred {
+			if p.Address.User == req.From().Address.User {
+				return []*sip.AssertedIdentityHeader{p}
+			}
+		}
+		return nil
+	}))
+
+	newRequest := func(source string) *sip.Request {
+		req, _, _ := createTestInvite(t, "sip:bob@example.com", "UDP", source)
+		req.SetSource(source)
+		req.AppendHeader(sip.NewHeader("P-Asserted-Identity", "<sip:spoofed@example.com>"))
+		return req
+	}
+
+	// Trusted source keeps identity
+	req := newRequest("10.1.1.1:5060")
+	td.Ingress(req)
+	require.Len(t, req.PAssertedIdentity(), 1)
+	assert.Equal(t, "spoofed", req.PAssertedIdentity()[0].Address.User)
+
+	// Untrusted source gets identity from asserter
+	req = newRequest("192.0.2.1:5060")
+	req.AppendHeader(&sip.AssertedIdentityHeader{Preferred: true, Address: sip.Uri{User: req.From().Address.User, Host: "example.com"}})
+	td.Ingress(req)
+	pai := req.PAssertedIdentity()
+	require.Len(t, pai, 1)
+	assert.Equal(t, req.From().Address.User, pai[0].Address.User)
+	assert.False(t, pai[0].Preferred)
+
+	// Preferred identity never leaves and asserted passes to trusted
+	td.Egress(req, "10.2.2.2:5060")
+	assert.Empty(t, req.PPreferredIdentity())
+	assert.Len(t, req.PAssertedIdentity(), 1)
+
+	// Untrusted hop gets identity only without privacy
+	td.Egress(req, "203.0.113.1:5060")
+	assert.Len(t, req.PAssertedIdentity(), 1)
+	req.AppendHeader(&sip.PrivacyHeader{Values: []string{sip.PrivacyValueID}})
+	td.Egress(req, "203.0.113.1:5060")
+	assert.Empty(t, req.PAssertedIdentity())
+
+	// Without asserter untrusted identity is removed
+	req = newRequest("192.0.2.1:5060")
+	NewTrustDomain(trusted).Ingress(req)
+	assert.Empty(t, req.PAssertedIdentity())
+
+	req = newRequest("10.1.1.1:5060")
+	NewTrustDomain(trusted, WithTrustDomainStripUntrusted()).Egress(req, "203.0.113.1:5060")
+	assert.Empty(t, req.PAssertedIdentity())
+}
@@ -2,20 +2,54 @@ package sipgo
 
 import (
 	"crypto/tls"
+	"errors"
+	"fmt"
 	"net"
+	"sync/atomic"
 
 	"github.com/emiago/sipgo/sip"
 )
 
 type UserAgent struct {
-	name        string
-	hostname    string
-	ip          net.IP
-	dnsResolver *net.Resolver
-	tlsConfig   *tls.Config
-	parser      *sip.Parser
-	tp          *sip.TransportLayer
-	tx          *sip.TransactionLayer
+	name           string
+	hostname       string
+	ip             net.IP
+	ip6            net.IP
+	preferIPv6     bool
+	nat64Prefix    *net.IPNet
+	dnsResolver    *net.Resolver
+	dnsCache       *sip.DNSCacheConfig
+	blacklist      *sip.DestinationBlacklist
+	tlsConfig      *tls.Config
+	tlsALPN        sip.TLSALPN
+	tlsMutualAuth  *sip.TLSMutualAuth
+	tlsGetCert     sip.TLSGetCertificate
+	acl            *sip.ACL
+	parseGuard     *sip.ParseErrorGuard
+	stun           *sip.STUNConfig
+	callSlots      *callSlots
+	autoAnswer     AutoAnswerPolicy
+	dialer         sip.Dialer
+	packetListener sip.PacketListener
+	quic           *uaQUIC
+	poolConf       *sip.ConnectionPoolConfig
+	socketOpts     *sip.SocketOptions
+	profLabels     bool
+	txJournal      *sip.TxJournal
+	// dialogs is number of active dialog sessions of dialog client and server
+	dialogs atomic.Int64
+	parser  *sip.Parser
+	tp      *sip.TransportLayer
+	// txl is default transaction layer attached to transport layer
+	txl *sip.TransactionLayer
+	// tx is transaction layer used by server and client. Default is txl
+	tx          TransactionLayer
+	txLayerFunc func(txl TransactionLayer) TransactionLayer
+}
+
+type uaQUIC struct {
+	dialer  sip.QUICDialer
+	framing sip.QUICFraming
 }
 
 type UserAgentOption func(s *UserAgent) error
@@ -45,6 +79,29 @@ func WithUserAgentDNSResolver(r *net.Resolver) UserAgentOption {
 	}
 }
 
+// WithUserAgentDNSCache caches DNS results of transport layer with TTL and negative caching,
+// so destinations are not resolved for every outbound transaction
+func WithUserAgentDNSCache(conf sip.DNSCacheConfig) UserAgentOption {
+	return func(s *UserAgent) error {
+		s.dnsCache = &conf
+		return nil
+	}
+}
+
+// WithUserAgentDestinationBlacklist skips failing destinations during target selection.
+// Client records timeouts, transport errors and 503 responses of transactions sent to resolved hosts
+// Ex:
+//
+//	bl := sip.NewDestinationBlacklist(5*time.Second, 5*time.Minute)
+//	ua, _ := sipgo.NewUA(sipgo.WithUserAgentDestinationBlacklist(bl))
+//	entries := bl.Entries()
+func WithUserAgentDestinationBlacklist(bl *sip.DestinationBlacklist) UserAgentOption {
+	return func(s *UserAgent) error {
+		s.blacklist = bl
+		return nil
+	}
+}
+
 // WithUserAgenTLSConfig allows customizing default tls config.
 func WithUserAgenTLSConfig(c *tls.Config) UserAgentOption {
 	return func(s *UserAgent) error {
@@ -53,6 +110,163 @@ func WithUserAgenTLSConfig(c *tls.Config) UserAgentOption {
 	}
 }
 
+// WithUserAgentTLSALPN sets ALPN protocols offered on TLS connections and optional handler
+// for accepted connections negotiating other protocol than SIP
+func WithUserAgentTLSALPN(alpn sip.TLSALPN) UserAgentOption {
+	return func(s *UserAgent) error {
+		s.tlsALPN = alpn
+		return nil
+	}
+}
+
+// WithUserAgentTLSMutualAuth requires or verifies client certificates on TLS listeners, so peers like trunks
+// can be authenticated by certificate. Verified identity is available with ConnectionInfo of request
+func WithUserAgentTLSMutualAuth(m sip.TLSMutualAuth) UserAgentOption {
+	return func(s *UserAgent) error {
+		s.tlsMutualAuth = &m
+		return nil
+	}
+}
+
+// WithUserAgentTLSGetCertificate sets certificate selection for TLS listeners, ex. SNICertificates.GetCertificate
+// for serving certificate per domain. Requested server name is available with ConnectionInfo of request
+func WithUserAgentTLSGetCertificate(fn sip.TLSGetCertificate) UserAgentOption {
+	return func(s *UserAgent) error {
+		s.tlsGetCert = fn
+		return nil
+	}
+}
+
+// WithUserAgentACL filters incoming connections and UDP packets by source address on all served listeners.
+// Filtering is done at transport layer before any parsing.
+// For per method rules check ACLHandler
+func WithUserAgentACL(acl *sip.ACL) UserAgentOption {
+	return func(s *UserAgent) error {
+		s.acl = acl
+		return nil
+	}
+}
+
+// WithUserAgentParseErrorGuard blocks sources on all served listeners after too many unparsable messages.
+// Ex. HTTP scanners flooding 5060
+func WithUserAgentParseErrorGuard(g *sip.ParseErrorGuard) UserAgentOption {
+	return func(s *UserAgent) error {
+		s.parseGuard = g
+		return nil
+	}
+}
+
+// WithUserAgentSTUN enables public address discovery with STUN for served UDP listeners.
+// Discovered address is advertised in Via, Contact and Record-Route
+func WithUserAgentSTUN(cfg sip.STUNConfig) UserAgentOption {
+	return func(s *UserAgent) error {
+		if cfg.Server == "" {
+			return errors.New("STUN server must be set")
+		}
+		s.stun = &cfg
+		return nil
+	}
+}
+
+// WithUserAgentDialer allows customizing dialer for outbound connections.
+// Can be used for binding to specific interface, VRF or dialing over proxy
+func WithUserAgentDialer(d sip.Dialer) UserAgentOption {
+	return func(s *UserAgent) error {
+		s.dialer = d
+		return nil
+	}
+}
+
+// WithUserAgentPacketListener allows customizing creation of UDP sockets for outbound requests
+func WithUserAgentPacketListener(lc sip.PacketListener) UserAgentOption {
+	return func(s *UserAgent) error {
+		s.packetListener = lc
+		return nil
+	}
+}
+
+// WithUserAgentSocketOptions sets socket options like TCP keepalive and DSCP marking on listeners
+// created by server ListenAndServe and on outbound connections. Custom dialer has priority.
+// For per listener options create listener with SocketOptions ListenConfig
+func WithUserAgentSocketOptions(o sip.SocketOptions) UserAgentOption {
+	return func(s *UserAgent) error {
+		s.socketOpts = &o
+		return nil
+	}
+}
+
+// WithUserAgentConnectionPool sets idle timeout and limits of TCP, TLS, WS and WSS connections.
+// Pool occupancy is available with TransportLayer ConnectionPoolStats
+func WithUserAgentConnectionPool(conf sip.ConnectionPoolConfig) UserAgentOption {
+	return func(s *UserAgent) error {
+		s.poolConf = &conf
+		return nil
+	}
+}
+
+// WithUserAgentQUIC enables experimental SIP over QUIC transport. Dialer adapts QUIC library
+// for outbound connections and can be nil for serving only. Peers must use same framing
+func WithUserAgentQUIC(dialer sip.QUICDialer, framing sip.QUICFraming) UserAgentOption {
+	return func(s *UserAgent) error {
+		s.quic = &uaQUIC{dialer: dialer, framing: framing}
+		return nil
+	}
+}
+
+// WithUserAgentIPv6 sets advertised IPv6 address for dual-stack setup.
+// It is used on Via and Record-Route instead of user agent IP when request destination is IPv6
+func WithUserAgentIPv6(ip net.IP) UserAgentOption {
+	return func(s *UserAgent) error {
+		if ip.To16() == nil || ip.To4() != nil {
+			return fmt.Errorf("invalid IPv6 address ip=%s", ip)
+		}
+		s.ip6 = ip
+		return nil
+	}
+}
+
+// WithUserAgentPreferIPv6 prefers AAAA over A results when resolving destinations.
+// It is enabled by default when user agent IP is IPv6, as on IPv6-only host
+func WithUserAgentPreferIPv6() UserAgentOption {
+	return func(s *UserAgent) error {
+		s.preferIPv6 = true
+		return nil
+	}
+}
+
+// WithUserAgentNAT64Prefix makes IPv4 destinations reachable from IPv6-only host through NAT64.
+// Names resolved by DNS64 resolver need no prefix, it is needed for IPv4 literals like in Contact or Record-Route.
+// Prefix can be discovered with sip.DiscoverNAT64Prefix
+// https://datatracker.ietf.org/doc/html/rfc6052
+func WithUserAgentNAT64Prefix(prefix *net.IPNet) UserAgentOption {
+	return func(s *UserAgent) error {
+		if ones, bits := prefix.Mask.Size(); bits != 128 || ones > 96 {
+			return fmt.Errorf("invalid NAT64 prefix %s", prefix)
+		}
+		s.nat64Prefix = prefix
+		return nil
+	}
+}
+
+// WithUserAgentProfilingLabels attaches pprof labels with method and Call-ID hash
+// on goroutines processing incoming requests and responses.
+// Check TransactionLayer.SetProfilingLabels
+func WithUserAgentProfilingLabels() UserAgentOption {
+	return func(s *UserAgent) error {
+		s.profLabels = true
+		return nil
+	}
+}
+
+// WithUserAgentTransactionJournal records recent transaction state transitions in bounded journal
+// for post-mortem analysis of stuck calls. Check sip.TxJournal
+func WithUserAgentTransactionJournal(j *sip.TxJournal) UserAgentOption {
+	return func(s *UserAgent) error {
+		s.txJournal = j
+		return nil
+	}
+}
+
 func WithUserAgentParser(p *sip.Parser) UserAgentOption {
 	return func(s *UserAgent) error {
 		s.parser = p
@@ -88,13 +302,53 @@ func NewUA(options ...UserAgentOption) (*UserAgent, error) {
 	}
 
 	ua.tp = sip.NewTransportLayer(ua.dnsResolver, ua.parser, ua.tlsConfig)
-	ua.tx = sip.NewTransactionLayer(ua.tp)
+	if ua.dnsCache != nil {
+		ua.tp.SetDNSResolver(sip.NewDNSCache(ua.dnsResolver, *ua.dnsCache))
+	}
+	ua.tp.ACL = ua.acl
+	ua.tp.ParseGuard = ua.parseGuard
+	ua.tp.STUN = ua.stun
+	ua.tp.PreferIPv6 = ua.preferIPv6 || ua.ip.To4() == nil
+	ua.tp.NAT64Prefix = ua.nat64Prefix
+	ua.tp.Blacklist = ua.blacklist
+	ua.tp.SetTLSALPN(ua.tlsALPN)
+	if ua.tlsGetCert != nil {
+		ua.tp.SetTLSGetCertificate(ua.tlsGetCert)
+	}
+	if ua.tlsMutualAuth != nil {
+		ua.tp.SetTLSMutualAuth(*ua.tlsMutualAuth)
+	}
+	if ua.socketOpts != nil {
+		ua.tp.SetSocketOptions(*ua.socketOpts)
+	}
+	if ua.dialer != nil {
+		ua.tp.SetDialer(ua.dialer)
+	}
+	if ua.packetListener != nil {
+		ua.tp.SetPacketListener(ua.packetListener)
+	}
+	if ua.quic != nil {
+		ua.tp.EnableQUIC(ua.quic.dialer, ua.quic.framing)
+	}
+	if ua.poolConf != nil {
+		ua.tp.SetConnectionPool(*ua.poolConf)
+	}
+	ua.txl = sip.NewTransactionLayer(ua.tp)
+	ua.txl.SetProfilingLabels(ua.profLabels)
+	if ua.txJournal != nil {
+		ua.txl.SetJournal(ua.txJournal)
+	}
+	ua.tx = transactionLayer{ua.txl}
+	if ua.txLayerFunc != nil {
+		ua.tx = ua.txLayerFunc(ua.tx)
+	}
 	return ua, nil
 }
 
 func (ua *UserAgent) Close() error {
 	// stop transaction layer
 	ua.tx.Close()
+	ua.txl.Close()
 
 	// stop transport layer
 	return ua.tp.Close()
@@ -110,6 +364,11 @@ func (ua *UserAgent) GetIP() net.IP {
 	return ua.ip
 }
 
+// GetIPv6 returns advertised IPv6 address. It is nil if not set
+func (ua *UserAgent) GetIPv6() net.IP {
+	return ua.ip6
+}
+
 func (ua *UserAgent) Name() string {
 	return ua.name
 }
@@ -0,0 +1,130 @@
+package sipgo
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+// AutoAnswer is auto answer request detected on incoming INVITE
+type AutoAnswer struct {
+	// Priv is set for Priv-Answer-Mode request, which can override local policies like do not disturb
+	Priv bool
+	// Require is set when caller requires auto answer. Refused call is rejected with 403 Forbidden
+	Require bool
+	// Delay is requested delay before answering, ex. answer-after param
+	Delay time.Duration
+}
+
+// AutoAnswerPolicy decides is auto answer request accepted for incoming INVITE
+type AutoAnswerPolicy func(req *sip.Request, aa AutoAnswer) bool
+
+// WithUserAgentAutoAnswerPolicy sets policy for accepting auto answer requests, used for intercom and paging.
+// Without policy auto answer is always refused, but required auto answer is not rejected by server,
+// leaving decision to handler. Proxies should not set it as they must forward request
+func WithUserAgentAutoAnswerPolicy(policy AutoAnswerPolicy) UserAgentOption {
+	return func(s *UserAgent) error {
+		s.autoAnswer = policy
+		return nil
+	}
+}
+
+// AutoAnswer reports should incoming INVITE be answered automatically based on request and user agent policy.
+// UAS should include Answer-Mode: Auto in 2xx response when call is answered automatically
+func (ua *UserAgent) AutoAnswer(req *sip.Request) (AutoAnswer, bool) {
+	aa, ok := ReadAutoAnswer(req)
+	if !ok || ua.autoAnswer == nil {
+		return aa, false
+	}
+	return aa, ua.autoAnswer(req, aa)
+}
+
+// ReadAutoAnswer detects auto answer request on INVITE. Checked in order are
+// Priv-Answer-Mode, Answer-Mode https://datatracker.ietf.org/doc/html/rfc5373
+// and common conventions Alert-Info info=alert-autoanswer|intercom|auto answer and Call-Info answer-after
+func ReadAutoAnswer(req *sip.Request) (AutoAnswer, bool) {
+	for _, h := range []*sip.AnswerModeHeader{req.PrivAnswerMode(), req.AnswerMode()} {
+		if h == nil {
+			continue
+		}
+		if !h.IsAuto() {
+			// Manual answer is requested explicitly
+			return AutoAnswer{}, false
+		}
+		return AutoAnswer{Priv: h.Priv, Require: h.Require}, true
+	}
+
+	for _, h := range req.GetHeaders("Alert-Info") {
+		params := autoAnswerHeaderParams(h.Value())
+		info, _ := params.Get("info")
+		switch strings.ToLower(strings.Trim(info, `"`)) {
+		case "alert-autoanswer", "intercom", "auto answer", "auto-answer":
+			return AutoAnswer{Delay: autoAnswerDelay(params, "delay")}, true
+		}
+	}
+
+	for _, h := range req.GetHeaders("Call-Info") {
+		params := autoAnswerHeaderParams(h.Value())
+		if params.Has("answer-after") {
+			return AutoAnswer{Delay: autoAnswerDelay(params, "answer-after")}, true
+		}
+	}
+	return AutoAnswer{}, false
+}
+
+// IntercomHeaders returns headers requesting auto answer for outgoing INVITE.
+// Answer-Mode is added together with Alert-Info and Call-Info conventions for wider phone support.
+// Priv requests privileged answer mode
+func IntercomHeaders(priv bool) []sip.Header {
+	return []sip.Header{
+		&sip.AnswerModeHeader{Priv: priv, Mode: sip.AnswerModeAuto},
+		sip.NewHeader("Alert-Info", "<http://127.0.0.1/intercom>;info=alert-autoanswer"),
+		sip.NewHeader("Call-Info", "<sip:127.0.0.1>;answer-after=0"),
+	}
+}
+
+// autoAnswerHeaderParams returns params of <uri>;params header value
+func autoAnswerHeaderParams(value string) sip.HeaderParams {
+	if ind := strings.LastIndexByte(value, '>'); ind >= 0 {
+		value = value[ind+1:]
+	}
+	params := sip.NewParams()
+	sip.UnmarshalParams(strings.TrimLeft(strings.TrimSpace(value), ";"), ';', 0, params)
+	return params
+}
+
+func autoAnswerDelay(params sip.HeaderParams, name string) time.Duration {
+	v, _ := params.Get(name)
+	sec, err := strconv.Atoi(v)
+	if err != nil || sec < 0 {
+		return 0
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// autoAnswerRequired rejects new incoming call with 403 Forbidden
+// when caller requires auto answer and policy refuses it.
+// It is UAS decision, so nothing is enforced when no policy is configured
+// https://datatracker.ietf.org/doc/html/rfc5373#section-6
+func (srv *Server) autoAnswerRequired(req *sip.Request, tx sip.ServerTransaction) bool {
+	if srv.autoAnswer == nil || !req.IsInvite() || tx == nil {
+		return false
+	}
+	if to := req.To(); to != nil && to.Params.Has("tag") {
+		return false
+	}
+
+	aa, accepted := srv.AutoAnswer(req)
+	if !aa.Require || accepted {
+		return false
+	}
+
+	req.Trail().Add("autoanswer", "required auto answer refused")
+	res := sip.NewResponseFromRequest(req, 403, "Forbidden", nil)
+	if err := tx.Respond(res); err != nil {
+		srv.log.Error().Err(err).Msg("Failed to respond refused auto answer")
+	}
+	return true
+}
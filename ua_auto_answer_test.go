@@ -0,0 +1,116 @@
+package sipgo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadAutoAnswer(t *testing.T) {
+	newInvite := func(hdrs ...sip.Header) *sip.Request {
+		req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+		for _, h := range hdrs {
+			req.AppendHeader(h)
+		}
+		return req
+	}
+
+	_, ok := ReadAutoAnswer(newInvite())
+	assert.False(t, ok)
+
+	aa, ok := ReadAutoAnswer(newInvite(IntercomHeaders(true)...))
+	require.True(t, ok)
+	assert.True(t, aa.Priv)
+
+	aa, ok = ReadAutoAnswer(newInvite(sip.NewHeader("Answer-Mode", "Auto;require")))
+	require.True(t, ok)
+	assert.True(t, aa.Require)
+	assert.False(t, aa.Priv)
+
+	// Explicit manual wins over conventions
+	_, ok = ReadAutoAnswer(newInvite(sip.NewHeader("Answer-Mode", "Manual"), sip.NewHeader("Call-Info", "<sip:x>;answer-after=0")))
+	assert.False(t, ok)
+
+	aa, ok = ReadAutoAnswer(newInvite(sip.NewHeader("Alert-Info", "<http://www.notused.com>;info=alert-autoanswer;delay=2")))
+	require.True(t, ok)
+	assert.Equal(t, 2*time.Second, aa.Delay)
+
+	aa, ok = ReadAutoAnswer(newInvite(sip.NewHeader("Call-Info", "<sip:127.0.0.1>;answer-after=3")))
+	require.True(t, ok)
+	assert.Equal(t, 3*time.Second, aa.Delay)
+
+	_, ok = ReadAutoAnswer(newInvite(sip.NewHeader("Alert-Info", "<http://127.0.0.1/ring.wav>")))
+	assert.False(t, ok)
+}
+
+func TestServerAutoAnswerPolicy(t *testing.T) {
+	ua, _ := NewUA(WithUserAgentAutoAnswerPolicy(func(req *sip.Request, aa AutoAnswer) bool {
+		return aa.Priv
+	}))
+	defer ua.Close()
+
+	srv, err := NewServer(ua)
+	require.NoError(t, err)
+
+	var handled int
+	srv.OnInvite(func(req *sip.Request, tx sip.ServerTransaction) {
+		handled++
+		tx.Respond(sip.NewResponseFromRequest(req, 200, "OK", nil))
+	})
+
+	invite := func(hdrs ...sip.Header) *siptest.ServerTxRecorder {
+		req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+		for _, h := range hdrs {
+			req.AppendHeader(h)
+		}
+		tx := siptest.NewServerTxRecorder(req)
+		srv.handleRequest(req, tx)
+		require.Len(t, tx.Result(), 1)
+		return tx
+	}
+
+	// Required auto answer refused by policy
+	tx := invite(&sip.AnswerModeHeader{Mode: sip.AnswerModeAuto, Require: true})
+	assert.Equal(t, sip.StatusCode(403), tx.Result()[0].StatusCode)
+	assert.Equal(t, 0, handled)
+
+	// Not required is passed to handler
+	tx = invite(&sip.AnswerModeHeader{Mode: sip.AnswerModeAuto})
+	assert.Equal(t, sip.StatusCode(200), tx.Result()[0].StatusCode)
+	assert.Equal(t, 1, handled)
+
+	// Privileged auto answer bypasses do not disturb
+	srv.SetDoNotDisturb(true)
+	tx = invite()
+	assert.Equal(t, sip.StatusCode(486), tx.Result()[0].StatusCode)
+	tx = invite(IntercomHeaders(true)...)
+	assert.Equal(t, sip.StatusCode(200), tx.Result()[0].StatusCode)
+	assert.Equal(t, 2, handled)
+}
+
+func TestServerAutoAnswerNoPolicy(t *testing.T) {
+	ua, _ := NewUA()
+	defer ua.Close()
+
+	srv, err := NewServer(ua)
+	require.NoError(t, err)
+
+	var handled int
+	srv.OnInvite(func(req *sip.Request, tx sip.ServerTransaction) {
+		handled++
+		tx.Respond(sip.NewResponseFromRequest(req, 200, "OK", nil))
+	})
+
+	// Without policy, like proxy, required auto answer is passed to handler
+	req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	req.AppendHeader(&sip.AnswerModeHeader{Mode: sip.AnswerModeAuto, Require: true})
+	tx := siptest.NewServerTxRecorder(req)
+	srv.handleRequest(req, tx)
+	require.Len(t, tx.Result(), 1)
+	assert.Equal(t, sip.StatusCode(200), tx.Result()[0].StatusCode)
+	assert.Equal(t, 1, handled)
+}
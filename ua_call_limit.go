@@ -0,0 +1,87 @@
+package sipgo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+)
+
+var (
+	ErrCallLimitReached = errors.New("Call limit reached")
+)
+
+// CallLimit limits number of concurrent calls (dialog sessions) on user agent
+type CallLimit struct {
+	// Max is maximum number of concurrent calls
+	Max int
+	// QueueTimeout is how long new call waits for free slot.
+	// If zero call is rejected immediately. Incoming calls are rejected with 486 Busy Here
+	QueueTimeout time.Duration
+	// OnExhausted is called when there is no free slot for new call.
+	// For outgoing calls request is INVITE being sent
+	OnExhausted func(req *sip.Request)
+}
+
+// WithUserAgentCallLimit sets maximum concurrent calls for dialog sessions created by
+// DialogClient and DialogServer using this user agent
+func WithUserAgentCallLimit(limit CallLimit) UserAgentOption {
+	return func(s *UserAgent) error {
+		if limit.Max <= 0 {
+			return errors.New("call limit max must be greater than 0")
+		}
+		s.callSlots = &callSlots{
+			slots: make(chan struct{}, limit.Max),
+			limit: limit,
+		}
+		return nil
+	}
+}
+
+// ActiveCalls returns number of taken call slots. It returns 0 if call limit is not set
+func (ua *UserAgent) ActiveCalls() int {
+	if ua.callSlots == nil {
+		return 0
+	}
+	return len(ua.callSlots.slots)
+}
+
+type callSlots struct {
+	slots chan struct{}
+	limit CallLimit
+}
+
+// acquire takes call slot. It waits up to QueueTimeout for slot to be freed or until done is closed
+func (c *callSlots) acquire(done <-chan struct{}, req *sip.Request) error {
+	select {
+	case c.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if c.limit.OnExhausted != nil {
+		c.limit.OnExhausted(req)
+	}
+
+	if c.limit.QueueTimeout <= 0 {
+		return ErrCallLimitReached
+	}
+
+	t := time.NewTimer(c.limit.QueueTimeout)
+	defer t.Stop()
+	select {
+	case c.slots <- struct{}{}:
+		return nil
+	case <-t.C:
+		return ErrCallLimitReached
+	case <-done:
+		return ErrCallLimitReached
+	}
+}
+
+func (c *callSlots) release() {
+	select {
+	case <-c.slots:
+	default:
+	}
+}
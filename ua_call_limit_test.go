@@ -0,0 +1,75 @@
+package sipgo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserAgentCallLimit(t *testing.T) {
+	exhausted := 0
+	ua, err := NewUA(WithUserAgentCallLimit(CallLimit{
+		Max: 1,
+		OnExhausted: func(req *sip.Request) {
+			exhausted++
+		},
+	}))
+	require.NoError(t, err)
+	defer ua.Close()
+
+	client, err := NewClient(ua)
+	require.NoError(t, err)
+
+	dialogSrv := NewDialogServer(client, sip.ContactHeader{Address: sip.Uri{User: "bob", Host: "127.0.0.1", Port: 5060}})
+
+	newInvite := func() (*sip.Request, *siptest.ServerTxRecorder) {
+		req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+		req.AppendHeader(&sip.ContactHeader{Address: sip.Uri{User: "alice", Host: "127.0.0.2", Port: 5060}})
+		return req, siptest.NewServerTxRecorder(req)
+	}
+
+	req, tx := newInvite()
+	dialog, err := dialogSrv.ReadInvite(req, tx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, ua.ActiveCalls())
+
+	req, tx = newInvite()
+	_, err = dialogSrv.ReadInvite(req, tx)
+	require.ErrorIs(t, err, ErrCallLimitReached)
+	require.Len(t, tx.Result(), 1)
+	assert.Equal(t, sip.StatusBusyHere, tx.Result()[0].StatusCode)
+	assert.Equal(t, 1, exhausted)
+
+	// Closing frees slot, and double close must not free other slot
+	dialog.Close()
+	dialog.Close()
+	assert.Equal(t, 0, ua.ActiveCalls())
+
+	req, tx = newInvite()
+	dialog, err = dialogSrv.ReadInvite(req, tx)
+	require.NoError(t, err)
+	defer dialog.Close()
+	assert.Equal(t, 1, ua.ActiveCalls())
+}
+
+func TestCallSlotsQueue(t *testing.T) {
+	slots := &callSlots{
+		slots: make(chan struct{}, 1),
+		limit: CallLimit{Max: 1, QueueTimeout: time.Second},
+	}
+	require.NoError(t, slots.acquire(nil, nil))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		slots.release()
+	}()
+	require.NoError(t, slots.acquire(nil, nil))
+
+	done := make(chan struct{})
+	close(done)
+	require.ErrorIs(t, slots.acquire(done, nil), ErrCallLimitReached)
+}
@@ -0,0 +1,26 @@
+package sipgo
+
+import (
+	"expvar"
+)
+
+// Expvar returns expvar map with user agent gauges:
+// client_transactions, server_transactions, connections, dialogs, calls and handling (messages being processed).
+// Publish it to expose on /debug/vars
+//
+//	expvar.Publish("sipgo", ua.Expvar())
+func (ua *UserAgent) Expvar() *expvar.Map {
+	m := new(expvar.Map)
+	m.Set("client_transactions", expvar.Func(func() any { return ua.txl.ClientTransactionsLen() }))
+	m.Set("server_transactions", expvar.Func(func() any { return ua.txl.ServerTransactionsLen() }))
+	m.Set("handling", expvar.Func(func() any { return ua.txl.HandlingLen() }))
+	m.Set("connections", expvar.Func(func() any { return ua.tp.ConnectionsLen() }))
+	m.Set("dialogs", expvar.Func(func() any { return ua.DialogsLen() }))
+	m.Set("calls", expvar.Func(func() any { return ua.ActiveCalls() }))
+	return m
+}
+
+// DialogsLen returns number of active dialog sessions created with DialogClient and DialogServer
+func (ua *UserAgent) DialogsLen() int {
+	return int(ua.dialogs.Load())
+}
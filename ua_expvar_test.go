@@ -0,0 +1,47 @@
+package sipgo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/emiago/sipgo/sip"
+	"github.com/emiago/sipgo/siptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserAgentExpvar(t *testing.T) {
+	ua, err := NewUA()
+	require.NoError(t, err)
+	defer ua.Close()
+
+	client, err := NewClient(ua)
+	require.NoError(t, err)
+
+	readVars := func() map[string]int {
+		vars := map[string]int{}
+		require.NoError(t, json.Unmarshal([]byte(ua.Expvar().String()), &vars))
+		return vars
+	}
+
+	vars := readVars()
+	for _, k := range []string{"client_transactions", "server_transactions", "handling", "connections", "dialogs", "calls"} {
+		v, exists := vars[k]
+		assert.True(t, exists, k)
+		assert.Equal(t, 0, v, k)
+	}
+
+	dialogSrv := NewDialogServer(client, sip.ContactHeader{Address: sip.Uri{User: "bob", Host: "127.0.0.1", Port: 5060}})
+	req, _, _ := createTestInvite(t, "sip:bob@127.0.0.1:5060", "UDP", "127.0.0.2:5060")
+	req.AppendHeader(&sip.ContactHeader{Address: sip.Uri{User: "alice", Host: "127.0.0.2", Port: 5060}})
+	tx := siptest.NewServerTxRecorder(req)
+
+	dialog, err := dialogSrv.ReadInvite(req, tx)
+	require.NoError(t, err)
+	require.NoError(t, dialog.Respond(sip.StatusOK, "OK", nil))
+	assert.Equal(t, 1, readVars()["dialogs"])
+
+	dialog.Close()
+	dialog.Close()
+	assert.Equal(t, 0, readVars()["dialogs"])
+}